@@ -25,20 +25,28 @@ import (
 
 	"github.com/evalphobia/logrus_sentry"
 	"github.com/facebookgo/inject"
-	"github.com/robfig/cron"
 	"github.com/sirupsen/logrus"
 
+	"github.com/skygeario/skygear-server/pkg/server/apikey"
 	"github.com/skygeario/skygear-server/pkg/server/asset"
 	"github.com/skygeario/skygear-server/pkg/server/audit"
 	"github.com/skygeario/skygear-server/pkg/server/authtoken"
 	"github.com/skygeario/skygear-server/pkg/server/handler"
+	"github.com/skygeario/skygear-server/pkg/server/job"
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
 	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/oauth"
+	"github.com/skygeario/skygear-server/pkg/server/permission"
 	"github.com/skygeario/skygear-server/pkg/server/plugin"
 	pluginEvent "github.com/skygeario/skygear-server/pkg/server/plugin/event"
 	_ "github.com/skygeario/skygear-server/pkg/server/plugin/exec"
+	_ "github.com/skygeario/skygear-server/pkg/server/plugin/grpc"
 	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
 	_ "github.com/skygeario/skygear-server/pkg/server/plugin/http"
+	_ "github.com/skygeario/skygear-server/pkg/server/plugin/inproc"
+	_ "github.com/skygeario/skygear-server/pkg/server/plugin/js"
 	"github.com/skygeario/skygear-server/pkg/server/plugin/provider"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/provider/oidc"
 	_ "github.com/skygeario/skygear-server/pkg/server/plugin/zmq"
 	pp "github.com/skygeario/skygear-server/pkg/server/preprocessor"
 	"github.com/skygeario/skygear-server/pkg/server/pubsub"
@@ -49,10 +57,48 @@ import (
 	_ "github.com/skygeario/skygear-server/pkg/server/skydb/pq"
 	"github.com/skygeario/skygear-server/pkg/server/skyversion"
 	"github.com/skygeario/skygear-server/pkg/server/subscription"
+	"github.com/skygeario/skygear-server/pkg/server/timer"
+	"github.com/skygeario/skygear-server/pkg/server/userblock"
+	"github.com/skygeario/skygear-server/pkg/server/userdata"
+	"github.com/skygeario/skygear-server/pkg/server/webhook"
 )
 
 var log = logging.LoggerEntry("main")
 
+// keysReloadInterval is the interval to reload signing keys from disk, so
+// that keys can be rotated without restart.
+const keysReloadInterval = 5 * time.Minute
+
+// apiKeyCacheTTL is how long a managed API key is cached before it is
+// looked up from the database again.
+const apiKeyCacheTTL = 30 * time.Second
+
+// permissionCacheTTL is how long role permissions are cached before they
+// are loaded from the database again.
+const permissionCacheTTL = 30 * time.Second
+
+// blockCacheTTL is how long the blocked users of a user are cached for
+// pubsub before they are loaded from the database again.
+const blockCacheTTL = 30 * time.Second
+
+// webhookCacheTTL is how long webhooks are cached before they are loaded
+// from the database again.
+const webhookCacheTTL = 30 * time.Second
+
+// webhookPollInterval is the interval to poll for due webhook deliveries.
+const webhookPollInterval = 5 * time.Second
+
+// jobPollInterval is the interval to poll for due background jobs.
+const jobPollInterval = 5 * time.Second
+
+// timerPollInterval is the interval to poll for due plugin timers. It is
+// short as timer specs have a resolution of seconds.
+const timerPollInterval = time.Second
+
+// pluginWatchInterval is the interval to check the code of watched plugins
+// for modification.
+const pluginWatchInterval = 2 * time.Second
+
 func main() {
 	if len(os.Args) > 1 {
 		if os.Args[1] == "version" {
@@ -92,6 +138,8 @@ func main() {
 		Prefix:         config.TokenStore.Prefix,
 		Expiry:         config.TokenStore.Expiry,
 		Secret:         config.TokenStore.Secret,
+		KeysPath:       config.TokenStore.KeysPath,
+		VerifySecret:   config.TokenStore.VerifySecret,
 	})
 
 	dbConfig := baseDBConfig(config)
@@ -125,18 +173,22 @@ func main() {
 
 	preprocessorRegistry := router.PreprocessorRegistry{}
 
-	var cronjob *cron.Cron
-	if !config.App.Slave {
-		cronjob = cron.New()
-	}
 	pluginContext := plugin.Context{
 		Router:           r,
 		Mux:              serveMux,
 		HookRegistry:     hook.NewRegistry(),
 		ProviderRegistry: provider.NewRegistry(),
-		Scheduler:        cronjob,
 		Config:           config,
 	}
+	assetStore := initAssetStore(config)
+	timerScheduler := timer.NewScheduler(connOpener)
+	timerScheduler.Ready = pluginContext.IsReady
+	if !config.App.Slave {
+		pluginContext.Scheduler = timerScheduler
+		initUserDataExportExpiry(timerScheduler, connOpener, assetStore)
+		timerScheduler.Start(timerPollInterval)
+	}
+	initOIDCProviders(config, pluginContext.ProviderRegistry)
 
 	var internalHub *pubsub.Hub
 	if !config.App.Slave {
@@ -145,20 +197,38 @@ func main() {
 		initDevice(config, connOpener)
 	}
 
+	apiKeyChecker := apikey.NewChecker(connOpener, apiKeyCacheTTL)
+	permissionChecker := permission.NewChecker(connOpener, permissionCacheTTL)
+	blockChecker := userblock.NewChecker(connOpener, blockCacheTTL)
+
+	webhookDispatcher := webhook.NewDispatcher(connOpener, webhookCacheTTL)
+	webhookDispatcher.RegisterHooks(pluginContext.HookRegistry)
+	if !config.App.Slave {
+		webhookDispatcher.Start(webhookPollInterval)
+	}
+
+	jobWorker := job.NewWorker(connOpener, pluginContext.RunLambda)
+	jobWorker.Ready = pluginContext.IsReady
+	if !config.App.Slave {
+		jobWorker.Start(jobPollInterval)
+	}
+
 	// Preprocessor
 	preprocessorRegistry["notification"] = &pp.NotificationPreprocessor{
 		NotificationSender: pushSender,
 	}
 	preprocessorRegistry["accesskey"] = &pp.AccessKeyValidationPreprocessor{
-		ClientKey: config.App.APIKey,
-		MasterKey: config.App.MasterKey,
-		AppName:   config.App.Name,
+		ClientKey:     config.App.APIKey,
+		MasterKey:     config.App.MasterKey,
+		AppName:       config.App.Name,
+		APIKeyChecker: apiKeyChecker,
 	}
 	preprocessorRegistry["authenticator"] = &pp.UserAuthenticator{
 		ClientKey:          config.App.APIKey,
 		MasterKey:          config.App.MasterKey,
 		AppName:            config.App.Name,
 		TokenStore:         tokenStore,
+		APIKeyChecker:      apiKeyChecker,
 		BypassUnauthorized: false,
 	}
 	preprocessorRegistry["inject_auth_id"] = &pp.UserAuthenticator{
@@ -166,6 +236,7 @@ func main() {
 		MasterKey:          config.App.MasterKey,
 		AppName:            config.App.Name,
 		TokenStore:         tokenStore,
+		APIKeyChecker:      apiKeyChecker,
 		BypassUnauthorized: true,
 	}
 	preprocessorRegistry["dbconn"] = &pp.ConnPreprocessor{
@@ -180,6 +251,7 @@ func main() {
 		PluginContext: &pluginContext,
 		ClientKey:     config.App.APIKey,
 		MasterKey:     config.App.MasterKey,
+		APIKeyChecker: apiKeyChecker,
 	}
 	preprocessorRegistry["inject_auth"] = &pp.InjectAuth{
 		PwExpiryDays: config.UserAudit.PwExpiryDays,
@@ -204,7 +276,9 @@ func main() {
 	} else {
 		preprocessorRegistry["check_user"] = &pp.Null{}
 	}
-	preprocessorRegistry["require_admin"] = &pp.RequireAdminOrMasterKey{}
+	preprocessorRegistry["require_admin"] = &pp.RequireAdminOrMasterKey{
+		PermissionChecker: permissionChecker,
+	}
 	preprocessorRegistry["require_master_key"] = &pp.RequireMasterKey{}
 	preprocessorRegistry["inject_db"] = &pp.InjectDatabase{}
 	preprocessorRegistry["inject_public_db"] = &pp.InjectPublicDatabase{}
@@ -212,6 +286,8 @@ func main() {
 		DevMode: config.App.DevMode,
 	}
 
+	oauthServer := initOAuthServer(config)
+
 	g := &inject.Graph{}
 	injectErr := g.Provide(
 		&inject.Object{
@@ -230,7 +306,7 @@ func main() {
 			Name:     "TokenStore",
 		},
 		&inject.Object{
-			Value:    initAssetStore(config),
+			Value:    assetStore,
 			Complete: true,
 			Name:     "AssetStore",
 		},
@@ -239,6 +315,14 @@ func main() {
 			Complete: true,
 			Name:     "PushSender",
 		},
+		&inject.Object{
+			Value: &handler.RelationRequestNotifier{
+				Hub:        internalHub,
+				PushSender: pushSender,
+			},
+			Complete: true,
+			Name:     "RelationRequestNotifier",
+		},
 		&inject.Object{
 			Value:    pluginEvent.NewSender(&pluginContext),
 			Complete: true,
@@ -264,6 +348,51 @@ func main() {
 			Complete: true,
 			Name:     "PwHousekeeper",
 		},
+		&inject.Object{
+			Value:    initLoginThrottler(config),
+			Complete: true,
+			Name:     "LoginThrottler",
+		},
+		&inject.Object{
+			Value:    oauthServer,
+			Complete: true,
+			Name:     "OAuthServer",
+		},
+		&inject.Object{
+			Value:    apiKeyChecker,
+			Complete: true,
+			Name:     "APIKeyChecker",
+		},
+		&inject.Object{
+			Value:    webhookDispatcher,
+			Complete: true,
+			Name:     "WebhookDispatcher",
+		},
+		&inject.Object{
+			Value:    timerScheduler,
+			Complete: true,
+			Name:     "TimerScheduler",
+		},
+		&inject.Object{
+			Value:    &pluginContext,
+			Complete: true,
+			Name:     "PluginContext",
+		},
+		&inject.Object{
+			Value:    permissionChecker,
+			Complete: true,
+			Name:     "PermissionChecker",
+		},
+		&inject.Object{
+			Value:    blockChecker,
+			Complete: true,
+			Name:     "BlockChecker",
+		},
+		&inject.Object{
+			Value:    initUserErasurePlan(config),
+			Complete: true,
+			Name:     "UserErasurePlan",
+		},
 	)
 	if injectErr != nil {
 		panic(fmt.Sprintf("Unable to set up handler: %v", injectErr))
@@ -275,6 +404,11 @@ func main() {
 	}
 	pluginContext.HandlerInjector = injector
 
+	// role permissions apply to every action, including plugin lambdas
+	r.AppendPreprocessors(&pp.RequirePermission{
+		PermissionChecker: permissionChecker,
+	})
+
 	r.Map("", "", &handler.HomeHandler{})
 	r.Map("_status:healthz", "", injector.Inject(&handler.HealthzHandler{}))
 
@@ -284,6 +418,9 @@ func main() {
 	r.Map("auth:password", "auth", injector.Inject(&handler.ChangePasswordHandler{}))
 	r.Map("auth:reset_password", "auth", injector.Inject(&handler.ResetPasswordHandler{}))
 	r.Map("auth:disable:set", "auth", injector.Inject(&handler.SetDisableUserHandler{}))
+	r.Map("auth:unlock", "auth", injector.Inject(&handler.UnlockUserHandler{}))
+	r.Map("auth:impersonate", "auth", injector.Inject(&handler.ImpersonateUserHandler{}))
+	r.Map("auth:delete", "auth", injector.Inject(&handler.DeleteUserHandler{}))
 	r.Map("sso:oauth:login", "sso", injector.Inject(&handler.LoginProviderHandler{}))
 	r.Map("sso:oauth:signup", "sso", injector.Inject(&handler.SignupProviderHandler{}))
 	r.Map("sso:oauth:link", "sso", injector.Inject(&handler.LinkProviderHandler{}))
@@ -292,12 +429,45 @@ func main() {
 		CustomTokenSecret: config.Auth.CustomTokenSecret,
 	}))
 
+	if config.OAuthServer.Enabled {
+		r.Map("oauth:client:create", "oauth", injector.Inject(&handler.OAuthClientCreateHandler{}))
+		r.Map("oauth:client:get", "oauth", injector.Inject(&handler.OAuthClientGetHandler{}))
+		r.Map("oauth:client:delete", "oauth", injector.Inject(&handler.OAuthClientDeleteHandler{}))
+	}
+
+	r.Map("apikey:create", "apikey", injector.Inject(&handler.APIKeyCreateHandler{}))
+	r.Map("apikey:rotate", "apikey", injector.Inject(&handler.APIKeyRotateHandler{}))
+	r.Map("apikey:revoke", "apikey", injector.Inject(&handler.APIKeyRevokeHandler{}))
+	r.Map("apikey:list", "apikey", injector.Inject(&handler.APIKeyListHandler{}))
+
+	r.Map("webhook:create", "webhook", injector.Inject(&handler.WebhookCreateHandler{}))
+	r.Map("webhook:update", "webhook", injector.Inject(&handler.WebhookUpdateHandler{}))
+	r.Map("webhook:delete", "webhook", injector.Inject(&handler.WebhookDeleteHandler{}))
+	r.Map("webhook:list", "webhook", injector.Inject(&handler.WebhookListHandler{}))
+	r.Map("webhook:deliveries", "webhook", injector.Inject(&handler.WebhookDeliveriesHandler{}))
+	r.Map("webhook:replay", "webhook", injector.Inject(&handler.WebhookReplayHandler{}))
+	r.Map("job:enqueue", "job", injector.Inject(&handler.JobEnqueueHandler{}))
+	r.Map("job:status", "job", injector.Inject(&handler.JobStatusHandler{}))
+	r.Map("job:list", "job", injector.Inject(&handler.JobListHandler{}))
+	r.Map("job:retry", "job", injector.Inject(&handler.JobRetryHandler{}))
+	r.Map("timer:list", "timer", injector.Inject(&handler.TimerListHandler{}))
+	r.Map("timer:run", "timer", injector.Inject(&handler.TimerRunHandler{}))
+	r.Map("plugin:reload", "plugin", injector.Inject(&handler.PluginReloadHandler{}))
+
+	r.Map("group:create", "group", injector.Inject(&handler.GroupCreateHandler{}))
+	r.Map("group:get", "group", injector.Inject(&handler.GroupGetHandler{}))
+	r.Map("group:delete", "group", injector.Inject(&handler.GroupDeleteHandler{}))
+	r.Map("group:list", "group", injector.Inject(&handler.GroupListHandler{}))
+	r.Map("group:member:add", "group", injector.Inject(&handler.GroupMemberAddHandler{}))
+	r.Map("group:member:remove", "group", injector.Inject(&handler.GroupMemberRemoveHandler{}))
+
 	r.Map("asset:put", "asset", injector.Inject(&handler.AssetUploadHandler{}))
 
 	r.Map("record:fetch", "record", injector.Inject(&handler.RecordFetchHandler{}))
 	r.Map("record:query", "record", injector.Inject(&handler.RecordQueryHandler{}))
 	r.Map("record:save", "record", injector.Inject(&handler.RecordSaveHandler{}))
 	r.Map("record:delete", "record", injector.Inject(&handler.RecordDeleteHandler{}))
+	r.Map("record:acl:update", "record", injector.Inject(&handler.RecordACLUpdateHandler{}))
 
 	r.Map("device:register", "device", injector.Inject(&handler.DeviceRegisterHandler{}))
 	r.Map("device:unregister", "device", injector.Inject(&handler.DeviceUnregisterHandler{}))
@@ -312,14 +482,32 @@ func main() {
 	r.Map("relation:query", "relation", injector.Inject(&handler.RelationQueryHandler{}))
 	r.Map("relation:add", "relation", injector.Inject(&handler.RelationAddHandler{}))
 	r.Map("relation:remove", "relation", injector.Inject(&handler.RelationRemoveHandler{}))
+	r.Map("relation:request", "relation", injector.Inject(&handler.RelationRequestHandler{}))
+	r.Map("relation:accept", "relation", injector.Inject(&handler.RelationAcceptHandler{}))
+	r.Map("relation:decline", "relation", injector.Inject(&handler.RelationDeclineHandler{}))
+	r.Map("relation:cancel", "relation", injector.Inject(&handler.RelationCancelHandler{}))
+	r.Map("relation:request:query", "relation", injector.Inject(&handler.RelationRequestQueryHandler{}))
+	r.Map("relation:type:create", "relation", injector.Inject(&handler.RelationTypeCreateHandler{}))
+	r.Map("relation:type:list", "relation", injector.Inject(&handler.RelationTypeListHandler{}))
+	r.Map("relation:type:delete", "relation", injector.Inject(&handler.RelationTypeDeleteHandler{}))
+
+	r.Map("block:add", "block", injector.Inject(&handler.BlockAddHandler{}))
+	r.Map("block:remove", "block", injector.Inject(&handler.BlockRemoveHandler{}))
+	r.Map("block:query", "block", injector.Inject(&handler.BlockQueryHandler{}))
 
 	r.Map("me", "", injector.Inject(&handler.MeHandler{}))
+	r.Map("me:delete", "", injector.Inject(&handler.MeDeleteHandler{}))
+	r.Map("me:export", "", injector.Inject(&handler.MeExportHandler{}))
 
 	r.Map("role:default", "role", injector.Inject(&handler.RoleDefaultHandler{}))
 	r.Map("role:admin", "role", injector.Inject(&handler.RoleAdminHandler{}))
 	r.Map("role:assign", "role", injector.Inject(&handler.RoleAssignHandler{}))
 	r.Map("role:revoke", "role", injector.Inject(&handler.RoleRevokeHandler{}))
 	r.Map("role:get", "role", injector.Inject(&handler.RoleGetHandler{}))
+	r.Map("role:hierarchy:set", "role", injector.Inject(&handler.RoleHierarchySetHandler{}))
+	r.Map("role:hierarchy:get", "role", injector.Inject(&handler.RoleHierarchyGetHandler{}))
+	r.Map("role:permission:set", "role", injector.Inject(&handler.RolePermissionSetHandler{}))
+	r.Map("role:permission:get", "role", injector.Inject(&handler.RolePermissionGetHandler{}))
 
 	r.Map("push:user", "push", injector.Inject(&handler.PushToUserHandler{}))
 	r.Map("push:device", "push", injector.Inject(&handler.PushToDeviceHandler{}))
@@ -332,12 +520,16 @@ func main() {
 	r.Map("schema:default_access", "schema", injector.Inject(&handler.SchemaDefaultAccessHandler{}))
 	r.Map("schema:field_access:get", "schema", injector.Inject(&handler.SchemaFieldAccessGetHandler{}))
 	r.Map("schema:field_access:update", "schema", injector.Inject(&handler.SchemaFieldAccessUpdateHandler{}))
+	r.Map("schema:predicate_access:get", "schema", injector.Inject(&handler.SchemaPredicateAccessGetHandler{}))
+	r.Map("schema:predicate_access:update", "schema", injector.Inject(&handler.SchemaPredicateAccessUpdateHandler{}))
 
 	serveMux.Handle("/", r)
 
 	// Following section is for Gateway
 	if !config.App.Slave {
-		pubSub := pubsub.NewWsPubsub(nil)
+		pubSubHub := pubsub.NewHub()
+		pubSubHub.BlockChecker = blockChecker
+		pubSub := pubsub.NewWsPubsub(pubSubHub)
 		pubSubGateway := router.NewGateway("", "/pubsub", "pubsub", serveMux)
 		pubSubGateway.GET(injector.InjectProcessors(&handler.PubSubHandler{
 			WebSocket: pubSub,
@@ -358,6 +550,36 @@ func main() {
 	fileGateway.PUT(uploadFileHandler)
 	fileGateway.POST(uploadFileHandler)
 
+	if jwtStore, ok := tokenStore.(*authtoken.JWTStore); ok && jwtStore.KeySet() != nil {
+		jwtStore.KeySet().Watch(keysReloadInterval)
+
+		jwksGateway := router.NewGateway("", "/.well-known/jwks.json", "auth", serveMux)
+		jwksGateway.GET(injector.Inject(&handler.JWKSHandler{
+			KeySet: jwtStore.KeySet(),
+		}))
+	}
+
+	if config.OAuthServer.Enabled {
+		authorizeGateway := router.NewGateway("", "/oauth/authorize", "oauth", serveMux)
+		authorizeGateway.GET(injector.Inject(&handler.OAuthAuthorizeHandler{}))
+
+		tokenGateway := router.NewGateway("", "/oauth/token", "oauth", serveMux)
+		tokenGateway.POST(injector.Inject(&handler.OAuthTokenHandler{}))
+
+		userInfoHandler := injector.Inject(&handler.OAuthUserInfoHandler{})
+		userInfoGateway := router.NewGateway("", "/oauth/userinfo", "oauth", serveMux)
+		userInfoGateway.GET(userInfoHandler)
+		userInfoGateway.POST(userInfoHandler)
+
+		oauthJWKSGateway := router.NewGateway("", "/oauth/jwks", "oauth", serveMux)
+		oauthJWKSGateway.GET(injector.Inject(&handler.JWKSHandler{
+			KeySet: oauthServer.KeySet,
+		}))
+
+		discoveryGateway := router.NewGateway("", "/.well-known/openid-configuration", "oauth", serveMux)
+		discoveryGateway.GET(injector.Inject(&handler.OAuthDiscoveryHandler{}))
+	}
+
 	corsHost := config.App.CORSHost
 
 	var finalMux http.Handler
@@ -470,6 +692,29 @@ func initUserAuthRecordKeys(connOpener func() (skydb.Conn, error), authRecordKey
 	}
 }
 
+// initUserDataExportExpiry registers an hourly timer deleting expired user
+// data export archives, if the asset store supports deleting files.
+func initUserDataExportExpiry(scheduler *timer.Scheduler, connOpener func() (skydb.Conn, error), store asset.Store) {
+	deleter, ok := store.(asset.FileDeleter)
+	if !ok {
+		return
+	}
+
+	err := scheduler.AddTimer("_expire_user_data_exports", "@hourly", false, func() error {
+		conn, err := connOpener()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		_, err = userdata.ExpireExports(conn, deleter, time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
 func initAssetStore(config skyconfig.Configuration) asset.Store {
 	var store asset.Store
 	switch config.AssetStore.ImplName {
@@ -512,6 +757,93 @@ func initAssetStore(config skyconfig.Configuration) asset.Store {
 	return store
 }
 
+func initUserErasurePlan(config skyconfig.Configuration) *userdata.Plan {
+	plan, err := userdata.NewPlan(
+		config.UserErasure.DefaultAction,
+		config.UserErasure.RecordActions,
+		config.UserErasure.AnonymizeFields,
+	)
+	if err != nil {
+		panic(err)
+	}
+	return plan
+}
+
+func initLoginThrottler(config skyconfig.Configuration) *audit.LoginThrottler {
+	throttler := &audit.LoginThrottler{
+		AccountMaxAttempts: config.LoginThrottle.AccountMaxAttempts,
+		IPMaxAttempts:      config.LoginThrottle.IPMaxAttempts,
+		Window:             time.Duration(config.LoginThrottle.Window) * time.Second,
+		LockoutDuration:    time.Duration(config.LoginThrottle.LockoutDuration) * time.Second,
+		MaxLockoutDuration: time.Duration(config.LoginThrottle.MaxLockoutDuration) * time.Second,
+	}
+	trustedProxies, err := audit.ParseTrustedProxies(config.LoginThrottle.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+	throttler.TrustedProxies = trustedProxies
+	if !config.LoginThrottle.Enabled {
+		return throttler
+	}
+
+	switch config.LoginThrottle.ImplName {
+	default:
+		panic("unrecognized login throttle store implementation: " + config.LoginThrottle.ImplName)
+	case "memory":
+		throttler.Store = audit.NewMemoryLoginAttemptStore()
+	case "redis":
+		throttler.Store = audit.NewRedisLoginAttemptStore(
+			config.LoginThrottle.Path,
+			config.LoginThrottle.Prefix,
+		)
+	}
+	return throttler
+}
+
+func initOIDCProviders(config skyconfig.Configuration, registry *provider.Registry) {
+	for name, providerConfig := range config.OIDC {
+		registry.RegisterAuthProvider(name, oidc.NewProvider(name, oidc.Config{
+			Issuer:        providerConfig.Issuer,
+			ClientID:      providerConfig.ClientID,
+			ClientSecret:  providerConfig.ClientSecret,
+			TokenEndpoint: providerConfig.TokenEndpoint,
+			JWKSURI:       providerConfig.JWKSURI,
+		}))
+	}
+}
+
+func initOAuthServer(config skyconfig.Configuration) *oauth.Server {
+	server := &oauth.Server{
+		Issuer:            config.OAuthServer.Issuer,
+		AccessTokenExpiry: time.Duration(config.OAuthServer.AccessTokenExpiry) * time.Second,
+		IDTokenExpiry:     time.Duration(config.OAuthServer.IDTokenExpiry) * time.Second,
+		CodeExpiry:        time.Duration(config.OAuthServer.CodeExpiry) * time.Second,
+	}
+	if !config.OAuthServer.Enabled {
+		server.KeySet = jwk.NewKeySet()
+		return server
+	}
+
+	logger := logging.LoggerEntryWithTag("main", "oauth")
+	if config.OAuthServer.KeysPath == "" {
+		logger.Warnln("OAUTH_SERVER_KEYS_PATH is not set, tokens are signed with a generated key which does not survive restart")
+		keySet, err := jwk.GenerateKeySet()
+		if err != nil {
+			logger.Fatalf("Failed to generate OAuth server key: %v", err)
+		}
+		server.KeySet = keySet
+		return server
+	}
+
+	keySet, err := jwk.LoadKeySet(config.OAuthServer.KeysPath)
+	if err != nil {
+		logger.Fatalf("Failed to load OAuth server keys: %v", err)
+	}
+	keySet.Watch(keysReloadInterval)
+	server.KeySet = keySet
+	return server
+}
+
 func initDevice(config skyconfig.Configuration, connOpener func() (skydb.Conn, error)) {
 	logger := logging.LoggerEntryWithTag("main", "device")
 	// TODO: Create a device service to check APNs to remove obsolete devices.
@@ -654,15 +986,13 @@ func initPlugin(config skyconfig.Configuration, ctx *plugin.Context) {
 	logger := logging.LoggerEntryWithTag("main", "logger")
 	logger.Infof("Supported plugin transports: %s", strings.Join(plugin.SupportedTransports(), ", "))
 
-	if ctx.Scheduler != nil {
-		ctx.Scheduler.Start()
-	}
-
 	for _, pluginConfig := range config.Plugin {
-		ctx.AddPluginConfiguration(pluginConfig.Transport, pluginConfig.Path, pluginConfig.Args)
+		plug := ctx.AddPluginConfiguration(pluginConfig.Transport, pluginConfig.Path, pluginConfig.Args)
+		plug.WatchPath = pluginConfig.Watch
 	}
 
 	ctx.InitPlugins()
+	ctx.WatchPlugins(pluginWatchInterval)
 }
 
 func initLogger(config skyconfig.Configuration) {
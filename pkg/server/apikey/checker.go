@@ -0,0 +1,187 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apikey checks the API keys issued to client applications and
+// stored in the database.
+package apikey
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var (
+	// ErrInvalidKey is returned when the key does not exist, or is
+	// revoked or expired.
+	ErrInvalidKey = errors.New("apikey: invalid api key")
+
+	// ErrOriginNotAllowed is returned when the request is made from an
+	// origin not allowed by the key.
+	ErrOriginNotAllowed = errors.New("apikey: origin not allowed")
+
+	// ErrScopeNotAllowed is returned when the key is not allowed to
+	// access the action or route.
+	ErrScopeNotAllowed = errors.New("apikey: scope not allowed")
+)
+
+// RateLimitError is returned when the rate limit of the key is exceeded.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return "apikey: rate limit exceeded"
+}
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+// Request is the information of a request needed to check its API key.
+type Request struct {
+	Key    string
+	Origin string
+	Action string
+	Tag    string
+}
+
+type cacheEntry struct {
+	key      skydb.APIKey
+	cachedAt time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// Checker checks API keys against the keys stored in the database.
+//
+// Keys are cached for CacheTTL to avoid a database query for every
+// request, so changes to a key made by another server instance take up to
+// CacheTTL to take effect. Rate limits are counted per server instance.
+type Checker struct {
+	ConnOpener func() (skydb.Conn, error)
+	CacheTTL   time.Duration
+
+	mutex   sync.Mutex
+	cache   map[string]cacheEntry
+	windows map[string]*rateWindow
+}
+
+// NewChecker creates a Checker.
+func NewChecker(connOpener func() (skydb.Conn, error), cacheTTL time.Duration) *Checker {
+	return &Checker{
+		ConnOpener: connOpener,
+		CacheTTL:   cacheTTL,
+		cache:      map[string]cacheEntry{},
+		windows:    map[string]*rateWindow{},
+	}
+}
+
+// Check returns the API key of the request if it is allowed to make the
+// request.
+func (c *Checker) Check(req Request) (*skydb.APIKey, error) {
+	hashedKey := skydb.HashAPIKey(req.Key)
+	key, err := c.lookup(hashedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timeNow()
+	if !key.IsValidAt(hashedKey, now) {
+		return nil, ErrInvalidKey
+	}
+	if !key.IsOriginAllowed(req.Origin) {
+		return nil, ErrOriginNotAllowed
+	}
+	if !key.HasScope(req.Action, req.Tag) {
+		return nil, ErrScopeNotAllowed
+	}
+	if key.RateLimit > 0 {
+		if retryAfter := c.take(key.ID, key.RateLimit, now); retryAfter > 0 {
+			return nil, &RateLimitError{RetryAfter: retryAfter}
+		}
+	}
+	return &key, nil
+}
+
+// Invalidate removes all cached keys, so that changes to keys take effect
+// immediately on this server instance.
+func (c *Checker) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cache = map[string]cacheEntry{}
+}
+
+func (c *Checker) lookup(hashedKey string) (skydb.APIKey, error) {
+	now := timeNow()
+
+	c.mutex.Lock()
+	entry, ok := c.cache[hashedKey]
+	c.mutex.Unlock()
+	if ok && now.Sub(entry.cachedAt) < c.CacheTTL {
+		return entry.key, nil
+	}
+
+	conn, err := c.ConnOpener()
+	if err != nil {
+		return skydb.APIKey{}, err
+	}
+	defer conn.Close()
+
+	key := skydb.APIKey{}
+	if err := conn.GetAPIKeyByHash(hashedKey, &key); err != nil {
+		if err == skydb.ErrAPIKeyNotFound {
+			return key, ErrInvalidKey
+		}
+		return key, err
+	}
+
+	c.mutex.Lock()
+	c.cache[hashedKey] = cacheEntry{key: key, cachedAt: now}
+	c.mutex.Unlock()
+	return key, nil
+}
+
+// take counts a request in the one-minute window of the key, returning
+// the time to wait if the limit of the window is reached.
+func (c *Checker) take(keyID string, limit int, now time.Time) time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	window, ok := c.windows[keyID]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &rateWindow{start: now}
+		c.windows[keyID] = window
+	}
+
+	if window.count >= limit {
+		return window.start.Add(time.Minute).Sub(now)
+	}
+	window.count++
+	return 0
+}
+
+// GenerateKey returns a new random API key.
+func GenerateKey() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,124 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apikey
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func TestChecker(t *testing.T) {
+	Convey("Checker", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		opened := 0
+		checker := NewChecker(func() (skydb.Conn, error) {
+			opened++
+			return conn, nil
+		}, time.Minute)
+
+		key := skydb.APIKey{
+			ID:             "key-id",
+			Scopes:         []string{"record:*", "@asset"},
+			AllowedOrigins: []string{"https://partner.example.com"},
+			RateLimit:      2,
+		}
+		key.SetKey("secret-key")
+		conn.CreateAPIKey(&key)
+
+		Convey("accepts key within scope", func() {
+			checked, err := checker.Check(Request{Key: "secret-key", Action: "record:fetch", Tag: "record"})
+			So(err, ShouldBeNil)
+			So(checked.ID, ShouldEqual, "key-id")
+
+			_, err = checker.Check(Request{Key: "secret-key", Tag: "asset"})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("caches key", func() {
+			checker.Check(Request{Key: "secret-key", Action: "record:fetch"})
+			checker.Check(Request{Key: "secret-key", Action: "record:query"})
+			So(opened, ShouldEqual, 1)
+
+			now = now.Add(time.Minute)
+			checker.Check(Request{Key: "secret-key", Action: "record:fetch"})
+			So(opened, ShouldEqual, 2)
+		})
+
+		Convey("rejects unknown key", func() {
+			_, err := checker.Check(Request{Key: "unknown", Action: "record:fetch"})
+			So(err, ShouldEqual, ErrInvalidKey)
+		})
+
+		Convey("rejects revoked key after invalidation", func() {
+			checker.Check(Request{Key: "secret-key", Action: "record:fetch"})
+
+			key.RevokedAt = &now
+			conn.UpdateAPIKey(&key)
+			checker.Invalidate()
+
+			_, err := checker.Check(Request{Key: "secret-key", Action: "record:fetch"})
+			So(err, ShouldEqual, ErrInvalidKey)
+		})
+
+		Convey("rejects expired key", func() {
+			expireAt := now.Add(-time.Second)
+			key.ExpireAt = &expireAt
+			conn.UpdateAPIKey(&key)
+
+			_, err := checker.Check(Request{Key: "secret-key", Action: "record:fetch"})
+			So(err, ShouldEqual, ErrInvalidKey)
+		})
+
+		Convey("rejects action out of scope", func() {
+			_, err := checker.Check(Request{Key: "secret-key", Action: "auth:login", Tag: "auth"})
+			So(err, ShouldEqual, ErrScopeNotAllowed)
+		})
+
+		Convey("rejects origin not allowed", func() {
+			_, err := checker.Check(Request{Key: "secret-key", Action: "record:fetch", Origin: "https://evil.example.com"})
+			So(err, ShouldEqual, ErrOriginNotAllowed)
+
+			_, err = checker.Check(Request{Key: "secret-key", Action: "record:fetch", Origin: "https://partner.example.com"})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("limits rate per minute", func() {
+			for i := 0; i < 2; i++ {
+				_, err := checker.Check(Request{Key: "secret-key", Action: "record:fetch"})
+				So(err, ShouldBeNil)
+			}
+
+			now = now.Add(20 * time.Second)
+			_, err := checker.Check(Request{Key: "secret-key", Action: "record:fetch"})
+			So(err, ShouldResemble, &RateLimitError{RetryAfter: 40 * time.Second})
+
+			now = now.Add(40 * time.Second)
+			_, err = checker.Check(Request{Key: "secret-key", Action: "record:fetch"})
+			So(err, ShouldBeNil)
+		})
+	})
+}
@@ -66,6 +66,12 @@ type FilePutter interface {
 	) error
 }
 
+// FileDeleter defines the interface of a deleter for files. Deleting a
+// file that does not exist is not an error.
+type FileDeleter interface {
+	DeleteFile(name string) error
+}
+
 // FilePostRequestGenerator defines the interface of a generator
 // for post file request
 type FilePostRequestGenerator interface {
@@ -119,6 +119,15 @@ func (s *fileStore) PutFileReader(name string, src io.Reader, length int64, cont
 	return nil
 }
 
+// DeleteFile removes a file from file system
+func (s *fileStore) DeleteFile(name string) error {
+	path := filepath.Join(s.dir, name)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 // GeneratePostFileRequest return a PostFileRequest for uploading asset
 func (s *fileStore) GeneratePostFileRequest(name string, contentType string, length int64) (*PostFileRequest, error) {
 	return &PostFileRequest{
@@ -1,7 +1,10 @@
 package asset
 
 import (
+	"bytes"
+	"io/ioutil"
 	"net/url"
+	"os"
 	"strconv"
 	"testing"
 	"time"
@@ -60,3 +63,30 @@ func TestFileStore(t *testing.T) {
 
 	})
 }
+
+func TestFileStoreDeleteFile(t *testing.T) {
+	Convey("FS Asset Store", t, func() {
+		dir, err := ioutil.TempDir("", "skygear-asset")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		fsStore := &fileStore{
+			dir,
+			"http://skygear.dev/files",
+			"asset_secret",
+			false,
+		}
+
+		Convey("delete an existing file", func() {
+			So(fsStore.PutFileReader("index.html", bytes.NewReader([]byte("hello")), 5, "text/html"), ShouldBeNil)
+			So(fsStore.DeleteFile("index.html"), ShouldBeNil)
+
+			_, err := fsStore.GetFileReader("index.html")
+			So(os.IsNotExist(err), ShouldBeTrue)
+		})
+
+		Convey("delete a not existing file", func() {
+			So(fsStore.DeleteFile("index.html"), ShouldBeNil)
+		})
+	})
+}
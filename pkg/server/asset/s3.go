@@ -133,6 +133,16 @@ func (s *s3Store) PutFileReader(
 	return err
 }
 
+// DeleteFile deletes a file from s3
+func (s *s3Store) DeleteFile(name string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket: s.bucket,
+		Key:    aws.String(name),
+	}
+	_, err := s.svc.DeleteObject(input)
+	return err
+}
+
 // GeneratePostFileRequest return a PostFileRequest for uploading asset
 func (s *s3Store) GeneratePostFileRequest(name string, contentType string, length int64) (*PostFileRequest, error) {
 	return &PostFileRequest{
@@ -0,0 +1,145 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryPurgeInterval is the minimum interval between purges of expired
+// attempts in MemoryLoginAttemptStore.
+const memoryPurgeInterval = time.Minute
+
+type memoryLoginAttempt struct {
+	attempt          LoginAttempt
+	failuresExpireAt time.Time
+	expireAt         time.Time
+}
+
+// MemoryLoginAttemptStore implements LoginAttemptStore by keeping login
+// attempts in memory. Attempts are not shared among server instances.
+type MemoryLoginAttemptStore struct {
+	mutex    sync.Mutex
+	attempts map[string]memoryLoginAttempt
+	purgedAt time.Time
+}
+
+// NewMemoryLoginAttemptStore creates a in-memory login attempt store.
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts: map[string]memoryLoginAttempt{},
+	}
+}
+
+// get returns the stored attempt of the key, with expired failures
+// reset. The caller must hold the mutex.
+func (s *MemoryLoginAttemptStore) get(key string, now time.Time) memoryLoginAttempt {
+	stored, ok := s.attempts[key]
+	if !ok || stored.expireAt.Before(now) {
+		return memoryLoginAttempt{}
+	}
+	if !stored.failuresExpireAt.After(now) {
+		stored.attempt.Failures = 0
+		stored.failuresExpireAt = time.Time{}
+	}
+	return stored
+}
+
+// put saves the attempt of the key, purging expired attempts if they
+// have not been purged for a while. The caller must hold the mutex.
+func (s *MemoryLoginAttemptStore) put(key string, stored memoryLoginAttempt, now time.Time) {
+	if now.Sub(s.purgedAt) >= memoryPurgeInterval {
+		s.purgeExpired(now)
+		s.purgedAt = now
+	}
+	s.attempts[key] = stored
+}
+
+// Get implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) Get(key string, attempt *LoginAttempt) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	*attempt = s.get(key, timeNow()).attempt
+	return nil
+}
+
+// IncrementFailures implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) IncrementFailures(key string, window time.Duration) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := timeNow()
+	stored := s.get(key, now)
+	if stored.failuresExpireAt.IsZero() {
+		stored.failuresExpireAt = now.Add(window)
+	}
+	stored.attempt.Failures++
+	if stored.failuresExpireAt.After(stored.expireAt) {
+		stored.expireAt = stored.failuresExpireAt
+	}
+
+	s.put(key, stored, now)
+	return stored.attempt.Failures, nil
+}
+
+// AddLockout implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) AddLockout(key string) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := timeNow()
+	stored := s.get(key, now)
+	stored.attempt.Lockouts++
+	stored.attempt.Failures = 0
+	stored.failuresExpireAt = time.Time{}
+
+	s.put(key, stored, now)
+	return stored.attempt.Lockouts, nil
+}
+
+// SetLockedUntil implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) SetLockedUntil(key string, lockedUntil time.Time, expireAt time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := timeNow()
+	stored := s.get(key, now)
+	stored.attempt.LockedUntil = lockedUntil
+	stored.expireAt = expireAt
+
+	s.put(key, stored, now)
+	return nil
+}
+
+// Delete implements LoginAttemptStore.
+func (s *MemoryLoginAttemptStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// purgeExpired removes expired attempts so that the store does not grow
+// indefinitely. The caller must hold the mutex.
+func (s *MemoryLoginAttemptStore) purgeExpired(now time.Time) {
+	for key, stored := range s.attempts {
+		if stored.expireAt.Before(now) {
+			delete(s.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// RedisLoginAttemptStore implements LoginAttemptStore by saving login
+// attempts in a redis server, so that attempts are shared among
+// server instances.
+type RedisLoginAttemptStore struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+// NewRedisLoginAttemptStore creates a redis login attempt store.
+//
+// address is url to the redis server
+//
+// prefix is a string prepending to the login attempt key in redis
+//   For example if the key is `account:some-user-id` and the prefix is
+//   `myApp`, the key in redis should be `myApp:login_attempt:account:some-user-id`.
+func NewRedisLoginAttemptStore(address string, prefix string) *RedisLoginAttemptStore {
+	store := RedisLoginAttemptStore{}
+
+	if prefix != "" {
+		store.prefix = prefix + ":"
+	}
+	store.prefix += "login_attempt:"
+
+	store.pool = &redis.Pool{
+		MaxIdle: 50,
+		Dial: func() (redis.Conn, error) {
+			return redis.DialURL(address)
+		},
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+
+	return &store
+}
+
+// redisLoginAttempt stores the lockout of a LoginAttempt with UnixNano
+// timestamp. Failures are counted in a separate key, which expires at the
+// end of the window.
+type redisLoginAttempt struct {
+	Lockouts    int   `redis:"lockouts"`
+	LockedUntil int64 `redis:"lockedUntil"`
+}
+
+// incrementFailuresScript increments the failures and starts the window
+// on the first failure, atomically.
+var incrementFailuresScript = redis.NewScript(1, `
+local failures = redis.call("INCR", KEYS[1])
+if failures == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return failures
+`)
+
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(i int64) time.Time {
+	if i == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, i).UTC()
+}
+
+func (s *RedisLoginAttemptStore) failuresKey(key string) string {
+	return s.prefix + key + ":failures"
+}
+
+// Get implements LoginAttemptStore.
+func (s *RedisLoginAttemptStore) Get(key string, attempt *LoginAttempt) error {
+	c := s.pool.Get()
+	if err := c.Err(); err != nil {
+		return err
+	}
+	defer c.Close()
+
+	c.Send("MULTI")
+	c.Send("HGETALL", s.prefix+key)
+	c.Send("GET", s.failuresKey(key))
+	replies, err := redis.Values(c.Do("EXEC"))
+	if err != nil {
+		return err
+	}
+
+	v, err := redis.Values(replies[0], nil)
+	if err != nil {
+		return err
+	}
+	var stored redisLoginAttempt
+	if err := redis.ScanStruct(v, &stored); err != nil {
+		return err
+	}
+
+	failures, err := redis.Int(replies[1], nil)
+	if err != nil && err != redis.ErrNil {
+		return err
+	}
+
+	*attempt = LoginAttempt{
+		Failures:    failures,
+		Lockouts:    stored.Lockouts,
+		LockedUntil: fromUnixNano(stored.LockedUntil),
+	}
+	return nil
+}
+
+// IncrementFailures implements LoginAttemptStore.
+func (s *RedisLoginAttemptStore) IncrementFailures(key string, window time.Duration) (int, error) {
+	c := s.pool.Get()
+	if err := c.Err(); err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	windowMillis := int64(window / time.Millisecond)
+	if windowMillis <= 0 {
+		windowMillis = 1
+	}
+	return redis.Int(incrementFailuresScript.Do(c, s.failuresKey(key), windowMillis))
+}
+
+// AddLockout implements LoginAttemptStore.
+func (s *RedisLoginAttemptStore) AddLockout(key string) (int, error) {
+	c := s.pool.Get()
+	if err := c.Err(); err != nil {
+		return 0, err
+	}
+	defer c.Close()
+
+	c.Send("MULTI")
+	c.Send("HINCRBY", s.prefix+key, "lockouts", 1)
+	c.Send("DEL", s.failuresKey(key))
+	replies, err := redis.Values(c.Do("EXEC"))
+	if err != nil {
+		return 0, err
+	}
+	return redis.Int(replies[0], nil)
+}
+
+// SetLockedUntil implements LoginAttemptStore.
+func (s *RedisLoginAttemptStore) SetLockedUntil(key string, lockedUntil time.Time, expireAt time.Time) error {
+	c := s.pool.Get()
+	if err := c.Err(); err != nil {
+		return err
+	}
+	defer c.Close()
+
+	keyWithPrefix := s.prefix + key
+
+	c.Send("MULTI")
+	c.Send("HSET", keyWithPrefix, "lockedUntil", toUnixNano(lockedUntil))
+	c.Send("EXPIREAT", keyWithPrefix, expireAt.Unix())
+	_, err := c.Do("EXEC")
+	return err
+}
+
+// Delete implements LoginAttemptStore.
+func (s *RedisLoginAttemptStore) Delete(key string) error {
+	c := s.pool.Get()
+	if err := c.Err(); err != nil {
+		return err
+	}
+	defer c.Close()
+
+	_, err := c.Do("DEL", s.prefix+key, s.failuresKey(key))
+	return err
+}
@@ -0,0 +1,349 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+// LoginAttempt is the failed login state tracked for an account or
+// a client address.
+type LoginAttempt struct {
+	// Failures is the number of failed attempts within the current window.
+	Failures int
+	// Lockouts is the number of consecutive lockouts, used to calculate
+	// the duration of the next lockout.
+	Lockouts    int
+	LockedUntil time.Time
+}
+
+// IsLocked returns true if the attempt is locked at the specified time.
+func (a LoginAttempt) IsLocked(t time.Time) bool {
+	return !a.LockedUntil.IsZero() && a.LockedUntil.After(t)
+}
+
+// LoginAttemptStore persists LoginAttempt by key. Failures and lockouts
+// are counted atomically, so that concurrent failed attempts are not
+// lost.
+type LoginAttemptStore interface {
+	// Get fetches the LoginAttempt of the key. If no attempt is recorded,
+	// the LoginAttempt is set to its zero value and no error is returned.
+	Get(key string, attempt *LoginAttempt) error
+
+	// IncrementFailures adds a failure to the attempt of the key and
+	// returns the number of failures in the current window. The window
+	// starts at the first failure and lasts for the specified duration.
+	IncrementFailures(key string, window time.Duration) (int, error)
+
+	// AddLockout adds a lockout to the attempt of the key, resets its
+	// failures and returns the number of consecutive lockouts.
+	AddLockout(key string) (int, error)
+
+	// SetLockedUntil locks the attempt of the key until the specified
+	// time. The store may discard the attempt after expireAt.
+	SetLockedUntil(key string, lockedUntil time.Time, expireAt time.Time) error
+
+	// Delete removes the LoginAttempt of the key.
+	Delete(key string) error
+}
+
+// LoginThrottler counts failed login attempts per account and per client
+// address, and locks out further attempts temporarily when the number of
+// failures reaches the configured limit. Each subsequent lockout lasts twice
+// as long as the previous one, up to MaxLockoutDuration.
+//
+// A LoginThrottler without Store does not throttle.
+type LoginThrottler struct {
+	Store              LoginAttemptStore
+	AccountMaxAttempts int
+	IPMaxAttempts      int
+	Window             time.Duration
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+
+	// TrustedProxies are the networks of the reverse proxies in front of
+	// the server, whose forwarding headers are used to find the client
+	// address. See ClientIP.
+	TrustedProxies []*net.IPNet
+}
+
+func (t *LoginThrottler) enabled() bool {
+	return t != nil && t.Store != nil
+}
+
+func accountAttemptKey(authID string) string {
+	return "account:" + authID
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// CheckAccount returns an error if login to the account is locked.
+func (t *LoginThrottler) CheckAccount(authID string) skyerr.Error {
+	if !t.enabled() || t.AccountMaxAttempts <= 0 || authID == "" {
+		return nil
+	}
+	return t.check(accountAttemptKey(authID))
+}
+
+// CheckIP returns an error if login from the client address is locked.
+func (t *LoginThrottler) CheckIP(ip string) skyerr.Error {
+	if !t.enabled() || t.IPMaxAttempts <= 0 || ip == "" {
+		return nil
+	}
+	return t.check(ipAttemptKey(ip))
+}
+
+func (t *LoginThrottler) check(key string) skyerr.Error {
+	attempt := LoginAttempt{}
+	if err := t.Store.Get(key, &attempt); err != nil {
+		return skyerr.MakeError(err)
+	}
+
+	if now := timeNow(); attempt.IsLocked(now) {
+		return newLoginThrottledError(attempt.LockedUntil, now)
+	}
+	return nil
+}
+
+// RecordFailure records a failed login attempt for the account and the
+// client address. Either of them can be empty if it is not known.
+//
+// The returned bool is true if the account is locked as a result of this
+// failure. If either the account or the client address becomes locked,
+// an error telling when the client may retry is also returned.
+func (t *LoginThrottler) RecordFailure(authID string, ip string) (bool, skyerr.Error) {
+	if !t.enabled() {
+		return false, nil
+	}
+
+	now := timeNow()
+	var lockedUntil time.Time
+	var accountLocked bool
+	if authID != "" && t.AccountMaxAttempts > 0 {
+		attempt, err := t.recordFailure(accountAttemptKey(authID), t.AccountMaxAttempts, now)
+		if err != nil {
+			return false, skyerr.MakeError(err)
+		}
+		if attempt.IsLocked(now) {
+			accountLocked = true
+			lockedUntil = attempt.LockedUntil
+		}
+	}
+
+	if ip != "" && t.IPMaxAttempts > 0 {
+		attempt, err := t.recordFailure(ipAttemptKey(ip), t.IPMaxAttempts, now)
+		if err != nil {
+			return accountLocked, skyerr.MakeError(err)
+		}
+		if attempt.IsLocked(now) && attempt.LockedUntil.After(lockedUntil) {
+			lockedUntil = attempt.LockedUntil
+		}
+	}
+
+	if lockedUntil.IsZero() {
+		return accountLocked, nil
+	}
+	return accountLocked, newLoginThrottledError(lockedUntil, now)
+}
+
+func (t *LoginThrottler) recordFailure(key string, maxAttempts int, now time.Time) (LoginAttempt, error) {
+	failures, err := t.Store.IncrementFailures(key, t.Window)
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+
+	// Failures are counted atomically, so only the attempt reaching the
+	// limit locks the key even if attempts are made concurrently.
+	if failures != maxAttempts {
+		return LoginAttempt{Failures: failures}, nil
+	}
+
+	lockouts, err := t.Store.AddLockout(key)
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+	attempt := LoginAttempt{
+		Lockouts:    lockouts,
+		LockedUntil: now.Add(t.lockoutDuration(lockouts)),
+	}
+
+	// Keep the record long enough so that consecutive lockouts are
+	// still known when the next lockout is calculated.
+	expireAt := now.Add(t.Window)
+	if lockedExpiry := attempt.LockedUntil.Add(t.MaxLockoutDuration); lockedExpiry.After(expireAt) {
+		expireAt = lockedExpiry
+	}
+
+	err = t.Store.SetLockedUntil(key, attempt.LockedUntil, expireAt)
+	return attempt, err
+}
+
+func (t *LoginThrottler) lockoutDuration(lockouts int) time.Duration {
+	multiplier := math.Pow(2, float64(lockouts-1))
+	duration := time.Duration(float64(t.LockoutDuration) * multiplier)
+	if t.MaxLockoutDuration > 0 && (duration > t.MaxLockoutDuration || duration <= 0) {
+		duration = t.MaxLockoutDuration
+	}
+	return duration
+}
+
+// RecordSuccess resets the failed attempts of the account.
+func (t *LoginThrottler) RecordSuccess(authID string) error {
+	if !t.enabled() || authID == "" {
+		return nil
+	}
+	return t.Store.Delete(accountAttemptKey(authID))
+}
+
+// Unlock removes the lockout and failed attempts of the account.
+func (t *LoginThrottler) Unlock(authID string) error {
+	if !t.enabled() {
+		return nil
+	}
+	return t.Store.Delete(accountAttemptKey(authID))
+}
+
+func newLoginThrottledError(lockedUntil time.Time, now time.Time) skyerr.Error {
+	retryAfter := int64(math.Ceil(lockedUntil.Sub(now).Seconds()))
+	return skyerr.NewErrorWithInfo(
+		skyerr.LoginThrottled,
+		"too many failed login attempts, please try again later",
+		map[string]interface{}{
+			"retry_after":  retryAfter,
+			"locked_until": lockedUntil.Format(time.RFC3339),
+		},
+	)
+}
+
+// ParseTrustedProxies parses the addresses and CIDR networks of trusted
+// proxies.
+func ParseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy address: %s", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy network: %s", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// ClientIP returns the address of the client making the request, with
+// port number removed.
+//
+// If the request is made by a trusted proxy, the client address is the
+// last address forwarded by the proxies that is not a trusted proxy, read
+// from the Forwarded header, or X-Forwarded-For, or X-Real-IP, in that
+// order. The headers are ignored if the request is not made by a trusted
+// proxy, since the client can set them to anything.
+func (t *LoginThrottler) ClientIP(payload *router.Payload) string {
+	if payload == nil {
+		return ""
+	}
+
+	remoteAddr, _ := payload.Meta["remote_addr"].(string)
+	ip := removePort(remoteAddr)
+	if !t.isTrustedProxy(ip) {
+		return ip
+	}
+
+	forwarded := forwardedAddrs(payload.Meta)
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip = forwarded[i]
+		if !t.isTrustedProxy(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+func (t *LoginThrottler) isTrustedProxy(addr string) bool {
+	if t == nil {
+		return false
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range t.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedAddrs returns the client addresses forwarded by proxies, from
+// the client to the last proxy.
+func forwardedAddrs(meta map[string]interface{}) []string {
+	addrs := []string{}
+	if forwarded, _ := meta["forwarded"].(string); forwarded != "" {
+		for _, element := range strings.Split(forwarded, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(kv) == 2 && strings.EqualFold(kv[0], "for") {
+					addrs = append(addrs, removePort(strings.Trim(kv[1], `"`)))
+				}
+			}
+		}
+		return addrs
+	}
+
+	if xff, _ := meta["x_forwarded_for"].(string); xff != "" {
+		for _, addr := range strings.Split(xff, ",") {
+			addrs = append(addrs, removePort(strings.TrimSpace(addr)))
+		}
+		return addrs
+	}
+
+	if xri, _ := meta["x_real_ip"].(string); xri != "" {
+		addrs = append(addrs, removePort(strings.TrimSpace(xri)))
+	}
+	return addrs
+}
+
+func removePort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
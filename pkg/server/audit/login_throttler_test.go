@@ -0,0 +1,313 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLoginThrottler(t *testing.T) {
+	Convey("LoginThrottler", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		throttler := &LoginThrottler{
+			Store:              NewMemoryLoginAttemptStore(),
+			AccountMaxAttempts: 3,
+			IPMaxAttempts:      5,
+			Window:             10 * time.Minute,
+			LockoutDuration:    time.Minute,
+			MaxLockoutDuration: 3 * time.Minute,
+		}
+
+		Convey("does not lock before reaching max attempts", func() {
+			for i := 0; i < 2; i++ {
+				locked, err := throttler.RecordFailure("faseng", "127.0.0.1")
+				So(locked, ShouldBeFalse)
+				So(err, ShouldBeNil)
+			}
+			So(throttler.CheckAccount("faseng"), ShouldBeNil)
+			So(throttler.CheckIP("127.0.0.1"), ShouldBeNil)
+		})
+
+		Convey("locks account when reaching max attempts", func() {
+			throttler.RecordFailure("faseng", "127.0.0.1")
+			throttler.RecordFailure("faseng", "127.0.0.1")
+			locked, err := throttler.RecordFailure("faseng", "127.0.0.1")
+			So(locked, ShouldBeTrue)
+			So(err.Code(), ShouldEqual, skyerr.LoginThrottled)
+			So(err.Info()["retry_after"], ShouldEqual, int64(60))
+			So(err.Info()["locked_until"], ShouldEqual, "2017-01-01T00:01:00Z")
+
+			So(throttler.CheckAccount("faseng").Code(), ShouldEqual, skyerr.LoginThrottled)
+			So(throttler.CheckAccount("chima"), ShouldBeNil)
+			So(throttler.CheckIP("127.0.0.1"), ShouldBeNil)
+
+			now = now.Add(time.Minute)
+			So(throttler.CheckAccount("faseng"), ShouldBeNil)
+		})
+
+		Convey("locks client address when reaching max attempts", func() {
+			for i := 0; i < 4; i++ {
+				throttler.RecordFailure("", "127.0.0.1")
+			}
+			locked, err := throttler.RecordFailure("", "127.0.0.1")
+			So(locked, ShouldBeFalse)
+			So(err.Code(), ShouldEqual, skyerr.LoginThrottled)
+			So(throttler.CheckIP("127.0.0.1").Code(), ShouldEqual, skyerr.LoginThrottled)
+			So(throttler.CheckIP("127.0.0.2"), ShouldBeNil)
+		})
+
+		Convey("doubles lockout duration up to max", func() {
+			lockUntil := func() string {
+				throttler.RecordFailure("faseng", "")
+				throttler.RecordFailure("faseng", "")
+				_, err := throttler.RecordFailure("faseng", "")
+				lockedUntil := err.Info()["locked_until"]
+				now = now.Add(5 * time.Minute)
+				return lockedUntil.(string)
+			}
+
+			So(lockUntil(), ShouldEqual, "2017-01-01T00:01:00Z")
+			So(lockUntil(), ShouldEqual, "2017-01-01T00:07:00Z")
+			So(lockUntil(), ShouldEqual, "2017-01-01T00:13:00Z")
+		})
+
+		Convey("resets failures outside of window", func() {
+			throttler.RecordFailure("faseng", "")
+			throttler.RecordFailure("faseng", "")
+			now = now.Add(11 * time.Minute)
+			locked, err := throttler.RecordFailure("faseng", "")
+			So(locked, ShouldBeFalse)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("unlocks account", func() {
+			throttler.RecordFailure("faseng", "")
+			throttler.RecordFailure("faseng", "")
+			throttler.RecordFailure("faseng", "")
+			So(throttler.CheckAccount("faseng"), ShouldNotBeNil)
+
+			So(throttler.Unlock("faseng"), ShouldBeNil)
+			So(throttler.CheckAccount("faseng"), ShouldBeNil)
+		})
+
+		Convey("counts concurrent failures", func() {
+			throttler.AccountMaxAttempts = 50
+			var wg sync.WaitGroup
+			for i := 0; i < 49; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					throttler.RecordFailure("faseng", "")
+				}()
+			}
+			wg.Wait()
+			So(throttler.CheckAccount("faseng"), ShouldBeNil)
+
+			locked, _ := throttler.RecordFailure("faseng", "")
+			So(locked, ShouldBeTrue)
+		})
+
+		Convey("does nothing without store", func() {
+			throttler.Store = nil
+			locked, err := throttler.RecordFailure("faseng", "127.0.0.1")
+			So(locked, ShouldBeFalse)
+			So(err, ShouldBeNil)
+			So(throttler.CheckAccount("faseng"), ShouldBeNil)
+			So(throttler.CheckIP("127.0.0.1"), ShouldBeNil)
+		})
+	})
+}
+
+func TestMemoryLoginAttemptStore(t *testing.T) {
+	Convey("MemoryLoginAttemptStore", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		store := NewMemoryLoginAttemptStore()
+
+		Convey("resets failures after window", func() {
+			store.IncrementFailures("key", time.Minute)
+			failures, err := store.IncrementFailures("key", time.Minute)
+			So(err, ShouldBeNil)
+			So(failures, ShouldEqual, 2)
+
+			now = now.Add(time.Minute)
+			failures, err = store.IncrementFailures("key", time.Minute)
+			So(err, ShouldBeNil)
+			So(failures, ShouldEqual, 1)
+		})
+
+		Convey("keeps lockouts until expiry", func() {
+			store.IncrementFailures("key", time.Minute)
+			lockouts, err := store.AddLockout("key")
+			So(err, ShouldBeNil)
+			So(lockouts, ShouldEqual, 1)
+			So(store.SetLockedUntil("key", now.Add(time.Minute), now.Add(time.Hour)), ShouldBeNil)
+
+			attempt := LoginAttempt{}
+			So(store.Get("key", &attempt), ShouldBeNil)
+			So(attempt, ShouldResemble, LoginAttempt{
+				Lockouts:    1,
+				LockedUntil: now.Add(time.Minute),
+			})
+
+			now = now.Add(2 * time.Hour)
+			So(store.Get("key", &attempt), ShouldBeNil)
+			So(attempt, ShouldResemble, LoginAttempt{})
+		})
+
+		Convey("purges expired attempts at interval", func() {
+			store.IncrementFailures("expired", time.Second)
+			now = now.Add(time.Second)
+			store.IncrementFailures("other", time.Hour)
+			So(store.attempts, ShouldContainKey, "expired")
+
+			now = now.Add(memoryPurgeInterval)
+			store.IncrementFailures("other", time.Hour)
+			So(store.attempts, ShouldNotContainKey, "expired")
+		})
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	Convey("ClientIP", t, func() {
+		throttler := &LoginThrottler{}
+
+		Convey("removes port number", func() {
+			payload := &router.Payload{
+				Meta: map[string]interface{}{
+					"remote_addr": "192.168.1.1:54321",
+				},
+			}
+			So(throttler.ClientIP(payload), ShouldEqual, "192.168.1.1")
+		})
+
+		Convey("returns empty string without remote address", func() {
+			So(throttler.ClientIP(&router.Payload{}), ShouldEqual, "")
+		})
+
+		Convey("ignores forwarding headers without trusted proxies", func() {
+			payload := &router.Payload{
+				Meta: map[string]interface{}{
+					"remote_addr":     "10.0.0.2:54321",
+					"x_forwarded_for": "203.0.113.1",
+				},
+			}
+			So(throttler.ClientIP(payload), ShouldEqual, "10.0.0.2")
+		})
+
+		Convey("with trusted proxies", func() {
+			trustedProxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "127.0.0.1"})
+			So(err, ShouldBeNil)
+			throttler.TrustedProxies = trustedProxies
+
+			Convey("ignores forwarding headers of untrusted client", func() {
+				payload := &router.Payload{
+					Meta: map[string]interface{}{
+						"remote_addr":     "198.51.100.1:54321",
+						"x_forwarded_for": "203.0.113.1",
+					},
+				}
+				So(throttler.ClientIP(payload), ShouldEqual, "198.51.100.1")
+			})
+
+			Convey("returns last untrusted address in X-Forwarded-For", func() {
+				payload := &router.Payload{
+					Meta: map[string]interface{}{
+						"remote_addr":     "127.0.0.1:54321",
+						"x_forwarded_for": "192.0.2.1, 203.0.113.1, 10.0.0.3",
+					},
+				}
+				So(throttler.ClientIP(payload), ShouldEqual, "203.0.113.1")
+			})
+
+			Convey("prefers Forwarded to X-Forwarded-For", func() {
+				payload := &router.Payload{
+					Meta: map[string]interface{}{
+						"remote_addr":     "10.0.0.2:54321",
+						"forwarded":       `for=192.0.2.1;proto=https, for="[2001:db8::1]:4711"`,
+						"x_forwarded_for": "203.0.113.1",
+					},
+				}
+				So(throttler.ClientIP(payload), ShouldEqual, "2001:db8::1")
+			})
+
+			Convey("returns X-Real-IP without other headers", func() {
+				payload := &router.Payload{
+					Meta: map[string]interface{}{
+						"remote_addr": "10.0.0.2:54321",
+						"x_real_ip":   "203.0.113.1",
+					},
+				}
+				So(throttler.ClientIP(payload), ShouldEqual, "203.0.113.1")
+			})
+
+			Convey("returns first address if all addresses are trusted", func() {
+				payload := &router.Payload{
+					Meta: map[string]interface{}{
+						"remote_addr":     "10.0.0.2:54321",
+						"x_forwarded_for": "10.0.0.4, 10.0.0.3",
+					},
+				}
+				So(throttler.ClientIP(payload), ShouldEqual, "10.0.0.4")
+			})
+
+			Convey("returns remote address without forwarding headers", func() {
+				payload := &router.Payload{
+					Meta: map[string]interface{}{
+						"remote_addr": "10.0.0.2:54321",
+					},
+				}
+				So(throttler.ClientIP(payload), ShouldEqual, "10.0.0.2")
+			})
+		})
+	})
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	Convey("ParseTrustedProxies", t, func() {
+		Convey("parses addresses and networks", func() {
+			networks, err := ParseTrustedProxies([]string{"127.0.0.1", "::1", "10.0.0.0/8"})
+			So(err, ShouldBeNil)
+			So(networks, ShouldHaveLength, 3)
+			So(networks[0].String(), ShouldEqual, "127.0.0.1/32")
+			So(networks[1].String(), ShouldEqual, "::1/128")
+			So(networks[2].String(), ShouldEqual, "10.0.0.0/8")
+		})
+
+		Convey("rejects invalid values", func() {
+			_, err := ParseTrustedProxies([]string{"localhost"})
+			So(err, ShouldNotBeNil)
+			_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
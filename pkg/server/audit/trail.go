@@ -71,6 +71,26 @@ const (
 
 	// EventEnableUser represents Enable User
 	EventEnableUser
+
+	// EventLockUser represents Lock User after too many failed logins
+	EventLockUser
+
+	// EventUnlockUser represents Unlock User
+	EventUnlockUser
+
+	// EventImpersonate represents an admin being issued a token to
+	// impersonate a user
+	EventImpersonate
+
+	// EventImpersonatedRequest represents a request made by an admin
+	// impersonating a user
+	EventImpersonatedRequest
+
+	// EventDeleteUser represents Delete User and erasure of the user data
+	EventDeleteUser
+
+	// EventExportUserData represents Export User Data
+	EventExportUserData
 )
 
 func (e Event) String() string {
@@ -93,6 +113,18 @@ func (e Event) String() string {
 		return "disable_user"
 	case EventEnableUser:
 		return "enable_user"
+	case EventLockUser:
+		return "lock_user"
+	case EventUnlockUser:
+		return "unlock_user"
+	case EventImpersonate:
+		return "impersonate"
+	case EventImpersonatedRequest:
+		return "impersonated_request"
+	case EventDeleteUser:
+		return "delete_user"
+	case EventExportUserData:
+		return "export_user_data"
 	default:
 		return ""
 	}
@@ -3,6 +3,7 @@ package authtoken
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
@@ -103,3 +104,31 @@ func (f *FileStore) Delete(accessToken string) error {
 
 	return nil
 }
+
+// DeleteUserTokens removes the tokens of the user of authInfoID by
+// reading every token in the directory.
+func (f *FileStore) DeleteUserTokens(authInfoID string) error {
+	files, err := ioutil.ReadDir(f.address)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range files {
+		if info.IsDir() {
+			continue
+		}
+
+		token := Token{}
+		if err := f.Get(info.Name(), &token); err != nil {
+			continue
+		}
+		if token.AuthInfoID != authInfoID {
+			continue
+		}
+		if err := f.Delete(info.Name()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package authtoken
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/garyburd/redigo/redis"
@@ -56,6 +57,8 @@ type RedisToken struct {
 	IssuedAt    int64  `redis:"issuedAt"`
 	AppName     string `redis:"appName"`
 	AuthInfoID  string `redis:"authInfoID"`
+
+	ImpersonatorID string `redis:"impersonatorID"`
 }
 
 // ToRedisToken converts an auth token to RedisToken
@@ -73,6 +76,7 @@ func (t Token) ToRedisToken() *RedisToken {
 		issuedAt,
 		t.AppName,
 		t.AuthInfoID,
+		t.ImpersonatorID,
 	}
 }
 
@@ -92,6 +96,7 @@ func (r RedisToken) ToToken() *Token {
 		r.AppName,
 		r.AuthInfoID,
 		issuedAt,
+		r.ImpersonatorID,
 	}
 }
 
@@ -148,11 +153,21 @@ func (r *RedisStore) Put(token *Token) error {
 	accessTokenWithPrefix := r.prefix + redisToken.AccessToken
 	tokenArgs := redis.Args{}.Add(accessTokenWithPrefix).AddFlat(redisToken)
 
+	// tokens of a user are indexed by expiry, so that expired tokens
+	// are trimmed from the index
+	userTokensKey := r.userTokensKey(token.AuthInfoID)
+	score := "+inf"
+	if !token.ExpiredAt.IsZero() {
+		score = strconv.FormatInt(token.ExpiredAt.Unix(), 10)
+	}
+
 	c.Send("MULTI")
 	c.Send("HMSET", tokenArgs...)
 	if !token.ExpiredAt.IsZero() {
 		c.Send("EXPIREAT", token.AccessToken, token.ExpiredAt.Unix())
 	}
+	c.Send("ZADD", userTokensKey, score, token.AccessToken)
+	c.Send("ZREMRANGEBYSCORE", userTokensKey, "-inf", time.Now().Unix())
 	_, err := c.Do("EXEC")
 	if err != nil {
 		return err
@@ -170,10 +185,50 @@ func (r *RedisStore) Delete(accessToken string) error {
 	defer c.Close()
 
 	accessTokenWithPrefix := r.prefix + accessToken
-	_, err := c.Do("DEL", accessTokenWithPrefix)
+	authInfoID, err := redis.String(c.Do("HGET", accessTokenWithPrefix, "authInfoID"))
+	if err != nil && err != redis.ErrNil {
+		return err
+	}
+
+	c.Send("MULTI")
+	c.Send("DEL", accessTokenWithPrefix)
+	if authInfoID != "" {
+		c.Send("ZREM", r.userTokensKey(authInfoID), accessToken)
+	}
+	if _, err := c.Do("EXEC"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUserTokens removes all tokens of the user of authInfoID from
+// redis store.
+func (r *RedisStore) DeleteUserTokens(authInfoID string) error {
+	c := r.pool.Get()
+	if err := c.Err(); err != nil {
+		return err
+	}
+	defer c.Close()
+
+	userTokensKey := r.userTokensKey(authInfoID)
+	accessTokens, err := redis.Strings(c.Do("ZRANGE", userTokensKey, 0, -1))
 	if err != nil {
 		return err
 	}
 
+	c.Send("MULTI")
+	for _, accessToken := range accessTokens {
+		c.Send("DEL", r.prefix+accessToken)
+	}
+	c.Send("DEL", userTokensKey)
+	if _, err := c.Do("EXEC"); err != nil {
+		return err
+	}
+
 	return nil
 }
+
+func (r *RedisStore) userTokensKey(authInfoID string) string {
+	return r.prefix + "user-tokens:" + authInfoID
+}
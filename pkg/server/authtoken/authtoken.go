@@ -21,6 +21,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
 	"github.com/skygeario/skygear-server/pkg/server/uuid"
 )
 
@@ -31,6 +32,11 @@ type Token struct {
 	AppName     string    `json:"appName" redis:"appName"`
 	AuthInfoID  string    `json:"authInfoID" redis:"authInfoID"`
 	issuedAt    time.Time `json:"issuedAt" redis:"issuedAt"`
+
+	// ImpersonatorID is the ID of the admin this token is issued to for
+	// acting as the user of AuthInfoID. It is empty for tokens issued to
+	// the user.
+	ImpersonatorID string `json:"impersonatorID" redis:"impersonatorID"`
 }
 
 // MarshalJSON implements the json.Marshaler interface.
@@ -48,6 +54,7 @@ func (t Token) MarshalJSON() ([]byte, error) {
 		t.AppName,
 		t.AuthInfoID,
 		issuedAt,
+		t.ImpersonatorID,
 	})
 }
 
@@ -69,6 +76,7 @@ func (t *Token) UnmarshalJSON(data []byte) (err error) {
 	t.AppName = token.AppName
 	t.AuthInfoID = token.AuthInfoID
 	t.issuedAt = issuedAt
+	t.ImpersonatorID = token.ImpersonatorID
 	return nil
 }
 
@@ -76,12 +84,20 @@ func (t Token) IssuedAt() time.Time {
 	return t.issuedAt
 }
 
+// IsImpersonated returns whether the token is issued to an admin
+// impersonating the user.
+func (t Token) IsImpersonated() bool {
+	return t.ImpersonatorID != ""
+}
+
 type jsonToken struct {
 	AccessToken string    `json:"accessToken"`
 	ExpiredAt   jsonStamp `json:"expiredAt"`
 	AppName     string    `json:"appName"`
 	AuthInfoID  string    `json:"authInfoID"`
 	issuedAt    jsonStamp `json:"issuedAt"`
+
+	ImpersonatorID string `json:"impersonatorID,omitempty"`
 }
 
 type jsonStamp time.Time
@@ -125,6 +141,53 @@ func New(appName string, authInfoID string, expiredAt time.Time) Token {
 	}
 }
 
+// impersonationTokenStore is implemented by a Store which cannot
+// change the impersonator and expiry of a token after it is created.
+type impersonationTokenStore interface {
+	NewImpersonationToken(appName string, authInfoID string, impersonatorID string, expiredAt time.Time) (Token, error)
+}
+
+// NewImpersonationToken creates a new token of the store for the admin of
+// impersonatorID to act as the user of authInfoID. The token expires at
+// expiredAt, or earlier if the tokens of the store expire earlier.
+//
+// Like tokens created by Store.NewToken, the token has to be Put into
+// the store before use.
+func NewImpersonationToken(store Store, appName string, authInfoID string, impersonatorID string, expiredAt time.Time) (Token, error) {
+	if impersonatorID == "" {
+		return Token{}, errors.New("impersonator is empty")
+	}
+
+	if s, ok := store.(impersonationTokenStore); ok {
+		return s.NewImpersonationToken(appName, authInfoID, impersonatorID, expiredAt)
+	}
+
+	token, err := store.NewToken(appName, authInfoID)
+	if err != nil {
+		return Token{}, err
+	}
+	token.ImpersonatorID = impersonatorID
+	if token.ExpiredAt.IsZero() || expiredAt.Before(token.ExpiredAt) {
+		token.ExpiredAt = expiredAt
+	}
+	return token, nil
+}
+
+type userTokenStore interface {
+	DeleteUserTokens(authInfoID string) error
+}
+
+// DeleteUserTokens removes all tokens of the user of authInfoID from the
+// store. Stores that cannot find tokens by user, such as JWTStore, are
+// left unchanged; their tokens are rejected once the auth info of the
+// user is removed.
+func DeleteUserTokens(store Store, authInfoID string) error {
+	if s, ok := store.(userTokenStore); ok {
+		return s.DeleteUserTokens(authInfoID)
+	}
+	return nil
+}
+
 // IsExpired determines whether the Token has expired now or not.
 func (t *Token) IsExpired() bool {
 	return !t.ExpiredAt.IsZero() && t.ExpiredAt.Before(time.Now())
@@ -166,6 +229,12 @@ type Configuration struct {
 	Prefix         string
 	Expiry         int64
 	Secret         string
+	// KeysPath is the directory of private keys signing JWT tokens. If
+	// empty, JWT tokens are signed with Secret.
+	KeysPath string
+	// VerifySecret allows JWT tokens signed with Secret to be verified
+	// when tokens are signed with keys in KeysPath.
+	VerifySecret bool
 }
 
 // InitTokenStore accept a implementation and path string. Return a Store.
@@ -179,7 +248,20 @@ func InitTokenStore(config Configuration) Store {
 	case "redis":
 		store = NewRedisStore(config.Path, config.Prefix, config.Expiry)
 	case "jwt":
-		store = NewJWTStore(config.Secret, config.Expiry)
+		if config.KeysPath == "" {
+			store = NewJWTStore(config.Secret, config.Expiry)
+			break
+		}
+
+		keySet, err := jwk.LoadKeySet(config.KeysPath)
+		if err != nil {
+			panic(fmt.Sprintf("unable to load jwt store keys: %v", err))
+		}
+		legacySecret := ""
+		if config.VerifySecret {
+			legacySecret = config.Secret
+		}
+		store = NewJWTKeySetStore(keySet, legacySecret, config.Expiry)
 	}
 	return store
 }
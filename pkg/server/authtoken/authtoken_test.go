@@ -236,6 +236,42 @@ func TestFileStoreGet(t *testing.T) {
 	})
 }
 
+func TestNewImpersonationToken(t *testing.T) {
+	Convey("NewImpersonationToken", t, func() {
+		dir := tempDir()
+		defer os.RemoveAll(dir)
+
+		store := NewFileStore(dir, 3600)
+
+		Convey("creates a token expiring earlier than the store expiry", func() {
+			expiredAt := time.Now().Add(10 * time.Minute)
+			token, err := NewImpersonationToken(store, "com_oursky_skygear", "someauthinfoid", "adminid", expiredAt)
+			So(err, ShouldBeNil)
+			So(token.AuthInfoID, ShouldEqual, "someauthinfoid")
+			So(token.ImpersonatorID, ShouldEqual, "adminid")
+			So(token.IsImpersonated(), ShouldBeTrue)
+			So(token.ExpiredAt.Equal(expiredAt), ShouldBeTrue)
+
+			So(store.Put(&token), ShouldBeNil)
+			fetched := Token{}
+			So(store.Get(token.AccessToken, &fetched), ShouldBeNil)
+			So(fetched.ImpersonatorID, ShouldEqual, "adminid")
+			So(fetched.ExpiredAt.UnixNano(), ShouldEqual, expiredAt.UnixNano())
+		})
+
+		Convey("does not extend the store expiry", func() {
+			token, err := NewImpersonationToken(store, "com_oursky_skygear", "someauthinfoid", "adminid", time.Now().Add(24*time.Hour))
+			So(err, ShouldBeNil)
+			So(token.ExpiredAt.Before(time.Now().Add(time.Hour+time.Minute)), ShouldBeTrue)
+		})
+
+		Convey("rejects empty impersonator", func() {
+			_, err := NewImpersonationToken(store, "com_oursky_skygear", "someauthinfoid", "", time.Now())
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
 func TestFileStoreEscape(t *testing.T) {
 	Convey("FileStore", t, func() {
 		tDir := tempDir()
@@ -311,6 +347,32 @@ func TestFileStoreDelete(t *testing.T) {
 	})
 }
 
+func TestFileStoreDeleteUserTokens(t *testing.T) {
+	Convey("FileStore", t, func() {
+		dir := tempDir()
+		defer os.RemoveAll(dir)
+		store := FileStore{dir, 0}
+
+		Convey("delete tokens of a user", func() {
+			tokens := []Token{
+				New("com.oursky.skygear", "alice", time.Time{}),
+				New("com.oursky.skygear", "alice", time.Time{}),
+				New("com.oursky.skygear", "bob", time.Time{}),
+			}
+			for i := range tokens {
+				So(store.Put(&tokens[i]), ShouldBeNil)
+			}
+
+			So(DeleteUserTokens(&store, "alice"), ShouldBeNil)
+
+			token := Token{}
+			So(store.Get(tokens[0].AccessToken, &token), ShouldHaveSameTypeAs, &NotFoundError{})
+			So(store.Get(tokens[1].AccessToken, &token), ShouldHaveSameTypeAs, &NotFoundError{})
+			So(store.Get(tokens[2].AccessToken, &token), ShouldBeNil)
+		})
+	})
+}
+
 func exists(path string) bool {
 	_, err := os.Stat(path)
 	return !os.IsNotExist(err)
@@ -507,6 +569,31 @@ func TestRedisStoreDelete(t *testing.T) {
 	})
 }
 
+func TestRedisStoreDeleteUserTokens(t *testing.T) {
+	Convey("RedisStore", t, func() {
+		r := tempRedisStore("")
+		defer r.clearRedisStore()
+
+		Convey("Delete tokens of a user", func() {
+			tomorrow := time.Now().AddDate(0, 0, 1).UTC()
+			tokens := []Token{
+				New("com_oursky_skygear", "alice", tomorrow),
+				New("com_oursky_skygear", "alice", time.Time{}),
+				New("com_oursky_skygear", "bob", tomorrow),
+			}
+			for i := range tokens {
+				So(r.Put(&tokens[i]), ShouldBeNil)
+			}
+
+			So(DeleteUserTokens(r, "alice"), ShouldBeNil)
+
+			result := Token{}
+			So(r.Get(tokens[0].AccessToken, &result), ShouldHaveSameTypeAs, &NotFoundError{})
+			So(r.Get(tokens[1].AccessToken, &result), ShouldHaveSameTypeAs, &NotFoundError{})
+			So(r.Get(tokens[2].AccessToken, &result), ShouldBeNil)
+		})
+	})
+}
 func TestRedisStorePrefix(t *testing.T) {
 	Convey("RedisStore with Prefix", t, func() {
 		r := tempRedisStore("testing-prefix")
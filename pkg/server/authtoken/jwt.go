@@ -19,17 +19,25 @@ import (
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
 	"github.com/skygeario/skygear-server/pkg/server/uuid"
 )
 
 // JWTStore implements TokenStore by encoding user information into
 // the access token string. This store does not keep state.
+//
+// Tokens are signed with HS256 using a shared secret, or with the
+// asymmetric keys of a jwk.KeySet. With a KeySet, services verifying the
+// tokens only need the public keys published as JWKS, and the signing
+// key can be rotated by adding a new key; tokens signed by an old key are
+// still accepted as long as the old key is kept in the KeySet.
 type JWTStore struct {
 	secret string
+	keySet *jwk.KeySet
 	expiry int64
 }
 
-// NewJWTStore creates a JWT token store.
+// NewJWTStore creates a JWT token store signing tokens with the secret.
 func NewJWTStore(secret string, expiry int64) *JWTStore {
 	if secret == "" {
 		panic("jwt store is not configured with a secret")
@@ -41,21 +49,81 @@ func NewJWTStore(secret string, expiry int64) *JWTStore {
 	return &store
 }
 
-// NewToken creates a new token for this token store.
-func (r *JWTStore) NewToken(appName string, authInfoID string) (Token, error) {
-	claims := jwt.StandardClaims{
-		Id:       uuid.New(),
-		IssuedAt: time.Now().Unix(),
-		Issuer:   appName,
-		Subject:  authInfoID,
+// NewJWTKeySetStore creates a JWT token store signing tokens with the
+// keys in the KeySet.
+//
+// If legacySecret is not empty, tokens signed with the secret by a
+// JWTStore created with NewJWTStore are also accepted, so that existing
+// tokens remain valid when migrating to signing with keys. New tokens are
+// never signed with the secret.
+func NewJWTKeySetStore(keySet *jwk.KeySet, legacySecret string, expiry int64) *JWTStore {
+	if keySet == nil {
+		panic("jwt store is not configured with a key set")
+	}
+	store := JWTStore{
+		secret: legacySecret,
+		keySet: keySet,
+		expiry: expiry,
+	}
+	return &store
+}
+
+// KeySet returns the KeySet signing the tokens, or nil if tokens are
+// signed with a secret.
+func (r *JWTStore) KeySet() *jwk.KeySet {
+	return r.keySet
+}
+
+// jwtClaims is the claims of the tokens signed by JWTStore.
+type jwtClaims struct {
+	jwt.StandardClaims
+
+	// Impersonator is the ID of the admin impersonating the subject.
+	Impersonator string `json:"imp,omitempty"`
+}
+
+func (r *JWTStore) newClaims(appName string, authInfoID string) jwtClaims {
+	claims := jwtClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:       uuid.New(),
+			IssuedAt: time.Now().Unix(),
+			Issuer:   appName,
+			Subject:  authInfoID,
+		},
 	}
 
 	if r.expiry > 0 {
 		claims.ExpiresAt = time.Now().Unix() + r.expiry
 	}
+	return claims
+}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedString, err := jwtToken.SignedString([]byte(r.secret))
+// NewToken creates a new token for this token store.
+func (r *JWTStore) NewToken(appName string, authInfoID string) (Token, error) {
+	return r.sign(r.newClaims(appName, authInfoID))
+}
+
+// NewImpersonationToken creates a new token for the admin of
+// impersonatorID to act as the user of authInfoID. The impersonator
+// and expiry are signed into the token.
+func (r *JWTStore) NewImpersonationToken(appName string, authInfoID string, impersonatorID string, expiredAt time.Time) (Token, error) {
+	claims := r.newClaims(appName, authInfoID)
+	claims.Impersonator = impersonatorID
+	if claims.ExpiresAt == 0 || expiredAt.Unix() < claims.ExpiresAt {
+		claims.ExpiresAt = expiredAt.Unix()
+	}
+	return r.sign(claims)
+}
+
+func (r *JWTStore) sign(claims jwtClaims) (Token, error) {
+	var signedString string
+	var err error
+	if r.keySet != nil {
+		signedString, err = r.keySet.Sign(claims, nil)
+	} else {
+		jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		signedString, err = jwtToken.SignedString([]byte(r.secret))
+	}
 	if err != nil {
 		return Token{}, err
 	}
@@ -69,13 +137,9 @@ func (r *JWTStore) NewToken(appName string, authInfoID string) (Token, error) {
 // Get decodes and verifies the access token for user information. It returns
 // the access token containing information about the user.
 func (r *JWTStore) Get(accessToken string, token *Token) error {
-	claims := jwt.StandardClaims{}
-	jwtToken, err := jwt.ParseWithClaims(accessToken, &claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, &NotFoundError{accessToken, errors.New("unexpected algorithm in token")}
-		}
-		return []byte(r.secret), nil
-	})
+	claims := jwtClaims{}
+	parser := jwt.Parser{ValidMethods: r.validMethods()}
+	jwtToken, err := parser.ParseWithClaims(accessToken, &claims, r.keyfunc)
 
 	if err != nil {
 		return &NotFoundError{accessToken, err}
@@ -97,7 +161,32 @@ func (r *JWTStore) Get(accessToken string, token *Token) error {
 	return nil
 }
 
-func (r *JWTStore) setTokenFromClaims(claims jwt.StandardClaims, token *Token) {
+func (r *JWTStore) validMethods() []string {
+	methods := []string{}
+	if r.keySet != nil {
+		methods = append(methods, r.keySet.SigningMethods()...)
+	}
+	if r.secret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	return methods
+}
+
+func (r *JWTStore) keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if r.secret == "" {
+			return nil, errors.New("unexpected algorithm in token")
+		}
+		return []byte(r.secret), nil
+	}
+
+	if r.keySet == nil {
+		return nil, errors.New("unexpected algorithm in token")
+	}
+	return r.keySet.Keyfunc(token)
+}
+
+func (r *JWTStore) setTokenFromClaims(claims jwtClaims, token *Token) {
 	if claims.ExpiresAt > 0 {
 		token.ExpiredAt = time.Unix(claims.ExpiresAt, 0)
 	} else {
@@ -110,6 +199,7 @@ func (r *JWTStore) setTokenFromClaims(claims jwt.StandardClaims, token *Token) {
 	}
 	token.AppName = claims.Issuer
 	token.AuthInfoID = claims.Subject
+	token.ImpersonatorID = claims.Impersonator
 }
 
 // Put does nothing because the JWT token store does not store token.
@@ -1,11 +1,16 @@
 package authtoken
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"errors"
 	"testing"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -37,6 +42,20 @@ func TestJWTStore(t *testing.T) {
 			So(claims.ExpiresAt, ShouldEqual, 0)
 		})
 
+		Convey("should create impersonation token", func() {
+			expiredAt := time.Now().Add(time.Hour)
+			token, err := NewImpersonationToken(store, "exampleapp", "userid1", "adminid", expiredAt)
+			So(err, ShouldBeNil)
+			So(token.ImpersonatorID, ShouldEqual, "adminid")
+			So(token.ExpiredAt.Unix(), ShouldEqual, expiredAt.Unix())
+
+			fetched := Token{}
+			So(store.Get(token.AccessToken, &fetched), ShouldBeNil)
+			So(fetched.AuthInfoID, ShouldEqual, "userid1")
+			So(fetched.ImpersonatorID, ShouldEqual, "adminid")
+			So(fetched.ExpiredAt.Unix(), ShouldEqual, expiredAt.Unix())
+		})
+
 		Convey("should get a token", func() {
 			issuedAt := time.Now()
 			claims := jwt.StandardClaims{
@@ -60,3 +79,84 @@ func TestJWTStore(t *testing.T) {
 		})
 	})
 }
+
+func TestJWTKeySetStore(t *testing.T) {
+	Convey("JWTStore with KeySet", t, func() {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		So(err, ShouldBeNil)
+		ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		So(err, ShouldBeNil)
+
+		oldKey := jwk.Key{ID: "2017-01", PrivateKey: rsaKey}
+		newKey := jwk.Key{ID: "2017-02", PrivateKey: ecKey}
+
+		Convey("should panic without key set", func() {
+			So(func() { NewJWTKeySetStore(nil, "", 0) }, ShouldPanic)
+		})
+
+		Convey("should sign token with kid", func() {
+			store := NewJWTKeySetStore(jwk.NewKeySet(oldKey), "", 3600)
+			token, err := store.NewToken("exampleapp", "userid1")
+			So(err, ShouldBeNil)
+
+			claims := jwt.StandardClaims{}
+			jwtToken, err := jwt.ParseWithClaims(token.AccessToken, &claims, func(token *jwt.Token) (interface{}, error) {
+				return &rsaKey.PublicKey, nil
+			})
+			So(err, ShouldBeNil)
+			So(jwtToken.Method.Alg(), ShouldEqual, "RS256")
+			So(jwtToken.Header["kid"], ShouldEqual, "2017-01")
+			So(claims.Subject, ShouldEqual, "userid1")
+
+			fetched := Token{}
+			So(store.Get(token.AccessToken, &fetched), ShouldBeNil)
+			So(fetched.AuthInfoID, ShouldEqual, "userid1")
+			So(fetched.ExpiredAt.Unix(), ShouldEqual, token.ExpiredAt.Unix())
+		})
+
+		Convey("should verify token signed by old key after rotation", func() {
+			oldStore := NewJWTKeySetStore(jwk.NewKeySet(oldKey), "", 0)
+			oldToken, err := oldStore.NewToken("exampleapp", "userid1")
+			So(err, ShouldBeNil)
+
+			store := NewJWTKeySetStore(jwk.NewKeySet(oldKey, newKey), "", 0)
+			newToken, err := store.NewToken("exampleapp", "userid2")
+			So(err, ShouldBeNil)
+
+			parsed, _ := jwt.Parse(newToken.AccessToken, nil)
+			So(parsed.Header["kid"], ShouldEqual, "2017-02")
+			So(parsed.Method.Alg(), ShouldEqual, "ES256")
+
+			token := Token{}
+			So(store.Get(oldToken.AccessToken, &token), ShouldBeNil)
+			So(token.AuthInfoID, ShouldEqual, "userid1")
+			So(store.Get(newToken.AccessToken, &token), ShouldBeNil)
+			So(token.AuthInfoID, ShouldEqual, "userid2")
+
+			Convey("and reject it after the old key is removed", func() {
+				store := NewJWTKeySetStore(jwk.NewKeySet(newKey), "", 0)
+				So(store.Get(oldToken.AccessToken, &token), ShouldNotBeNil)
+			})
+		})
+
+		Convey("should reject token signed with secret", func() {
+			secretToken, err := NewJWTStore("secret", 0).NewToken("exampleapp", "userid1")
+			So(err, ShouldBeNil)
+
+			store := NewJWTKeySetStore(jwk.NewKeySet(newKey), "", 0)
+			token := Token{}
+			So(store.Get(secretToken.AccessToken, &token), ShouldNotBeNil)
+
+			Convey("unless legacy secret is configured", func() {
+				store := NewJWTKeySetStore(jwk.NewKeySet(newKey), "secret", 0)
+				So(store.Get(secretToken.AccessToken, &token), ShouldBeNil)
+				So(token.AuthInfoID, ShouldEqual, "userid1")
+
+				newToken, err := store.NewToken("exampleapp", "userid1")
+				So(err, ShouldBeNil)
+				parsed, _ := jwt.Parse(newToken.AccessToken, nil)
+				So(parsed.Method.Alg(), ShouldEqual, "ES256")
+			})
+		})
+	})
+}
@@ -0,0 +1,369 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/skygeario/skygear-server/pkg/server/apikey"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+type apiKeyResponse struct {
+	*skydb.APIKey
+	Key string `json:"api_key,omitempty"`
+}
+
+type apiKeyCreatePayload struct {
+	Name           string   `mapstructure:"name"`
+	Scopes         []string `mapstructure:"scopes"`
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	RateLimit      int      `mapstructure:"rate_limit"`
+	ExpireAtString string   `mapstructure:"expire_at"`
+	expireAt       *time.Time
+}
+
+func (payload *apiKeyCreatePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+
+	if payload.ExpireAtString != "" {
+		expireAt, err := time.Parse(time.RFC3339, payload.ExpireAtString)
+		if err != nil {
+			return skyerr.NewInvalidArgument("expire_at must be in RFC3339 format", []string{"expire_at"})
+		}
+		expireAt = expireAt.UTC()
+		payload.expireAt = &expireAt
+	}
+
+	return payload.Validate()
+}
+
+func (payload *apiKeyCreatePayload) Validate() skyerr.Error {
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty name", []string{"name"})
+	}
+
+	if len(payload.Scopes) == 0 {
+		return skyerr.NewInvalidArgument("empty scopes", []string{"scopes"})
+	}
+
+	if payload.RateLimit < 0 {
+		return skyerr.NewInvalidArgument("rate_limit must not be negative", []string{"rate_limit"})
+	}
+
+	return nil
+}
+
+/*
+APIKeyCreateHandler creates an API key for a client application. The key
+is returned only once in the response.
+
+Scopes limit the actions the key can call: "*" for all actions, an action
+name such as "record:fetch", an action prefix such as "record:*", or a
+tag prefixed with "@" such as "@asset". rate_limit is the maximum number
+of requests per minute, 0 for unlimited.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "apikey:create",
+		"name": "Partner",
+		"scopes": ["record:fetch", "record:query"],
+		"allowed_origins": ["https://partner.example.com"],
+		"rate_limit": 600,
+		"expire_at": "2018-01-01T00:00:00Z"
+	}
+	EOF
+*/
+type APIKeyCreateHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *APIKeyCreateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *APIKeyCreateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *APIKeyCreateHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &apiKeyCreatePayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	key := skydb.APIKey{
+		ID:             uuidNew(),
+		Name:           p.Name,
+		Scopes:         p.Scopes,
+		AllowedOrigins: p.AllowedOrigins,
+		RateLimit:      p.RateLimit,
+		ExpireAt:       p.expireAt,
+		CreatedAt:      timeNow(),
+	}
+	plainKey := apikey.GenerateKey()
+	key.SetKey(plainKey)
+
+	if err := payload.DBConn.CreateAPIKey(&key); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = apiKeyResponse{
+		APIKey: &key,
+		Key:    plainKey,
+	}
+}
+
+type apiKeyRotatePayload struct {
+	ID          string `mapstructure:"id"`
+	GracePeriod int64  `mapstructure:"grace_period"`
+}
+
+func (payload *apiKeyRotatePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *apiKeyRotatePayload) Validate() skyerr.Error {
+	if payload.ID == "" {
+		return skyerr.NewInvalidArgument("empty id", []string{"id"})
+	}
+	if payload.GracePeriod < 0 {
+		return skyerr.NewInvalidArgument("grace_period must not be negative", []string{"grace_period"})
+	}
+	return nil
+}
+
+/*
+APIKeyRotateHandler replaces an API key with a new key, returned only once
+in the response. The previous key remains usable for grace_period seconds
+so that clients can be updated without downtime.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "apikey:rotate",
+		"id": "KEY_ID",
+		"grace_period": 86400
+	}
+	EOF
+*/
+type APIKeyRotateHandler struct {
+	APIKeyChecker    *apikey.Checker  `inject:"APIKeyChecker"`
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *APIKeyRotateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *APIKeyRotateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *APIKeyRotateHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &apiKeyRotatePayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	key := skydb.APIKey{}
+	if err := getAPIKey(payload.DBConn, p.ID, &key); err != nil {
+		response.Err = err
+		return
+	}
+
+	plainKey := apikey.GenerateKey()
+	key.Rotate(plainKey, timeNow(), time.Duration(p.GracePeriod)*time.Second)
+	if err := payload.DBConn.UpdateAPIKey(&key); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	h.APIKeyChecker.Invalidate()
+
+	response.Result = apiKeyResponse{
+		APIKey: &key,
+		Key:    plainKey,
+	}
+}
+
+type apiKeyIDPayload struct {
+	ID string `mapstructure:"id"`
+}
+
+func (payload *apiKeyIDPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *apiKeyIDPayload) Validate() skyerr.Error {
+	if payload.ID == "" {
+		return skyerr.NewInvalidArgument("empty id", []string{"id"})
+	}
+	return nil
+}
+
+/*
+APIKeyRevokeHandler revokes an API key. The key is kept for reference but
+cannot be used anymore, including its previous key in grace period.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "apikey:revoke",
+		"id": "KEY_ID"
+	}
+	EOF
+*/
+type APIKeyRevokeHandler struct {
+	APIKeyChecker    *apikey.Checker  `inject:"APIKeyChecker"`
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *APIKeyRevokeHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *APIKeyRevokeHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *APIKeyRevokeHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &apiKeyIDPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	key := skydb.APIKey{}
+	if err := getAPIKey(payload.DBConn, p.ID, &key); err != nil {
+		response.Err = err
+		return
+	}
+
+	if key.RevokedAt == nil {
+		now := timeNow()
+		key.RevokedAt = &now
+		if err := payload.DBConn.UpdateAPIKey(&key); err != nil {
+			response.Err = skyerr.MakeError(err)
+			return
+		}
+		h.APIKeyChecker.Invalidate()
+	}
+
+	response.Result = apiKeyResponse{
+		APIKey: &key,
+	}
+}
+
+/*
+APIKeyListHandler returns all API keys. The keys themselves are not
+returned.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "apikey:list"
+	}
+	EOF
+*/
+type APIKeyListHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *APIKeyListHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *APIKeyListHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *APIKeyListHandler) Handle(payload *router.Payload, response *router.Response) {
+	keys, err := payload.DBConn.QueryAPIKeys()
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = keys
+}
+
+func getAPIKey(conn skydb.Conn, id string, key *skydb.APIKey) skyerr.Error {
+	if err := conn.GetAPIKey(id, key); err != nil {
+		if err == skydb.ErrAPIKeyNotFound {
+			return skyerr.NewError(skyerr.ResourceNotFound, "api key not found")
+		}
+		return skyerr.MakeError(err)
+	}
+	return nil
+}
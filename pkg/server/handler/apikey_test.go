@@ -0,0 +1,207 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/apikey"
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+)
+
+func TestAPIKeyCreateHandler(t *testing.T) {
+	Convey("APIKeyCreateHandler", t, func() {
+		realUUIDNew := uuidNew
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		uuidNew = func() string { return "key-id" }
+		timeNow = func() time.Time { return now }
+		defer func() {
+			uuidNew = realUUIDNew
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		r := handlertest.NewSingleRouteRouter(&APIKeyCreateHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("creates key and returns it once", func() {
+			resp := r.POST(`{
+				"name": "Partner",
+				"scopes": ["record:fetch", "@asset"],
+				"allowed_origins": ["https://partner.example.com"],
+				"rate_limit": 600,
+				"expire_at": "2018-01-01T00:00:00Z"
+			}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			body := struct {
+				Result map[string]interface{} `json:"result"`
+			}{}
+			So(json.Unmarshal(resp.Body.Bytes(), &body), ShouldBeNil)
+			plainKey, _ := body.Result["api_key"].(string)
+			So(plainKey, ShouldNotBeEmpty)
+			So(body.Result["id"], ShouldEqual, "key-id")
+			So(body.Result["rate_limit"], ShouldEqual, float64(600))
+
+			key := skydb.APIKey{}
+			So(conn.GetAPIKey("key-id", &key), ShouldBeNil)
+			So(key.Name, ShouldEqual, "Partner")
+			So(key.HashedKey, ShouldEqual, skydb.HashAPIKey(plainKey))
+			So(key.Scopes, ShouldResemble, []string{"record:fetch", "@asset"})
+			So(key.AllowedOrigins, ShouldResemble, []string{"https://partner.example.com"})
+			So(*key.ExpireAt, ShouldResemble, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+			So(key.CreatedAt, ShouldResemble, now)
+		})
+
+		Convey("rejects key without scopes", func() {
+			resp := r.POST(`{"name": "Partner"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "empty scopes",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["scopes"]}
+				}
+			}`)
+		})
+
+		Convey("rejects malformed expire_at", func() {
+			resp := r.POST(`{"name": "Partner", "scopes": ["*"], "expire_at": "tomorrow"}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+	})
+}
+
+func TestAPIKeyRotateHandler(t *testing.T) {
+	Convey("APIKeyRotateHandler", t, func() {
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		key := skydb.APIKey{
+			ID:     "key-id",
+			Name:   "Partner",
+			Scopes: []string{"*"},
+		}
+		key.SetKey("old-key")
+		So(conn.CreateAPIKey(&key), ShouldBeNil)
+
+		r := handlertest.NewSingleRouteRouter(&APIKeyRotateHandler{
+			APIKeyChecker: apikey.NewChecker(nil, time.Minute),
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("rotates key with grace period", func() {
+			resp := r.POST(`{"id": "key-id", "grace_period": 3600}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(resp.Body.String(), ShouldContainSubstring, `"api_key"`)
+
+			updated := skydb.APIKey{}
+			So(conn.GetAPIKey("key-id", &updated), ShouldBeNil)
+			So(updated.HashedKey, ShouldNotEqual, skydb.HashAPIKey("old-key"))
+			So(updated.PreviousHashedKey, ShouldEqual, skydb.HashAPIKey("old-key"))
+			So(*updated.PreviousKeyExpireAt, ShouldResemble, now.Add(time.Hour))
+			So(updated.IsValidAt(skydb.HashAPIKey("old-key"), now), ShouldBeTrue)
+		})
+
+		Convey("returns not found for unknown key", func() {
+			resp := r.POST(`{"id": "not-exist"}`)
+			So(resp.Code, ShouldEqual, 404)
+		})
+	})
+}
+
+func TestAPIKeyRevokeHandler(t *testing.T) {
+	Convey("APIKeyRevokeHandler", t, func() {
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		key := skydb.APIKey{
+			ID:     "key-id",
+			Name:   "Partner",
+			Scopes: []string{"*"},
+		}
+		key.SetKey("some-key")
+		So(conn.CreateAPIKey(&key), ShouldBeNil)
+
+		r := handlertest.NewSingleRouteRouter(&APIKeyRevokeHandler{
+			APIKeyChecker: apikey.NewChecker(nil, time.Minute),
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("revokes key", func() {
+			resp := r.POST(`{"id": "key-id"}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(resp.Body.String(), ShouldNotContainSubstring, `"api_key"`)
+
+			updated := skydb.APIKey{}
+			So(conn.GetAPIKey("key-id", &updated), ShouldBeNil)
+			So(*updated.RevokedAt, ShouldResemble, now)
+			So(updated.IsValidAt(skydb.HashAPIKey("some-key"), now), ShouldBeFalse)
+		})
+	})
+}
+
+func TestAPIKeyListHandler(t *testing.T) {
+	Convey("APIKeyListHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		key := skydb.APIKey{
+			ID:     "key-id",
+			Name:   "Partner",
+			Scopes: []string{"record:*"},
+		}
+		key.SetKey("some-key")
+		So(conn.CreateAPIKey(&key), ShouldBeNil)
+
+		r := handlertest.NewSingleRouteRouter(&APIKeyListHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("lists keys without hashes", func() {
+			resp := r.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"id": "key-id",
+					"name": "Partner",
+					"scopes": ["record:*"],
+					"allowed_origins": null,
+					"rate_limit": 0,
+					"created_at": "0001-01-01T00:00:00Z"
+				}]
+			}`)
+		})
+	})
+}
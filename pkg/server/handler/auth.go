@@ -327,15 +327,16 @@ curl -X POST -H "Content-Type: application/json" \
 EOF
 */
 type LoginHandler struct {
-	TokenStore       authtoken.Store    `inject:"TokenStore"`
-	ProviderRegistry *provider.Registry `inject:"ProviderRegistry"`
-	HookRegistry     *hook.Registry     `inject:"HookRegistry"`
-	AssetStore       asset.Store        `inject:"AssetStore"`
-	AuthRecordKeys   [][]string         `inject:"AuthRecordKeys"`
-	AccessKey        router.Processor   `preprocessor:"accesskey"`
-	DBConn           router.Processor   `preprocessor:"dbconn"`
-	InjectPublicDB   router.Processor   `preprocessor:"inject_public_db"`
-	PluginReady      router.Processor   `preprocessor:"plugin_ready"`
+	TokenStore       authtoken.Store       `inject:"TokenStore"`
+	ProviderRegistry *provider.Registry    `inject:"ProviderRegistry"`
+	HookRegistry     *hook.Registry        `inject:"HookRegistry"`
+	AssetStore       asset.Store           `inject:"AssetStore"`
+	AuthRecordKeys   [][]string            `inject:"AuthRecordKeys"`
+	LoginThrottler   *audit.LoginThrottler `inject:"LoginThrottler"`
+	AccessKey        router.Processor      `preprocessor:"accesskey"`
+	DBConn           router.Processor      `preprocessor:"dbconn"`
+	InjectPublicDB   router.Processor      `preprocessor:"inject_public_db"`
+	PluginReady      router.Processor      `preprocessor:"plugin_ready"`
 	preprocessors    []router.Processor
 }
 
@@ -383,6 +384,12 @@ func (h *LoginHandler) Handle(payload *router.Payload, response *router.Response
 	}
 	store := h.TokenStore
 
+	clientIP := h.LoginThrottler.ClientIP(payload)
+	if skyErr = h.LoginThrottler.CheckIP(clientIP); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
 	user := skydb.Record{}
 
 	var handleLoginFunc func(*router.Payload, *loginPayload, *skydb.AuthInfo, *skydb.Record) skyerr.Error
@@ -393,10 +400,26 @@ func (h *LoginHandler) Handle(payload *router.Payload, response *router.Response
 	}
 
 	if skyErr = handleLoginFunc(payload, p, &info, &user); skyErr != nil {
+		response.Err = h.recordLoginFailure(payload, info.ID, clientIP, skyErr)
+		return
+	}
+
+	// The user record is saved below with the last login time, so changes
+	// made by the hooks are saved along with it.
+	hookCtx := authHookContext{
+		HookRegistry: h.HookRegistry,
+		Context:      payload.Context(),
+	}
+	if skyErr = hookCtx.execute(hook.BeforeLogin, &info, &user); skyErr != nil {
 		response.Err = skyErr
 		return
 	}
 
+	if err := h.LoginThrottler.RecordSuccess(info.ID); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
 	// generate access-token
 	token, err := store.NewToken(payload.AppName, info.ID)
 	if err != nil {
@@ -407,6 +430,8 @@ func (h *LoginHandler) Handle(payload *router.Payload, response *router.Response
 		panic(err)
 	}
 
+	hookCtx.executeAfter(hook.AfterLogin, &info, &user)
+
 	authResponse, err := AuthResponseFactory{
 		AssetStore: h.AssetStore,
 		Conn:       payload.DBConn,
@@ -503,6 +528,10 @@ func (h *LoginHandler) handleLoginWithAuthData(payload *router.Payload, p *login
 		return err
 	}
 
+	if err := h.LoginThrottler.CheckAccount(fetchedAuthInfo.ID); err != nil {
+		return err
+	}
+
 	*authinfo = fetchedAuthInfo
 	*user = fetchedUser
 
@@ -513,6 +542,36 @@ func (h *LoginHandler) handleLoginWithAuthData(payload *router.Payload, p *login
 	return nil
 }
 
+// recordLoginFailure counts the failed attempt towards login throttling
+// if the failure is caused by invalid credentials. The returned error is
+// the error to be returned to the client.
+func (h *LoginHandler) recordLoginFailure(payload *router.Payload, authID string, clientIP string, loginErr skyerr.Error) skyerr.Error {
+	switch loginErr.Code() {
+	case skyerr.InvalidCredentials:
+		// The failure counts towards both the account and the client
+		// address.
+	case skyerr.ResourceNotFound:
+		// The user does not exist, so the failure only counts towards
+		// the client address.
+		authID = ""
+	default:
+		return loginErr
+	}
+
+	accountLocked, throttledErr := h.LoginThrottler.RecordFailure(authID, clientIP)
+	if accountLocked {
+		audit.Trail(audit.Entry{
+			AuthID: authID,
+			Event:  audit.EventLockUser,
+		}.WithRouterPayload(payload))
+	}
+
+	if throttledErr != nil {
+		return throttledErr
+	}
+	return loginErr
+}
+
 func (h *LoginHandler) authPrincipal(ctx context.Context, p *loginPayload) (string, map[string]interface{}, skyerr.Error) {
 	logger := logging.CreateLogger(ctx, "handler")
 	logger.Debugf(`Client requested auth provider: "%v".`, p.Provider)
@@ -532,15 +591,22 @@ func (h *LoginHandler) authPrincipal(ctx context.Context, p *loginPayload) (stri
 
 // LogoutHandler receives an access token and invalidates it
 type LogoutHandler struct {
-	TokenStore    authtoken.Store  `inject:"TokenStore"`
-	Authenticator router.Processor `preprocessor:"authenticator"`
-	PluginReady   router.Processor `preprocessor:"plugin_ready"`
-	preprocessors []router.Processor
+	TokenStore     authtoken.Store  `inject:"TokenStore"`
+	HookRegistry   *hook.Registry   `inject:"HookRegistry"`
+	Authenticator  router.Processor `preprocessor:"authenticator"`
+	DBConn         router.Processor `preprocessor:"dbconn"`
+	InjectPublicDB router.Processor `preprocessor:"inject_public_db"`
+	InjectAuth     router.Processor `preprocessor:"inject_auth"`
+	PluginReady    router.Processor `preprocessor:"plugin_ready"`
+	preprocessors  []router.Processor
 }
 
 func (h *LogoutHandler) Setup() {
 	h.preprocessors = []router.Processor{
 		h.Authenticator,
+		h.DBConn,
+		h.InjectPublicDB,
+		h.InjectAuth,
 		h.PluginReady,
 	}
 }
@@ -553,6 +619,21 @@ func (h *LogoutHandler) Handle(payload *router.Payload, response *router.Respons
 	store := h.TokenStore
 	accessToken := payload.AccessTokenString()
 
+	hookCtx := authHookContext{
+		HookRegistry: h.HookRegistry,
+		Database:     payload.Database,
+		Context:      payload.Context(),
+	}
+	info := payload.AuthInfo
+	var user *skydb.Record
+	if info != nil {
+		user = hookCtx.fetchUser(info.ID)
+		if skyErr := hookCtx.execute(hook.BeforeLogout, info, user); skyErr != nil {
+			response.Err = skyErr
+			return
+		}
+	}
+
 	var err error
 
 	if err = store.Delete(accessToken); err != nil {
@@ -563,6 +644,9 @@ func (h *LogoutHandler) Handle(payload *router.Payload, response *router.Respons
 	if err != nil {
 		response.Err = skyerr.MakeError(err)
 	} else {
+		if info != nil {
+			hookCtx.executeAfter(hook.AfterLogout, info, user)
+		}
 		response.Result = struct {
 			Status string `json:"status,omitempty"`
 		}{
@@ -629,6 +713,7 @@ func (payload *changePasswordPayload) Validate() skyerr.Error {
 type ChangePasswordHandler struct {
 	TokenStore      authtoken.Store        `inject:"TokenStore"`
 	AssetStore      asset.Store            `inject:"AssetStore"`
+	HookRegistry    *hook.Registry         `inject:"HookRegistry"`
 	PasswordChecker *audit.PasswordChecker `inject:"PasswordChecker"`
 	PwHousekeeper   *audit.PwHousekeeper   `inject:"PwHousekeeper"`
 	Authenticator   router.Processor       `preprocessor:"authenticator"`
@@ -688,12 +773,24 @@ func (h *ChangePasswordHandler) Handle(payload *router.Payload, response *router
 		return
 	}
 
+	hookCtx := authHookContext{
+		HookRegistry: h.HookRegistry,
+		Database:     payload.Database,
+		Context:      payload.Context(),
+	}
+	if skyErr = hookCtx.execute(hook.BeforePasswordChange, info, payload.User); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
 	info.SetPassword(p.NewPassword)
 	if err := payload.DBConn.UpdateAuth(info); err != nil {
 		response.Err = skyerr.MakeError(err)
 		return
 	}
 
+	hookCtx.executeAfter(hook.AfterPasswordChange, info, payload.User)
+
 	if p.Invalidate {
 		logger.Warningf("Invalidate is not yet implement")
 		// TODO: invalidate all existing token and generate a new one for response
@@ -24,6 +24,7 @@ import (
 	"github.com/skygeario/skygear-server/pkg/server/audit"
 	"github.com/skygeario/skygear-server/pkg/server/authtoken"
 	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
 	"github.com/skygeario/skygear-server/pkg/server/skyerr"
@@ -88,14 +89,16 @@ func (payload *setDisableUserPayload) Validate() skyerr.Error {
 // Response:
 // * success response
 type SetDisableUserHandler struct {
-	TokenStore    authtoken.Store  `inject:"TokenStore"`
-	AssetStore    asset.Store      `inject:"AssetStore"`
-	Authenticator router.Processor `preprocessor:"authenticator"`
-	DBConn        router.Processor `preprocessor:"dbconn"`
-	InjectAuth    router.Processor `preprocessor:"inject_auth"`
-	RequireAdmin  router.Processor `preprocessor:"require_admin"`
-	PluginReady   router.Processor `preprocessor:"plugin_ready"`
-	preprocessors []router.Processor
+	TokenStore     authtoken.Store  `inject:"TokenStore"`
+	AssetStore     asset.Store      `inject:"AssetStore"`
+	HookRegistry   *hook.Registry   `inject:"HookRegistry"`
+	Authenticator  router.Processor `preprocessor:"authenticator"`
+	DBConn         router.Processor `preprocessor:"dbconn"`
+	InjectAuth     router.Processor `preprocessor:"inject_auth"`
+	InjectPublicDB router.Processor `preprocessor:"inject_public_db"`
+	RequireAdmin   router.Processor `preprocessor:"require_admin"`
+	PluginReady    router.Processor `preprocessor:"plugin_ready"`
+	preprocessors  []router.Processor
 }
 
 func (h *SetDisableUserHandler) Setup() {
@@ -103,6 +106,7 @@ func (h *SetDisableUserHandler) Setup() {
 		h.Authenticator,
 		h.DBConn,
 		h.InjectAuth,
+		h.InjectPublicDB,
 		h.RequireAdmin,
 		h.PluginReady,
 	}
@@ -148,12 +152,32 @@ func (h *SetDisableUserHandler) Handle(payload *router.Payload, response *router
 		"expiry":   authinfo.DisabledExpiry,
 	}).Debug("Will set disabled user status")
 
+	// Hooks are only executed when the user is disabled; they receive the
+	// auth info with the disabled status to be saved.
+	hookCtx := authHookContext{
+		HookRegistry: h.HookRegistry,
+		Database:     payload.Database,
+		Context:      payload.Context(),
+	}
+	var user *skydb.Record
+	if p.Disabled {
+		user = hookCtx.fetchUser(authinfo.ID)
+		if skyErr := hookCtx.execute(hook.BeforeUserDisable, &authinfo, user); skyErr != nil {
+			response.Err = skyErr
+			return
+		}
+	}
+
 	if err := payload.DBConn.UpdateAuth(&authinfo); err != nil {
 		logger.WithError(err).Error("Unable to update auth info when setting disabled user status")
 		response.Err = skyerr.MakeError(err)
 		return
 	}
 
+	if p.Disabled {
+		hookCtx.executeAfter(hook.AfterUserDisable, &authinfo, user)
+	}
+
 	logger.Info("Successfully set disabled user status")
 
 	h.logAuditTrail(payload, p)
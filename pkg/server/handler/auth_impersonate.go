@@ -0,0 +1,221 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+
+	"github.com/skygeario/skygear-server/pkg/server/audit"
+	"github.com/skygeario/skygear-server/pkg/server/authtoken"
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+const (
+	// defaultImpersonationExpiry is the lifetime of an impersonation
+	// token if expires_in is not specified.
+	defaultImpersonationExpiry = 15 * time.Minute
+
+	// maxImpersonationExpiry is the longest lifetime of an impersonation
+	// token.
+	maxImpersonationExpiry = time.Hour
+
+	// masterKeyImpersonatorID is recorded as the impersonator if the
+	// request is made with the master key without a user.
+	masterKeyImpersonatorID = "$master_key"
+)
+
+// Define the playload that impersonate user handler will process
+type impersonateUserPayload struct {
+	AuthInfoID string `mapstructure:"auth_id"`
+	ExpiresIn  int    `mapstructure:"expires_in"`
+}
+
+func (payload *impersonateUserPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *impersonateUserPayload) Validate() skyerr.Error {
+	if payload.AuthInfoID == "" {
+		return skyerr.NewInvalidArgument("empty auth_id", []string{"auth_id"})
+	}
+	if payload.ExpiresIn < 0 || time.Duration(payload.ExpiresIn)*time.Second > maxImpersonationExpiry {
+		return skyerr.NewInvalidArgument("expires_in must be between 0 and 3600 seconds", []string{"expires_in"})
+	}
+	return nil
+}
+
+func (payload *impersonateUserPayload) expiry() time.Duration {
+	if payload.ExpiresIn == 0 {
+		return defaultImpersonationExpiry
+	}
+	return time.Duration(payload.ExpiresIn) * time.Second
+}
+
+type impersonateUserResponse struct {
+	UserID         string    `json:"user_id"`
+	ImpersonatorID string    `json:"impersonator_id"`
+	AccessToken    string    `json:"access_token"`
+	ExpiredAt      time.Time `json:"expired_at"`
+}
+
+// ImpersonateUserHandler issues an access token for an admin to act as
+// the specified user
+//
+// Requests made with the access token run with the ACL of the user, even
+// if the master key is specified. Responses of these requests carry the
+// X-Skygear-Impersonated-By header, and each request is written to the
+// audit trail with both the admin and the user ID.
+//
+// ImpersonateUserHandler receives these parameters:
+//
+// * auth_id (string, required)
+// * expires_in (integer, optional): lifetime of the token in seconds,
+//   defaults to 900 and at most 3600
+//
+// Current implementation:
+//
+// ```
+// curl -X POST -H "Content-Type: application/json" \
+//   -d @- http://localhost:3000/ <<EOF
+// {
+//     "action": "auth:impersonate",
+//     "auth_id": "77FA8BCF-CD6C-4A22-A170-CECC2667654F",
+//     "expires_in": 600
+// }
+// EOF
+// ```
+//
+// Response:
+//
+// ```
+// {
+//     "result": {
+//         "user_id": "77FA8BCF-CD6C-4A22-A170-CECC2667654F",
+//         "impersonator_id": "admin",
+//         "access_token": "...",
+//         "expired_at": "2017-07-01T00:10:00Z"
+//     }
+// }
+// ```
+type ImpersonateUserHandler struct {
+	TokenStore    authtoken.Store  `inject:"TokenStore"`
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"inject_auth"`
+	RequireAdmin  router.Processor `preprocessor:"require_admin"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *ImpersonateUserHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.RequireAdmin,
+		h.PluginReady,
+	}
+}
+
+func (h *ImpersonateUserHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *ImpersonateUserHandler) Handle(payload *router.Payload, response *router.Response) {
+	logger := logging.CreateLogger(payload.Context(), "handler")
+	p := &impersonateUserPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	// An impersonated admin must not issue tokens of other users.
+	if payload.ImpersonatorID != "" {
+		response.Err = skyerr.NewError(skyerr.PermissionDenied, "cannot impersonate while impersonating")
+		return
+	}
+
+	impersonatorID := payload.AuthInfoID
+	if impersonatorID == "" {
+		impersonatorID = masterKeyImpersonatorID
+	}
+	if impersonatorID == p.AuthInfoID {
+		response.Err = skyerr.NewInvalidArgument("cannot impersonate oneself", []string{"auth_id"})
+		return
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"auth_id":         p.AuthInfoID,
+		"impersonator_id": impersonatorID,
+	})
+	logger.Debug("Handler called to impersonate user")
+
+	authinfo := skydb.AuthInfo{}
+	if err := payload.DBConn.GetAuth(p.AuthInfoID, &authinfo); err != nil {
+		if err == skydb.ErrUserNotFound {
+			logger.Info("Auth info not found when impersonating user")
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "User not found")
+			return
+		}
+		logger.WithError(err).Error("Unable to get auth info when impersonating user")
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	token, err := authtoken.NewImpersonationToken(
+		h.TokenStore,
+		payload.AppName,
+		authinfo.ID,
+		impersonatorID,
+		timeNow().Add(p.expiry()),
+	)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	if err := h.TokenStore.Put(&token); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	logger.Info("Issued impersonation token")
+
+	audit.Trail(audit.Entry{
+		Event:  audit.EventImpersonate,
+		Admin:  true,
+		AuthID: authinfo.ID,
+		Data: map[string]interface{}{
+			"impersonator_id": impersonatorID,
+			"expired_at":      token.ExpiredAt,
+		},
+	}.WithRouterPayload(payload))
+
+	response.Result = impersonateUserResponse{
+		UserID:         authinfo.ID,
+		ImpersonatorID: impersonatorID,
+		AccessToken:    token.AccessToken,
+		ExpiredAt:      token.ExpiredAt.UTC(),
+	}
+}
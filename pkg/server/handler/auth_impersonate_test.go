@@ -0,0 +1,109 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/authtoken/authtokentest"
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestImpersonateUserHandler(t *testing.T) {
+	now := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+	realTimeNow := timeNow
+	timeNow = func() time.Time { return now }
+	defer func() {
+		timeNow = realTimeNow
+	}()
+
+	Convey("ImpersonateUserHandler", t, func() {
+		conn := singleUserConn{}
+		authInfo := skydb.NewAuthInfo("chima")
+		authInfo.ID = "chima"
+		conn.CreateAuth(&authInfo)
+
+		tokenStore := authtokentest.SingleTokenStore{}
+		impersonatorID := "admin"
+		impersonating := ""
+		r := handlertest.NewSingleRouteRouter(&ImpersonateUserHandler{
+			TokenStore: &tokenStore,
+		}, func(p *router.Payload) {
+			p.DBConn = &conn
+			p.AuthInfoID = impersonatorID
+			p.ImpersonatorID = impersonating
+		})
+
+		Convey("should issue an impersonation token", func() {
+			resp := r.POST(`{"auth_id": "chima", "expires_in": 600}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			token := tokenStore.Token
+			So(token, ShouldNotBeNil)
+			So(token.AuthInfoID, ShouldEqual, "chima")
+			So(token.ImpersonatorID, ShouldEqual, "admin")
+			So(token.ExpiredAt, ShouldResemble, now.Add(10*time.Minute))
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"user_id": "chima",
+					"impersonator_id": "admin",
+					"access_token": "`+token.AccessToken+`",
+					"expired_at": "2017-07-01T00:10:00Z"
+				}
+			}`)
+		})
+
+		Convey("should default to 15 minutes", func() {
+			r.POST(`{"auth_id": "chima"}`)
+			So(tokenStore.Token.ExpiredAt, ShouldResemble, now.Add(15*time.Minute))
+		})
+
+		Convey("should record master key as impersonator", func() {
+			impersonatorID = ""
+			r.POST(`{"auth_id": "chima"}`)
+			So(tokenStore.Token.ImpersonatorID, ShouldEqual, "$master_key")
+		})
+
+		Convey("should reject impersonation while impersonating", func() {
+			impersonating = "another-admin"
+			resp := r.POST(`{"auth_id": "chima"}`)
+			So(resp.Code, ShouldEqual, 403)
+			So(tokenStore.Token, ShouldBeNil)
+		})
+
+		Convey("should reject impersonating oneself", func() {
+			impersonatorID = "chima"
+			resp := r.POST(`{"auth_id": "chima"}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+
+		Convey("should reject expiry longer than an hour", func() {
+			resp := r.POST(`{"auth_id": "chima", "expires_in": 7200}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+
+		Convey("should reject non-existent user", func() {
+			conn.authinfo = nil
+			resp := r.POST(`{"auth_id": "nobody"}`)
+			So(resp.Code, ShouldEqual, 404)
+			So(tokenStore.Token, ShouldBeNil)
+		})
+	})
+}
@@ -15,6 +15,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,6 +29,7 @@ import (
 	"github.com/skygeario/skygear-server/pkg/server/authtoken"
 	"github.com/skygeario/skygear-server/pkg/server/authtoken/authtokentest"
 	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
 	"github.com/skygeario/skygear-server/pkg/server/plugin/provider"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
@@ -454,6 +456,79 @@ func TestSignupHandler(t *testing.T) {
 			errorResponse := resp.Err.(skyerr.Error)
 			So(errorResponse.Code(), ShouldEqual, skyerr.Duplicated)
 		})
+
+		Convey("sign up with profile set by beforeSignup hook", func() {
+			txBegin := db.EXPECT().Begin().AnyTimes()
+			db.EXPECT().Commit().After(txBegin)
+
+			skydbtest.ExpectDBSaveUser(db, &skydb.RecordSchema{
+				"username": skydb.FieldType{Type: skydb.TypeString},
+				"email":    skydb.FieldType{Type: skydb.TypeString},
+				"nickname": skydb.FieldType{Type: skydb.TypeString},
+			}, MakeUserRecordAssertion(skydb.NewAuthData(map[string]interface{}{
+				"username": "john.doe",
+				"email":    "john.doe@example.com",
+				"nickname": "john",
+			}, authRecordKeys)), nil)
+
+			afterSignupUserID := ""
+			registry := hook.NewRegistry()
+			registry.RegisterAuthHook(hook.BeforeSignup, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				user.Data["nickname"] = "john"
+				return nil
+			})
+			registry.RegisterAuthHook(hook.AfterSignup, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				afterSignupUserID = user.ID.Key
+				return nil
+			})
+			handler.HookRegistry = registry
+
+			req := router.Payload{
+				Data: map[string]interface{}{
+					"auth_data": map[string]interface{}{
+						"username": "john.doe",
+						"email":    "john.doe@example.com",
+					},
+					"password": "secret",
+				},
+				DBConn:   conn,
+				Database: db,
+			}
+			resp := router.Response{}
+			handler.Handle(&req, &resp)
+
+			So(resp.Err, ShouldBeNil)
+			authResp := resp.Result.(AuthResponse)
+			So(authResp.Profile.Data["nickname"], ShouldEqual, "john")
+			So(afterSignupUserID, ShouldEqual, authResp.UserID)
+		})
+
+		Convey("sign up rejected by beforeSignup hook", func() {
+			db.EXPECT().UserRecordType().Return("user").AnyTimes()
+
+			registry := hook.NewRegistry()
+			registry.RegisterAuthHook(hook.BeforeSignup, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				return skyerr.NewError(skyerr.PermissionDenied, "signup is closed")
+			})
+			handler.HookRegistry = registry
+
+			req := router.Payload{
+				Data: map[string]interface{}{
+					"auth_data": map[string]interface{}{
+						"username": "john.doe",
+						"email":    "john.doe@example.com",
+					},
+					"password": "secret",
+				},
+				DBConn:   conn,
+				Database: db,
+			}
+			resp := router.Response{}
+			handler.Handle(&req, &resp)
+
+			So(resp.Err, ShouldResemble, skyerr.NewError(skyerr.PermissionDenied, "signup is closed"))
+			So(tokenStore.Token, ShouldBeNil)
+		})
 	})
 }
 
@@ -566,6 +641,46 @@ func TestLoginHandler(t *testing.T) {
 			So(errorResponse.Code(), ShouldEqual, skyerr.InvalidArgument)
 		})
 
+		Convey("login user rejected by beforeLogin hook", func() {
+			authinfo := skydb.NewAuthInfo("secret")
+			conn.CreateAuth(&authinfo)
+
+			db.EXPECT().
+				Query(gomock.Any(), gomock.Any()).
+				Do(MakeUsernameEmailQueryAssertion("john.doe", "")).
+				Return(skydb.NewRows(skydb.NewMemoryRows([]skydb.Record{skydb.Record{
+					ID:   skydb.NewRecordID("user", authinfo.ID),
+					Data: map[string]interface{}{"username": "john.doe", "email": "john.doe@example.com", "banned": true},
+				}})), nil).
+				AnyTimes()
+
+			registry := hook.NewRegistry()
+			registry.RegisterAuthHook(hook.BeforeLogin, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				So(authInfo.ID, ShouldEqual, authinfo.ID)
+				if user.Data["banned"] == true {
+					return skyerr.NewError(skyerr.PermissionDenied, "user is banned")
+				}
+				return nil
+			})
+			handler.HookRegistry = registry
+
+			req := router.Payload{
+				Data: map[string]interface{}{
+					"auth_data": map[string]interface{}{
+						"username": "john.doe",
+					},
+					"password": "secret",
+				},
+				DBConn:   conn,
+				Database: db,
+			}
+			resp := router.Response{}
+			handler.Handle(&req, &resp)
+
+			So(resp.Err, ShouldResemble, skyerr.NewError(skyerr.PermissionDenied, "user is banned"))
+			So(tokenStore.Token, ShouldBeNil)
+		})
+
 		Convey("login user wrong password", func() {
 			authinfo := skydb.NewAuthInfo("secret")
 			conn.CreateAuth(&authinfo)
@@ -686,6 +801,63 @@ func TestLoginHandler(t *testing.T) {
 			So(resp.Err, ShouldBeNil)
 			So(resp.Result, ShouldHaveSameTypeAs, AuthResponse{})
 		})
+
+		Convey("login user locked after too many failed attempts", func() {
+			handler.LoginThrottler = &audit.LoginThrottler{
+				Store:              audit.NewMemoryLoginAttemptStore(),
+				AccountMaxAttempts: 2,
+				Window:             time.Hour,
+				LockoutDuration:    time.Minute,
+				MaxLockoutDuration: time.Hour,
+			}
+			defer func() {
+				handler.LoginThrottler = nil
+			}()
+
+			authinfo := skydb.NewAuthInfo("secret")
+			conn.CreateAuth(&authinfo)
+
+			login := func(password string) *router.Response {
+				// Rows are consumed by the query, so each attempt needs
+				// its own.
+				db.EXPECT().
+					Query(gomock.Any(), gomock.Any()).
+					Do(MakeUsernameEmailQueryAssertion("john.doe", "")).
+					Return(skydb.NewRows(skydb.NewMemoryRows([]skydb.Record{skydb.Record{
+						ID:   skydb.NewRecordID("user", authinfo.ID),
+						Data: map[string]interface{}{"username": "john.doe"},
+					}})), nil)
+
+				req := router.Payload{
+					Data: map[string]interface{}{
+						"auth_data": map[string]interface{}{
+							"username": "john.doe",
+						},
+						"password": password,
+					},
+					DBConn:   conn,
+					Database: db,
+				}
+				resp := &router.Response{}
+				handler.Handle(&req, resp)
+				return resp
+			}
+
+			resp := login("wrongsecret")
+			So(resp.Err.Code(), ShouldEqual, skyerr.InvalidCredentials)
+
+			resp = login("wrongsecret")
+			So(resp.Err.Code(), ShouldEqual, skyerr.LoginThrottled)
+			So(resp.Err.Info()["retry_after"], ShouldEqual, int64(60))
+
+			resp = login("secret")
+			So(resp.Err.Code(), ShouldEqual, skyerr.LoginThrottled)
+
+			So(handler.LoginThrottler.Unlock(authinfo.ID), ShouldBeNil)
+			resp = login("secret")
+			So(resp.Err, ShouldBeNil)
+			So(resp.Result, ShouldHaveSameTypeAs, AuthResponse{})
+		})
 	})
 }
 
@@ -931,6 +1103,10 @@ func (conn *singleUserConn) EnsureAuthRecordKeysValid(authRecordKeys [][]string)
 	return nil
 }
 
+func (conn *singleUserConn) GetRecordPredicateAccess(recordType string) (skydb.RecordPredicateAccess, error) {
+	return skydb.RecordPredicateAccess{}, nil
+}
+
 func TestSignupHandlerAsAnonymous(t *testing.T) {
 	Convey("SignupHandler", t, func() {
 		realTime := timeNow
@@ -1184,6 +1360,7 @@ func TestSignupHandlerWithUserAuditor(t *testing.T) {
 
 type deleteTokenStore struct {
 	deletedAccessToken string
+	deletedAuthInfoID  string
 	errToReturn        error
 }
 
@@ -1204,6 +1381,11 @@ func (store *deleteTokenStore) Delete(accessToken string) error {
 	return store.errToReturn
 }
 
+func (store *deleteTokenStore) DeleteUserTokens(authInfoID string) error {
+	store.deletedAuthInfoID = authInfoID
+	return store.errToReturn
+}
+
 func TestLogoutHandler(t *testing.T) {
 	Convey("LogoutHandler", t, func() {
 		tokenStore := &deleteTokenStore{}
@@ -1247,6 +1429,60 @@ func TestLogoutHandler(t *testing.T) {
 			So(resp.Code, ShouldEqual, 500)
 		})
 	})
+
+	Convey("LogoutHandler with auth hooks", t, func() {
+		tokenStore := &deleteTokenStore{}
+		conn := skydbtest.NewMapConn()
+		db := skydbtest.NewMapDB()
+		db.Save(&skydb.Record{
+			ID:   skydb.NewRecordID("user", "user0"),
+			Data: skydb.Data{"username": "john.doe"},
+		})
+
+		registry := hook.NewRegistry()
+		r := handlertest.NewSingleRouteRouter(&LogoutHandler{
+			TokenStore:   tokenStore,
+			HookRegistry: registry,
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.Database = db
+			p.AuthInfo = &skydb.AuthInfo{ID: "user0"}
+		})
+
+		Convey("saves user modified by hooks", func() {
+			afterLogoutCalled := false
+			var hookedUsername interface{}
+			registry.RegisterAuthHook(hook.BeforeLogout, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				hookedUsername = user.Data["username"]
+				user.Data["online"] = false
+				return nil
+			})
+			registry.RegisterAuthHook(hook.AfterLogout, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				afterLogoutCalled = true
+				return skyerr.NewError(skyerr.UnexpectedError, "ignored")
+			})
+
+			resp := r.POST(`{"access_token": "someaccesstoken"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{"result":{"status":"OK"}}`)
+			So(tokenStore.deletedAccessToken, ShouldEqual, "someaccesstoken")
+			So(afterLogoutCalled, ShouldBeTrue)
+			So(hookedUsername, ShouldEqual, "john.doe")
+
+			user := skydb.Record{}
+			So(db.Get(skydb.NewRecordID("user", "user0"), &user), ShouldBeNil)
+			So(user.Data["online"], ShouldEqual, false)
+		})
+
+		Convey("does not logout if rejected by hooks", func() {
+			registry.RegisterAuthHook(hook.BeforeLogout, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				return skyerr.NewError(skyerr.PermissionDenied, "logout disallowed")
+			})
+
+			resp := r.POST(`{"access_token": "someaccesstoken"}`)
+			So(tokenStore.deletedAccessToken, ShouldEqual, "")
+			So(resp.Code, ShouldEqual, 403)
+		})
+	})
 }
 
 func TestChangePasswordHandlerWithProvider(t *testing.T) {
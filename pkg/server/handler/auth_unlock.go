@@ -0,0 +1,137 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+
+	"github.com/skygeario/skygear-server/pkg/server/audit"
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+// Define the playload that unlock user handler will process
+type unlockUserPayload struct {
+	AuthInfoID string `mapstructure:"auth_id"`
+}
+
+func (payload *unlockUserPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *unlockUserPayload) Validate() skyerr.Error {
+	if payload.AuthInfoID == "" {
+		return skyerr.NewInvalidArgument("empty auth_id", []string{"auth_id"})
+	}
+	return nil
+}
+
+// UnlockUserHandler removes the login lockout of the specified user
+//
+// A user is locked out temporarily after too many failed login attempts.
+// UnlockUserHandler allows an admin to unlock the user before the lockout
+// expires.
+//
+// UnlockUserHandler receives these parameters:
+//
+// * auth_id (string, required)
+//
+// Current implementation:
+//
+// ```
+// curl -X POST -H "Content-Type: application/json" \
+//   -d @- http://localhost:3000/ <<EOF
+// {
+//     "action": "auth:unlock",
+//     "auth_id": "77FA8BCF-CD6C-4A22-A170-CECC2667654F"
+// }
+// EOF
+// ```
+//
+// Response:
+// * success response
+type UnlockUserHandler struct {
+	LoginThrottler *audit.LoginThrottler `inject:"LoginThrottler"`
+	Authenticator  router.Processor      `preprocessor:"authenticator"`
+	DBConn         router.Processor      `preprocessor:"dbconn"`
+	InjectAuth     router.Processor      `preprocessor:"inject_auth"`
+	RequireAdmin   router.Processor      `preprocessor:"require_admin"`
+	PluginReady    router.Processor      `preprocessor:"plugin_ready"`
+	preprocessors  []router.Processor
+}
+
+func (h *UnlockUserHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.RequireAdmin,
+		h.PluginReady,
+	}
+}
+
+func (h *UnlockUserHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *UnlockUserHandler) Handle(payload *router.Payload, response *router.Response) {
+	logger := logging.CreateLogger(payload.Context(), "handler")
+	p := &unlockUserPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	logger = logger.WithFields(logrus.Fields{
+		"auth_id": p.AuthInfoID,
+	})
+	logger.Debug("Handler called to unlock user")
+
+	authinfo := skydb.AuthInfo{}
+	if err := payload.DBConn.GetAuth(p.AuthInfoID, &authinfo); err != nil {
+		if err == skydb.ErrUserNotFound {
+			logger.Info("Auth info not found when unlocking user")
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "User not found")
+			return
+		}
+		logger.WithError(err).Error("Unable to get auth info when unlocking user")
+		response.Err = skyerr.NewError(skyerr.ResourceNotFound, "User not found")
+		return
+	}
+
+	if err := h.LoginThrottler.Unlock(authinfo.ID); err != nil {
+		logger.WithError(err).Error("Unable to unlock user")
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	logger.Info("Successfully unlocked user")
+
+	audit.Trail(audit.Entry{
+		AuthID: authinfo.ID,
+		Event:  audit.EventUnlockUser,
+	}.WithRouterPayload(payload))
+
+	response.Result = statusResponse{
+		Status: "OK",
+	}
+}
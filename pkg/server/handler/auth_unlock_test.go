@@ -0,0 +1,73 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/audit"
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnlockUserHandler(t *testing.T) {
+	Convey("UnlockUserHandler", t, func() {
+		conn := singleUserConn{}
+		authInfo := skydb.NewAuthInfo("chima")
+		authInfo.ID = "chima"
+		conn.CreateAuth(&authInfo)
+
+		throttler := &audit.LoginThrottler{
+			Store:              audit.NewMemoryLoginAttemptStore(),
+			AccountMaxAttempts: 1,
+			Window:             time.Minute,
+			LockoutDuration:    time.Minute,
+		}
+
+		r := handlertest.NewSingleRouteRouter(&UnlockUserHandler{
+			LoginThrottler: throttler,
+		}, func(p *router.Payload) {
+			p.DBConn = &conn
+		})
+
+		Convey("should unlock a locked user", func() {
+			throttler.RecordFailure("chima", "")
+			So(throttler.CheckAccount("chima"), ShouldNotBeNil)
+
+			resp := r.POST(`
+				{
+					"auth_id": "chima"
+				}
+			`)
+
+			So(resp.Body.Bytes(), ShouldEqualJSON, `
+				{
+					"result": {"status": "OK"}
+				}
+			`)
+			So(resp.Code, ShouldEqual, 200)
+			So(throttler.CheckAccount("chima"), ShouldBeNil)
+		})
+
+		Convey("should reject empty auth_id", func() {
+			resp := r.POST(`{}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+	})
+}
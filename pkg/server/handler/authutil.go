@@ -17,6 +17,7 @@ package handler
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -123,6 +124,24 @@ type createUserWithRecordContext struct {
 }
 
 func (ctx *createUserWithRecordContext) execute(info *skydb.AuthInfo, authData skydb.AuthData, profile skydb.Data) (*skydb.Record, skyerr.Error) {
+	// The user record is saved by the caller after signup, so changes
+	// made by afterSignup hooks are saved along with it.
+	hookCtx := authHookContext{
+		HookRegistry: ctx.HookRegistry,
+		Context:      ctx.Context,
+	}
+
+	if ctx.HookRegistry != nil {
+		newUser := skydb.Record{
+			ID:   skydb.NewRecordID(ctx.Database.UserRecordType(), info.ID),
+			Data: mergeAuthDataWithProfile(authData, profile),
+		}
+		if err := hookCtx.execute(hook.BeforeSignup, info, &newUser); err != nil {
+			return nil, err
+		}
+		profile = newUser.Data
+	}
+
 	newCtx := authUserRecordContext{
 		DBConn:         ctx.DBConn,
 		Database:       ctx.Database,
@@ -142,7 +161,14 @@ func (ctx *createUserWithRecordContext) execute(info *skydb.AuthInfo, authData s
 			return nil
 		},
 	}
-	return newCtx.execute(info, authData, profile)
+
+	user, err := newCtx.execute(info, authData, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	hookCtx.executeAfter(hook.AfterSignup, info, user)
+	return user, nil
 }
 
 // authUserRecordContext is a context for manipulating a user with
@@ -283,6 +309,69 @@ func getAuthDataFromUser(authData skydb.AuthData, user skydb.Record) {
 	authData.UpdateFromRecordData(user.Data)
 }
 
+// authHookContext is a context for executing auth hooks on a user
+//
+// If Database is set, changes made by the hooks to the data of the user
+// record are saved to it. Otherwise the caller is responsible for saving
+// the user record.
+type authHookContext struct {
+	HookRegistry *hook.Registry
+	Database     skydb.Database
+	Context      context.Context
+}
+
+// fetchUser returns the user record to be passed to auth hooks, or nil if
+// the user record cannot be fetched.
+func (ctx *authHookContext) fetchUser(authInfoID string) *skydb.Record {
+	if ctx.HookRegistry == nil || ctx.Database == nil {
+		return nil
+	}
+
+	user := skydb.Record{}
+	userID := skydb.NewRecordID(ctx.Database.UserRecordType(), authInfoID)
+	if err := ctx.Database.Get(userID, &user); err != nil {
+		logger := logging.CreateLogger(ctx.Context, "handler")
+		logger.WithError(err).Warnf("Unable to fetch user record for auth hooks")
+		return nil
+	}
+	return &user
+}
+
+// execute executes the auth hooks of kind. An error returned by a hook
+// should reject the action.
+func (ctx *authHookContext) execute(kind hook.Kind, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+	if ctx.HookRegistry == nil {
+		return nil
+	}
+
+	var original skydb.Data
+	if user != nil {
+		original = user.Data.Copy()
+	}
+
+	if err := ctx.HookRegistry.ExecuteAuthHooks(ctx.Context, kind, authInfo, user); err != nil {
+		return err
+	}
+
+	if ctx.Database == nil || user == nil || reflect.DeepEqual(original, user.Data) {
+		return nil
+	}
+
+	if err := ctx.Database.Save(user); err != nil {
+		return skyerr.MakeError(err)
+	}
+	return nil
+}
+
+// executeAfter executes the auth hooks of kind after the action is done,
+// so errors are logged instead of returned.
+func (ctx *authHookContext) executeAfter(kind hook.Kind, authInfo *skydb.AuthInfo, user *skydb.Record) {
+	if err := ctx.execute(kind, authInfo, user); err != nil {
+		logger := logging.CreateLogger(ctx.Context, "handler")
+		logger.WithError(err).Errorf("Error occurred while executing %s hooks", kind)
+	}
+}
+
 // checkUserIsNotDisabled is used by login handlers to check if the user is
 // not disabled.
 func checkUserIsNotDisabled(authInfo *skydb.AuthInfo) skyerr.Error {
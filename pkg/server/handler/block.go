@@ -0,0 +1,243 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	"github.com/skygeario/skygear-server/pkg/server/userblock"
+)
+
+type blockPayload struct {
+	Target []string `mapstructure:"targets"`
+}
+
+func (payload *blockPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *blockPayload) Validate() skyerr.Error {
+	if len(payload.Target) == 0 {
+		return skyerr.NewInvalidArgument("empty targets", []string{"targets"})
+	}
+	return nil
+}
+
+type blockResultItem struct {
+	ID   string       `json:"id"`
+	Type string       `json:"type,omitempty"`
+	Data skyerr.Error `json:"data,omitempty"`
+}
+
+/*
+BlockAddHandler blocks the target users. A blocked user cannot read
+records owned by the current user, request or add relations with the
+current user, or publish pubsub messages to the current user.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"action": "block:add",
+	"access_token": "ACCESS_TOKEN",
+	"targets": ["1001"]
+}
+EOF
+
+{
+	"result": [{"id": "1001"}]
+}
+*/
+type BlockAddHandler struct {
+	BlockChecker  *userblock.Checker `inject:"BlockChecker"`
+	Authenticator router.Processor   `preprocessor:"authenticator"`
+	DBConn        router.Processor   `preprocessor:"dbconn"`
+	InjectAuth    router.Processor   `preprocessor:"require_auth"`
+	CheckUser     router.Processor   `preprocessor:"check_user"`
+	PluginReady   router.Processor   `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *BlockAddHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *BlockAddHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *BlockAddHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := blockPayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	results := make([]blockResultItem, 0, len(payload.Target))
+	for _, target := range payload.Target {
+		var skyErr skyerr.Error
+		if target == rpayload.AuthInfoID {
+			skyErr = skyerr.NewInvalidArgument("cannot block oneself", []string{"targets"})
+		} else if err := rpayload.DBConn.BlockUser(&skydb.UserBlock{
+			BlockerID: rpayload.AuthInfoID,
+			BlockedID: target,
+			CreatedAt: timeNow(),
+		}); err == skydb.ErrUserNotFound {
+			skyErr = skyerr.NewError(skyerr.ResourceNotFound, "user not found")
+		} else if err != nil {
+			skyErr = skyerr.MakeError(err)
+		}
+
+		if skyErr != nil {
+			results = append(results, blockResultItem{target, "error", skyErr})
+		} else {
+			results = append(results, blockResultItem{ID: target})
+		}
+	}
+
+	h.BlockChecker.Invalidate(rpayload.AuthInfoID)
+	response.Result = results
+}
+
+/*
+BlockRemoveHandler unblocks the target users.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"action": "block:remove",
+	"access_token": "ACCESS_TOKEN",
+	"targets": ["1001"]
+}
+EOF
+*/
+type BlockRemoveHandler struct {
+	BlockChecker  *userblock.Checker `inject:"BlockChecker"`
+	Authenticator router.Processor   `preprocessor:"authenticator"`
+	DBConn        router.Processor   `preprocessor:"dbconn"`
+	InjectAuth    router.Processor   `preprocessor:"require_auth"`
+	CheckUser     router.Processor   `preprocessor:"check_user"`
+	PluginReady   router.Processor   `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *BlockRemoveHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *BlockRemoveHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *BlockRemoveHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := blockPayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	results := make([]blockResultItem, 0, len(payload.Target))
+	for _, target := range payload.Target {
+		err := rpayload.DBConn.UnblockUser(rpayload.AuthInfoID, target)
+		if err == skydb.ErrUserBlockNotFound {
+			results = append(results, blockResultItem{target, "error", skyerr.NewError(skyerr.ResourceNotFound, "user is not blocked")})
+		} else if err != nil {
+			results = append(results, blockResultItem{target, "error", skyerr.MakeError(err)})
+		} else {
+			results = append(results, blockResultItem{ID: target})
+		}
+	}
+
+	h.BlockChecker.Invalidate(rpayload.AuthInfoID)
+	response.Result = results
+}
+
+/*
+BlockQueryHandler lists the users blocked by the current user.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"action": "block:query",
+	"access_token": "ACCESS_TOKEN"
+}
+EOF
+
+{
+	"result": [{
+		"blocker_id": "1000",
+		"blocked_id": "1001",
+		"created_at": "2017-07-01T00:00:00Z"
+	}]
+}
+*/
+type BlockQueryHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	CheckUser     router.Processor `preprocessor:"check_user"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *BlockQueryHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *BlockQueryHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *BlockQueryHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	blocks, err := rpayload.DBConn.QueryBlockedUsers(rpayload.AuthInfoID)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = blocks
+}
+
+// checkBlocked returns an error if the user is blocked by the target.
+func checkBlocked(conn skydb.Conn, userID string, target string) skyerr.Error {
+	blocked, err := conn.IsUserBlocked(target, userID)
+	if err != nil {
+		return skyerr.MakeError(err)
+	}
+	if blocked {
+		return skyerr.NewError(skyerr.PermissionDenied, "blocked by the user")
+	}
+	return nil
+}
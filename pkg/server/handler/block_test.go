@@ -0,0 +1,141 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	"github.com/skygeario/skygear-server/pkg/server/userblock"
+)
+
+func TestBlockHandlers(t *testing.T) {
+	now := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+	realTimeNow := timeNow
+	timeNow = func() time.Time { return now }
+	defer func() {
+		timeNow = realTimeNow
+	}()
+
+	Convey("Block handlers", t, func() {
+		conn := skydbtest.NewMapConn()
+		checker := userblock.NewChecker(func() (skydb.Conn, error) {
+			return conn, nil
+		}, time.Minute)
+		injectUser := func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = &skydb.AuthInfo{ID: "alice"}
+			p.AuthInfoID = "alice"
+		}
+
+		Convey("blocks a user", func() {
+			r := handlertest.NewSingleRouteRouter(&BlockAddHandler{
+				BlockChecker: checker,
+			}, injectUser)
+
+			resp := r.POST(`{"targets": ["bob", "alice"]}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"id": "bob"
+				}, {
+					"id": "alice",
+					"type": "error",
+					"data": {
+						"code": 108,
+						"message": "cannot block oneself",
+						"name": "InvalidArgument",
+						"info": {"arguments": ["targets"]}
+					}
+				}]
+			}`)
+
+			blocked, err := checker.IsBlocked("alice", "bob")
+			So(err, ShouldBeNil)
+			So(blocked, ShouldBeTrue)
+		})
+
+		Convey("rejects empty targets", func() {
+			r := handlertest.NewSingleRouteRouter(&BlockAddHandler{
+				BlockChecker: checker,
+			}, injectUser)
+
+			resp := r.POST(`{"targets": []}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+
+		Convey("unblocks a user and invalidates the cache", func() {
+			So(conn.BlockUser(&skydb.UserBlock{
+				BlockerID: "alice",
+				BlockedID: "bob",
+				CreatedAt: now,
+			}), ShouldBeNil)
+			blocked, _ := checker.IsBlocked("alice", "bob")
+			So(blocked, ShouldBeTrue)
+
+			r := handlertest.NewSingleRouteRouter(&BlockRemoveHandler{
+				BlockChecker: checker,
+			}, injectUser)
+
+			resp := r.POST(`{"targets": ["bob", "carol"]}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"id": "bob"
+				}, {
+					"id": "carol",
+					"type": "error",
+					"data": {
+						"code": 110,
+						"message": "user is not blocked",
+						"name": "ResourceNotFound"
+					}
+				}]
+			}`)
+
+			blocked, _ = checker.IsBlocked("alice", "bob")
+			So(blocked, ShouldBeFalse)
+		})
+
+		Convey("queries blocked users", func() {
+			So(conn.BlockUser(&skydb.UserBlock{
+				BlockerID: "alice",
+				BlockedID: "bob",
+				CreatedAt: now,
+			}), ShouldBeNil)
+			So(conn.BlockUser(&skydb.UserBlock{
+				BlockerID: "bob",
+				BlockedID: "alice",
+				CreatedAt: now,
+			}), ShouldBeNil)
+
+			r := handlertest.NewSingleRouteRouter(&BlockQueryHandler{}, injectUser)
+
+			resp := r.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"blocker_id": "alice",
+					"blocked_id": "bob",
+					"created_at": "2017-07-01T00:00:00Z"
+				}]
+			}`)
+		})
+	})
+}
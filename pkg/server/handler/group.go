@@ -0,0 +1,593 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+type groupResponse struct {
+	skydb.Group
+	Members []skydb.GroupMembership `json:"members,omitempty"`
+}
+
+// getGroupAccess returns the group and the role of the current user in
+// the group. Requests with master key are treated as the group owner.
+func getGroupAccess(payload *router.Payload, groupID string) (*skydb.Group, skydb.GroupMemberRole, skyerr.Error) {
+	group := skydb.Group{}
+	if err := payload.DBConn.GetGroup(groupID, &group); err != nil {
+		if err == skydb.ErrGroupNotFound {
+			return nil, "", skyerr.NewError(skyerr.ResourceNotFound, "group not found")
+		}
+		return nil, "", skyerr.MakeError(err)
+	}
+
+	if payload.HasMasterKey() {
+		return &group, skydb.GroupOwner, nil
+	}
+
+	membership := skydb.GroupMembership{}
+	if err := payload.DBConn.GetGroupMember(groupID, payload.AuthInfoID, &membership); err != nil {
+		if err == skydb.ErrGroupMemberNotFound {
+			return nil, "", skyerr.NewError(skyerr.PermissionDenied, "user is not a member of the group")
+		}
+		return nil, "", skyerr.MakeError(err)
+	}
+	return &group, membership.Role, nil
+}
+
+// isLastGroupOwner returns true if the user is the only owner of the group.
+func isLastGroupOwner(conn skydb.Conn, groupID string, userID string) (bool, skyerr.Error) {
+	memberships, err := conn.QueryGroupMembers(groupID)
+	if err != nil {
+		return false, skyerr.MakeError(err)
+	}
+
+	owners := 0
+	isOwner := false
+	for _, membership := range memberships {
+		if membership.Role == skydb.GroupOwner {
+			owners++
+			if membership.UserID == userID {
+				isOwner = true
+			}
+		}
+	}
+	return isOwner && owners == 1, nil
+}
+
+type groupCreatePayload struct {
+	Name    string `mapstructure:"name"`
+	OwnerID string `mapstructure:"owner_id"`
+}
+
+func (payload *groupCreatePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *groupCreatePayload) Validate() skyerr.Error {
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty name", []string{"name"})
+	}
+	return nil
+}
+
+/*
+GroupCreateHandler creates a group. The current user becomes the owner of
+the group. With master key, owner_id specifies the owner; a group created
+with master key without owner_id has no members.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "group:create",
+		"access_token": "ACCESS_TOKEN",
+		"name": "Editors"
+	}
+	EOF
+*/
+type GroupCreateHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *GroupCreateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.PluginReady,
+	}
+}
+
+func (h *GroupCreateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *GroupCreateHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &groupCreatePayload{}
+	if skyErr := p.Decode(payload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	ownerID := payload.AuthInfoID
+	if payload.HasMasterKey() {
+		ownerID = p.OwnerID
+	} else if p.OwnerID != "" && p.OwnerID != ownerID {
+		response.Err = skyerr.NewError(skyerr.PermissionDenied, "owner_id can only be specified with master key")
+		return
+	}
+
+	if ownerID != "" {
+		if err := payload.DBConn.GetAuth(ownerID, &skydb.AuthInfo{}); err != nil {
+			if err == skydb.ErrUserNotFound {
+				response.Err = skyerr.NewInvalidArgument("user not found", []string{"owner_id"})
+				return
+			}
+			response.Err = skyerr.MakeError(err)
+			return
+		}
+	}
+
+	now := timeNow()
+	group := skydb.Group{
+		ID:        uuidNew(),
+		Name:      p.Name,
+		CreatedBy: ownerID,
+		CreatedAt: now,
+	}
+	if err := payload.DBConn.CreateGroup(&group); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	result := groupResponse{Group: group}
+	if ownerID != "" {
+		membership := skydb.GroupMembership{
+			GroupID:   group.ID,
+			UserID:    ownerID,
+			Role:      skydb.GroupOwner,
+			CreatedAt: now,
+		}
+		if err := payload.DBConn.SetGroupMember(&membership); err != nil {
+			response.Err = skyerr.MakeError(err)
+			return
+		}
+		result.Members = []skydb.GroupMembership{membership}
+	}
+
+	response.Result = result
+}
+
+type groupPayload struct {
+	ID string `mapstructure:"id"`
+}
+
+func (payload *groupPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *groupPayload) Validate() skyerr.Error {
+	if payload.ID == "" {
+		return skyerr.NewInvalidArgument("empty id", []string{"id"})
+	}
+	return nil
+}
+
+/*
+GroupGetHandler returns a group with its members. Only members of the
+group can get the group.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "group:get",
+		"access_token": "ACCESS_TOKEN",
+		"id": "GROUP_ID"
+	}
+	EOF
+*/
+type GroupGetHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *GroupGetHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.PluginReady,
+	}
+}
+
+func (h *GroupGetHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *GroupGetHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &groupPayload{}
+	if skyErr := p.Decode(payload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	group, _, skyErr := getGroupAccess(payload, p.ID)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	memberships, err := payload.DBConn.QueryGroupMembers(group.ID)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = groupResponse{
+		Group:   *group,
+		Members: memberships,
+	}
+}
+
+/*
+GroupDeleteHandler deletes a group. Only owners of the group can delete
+the group.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "group:delete",
+		"access_token": "ACCESS_TOKEN",
+		"id": "GROUP_ID"
+	}
+	EOF
+*/
+type GroupDeleteHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *GroupDeleteHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.PluginReady,
+	}
+}
+
+func (h *GroupDeleteHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *GroupDeleteHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &groupPayload{}
+	if skyErr := p.Decode(payload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	group, role, skyErr := getGroupAccess(payload, p.ID)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	if role != skydb.GroupOwner {
+		response.Err = skyerr.NewError(skyerr.PermissionDenied, "only group owner can delete the group")
+		return
+	}
+
+	if err := payload.DBConn.DeleteGroup(group.ID); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = struct {
+		ID string `json:"_id"`
+	}{group.ID}
+}
+
+/*
+GroupListHandler returns the groups the current user is a member of.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "group:list",
+		"access_token": "ACCESS_TOKEN"
+	}
+	EOF
+*/
+type GroupListHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *GroupListHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.PluginReady,
+	}
+}
+
+func (h *GroupListHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *GroupListHandler) Handle(payload *router.Payload, response *router.Response) {
+	groups, err := payload.DBConn.QueryGroupsByMember(payload.AuthInfoID)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	response.Result = groups
+}
+
+type groupMemberPayload struct {
+	GroupID string `mapstructure:"group_id"`
+	UserID  string `mapstructure:"user_id"`
+	Role    string `mapstructure:"role"`
+}
+
+func (payload *groupMemberPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *groupMemberPayload) Validate() skyerr.Error {
+	if payload.GroupID == "" {
+		return skyerr.NewInvalidArgument("empty group_id", []string{"group_id"})
+	}
+	if payload.UserID == "" {
+		return skyerr.NewInvalidArgument("empty user_id", []string{"user_id"})
+	}
+	if payload.Role != "" && !skydb.GroupMemberRole(payload.Role).IsValid() {
+		return skyerr.NewInvalidArgument("role must be owner, admin or member", []string{"role"})
+	}
+	return nil
+}
+
+/*
+GroupMemberAddHandler adds a user to a group, or changes the role of an
+existing member. role is one of "owner", "admin" and "member" (default).
+
+Owners and admins of the group can add members. Only owners can grant
+owner or admin, or change the role of other owners and admins.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "group:member:add",
+		"access_token": "ACCESS_TOKEN",
+		"group_id": "GROUP_ID",
+		"user_id": "USER_ID",
+		"role": "admin"
+	}
+	EOF
+*/
+type GroupMemberAddHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *GroupMemberAddHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.PluginReady,
+	}
+}
+
+func (h *GroupMemberAddHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *GroupMemberAddHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &groupMemberPayload{}
+	if skyErr := p.Decode(payload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	newRole := skydb.GroupMember
+	if p.Role != "" {
+		newRole = skydb.GroupMemberRole(p.Role)
+	}
+
+	group, role, skyErr := getGroupAccess(payload, p.GroupID)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	if !role.CanManageMembers() {
+		response.Err = skyerr.NewError(skyerr.PermissionDenied, "only group owner or admin can add members")
+		return
+	}
+
+	conn := payload.DBConn
+	if err := conn.GetAuth(p.UserID, &skydb.AuthInfo{}); err != nil {
+		if err == skydb.ErrUserNotFound {
+			response.Err = skyerr.NewInvalidArgument("user not found", []string{"user_id"})
+			return
+		}
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	membership := skydb.GroupMembership{}
+	err := conn.GetGroupMember(group.ID, p.UserID, &membership)
+	if err != nil && err != skydb.ErrGroupMemberNotFound {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	isMember := err == nil
+
+	if role != skydb.GroupOwner &&
+		(newRole.CanManageMembers() || (isMember && membership.Role.CanManageMembers())) {
+		response.Err = skyerr.NewError(skyerr.PermissionDenied, "only group owner can manage owners and admins")
+		return
+	}
+
+	if isMember && membership.Role == skydb.GroupOwner && newRole != skydb.GroupOwner {
+		isLast, skyErr := isLastGroupOwner(conn, group.ID, p.UserID)
+		if skyErr != nil {
+			response.Err = skyErr
+			return
+		}
+		if isLast {
+			response.Err = skyerr.NewInvalidArgument("cannot demote the last owner of the group", []string{"role"})
+			return
+		}
+	}
+
+	if !isMember {
+		membership = skydb.GroupMembership{
+			GroupID:   group.ID,
+			UserID:    p.UserID,
+			CreatedAt: timeNow(),
+		}
+	}
+	membership.Role = newRole
+
+	if err := conn.SetGroupMember(&membership); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	response.Result = membership
+}
+
+/*
+GroupMemberRemoveHandler removes a user from a group. Owners and admins
+can remove members; only owners can remove other owners and admins. Any
+member can leave the group by removing themselves. The last owner of a
+group cannot be removed.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "group:member:remove",
+		"access_token": "ACCESS_TOKEN",
+		"group_id": "GROUP_ID",
+		"user_id": "USER_ID"
+	}
+	EOF
+*/
+type GroupMemberRemoveHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *GroupMemberRemoveHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.PluginReady,
+	}
+}
+
+func (h *GroupMemberRemoveHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *GroupMemberRemoveHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &groupMemberPayload{}
+	if skyErr := p.Decode(payload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	group, role, skyErr := getGroupAccess(payload, p.GroupID)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	conn := payload.DBConn
+	membership := skydb.GroupMembership{}
+	if err := conn.GetGroupMember(group.ID, p.UserID, &membership); err != nil {
+		if err == skydb.ErrGroupMemberNotFound {
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "user is not a member of the group")
+			return
+		}
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	isSelf := !payload.HasMasterKey() && p.UserID == payload.AuthInfoID
+	if !isSelf {
+		if !role.CanManageMembers() {
+			response.Err = skyerr.NewError(skyerr.PermissionDenied, "only group owner or admin can remove members")
+			return
+		}
+		if role != skydb.GroupOwner && membership.Role.CanManageMembers() {
+			response.Err = skyerr.NewError(skyerr.PermissionDenied, "only group owner can manage owners and admins")
+			return
+		}
+	}
+
+	isLast, skyErr := isLastGroupOwner(conn, group.ID, p.UserID)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+	if isLast {
+		response.Err = skyerr.NewInvalidArgument("cannot remove the last owner of the group", []string{"user_id"})
+		return
+	}
+
+	if err := conn.RemoveGroupMember(group.ID, p.UserID); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	response.Result = membership
+}
@@ -0,0 +1,398 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+)
+
+func newGroupTestConn(now time.Time) *skydbtest.MapConn {
+	conn := skydbtest.NewMapConn()
+	for _, id := range []string{"owner", "admin", "member", "stranger"} {
+		conn.UserMap[id] = skydb.AuthInfo{ID: id}
+	}
+	conn.GroupMap["group-id"] = skydb.Group{
+		ID:        "group-id",
+		Name:      "Editors",
+		CreatedBy: "owner",
+		CreatedAt: now,
+	}
+	conn.GroupMemberMap["group-id"] = map[string]skydb.GroupMembership{
+		"owner":  {GroupID: "group-id", UserID: "owner", Role: skydb.GroupOwner, CreatedAt: now},
+		"admin":  {GroupID: "group-id", UserID: "admin", Role: skydb.GroupAdmin, CreatedAt: now},
+		"member": {GroupID: "group-id", UserID: "member", Role: skydb.GroupMember, CreatedAt: now},
+	}
+	return conn
+}
+
+func TestGroupCreateHandler(t *testing.T) {
+	Convey("GroupCreateHandler", t, func() {
+		realUUIDNew := uuidNew
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		uuidNew = func() string { return "new-group-id" }
+		timeNow = func() time.Time { return now }
+		defer func() {
+			uuidNew = realUUIDNew
+			timeNow = realTimeNow
+		}()
+
+		conn := newGroupTestConn(now)
+		r := handlertest.NewSingleRouteRouter(&GroupCreateHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfoID = "member"
+		})
+
+		Convey("creates group with current user as owner", func() {
+			resp := r.POST(`{"name": "Authors"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"_id": "new-group-id",
+					"name": "Authors",
+					"created_by": "member",
+					"created_at": "2017-01-01T00:00:00Z",
+					"members": [{
+						"group_id": "new-group-id",
+						"user_id": "member",
+						"role": "owner",
+						"created_at": "2017-01-01T00:00:00Z"
+					}]
+				}
+			}`)
+
+			membership := skydb.GroupMembership{}
+			So(conn.GetGroupMember("new-group-id", "member", &membership), ShouldBeNil)
+			So(membership.Role, ShouldEqual, skydb.GroupOwner)
+		})
+
+		Convey("rejects owner_id without master key", func() {
+			resp := r.POST(`{"name": "Authors", "owner_id": "stranger"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 102,
+					"message": "owner_id can only be specified with master key",
+					"name": "PermissionDenied"
+				}
+			}`)
+		})
+
+		Convey("rejects empty name", func() {
+			resp := r.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "empty name",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["name"]}
+				}
+			}`)
+		})
+	})
+}
+
+func TestGroupGetHandler(t *testing.T) {
+	Convey("GroupGetHandler", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		conn := newGroupTestConn(now)
+		userID := "member"
+		r := handlertest.NewSingleRouteRouter(&GroupGetHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfoID = userID
+		})
+
+		Convey("returns group with members", func() {
+			resp := r.POST(`{"id": "group-id"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"_id": "group-id",
+					"name": "Editors",
+					"created_by": "owner",
+					"created_at": "2017-01-01T00:00:00Z",
+					"members": [{
+						"group_id": "group-id",
+						"user_id": "admin",
+						"role": "admin",
+						"created_at": "2017-01-01T00:00:00Z"
+					}, {
+						"group_id": "group-id",
+						"user_id": "member",
+						"role": "member",
+						"created_at": "2017-01-01T00:00:00Z"
+					}, {
+						"group_id": "group-id",
+						"user_id": "owner",
+						"role": "owner",
+						"created_at": "2017-01-01T00:00:00Z"
+					}]
+				}
+			}`)
+		})
+
+		Convey("rejects non-member", func() {
+			userID = "stranger"
+			resp := r.POST(`{"id": "group-id"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 102,
+					"message": "user is not a member of the group",
+					"name": "PermissionDenied"
+				}
+			}`)
+		})
+
+		Convey("returns not found for non-existent group", func() {
+			resp := r.POST(`{"id": "not-exist"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 110,
+					"message": "group not found",
+					"name": "ResourceNotFound"
+				}
+			}`)
+		})
+	})
+}
+
+func TestGroupDeleteHandler(t *testing.T) {
+	Convey("GroupDeleteHandler", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		conn := newGroupTestConn(now)
+		userID := "owner"
+		r := handlertest.NewSingleRouteRouter(&GroupDeleteHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfoID = userID
+		})
+
+		Convey("deletes group by owner", func() {
+			resp := r.POST(`{"id": "group-id"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {"_id": "group-id"}
+			}`)
+			So(conn.GroupMap, ShouldBeEmpty)
+			So(conn.GroupMemberMap, ShouldBeEmpty)
+		})
+
+		Convey("rejects admin", func() {
+			userID = "admin"
+			resp := r.POST(`{"id": "group-id"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 102,
+					"message": "only group owner can delete the group",
+					"name": "PermissionDenied"
+				}
+			}`)
+			So(conn.GroupMap, ShouldContainKey, "group-id")
+		})
+	})
+}
+
+func TestGroupListHandler(t *testing.T) {
+	Convey("GroupListHandler", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		conn := newGroupTestConn(now)
+		userID := "member"
+		r := handlertest.NewSingleRouteRouter(&GroupListHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfoID = userID
+		})
+
+		Convey("lists groups of current user", func() {
+			resp := r.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"_id": "group-id",
+					"name": "Editors",
+					"created_by": "owner",
+					"created_at": "2017-01-01T00:00:00Z"
+				}]
+			}`)
+		})
+
+		Convey("lists no groups for non-member", func() {
+			userID = "stranger"
+			resp := r.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{"result": []}`)
+		})
+	})
+}
+
+func TestGroupMemberAddHandler(t *testing.T) {
+	Convey("GroupMemberAddHandler", t, func() {
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := newGroupTestConn(now)
+		userID := "admin"
+		r := handlertest.NewSingleRouteRouter(&GroupMemberAddHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfoID = userID
+		})
+
+		Convey("adds member by admin", func() {
+			resp := r.POST(`{"group_id": "group-id", "user_id": "stranger"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"group_id": "group-id",
+					"user_id": "stranger",
+					"role": "member",
+					"created_at": "2017-01-01T00:00:00Z"
+				}
+			}`)
+			So(conn.GroupMemberMap["group-id"], ShouldContainKey, "stranger")
+		})
+
+		Convey("rejects granting admin by admin", func() {
+			resp := r.POST(`{"group_id": "group-id", "user_id": "stranger", "role": "admin"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 102,
+					"message": "only group owner can manage owners and admins",
+					"name": "PermissionDenied"
+				}
+			}`)
+		})
+
+		Convey("promotes member to admin by owner", func() {
+			userID = "owner"
+			resp := r.POST(`{"group_id": "group-id", "user_id": "member", "role": "admin"}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(conn.GroupMemberMap["group-id"]["member"].Role, ShouldEqual, skydb.GroupAdmin)
+		})
+
+		Convey("rejects member adding members", func() {
+			userID = "member"
+			resp := r.POST(`{"group_id": "group-id", "user_id": "stranger"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 102,
+					"message": "only group owner or admin can add members",
+					"name": "PermissionDenied"
+				}
+			}`)
+		})
+
+		Convey("rejects demoting the last owner", func() {
+			userID = "owner"
+			resp := r.POST(`{"group_id": "group-id", "user_id": "owner", "role": "member"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "cannot demote the last owner of the group",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["role"]}
+				}
+			}`)
+		})
+
+		Convey("rejects invalid role", func() {
+			resp := r.POST(`{"group_id": "group-id", "user_id": "stranger", "role": "guest"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "role must be owner, admin or member",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["role"]}
+				}
+			}`)
+		})
+
+		Convey("rejects non-existent user", func() {
+			resp := r.POST(`{"group_id": "group-id", "user_id": "not-exist"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "user not found",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["user_id"]}
+				}
+			}`)
+		})
+	})
+}
+
+func TestGroupMemberRemoveHandler(t *testing.T) {
+	Convey("GroupMemberRemoveHandler", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		conn := newGroupTestConn(now)
+		userID := "admin"
+		r := handlertest.NewSingleRouteRouter(&GroupMemberRemoveHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfoID = userID
+		})
+
+		Convey("removes member by admin", func() {
+			resp := r.POST(`{"group_id": "group-id", "user_id": "member"}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(conn.GroupMemberMap["group-id"], ShouldNotContainKey, "member")
+		})
+
+		Convey("allows member to leave", func() {
+			userID = "member"
+			resp := r.POST(`{"group_id": "group-id", "user_id": "member"}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(conn.GroupMemberMap["group-id"], ShouldNotContainKey, "member")
+		})
+
+		Convey("rejects member removing others", func() {
+			userID = "member"
+			resp := r.POST(`{"group_id": "group-id", "user_id": "admin"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 102,
+					"message": "only group owner or admin can remove members",
+					"name": "PermissionDenied"
+				}
+			}`)
+		})
+
+		Convey("rejects admin removing owner", func() {
+			resp := r.POST(`{"group_id": "group-id", "user_id": "owner"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 102,
+					"message": "only group owner can manage owners and admins",
+					"name": "PermissionDenied"
+				}
+			}`)
+		})
+
+		Convey("rejects removing the last owner", func() {
+			userID = "owner"
+			resp := r.POST(`{"group_id": "group-id", "user_id": "owner"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "cannot remove the last owner of the group",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["user_id"]}
+				}
+			}`)
+		})
+	})
+}
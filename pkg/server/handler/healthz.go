@@ -15,14 +15,19 @@
 package handler
 
 import (
+	"github.com/skygeario/skygear-server/pkg/server/plugin"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 )
 
 type healthStatusResponse struct {
-	Status string `json:"status,omitempty"`
+	Status  string          `json:"status,omitempty"`
+	Plugins []plugin.Status `json:"plugins,omitempty"`
 }
 
+// HealthzHandler reports that the server is up, together with the status
+// of each plugin and its circuit breaker.
 type HealthzHandler struct {
+	PluginContext *plugin.Context  `inject:"PluginContext"`
 	PluginReady   router.Processor `preprocessor:"plugin_ready"`
 	preprocessors []router.Processor
 }
@@ -42,6 +47,9 @@ func (h *HealthzHandler) Handle(playload *router.Payload, response *router.Respo
 		rep healthStatusResponse
 	)
 	rep.Status = "OK"
+	if h.PluginContext != nil {
+		rep.Plugins = h.PluginContext.Statuses()
+	}
 	response.Result = rep
 	return
 }
@@ -17,6 +17,7 @@ package handler
 import (
 	"testing"
 
+	"github.com/skygeario/skygear-server/pkg/server/plugin"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	. "github.com/smartystreets/goconvey/convey"
 )
@@ -31,5 +32,17 @@ func TestHealthzHandler(t *testing.T) {
 		So(resp.Result, ShouldHaveSameTypeAs, healthStatusResponse{})
 		s := resp.Result.(healthStatusResponse)
 		So(s.Status, ShouldEqual, "OK")
+		So(s.Plugins, ShouldBeNil)
+	})
+
+	Convey("HealthzHandler with plugin context", t, func() {
+		req := router.Payload{}
+		resp := router.Response{}
+
+		handler := &HealthzHandler{PluginContext: &plugin.Context{}}
+		handler.Handle(&req, &resp)
+		s := resp.Result.(healthStatusResponse)
+		So(s.Status, ShouldEqual, "OK")
+		So(s.Plugins, ShouldResemble, []plugin.Status{})
 	})
 }
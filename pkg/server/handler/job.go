@@ -0,0 +1,369 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/skygeario/skygear-server/pkg/server/job"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+const defaultJobsLimit = 50
+
+type jobEnqueuePayload struct {
+	Lambda      string      `mapstructure:"lambda"`
+	Args        interface{} `mapstructure:"args"`
+	Delay       float64     `mapstructure:"delay"`
+	RunAtString string      `mapstructure:"run_at"`
+	MaxAttempts int         `mapstructure:"max_attempts"`
+	UserID      string      `mapstructure:"user_id"`
+
+	runAt time.Time
+}
+
+func (payload *jobEnqueuePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	if payload.RunAtString != "" {
+		runAt, err := time.Parse(time.RFC3339Nano, payload.RunAtString)
+		if err != nil {
+			return skyerr.NewInvalidArgument("run_at must be in RFC3339 format", []string{"run_at"})
+		}
+		payload.runAt = runAt.UTC()
+	}
+	return payload.Validate()
+}
+
+func (payload *jobEnqueuePayload) Validate() skyerr.Error {
+	if payload.Lambda == "" {
+		return skyerr.NewInvalidArgument("empty lambda", []string{"lambda"})
+	}
+	if payload.Delay < 0 {
+		return skyerr.NewInvalidArgument("delay must not be negative", []string{"delay"})
+	}
+	if payload.Delay > 0 && payload.RunAtString != "" {
+		return skyerr.NewInvalidArgument("delay and run_at are mutually exclusive", []string{"delay", "run_at"})
+	}
+	if payload.MaxAttempts < 0 {
+		return skyerr.NewInvalidArgument("max_attempts must not be negative", []string{"max_attempts"})
+	}
+	return nil
+}
+
+/*
+JobEnqueueHandler enqueues a job to run a plugin lambda in the background
+with args as its parameters, optionally after delay seconds or at run_at.
+If user_id is specified, the lambda is run on behalf of the user.
+
+A failed job is retried with exponential backoff until it has been
+attempted max_attempts times, which is 5 if not specified. The job is then
+dead and kept for inspection with job:list.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "job:enqueue",
+		"lambda": "send_email",
+		"args": {"to": "someone@example.com"},
+		"delay": 60,
+		"max_attempts": 3
+	}
+	EOF
+*/
+type JobEnqueueHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *JobEnqueueHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *JobEnqueueHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *JobEnqueueHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &jobEnqueuePayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	args, err := json.Marshal(p.Args)
+	if err != nil {
+		response.Err = skyerr.NewInvalidArgument("args must be JSON", []string{"args"})
+		return
+	}
+
+	now := timeNow()
+	runAt := p.runAt
+	if runAt.IsZero() {
+		runAt = now.Add(time.Duration(p.Delay * float64(time.Second)))
+	}
+	maxAttempts := p.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = job.DefaultMaxAttempts
+	}
+
+	j := skydb.Job{
+		ID:          uuidNew(),
+		Lambda:      p.Lambda,
+		Args:        args,
+		UserID:      p.UserID,
+		Status:      skydb.JobPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   now,
+	}
+	if err := payload.DBConn.CreateJob(&j); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = j
+}
+
+type jobIDPayload struct {
+	ID string `mapstructure:"id"`
+}
+
+func (payload *jobIDPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *jobIDPayload) Validate() skyerr.Error {
+	if payload.ID == "" {
+		return skyerr.NewInvalidArgument("empty id", []string{"id"})
+	}
+	return nil
+}
+
+func getJob(conn skydb.Conn, id string, j *skydb.Job) skyerr.Error {
+	if err := conn.GetJob(id, j); err != nil {
+		if err == skydb.ErrJobNotFound {
+			return skyerr.NewError(skyerr.ResourceNotFound, "job not found")
+		}
+		return skyerr.MakeError(err)
+	}
+	return nil
+}
+
+/*
+JobStatusHandler returns a job, including its status, attempts, last error
+and the result of the lambda once succeeded.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "job:status",
+		"id": "JOB_ID"
+	}
+	EOF
+*/
+type JobStatusHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *JobStatusHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *JobStatusHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *JobStatusHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &jobIDPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	j := skydb.Job{}
+	if err := getJob(payload.DBConn, p.ID, &j); err != nil {
+		response.Err = err
+		return
+	}
+
+	response.Result = j
+}
+
+type jobListPayload struct {
+	Status string `mapstructure:"status"`
+	Limit  uint64 `mapstructure:"limit"`
+}
+
+func (payload *jobListPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	if payload.Limit == 0 {
+		payload.Limit = defaultJobsLimit
+	}
+	return payload.Validate()
+}
+
+func (payload *jobListPayload) Validate() skyerr.Error {
+	if payload.Status != "" && !skydb.JobStatus(payload.Status).IsValid() {
+		return skyerr.NewInvalidArgument("unknown status "+payload.Status, []string{"status"})
+	}
+	return nil
+}
+
+/*
+JobListHandler returns the latest jobs, optionally of the specified status:
+pending, running, succeeded or dead.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "job:list",
+		"status": "dead",
+		"limit": 50
+	}
+	EOF
+*/
+type JobListHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *JobListHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *JobListHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *JobListHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &jobListPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	jobs, err := payload.DBConn.QueryJobs(skydb.JobStatus(p.Status), p.Limit)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = jobs
+}
+
+/*
+JobRetryHandler runs a job again as soon as possible with its attempts
+reset, regardless of its status. It is usually used to requeue a dead job
+after the cause of its failure is fixed.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "job:retry",
+		"id": "JOB_ID"
+	}
+	EOF
+*/
+type JobRetryHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *JobRetryHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *JobRetryHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *JobRetryHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &jobIDPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	j := skydb.Job{}
+	if err := getJob(payload.DBConn, p.ID, &j); err != nil {
+		response.Err = err
+		return
+	}
+	if j.Status == skydb.JobRunning {
+		response.Err = skyerr.NewInvalidArgument("job is running", []string{"id"})
+		return
+	}
+
+	job.Retry(&j, timeNow())
+	if err := payload.DBConn.UpdateJob(&j); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = j
+}
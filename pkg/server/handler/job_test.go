@@ -0,0 +1,254 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+)
+
+func TestJobEnqueueHandler(t *testing.T) {
+	Convey("JobEnqueueHandler", t, func() {
+		realUUIDNew := uuidNew
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		uuidNew = func() string { return "job-id" }
+		timeNow = func() time.Time { return now }
+		defer func() {
+			uuidNew = realUUIDNew
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		r := handlertest.NewSingleRouteRouter(&JobEnqueueHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("enqueues delayed job", func() {
+			resp := r.POST(`{
+				"lambda": "send_email",
+				"args": {"to": "someone@example.com"},
+				"delay": 60,
+				"user_id": "user-id"
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"id": "job-id",
+					"lambda": "send_email",
+					"args": {"to": "someone@example.com"},
+					"user_id": "user-id",
+					"status": "pending",
+					"attempts": 0,
+					"max_attempts": 5,
+					"run_at": "2017-01-01T00:01:00Z",
+					"created_at": "2017-01-01T00:00:00Z"
+				}
+			}`)
+
+			j := skydb.Job{}
+			So(conn.GetJob("job-id", &j), ShouldBeNil)
+			So(j.RunAt, ShouldResemble, now.Add(time.Minute))
+			So(j.Args, ShouldResemble, json.RawMessage(`{"to":"someone@example.com"}`))
+		})
+
+		Convey("enqueues job at time", func() {
+			resp := r.POST(`{
+				"lambda": "send_email",
+				"run_at": "2017-01-02T08:00:00+08:00",
+				"max_attempts": 1
+			}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			j := skydb.Job{}
+			So(conn.GetJob("job-id", &j), ShouldBeNil)
+			So(j.RunAt, ShouldResemble, time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC))
+			So(j.MaxAttempts, ShouldEqual, 1)
+			So(string(j.Args), ShouldEqual, "null")
+		})
+
+		Convey("rejects both delay and run_at", func() {
+			resp := r.POST(`{
+				"lambda": "send_email",
+				"delay": 60,
+				"run_at": "2017-01-02T00:00:00Z"
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "delay and run_at are mutually exclusive",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["delay", "run_at"]}
+				}
+			}`)
+		})
+
+		Convey("rejects empty lambda", func() {
+			resp := r.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "empty lambda",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["lambda"]}
+				}
+			}`)
+		})
+	})
+}
+
+func TestJobStatusHandler(t *testing.T) {
+	Convey("JobStatusHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.CreateJob(&skydb.Job{
+			ID:          "job-id",
+			Lambda:      "send_email",
+			Args:        json.RawMessage(`null`),
+			Status:      skydb.JobDead,
+			Attempts:    5,
+			MaxAttempts: 5,
+			RunAt:       time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+			LastError:   "smtp unavailable",
+			CreatedAt:   time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		})
+
+		r := handlertest.NewSingleRouteRouter(&JobStatusHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("returns job", func() {
+			resp := r.POST(`{"id": "job-id"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"id": "job-id",
+					"lambda": "send_email",
+					"args": null,
+					"status": "dead",
+					"attempts": 5,
+					"max_attempts": 5,
+					"run_at": "2017-01-01T00:00:00Z",
+					"last_error": "smtp unavailable",
+					"created_at": "2017-01-01T00:00:00Z"
+				}
+			}`)
+		})
+
+		Convey("returns not found for unknown job", func() {
+			resp := r.POST(`{"id": "unknown"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 110,
+					"message": "job not found",
+					"name": "ResourceNotFound"
+				}
+			}`)
+		})
+	})
+}
+
+func TestJobListHandler(t *testing.T) {
+	Convey("JobListHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.CreateJob(&skydb.Job{ID: "dead", Status: skydb.JobDead})
+		conn.CreateJob(&skydb.Job{ID: "pending", Status: skydb.JobPending})
+
+		r := handlertest.NewSingleRouteRouter(&JobListHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("lists jobs of status", func() {
+			resp := r.POST(`{"status": "dead"}`)
+			body := struct {
+				Result []skydb.Job `json:"result"`
+			}{}
+			So(json.Unmarshal(resp.Body.Bytes(), &body), ShouldBeNil)
+			So(body.Result, ShouldHaveLength, 1)
+			So(body.Result[0].ID, ShouldEqual, "dead")
+		})
+
+		Convey("rejects unknown status", func() {
+			resp := r.POST(`{"status": "failed"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "unknown status failed",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["status"]}
+				}
+			}`)
+		})
+	})
+}
+
+func TestJobRetryHandler(t *testing.T) {
+	Convey("JobRetryHandler", t, func() {
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		completedAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		conn := skydbtest.NewMapConn()
+		conn.CreateJob(&skydb.Job{
+			ID:          "dead",
+			Lambda:      "send_email",
+			Status:      skydb.JobDead,
+			Attempts:    5,
+			MaxAttempts: 5,
+			LastError:   "smtp unavailable",
+			CompletedAt: &completedAt,
+		})
+		conn.CreateJob(&skydb.Job{ID: "running", Status: skydb.JobRunning})
+
+		r := handlertest.NewSingleRouteRouter(&JobRetryHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("requeues dead job", func() {
+			resp := r.POST(`{"id": "dead"}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			j := skydb.Job{}
+			So(conn.GetJob("dead", &j), ShouldBeNil)
+			So(j.Status, ShouldEqual, skydb.JobPending)
+			So(j.Attempts, ShouldEqual, 0)
+			So(j.RunAt, ShouldResemble, now)
+			So(j.LastError, ShouldBeEmpty)
+			So(j.CompletedAt, ShouldBeNil)
+		})
+
+		Convey("rejects running job", func() {
+			resp := r.POST(`{"id": "running"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "job is running",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["id"]}
+				}
+			}`)
+		})
+	})
+}
@@ -0,0 +1,53 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+)
+
+// JWKSHandler serves the public keys of a KeySet as a JSON Web Key Set,
+// so that other services can verify tokens signed by the keys without
+// holding any secret.
+//
+//	curl http://localhost:3000/.well-known/jwks.json
+type JWKSHandler struct {
+	KeySet        *jwk.KeySet
+	preprocessors []router.Processor
+}
+
+func (h *JWKSHandler) Setup() {
+	h.preprocessors = []router.Processor{}
+}
+
+func (h *JWKSHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *JWKSHandler) Handle(payload *router.Payload, response *router.Response) {
+	writer := response.Writer()
+	if writer == nil {
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "public, max-age=300")
+	writer.WriteHeader(http.StatusOK)
+	json.NewEncoder(writer).Encode(h.KeySet.JWKS())
+}
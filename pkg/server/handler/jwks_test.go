@@ -0,0 +1,51 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	Convey("JWKSHandler", t, func() {
+		keySet, err := jwk.GenerateKeySet()
+		So(err, ShouldBeNil)
+
+		h := &JWKSHandler{KeySet: keySet}
+		h.Setup()
+
+		Convey("serves public keys", func() {
+			req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+			recorder := httptest.NewRecorder()
+			h.Handle(&router.Payload{Req: req}, router.NewResponse(recorder))
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+
+			jwks := jwk.JSONWebKeySet{}
+			So(json.Unmarshal(recorder.Body.Bytes(), &jwks), ShouldBeNil)
+			So(jwks.Keys, ShouldHaveLength, 1)
+			So(jwks.Keys[0].Kid, ShouldEqual, "default")
+			So(jwks.Keys[0].Kty, ShouldEqual, "RSA")
+			So(jwks.Keys[0].Alg, ShouldEqual, "RS256")
+		})
+	})
+}
@@ -0,0 +1,281 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/skygeario/skygear-server/pkg/server/oauth"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+type oauthClientCreatePayload struct {
+	Name         string   `mapstructure:"name"`
+	RedirectURIs []string `mapstructure:"redirect_uris"`
+	GrantTypes   []string `mapstructure:"grant_types"`
+	Scopes       []string `mapstructure:"scopes"`
+	Public       bool     `mapstructure:"public"`
+}
+
+func (payload *oauthClientCreatePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+
+	if len(payload.GrantTypes) == 0 {
+		payload.GrantTypes = []string{oauth.GrantTypeAuthorizationCode}
+	}
+	if len(payload.Scopes) == 0 {
+		payload.Scopes = oauth.DefaultScopes
+	}
+
+	return payload.Validate()
+}
+
+func (payload *oauthClientCreatePayload) Validate() skyerr.Error {
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty name", []string{"name"})
+	}
+
+	for _, grantType := range payload.GrantTypes {
+		switch grantType {
+		case oauth.GrantTypeAuthorizationCode:
+			if len(payload.RedirectURIs) == 0 {
+				return skyerr.NewInvalidArgument("redirect_uris is required for authorization_code grant", []string{"redirect_uris"})
+			}
+		case oauth.GrantTypeClientCredentials:
+			if payload.Public {
+				return skyerr.NewInvalidArgument("public client cannot use client_credentials grant", []string{"grant_types"})
+			}
+		default:
+			return skyerr.NewInvalidArgument("unsupported grant type: "+grantType, []string{"grant_types"})
+		}
+	}
+
+	return nil
+}
+
+type oauthClientResponse struct {
+	*skydb.OAuthClient
+	Public       bool   `json:"public"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+/*
+OAuthClientCreateHandler registers a client of the OAuth authorization
+server. The client secret is generated and returned only once in the
+response; a public client does not have a secret and must use PKCE.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "oauth:client:create",
+		"name": "My App",
+		"redirect_uris": ["https://app.example.com/callback"],
+		"grant_types": ["authorization_code"],
+		"scopes": ["openid", "profile", "email"]
+	}
+	EOF
+*/
+type OAuthClientCreateHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *OAuthClientCreateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *OAuthClientCreateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *OAuthClientCreateHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &oauthClientCreatePayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	now := timeNow()
+	client := skydb.OAuthClient{
+		ID:           uuidNew(),
+		Name:         p.Name,
+		RedirectURIs: p.RedirectURIs,
+		GrantTypes:   p.GrantTypes,
+		Scopes:       p.Scopes,
+		CreatedAt:    &now,
+	}
+
+	secret := ""
+	if !p.Public {
+		secret = oauth.GenerateSecret()
+		client.SetSecret(secret)
+	}
+
+	if err := payload.DBConn.CreateOAuthClient(&client); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = oauthClientResponse{
+		OAuthClient:  &client,
+		Public:       client.IsPublic(),
+		ClientSecret: secret,
+	}
+}
+
+type oauthClientIDPayload struct {
+	ClientID string `mapstructure:"client_id"`
+}
+
+func (payload *oauthClientIDPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *oauthClientIDPayload) Validate() skyerr.Error {
+	if payload.ClientID == "" {
+		return skyerr.NewInvalidArgument("empty client_id", []string{"client_id"})
+	}
+	return nil
+}
+
+/*
+OAuthClientGetHandler returns a registered client of the OAuth
+authorization server.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "oauth:client:get",
+		"client_id": "CLIENT_ID"
+	}
+	EOF
+*/
+type OAuthClientGetHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *OAuthClientGetHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *OAuthClientGetHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *OAuthClientGetHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &oauthClientIDPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	client := skydb.OAuthClient{}
+	if err := payload.DBConn.GetOAuthClient(p.ClientID, &client); err != nil {
+		if err == skydb.ErrOAuthClientNotFound {
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "client not found")
+		} else {
+			response.Err = skyerr.MakeError(err)
+		}
+		return
+	}
+
+	response.Result = oauthClientResponse{
+		OAuthClient: &client,
+		Public:      client.IsPublic(),
+	}
+}
+
+/*
+OAuthClientDeleteHandler deletes a registered client of the OAuth
+authorization server. Authorization codes issued to the client are
+deleted as well; issued access tokens remain valid until expiry.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "oauth:client:delete",
+		"client_id": "CLIENT_ID"
+	}
+	EOF
+*/
+type OAuthClientDeleteHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *OAuthClientDeleteHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *OAuthClientDeleteHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *OAuthClientDeleteHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &oauthClientIDPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	if err := payload.DBConn.DeleteOAuthClient(p.ClientID); err != nil {
+		if err == skydb.ErrOAuthClientNotFound {
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "client not found")
+		} else {
+			response.Err = skyerr.MakeError(err)
+		}
+		return
+	}
+
+	response.Result = statusResponse{Status: "OK"}
+}
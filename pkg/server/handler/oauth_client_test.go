@@ -0,0 +1,110 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+)
+
+func TestOAuthClientCreateHandler(t *testing.T) {
+	Convey("OAuthClientCreateHandler", t, func() {
+		realUUIDNew := uuidNew
+		uuidNew = func() string { return "client-id" }
+		defer func() {
+			uuidNew = realUUIDNew
+		}()
+
+		conn := skydbtest.NewMapConn()
+		r := handlertest.NewSingleRouteRouter(&OAuthClientCreateHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("creates confidential client with secret", func() {
+			resp := r.POST(`{
+				"name": "My App",
+				"redirect_uris": ["https://app.example.com/callback"]
+			}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			client := skydb.OAuthClient{}
+			So(conn.GetOAuthClient("client-id", &client), ShouldBeNil)
+			So(client.Name, ShouldEqual, "My App")
+			So(client.GrantTypes, ShouldResemble, []string{"authorization_code"})
+			So(client.Scopes, ShouldResemble, []string{"openid", "profile", "email", "roles"})
+			So(client.IsPublic(), ShouldBeFalse)
+			So(resp.Body.String(), ShouldContainSubstring, `"client_secret"`)
+		})
+
+		Convey("creates public client without secret", func() {
+			resp := r.POST(`{
+				"name": "My App",
+				"redirect_uris": ["https://app.example.com/callback"],
+				"public": true
+			}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(resp.Body.String(), ShouldNotContainSubstring, `"client_secret"`)
+
+			client := skydb.OAuthClient{}
+			So(conn.GetOAuthClient("client-id", &client), ShouldBeNil)
+			So(client.IsPublic(), ShouldBeTrue)
+		})
+
+		Convey("rejects authorization code client without redirect uri", func() {
+			resp := r.POST(`{"name": "My App"}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+
+		Convey("rejects public client with client credentials grant", func() {
+			resp := r.POST(`{
+				"name": "My App",
+				"grant_types": ["client_credentials"],
+				"public": true
+			}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+	})
+}
+
+func TestOAuthClientDeleteHandler(t *testing.T) {
+	Convey("OAuthClientDeleteHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.CreateOAuthClient(&skydb.OAuthClient{ID: "client-id"})
+
+		r := handlertest.NewSingleRouteRouter(&OAuthClientDeleteHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("deletes client", func() {
+			resp := r.POST(`{"client_id": "client-id"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{"result": {"status": "OK"}}`)
+
+			client := skydb.OAuthClient{}
+			So(conn.GetOAuthClient("client-id", &client), ShouldEqual, skydb.ErrOAuthClientNotFound)
+		})
+
+		Convey("returns not found for unknown client", func() {
+			resp := r.POST(`{"client_id": "unknown"}`)
+			So(resp.Code, ShouldEqual, 404)
+		})
+	})
+}
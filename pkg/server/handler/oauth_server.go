@@ -0,0 +1,465 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/oauth"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+// writeOAuthJSON writes a JSON response as defined in RFC 6749, bypassing
+// the response envelope of the router.
+func writeOAuthJSON(response *router.Response, status int, v interface{}) {
+	writer := response.Writer()
+	if writer == nil {
+		return
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Cache-Control", "no-store")
+	writer.Header().Set("Pragma", "no-cache")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(v)
+}
+
+func writeOAuthError(response *router.Response, err *oauth.Error) {
+	status := http.StatusBadRequest
+	if err.Code == "invalid_client" || err.Code == "invalid_token" {
+		status = http.StatusUnauthorized
+	}
+	writeOAuthJSON(response, status, err)
+}
+
+/*
+OAuthAuthorizeHandler handles the authorization endpoint of the OAuth
+authorization server. Only the code response type is supported.
+
+The request must be authenticated with the access token of the user
+granting the authorization. Once the request is validated, the user
+agent is redirected to the redirect URI with the authorization code.
+
+	curl -i -H "X-Skygear-Access-Token: ACCESS_TOKEN" \
+	  "http://localhost:3000/oauth/authorize?response_type=code&client_id=CLIENT_ID&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcallback&scope=openid+profile&state=STATE"
+*/
+type OAuthAuthorizeHandler struct {
+	OAuthServer    *oauth.Server    `inject:"OAuthServer"`
+	Authenticator  router.Processor `preprocessor:"authenticator"`
+	DBConn         router.Processor `preprocessor:"dbconn"`
+	InjectAuth     router.Processor `preprocessor:"require_auth"`
+	InjectPublicDB router.Processor `preprocessor:"inject_public_db"`
+	preprocessors  []router.Processor
+}
+
+func (h *OAuthAuthorizeHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.InjectPublicDB,
+	}
+}
+
+func (h *OAuthAuthorizeHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *OAuthAuthorizeHandler) Handle(payload *router.Payload, response *router.Response) {
+	query := payload.Req.URL.Query()
+
+	clientID := query.Get("client_id")
+	if clientID == "" {
+		response.Err = skyerr.NewInvalidArgument("empty client_id", []string{"client_id"})
+		return
+	}
+
+	client := skydb.OAuthClient{}
+	if err := payload.DBConn.GetOAuthClient(clientID, &client); err != nil {
+		if err == skydb.ErrOAuthClientNotFound {
+			response.Err = skyerr.NewInvalidArgument("unknown client_id", []string{"client_id"})
+		} else {
+			response.Err = skyerr.MakeError(err)
+		}
+		return
+	}
+
+	// Errors are not redirected until the redirect URI is known to be
+	// registered by the client.
+	redirectURI := query.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.HasRedirectURI(redirectURI) {
+		response.Err = skyerr.NewInvalidArgument("redirect_uri is not registered", []string{"redirect_uri"})
+		return
+	}
+
+	state := query.Get("state")
+	redirectError := func(err *oauth.Error) {
+		params := url.Values{}
+		params.Set("error", err.Code)
+		params.Set("error_description", err.Description)
+		redirectOAuthResponse(payload, response, redirectURI, state, params)
+	}
+
+	if query.Get("response_type") != "code" {
+		redirectError(oauth.NewError("unsupported_response_type", "only code response type is supported"))
+		return
+	}
+
+	if !client.HasGrantType(oauth.GrantTypeAuthorizationCode) {
+		redirectError(oauth.NewError("unauthorized_client", "client is not allowed to use authorization code grant"))
+		return
+	}
+
+	scopes := oauth.ParseScope(query.Get("scope"))
+	for _, scope := range scopes {
+		if !client.HasScope(scope) {
+			redirectError(oauth.NewError("invalid_scope", "client is not allowed to request scope "+scope))
+			return
+		}
+	}
+
+	codeChallenge := query.Get("code_challenge")
+	codeChallengeMethod := query.Get("code_challenge_method")
+	if codeChallenge == "" && client.IsPublic() {
+		redirectError(oauth.NewError("invalid_request", "code_challenge is required for public client"))
+		return
+	}
+	if codeChallenge != "" && codeChallengeMethod != oauth.CodeChallengeMethodS256 {
+		redirectError(oauth.NewError("invalid_request", "only S256 code_challenge_method is supported"))
+		return
+	}
+
+	code := h.OAuthServer.NewAuthorizationCode(client.ID, payload.AuthInfo.ID, redirectURI, scopes)
+	code.Nonce = query.Get("nonce")
+	code.CodeChallenge = codeChallenge
+	if codeChallenge != "" {
+		code.CodeChallengeMethod = codeChallengeMethod
+	}
+
+	if err := payload.DBConn.CreateOAuthAuthorizationCode(&code); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	params := url.Values{}
+	params.Set("code", code.Code)
+	redirectOAuthResponse(payload, response, redirectURI, state, params)
+}
+
+func redirectOAuthResponse(payload *router.Payload, response *router.Response, redirectURI string, state string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		response.Err = skyerr.NewInvalidArgument("malformed redirect_uri", []string{"redirect_uri"})
+		return
+	}
+
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if state != "" {
+		query.Set("state", state)
+	}
+	u.RawQuery = query.Encode()
+
+	writer := response.Writer()
+	if writer == nil {
+		return
+	}
+	http.Redirect(writer, payload.Req, u.String(), http.StatusFound)
+}
+
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+/*
+OAuthTokenHandler handles the token endpoint of the OAuth authorization
+server, supporting authorization code grant (with PKCE) and client
+credentials grant. The client authenticates with HTTP Basic
+authentication or with client_id and client_secret in the form; a public
+client sends client_id only.
+
+	curl -X POST -u CLIENT_ID:CLIENT_SECRET \
+	  -d grant_type=authorization_code \
+	  -d code=CODE \
+	  -d redirect_uri=https://app.example.com/callback \
+	  http://localhost:3000/oauth/token
+*/
+type OAuthTokenHandler struct {
+	OAuthServer    *oauth.Server    `inject:"OAuthServer"`
+	DBConn         router.Processor `preprocessor:"dbconn"`
+	InjectPublicDB router.Processor `preprocessor:"inject_public_db"`
+	preprocessors  []router.Processor
+}
+
+func (h *OAuthTokenHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.DBConn,
+		h.InjectPublicDB,
+	}
+}
+
+func (h *OAuthTokenHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *OAuthTokenHandler) Handle(payload *router.Payload, response *router.Response) {
+	logger := logging.CreateLogger(payload.Context(), "handler")
+
+	req := payload.Req
+	if err := req.ParseForm(); err != nil {
+		writeOAuthError(response, oauth.NewError("invalid_request", "malformed request body"))
+		return
+	}
+
+	client, clientAuthenticated, oauthErr := h.authenticateClient(payload)
+	if oauthErr != nil {
+		writeOAuthError(response, oauthErr)
+		return
+	}
+
+	grantType := req.PostForm.Get("grant_type")
+	if !client.HasGrantType(grantType) {
+		writeOAuthError(response, oauth.NewError("unauthorized_client", "client is not allowed to use grant type "+grantType))
+		return
+	}
+
+	var resp oauthTokenResponse
+	switch grantType {
+	case oauth.GrantTypeAuthorizationCode:
+		resp, oauthErr = h.grantAuthorizationCode(payload, &client, clientAuthenticated)
+	case oauth.GrantTypeClientCredentials:
+		resp, oauthErr = h.grantClientCredentials(payload, &client, clientAuthenticated)
+	default:
+		oauthErr = oauth.NewError("unsupported_grant_type", "grant type is not supported")
+	}
+
+	if oauthErr != nil {
+		logger.WithField("client_id", client.ID).Infof("Token request rejected: %v", oauthErr)
+		writeOAuthError(response, oauthErr)
+		return
+	}
+
+	writeOAuthJSON(response, http.StatusOK, resp)
+}
+
+// authenticateClient returns the client making the request, and whether
+// the client is authenticated with its secret.
+func (h *OAuthTokenHandler) authenticateClient(payload *router.Payload) (skydb.OAuthClient, bool, *oauth.Error) {
+	req := payload.Req
+	client := skydb.OAuthClient{}
+
+	clientID, clientSecret, hasBasicAuth := req.BasicAuth()
+	if !hasBasicAuth {
+		clientID = req.PostForm.Get("client_id")
+		clientSecret = req.PostForm.Get("client_secret")
+	}
+
+	if clientID == "" {
+		return client, false, oauth.NewError("invalid_client", "client authentication failed")
+	}
+
+	if err := payload.DBConn.GetOAuthClient(clientID, &client); err != nil {
+		return client, false, oauth.NewError("invalid_client", "client authentication failed")
+	}
+
+	if client.IsPublic() {
+		return client, false, nil
+	}
+
+	if !client.IsSameSecret(clientSecret) {
+		return client, false, oauth.NewError("invalid_client", "client authentication failed")
+	}
+
+	return client, true, nil
+}
+
+func (h *OAuthTokenHandler) grantAuthorizationCode(payload *router.Payload, client *skydb.OAuthClient, clientAuthenticated bool) (oauthTokenResponse, *oauth.Error) {
+	form := payload.Req.PostForm
+	resp := oauthTokenResponse{}
+
+	code := skydb.OAuthAuthorizationCode{}
+	if err := payload.DBConn.ConsumeOAuthAuthorizationCode(form.Get("code"), &code); err != nil {
+		if err == skydb.ErrOAuthCodeNotFound {
+			return resp, oauth.NewError("invalid_grant", "authorization code is invalid or expired")
+		}
+		return resp, oauth.NewError("server_error", "failed to consume authorization code")
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != form.Get("redirect_uri") {
+		return resp, oauth.NewError("invalid_grant", "authorization code is not issued to this client")
+	}
+
+	if code.CodeChallenge != "" {
+		if !oauth.VerifyCodeChallenge(code.CodeChallenge, code.CodeChallengeMethod, form.Get("code_verifier")) {
+			return resp, oauth.NewError("invalid_grant", "code_verifier does not match code_challenge")
+		}
+	} else if !clientAuthenticated {
+		return resp, oauth.NewError("invalid_grant", "code_verifier is required for public client")
+	}
+
+	authInfo := skydb.AuthInfo{}
+	if err := payload.DBConn.GetAuth(code.UserID, &authInfo); err != nil {
+		return resp, oauth.NewError("invalid_grant", "user not found")
+	}
+	if authInfo.IsDisabled() {
+		return resp, oauth.NewError("invalid_grant", "user is disabled")
+	}
+
+	accessToken, err := h.OAuthServer.IssueAccessToken(client.ID, authInfo.ID, code.Scopes)
+	if err != nil {
+		return resp, oauth.NewError("server_error", "failed to issue access token")
+	}
+
+	resp = oauthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(h.OAuthServer.AccessTokenExpiry.Seconds()),
+		Scope:       strings.Join(code.Scopes, " "),
+	}
+
+	if oauth.HasScope(code.Scopes, oauth.ScopeOpenID) {
+		user := fetchOAuthUserRecord(payload, authInfo.ID)
+		userInfo := h.OAuthServer.UserInfo(&authInfo, user, code.Scopes)
+		resp.IDToken, err = h.OAuthServer.IssueIDToken(client.ID, authInfo.ID, code.Nonce, userInfo)
+		if err != nil {
+			return oauthTokenResponse{}, oauth.NewError("server_error", "failed to issue id token")
+		}
+	}
+
+	return resp, nil
+}
+
+func (h *OAuthTokenHandler) grantClientCredentials(payload *router.Payload, client *skydb.OAuthClient, clientAuthenticated bool) (oauthTokenResponse, *oauth.Error) {
+	resp := oauthTokenResponse{}
+	if !clientAuthenticated {
+		return resp, oauth.NewError("unauthorized_client", "public client cannot use client credentials grant")
+	}
+
+	scopes := oauth.ParseScope(payload.Req.PostForm.Get("scope"))
+	for _, scope := range scopes {
+		if scope == oauth.ScopeOpenID || !client.HasScope(scope) {
+			return resp, oauth.NewError("invalid_scope", "client is not allowed to request scope "+scope)
+		}
+	}
+
+	accessToken, err := h.OAuthServer.IssueAccessToken(client.ID, client.ID, scopes)
+	if err != nil {
+		return resp, oauth.NewError("server_error", "failed to issue access token")
+	}
+
+	return oauthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(h.OAuthServer.AccessTokenExpiry.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// fetchOAuthUserRecord returns the user record of the user, or nil if
+// the record cannot be fetched. The record is fetched bypassing access
+// control as the user is not the requester of the token endpoint.
+func fetchOAuthUserRecord(payload *router.Payload, userID string) *skydb.Record {
+	user := skydb.Record{}
+	recordID := skydb.NewRecordID(payload.Database.UserRecordType(), userID)
+	if err := payload.Database.Get(recordID, &user); err != nil {
+		return nil
+	}
+	return &user
+}
+
+/*
+OAuthUserInfoHandler handles the UserInfo endpoint of the OpenID Connect
+provider. Claims are derived from the user record and the roles of the
+user, limited by the scopes granted to the access token.
+
+	curl -H "Authorization: Bearer OAUTH_ACCESS_TOKEN" \
+	  http://localhost:3000/oauth/userinfo
+*/
+type OAuthUserInfoHandler struct {
+	OAuthServer    *oauth.Server    `inject:"OAuthServer"`
+	DBConn         router.Processor `preprocessor:"dbconn"`
+	InjectPublicDB router.Processor `preprocessor:"inject_public_db"`
+	preprocessors  []router.Processor
+}
+
+func (h *OAuthUserInfoHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.DBConn,
+		h.InjectPublicDB,
+	}
+}
+
+func (h *OAuthUserInfoHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *OAuthUserInfoHandler) Handle(payload *router.Payload, response *router.Response) {
+	invalidToken := oauth.NewError("invalid_token", "access token is invalid")
+
+	authorization := payload.Req.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		writeOAuthError(response, invalidToken)
+		return
+	}
+
+	claims, err := h.OAuthServer.VerifyAccessToken(strings.TrimPrefix(authorization, "Bearer "))
+	if err != nil || claims.IsClientToken() {
+		writeOAuthError(response, invalidToken)
+		return
+	}
+
+	authInfo := skydb.AuthInfo{}
+	if err := payload.DBConn.GetAuth(claims.Subject, &authInfo); err != nil {
+		writeOAuthError(response, invalidToken)
+		return
+	}
+
+	user := fetchOAuthUserRecord(payload, authInfo.ID)
+	writeOAuthJSON(response, http.StatusOK, h.OAuthServer.UserInfo(&authInfo, user, claims.Scopes()))
+}
+
+// OAuthDiscoveryHandler serves the OpenID Provider Metadata at
+// /.well-known/openid-configuration.
+type OAuthDiscoveryHandler struct {
+	OAuthServer   *oauth.Server `inject:"OAuthServer"`
+	preprocessors []router.Processor
+}
+
+func (h *OAuthDiscoveryHandler) Setup() {
+	h.preprocessors = []router.Processor{}
+}
+
+func (h *OAuthDiscoveryHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *OAuthDiscoveryHandler) Handle(payload *router.Payload, response *router.Response) {
+	writeOAuthJSON(response, http.StatusOK, h.OAuthServer.Discovery())
+}
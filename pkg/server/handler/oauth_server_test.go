@@ -0,0 +1,312 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
+	"github.com/skygeario/skygear-server/pkg/server/oauth"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func newTestOAuthServer() *oauth.Server {
+	keySet, err := jwk.GenerateKeySet()
+	if err != nil {
+		panic(err)
+	}
+	return &oauth.Server{
+		KeySet:            keySet,
+		Issuer:            "https://skygear.example.com",
+		AccessTokenExpiry: time.Hour,
+		IDTokenExpiry:     time.Hour,
+		CodeExpiry:        10 * time.Minute,
+	}
+}
+
+func handleOAuthRequest(h router.Handler, req *http.Request, conn *skydbtest.MapConn, db skydb.Database, authInfo *skydb.AuthInfo) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	payload := &router.Payload{
+		Req:      req,
+		Meta:     map[string]interface{}{},
+		Data:     map[string]interface{}{},
+		DBConn:   conn,
+		Database: db,
+		AuthInfo: authInfo,
+	}
+	h.Handle(payload, router.NewResponse(recorder))
+	return recorder
+}
+
+func newTokenRequest(form url.Values) *http.Request {
+	req, _ := http.NewRequest("POST", "/oauth/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func decodeOAuthResponse(recorder *httptest.ResponseRecorder) map[string]interface{} {
+	body := map[string]interface{}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		panic(err)
+	}
+	return body
+}
+
+func TestOAuthServerHandlers(t *testing.T) {
+	Convey("OAuth server handlers", t, func() {
+		server := newTestOAuthServer()
+
+		conn := skydbtest.NewMapConn()
+		db := skydbtest.NewMapDB()
+
+		authInfo := skydb.AuthInfo{
+			ID:    "user-id",
+			Roles: []string{"admin"},
+		}
+		conn.CreateAuth(&authInfo)
+		db.Save(&skydb.Record{
+			ID: skydb.NewRecordID("user", "user-id"),
+			Data: map[string]interface{}{
+				"username": "john",
+				"email":    "john@example.com",
+			},
+		})
+
+		confidentialClient := skydb.OAuthClient{
+			ID:           "confidential",
+			RedirectURIs: []string{"https://app.example.com/callback"},
+			GrantTypes:   []string{oauth.GrantTypeAuthorizationCode, oauth.GrantTypeClientCredentials},
+			Scopes:       []string{"openid", "profile", "email", "roles"},
+		}
+		confidentialClient.SetSecret("secret")
+		conn.CreateOAuthClient(&confidentialClient)
+
+		publicClient := skydb.OAuthClient{
+			ID:           "public",
+			RedirectURIs: []string{"https://app.example.com/callback"},
+			GrantTypes:   []string{oauth.GrantTypeAuthorizationCode},
+			Scopes:       []string{"openid", "email"},
+		}
+		conn.CreateOAuthClient(&publicClient)
+
+		authorizeHandler := &OAuthAuthorizeHandler{OAuthServer: server}
+		tokenHandler := &OAuthTokenHandler{OAuthServer: server}
+		userInfoHandler := &OAuthUserInfoHandler{OAuthServer: server}
+
+		authorize := func(query url.Values) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("GET", "/oauth/authorize?"+query.Encode(), nil)
+			return handleOAuthRequest(authorizeHandler, req, conn, db, &authInfo)
+		}
+
+		codeFromRedirect := func(recorder *httptest.ResponseRecorder) url.Values {
+			So(recorder.Code, ShouldEqual, http.StatusFound)
+			location, err := url.Parse(recorder.Header().Get("Location"))
+			So(err, ShouldBeNil)
+			So(location.Host, ShouldEqual, "app.example.com")
+			return location.Query()
+		}
+
+		Convey("issues tokens with authorization code grant", func() {
+			params := codeFromRedirect(authorize(url.Values{
+				"response_type": {"code"},
+				"client_id":     {"confidential"},
+				"redirect_uri":  {"https://app.example.com/callback"},
+				"scope":         {"openid profile roles"},
+				"state":         {"xyz"},
+				"nonce":         {"n-0S6_WzA2Mj"},
+			}))
+			So(params.Get("state"), ShouldEqual, "xyz")
+			So(params.Get("code"), ShouldNotBeEmpty)
+
+			req := newTokenRequest(url.Values{
+				"grant_type":   {"authorization_code"},
+				"code":         {params.Get("code")},
+				"redirect_uri": {"https://app.example.com/callback"},
+			})
+			req.SetBasicAuth("confidential", "secret")
+			recorder := handleOAuthRequest(tokenHandler, req, conn, db, nil)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+			So(recorder.Header().Get("Cache-Control"), ShouldEqual, "no-store")
+
+			body := decodeOAuthResponse(recorder)
+			So(body["token_type"], ShouldEqual, "Bearer")
+			So(body["expires_in"], ShouldEqual, float64(3600))
+			So(body["scope"], ShouldEqual, "openid profile roles")
+
+			idToken, err := jwt.Parse(body["id_token"].(string), server.KeySet.Keyfunc)
+			So(err, ShouldBeNil)
+			claims := idToken.Claims.(jwt.MapClaims)
+			So(claims["iss"], ShouldEqual, "https://skygear.example.com")
+			So(claims["aud"], ShouldEqual, "confidential")
+			So(claims["sub"], ShouldEqual, "user-id")
+			So(claims["nonce"], ShouldEqual, "n-0S6_WzA2Mj")
+			So(claims["preferred_username"], ShouldEqual, "john")
+			So(claims["email"], ShouldBeNil)
+			So(claims["roles"], ShouldResemble, []interface{}{"admin"})
+
+			Convey("serves user info with the access token", func() {
+				req, _ := http.NewRequest("GET", "/oauth/userinfo", nil)
+				req.Header.Set("Authorization", "Bearer "+body["access_token"].(string))
+				recorder := handleOAuthRequest(userInfoHandler, req, conn, db, nil)
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(decodeOAuthResponse(recorder), ShouldResemble, map[string]interface{}{
+					"sub":                "user-id",
+					"preferred_username": "john",
+					"roles":              []interface{}{"admin"},
+				})
+			})
+
+			Convey("rejects id token at user info endpoint", func() {
+				req, _ := http.NewRequest("GET", "/oauth/userinfo", nil)
+				req.Header.Set("Authorization", "Bearer "+body["id_token"].(string))
+				recorder := handleOAuthRequest(userInfoHandler, req, conn, db, nil)
+				So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			})
+
+			Convey("rejects reused code", func() {
+				recorder := handleOAuthRequest(tokenHandler, req, conn, db, nil)
+				So(recorder.Code, ShouldEqual, http.StatusBadRequest)
+				So(decodeOAuthResponse(recorder)["error"], ShouldEqual, "invalid_grant")
+			})
+		})
+
+		Convey("requires PKCE for public client", func() {
+			params := codeFromRedirect(authorize(url.Values{
+				"response_type": {"code"},
+				"client_id":     {"public"},
+				"scope":         {"openid email"},
+			}))
+			So(params.Get("error"), ShouldEqual, "invalid_request")
+			So(params.Get("code"), ShouldBeEmpty)
+		})
+
+		Convey("verifies PKCE code verifier", func() {
+			verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+			sum := sha256.Sum256([]byte(verifier))
+			params := codeFromRedirect(authorize(url.Values{
+				"response_type":         {"code"},
+				"client_id":             {"public"},
+				"scope":                 {"openid email"},
+				"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+				"code_challenge_method": {"S256"},
+			}))
+			code := params.Get("code")
+			So(code, ShouldNotBeEmpty)
+
+			Convey("with correct verifier", func() {
+				recorder := handleOAuthRequest(tokenHandler, newTokenRequest(url.Values{
+					"grant_type":    {"authorization_code"},
+					"client_id":     {"public"},
+					"code":          {code},
+					"redirect_uri":  {"https://app.example.com/callback"},
+					"code_verifier": {verifier},
+				}), conn, db, nil)
+				So(recorder.Code, ShouldEqual, http.StatusOK)
+				So(decodeOAuthResponse(recorder)["id_token"], ShouldNotBeEmpty)
+			})
+
+			Convey("with wrong verifier", func() {
+				recorder := handleOAuthRequest(tokenHandler, newTokenRequest(url.Values{
+					"grant_type":    {"authorization_code"},
+					"client_id":     {"public"},
+					"code":          {code},
+					"redirect_uri":  {"https://app.example.com/callback"},
+					"code_verifier": {"wrong"},
+				}), conn, db, nil)
+				So(recorder.Code, ShouldEqual, http.StatusBadRequest)
+				So(decodeOAuthResponse(recorder)["error"], ShouldEqual, "invalid_grant")
+			})
+		})
+
+		Convey("rejects unregistered redirect uri without redirect", func() {
+			req, _ := http.NewRequest("GET", "/oauth/authorize?"+url.Values{
+				"response_type": {"code"},
+				"client_id":     {"confidential"},
+				"redirect_uri":  {"https://evil.example.com/callback"},
+			}.Encode(), nil)
+			recorder := httptest.NewRecorder()
+			response := router.NewResponse(recorder)
+			authorizeHandler.Handle(&router.Payload{
+				Req:      req,
+				DBConn:   conn,
+				Database: db,
+				AuthInfo: &authInfo,
+			}, response)
+
+			So(response.Err, ShouldNotBeNil)
+			So(recorder.Header().Get("Location"), ShouldBeEmpty)
+		})
+
+		Convey("rejects scope not allowed for client", func() {
+			params := codeFromRedirect(authorize(url.Values{
+				"response_type":         {"code"},
+				"client_id":             {"public"},
+				"scope":                 {"openid roles"},
+				"code_challenge":        {"challenge"},
+				"code_challenge_method": {"S256"},
+			}))
+			So(params.Get("error"), ShouldEqual, "invalid_scope")
+		})
+
+		Convey("issues token with client credentials grant", func() {
+			recorder := handleOAuthRequest(tokenHandler, newTokenRequest(url.Values{
+				"grant_type":    {"client_credentials"},
+				"client_id":     {"confidential"},
+				"client_secret": {"secret"},
+				"scope":         {"email"},
+			}), conn, db, nil)
+			So(recorder.Code, ShouldEqual, http.StatusOK)
+
+			body := decodeOAuthResponse(recorder)
+			So(body["id_token"], ShouldBeNil)
+			claims, err := server.VerifyAccessToken(body["access_token"].(string))
+			So(err, ShouldBeNil)
+			So(claims.Subject, ShouldEqual, "confidential")
+			So(claims.IsClientToken(), ShouldBeTrue)
+		})
+
+		Convey("rejects wrong client secret", func() {
+			recorder := handleOAuthRequest(tokenHandler, newTokenRequest(url.Values{
+				"grant_type":    {"client_credentials"},
+				"client_id":     {"confidential"},
+				"client_secret": {"wrong"},
+			}), conn, db, nil)
+			So(recorder.Code, ShouldEqual, http.StatusUnauthorized)
+			So(decodeOAuthResponse(recorder)["error"], ShouldEqual, "invalid_client")
+		})
+
+		Convey("rejects client credentials grant of public client", func() {
+			recorder := handleOAuthRequest(tokenHandler, newTokenRequest(url.Values{
+				"grant_type": {"client_credentials"},
+				"client_id":  {"public"},
+			}), conn, db, nil)
+			So(recorder.Code, ShouldEqual, http.StatusBadRequest)
+			So(decodeOAuthResponse(recorder)["error"], ShouldEqual, "unauthorized_client")
+		})
+	})
+}
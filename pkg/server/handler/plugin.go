@@ -0,0 +1,71 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+type pluginReloadResponse struct {
+	Plugins []plugin.ReloadResult `json:"plugins"`
+}
+
+/*
+PluginReloadHandler reloads the plugins without restarting the server. The
+init handshake is run with each plugin again, and the handlers, lambdas,
+hooks, timers and providers registered by the plugin are replaced with the
+ones returned. Requests in progress are completed by the registrations
+they are matched to. The registrations added and removed by each plugin
+are returned.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "plugin:reload"
+	}
+	EOF
+*/
+type PluginReloadHandler struct {
+	PluginContext    *plugin.Context  `inject:"PluginContext"`
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *PluginReloadHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *PluginReloadHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *PluginReloadHandler) Handle(payload *router.Payload, response *router.Response) {
+	results, err := h.PluginContext.ReloadPlugins()
+	if err != nil {
+		response.Err = skyerr.NewError(skyerr.PluginUnavailable, err.Error())
+		return
+	}
+
+	response.Result = pluginReloadResponse{results}
+}
@@ -0,0 +1,44 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+)
+
+func TestPluginReloadHandler(t *testing.T) {
+	Convey("PluginReloadHandler", t, func() {
+		r := handlertest.NewSingleRouteRouter(&PluginReloadHandler{
+			PluginContext: &plugin.Context{},
+		}, func(p *router.Payload) {})
+
+		Convey("reloads no plugins", func() {
+			resp := r.POST(`{}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"plugins": []
+				}
+			}`)
+		})
+	})
+}
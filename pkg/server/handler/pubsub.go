@@ -22,12 +22,14 @@ import (
 type PubSubHandler struct {
 	WebSocket     *pubsub.WsPubSub
 	AccessKey     router.Processor `preprocessor:"accesskey"`
+	InjectAuthID  router.Processor `preprocessor:"inject_auth_id"`
 	preprocessors []router.Processor
 }
 
 func (h *PubSubHandler) Setup() {
 	h.preprocessors = []router.Processor{
 		h.AccessKey,
+		h.InjectAuthID,
 	}
 }
 
@@ -42,5 +44,5 @@ func (h *PubSubHandler) Handle(payload *router.Payload, response *router.Respons
 		return
 	}
 
-	h.WebSocket.Handle(writer, payload.Req)
+	h.WebSocket.HandleUser(writer, payload.Req, payload.AuthInfoID)
 }
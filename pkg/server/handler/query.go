@@ -84,180 +84,28 @@ func (parser *QueryParser) sortsFromRaw(rawSorts []interface{}) []skydb.Sort {
 	return sorts
 }
 
-func (parser *QueryParser) predicateOperatorFromString(operatorString string) skydb.Operator {
-	switch operatorString {
-	case "and":
-		return skydb.And
-	case "or":
-		return skydb.Or
-	case "not":
-		return skydb.Not
-	case "eq":
-		return skydb.Equal
-	case "gt":
-		return skydb.GreaterThan
-	case "lt":
-		return skydb.LessThan
-	case "gte":
-		return skydb.GreaterThanOrEqual
-	case "lte":
-		return skydb.LessThanOrEqual
-	case "neq":
-		return skydb.NotEqual
-	case "like":
-		return skydb.Like
-	case "ilike":
-		return skydb.ILike
-	case "in":
-		return skydb.In
-	case "func":
-		return skydb.Functional
-	default:
-		panic(fmt.Errorf("unrecognized operator = %s", operatorString))
-	}
+func (parser *QueryParser) predicateParser() *skyconv.PredicateParser {
+	return &skyconv.PredicateParser{UserID: parser.UserID}
 }
 
+// predicateFromRaw parses the specified structure into a Predicate struct,
+// with {"$type": "user"} substituted by the current user.
 func (parser *QueryParser) predicateFromRaw(rawPredicate []interface{}) skydb.Predicate {
-	if len(rawPredicate) < 2 {
-		panic(fmt.Errorf("got len(predicate) = %v, want at least 2", len(rawPredicate)))
-	}
-
-	rawOperator, ok := rawPredicate[0].(string)
-	if !ok {
-		panic(fmt.Errorf("got predicate[0]'s type = %T, want string", rawPredicate[0]))
-	}
-
-	predicate := skydb.Predicate{
-		Operator: parser.predicateOperatorFromString(rawOperator),
-		Children: make([]interface{}, 0),
-	}
-	if predicate.Operator == skydb.Functional {
-		predicate.Children = append(predicate.Children, parser.parseExpression(rawPredicate))
-	} else if predicate.Operator.IsCompound() {
-		for i := 1; i < len(rawPredicate); i++ {
-			subRawPredicate, ok := rawPredicate[i].([]interface{})
-			if !ok {
-				panic(fmt.Errorf("got non-dict in subpredicate at %v", i-1))
-			}
-			predicate.Children = append(predicate.Children, parser.predicateFromRaw(subRawPredicate))
-		}
-	} else {
-		for i := 1; i < len(rawPredicate); i++ {
-			expr := parser.parseExpression(rawPredicate[i])
-			predicate.Children = append(predicate.Children, expr)
-		}
+	predicate, err := parser.predicateParser().ParsePredicate(rawPredicate)
+	if err != nil {
+		panic(err)
 	}
-
-	if predicate.Operator.IsBinary() && len(predicate.Children) != 2 {
-		panic(fmt.Errorf("Expected number of expressions be 2, got %v", len(predicate.Children)))
-	}
-
-	return predicate
+	return predicate.BindCurrentUser(parser.UserID)
 }
 
 // parseExpression parses the specific structure into an Expression struct.
-//
-// Accepts one of the following types:
-//
-// * { "$type": "keypath", "$val": "_key_path_name_" }    // key path
-// * [ "_func_name_" , _expression_1_ , _expression_2_ ]  // function
-// * 42                                                   // literal
+// See skyconv.PredicateParser for the accepted types.
 func (parser *QueryParser) parseExpression(i interface{}) skydb.Expression {
-	switch v := i.(type) {
-	case map[string]interface{}:
-		var keyPath string
-		if err := skyconv.MapFrom(i, (*skyconv.MapKeyPath)(&keyPath)); err == nil {
-			if keyPath == "_owner" {
-				keyPath = "_owner_id"
-			}
-			return skydb.Expression{
-				Type:  skydb.KeyPath,
-				Value: keyPath,
-			}
-		}
-	case []interface{}:
-		if len(v) > 0 {
-			if f, err := parser.parseFunc(v); err == nil {
-				return skydb.Expression{
-					Type:  skydb.Function,
-					Value: f,
-				}
-			}
-		}
-	}
-
-	return skydb.Expression{
-		Type:  skydb.Literal,
-		Value: skyconv.ParseLiteral(i),
+	expr, err := parser.predicateParser().ParseExpression(i)
+	if err != nil {
+		panic(err)
 	}
-}
-
-func (parser *QueryParser) parseFunc(s []interface{}) (f skydb.Func, err error) {
-	keyword, _ := s[0].(string)
-	if keyword != "func" {
-		return nil, errors.New("not a function")
-	}
-
-	funcName, _ := s[1].(string)
-	switch funcName {
-	case "distance":
-		f, err = parser.parseDistanceFunc(s[2:])
-	case "userRelation":
-		f, err = parser.parseUserRelationFunc(s[2:])
-	case "":
-		return nil, errors.New("empty function name")
-	default:
-		return nil, fmt.Errorf("got unrecgonized function name = %s", funcName)
-	}
-
-	return
-}
-
-func (parser *QueryParser) parseDistanceFunc(s []interface{}) (skydb.DistanceFunc, error) {
-	emptyDistanceFunc := skydb.DistanceFunc{}
-	if len(s) != 2 {
-		return emptyDistanceFunc, fmt.Errorf("want 2 arguments for distance func, got %d", len(s))
-	}
-
-	var field string
-	if err := skyconv.MapFrom(s[0], (*skyconv.MapKeyPath)(&field)); err != nil {
-		return emptyDistanceFunc, fmt.Errorf("invalid key path: %v", err)
-	}
-
-	var location skydb.Location
-	if err := skyconv.MapFrom(s[1], (*skyconv.MapLocation)(&location)); err != nil {
-		return emptyDistanceFunc, fmt.Errorf("invalid location: %v", err)
-	}
-
-	return skydb.DistanceFunc{
-		Field:    field,
-		Location: location,
-	}, nil
-}
-
-func (parser *QueryParser) parseUserRelationFunc(s []interface{}) (skydb.UserRelationFunc, error) {
-	emptyUserRelationFunc := skydb.UserRelationFunc{}
-	if len(s) != 2 {
-		return emptyUserRelationFunc, fmt.Errorf("want 2 arguments for user relation func, got %d", len(s))
-	}
-
-	var field string
-	if err := skyconv.MapFrom(s[0], (*skyconv.MapKeyPath)(&field)); err != nil {
-		return emptyUserRelationFunc, fmt.Errorf("invalid key path: %v", err)
-	}
-
-	var relation skyconv.MapRelation
-	if err := skyconv.MapFrom(s[1], (*skyconv.MapRelation)(&relation)); err != nil {
-		return emptyUserRelationFunc, fmt.Errorf("invalid relation: %v", err)
-	}
-
-	return skydb.UserRelationFunc{
-		KeyPath:           field,
-		RelationName:      relation.Name,
-		RelationDirection: relation.Direction,
-		User:              parser.UserID,
-	}, nil
-
+	return expr
 }
 
 func (parser *QueryParser) queryFromRaw(rawQuery map[string]interface{}, query *skydb.Query) (err skyerr.Error) {
@@ -41,8 +41,8 @@ func TestQueryFromRaw(t *testing.T) {
 			So(query, ShouldResemble, skydb.Query{
 				Type: "note",
 				Predicate: skydb.Predicate{
-					skydb.Equal,
-					[]interface{}{
+					Operator: skydb.Equal,
+					Children: []interface{}{
 						skydb.Expression{
 							Type:  skydb.KeyPath,
 							Value: "category.name",
@@ -71,11 +71,11 @@ func TestQueryFromRaw(t *testing.T) {
 			So(query, ShouldResemble, skydb.Query{
 				Type: "note",
 				Predicate: skydb.Predicate{
-					skydb.Functional,
-					[]interface{}{
+					Operator: skydb.Functional,
+					Children: []interface{}{
 						skydb.Expression{
 							Type:  skydb.Function,
-							Value: skydb.UserRelationFunc{"assignee", "_follow", "outward", "USER_ID"},
+							Value: skydb.UserRelationFunc{KeyPath: "assignee", RelationName: "_follow", RelationDirection: "outward", User: "USER_ID"},
 						},
 					},
 				},
@@ -97,11 +97,40 @@ func TestQueryFromRaw(t *testing.T) {
 			So(query, ShouldResemble, skydb.Query{
 				Type: "note",
 				Predicate: skydb.Predicate{
-					skydb.Functional,
-					[]interface{}{
+					Operator: skydb.Functional,
+					Children: []interface{}{
 						skydb.Expression{
 							Type:  skydb.Function,
-							Value: skydb.UserRelationFunc{"_owner", "_friend", "mutual", "USER_ID"},
+							Value: skydb.UserRelationFunc{KeyPath: "_owner", RelationName: "_friend", RelationDirection: "mutual", User: "USER_ID"},
+						},
+					},
+				},
+			})
+		})
+
+		Convey("should bind current user literal", func() {
+			query := skydb.Query{}
+			err := parser.queryFromRaw(map[string]interface{}{
+				"record_type": "note",
+				"predicate": []interface{}{
+					"eq",
+					map[string]interface{}{"$type": "keypath", "$val": "assignee"},
+					map[string]interface{}{"$type": "user"},
+				},
+			}, &query)
+			So(err, ShouldBeNil)
+			So(query, ShouldResemble, skydb.Query{
+				Type: "note",
+				Predicate: skydb.Predicate{
+					Operator: skydb.Equal,
+					Children: []interface{}{
+						skydb.Expression{
+							Type:  skydb.KeyPath,
+							Value: "assignee",
+						},
+						skydb.Expression{
+							Type:  skydb.Literal,
+							Value: "USER_ID",
 						},
 					},
 				},
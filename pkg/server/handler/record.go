@@ -392,6 +392,7 @@ EOF
 type RecordFetchHandler struct {
 	AssetStore    asset.Store       `inject:"AssetStore"`
 	AccessModel   skydb.AccessModel `inject:"AccessModel"`
+	HookRegistry  *hook.Registry    `inject:"HookRegistry"`
 	Authenticator router.Processor  `preprocessor:"authenticator"`
 	DBConn        router.Processor  `preprocessor:"dbconn"`
 	InjectAuth    router.Processor  `preprocessor:"inject_auth"`
@@ -439,6 +440,8 @@ func (h *RecordFetchHandler) Handle(payload *router.Payload, response *router.Re
 	fetcher := recordutil.NewRecordFetcher(payload.Context(), db, payload.DBConn, payload.HasMasterKey())
 
 	results := make([]interface{}, p.ItemLen(), p.ItemLen())
+	records := []*skydb.Record{}
+	resultIndexes := map[skydb.RecordID]int{}
 	for i, recordID := range p.RecordIDs {
 		record, err := fetcher.FetchRecord(recordID, payload.AuthInfo, skydb.ReadLevel)
 		if err != nil {
@@ -448,7 +451,28 @@ func (h *RecordFetchHandler) Handle(payload *router.Payload, response *router.Re
 			)
 			continue
 		}
-		results[i] = resultFilter.JSONResult(record)
+		records = append(records, record)
+		resultIndexes[record.ID] = i
+	}
+
+	records, skyErr = executeFetchHooks(payload.Context(), h.HookRegistry, records)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	for _, record := range records {
+		results[resultIndexes[record.ID]] = resultFilter.JSONResult(record)
+	}
+
+	// records dropped by afterFetch hooks are reported as not found
+	for i, recordID := range p.RecordIDs {
+		if results[i] == nil {
+			results[i] = newSerializedError(
+				recordID.String(),
+				skyerr.NewError(skyerr.ResourceNotFound, "record not found"),
+			)
+		}
 	}
 
 	response.Result = results
@@ -493,6 +517,7 @@ EOF
 type RecordQueryHandler struct {
 	AssetStore    asset.Store       `inject:"AssetStore"`
 	AccessModel   skydb.AccessModel `inject:"AccessModel"`
+	HookRegistry  *hook.Registry    `inject:"HookRegistry"`
 	Authenticator router.Processor  `preprocessor:"authenticator"`
 	DBConn        router.Processor  `preprocessor:"dbconn"`
 	InjectAuth    router.Processor  `preprocessor:"inject_auth"`
@@ -558,6 +583,15 @@ func (h *RecordQueryHandler) Handle(payload *router.Payload, response *router.Re
 		}
 	}
 
+	// beforeQuery hooks are run after the access check so that
+	// predicates added by plugins are not subject to field ACL
+	if h.HookRegistry != nil {
+		if err := h.HookRegistry.ExecuteQueryHooks(payload.Context(), &p.Query); err != nil {
+			response.Err = err
+			return
+		}
+	}
+
 	db := payload.Database
 
 	results, err := db.Query(&p.Query, accessControlOptions)
@@ -583,7 +617,20 @@ func (h *RecordQueryHandler) Handle(payload *router.Payload, response *router.Re
 	// so we replace them with some complete assets.
 	recordutil.MakeAssetsComplete(db, payload.DBConn, records)
 
+	records, skyErr = executeQueryFetchHooks(payload.Context(), h.HookRegistry, records)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
 	eagerRecords := recordutil.DoQueryEager(payload.Context(), db, recordutil.EagerIDs(db, records, p.Query), accessControlOptions)
+	for keyPath, recordMap := range eagerRecords {
+		eagerRecords[keyPath], skyErr = executeEagerFetchHooks(payload.Context(), h.HookRegistry, recordMap)
+		if skyErr != nil {
+			response.Err = skyErr
+			return
+		}
+	}
 
 	recordResultFilter, err := recordutil.NewRecordResultFilter(
 		payload.DBConn,
@@ -621,6 +668,88 @@ func (h *RecordQueryHandler) Handle(payload *router.Payload, response *router.Re
 	}
 }
 
+// executeFetchHooks runs the afterFetch hooks on records grouped by record
+// type. Records returned by the hooks are in the same order as the input,
+// with records dropped by the hooks removed.
+func executeFetchHooks(ctx context.Context, registry *hook.Registry, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+	if registry == nil || len(records) == 0 {
+		return records, nil
+	}
+
+	recordTypes := []string{}
+	recordsByType := map[string][]*skydb.Record{}
+	for _, record := range records {
+		recordType := record.ID.Type
+		if _, ok := recordsByType[recordType]; !ok {
+			recordTypes = append(recordTypes, recordType)
+		}
+		recordsByType[recordType] = append(recordsByType[recordType], record)
+	}
+
+	recordMap := map[skydb.RecordID]*skydb.Record{}
+	for _, recordType := range recordTypes {
+		hooked, err := registry.ExecuteFetchHooks(ctx, recordType, recordsByType[recordType])
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range hooked {
+			recordMap[record.ID] = record
+		}
+	}
+
+	output := []*skydb.Record{}
+	for _, record := range records {
+		if hooked, ok := recordMap[record.ID]; ok {
+			output = append(output, hooked)
+		}
+	}
+	return output, nil
+}
+
+func executeQueryFetchHooks(ctx context.Context, registry *hook.Registry, records []skydb.Record) ([]skydb.Record, skyerr.Error) {
+	if registry == nil || len(records) == 0 {
+		return records, nil
+	}
+
+	recordPtrs := make([]*skydb.Record, len(records))
+	for i := range records {
+		recordPtrs[i] = &records[i]
+	}
+
+	recordPtrs, err := executeFetchHooks(ctx, registry, recordPtrs)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]skydb.Record, len(recordPtrs))
+	for i, record := range recordPtrs {
+		output[i] = *record
+	}
+	return output, nil
+}
+
+func executeEagerFetchHooks(ctx context.Context, registry *hook.Registry, recordMap map[string]*skydb.Record) (map[string]*skydb.Record, skyerr.Error) {
+	if registry == nil || len(recordMap) == 0 {
+		return recordMap, nil
+	}
+
+	records := []*skydb.Record{}
+	for _, record := range recordMap {
+		records = append(records, record)
+	}
+
+	records, err := executeFetchHooks(ctx, registry, records)
+	if err != nil {
+		return nil, err
+	}
+
+	output := map[string]*skydb.Record{}
+	for _, record := range records {
+		output[record.ID.Key] = record
+	}
+	return output, nil
+}
+
 type recordDeleteRecordPayload struct {
 	Type string `mapstructure:"_recordType"`
 	Key  string `mapstructure:"_recordID"`
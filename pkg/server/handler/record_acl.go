@@ -0,0 +1,223 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+const (
+	defaultRecordACLUpdateBatchSize = 100
+	maxRecordACLUpdateBatchSize     = 1000
+)
+
+type recordACLUpdatePayload struct {
+	RawAdd        []map[string]interface{} `mapstructure:"add"`
+	RawRemove     []map[string]interface{} `mapstructure:"remove"`
+	BatchSize     uint64                   `mapstructure:"batch_size"`
+	SuppressHooks bool                     `mapstructure:"suppress_hooks"`
+
+	Query skydb.Query
+	Patch skydb.RecordACLPatch
+}
+
+func (payload *recordACLUpdatePayload) Decode(data map[string]interface{}, parser *QueryParser) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+
+	// The query is specified in the top-level as in record:query.
+	if err := parser.queryFromRaw(data, &payload.Query); err != nil {
+		return err
+	}
+
+	add, err := recordACLEntriesFromRaw(payload.RawAdd)
+	if err != nil {
+		return skyerr.NewInvalidArgument("invalid add entry: "+err.Error(), []string{"add"})
+	}
+	remove, err := recordACLEntriesFromRaw(payload.RawRemove)
+	if err != nil {
+		return skyerr.NewInvalidArgument("invalid remove entry: "+err.Error(), []string{"remove"})
+	}
+	payload.Patch = skydb.RecordACLPatch{
+		Add:    add,
+		Remove: remove,
+	}
+
+	if payload.BatchSize == 0 {
+		payload.BatchSize = defaultRecordACLUpdateBatchSize
+	}
+
+	return payload.Validate()
+}
+
+func (payload *recordACLUpdatePayload) Validate() skyerr.Error {
+	if payload.Patch.IsEmpty() {
+		return skyerr.NewInvalidArgument("add or remove must be specified", []string{"add", "remove"})
+	}
+
+	if payload.BatchSize > maxRecordACLUpdateBatchSize {
+		return skyerr.NewInvalidArgument("batch_size is too large", []string{"batch_size"})
+	}
+
+	return nil
+}
+
+func recordACLEntriesFromRaw(rawEntries []map[string]interface{}) ([]skydb.RecordACLEntry, error) {
+	entries := []skydb.RecordACLEntry{}
+	for _, v := range rawEntries {
+		ace := skydb.RecordACLEntry{}
+		if err := (*skyconv.MapACLEntry)(&ace).FromMap(v); err != nil {
+			return nil, err
+		}
+		entries = append(entries, ace)
+	}
+	return entries, nil
+}
+
+type recordACLUpdateResponse struct {
+	Batches uint64 `json:"batches"`
+	Matched uint64 `json:"matched"`
+	Updated uint64 `json:"updated"`
+}
+
+/*
+RecordACLUpdateHandler applies an ACL patch to all records matching a
+query. Entries in remove are removed from the ACL of each record, then
+entries in add are appended. Records without ACL, which are accessible
+by everyone, are patched as having a public write entry. Records are updated in batches of batch_size
+(default 100) and the afterSave hooks are executed for each updated
+record unless suppress_hooks is true. beforeSave hooks are not executed.
+
+If the update fails in the middle, the batches already written are not
+rolled back; the error info contains the progress so far.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"api_key": "MASTER_KEY",
+	"action": "record:acl:update",
+	"database_id": "_public",
+	"record_type": "note",
+	"predicate": ["eq", {"$type": "keypath", "$val": "category"}, "work"],
+	"add": [{"role": "admin", "level": "write"}],
+	"remove": [{"public": true, "level": "read"}],
+	"batch_size": 500,
+	"suppress_hooks": true
+}
+EOF
+
+{
+	"result": {
+		"batches": 3,
+		"matched": 1200,
+		"updated": 1180
+	}
+}
+*/
+type RecordACLUpdateHandler struct {
+	HookRegistry     *hook.Registry   `inject:"HookRegistry"`
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	InjectDB         router.Processor `preprocessor:"inject_db"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *RecordACLUpdateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.InjectDB,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *RecordACLUpdateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RecordACLUpdateHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := recordACLUpdatePayload{}
+	parser := QueryParser{UserID: rpayload.AuthInfoID}
+	if skyErr := payload.Decode(rpayload.Data, &parser); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	if rpayload.Database.IsReadOnly() {
+		response.Err = skyerr.NewError(skyerr.NotSupported, "modifying the selected database is not supported")
+		return
+	}
+
+	logger := logging.CreateLogger(rpayload.Context(), "handler")
+	progress, err := rpayload.Database.UpdateRecordACL(
+		&payload.Query,
+		payload.Patch,
+		payload.BatchSize,
+		func(records []skydb.Record, originalRecords []skydb.Record, progress skydb.RecordACLUpdateProgress) error {
+			logger.WithFields(logrus.Fields{
+				"recordType": payload.Query.Type,
+				"batches":    progress.Batches,
+				"matched":    progress.Matched,
+				"updated":    progress.Updated,
+			}).Infoln("Updated record ACL in batch")
+
+			if h.HookRegistry == nil || payload.SuppressHooks {
+				return nil
+			}
+			for i := range records {
+				err := h.HookRegistry.ExecuteHooks(rpayload.Context(), hook.AfterSave, &records[i], &originalRecords[i])
+				if err != nil {
+					logger.WithFields(logrus.Fields{
+						"recordID": records[i].ID,
+						"err":      err,
+					}).Errorln("Failed to execute afterSave hook")
+				}
+			}
+			return nil
+		},
+	)
+
+	result := recordACLUpdateResponse{
+		Batches: progress.Batches,
+		Matched: progress.Matched,
+		Updated: progress.Updated,
+	}
+	if err != nil {
+		logger.WithError(err).Errorln("Failed to update record ACL")
+		response.Err = skyerr.NewErrorWithInfo(
+			skyerr.UnexpectedError,
+			"failed to update record ACL: "+err.Error(),
+			map[string]interface{}{
+				"batches": result.Batches,
+				"matched": result.Matched,
+				"updated": result.Updated,
+			},
+		)
+		return
+	}
+
+	response.Result = result
+}
@@ -0,0 +1,185 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook/hooktest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+)
+
+type recordACLUpdateDatabase struct {
+	records   []skydb.Record
+	err       error
+	query     *skydb.Query
+	patch     skydb.RecordACLPatch
+	batchSize uint64
+	skydb.Database
+}
+
+func (db *recordACLUpdateDatabase) IsReadOnly() bool { return false }
+
+func (db *recordACLUpdateDatabase) UpdateRecordACL(query *skydb.Query, patch skydb.RecordACLPatch, batchSize uint64, fn skydb.RecordACLUpdateFunc) (skydb.RecordACLUpdateProgress, error) {
+	db.query = query
+	db.patch = patch
+	db.batchSize = batchSize
+
+	progress := skydb.RecordACLUpdateProgress{}
+	updatedRecords := []skydb.Record{}
+	originalRecords := []skydb.Record{}
+	for _, record := range db.records {
+		originalRecords = append(originalRecords, record)
+		record.ACL, _ = patch.Apply(record.ACL)
+		updatedRecords = append(updatedRecords, record)
+	}
+	progress.Batches = 1
+	progress.Matched = uint64(len(db.records))
+	progress.Updated = uint64(len(updatedRecords))
+	if err := fn(updatedRecords, originalRecords, progress); err != nil {
+		return progress, err
+	}
+	return progress, db.err
+}
+
+func TestRecordACLUpdateHandler(t *testing.T) {
+	Convey("RecordACLUpdateHandler", t, func() {
+		registry := hook.NewRegistry()
+		afterHook := hooktest.StackingHook{}
+		registry.Register(hook.AfterSave, "note", afterHook.Func)
+
+		publicRead := skydb.NewRecordACLEntryPublic(skydb.ReadLevel)
+		adminWrite := skydb.NewRecordACLEntryRole("admin", skydb.WriteLevel)
+		db := &recordACLUpdateDatabase{
+			records: []skydb.Record{
+				{
+					ID:  skydb.NewRecordID("note", "note1"),
+					ACL: skydb.RecordACL{publicRead},
+				},
+			},
+		}
+		r := handlertest.NewSingleRouteRouter(&RecordACLUpdateHandler{
+			HookRegistry: registry,
+		}, func(p *router.Payload) {
+			p.Database = db
+		})
+
+		Convey("should update ACL and execute afterSave hooks", func() {
+			resp := r.POST(`{
+				"record_type": "note",
+				"predicate": ["eq", {"$type": "keypath", "$val": "category"}, "work"],
+				"add": [{"role": "admin", "level": "write"}],
+				"remove": [{"public": true, "level": "read"}]
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"batches": 1,
+					"matched": 1,
+					"updated": 1
+				}
+			}`)
+
+			So(db.query.Type, ShouldEqual, "note")
+			So(db.query.Predicate.IsEmpty(), ShouldBeFalse)
+			So(db.patch, ShouldResemble, skydb.RecordACLPatch{
+				Add:    []skydb.RecordACLEntry{adminWrite},
+				Remove: []skydb.RecordACLEntry{publicRead},
+			})
+			So(db.batchSize, ShouldEqual, 100)
+
+			So(afterHook.Records, ShouldHaveLength, 1)
+			So(afterHook.Records[0].ACL, ShouldResemble, skydb.RecordACL{adminWrite})
+			So(afterHook.OriginalRecords[0].ACL, ShouldResemble, skydb.RecordACL{publicRead})
+		})
+
+		Convey("should not execute hooks if suppressed", func() {
+			resp := r.POST(`{
+				"record_type": "note",
+				"add": [{"role": "admin", "level": "write"}],
+				"batch_size": 10,
+				"suppress_hooks": true
+			}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(db.batchSize, ShouldEqual, 10)
+			So(afterHook.Records, ShouldBeEmpty)
+		})
+
+		Convey("should report progress on error", func() {
+			db.err = errors.New("connection lost")
+			resp := r.POST(`{
+				"record_type": "note",
+				"add": [{"role": "admin", "level": "write"}],
+				"suppress_hooks": true
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 10000,
+					"message": "failed to update record ACL: connection lost",
+					"name": "UnexpectedError",
+					"info": {
+						"batches": 1,
+						"matched": 1,
+						"updated": 1
+					}
+				}
+			}`)
+		})
+
+		Convey("should reject empty patch", func() {
+			resp := r.POST(`{
+				"record_type": "note"
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "add or remove must be specified",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["add", "remove"]}
+				}
+			}`)
+		})
+
+		Convey("should reject invalid entry", func() {
+			resp := r.POST(`{
+				"record_type": "note",
+				"add": [{"role": "admin"}]
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "invalid add entry: empty level",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["add"]}
+				}
+			}`)
+		})
+
+		Convey("should reject large batch size", func() {
+			resp := r.POST(`{
+				"record_type": "note",
+				"add": [{"role": "admin", "level": "write"}],
+				"batch_size": 5000
+			}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+	})
+}
@@ -687,6 +687,76 @@ func TestRecordSaveHandler(t *testing.T) {
 			So(mapDB.RecordMap["note/new-note"].Data["favorite"], ShouldEqual, true)
 		})
 	})
+
+	Convey("RecordSaveHandler with record predicate", t, func() {
+		db := skydbtest.NewMapDB()
+		conn := skydbtest.NewMapConn()
+		conn.SetRecordPredicateAccess("note", skydb.RecordPredicateAccess{
+			Write: []interface{}{
+				"and",
+				[]interface{}{"eq", map[string]interface{}{"$type": "keypath", "$val": "_owner"}, map[string]interface{}{"$type": "user"}},
+				[]interface{}{"neq", map[string]interface{}{"$type": "keypath", "$val": "status"}, "archived"},
+			},
+		})
+
+		db.Save(&skydb.Record{
+			ID:      skydb.NewRecordID("note", "mine"),
+			OwnerID: "user0",
+			Data:    skydb.Data{"status": "draft"},
+		})
+		db.Save(&skydb.Record{
+			ID:      skydb.NewRecordID("note", "archived"),
+			OwnerID: "user0",
+			Data:    skydb.Data{"status": "archived"},
+			ACL:     skydb.RecordACL{skydb.NewRecordACLEntryPublic(skydb.WriteLevel)},
+		})
+		db.Save(&skydb.Record{
+			ID:      skydb.NewRecordID("note", "others"),
+			OwnerID: "user1",
+			Data:    skydb.Data{"status": "draft"},
+			ACL:     skydb.RecordACL{skydb.NewRecordACLEntryPublic(skydb.WriteLevel)},
+		})
+
+		r := handlertest.NewSingleRouteRouter(&RecordSaveHandler{}, func(payload *router.Payload) {
+			payload.DBConn = conn
+			payload.Database = db
+			payload.AuthInfo = &skydb.AuthInfo{
+				ID: "user0",
+			}
+		})
+
+		Convey("should save record matching the predicate", func() {
+			resp := r.POST(`{"records": [{"_id": "note/mine", "status": "published"}]}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(db.RecordMap["note/mine"].Data["status"], ShouldEqual, "published")
+		})
+
+		Convey("should not save existing record not matching the predicate", func() {
+			resp := r.POST(`{"records": [{"_id": "note/others", "status": "published"}]}`)
+			So(resp.Body.String(), ShouldContainSubstring, `"name":"PermissionDenied"`)
+			So(db.RecordMap["note/others"].Data["status"], ShouldEqual, "draft")
+
+			resp = r.POST(`{"records": [{"_id": "note/archived", "status": "draft"}]}`)
+			So(resp.Body.String(), ShouldContainSubstring, `"name":"PermissionDenied"`)
+			So(db.RecordMap["note/archived"].Data["status"], ShouldEqual, "archived")
+		})
+
+		Convey("should not change record to not match the predicate", func() {
+			resp := r.POST(`{"records": [{"_id": "note/mine", "status": "archived"}]}`)
+			So(resp.Body.String(), ShouldContainSubstring, `"name":"PermissionDenied"`)
+			So(db.RecordMap["note/mine"].Data["status"], ShouldEqual, "draft")
+		})
+
+		Convey("should not create record not matching the predicate", func() {
+			resp := r.POST(`{"records": [{"_id": "note/new", "status": "archived"}]}`)
+			So(resp.Body.String(), ShouldContainSubstring, `"name":"PermissionDenied"`)
+			So(db.RecordMap, ShouldNotContainKey, "note/new")
+
+			resp = r.POST(`{"records": [{"_id": "note/new", "status": "draft"}]}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(db.RecordMap, ShouldContainKey, "note/new")
+		})
+	})
 }
 
 func TestRecordSaveDataType(t *testing.T) {
@@ -883,6 +953,10 @@ func (db bogusFieldDatabaseConnection) EnsureAuthRecordKeysValid(authRecordKeys
 	return nil
 }
 
+func (db bogusFieldDatabaseConnection) GetRecordPredicateAccess(recordType string) (skydb.RecordPredicateAccess, error) {
+	return skydb.RecordPredicateAccess{}, nil
+}
+
 type bogusFieldDatabase struct {
 	SaveFunc func(record *skydb.Record) error
 	GetFunc  func(id skydb.RecordID, record *skydb.Record) error
@@ -1959,6 +2033,196 @@ func TestRecordFetchHandler(t *testing.T) {
 	})
 }
 
+func TestRecordQueryHooks(t *testing.T) {
+	Convey("RecordQueryHandler with beforeQuery hooks", t, func() {
+		db := &queryDatabase{}
+		conn := skydbtest.NewMapConn()
+		registry := hook.NewRegistry()
+
+		predicate := skydb.Predicate{
+			Operator: skydb.Equal,
+			Children: []interface{}{
+				skydb.Expression{Type: skydb.KeyPath, Value: "tenant"},
+				skydb.Expression{Type: skydb.Literal, Value: "skygear"},
+			},
+		}
+
+		Convey("rewrites query predicate", func() {
+			registry.RegisterQueryHook("note", func(ctx context.Context, query *skydb.Query) skyerr.Error {
+				query.Predicate = predicate
+				return nil
+			})
+
+			payload := router.Payload{
+				Data: map[string]interface{}{
+					"record_type": "note",
+				},
+				DBConn:   conn,
+				Database: db,
+			}
+			response := router.Response{}
+
+			handler := &RecordQueryHandler{HookRegistry: registry}
+			handler.Handle(&payload, &response)
+
+			So(response.Err, ShouldBeNil)
+			So(db.lastquery, ShouldResemble, &skydb.Query{
+				Type:      "note",
+				Predicate: predicate,
+			})
+		})
+
+		Convey("does not run hooks of other record type", func() {
+			registry.RegisterQueryHook("category", func(ctx context.Context, query *skydb.Query) skyerr.Error {
+				query.Predicate = predicate
+				return nil
+			})
+
+			payload := router.Payload{
+				Data: map[string]interface{}{
+					"record_type": "note",
+				},
+				DBConn:   conn,
+				Database: db,
+			}
+			response := router.Response{}
+
+			handler := &RecordQueryHandler{HookRegistry: registry}
+			handler.Handle(&payload, &response)
+
+			So(response.Err, ShouldBeNil)
+			So(db.lastquery, ShouldResemble, &skydb.Query{
+				Type: "note",
+			})
+		})
+
+		Convey("rejects query when hook returns error", func() {
+			registry.RegisterQueryHook("note", func(ctx context.Context, query *skydb.Query) skyerr.Error {
+				return skyerr.NewError(skyerr.PermissionDenied, "no query on note")
+			})
+
+			payload := router.Payload{
+				Data: map[string]interface{}{
+					"record_type": "note",
+				},
+				DBConn:   conn,
+				Database: db,
+			}
+			response := router.Response{}
+
+			handler := &RecordQueryHandler{HookRegistry: registry}
+			handler.Handle(&payload, &response)
+
+			So(response.Err, ShouldResemble, skyerr.NewError(skyerr.PermissionDenied, "no query on note"))
+			So(db.lastquery, ShouldBeNil)
+		})
+	})
+
+	Convey("RecordQueryHandler with afterFetch hooks", t, func() {
+		conn := skydbtest.NewMapConn()
+		db := &queryResultsDatabase{}
+		db.records = []skydb.Record{
+			skydb.Record{ID: skydb.NewRecordID("note", "0")},
+			skydb.Record{ID: skydb.NewRecordID("note", "1")},
+		}
+		registry := hook.NewRegistry()
+		registry.RegisterFetchHook("note", func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+			output := []*skydb.Record{}
+			for _, record := range records {
+				if record.ID.Key == "0" {
+					continue
+				}
+				record.Data = skydb.Data{"title": "redacted"}
+				output = append(output, record)
+			}
+			return output, nil
+		})
+
+		r := handlertest.NewSingleRouteRouter(&RecordQueryHandler{
+			HookRegistry: registry,
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.Database = db
+		})
+
+		Convey("filters and transforms query results", func() {
+			resp := r.POST(`{
+				"record_type": "note"
+			}`)
+
+			So(resp.Body.String(), ShouldEqualJSON, `{
+				"result": [{
+					"_type": "record",
+					"_id": "note/1",
+					"_recordType": "note",
+					"_recordID": "1",
+					"_access": null,
+					"title": "redacted"
+				}]
+			}`)
+			So(resp.Code, ShouldEqual, 200)
+		})
+	})
+}
+
+func TestRecordFetchHooks(t *testing.T) {
+	Convey("RecordFetchHandler with afterFetch hooks", t, func() {
+		db := skydbtest.NewMapDB()
+		conn := skydbtest.NewMapConn()
+		db.Save(&skydb.Record{
+			ID:   skydb.NewRecordID("note", "note0"),
+			Data: map[string]interface{}{"content": "Hello"},
+		})
+		db.Save(&skydb.Record{
+			ID:   skydb.NewRecordID("note", "note1"),
+			Data: map[string]interface{}{"content": "World"},
+		})
+
+		registry := hook.NewRegistry()
+		r := handlertest.NewSingleRouteRouter(&RecordFetchHandler{
+			HookRegistry: registry,
+		}, func(payload *router.Payload) {
+			payload.DBConn = conn
+			payload.Database = db
+			payload.AccessKey = router.MasterAccessKey
+		})
+
+		Convey("reports dropped records as not found", func() {
+			hookedCount := 0
+			registry.RegisterFetchHook("note", func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+				hookedCount = len(records)
+				records[1].Data["content"] = "Skygear"
+				return records[1:], nil
+			})
+
+			resp := r.POST(`{
+				"ids": ["note/note0", "note/note1", "note/notexistid"]
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [
+					{"_id": "note/note0", "_recordType": "note", "_recordID": "note0", "_type": "error", "code": 110, "message": "record not found", "name": "ResourceNotFound"},
+					{"_id": "note/note1", "_recordType": "note", "_recordID": "note1", "_type": "record", "_access": null, "content": "Skygear"},
+					{"_id": "note/notexistid", "_recordType": "note", "_recordID": "notexistid", "_type": "error", "code": 110, "message": "record not found", "name": "ResourceNotFound"}
+				]
+			}`)
+			So(hookedCount, ShouldEqual, 2)
+		})
+
+		Convey("returns error from hook", func() {
+			registry.RegisterFetchHook("note", func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+				return nil, skyerr.NewError(skyerr.UnexpectedError, "plugin failed")
+			})
+
+			resp := r.POST(`{
+				"ids": ["note/note0"]
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {"code": 10000, "message": "plugin failed", "name": "UnexpectedError"}
+			}`)
+		})
+	})
+}
+
 func TestRecordOwnerIDSerialization(t *testing.T) {
 	realTime := timeNow
 	timeNow = func() time.Time { return ZeroTime }
@@ -3196,6 +3460,7 @@ func TestAtomicOperation(t *testing.T) {
 			}), ShouldBeNil)
 
 			r := handlertest.NewSingleRouteRouter(&RecordDeleteHandler{}, func(payload *router.Payload) {
+				payload.DBConn = conn
 				payload.Database = db
 				payload.AuthInfo = &skydb.AuthInfo{
 					ID: "user0",
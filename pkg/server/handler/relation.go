@@ -15,6 +15,8 @@
 package handler
 
 import (
+	"fmt"
+
 	"github.com/mitchellh/mapstructure"
 	"github.com/sirupsen/logrus"
 
@@ -27,18 +29,20 @@ import (
 	"github.com/skygeario/skygear-server/pkg/server/skyerr"
 )
 
-func canonicalRelationName(name string) (string, skyerr.Error) {
-	relationMap := map[string]string{
-		"friend":  "_friend",
-		"_friend": "_friend",
-		"follow":  "_follow",
-		"_follow": "_follow",
+// resolveRelation returns the canonical name of the relation. For a
+// relation type defined by the app, the relation type is also returned.
+func resolveRelation(conn skydb.Conn, name string) (string, *skydb.RelationType, skyerr.Error) {
+	if relationName := skydb.CanonicalRelationName(name); skydb.IsBuiltinRelation(relationName) {
+		return relationName, nil, nil
 	}
-	relationName, ok := relationMap[name]
-	if !ok {
-		return "", skyerr.NewError(skyerr.NotSupported, "Only friend and follow relation is supported")
+
+	relationType, err := conn.GetRelationType(name)
+	if err == skydb.ErrRelationTypeNotFound {
+		return "", nil, skyerr.NewError(skyerr.NotSupported, fmt.Sprintf("Relation %s is not defined", name))
+	} else if err != nil {
+		return "", nil, skyerr.MakeError(err)
 	}
-	return relationName, nil
+	return relationType.Name, &relationType, nil
 }
 
 type relationQueryPayload struct {
@@ -57,11 +61,9 @@ func (payload *relationQueryPayload) Decode(data map[string]interface{}) skyerr.
 }
 
 func (payload *relationQueryPayload) Validate() skyerr.Error {
-	relationName, err := canonicalRelationName(payload.Name)
-	if err != nil {
-		return err
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty relation name", []string{"name"})
 	}
-	payload.Name = relationName
 
 	if payload.Direction != "" && payload.Direction != "outward" && payload.Direction != "inward" && payload.Direction != "mutual" {
 		return skyerr.NewInvalidArgument("only outward, inward and mutual direction is allowed", []string{"direction"})
@@ -148,6 +150,12 @@ func (h *RelationQueryHandler) Handle(rpayload *router.Payload, response *router
 		return
 	}
 
+	payload.Name, _, skyErr = resolveRelation(rpayload.DBConn, payload.Name)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
 	result := rpayload.DBConn.QueryRelation(
 		rpayload.AuthInfoID, payload.Name, payload.Direction, skydb.QueryConfig{
 			Limit:  payload.Limit,
@@ -192,8 +200,9 @@ func (h *RelationQueryHandler) Handle(rpayload *router.Payload, response *router
 
 // relationChangePayload is shared by RelationAddHandler and RelationRemoveHandler
 type relationChangePayload struct {
-	Name   string   `mapstructure:"name"`
-	Target []string `mapstructure:"targets"`
+	Name     string                 `mapstructure:"name"`
+	Target   []string               `mapstructure:"targets"`
+	Metadata map[string]interface{} `mapstructure:"metadata"`
 }
 
 func (payload *relationChangePayload) Decode(data map[string]interface{}) skyerr.Error {
@@ -204,11 +213,9 @@ func (payload *relationChangePayload) Decode(data map[string]interface{}) skyerr
 }
 
 func (payload *relationChangePayload) Validate() skyerr.Error {
-	relationName, err := canonicalRelationName(payload.Name)
-	if err != nil {
-		return err
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty relation name", []string{"name"})
 	}
-	payload.Name = relationName
 	return nil
 }
 
@@ -287,10 +294,45 @@ func (h *RelationAddHandler) Handle(rpayload *router.Payload, response *router.R
 		return
 	}
 
+	relationName, relationType, skyErr := resolveRelation(rpayload.DBConn, payload.Name)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+	if len(payload.Metadata) > 0 {
+		if relationType == nil {
+			response.Err = skyerr.NewInvalidArgument("metadata is not supported by builtin relations", []string{"metadata"})
+			return
+		}
+		if err := relationType.ValidateMetadata(payload.Metadata); err != nil {
+			response.Err = skyerr.NewInvalidArgument(err.Error(), []string{"metadata"})
+			return
+		}
+	}
+
 	results := make([]interface{}, 0, len(payload.Target))
 	for s := range payload.Target {
 		target := payload.Target[s]
-		err := rpayload.DBConn.AddRelation(rpayload.AuthInfoID, payload.Name, target)
+		if skyErr := checkBlocked(rpayload.DBConn, rpayload.AuthInfoID, target); skyErr != nil {
+			results = append(results, struct {
+				ID   string       `json:"id"`
+				Type string       `json:"type"`
+				Data skyerr.Error `json:"data"`
+			}{target, "error", skyErr})
+			continue
+		}
+
+		var err error
+		if relationType == nil {
+			err = rpayload.DBConn.AddRelation(rpayload.AuthInfoID, relationName, target)
+		} else {
+			err = rpayload.DBConn.SaveRelation(&skydb.Relation{
+				Name:     relationName,
+				LeftID:   rpayload.AuthInfoID,
+				RightID:  target,
+				Metadata: payload.Metadata,
+			})
+		}
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"target": target,
@@ -373,10 +415,16 @@ func (h *RelationRemoveHandler) Handle(rpayload *router.Payload, response *route
 		return
 	}
 
+	relationName, _, skyErr := resolveRelation(rpayload.DBConn, payload.Name)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
 	results := make([]interface{}, 0, len(payload.Target))
 	for s := range payload.Target {
 		target := payload.Target[s]
-		err := rpayload.DBConn.RemoveRelation(rpayload.AuthInfoID, payload.Name, target)
+		err := rpayload.DBConn.RemoveRelation(rpayload.AuthInfoID, relationName, target)
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"target": target,
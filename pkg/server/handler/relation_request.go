@@ -0,0 +1,610 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/sirupsen/logrus"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/pubsub"
+	"github.com/skygeario/skygear-server/pkg/server/push"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+// RelationRequestEvent is the event of a relation request sent to the
+// other user of the request.
+type RelationRequestEvent string
+
+const (
+	// RelationRequestRequested is sent to the recipient of a new request.
+	RelationRequestRequested RelationRequestEvent = "requested"
+	// RelationRequestAccepted is sent to the requester when the request
+	// is accepted.
+	RelationRequestAccepted RelationRequestEvent = "accepted"
+	// RelationRequestDeclined is sent to the requester when the request
+	// is declined.
+	RelationRequestDeclined RelationRequestEvent = "declined"
+	// RelationRequestCancelled is sent to the recipient when the request
+	// is cancelled.
+	RelationRequestCancelled RelationRequestEvent = "cancelled"
+)
+
+// RelationRequestNotifier notifies a user of relation request events on
+// every device of the user. The event is published thru the internal
+// pubsub hub via the channel name "_relation_request_[DEVICE_ID]", and
+// sent as push notification if PushSender is set.
+type RelationRequestNotifier struct {
+	Hub        *pubsub.Hub
+	PushSender push.Sender
+}
+
+// Notify sends the event of the request to the user. Failures are logged
+// and not returned as the request is already processed.
+func (n *RelationRequestNotifier) Notify(conn skydb.Conn, userID string, event RelationRequestEvent, request skydb.RelationRequest) {
+	if n == nil || (n.Hub == nil && n.PushSender == nil) {
+		return
+	}
+
+	logger := logging.LoggerEntry("handler")
+	devices, err := conn.QueryDevicesByUser(userID)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"userID": userID,
+			"err":    err,
+		}).Warnln("Failed to query devices for relation request notification")
+		return
+	}
+
+	notice := map[string]interface{}{
+		"event":   event,
+		"request": request,
+	}
+	data, err := json.Marshal(notice)
+	if err != nil {
+		logger.WithField("err", err).Warnln("Failed to encode relation request notification")
+		return
+	}
+
+	tokens := map[string]bool{}
+	for _, device := range devices {
+		if n.Hub != nil {
+			n.Hub.Broadcast <- pubsub.Parcel{
+				Channel: fmt.Sprintf("_relation_request_%s", device.ID),
+				Data:    data,
+			}
+		}
+
+		if n.PushSender != nil && device.Token != "" && !tokens[device.Token] {
+			tokens[device.Token] = true
+			sendPushNotification(n.PushSender, device, relationRequestPushMapper(event, request, notice))
+		}
+	}
+}
+
+func relationRequestPushMapper(event RelationRequestEvent, request skydb.RelationRequest, notice map[string]interface{}) push.Mapper {
+	skygear := map[string]interface{}{
+		"relation_request": notice,
+	}
+	otherUserID := request.RequesterID
+	if event == RelationRequestAccepted || event == RelationRequestDeclined {
+		otherUserID = request.RecipientID
+	}
+
+	return push.MapMapper{
+		"apns": map[string]interface{}{
+			"aps": map[string]interface{}{
+				"alert": map[string]interface{}{
+					"loc-key":  "RELATION_REQUEST_" + strings.ToUpper(string(event)),
+					"loc-args": []string{request.Name, otherUserID},
+				},
+				"content-available": 1,
+			},
+			"_skygear": skygear,
+		},
+		"gcm": map[string]interface{}{
+			"data": map[string]interface{}{
+				"_skygear": skygear,
+			},
+		},
+	}
+}
+
+// relationRequestPayload is shared by the relation request handlers.
+type relationRequestPayload struct {
+	Name   string   `mapstructure:"name"`
+	Target []string `mapstructure:"targets"`
+}
+
+func (payload *relationRequestPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *relationRequestPayload) Validate() skyerr.Error {
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty relation name", []string{"name"})
+	}
+	if len(payload.Target) == 0 {
+		return skyerr.NewInvalidArgument("empty targets", []string{"targets"})
+	}
+	return nil
+}
+
+type relationRequestResultItem struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+func newRelationRequestResultItem(target string, request skydb.RelationRequest, err skyerr.Error) relationRequestResultItem {
+	if err != nil {
+		return relationRequestResultItem{target, "error", err}
+	}
+	return relationRequestResultItem{target, "relation_request", request}
+}
+
+// acceptRelationRequest adds the relation of the request and marks the
+// request accepted. A friend request relates both users.
+func acceptRelationRequest(conn skydb.Conn, request *skydb.RelationRequest) error {
+	if err := conn.AddRelation(request.RequesterID, request.Name, request.RecipientID); err != nil {
+		return err
+	}
+	if request.Name == "_friend" {
+		if err := conn.AddRelation(request.RecipientID, request.Name, request.RequesterID); err != nil {
+			return err
+		}
+	}
+
+	request.State = skydb.RelationRequestAccepted
+	return conn.UpdateRelationRequest(request)
+}
+
+// getPendingRelationRequest returns the pending request of the relation
+// from the requester to the recipient.
+func getPendingRelationRequest(conn skydb.Conn, name string, requesterID string, recipientID string) (skydb.RelationRequest, skyerr.Error) {
+	request, err := conn.GetRelationRequest(name, requesterID, recipientID)
+	if err == skydb.ErrRelationRequestNotFound || (err == nil && !request.IsPending()) {
+		return skydb.RelationRequest{}, skyerr.NewError(skyerr.ResourceNotFound, "no pending relation request")
+	} else if err != nil {
+		return skydb.RelationRequest{}, skyerr.MakeError(err)
+	}
+	return request, nil
+}
+
+/*
+RelationRequestHandler sends requests of a relation to the target users.
+The relation is added only after the recipient accepts the request with
+relation:accept. If a target has already sent a pending request of the
+relation to the current user, that request is accepted instead.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"action": "relation:request",
+	"access_token": "ACCESS_TOKEN",
+	"name": "friend",
+	"targets": ["1001"]
+}
+EOF
+
+{
+	"result": [{
+		"id": "1001",
+		"type": "relation_request",
+		"data": {
+			"name": "_friend",
+			"requester_id": "1000",
+			"recipient_id": "1001",
+			"state": "pending",
+			"created_at": "2017-07-01T00:00:00Z",
+			"updated_at": "2017-07-01T00:00:00Z"
+		}
+	}]
+}
+*/
+type RelationRequestHandler struct {
+	Notifier      *RelationRequestNotifier `inject:"RelationRequestNotifier"`
+	Authenticator router.Processor         `preprocessor:"authenticator"`
+	DBConn        router.Processor         `preprocessor:"dbconn"`
+	InjectAuth    router.Processor         `preprocessor:"require_auth"`
+	CheckUser     router.Processor         `preprocessor:"check_user"`
+	PluginReady   router.Processor         `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *RelationRequestHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *RelationRequestHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RelationRequestHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := relationRequestPayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	conn := rpayload.DBConn
+	name, _, skyErr := resolveRelation(conn, payload.Name)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	results := make([]relationRequestResultItem, 0, len(payload.Target))
+	for _, target := range payload.Target {
+		request, skyErr := h.request(conn, name, rpayload.AuthInfoID, target)
+		results = append(results, newRelationRequestResultItem(target, request, skyErr))
+	}
+	response.Result = results
+}
+
+func (h *RelationRequestHandler) request(conn skydb.Conn, name string, userID string, target string) (skydb.RelationRequest, skyerr.Error) {
+	if target == userID {
+		return skydb.RelationRequest{}, skyerr.NewInvalidArgument("cannot request relation with oneself", []string{"targets"})
+	}
+
+	if skyErr := checkBlocked(conn, userID, target); skyErr != nil {
+		return skydb.RelationRequest{}, skyErr
+	}
+
+	if _, err := conn.GetRelation(userID, name, target); err == nil {
+		return skydb.RelationRequest{}, skyerr.NewError(skyerr.Duplicated, "relation already exists")
+	} else if err != skydb.ErrRelationNotFound {
+		return skydb.RelationRequest{}, skyerr.MakeError(err)
+	}
+
+	// The target has requested the same relation, so both users agree.
+	reverse, err := conn.GetRelationRequest(name, target, userID)
+	if err == nil && reverse.IsPending() {
+		if err := acceptRelationRequest(conn, &reverse); err != nil {
+			return skydb.RelationRequest{}, skyerr.MakeError(err)
+		}
+		h.Notifier.Notify(conn, target, RelationRequestAccepted, reverse)
+		return reverse, nil
+	} else if err != nil && err != skydb.ErrRelationRequestNotFound {
+		return skydb.RelationRequest{}, skyerr.MakeError(err)
+	}
+
+	request := skydb.RelationRequest{
+		Name:        name,
+		RequesterID: userID,
+		RecipientID: target,
+		CreatedAt:   timeNow(),
+	}
+	if err := conn.CreateRelationRequest(&request); err != nil {
+		switch err {
+		case skydb.ErrRelationRequestDuplicated:
+			return skydb.RelationRequest{}, skyerr.NewError(skyerr.Duplicated, "relation request already pending")
+		case skydb.ErrUserNotFound:
+			return skydb.RelationRequest{}, skyerr.NewError(skyerr.ResourceNotFound, "user not found")
+		default:
+			return skydb.RelationRequest{}, skyerr.MakeError(err)
+		}
+	}
+
+	h.Notifier.Notify(conn, target, RelationRequestRequested, request)
+	return request, nil
+}
+
+/*
+RelationAcceptHandler accepts pending requests of a relation sent by the
+target users to the current user, which adds the relation.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"action": "relation:accept",
+	"access_token": "ACCESS_TOKEN",
+	"name": "friend",
+	"targets": ["1000"]
+}
+EOF
+*/
+type RelationAcceptHandler struct {
+	Notifier      *RelationRequestNotifier `inject:"RelationRequestNotifier"`
+	Authenticator router.Processor         `preprocessor:"authenticator"`
+	DBConn        router.Processor         `preprocessor:"dbconn"`
+	InjectAuth    router.Processor         `preprocessor:"require_auth"`
+	CheckUser     router.Processor         `preprocessor:"check_user"`
+	PluginReady   router.Processor         `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *RelationAcceptHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *RelationAcceptHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RelationAcceptHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := relationRequestPayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	conn := rpayload.DBConn
+	name, _, skyErr := resolveRelation(conn, payload.Name)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	results := make([]relationRequestResultItem, 0, len(payload.Target))
+	for _, target := range payload.Target {
+		request, skyErr := getPendingRelationRequest(conn, name, target, rpayload.AuthInfoID)
+		if skyErr == nil {
+			if err := acceptRelationRequest(conn, &request); err != nil {
+				skyErr = skyerr.MakeError(err)
+			} else {
+				h.Notifier.Notify(conn, target, RelationRequestAccepted, request)
+			}
+		}
+		results = append(results, newRelationRequestResultItem(target, request, skyErr))
+	}
+	response.Result = results
+}
+
+/*
+RelationDeclineHandler declines pending requests of a relation sent by
+the target users to the current user.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"action": "relation:decline",
+	"access_token": "ACCESS_TOKEN",
+	"name": "friend",
+	"targets": ["1000"]
+}
+EOF
+*/
+type RelationDeclineHandler struct {
+	Notifier      *RelationRequestNotifier `inject:"RelationRequestNotifier"`
+	Authenticator router.Processor         `preprocessor:"authenticator"`
+	DBConn        router.Processor         `preprocessor:"dbconn"`
+	InjectAuth    router.Processor         `preprocessor:"require_auth"`
+	CheckUser     router.Processor         `preprocessor:"check_user"`
+	PluginReady   router.Processor         `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *RelationDeclineHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *RelationDeclineHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RelationDeclineHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := relationRequestPayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	conn := rpayload.DBConn
+	name, _, skyErr := resolveRelation(conn, payload.Name)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	results := make([]relationRequestResultItem, 0, len(payload.Target))
+	for _, target := range payload.Target {
+		request, skyErr := getPendingRelationRequest(conn, name, target, rpayload.AuthInfoID)
+		if skyErr == nil {
+			request.State = skydb.RelationRequestDeclined
+			if err := conn.UpdateRelationRequest(&request); err != nil {
+				skyErr = skyerr.MakeError(err)
+			} else {
+				h.Notifier.Notify(conn, target, RelationRequestDeclined, request)
+			}
+		}
+		results = append(results, newRelationRequestResultItem(target, request, skyErr))
+	}
+	response.Result = results
+}
+
+/*
+RelationCancelHandler cancels pending requests of a relation sent by the
+current user to the target users.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"action": "relation:cancel",
+	"access_token": "ACCESS_TOKEN",
+	"name": "friend",
+	"targets": ["1001"]
+}
+EOF
+*/
+type RelationCancelHandler struct {
+	Notifier      *RelationRequestNotifier `inject:"RelationRequestNotifier"`
+	Authenticator router.Processor         `preprocessor:"authenticator"`
+	DBConn        router.Processor         `preprocessor:"dbconn"`
+	InjectAuth    router.Processor         `preprocessor:"require_auth"`
+	CheckUser     router.Processor         `preprocessor:"check_user"`
+	PluginReady   router.Processor         `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *RelationCancelHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *RelationCancelHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RelationCancelHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := relationRequestPayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	conn := rpayload.DBConn
+	name, _, skyErr := resolveRelation(conn, payload.Name)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	results := make([]relationRequestResultItem, 0, len(payload.Target))
+	for _, target := range payload.Target {
+		request, skyErr := getPendingRelationRequest(conn, name, rpayload.AuthInfoID, target)
+		if skyErr == nil {
+			request.State = skydb.RelationRequestCancelled
+			if err := conn.UpdateRelationRequest(&request); err != nil {
+				skyErr = skyerr.MakeError(err)
+			} else {
+				h.Notifier.Notify(conn, target, RelationRequestCancelled, request)
+			}
+		}
+		results = append(results, newRelationRequestResultItem(target, request, skyErr))
+	}
+	response.Result = results
+}
+
+type relationRequestQueryPayload struct {
+	Name      string `mapstructure:"name"`
+	Direction string `mapstructure:"direction"`
+
+	Limit  uint64 `mapstructure:"limit"`
+	Offset uint64 `mapstructure:"offset"`
+}
+
+func (payload *relationRequestQueryPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *relationRequestQueryPayload) Validate() skyerr.Error {
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty relation name", []string{"name"})
+	}
+	if payload.Direction == "" {
+		payload.Direction = "incoming"
+	}
+	if payload.Direction != "incoming" && payload.Direction != "outgoing" {
+		return skyerr.NewInvalidArgument("only incoming and outgoing direction is allowed", []string{"direction"})
+	}
+	return nil
+}
+
+/*
+RelationRequestQueryHandler queries pending requests of a relation
+received by (direction "incoming", the default) or sent by (direction
+"outgoing") the current user, latest first.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"action": "relation:request:query",
+	"access_token": "ACCESS_TOKEN",
+	"name": "friend",
+	"direction": "incoming",
+	"limit": 20,
+	"offset": 0
+}
+EOF
+*/
+type RelationRequestQueryHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	CheckUser     router.Processor `preprocessor:"check_user"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *RelationRequestQueryHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *RelationRequestQueryHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RelationRequestQueryHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := relationRequestQueryPayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	name, _, skyErr := resolveRelation(rpayload.DBConn, payload.Name)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	requests, err := rpayload.DBConn.QueryRelationRequests(
+		rpayload.AuthInfoID, name, payload.Direction, skydb.QueryConfig{
+			Limit:  payload.Limit,
+			Offset: payload.Offset,
+		})
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = requests
+}
@@ -0,0 +1,336 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/pubsub"
+	"github.com/skygeario/skygear-server/pkg/server/push"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+type relationRequestDeviceConn struct {
+	devices []skydb.Device
+	*skydbtest.MapConn
+}
+
+func (conn *relationRequestDeviceConn) QueryDevicesByUser(user string) ([]skydb.Device, error) {
+	return conn.devices, nil
+}
+
+func TestRelationRequestHandlers(t *testing.T) {
+	now := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+	realTimeNow := timeNow
+	timeNow = func() time.Time { return now }
+	defer func() {
+		timeNow = realTimeNow
+	}()
+
+	Convey("RelationRequestHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		r := handlertest.NewSingleRouteRouter(&RelationRequestHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = &skydb.AuthInfo{ID: "alice"}
+			p.AuthInfoID = "alice"
+		})
+
+		Convey("sends a friend request", func() {
+			resp := r.POST(`{"name": "friend", "targets": ["bob"]}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"id": "bob",
+					"type": "relation_request",
+					"data": {
+						"name": "_friend",
+						"requester_id": "alice",
+						"recipient_id": "bob",
+						"state": "pending",
+						"created_at": "2017-07-01T00:00:00Z",
+						"updated_at": "2017-07-01T00:00:00Z"
+					}
+				}]
+			}`)
+			So(conn.RelationMap, ShouldBeEmpty)
+		})
+
+		Convey("rejects duplicated pending request", func() {
+			r.POST(`{"name": "friend", "targets": ["bob"]}`)
+			resp := r.POST(`{"name": "friend", "targets": ["bob"]}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"id": "bob",
+					"type": "error",
+					"data": {
+						"code": 109,
+						"message": "relation request already pending",
+						"name": "Duplicated"
+					}
+				}]
+			}`)
+		})
+
+		Convey("rejects request to oneself", func() {
+			resp := r.POST(`{"name": "friend", "targets": ["alice"]}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(conn.RelationRequestMap, ShouldBeEmpty)
+		})
+
+		Convey("rejects request to a user who blocked the requester", func() {
+			So(conn.BlockUser(&skydb.UserBlock{
+				BlockerID: "bob",
+				BlockedID: "alice",
+				CreatedAt: now,
+			}), ShouldBeNil)
+
+			resp := r.POST(`{"name": "friend", "targets": ["bob"]}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"id": "bob",
+					"type": "error",
+					"data": {
+						"code": 102,
+						"message": "blocked by the user",
+						"name": "PermissionDenied"
+					}
+				}]
+			}`)
+			So(conn.RelationRequestMap, ShouldBeEmpty)
+		})
+
+		Convey("accepts the pending request of the target", func() {
+			So(conn.CreateRelationRequest(&skydb.RelationRequest{
+				Name:        "_friend",
+				RequesterID: "bob",
+				RecipientID: "alice",
+				CreatedAt:   now,
+			}), ShouldBeNil)
+
+			r.POST(`{"name": "friend", "targets": ["bob"]}`)
+			request, err := conn.GetRelationRequest("_friend", "bob", "alice")
+			So(err, ShouldBeNil)
+			So(request.State, ShouldEqual, skydb.RelationRequestAccepted)
+			So(conn.RelationMap, ShouldContainKey, "_friend/alice/bob")
+			So(conn.RelationMap, ShouldContainKey, "_friend/bob/alice")
+		})
+	})
+
+	Convey("RelationAcceptHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		So(conn.CreateRelationRequest(&skydb.RelationRequest{
+			Name:        "_friend",
+			RequesterID: "bob",
+			RecipientID: "alice",
+			CreatedAt:   now,
+		}), ShouldBeNil)
+
+		r := handlertest.NewSingleRouteRouter(&RelationAcceptHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = &skydb.AuthInfo{ID: "alice"}
+			p.AuthInfoID = "alice"
+		})
+
+		Convey("accepts a friend request", func() {
+			resp := r.POST(`{"name": "friend", "targets": ["bob"]}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(conn.RelationRequestMap["_friend/bob/alice"].State, ShouldEqual, skydb.RelationRequestAccepted)
+			So(conn.RelationMap, ShouldContainKey, "_friend/alice/bob")
+			So(conn.RelationMap, ShouldContainKey, "_friend/bob/alice")
+		})
+
+		Convey("rejects missing request", func() {
+			resp := r.POST(`{"name": "friend", "targets": ["carol"]}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"id": "carol",
+					"type": "error",
+					"data": {
+						"code": 110,
+						"message": "no pending relation request",
+						"name": "ResourceNotFound"
+					}
+				}]
+			}`)
+		})
+	})
+
+	Convey("RelationDeclineHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		So(conn.CreateRelationRequest(&skydb.RelationRequest{
+			Name:        "_friend",
+			RequesterID: "bob",
+			RecipientID: "alice",
+			CreatedAt:   now,
+		}), ShouldBeNil)
+
+		r := handlertest.NewSingleRouteRouter(&RelationDeclineHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = &skydb.AuthInfo{ID: "alice"}
+			p.AuthInfoID = "alice"
+		})
+
+		Convey("declines a friend request", func() {
+			resp := r.POST(`{"name": "friend", "targets": ["bob"]}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(conn.RelationRequestMap["_friend/bob/alice"].State, ShouldEqual, skydb.RelationRequestDeclined)
+			So(conn.RelationMap, ShouldBeEmpty)
+		})
+	})
+
+	Convey("RelationCancelHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		So(conn.CreateRelationRequest(&skydb.RelationRequest{
+			Name:        "_friend",
+			RequesterID: "alice",
+			RecipientID: "bob",
+			CreatedAt:   now,
+		}), ShouldBeNil)
+
+		r := handlertest.NewSingleRouteRouter(&RelationCancelHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = &skydb.AuthInfo{ID: "alice"}
+			p.AuthInfoID = "alice"
+		})
+
+		Convey("cancels a friend request", func() {
+			resp := r.POST(`{"name": "friend", "targets": ["bob"]}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(conn.RelationRequestMap["_friend/alice/bob"].State, ShouldEqual, skydb.RelationRequestCancelled)
+		})
+
+		Convey("cannot cancel request of others", func() {
+			r := handlertest.NewSingleRouteRouter(&RelationCancelHandler{}, func(p *router.Payload) {
+				p.DBConn = conn
+				p.AuthInfo = &skydb.AuthInfo{ID: "bob"}
+				p.AuthInfoID = "bob"
+			})
+			r.POST(`{"name": "friend", "targets": ["alice"]}`)
+			So(conn.RelationRequestMap["_friend/alice/bob"].State, ShouldEqual, skydb.RelationRequestPending)
+		})
+	})
+
+	Convey("RelationRequestQueryHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		for _, requester := range []string{"bob", "carol"} {
+			So(conn.CreateRelationRequest(&skydb.RelationRequest{
+				Name:        "_friend",
+				RequesterID: requester,
+				RecipientID: "alice",
+				CreatedAt:   now,
+			}), ShouldBeNil)
+		}
+
+		r := handlertest.NewSingleRouteRouter(&RelationRequestQueryHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = &skydb.AuthInfo{ID: "alice"}
+			p.AuthInfoID = "alice"
+		})
+
+		Convey("queries incoming requests", func() {
+			resp := r.POST(`{"name": "friend"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"name": "_friend",
+					"requester_id": "bob",
+					"recipient_id": "alice",
+					"state": "pending",
+					"created_at": "2017-07-01T00:00:00Z",
+					"updated_at": "2017-07-01T00:00:00Z"
+				}, {
+					"name": "_friend",
+					"requester_id": "carol",
+					"recipient_id": "alice",
+					"state": "pending",
+					"created_at": "2017-07-01T00:00:00Z",
+					"updated_at": "2017-07-01T00:00:00Z"
+				}]
+			}`)
+		})
+
+		Convey("queries outgoing requests", func() {
+			resp := r.POST(`{"name": "friend", "direction": "outgoing"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{"result": []}`)
+		})
+
+		Convey("rejects wrong direction", func() {
+			resp := r.POST(`{"name": "friend", "direction": "mutual"}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+	})
+}
+
+func TestRelationRequestNotifier(t *testing.T) {
+	Convey("RelationRequestNotifier", t, func(c C) {
+		device := skydb.Device{
+			ID:    "device-id",
+			Type:  "ios",
+			Token: "device-token",
+		}
+		conn := &relationRequestDeviceConn{
+			devices: []skydb.Device{device},
+			MapConn: skydbtest.NewMapConn(),
+		}
+
+		originalSendFunc := sendPushNotification
+		defer func() {
+			sendPushNotification = originalSendFunc
+		}()
+
+		var pushed push.Mapper
+		sendPushNotification = func(sender push.Sender, d skydb.Device, m push.Mapper) {
+			c.So(d, ShouldResemble, device)
+			pushed = m
+		}
+
+		hub := pubsub.NewHub()
+		parcels := make(chan pubsub.Parcel, 1)
+		go func() {
+			parcels <- <-hub.Broadcast
+		}()
+
+		notifier := &RelationRequestNotifier{
+			Hub:        hub,
+			PushSender: push.NewRouteSender(),
+		}
+		request := skydb.RelationRequest{
+			Name:        "_friend",
+			RequesterID: "alice",
+			RecipientID: "bob",
+			State:       skydb.RelationRequestPending,
+		}
+		notifier.Notify(conn, "bob", RelationRequestRequested, request)
+
+		parcel := <-parcels
+		So(parcel.Channel, ShouldEqual, "_relation_request_device-id")
+		notice := map[string]interface{}{}
+		So(json.Unmarshal(parcel.Data, &notice), ShouldBeNil)
+		So(notice["event"], ShouldEqual, "requested")
+
+		So(pushed, ShouldNotBeNil)
+		aps := pushed.Map()["apns"].(map[string]interface{})["aps"].(map[string]interface{})
+		So(aps["alert"], ShouldResemble, map[string]interface{}{
+			"loc-key":  "RELATION_REQUEST_REQUESTED",
+			"loc-args": []string{"_friend", "alice"},
+		})
+	})
+}
@@ -73,6 +73,10 @@ func (conn *testRelationConn) AddRelation(user string, name string, targetUser s
 	return nil
 }
 
+func (conn *testRelationConn) IsUserBlocked(blockerID string, blockedID string) (bool, error) {
+	return false, nil
+}
+
 func (conn *testRelationConn) RemoveRelation(user string, name string, targetUser string) error {
 	conn.RelationName = name
 	conn.removeID = targetUser
@@ -0,0 +1,236 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+type relationTypeCreatePayload struct {
+	Name      string            `mapstructure:"name"`
+	Direction string            `mapstructure:"direction"`
+	Fields    map[string]string `mapstructure:"fields"`
+}
+
+func (payload *relationTypeCreatePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *relationTypeCreatePayload) Validate() skyerr.Error {
+	if payload.Direction == "" {
+		payload.Direction = string(skydb.DirectedRelation)
+	}
+	if skydb.IsBuiltinRelation(skydb.CanonicalRelationName(payload.Name)) {
+		return skyerr.NewInvalidArgument("cannot redefine builtin relation", []string{"name"})
+	}
+	if err := payload.RelationType().Validate(); err != nil {
+		return skyerr.NewInvalidArgument(err.Error(), []string{"name", "direction", "fields"})
+	}
+	return nil
+}
+
+func (payload *relationTypeCreatePayload) RelationType() skydb.RelationType {
+	return skydb.RelationType{
+		Name:      payload.Name,
+		Direction: skydb.RelationDirection(payload.Direction),
+		Fields:    payload.Fields,
+	}
+}
+
+/*
+RelationTypeCreateHandler defines a relation type in addition to the
+builtin friend and follow relations. A mutual relation type relates both
+users when one of them adds the relation. Metadata fields are declared
+with one of the types string, number, boolean, datetime and json.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"api_key": "MASTER_KEY",
+	"action": "relation:type:create",
+	"name": "colleague",
+	"direction": "mutual",
+	"fields": {
+		"department": "string",
+		"since": "datetime"
+	}
+}
+EOF
+*/
+type RelationTypeCreateHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *RelationTypeCreateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *RelationTypeCreateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RelationTypeCreateHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := relationTypeCreatePayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	relationType := payload.RelationType()
+	relationType.CreatedAt = timeNow()
+	if err := rpayload.DBConn.CreateRelationType(&relationType); err != nil {
+		if err == skydb.ErrRelationTypeDuplicated {
+			response.Err = skyerr.NewError(skyerr.Duplicated, fmt.Sprintf("relation %s already exists", relationType.Name))
+		} else {
+			response.Err = skyerr.MakeError(err)
+		}
+		return
+	}
+
+	response.Result = relationType
+}
+
+/*
+RelationTypeListHandler lists the relation types defined by the app.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"api_key": "MASTER_KEY",
+	"action": "relation:type:list"
+}
+EOF
+*/
+type RelationTypeListHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *RelationTypeListHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *RelationTypeListHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RelationTypeListHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	relationTypes, err := rpayload.DBConn.GetRelationTypes()
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = relationTypes
+}
+
+type relationTypeDeletePayload struct {
+	Name string `mapstructure:"name"`
+}
+
+func (payload *relationTypeDeletePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *relationTypeDeletePayload) Validate() skyerr.Error {
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty relation name", []string{"name"})
+	}
+	if skydb.IsBuiltinRelation(skydb.CanonicalRelationName(payload.Name)) {
+		return skyerr.NewInvalidArgument("cannot delete builtin relation", []string{"name"})
+	}
+	return nil
+}
+
+/*
+RelationTypeDeleteHandler deletes a relation type defined by the app,
+together with all relations of the type.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"api_key": "MASTER_KEY",
+	"action": "relation:type:delete",
+	"name": "colleague"
+}
+EOF
+*/
+type RelationTypeDeleteHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *RelationTypeDeleteHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *RelationTypeDeleteHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RelationTypeDeleteHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := relationTypeDeletePayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	if err := rpayload.DBConn.DeleteRelationType(payload.Name); err != nil {
+		if err == skydb.ErrRelationTypeNotFound {
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, fmt.Sprintf("relation %s is not defined", payload.Name))
+		} else {
+			response.Err = skyerr.MakeError(err)
+		}
+		return
+	}
+
+	response.Result = struct {
+		Name string `json:"name"`
+	}{payload.Name}
+}
@@ -0,0 +1,157 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func TestRelationTypeHandler(t *testing.T) {
+	Convey("RelationTypeCreateHandler", t, func() {
+		now := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		r := handlertest.NewSingleRouteRouter(&RelationTypeCreateHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("creates relation type", func() {
+			resp := r.POST(`{
+				"name": "colleague",
+				"direction": "mutual",
+				"fields": {"department": "string"}
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"name": "colleague",
+					"direction": "mutual",
+					"fields": {"department": "string"},
+					"created_at": "2017-07-01T00:00:00Z"
+				}
+			}`)
+			So(conn.RelationTypeMap["colleague"].Direction, ShouldEqual, skydb.MutualRelation)
+		})
+
+		Convey("defaults to directed relation", func() {
+			resp := r.POST(`{"name": "mentor"}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(conn.RelationTypeMap["mentor"].Direction, ShouldEqual, skydb.DirectedRelation)
+		})
+
+		Convey("rejects builtin relation", func() {
+			resp := r.POST(`{"name": "friend"}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+
+		Convey("rejects invalid field type", func() {
+			resp := r.POST(`{
+				"name": "colleague",
+				"fields": {"office": "geometry"}
+			}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+
+		Convey("rejects duplicated relation type", func() {
+			So(conn.CreateRelationType(&skydb.RelationType{
+				Name:      "colleague",
+				Direction: skydb.DirectedRelation,
+			}), ShouldBeNil)
+
+			resp := r.POST(`{"name": "colleague"}`)
+			So(resp.Code, ShouldEqual, 409)
+		})
+	})
+
+	Convey("RelationTypeDeleteHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		r := handlertest.NewSingleRouteRouter(&RelationTypeDeleteHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("deletes relation type", func() {
+			So(conn.CreateRelationType(&skydb.RelationType{
+				Name:      "colleague",
+				Direction: skydb.MutualRelation,
+			}), ShouldBeNil)
+
+			resp := r.POST(`{"name": "colleague"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {"name": "colleague"}
+			}`)
+			So(conn.RelationTypeMap, ShouldBeEmpty)
+		})
+
+		Convey("rejects non-existent relation type", func() {
+			resp := r.POST(`{"name": "colleague"}`)
+			So(resp.Code, ShouldEqual, 404)
+		})
+	})
+
+	Convey("RelationAddHandler with relation type", t, func() {
+		conn := skydbtest.NewMapConn()
+		So(conn.CreateRelationType(&skydb.RelationType{
+			Name:      "colleague",
+			Direction: skydb.MutualRelation,
+			Fields: map[string]string{
+				"department": "string",
+			},
+		}), ShouldBeNil)
+
+		r := handlertest.NewSingleRouteRouter(&RelationAddHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = &skydb.AuthInfo{
+				ID: "user-1",
+			}
+		})
+
+		Convey("rejects metadata of wrong type", func() {
+			resp := r.POST(`{
+				"name": "colleague",
+				"targets": ["user-2"],
+				"metadata": {"department": 1}
+			}`)
+			So(resp.Code, ShouldEqual, 400)
+			So(conn.RelationMap, ShouldBeEmpty)
+		})
+
+		Convey("rejects undefined relation", func() {
+			resp := r.POST(`{
+				"name": "neighbour",
+				"targets": ["user-2"]
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 111,
+					"message": "Relation neighbour is not defined",
+					"name": "NotSupported"
+				}
+			}`)
+		})
+	})
+}
@@ -19,7 +19,9 @@ import (
 
 	"github.com/skygeario/skygear-server/pkg/server/audit"
 	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/permission"
 	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
 	"github.com/skygeario/skygear-server/pkg/server/skyerr"
 )
 
@@ -457,3 +459,300 @@ func (h *RoleGetHandler) Handle(rpayload *router.Payload, response *router.Respo
 
 	response.Result = roleMap
 }
+
+type roleHierarchyPayload struct {
+	Hierarchy map[string][]string `mapstructure:"hierarchy"`
+}
+
+func (payload *roleHierarchyPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *roleHierarchyPayload) Validate() skyerr.Error {
+	if payload.Hierarchy == nil {
+		return skyerr.NewInvalidArgument("unspecified hierarchy in request", []string{"hierarchy"})
+	}
+	for role, inheritedRoles := range payload.Hierarchy {
+		if role == "" {
+			return skyerr.NewInvalidArgument("empty role in hierarchy", []string{"hierarchy"})
+		}
+		for _, inheritedRole := range inheritedRoles {
+			if inheritedRole == "" {
+				return skyerr.NewInvalidArgument("empty role in hierarchy", []string{"hierarchy"})
+			}
+		}
+	}
+	return nil
+}
+
+// RoleHierarchySetHandler enable system administrator to set the roles
+// inherited by a role. A user having a role also has all the roles inherited
+// by that role, directly or indirectly, when record ACL, field ACL and admin
+// roles are checked.
+//
+// Roles not specified in the request keep their inherited roles. Specify an
+// empty array to remove all inherited roles of a role. Cyclic inheritance is
+// rejected.
+//
+// curl -X POST -H "Content-Type: application/json" \
+//   -d @- http://localhost:3000/ <<EOF
+// {
+//     "action": "role:hierarchy:set",
+//     "master_key": "MASTER_KEY",
+//     "hierarchy": {
+//        "admin": ["editor"],
+//        "editor": ["viewer"]
+//     }
+// }
+// EOF
+//
+// {
+//     "result": {
+//        "admin": ["editor"],
+//        "editor": ["viewer"]
+//     }
+// }
+type RoleHierarchySetHandler struct {
+	AccessKey     router.Processor `preprocessor:"accesskey"`
+	DevOnly       router.Processor `preprocessor:"dev_only"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *RoleHierarchySetHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DevOnly,
+		h.DBConn,
+		h.PluginReady,
+	}
+}
+
+func (h *RoleHierarchySetHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RoleHierarchySetHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := &roleHierarchyPayload{}
+	skyErr := payload.Decode(rpayload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	hierarchy, err := rpayload.DBConn.GetRoleHierarchy()
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	for role, inheritedRoles := range payload.Hierarchy {
+		if len(inheritedRoles) == 0 {
+			delete(hierarchy, role)
+			continue
+		}
+		hierarchy[role] = inheritedRoles
+	}
+	if hierarchy.HasCycle() {
+		response.Err = skyerr.NewInvalidArgument("role hierarchy contains cyclic inheritance", []string{"hierarchy"})
+		return
+	}
+
+	if err := rpayload.DBConn.SetRoleHierarchy(skydb.RoleHierarchy(payload.Hierarchy)); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	response.Result = hierarchy
+}
+
+// RoleHierarchyGetHandler returns the roles inherited by each role.
+//
+// curl -X POST -H "Content-Type: application/json" \
+//   -d @- http://localhost:3000/ <<EOF
+// {
+//     "action": "role:hierarchy:get",
+//     "api_key": "API_KEY",
+//     "access_token": "ACCESS_TOKEN"
+// }
+// EOF
+//
+// {
+//     "result": {
+//        "admin": ["editor"],
+//        "editor": ["viewer"]
+//     }
+// }
+type RoleHierarchyGetHandler struct {
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"inject_auth"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *RoleHierarchyGetHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.PluginReady,
+	}
+}
+
+func (h *RoleHierarchyGetHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RoleHierarchyGetHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	hierarchy, err := rpayload.DBConn.GetRoleHierarchy()
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	response.Result = hierarchy
+}
+
+type rolePermissionPayload struct {
+	Role    string   `mapstructure:"role"`
+	Actions []string `mapstructure:"actions"`
+}
+
+func (payload *rolePermissionPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *rolePermissionPayload) Validate() skyerr.Error {
+	if payload.Role == "" {
+		return skyerr.NewInvalidArgument("unspecified role in request", []string{"role"})
+	}
+	if payload.Actions == nil {
+		return skyerr.NewInvalidArgument("unspecified actions in request", []string{"actions"})
+	}
+	for _, action := range payload.Actions {
+		if action == "" {
+			return skyerr.NewInvalidArgument("empty action in request", []string{"actions"})
+		}
+	}
+	return nil
+}
+
+// RolePermissionSetHandler enable system administrator to set the router
+// actions a role is permitted to call, including plugin lambdas.
+//
+// Once an action is permitted to any role, only master key, admins and
+// users having a permitted role can call the action. Users having a
+// permitted role can also call actions otherwise requiring an admin role.
+// An action can be an action name, an action prefix such as "record:*", or
+// "*" for all actions. Specify an empty array to remove all permissions of
+// a role.
+//
+// curl -X POST -H "Content-Type: application/json" \
+//   -d @- http://localhost:3000/ <<EOF
+// {
+//     "action": "role:permission:set",
+//     "master_key": "MASTER_KEY",
+//     "role": "support",
+//     "actions": [
+//        "auth:disable:set",
+//        "auth:unlock"
+//     ]
+// }
+// EOF
+//
+// {
+//     "result": {
+//        "support": ["auth:disable:set", "auth:unlock"]
+//     }
+// }
+type RolePermissionSetHandler struct {
+	PermissionChecker *permission.Checker `inject:"PermissionChecker"`
+	AccessKey         router.Processor    `preprocessor:"accesskey"`
+	DBConn            router.Processor    `preprocessor:"dbconn"`
+	PluginReady       router.Processor    `preprocessor:"plugin_ready"`
+	RequireMasterKey  router.Processor    `preprocessor:"require_master_key"`
+	preprocessors     []router.Processor
+}
+
+func (h *RolePermissionSetHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *RolePermissionSetHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RolePermissionSetHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := &rolePermissionPayload{}
+	skyErr := payload.Decode(rpayload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	if err := rpayload.DBConn.SetRolePermissions(payload.Role, payload.Actions); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	h.PermissionChecker.Invalidate()
+
+	response.Result = map[string][]string{
+		payload.Role: payload.Actions,
+	}
+}
+
+// RolePermissionGetHandler returns the router actions each role is
+// permitted to call.
+//
+// curl -X POST -H "Content-Type: application/json" \
+//   -d @- http://localhost:3000/ <<EOF
+// {
+//     "action": "role:permission:get",
+//     "master_key": "MASTER_KEY"
+// }
+// EOF
+//
+// {
+//     "result": {
+//        "support": ["auth:disable:set", "auth:unlock"]
+//     }
+// }
+type RolePermissionGetHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *RolePermissionGetHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *RolePermissionGetHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *RolePermissionGetHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	permissions, err := rpayload.DBConn.GetRolePermissions()
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	response.Result = permissions
+}
@@ -16,17 +16,20 @@ package handler
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/smartystreets/goconvey/convey"
 
 	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/permission"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skyerr"
 	. "github.com/skygeario/skygear-server/pkg/server/skytest"
 
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
 	"github.com/skygeario/skygear-server/pkg/server/skydb/mock_skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
 )
 
 func TestRolePayload(t *testing.T) {
@@ -420,3 +423,155 @@ func TestRoleGetHandler(t *testing.T) {
 		})
 	})
 }
+
+func TestRoleHierarchySetHandler(t *testing.T) {
+	Convey("RoleHierarchySetHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.RoleHierarchy = skydb.RoleHierarchy{
+			"editor": []string{"viewer"},
+		}
+		router := handlertest.NewSingleRouteRouter(&RoleHierarchySetHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("set role hierarchy successfully", func() {
+			resp := router.POST(`{
+    "hierarchy": {"admin": ["editor"]}
+}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+    "result": {
+        "admin": ["editor"],
+        "editor": ["viewer"]
+    }
+}`)
+			So(conn.RoleHierarchy, ShouldResemble, skydb.RoleHierarchy{
+				"admin":  []string{"editor"},
+				"editor": []string{"viewer"},
+			})
+		})
+
+		Convey("remove inherited roles with empty array", func() {
+			resp := router.POST(`{
+    "hierarchy": {"editor": []}
+}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+    "result": {}
+}`)
+			So(conn.RoleHierarchy, ShouldResemble, skydb.RoleHierarchy{})
+		})
+
+		Convey("reject cyclic inheritance", func() {
+			resp := router.POST(`{
+    "hierarchy": {"viewer": ["editor"]}
+}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+    "error": {
+        "code": 108,
+        "name": "InvalidArgument",
+        "info": {"arguments": ["hierarchy"]},
+        "message": "role hierarchy contains cyclic inheritance"
+    }
+}`)
+			So(conn.RoleHierarchy, ShouldResemble, skydb.RoleHierarchy{
+				"editor": []string{"viewer"},
+			})
+		})
+
+		Convey("reject request without hierarchy", func() {
+			resp := router.POST(`{}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+	})
+}
+
+func TestRoleHierarchyGetHandler(t *testing.T) {
+	Convey("RoleHierarchyGetHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.RoleHierarchy = skydb.RoleHierarchy{
+			"admin": []string{"editor"},
+		}
+		router := handlertest.NewSingleRouteRouter(&RoleHierarchyGetHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("get role hierarchy", func() {
+			resp := router.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+    "result": {
+        "admin": ["editor"]
+    }
+}`)
+		})
+	})
+}
+
+func TestRolePermissionSetHandler(t *testing.T) {
+	Convey("RolePermissionSetHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		checker := permission.NewChecker(func() (skydb.Conn, error) {
+			return conn, nil
+		}, time.Minute)
+		router := handlertest.NewSingleRouteRouter(&RolePermissionSetHandler{
+			PermissionChecker: checker,
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("set role permissions successfully", func() {
+			support := &skydb.AuthInfo{ID: "support", Roles: []string{"support"}}
+			decision, _ := checker.Decide(support, "auth:disable:set")
+			So(decision, ShouldEqual, permission.Unrestricted)
+
+			resp := router.POST(`{
+    "role": "support",
+    "actions": ["auth:disable:set", "auth:unlock"]
+}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+    "result": {
+        "support": ["auth:disable:set", "auth:unlock"]
+    }
+}`)
+			So(conn.RolePermissions, ShouldResemble, skydb.RolePermissions{
+				"support": []string{"auth:disable:set", "auth:unlock"},
+			})
+
+			decision, _ = checker.Decide(support, "auth:disable:set")
+			So(decision, ShouldEqual, permission.Permitted)
+		})
+
+		Convey("reject request without actions", func() {
+			resp := router.POST(`{
+    "role": "support"
+}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+    "error": {
+        "code": 108,
+        "name": "InvalidArgument",
+        "info": {"arguments": ["actions"]},
+        "message": "unspecified actions in request"
+    }
+}`)
+		})
+	})
+}
+
+func TestRolePermissionGetHandler(t *testing.T) {
+	Convey("RolePermissionGetHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.RolePermissions = skydb.RolePermissions{
+			"support": []string{"auth:disable:set"},
+		}
+		router := handlertest.NewSingleRouteRouter(&RolePermissionGetHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("get role permissions", func() {
+			resp := router.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+    "result": {
+        "support": ["auth:disable:set"]
+    }
+}`)
+		})
+	})
+}
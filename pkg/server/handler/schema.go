@@ -21,6 +21,7 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/skygeario/skygear-server/pkg/server/logging"
 	pluginEvent "github.com/skygeario/skygear-server/pkg/server/plugin/event"
+	"github.com/skygeario/skygear-server/pkg/server/recordutil"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
 	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
@@ -774,3 +775,192 @@ func (h *SchemaFieldAccessUpdateHandler) Handle(rpayload *router.Payload, respon
 
 	response.Result = schemaFieldAccessResponse{}.WithAccess(payload.FieldACL)
 }
+
+type schemaPredicateAccessResponse struct {
+	Type  string        `json:"type"`
+	Read  []interface{} `json:"read,omitempty"`
+	Write []interface{} `json:"write,omitempty"`
+}
+
+type schemaPredicateAccessGetPayload struct {
+	Type string `mapstructure:"type"`
+}
+
+func (payload *schemaPredicateAccessGetPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *schemaPredicateAccessGetPayload) Validate() skyerr.Error {
+	if payload.Type == "" {
+		return skyerr.NewInvalidArgument("missing required fields", []string{"type"})
+	}
+	return nil
+}
+
+/*
+SchemaPredicateAccessGetHandler fetches the row-level read and write
+predicates of a record type.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"api_key": "MASTER_KEY",
+	"action": "schema:predicate_access:get",
+	"type": "note"
+}
+EOF
+*/
+type SchemaPredicateAccessGetHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *SchemaPredicateAccessGetHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *SchemaPredicateAccessGetHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *SchemaPredicateAccessGetHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := schemaPredicateAccessGetPayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	access, err := rpayload.DBConn.GetRecordPredicateAccess(payload.Type)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = schemaPredicateAccessResponse{
+		Type:  payload.Type,
+		Read:  access.Read,
+		Write: access.Write,
+	}
+}
+
+type schemaPredicateAccessUpdatePayload struct {
+	Type  string        `mapstructure:"type"`
+	Read  []interface{} `mapstructure:"read"`
+	Write []interface{} `mapstructure:"write"`
+}
+
+func (payload *schemaPredicateAccessUpdatePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *schemaPredicateAccessUpdatePayload) Validate() skyerr.Error {
+	if payload.Type == "" {
+		return skyerr.NewInvalidArgument("missing required fields", []string{"type"})
+	}
+	if _, err := parseRecordPredicate(payload.Read); err != nil {
+		return skyerr.NewInvalidArgument("invalid read predicate: "+err.Error(), []string{"read"})
+	}
+	predicate, err := parseRecordPredicate(payload.Write)
+	if err == nil {
+		err = recordutil.ValidateRecordWritePredicate(predicate)
+	}
+	if err != nil {
+		return skyerr.NewInvalidArgument("invalid write predicate: "+err.Error(), []string{"write"})
+	}
+	return nil
+}
+
+func parseRecordPredicate(rawPredicate []interface{}) (skydb.Predicate, error) {
+	if len(rawPredicate) == 0 {
+		return skydb.Predicate{}, nil
+	}
+
+	parser := skyconv.PredicateParser{}
+	predicate, err := parser.ParsePredicate(rawPredicate)
+	if err != nil {
+		return skydb.Predicate{}, err
+	}
+	if err := predicate.Validate(); err != nil {
+		return skydb.Predicate{}, err
+	}
+	return predicate, nil
+}
+
+/*
+SchemaPredicateAccessUpdateHandler replaces the row-level read and write
+predicates of a record type. The read predicate is ANDed into every query
+of the record type and checked on fetch; the write predicate is checked on
+the existing record before it is saved or deleted, and on the record as it
+is saved, so it can only refer to fields of the record and user relations.
+{"$type": "user"} stands for the current user. Omit a predicate to remove
+it.
+curl -X POST -H "Content-Type: application/json" \
+  -d @- http://localhost:3000/ <<EOF
+{
+	"api_key": "MASTER_KEY",
+	"action": "schema:predicate_access:update",
+	"type": "note",
+	"read": ["or",
+		["eq", {"$type": "keypath", "$val": "_owner"}, {"$type": "user"}],
+		["eq", {"$type": "keypath", "$val": "status"}, "published"]
+	],
+	"write": ["eq", {"$type": "keypath", "$val": "_owner"}, {"$type": "user"}]
+}
+EOF
+*/
+type SchemaPredicateAccessUpdateHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *SchemaPredicateAccessUpdateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *SchemaPredicateAccessUpdateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *SchemaPredicateAccessUpdateHandler) Handle(rpayload *router.Payload, response *router.Response) {
+	payload := schemaPredicateAccessUpdatePayload{}
+	if skyErr := payload.Decode(rpayload.Data); skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	access := skydb.RecordPredicateAccess{
+		Read:  payload.Read,
+		Write: payload.Write,
+	}
+	if err := rpayload.DBConn.SetRecordPredicateAccess(payload.Type, access); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = schemaPredicateAccessResponse{
+		Type:  payload.Type,
+		Read:  access.Read,
+		Write: access.Write,
+	}
+}
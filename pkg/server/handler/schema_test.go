@@ -1117,3 +1117,98 @@ func TestSchemaFieldAccessUpdateHandler(t *testing.T) {
 		})
 	})
 }
+
+func TestSchemaPredicateAccessHandler(t *testing.T) {
+	Convey("SchemaPredicateAccessUpdateHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		handler := handlertest.NewSingleRouteRouter(&SchemaPredicateAccessUpdateHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("should set read and write predicates", func() {
+			resp := handler.POST(`{
+				"type": "note",
+				"read": ["eq", {"$type": "keypath", "$val": "status"}, "published"],
+				"write": ["eq", {"$type": "keypath", "$val": "_owner"}, {"$type": "user"}]
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"type": "note",
+					"read": ["eq", {"$type": "keypath", "$val": "status"}, "published"],
+					"write": ["eq", {"$type": "keypath", "$val": "_owner"}, {"$type": "user"}]
+				}
+			}`)
+
+			access, err := conn.GetRecordPredicateAccess("note")
+			So(err, ShouldBeNil)
+			So(access.Read, ShouldHaveLength, 3)
+			So(access.Write, ShouldHaveLength, 3)
+		})
+
+		Convey("should reject invalid predicate", func() {
+			resp := handler.POST(`{
+				"type": "note",
+				"read": ["unknown", {"$type": "keypath", "$val": "status"}, "published"]
+			}`)
+			So(resp.Code, ShouldEqual, 400)
+			So(resp.Body.String(), ShouldContainSubstring, "invalid read predicate")
+		})
+
+		Convey("should reject write predicate referring to another record", func() {
+			resp := handler.POST(`{
+				"type": "note",
+				"write": ["eq", {"$type": "keypath", "$val": "project.owner"}, {"$type": "user"}]
+			}`)
+			So(resp.Code, ShouldEqual, 400)
+			So(resp.Body.String(), ShouldContainSubstring, "invalid write predicate")
+		})
+
+		Convey("should reject missing type", func() {
+			resp := handler.POST(`{
+				"read": ["eq", {"$type": "keypath", "$val": "status"}, "published"]
+			}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "missing required fields",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["type"]}
+				}
+			}`)
+		})
+	})
+
+	Convey("SchemaPredicateAccessGetHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		handler := handlertest.NewSingleRouteRouter(&SchemaPredicateAccessGetHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("should get predicates", func() {
+			conn.SetRecordPredicateAccess("note", skydb.RecordPredicateAccess{
+				Read: []interface{}{
+					"eq",
+					map[string]interface{}{"$type": "keypath", "$val": "status"},
+					"published",
+				},
+			})
+
+			resp := handler.POST(`{"type": "note"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"type": "note",
+					"read": ["eq", {"$type": "keypath", "$val": "status"}, "published"]
+				}
+			}`)
+		})
+
+		Convey("should return no predicates for unknown type", func() {
+			resp := handler.POST(`{"type": "comment"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"type": "comment"
+				}
+			}`)
+		})
+	})
+}
@@ -155,6 +155,17 @@ func (h *LoginProviderHandler) Handle(payload *router.Payload, response *router.
 		return
 	}
 
+	// The user record is saved below with the last login time, so changes
+	// made by the hooks are saved along with it.
+	hookCtx := authHookContext{
+		HookRegistry: h.HookRegistry,
+		Context:      payload.Context(),
+	}
+	if err := hookCtx.execute(hook.BeforeLogin, &info, &user); err != nil {
+		response.Err = err
+		return
+	}
+
 	// generate access-token
 	token, err := store.NewToken(payload.AppName, oauth.UserID)
 	if err != nil {
@@ -165,6 +176,8 @@ func (h *LoginProviderHandler) Handle(payload *router.Payload, response *router.
 		panic(err)
 	}
 
+	hookCtx.executeAfter(hook.AfterLogin, &info, &user)
+
 	authResponse, err := AuthResponseFactory{
 		AssetStore: h.AssetStore,
 		Conn:       payload.DBConn,
@@ -508,6 +521,17 @@ func (h *LinkProviderHandler) Handle(payload *router.Payload, response *router.R
 		return
 	}
 
+	hookCtx := authHookContext{
+		HookRegistry: h.HookRegistry,
+		Database:     payload.Database,
+		Context:      payload.Context(),
+	}
+	user := hookCtx.fetchUser(info.ID)
+	if err := hookCtx.execute(hook.BeforeProviderLink, &info, user); err != nil {
+		response.Err = err
+		return
+	}
+
 	// new oauth record for linking provider
 	now := timeNow()
 	oauth = skydb.OAuthInfo{
@@ -525,6 +549,8 @@ func (h *LinkProviderHandler) Handle(payload *router.Payload, response *router.R
 		return
 	}
 
+	hookCtx.executeAfter(hook.AfterProviderLink, &info, user)
+
 	response.Result = "OK"
 	return
 }
@@ -610,17 +636,36 @@ func (h *UnlinkProviderHandler) Handle(payload *router.Payload, response *router
 	}
 
 	oauth := skydb.OAuthInfo{}
+	info := skydb.AuthInfo{}
 
 	if err := payload.DBConn.GetOAuthInfoByProviderAndUserID(p.Provider, p.UserID, &oauth); err != nil {
 		response.Err = skyerr.NewError(skyerr.ResourceNotFound, "user not found")
 		return
 	}
 
+	if err := payload.DBConn.GetAuth(oauth.UserID, &info); err != nil {
+		response.Err = skyerr.NewError(skyerr.ResourceNotFound, "user not found")
+		return
+	}
+
+	hookCtx := authHookContext{
+		HookRegistry: h.HookRegistry,
+		Database:     payload.Database,
+		Context:      payload.Context(),
+	}
+	user := hookCtx.fetchUser(info.ID)
+	if err := hookCtx.execute(hook.BeforeProviderUnlink, &info, user); err != nil {
+		response.Err = err
+		return
+	}
+
 	if err := payload.DBConn.DeleteOAuth(oauth.Provider, oauth.PrincipalID); err != nil {
 		response.Err = skyerr.MakeError(err)
 		return
 	}
 
+	hookCtx.executeAfter(hook.AfterProviderUnlink, &info, user)
+
 	response.Result = "OK"
 	return
 }
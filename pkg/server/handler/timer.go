@@ -0,0 +1,144 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	"github.com/skygeario/skygear-server/pkg/server/timer"
+)
+
+/*
+TimerListHandler returns the timers registered by plugins, including their
+next run and the time and outcome of their last run.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "timer:list"
+	}
+	EOF
+*/
+type TimerListHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *TimerListHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *TimerListHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *TimerListHandler) Handle(payload *router.Payload, response *router.Response) {
+	timers, err := payload.DBConn.QueryTimers()
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = timers
+}
+
+type timerRunPayload struct {
+	Name string `mapstructure:"name"`
+}
+
+func (payload *timerRunPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *timerRunPayload) Validate() skyerr.Error {
+	if payload.Name == "" {
+		return skyerr.NewInvalidArgument("empty name", []string{"name"})
+	}
+	return nil
+}
+
+/*
+TimerRunHandler runs a timer immediately and returns the timer with the
+outcome of the run. The next scheduled run of the timer is unchanged. A
+timer being run, on this or another server instance, cannot be run again
+until the run is finished.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "timer:run",
+		"name": "cleanup"
+	}
+	EOF
+*/
+type TimerRunHandler struct {
+	TimerScheduler   *timer.Scheduler `inject:"TimerScheduler"`
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *TimerRunHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *TimerRunHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *TimerRunHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &timerRunPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	t, err := h.TimerScheduler.Run(p.Name)
+	if err != nil {
+		switch err {
+		case timer.ErrTimerNotRegistered:
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "timer not found")
+		case skydb.ErrTimerLocked:
+			response.Err = skyerr.NewInvalidArgument("timer is running", []string{"name"})
+		default:
+			response.Err = skyerr.MakeError(err)
+		}
+		return
+	}
+
+	response.Result = t
+}
@@ -0,0 +1,148 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	"github.com/skygeario/skygear-server/pkg/server/timer"
+)
+
+func TestTimerListHandler(t *testing.T) {
+	Convey("TimerListHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		lastRunAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		conn.RegisterTimer(&skydb.Timer{
+			Name:      "cleanup",
+			Spec:      "0 0 * * * *",
+			NextRunAt: time.Date(2017, 1, 1, 1, 0, 0, 0, time.UTC),
+		})
+		conn.UpdateTimer(&skydb.Timer{
+			Name:           "cleanup",
+			NextRunAt:      time.Date(2017, 1, 1, 1, 0, 0, 0, time.UTC),
+			LastRunAt:      &lastRunAt,
+			LastFinishedAt: &lastRunAt,
+			LastStatus:     skydb.TimerFailed,
+			LastError:      "database unavailable",
+		})
+
+		r := handlertest.NewSingleRouteRouter(&TimerListHandler{}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("lists timers with last run", func() {
+			resp := r.POST(`{}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"name": "cleanup",
+					"spec": "0 0 * * * *",
+					"catch_up": false,
+					"next_run_at": "2017-01-01T01:00:00Z",
+					"last_run_at": "2017-01-01T00:00:00Z",
+					"last_finished_at": "2017-01-01T00:00:00Z",
+					"last_status": "failed",
+					"last_error": "database unavailable"
+				}]
+			}`)
+		})
+	})
+}
+
+func TestTimerRunHandler(t *testing.T) {
+	Convey("TimerRunHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		scheduler := timer.NewScheduler(func() (skydb.Conn, error) {
+			return conn, nil
+		})
+		runs := 0
+		scheduler.AddTimer("cleanup", "0 0 * * * *", false, func() error {
+			runs++
+			return nil
+		})
+		scheduler.AddTimer("broken", "0 0 * * * *", false, func() error {
+			return errors.New("database unavailable")
+		})
+
+		r := handlertest.NewSingleRouteRouter(&TimerRunHandler{
+			TimerScheduler: scheduler,
+		}, func(p *router.Payload) {})
+
+		Convey("runs timer", func() {
+			resp := r.POST(`{"name": "cleanup"}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(runs, ShouldEqual, 1)
+
+			body := struct {
+				Result skydb.Timer `json:"result"`
+			}{}
+			So(json.Unmarshal(resp.Body.Bytes(), &body), ShouldBeNil)
+			So(body.Result.Name, ShouldEqual, "cleanup")
+			So(body.Result.LastStatus, ShouldEqual, skydb.TimerSucceeded)
+			So(body.Result.LockedUntil, ShouldBeNil)
+		})
+
+		Convey("returns failed run", func() {
+			resp := r.POST(`{"name": "broken"}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			body := struct {
+				Result skydb.Timer `json:"result"`
+			}{}
+			So(json.Unmarshal(resp.Body.Bytes(), &body), ShouldBeNil)
+			So(body.Result.LastStatus, ShouldEqual, skydb.TimerFailed)
+			So(body.Result.LastError, ShouldEqual, "database unavailable")
+		})
+
+		Convey("rejects running timer", func() {
+			r.POST(`{"name": "cleanup"}`)
+			lockedUntil := time.Now().UTC().Add(time.Minute)
+			locked := conn.TimerMap["cleanup"]
+			locked.LockedUntil = &lockedUntil
+			conn.TimerMap["cleanup"] = locked
+
+			resp := r.POST(`{"name": "cleanup"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "timer is running",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["name"]}
+				}
+			}`)
+			So(runs, ShouldEqual, 1)
+		})
+
+		Convey("returns not found for unknown timer", func() {
+			resp := r.POST(`{"name": "unknown"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 110,
+					"message": "timer not found",
+					"name": "ResourceNotFound"
+				}
+			}`)
+		})
+	})
+}
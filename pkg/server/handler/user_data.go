@@ -0,0 +1,340 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+
+	"github.com/mitchellh/mapstructure"
+
+	skyAsset "github.com/skygeario/skygear-server/pkg/server/asset"
+	"github.com/skygeario/skygear-server/pkg/server/audit"
+	"github.com/skygeario/skygear-server/pkg/server/authtoken"
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	"github.com/skygeario/skygear-server/pkg/server/userdata"
+)
+
+type meDeletePayload struct {
+	Password string `mapstructure:"password"`
+}
+
+func (payload *meDeletePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return nil
+}
+
+// MeDeleteHandler deletes the account of the current user and erases
+// the user data according to the erasure plan.
+//
+// MeDeleteHandler receives one parameter:
+//
+// * password (string): required if the user has a password
+//
+// Records owned by the user are deleted, anonymized or kept according
+// to USER_ERASURE_DEFAULT_ACTION and USER_ERASURE_RECORD_ACTIONS.
+// Private records, the user record, devices, subscriptions, roles,
+// relations and password history are always removed, as are the files
+// of assets in deleted records and anonymized fields. The access tokens
+// of the user are invalidated.
+//
+//  curl -X POST -H "Content-Type: application/json" \
+//    -d @- http://localhost:3000/ <<EOF
+//  {
+//      "action": "me:delete",
+//      "password": "123456"
+//  }
+//  EOF
+type MeDeleteHandler struct {
+	AssetStore      skyAsset.Store   `inject:"AssetStore"`
+	TokenStore      authtoken.Store  `inject:"TokenStore"`
+	UserErasurePlan *userdata.Plan   `inject:"UserErasurePlan"`
+	Authenticator   router.Processor `preprocessor:"authenticator"`
+	DBConn          router.Processor `preprocessor:"dbconn"`
+	InjectAuth      router.Processor `preprocessor:"require_auth"`
+	CheckUser       router.Processor `preprocessor:"check_user"`
+	PluginReady     router.Processor `preprocessor:"plugin_ready"`
+	preprocessors   []router.Processor
+}
+
+func (h *MeDeleteHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *MeDeleteHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *MeDeleteHandler) Handle(payload *router.Payload, response *router.Response) {
+	logger := logging.CreateLogger(payload.Context(), "handler")
+	p := &meDeletePayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	// Deleting an account is up to the user, not an impersonating admin.
+	if payload.ImpersonatorID != "" {
+		response.Err = skyerr.NewError(skyerr.PermissionDenied, "cannot delete account while impersonating")
+		return
+	}
+
+	info := payload.AuthInfo
+	if len(info.HashedPassword) > 0 && !info.IsSamePassword(p.Password) {
+		logger.Debug("Incorrect password when deleting account")
+		response.Err = skyerr.NewError(skyerr.InvalidCredentials, "Incorrect password")
+		return
+	}
+
+	if err := userdata.Erase(payload.DBConn, h.AssetStore, h.UserErasurePlan, info.ID); err != nil {
+		logger.WithError(err).Error("Unable to erase user data")
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	if err := authtoken.DeleteUserTokens(h.TokenStore, info.ID); err != nil {
+		logger.WithError(err).Error("Unable to delete access tokens of deleted user")
+	}
+	if err := h.TokenStore.Delete(payload.AccessTokenString()); err != nil {
+		if _, notfound := err.(*authtoken.NotFoundError); !notfound {
+			logger.WithError(err).Error("Unable to delete access token of deleted user")
+		}
+	}
+
+	logger.WithField("auth_id", info.ID).Info("Deleted user account")
+
+	audit.Trail(audit.Entry{
+		AuthID: info.ID,
+		Event:  audit.EventDeleteUser,
+	}.WithRouterPayload(payload))
+
+	response.Result = statusResponse{
+		Status: "OK",
+	}
+}
+
+type deleteUserPayload struct {
+	AuthInfoID string `mapstructure:"auth_id"`
+}
+
+func (payload *deleteUserPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *deleteUserPayload) Validate() skyerr.Error {
+	if payload.AuthInfoID == "" {
+		return skyerr.NewInvalidArgument("empty auth_id", []string{"auth_id"})
+	}
+	return nil
+}
+
+// DeleteUserHandler deletes the account of the specified user and erases
+// the user data according to the erasure plan, like MeDeleteHandler. The
+// access tokens of the user are invalidated.
+//
+// DeleteUserHandler receives one parameter:
+//
+// * auth_id (string, required)
+//
+//  curl -X POST -H "Content-Type: application/json" \
+//    -H "X-Skygear-Api-Key: MASTER_KEY" \
+//    -d @- http://localhost:3000/ <<EOF
+//  {
+//      "action": "auth:delete",
+//      "auth_id": "77FA8BCF-CD6C-4A22-A170-CECC2667654F"
+//  }
+//  EOF
+type DeleteUserHandler struct {
+	AssetStore       skyAsset.Store   `inject:"AssetStore"`
+	TokenStore       authtoken.Store  `inject:"TokenStore"`
+	UserErasurePlan  *userdata.Plan   `inject:"UserErasurePlan"`
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *DeleteUserHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *DeleteUserHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *DeleteUserHandler) Handle(payload *router.Payload, response *router.Response) {
+	logger := logging.CreateLogger(payload.Context(), "handler")
+	p := &deleteUserPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	logger = logger.WithField("auth_id", p.AuthInfoID)
+
+	info := skydb.AuthInfo{}
+	if err := payload.DBConn.GetAuth(p.AuthInfoID, &info); err != nil {
+		if err == skydb.ErrUserNotFound {
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "User not found")
+			return
+		}
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	if err := userdata.Erase(payload.DBConn, h.AssetStore, h.UserErasurePlan, info.ID); err != nil {
+		logger.WithError(err).Error("Unable to erase user data")
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	if err := authtoken.DeleteUserTokens(h.TokenStore, info.ID); err != nil {
+		logger.WithError(err).Error("Unable to delete access tokens of deleted user")
+	}
+
+	logger.Info("Deleted user account")
+
+	audit.Trail(audit.Entry{
+		AuthID: info.ID,
+		Event:  audit.EventDeleteUser,
+		Admin:  true,
+	}.WithRouterPayload(payload))
+
+	response.Result = statusResponse{
+		Status: "OK",
+	}
+}
+
+// MeExportHandler exports the data of the current user as a zip archive
+// of JSON files and returns the archive as an asset.
+//
+// The archive contains the auth info, the user record, records owned by
+// the user, private records, devices, groups, relations and blocks of
+// the user. The archive is deleted after userdata.ExportExpiry.
+//
+//  curl -X POST -H "Content-Type: application/json" \
+//    -d @- http://localhost:3000/ <<EOF
+//  {
+//      "action": "me:export"
+//  }
+//  EOF
+//
+// {
+//   "result": {
+//     "$type": "asset",
+//     "$name": "0d2d6ae0-3e29-4e7e-a6a9-2a4c6d8a4a71-export.zip",
+//     "$url": "http://localhost:3000/files/..."
+//   }
+// }
+type MeExportHandler struct {
+	AssetStore    skyAsset.Store   `inject:"AssetStore"`
+	Authenticator router.Processor `preprocessor:"authenticator"`
+	DBConn        router.Processor `preprocessor:"dbconn"`
+	InjectAuth    router.Processor `preprocessor:"require_auth"`
+	CheckUser     router.Processor `preprocessor:"check_user"`
+	PluginReady   router.Processor `preprocessor:"plugin_ready"`
+	preprocessors []router.Processor
+}
+
+func (h *MeExportHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.Authenticator,
+		h.DBConn,
+		h.InjectAuth,
+		h.CheckUser,
+		h.PluginReady,
+	}
+}
+
+func (h *MeExportHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *MeExportHandler) Handle(payload *router.Payload, response *router.Response) {
+	logger := logging.CreateLogger(payload.Context(), "handler")
+	info := payload.AuthInfo
+
+	buf := bytes.Buffer{}
+	if err := userdata.Export(payload.DBConn, info.ID, &buf); err != nil {
+		logger.WithError(err).Error("Unable to export user data")
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	asset := skydb.Asset{
+		Name:        uuidNew() + "-export.zip",
+		ContentType: "application/zip",
+		Size:        int64(buf.Len()),
+	}
+	if err := h.AssetStore.PutFileReader(asset.Name, &buf, asset.Size, asset.ContentType); err != nil {
+		logger.WithError(err).Error("Unable to save user data export")
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	if err := payload.DBConn.SaveAsset(&asset); err != nil {
+		response.Err = skyerr.NewResourceSaveFailureErrWithStringID("asset", asset.Name)
+		return
+	}
+
+	now := timeNow()
+	export := skydb.UserDataExport{
+		AssetName: asset.Name,
+		UserID:    info.ID,
+		CreatedAt: now,
+		ExpiredAt: now.Add(userdata.ExportExpiry),
+	}
+	if err := payload.DBConn.CreateUserDataExport(&export); err != nil {
+		logger.WithError(err).Error("Unable to save user data export")
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	if signer, ok := h.AssetStore.(skyAsset.URLSigner); ok {
+		asset.Signer = signer
+	} else {
+		logger.Warnf("Failed to acquire asset URLSigner, please check configuration")
+		response.Err = skyerr.NewError(skyerr.UnexpectedError, "Failed to sign the url")
+		return
+	}
+
+	audit.Trail(audit.Entry{
+		AuthID: info.ID,
+		Event:  audit.EventExportUserData,
+	}.WithRouterPayload(payload))
+
+	response.Result = skyconv.ToMap((*skyconv.MapAsset)(&asset))
+}
@@ -0,0 +1,207 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	"github.com/skygeario/skygear-server/pkg/server/userdata"
+)
+
+// emptyQueryDB is a MapDB returning no records for queries.
+type emptyQueryDB struct {
+	*skydbtest.MapDB
+}
+
+func (db *emptyQueryDB) Query(query *skydb.Query, accessControlOptions *skydb.AccessControlOptions) (*skydb.Rows, error) {
+	return skydb.NewRows(skydb.NewMemoryRows([]skydb.Record{})), nil
+}
+
+type userDataConn struct {
+	*skydbtest.MapConn
+	savedAsset *skydb.Asset
+}
+
+func newUserDataConn() *userDataConn {
+	conn := &userDataConn{MapConn: skydbtest.NewMapConn()}
+	db := &emptyQueryDB{skydbtest.NewMapDB()}
+	db.RecordSchemaMap["note"] = skydb.RecordSchema{}
+	conn.InternalPublicDB = db
+	return conn
+}
+
+func (conn *userDataConn) PrivateDB(userKey string) skydb.Database {
+	return &emptyQueryDB{skydbtest.NewMapDB()}
+}
+
+func (conn *userDataConn) QueryDevicesByUser(user string) ([]skydb.Device, error) {
+	return []skydb.Device{}, nil
+}
+
+func (conn *userDataConn) QueryRelation(user string, name string, direction string, config skydb.QueryConfig) []skydb.AuthInfo {
+	return []skydb.AuthInfo{}
+}
+
+func (conn *userDataConn) SaveAsset(asset *skydb.Asset) error {
+	conn.savedAsset = asset
+	return nil
+}
+
+func addUserDataUser(conn *userDataConn, id string, password string) *skydb.AuthInfo {
+	info := skydb.NewAuthInfo(password)
+	info.ID = id
+	conn.UserMap[id] = info
+	conn.InternalPublicDB.Save(&skydb.Record{
+		ID:      skydb.NewRecordID("user", id),
+		OwnerID: id,
+	})
+	return &info
+}
+
+func TestMeDeleteHandler(t *testing.T) {
+	Convey("MeDeleteHandler", t, func() {
+		conn := newUserDataConn()
+		info := addUserDataUser(conn, "alice", "secret")
+		plan, _ := userdata.NewPlan("delete", nil, nil)
+		tokenStore := &deleteTokenStore{}
+		impersonatorID := ""
+
+		r := handlertest.NewSingleRouteRouter(&MeDeleteHandler{
+			TokenStore:      tokenStore,
+			UserErasurePlan: plan,
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = info
+			p.AuthInfoID = info.ID
+			p.ImpersonatorID = impersonatorID
+		})
+
+		Convey("deletes the user and access token", func() {
+			resp := r.POST(`{"password": "secret", "access_token": "someaccesstoken"}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{"result":{"status":"OK"}}`)
+			So(conn.UserMap, ShouldNotContainKey, "alice")
+			So(conn.InternalPublicDB.(*emptyQueryDB).RecordMap, ShouldNotContainKey, "user/alice")
+			So(tokenStore.deletedAccessToken, ShouldEqual, "someaccesstoken")
+			So(tokenStore.deletedAuthInfoID, ShouldEqual, "alice")
+		})
+
+		Convey("rejects incorrect password", func() {
+			resp := r.POST(`{"password": "wrong"}`)
+			So(resp.Code, ShouldEqual, 401)
+			So(conn.UserMap, ShouldContainKey, "alice")
+		})
+
+		Convey("rejects deletion while impersonating", func() {
+			impersonatorID = "admin"
+			resp := r.POST(`{"password": "secret"}`)
+			So(resp.Code, ShouldEqual, 403)
+			So(conn.UserMap, ShouldContainKey, "alice")
+		})
+	})
+}
+
+func TestDeleteUserHandler(t *testing.T) {
+	Convey("DeleteUserHandler", t, func() {
+		conn := newUserDataConn()
+		addUserDataUser(conn, "alice", "secret")
+		plan, _ := userdata.NewPlan("delete", nil, nil)
+		tokenStore := &deleteTokenStore{}
+
+		r := handlertest.NewSingleRouteRouter(&DeleteUserHandler{
+			TokenStore:      tokenStore,
+			UserErasurePlan: plan,
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("deletes the user", func() {
+			resp := r.POST(`{"auth_id": "alice"}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{"result":{"status":"OK"}}`)
+			So(conn.UserMap, ShouldNotContainKey, "alice")
+			So(tokenStore.deletedAuthInfoID, ShouldEqual, "alice")
+		})
+
+		Convey("rejects empty auth_id", func() {
+			resp := r.POST(`{}`)
+			So(resp.Code, ShouldEqual, 400)
+		})
+
+		Convey("rejects non-existent user", func() {
+			resp := r.POST(`{"auth_id": "nobody"}`)
+			So(resp.Code, ShouldEqual, 404)
+		})
+	})
+}
+
+func TestMeExportHandler(t *testing.T) {
+	realUUIDNew := uuidNew
+	uuidNew = func() string { return "export-id" }
+	defer func() {
+		uuidNew = realUUIDNew
+	}()
+
+	Convey("MeExportHandler", t, func() {
+		conn := newUserDataConn()
+		info := addUserDataUser(conn, "alice", "secret")
+		assetStore := newBufferedStore()
+
+		r := handlertest.NewSingleRouteRouter(&MeExportHandler{
+			AssetStore: assetStore,
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+			p.AuthInfo = info
+			p.AuthInfoID = info.ID
+		})
+
+		Convey("uploads an archive of user data", func() {
+			resp := r.POST(`{}`)
+			So(resp.Code, ShouldEqual, 200)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": {
+					"$type": "asset",
+					"$name": "export-id-export.zip",
+					"$content_type": "application/zip",
+					"$url": "export-id-export.zip?signedurl=true"
+				}
+			}`)
+			So(assetStore.contentType, ShouldEqual, "application/zip")
+			So(conn.savedAsset.Name, ShouldEqual, "export-id-export.zip")
+			export := conn.UserDataExportMap["export-id-export.zip"]
+			So(export.UserID, ShouldEqual, "alice")
+			So(export.ExpiredAt, ShouldResemble, export.CreatedAt.Add(userdata.ExportExpiry))
+
+			data := assetStore.buf.Bytes()
+			zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			So(err, ShouldBeNil)
+			names := []string{}
+			for _, f := range zr.File {
+				names = append(names, f.Name)
+			}
+			So(names, ShouldContain, "auth.json")
+			So(names, ShouldContain, "user.json")
+		})
+	})
+}
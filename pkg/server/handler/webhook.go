@@ -0,0 +1,531 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"net/url"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	"github.com/skygeario/skygear-server/pkg/server/webhook"
+)
+
+const defaultWebhookDeliveriesLimit = 50
+
+type webhookResponse struct {
+	*skydb.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+func validateWebhookURL(rawURL string) skyerr.Error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return skyerr.NewInvalidArgument("url must be an absolute http or https URL", []string{"url"})
+	}
+	return nil
+}
+
+func validateWebhookEvents(events []string) skyerr.Error {
+	if len(events) == 0 {
+		return skyerr.NewInvalidArgument("empty events", []string{"events"})
+	}
+	for _, event := range events {
+		if !webhook.IsEvent(event) {
+			return skyerr.NewInvalidArgument("unknown event "+event, []string{"events"})
+		}
+	}
+	return nil
+}
+
+type webhookCreatePayload struct {
+	URL        string   `mapstructure:"url"`
+	Secret     string   `mapstructure:"secret"`
+	RecordType string   `mapstructure:"record_type"`
+	Events     []string `mapstructure:"events"`
+}
+
+func (payload *webhookCreatePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *webhookCreatePayload) Validate() skyerr.Error {
+	if err := validateWebhookURL(payload.URL); err != nil {
+		return err
+	}
+	return validateWebhookEvents(payload.Events)
+}
+
+/*
+WebhookCreateHandler registers a URL to receive events of records and
+users. Events are names of after hooks: afterSave and afterDelete of
+records, optionally limited to record_type, and afterSignup, afterLogin,
+afterLogout, afterPasswordChange, afterProviderLink, afterProviderUnlink
+and afterUserDisable of users, or "*" for all events.
+
+Deliveries are signed with the secret, which is generated if not
+specified and returned only once in the response.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "webhook:create",
+		"url": "https://example.com/hook",
+		"record_type": "note",
+		"events": ["afterSave", "afterDelete"]
+	}
+	EOF
+*/
+type WebhookCreateHandler struct {
+	WebhookDispatcher *webhook.Dispatcher `inject:"WebhookDispatcher"`
+	AccessKey         router.Processor    `preprocessor:"accesskey"`
+	DBConn            router.Processor    `preprocessor:"dbconn"`
+	PluginReady       router.Processor    `preprocessor:"plugin_ready"`
+	RequireMasterKey  router.Processor    `preprocessor:"require_master_key"`
+	preprocessors     []router.Processor
+}
+
+func (h *WebhookCreateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *WebhookCreateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *WebhookCreateHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &webhookCreatePayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	secret := p.Secret
+	if secret == "" {
+		secret = webhook.GenerateSecret()
+	}
+	w := skydb.Webhook{
+		ID:         uuidNew(),
+		URL:        p.URL,
+		Secret:     secret,
+		RecordType: p.RecordType,
+		Events:     p.Events,
+		CreatedAt:  timeNow(),
+	}
+	if err := payload.DBConn.CreateWebhook(&w); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	h.WebhookDispatcher.Invalidate()
+
+	response.Result = webhookResponse{
+		Webhook: &w,
+		Secret:  secret,
+	}
+}
+
+type webhookUpdatePayload struct {
+	ID         string   `mapstructure:"id"`
+	URL        string   `mapstructure:"url"`
+	RecordType *string  `mapstructure:"record_type"`
+	Events     []string `mapstructure:"events"`
+	Disabled   *bool    `mapstructure:"disabled"`
+}
+
+func (payload *webhookUpdatePayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *webhookUpdatePayload) Validate() skyerr.Error {
+	if payload.ID == "" {
+		return skyerr.NewInvalidArgument("empty id", []string{"id"})
+	}
+	if payload.URL != "" {
+		if err := validateWebhookURL(payload.URL); err != nil {
+			return err
+		}
+	}
+	if payload.Events != nil {
+		if err := validateWebhookEvents(payload.Events); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+WebhookUpdateHandler updates the URL, record type, events or disabled
+state of a webhook. Fields not specified are unchanged. Pending deliveries
+of a disabled webhook are marked as failed when attempted.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "webhook:update",
+		"id": "WEBHOOK_ID",
+		"disabled": true
+	}
+	EOF
+*/
+type WebhookUpdateHandler struct {
+	WebhookDispatcher *webhook.Dispatcher `inject:"WebhookDispatcher"`
+	AccessKey         router.Processor    `preprocessor:"accesskey"`
+	DBConn            router.Processor    `preprocessor:"dbconn"`
+	PluginReady       router.Processor    `preprocessor:"plugin_ready"`
+	RequireMasterKey  router.Processor    `preprocessor:"require_master_key"`
+	preprocessors     []router.Processor
+}
+
+func (h *WebhookUpdateHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *WebhookUpdateHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *WebhookUpdateHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &webhookUpdatePayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	w := skydb.Webhook{}
+	if err := getWebhook(payload.DBConn, p.ID, &w); err != nil {
+		response.Err = err
+		return
+	}
+
+	if p.URL != "" {
+		w.URL = p.URL
+	}
+	if p.RecordType != nil {
+		w.RecordType = *p.RecordType
+	}
+	if p.Events != nil {
+		w.Events = p.Events
+	}
+	if p.Disabled != nil {
+		w.Disabled = *p.Disabled
+	}
+	if err := payload.DBConn.UpdateWebhook(&w); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+	h.WebhookDispatcher.Invalidate()
+
+	response.Result = webhookResponse{
+		Webhook: &w,
+	}
+}
+
+type webhookIDPayload struct {
+	ID string `mapstructure:"id"`
+}
+
+func (payload *webhookIDPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	return payload.Validate()
+}
+
+func (payload *webhookIDPayload) Validate() skyerr.Error {
+	if payload.ID == "" {
+		return skyerr.NewInvalidArgument("empty id", []string{"id"})
+	}
+	return nil
+}
+
+/*
+WebhookDeleteHandler deletes a webhook together with its deliveries.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "webhook:delete",
+		"id": "WEBHOOK_ID"
+	}
+	EOF
+*/
+type WebhookDeleteHandler struct {
+	WebhookDispatcher *webhook.Dispatcher `inject:"WebhookDispatcher"`
+	AccessKey         router.Processor    `preprocessor:"accesskey"`
+	DBConn            router.Processor    `preprocessor:"dbconn"`
+	PluginReady       router.Processor    `preprocessor:"plugin_ready"`
+	RequireMasterKey  router.Processor    `preprocessor:"require_master_key"`
+	preprocessors     []router.Processor
+}
+
+func (h *WebhookDeleteHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *WebhookDeleteHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *WebhookDeleteHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &webhookIDPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	if err := payload.DBConn.DeleteWebhook(p.ID); err != nil {
+		if err == skydb.ErrWebhookNotFound {
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "webhook not found")
+		} else {
+			response.Err = skyerr.MakeError(err)
+		}
+		return
+	}
+	h.WebhookDispatcher.Invalidate()
+
+	response.Result = struct {
+		ID string `json:"id"`
+	}{p.ID}
+}
+
+/*
+WebhookListHandler returns all webhooks. The secrets are not returned.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "webhook:list"
+	}
+	EOF
+*/
+type WebhookListHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *WebhookListHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *WebhookListHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *WebhookListHandler) Handle(payload *router.Payload, response *router.Response) {
+	webhooks, err := payload.DBConn.QueryWebhooks()
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = webhooks
+}
+
+type webhookDeliveriesPayload struct {
+	WebhookID string `mapstructure:"webhook_id"`
+	Status    string `mapstructure:"status"`
+	Limit     uint64 `mapstructure:"limit"`
+}
+
+func (payload *webhookDeliveriesPayload) Decode(data map[string]interface{}) skyerr.Error {
+	if err := mapstructure.Decode(data, payload); err != nil {
+		return skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")
+	}
+	if payload.Limit == 0 {
+		payload.Limit = defaultWebhookDeliveriesLimit
+	}
+	return payload.Validate()
+}
+
+func (payload *webhookDeliveriesPayload) Validate() skyerr.Error {
+	if payload.WebhookID == "" {
+		return skyerr.NewInvalidArgument("empty webhook_id", []string{"webhook_id"})
+	}
+	switch skydb.WebhookDeliveryStatus(payload.Status) {
+	case "", skydb.WebhookDeliveryPending, skydb.WebhookDeliveryDelivered, skydb.WebhookDeliveryFailed:
+	default:
+		return skyerr.NewInvalidArgument("unknown status "+payload.Status, []string{"status"})
+	}
+	return nil
+}
+
+/*
+WebhookDeliveriesHandler returns the latest deliveries of a webhook,
+optionally of the specified status: pending, delivered or failed.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "webhook:deliveries",
+		"webhook_id": "WEBHOOK_ID",
+		"status": "failed",
+		"limit": 50
+	}
+	EOF
+*/
+type WebhookDeliveriesHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *WebhookDeliveriesHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *WebhookDeliveriesHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *WebhookDeliveriesHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &webhookDeliveriesPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	w := skydb.Webhook{}
+	if err := getWebhook(payload.DBConn, p.WebhookID, &w); err != nil {
+		response.Err = err
+		return
+	}
+
+	deliveries, err := payload.DBConn.QueryWebhookDeliveries(p.WebhookID, skydb.WebhookDeliveryStatus(p.Status), p.Limit)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = deliveries
+}
+
+/*
+WebhookReplayHandler attempts a delivery again as soon as possible,
+regardless of its status. The payload, including its ID, is unchanged
+so that receivers can detect duplicated deliveries.
+
+	curl -X POST -H "Content-Type: application/json" \
+	  -H "X-Skygear-Api-Key: MASTER_KEY" \
+	  -d @- http://localhost:3000/ <<EOF
+	{
+		"action": "webhook:replay",
+		"id": "DELIVERY_ID"
+	}
+	EOF
+*/
+type WebhookReplayHandler struct {
+	AccessKey        router.Processor `preprocessor:"accesskey"`
+	DBConn           router.Processor `preprocessor:"dbconn"`
+	PluginReady      router.Processor `preprocessor:"plugin_ready"`
+	RequireMasterKey router.Processor `preprocessor:"require_master_key"`
+	preprocessors    []router.Processor
+}
+
+func (h *WebhookReplayHandler) Setup() {
+	h.preprocessors = []router.Processor{
+		h.AccessKey,
+		h.DBConn,
+		h.PluginReady,
+		h.RequireMasterKey,
+	}
+}
+
+func (h *WebhookReplayHandler) GetPreprocessors() []router.Processor {
+	return h.preprocessors
+}
+
+func (h *WebhookReplayHandler) Handle(payload *router.Payload, response *router.Response) {
+	p := &webhookIDPayload{}
+	skyErr := p.Decode(payload.Data)
+	if skyErr != nil {
+		response.Err = skyErr
+		return
+	}
+
+	delivery := skydb.WebhookDelivery{}
+	if err := payload.DBConn.GetWebhookDelivery(p.ID, &delivery); err != nil {
+		if err == skydb.ErrWebhookDeliveryNotFound {
+			response.Err = skyerr.NewError(skyerr.ResourceNotFound, "webhook delivery not found")
+		} else {
+			response.Err = skyerr.MakeError(err)
+		}
+		return
+	}
+
+	webhook.Replay(&delivery, timeNow())
+	if err := payload.DBConn.UpdateWebhookDelivery(&delivery); err != nil {
+		response.Err = skyerr.MakeError(err)
+		return
+	}
+
+	response.Result = delivery
+}
+
+func getWebhook(conn skydb.Conn, id string, w *skydb.Webhook) skyerr.Error {
+	if err := conn.GetWebhook(id, w); err != nil {
+		if err == skydb.ErrWebhookNotFound {
+			return skyerr.NewError(skyerr.ResourceNotFound, "webhook not found")
+		}
+		return skyerr.MakeError(err)
+	}
+	return nil
+}
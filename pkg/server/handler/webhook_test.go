@@ -0,0 +1,260 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/handler/handlertest"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	"github.com/skygeario/skygear-server/pkg/server/webhook"
+)
+
+func TestWebhookCreateHandler(t *testing.T) {
+	Convey("WebhookCreateHandler", t, func() {
+		realUUIDNew := uuidNew
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		uuidNew = func() string { return "webhook-id" }
+		timeNow = func() time.Time { return now }
+		defer func() {
+			uuidNew = realUUIDNew
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		r := handlertest.NewSingleRouteRouter(&WebhookCreateHandler{
+			WebhookDispatcher: webhook.NewDispatcher(nil, time.Minute),
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("creates webhook and returns secret once", func() {
+			resp := r.POST(`{
+				"url": "https://example.com/hook",
+				"record_type": "note",
+				"events": ["afterSave", "afterDelete"]
+			}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			body := struct {
+				Result map[string]interface{} `json:"result"`
+			}{}
+			So(json.Unmarshal(resp.Body.Bytes(), &body), ShouldBeNil)
+			secret, _ := body.Result["secret"].(string)
+			So(secret, ShouldNotBeEmpty)
+			So(body.Result["id"], ShouldEqual, "webhook-id")
+
+			w := skydb.Webhook{}
+			So(conn.GetWebhook("webhook-id", &w), ShouldBeNil)
+			So(w.URL, ShouldEqual, "https://example.com/hook")
+			So(w.Secret, ShouldEqual, secret)
+			So(w.RecordType, ShouldEqual, "note")
+			So(w.Events, ShouldResemble, []string{"afterSave", "afterDelete"})
+			So(w.CreatedAt, ShouldResemble, now)
+		})
+
+		Convey("rejects relative url", func() {
+			resp := r.POST(`{"url": "/hook", "events": ["*"]}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "url must be an absolute http or https URL",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["url"]}
+				}
+			}`)
+		})
+
+		Convey("rejects unknown event", func() {
+			resp := r.POST(`{"url": "https://example.com/hook", "events": ["beforeSave"]}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 108,
+					"message": "unknown event beforeSave",
+					"name": "InvalidArgument",
+					"info": {"arguments": ["events"]}
+				}
+			}`)
+		})
+	})
+}
+
+func TestWebhookUpdateHandler(t *testing.T) {
+	Convey("WebhookUpdateHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.CreateWebhook(&skydb.Webhook{
+			ID:         "webhook-id",
+			URL:        "https://example.com/hook",
+			Secret:     "secret",
+			RecordType: "note",
+			Events:     []string{"afterSave"},
+		})
+
+		r := handlertest.NewSingleRouteRouter(&WebhookUpdateHandler{
+			WebhookDispatcher: webhook.NewDispatcher(nil, time.Minute),
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		Convey("updates specified fields", func() {
+			resp := r.POST(`{"id": "webhook-id", "record_type": "", "disabled": true}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			w := skydb.Webhook{}
+			So(conn.GetWebhook("webhook-id", &w), ShouldBeNil)
+			So(w.URL, ShouldEqual, "https://example.com/hook")
+			So(w.RecordType, ShouldEqual, "")
+			So(w.Events, ShouldResemble, []string{"afterSave"})
+			So(w.Disabled, ShouldBeTrue)
+		})
+
+		Convey("returns not found for unknown webhook", func() {
+			resp := r.POST(`{"id": "unknown", "disabled": true}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 110,
+					"message": "webhook not found",
+					"name": "ResourceNotFound"
+				}
+			}`)
+		})
+	})
+}
+
+func TestWebhookDeleteHandler(t *testing.T) {
+	Convey("WebhookDeleteHandler", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.CreateWebhook(&skydb.Webhook{ID: "webhook-id"})
+		conn.CreateWebhookDelivery(&skydb.WebhookDelivery{ID: "delivery-id", WebhookID: "webhook-id"})
+
+		r := handlertest.NewSingleRouteRouter(&WebhookDeleteHandler{
+			WebhookDispatcher: webhook.NewDispatcher(nil, time.Minute),
+		}, func(p *router.Payload) {
+			p.DBConn = conn
+		})
+
+		resp := r.POST(`{"id": "webhook-id"}`)
+		So(resp.Body.Bytes(), ShouldEqualJSON, `{"result": {"id": "webhook-id"}}`)
+		So(conn.WebhookMap, ShouldBeEmpty)
+		So(conn.WebhookDeliveryMap, ShouldBeEmpty)
+	})
+}
+
+func TestWebhookDeliveriesHandler(t *testing.T) {
+	Convey("WebhookDeliveriesHandler and WebhookReplayHandler", t, func() {
+		realTimeNow := timeNow
+		now := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		conn.CreateWebhook(&skydb.Webhook{ID: "webhook-id"})
+		createdAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		lastAttemptAt := createdAt.Add(time.Hour)
+		conn.CreateWebhookDelivery(&skydb.WebhookDelivery{
+			ID:             "failed",
+			WebhookID:      "webhook-id",
+			Event:          "afterSave",
+			Payload:        json.RawMessage(`{"id":"failed"}`),
+			Status:         skydb.WebhookDeliveryFailed,
+			Attempts:       10,
+			NextAttemptAt:  lastAttemptAt,
+			LastAttemptAt:  &lastAttemptAt,
+			LastError:      "webhook: unexpected status code 503",
+			ResponseStatus: 503,
+			CreatedAt:      createdAt,
+		})
+		conn.CreateWebhookDelivery(&skydb.WebhookDelivery{
+			ID:            "delivered",
+			WebhookID:     "webhook-id",
+			Event:         "afterSave",
+			Payload:       json.RawMessage(`{"id":"delivered"}`),
+			Status:        skydb.WebhookDeliveryDelivered,
+			Attempts:      1,
+			NextAttemptAt: createdAt,
+			CreatedAt:     createdAt.Add(time.Minute),
+		})
+
+		setConn := func(p *router.Payload) {
+			p.DBConn = conn
+		}
+
+		Convey("lists failed deliveries", func() {
+			r := handlertest.NewSingleRouteRouter(&WebhookDeliveriesHandler{}, setConn)
+			resp := r.POST(`{"webhook_id": "webhook-id", "status": "failed"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"result": [{
+					"id": "failed",
+					"webhook_id": "webhook-id",
+					"event": "afterSave",
+					"payload": {"id": "failed"},
+					"status": "failed",
+					"attempts": 10,
+					"next_attempt_at": "2017-01-01T01:00:00Z",
+					"last_attempt_at": "2017-01-01T01:00:00Z",
+					"last_error": "webhook: unexpected status code 503",
+					"response_status": 503,
+					"created_at": "2017-01-01T00:00:00Z"
+				}]
+			}`)
+		})
+
+		Convey("lists latest deliveries first", func() {
+			r := handlertest.NewSingleRouteRouter(&WebhookDeliveriesHandler{}, setConn)
+			resp := r.POST(`{"webhook_id": "webhook-id", "limit": 1}`)
+			body := struct {
+				Result []skydb.WebhookDelivery `json:"result"`
+			}{}
+			So(json.Unmarshal(resp.Body.Bytes(), &body), ShouldBeNil)
+			So(body.Result, ShouldHaveLength, 1)
+			So(body.Result[0].ID, ShouldEqual, "delivered")
+		})
+
+		Convey("replays failed delivery", func() {
+			r := handlertest.NewSingleRouteRouter(&WebhookReplayHandler{}, setConn)
+			resp := r.POST(`{"id": "failed"}`)
+			So(resp.Code, ShouldEqual, 200)
+
+			delivery := skydb.WebhookDelivery{}
+			So(conn.GetWebhookDelivery("failed", &delivery), ShouldBeNil)
+			So(delivery.Status, ShouldEqual, skydb.WebhookDeliveryPending)
+			So(delivery.Attempts, ShouldEqual, 0)
+			So(delivery.NextAttemptAt, ShouldResemble, now)
+			So(delivery.LastError, ShouldEqual, "")
+		})
+
+		Convey("returns not found for unknown delivery", func() {
+			r := handlertest.NewSingleRouteRouter(&WebhookReplayHandler{}, setConn)
+			resp := r.POST(`{"id": "unknown"}`)
+			So(resp.Body.Bytes(), ShouldEqualJSON, `{
+				"error": {
+					"code": 110,
+					"message": "webhook delivery not found",
+					"name": "ResourceNotFound"
+				}
+			}`)
+		})
+	})
+}
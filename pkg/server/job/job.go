@@ -0,0 +1,212 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package job runs plugin lambdas in the background.
+//
+// Jobs are persisted in the database and claimed by the workers of all
+// server instances, so that a job is run once even when there are many
+// instances. A failed job is retried with exponential backoff until it is
+// dead, after which it is kept in the database for inspection.
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/poller"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var log = logging.LoggerEntry("job")
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+// DefaultMaxAttempts is the number of attempts of a job if not specified
+// when the job is enqueued.
+const DefaultMaxAttempts = 5
+
+// Retry resets a job to be run again at now, regardless of its status.
+func Retry(job *skydb.Job, now time.Time) {
+	job.Status = skydb.JobPending
+	job.Attempts = 0
+	job.RunAt = now
+	job.LockedUntil = nil
+	job.LastError = ""
+	job.Result = nil
+	job.CompletedAt = nil
+}
+
+// Worker claims due jobs from the database and runs their lambdas.
+type Worker struct {
+	ConnOpener func() (skydb.Conn, error)
+
+	// RunLambda runs the lambda with the arguments in JSON and returns
+	// the result in JSON.
+	RunLambda func(ctx context.Context, name string, in []byte) ([]byte, error)
+
+	// Ready reports whether the lambdas can be run. Jobs are not claimed
+	// until it returns true, so that they are not failed while plugins
+	// are being initialized. Jobs are claimed anytime if it is nil.
+	Ready func() bool
+
+	// Timeout is the maximum duration of a run of a job.
+	Timeout time.Duration
+
+	// Lease is how long a claimed job is locked from other server
+	// instances. A job still running after Lease, such as one whose
+	// server instance crashed, is claimed and run again. It must be
+	// longer than Timeout.
+	Lease time.Duration
+
+	// RetryInterval is the interval before the first retry, which is
+	// doubled for every further retry up to MaxRetryInterval.
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+
+	// BatchSize is the maximum number of jobs run in a poll.
+	BatchSize uint64
+
+	poller poller.Poller
+}
+
+// NewWorker creates a Worker with default settings.
+func NewWorker(connOpener func() (skydb.Conn, error), runLambda func(ctx context.Context, name string, in []byte) ([]byte, error)) *Worker {
+	return &Worker{
+		ConnOpener:       connOpener,
+		RunLambda:        runLambda,
+		Timeout:          5 * time.Minute,
+		Lease:            10 * time.Minute,
+		RetryInterval:    30 * time.Second,
+		MaxRetryInterval: 6 * time.Hour,
+		BatchSize:        10,
+	}
+}
+
+// Start polls for due jobs at the interval until Stop is called.
+func (w *Worker) Start(interval time.Duration) {
+	w.poller.Start("job", interval, func() error {
+		_, err := w.RunDue()
+		return err
+	})
+}
+
+// Stop stops polling for due jobs. Jobs being run are not interrupted.
+func (w *Worker) Stop() {
+	w.poller.Stop()
+}
+
+// RunDue claims and runs the jobs that are due, at most BatchSize of
+// them, returning the number of jobs run.
+//
+// Each job is claimed for Lease right before it is run. The outcome of a
+// run that outlives the lease is dropped, since the job may have been
+// claimed again by another server instance.
+func (w *Worker) RunDue() (int, error) {
+	if w.Ready != nil && !w.Ready() {
+		return 0, nil
+	}
+
+	conn, err := w.ConnOpener()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	return poller.Drain(w.BatchSize, func() (bool, error) {
+		now := timeNow()
+		job := skydb.Job{}
+		if err := conn.ClaimJob(now, now.Add(w.Lease), &job); err == skydb.ErrJobNotFound {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		lockedUntil := *job.LockedUntil
+
+		w.run(&job)
+		err := conn.UpdateClaimedJob(&job, lockedUntil)
+		switch err {
+		case nil, skydb.ErrJobNotFound:
+		case skydb.ErrLeaseLost:
+			log.WithField("job", job.ID).Warnln("Dropped the run of job after its lease expired")
+		default:
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// run runs the lambda of a claimed job and updates the job with the
+// outcome. The attempts of the job are incremented when it is claimed.
+func (w *Worker) run(job *skydb.Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.Timeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, router.AccessKeyTypeContextKey, router.MasterAccessKey)
+	if job.UserID != "" {
+		ctx = context.WithValue(ctx, router.UserIDContextKey, job.UserID)
+	}
+
+	result, err := w.runLambda(ctx, job)
+	now := timeNow()
+	job.LockedUntil = nil
+
+	if err == nil {
+		job.Status = skydb.JobSucceeded
+		job.LastError = ""
+		job.Result = result
+		job.CompletedAt = &now
+		return
+	}
+
+	log.WithField("job", job.ID).WithError(err).Warnln("Failed to run job")
+	job.LastError = err.Error()
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = skydb.JobDead
+		job.CompletedAt = &now
+		return
+	}
+	job.Status = skydb.JobPending
+	job.RunAt = now.Add(w.retryInterval(job.Attempts))
+}
+
+func (w *Worker) runLambda(ctx context.Context, job *skydb.Job) (result json.RawMessage, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job: lambda %s panicked: %v", job.Lambda, r)
+		}
+	}()
+
+	args := job.Args
+	if len(args) == 0 {
+		args = json.RawMessage("null")
+	}
+	out, err := w.RunLambda(ctx, job.Lambda, args)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, errors.New("job: lambda timed out")
+	}
+	return out, nil
+}
+
+// retryInterval returns the interval before the next run after the
+// number of attempts made.
+func (w *Worker) retryInterval(attempts int) time.Duration {
+	return poller.Backoff(w.RetryInterval, w.MaxRetryInterval, attempts)
+}
@@ -0,0 +1,235 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func TestWorker(t *testing.T) {
+	Convey("Worker", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		var (
+			ran     []string
+			lastCtx context.Context
+			lastIn  []byte
+			out     = []byte(`{"ok":true}`)
+			runErr  error
+		)
+		worker := NewWorker(func() (skydb.Conn, error) {
+			return conn, nil
+		}, func(ctx context.Context, name string, in []byte) ([]byte, error) {
+			ran = append(ran, name)
+			lastCtx = ctx
+			lastIn = in
+			return out, runErr
+		})
+
+		Convey("run due job on behalf of user", func() {
+			job := newJob(json.RawMessage(`{"to":"someone"}`), "user-id", now, 3)
+			So(conn.CreateJob(&job), ShouldBeNil)
+
+			n, err := worker.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(ran, ShouldResemble, []string{"send_email"})
+			So(string(lastIn), ShouldEqual, `{"to":"someone"}`)
+			So(lastCtx.Value(router.UserIDContextKey), ShouldEqual, "user-id")
+			So(lastCtx.Value(router.AccessKeyTypeContextKey), ShouldEqual, router.MasterAccessKey)
+
+			saved := conn.JobMap["job-id"]
+			So(saved.Status, ShouldEqual, skydb.JobSucceeded)
+			So(saved.Attempts, ShouldEqual, 1)
+			So(string(saved.Result), ShouldEqual, `{"ok":true}`)
+			So(saved.LockedUntil, ShouldBeNil)
+			So(*saved.CompletedAt, ShouldResemble, now)
+		})
+
+		Convey("not run job before it is due", func() {
+			job := newJob(nil, "", now.Add(time.Hour), 3)
+			So(conn.CreateJob(&job), ShouldBeNil)
+
+			n, err := worker.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(ran, ShouldBeEmpty)
+		})
+
+		Convey("retry failed job with backoff until dead", func() {
+			runErr = errors.New("smtp unavailable")
+			job := newJob(nil, "", now, 2)
+			So(conn.CreateJob(&job), ShouldBeNil)
+
+			_, err := worker.RunDue()
+			So(err, ShouldBeNil)
+			saved := conn.JobMap["job-id"]
+			So(saved.Status, ShouldEqual, skydb.JobPending)
+			So(saved.Attempts, ShouldEqual, 1)
+			So(saved.LastError, ShouldEqual, "smtp unavailable")
+			So(saved.RunAt, ShouldResemble, now.Add(30*time.Second))
+			So(string(lastIn), ShouldEqual, "null")
+
+			now = now.Add(time.Minute)
+			_, err = worker.RunDue()
+			So(err, ShouldBeNil)
+			saved = conn.JobMap["job-id"]
+			So(saved.Status, ShouldEqual, skydb.JobDead)
+			So(saved.Attempts, ShouldEqual, 2)
+			So(*saved.CompletedAt, ShouldResemble, now)
+
+			Convey("and retry dead job", func() {
+				Retry(&saved, now)
+				So(conn.UpdateJob(&saved), ShouldBeNil)
+				runErr = nil
+
+				n, err := worker.RunDue()
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 1)
+				So(conn.JobMap["job-id"].Status, ShouldEqual, skydb.JobSucceeded)
+			})
+		})
+
+		Convey("not run job until ready", func() {
+			ready := false
+			worker.Ready = func() bool { return ready }
+			job := newJob(nil, "", now, 3)
+			So(conn.CreateJob(&job), ShouldBeNil)
+
+			n, err := worker.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(conn.JobMap["job-id"].Attempts, ShouldEqual, 0)
+
+			ready = true
+			n, err = worker.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+		})
+
+		Convey("rerun job abandoned by crashed worker", func() {
+			lockedUntil := now.Add(-time.Second)
+			So(conn.CreateJob(&skydb.Job{
+				ID:          "abandoned",
+				Lambda:      "send_email",
+				Status:      skydb.JobRunning,
+				Attempts:    1,
+				MaxAttempts: 3,
+				RunAt:       now.Add(-time.Hour),
+				LockedUntil: &lockedUntil,
+			}), ShouldBeNil)
+
+			n, err := worker.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(conn.JobMap["abandoned"].Status, ShouldEqual, skydb.JobSucceeded)
+			So(conn.JobMap["abandoned"].Attempts, ShouldEqual, 2)
+		})
+
+		Convey("run at most BatchSize jobs", func() {
+			worker.BatchSize = 2
+			for _, id := range []string{"job-1", "job-2", "job-3"} {
+				job := newJob(nil, "", now, 3)
+				job.ID = id
+				So(conn.CreateJob(&job), ShouldBeNil)
+			}
+
+			n, err := worker.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+
+			n, err = worker.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(ran, ShouldHaveLength, 3)
+		})
+
+		Convey("drop the run after the lease is lost", func() {
+			job := newJob(nil, "", now, 3)
+			So(conn.CreateJob(&job), ShouldBeNil)
+			worker.RunLambda = func(ctx context.Context, name string, in []byte) ([]byte, error) {
+				// another worker claims the job after the lease expired
+				claimed := conn.JobMap["job-id"]
+				claimed.Attempts++
+				conn.JobMap["job-id"] = claimed
+				return out, nil
+			}
+
+			n, err := worker.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+
+			saved := conn.JobMap["job-id"]
+			So(saved.Status, ShouldEqual, skydb.JobRunning)
+			So(saved.Attempts, ShouldEqual, 2)
+			So(saved.Result, ShouldBeNil)
+		})
+
+		Convey("recover panicking lambda", func() {
+			worker.RunLambda = func(ctx context.Context, name string, in []byte) ([]byte, error) {
+				panic("boom")
+			}
+			job := newJob(nil, "", now, 1)
+			So(conn.CreateJob(&job), ShouldBeNil)
+
+			_, err := worker.RunDue()
+			So(err, ShouldBeNil)
+			So(conn.JobMap["job-id"].Status, ShouldEqual, skydb.JobDead)
+			So(conn.JobMap["job-id"].LastError, ShouldEqual, "job: lambda send_email panicked: boom")
+		})
+	})
+}
+
+func TestRetryInterval(t *testing.T) {
+	Convey("retryInterval", t, func() {
+		worker := &Worker{
+			RetryInterval:    time.Second,
+			MaxRetryInterval: 10 * time.Second,
+		}
+		So(worker.retryInterval(1), ShouldEqual, time.Second)
+		So(worker.retryInterval(2), ShouldEqual, 2*time.Second)
+		So(worker.retryInterval(4), ShouldEqual, 8*time.Second)
+		So(worker.retryInterval(5), ShouldEqual, 10*time.Second)
+	})
+}
+
+func newJob(args json.RawMessage, userID string, runAt time.Time, maxAttempts int) skydb.Job {
+	return skydb.Job{
+		ID:          "job-id",
+		Lambda:      "send_email",
+		Args:        args,
+		UserID:      userID,
+		Status:      skydb.JobPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   runAt,
+	}
+}
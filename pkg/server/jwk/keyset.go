@@ -0,0 +1,331 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jwk manages the keys used to sign JSON Web Tokens and
+// publishes their public keys as a JSON Web Key Set.
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+)
+
+// ErrNoSigningKey is returned when the KeySet does not contain any key.
+var ErrNoSigningKey = errors.New("jwk: no signing key")
+
+// Key is a private key identified by key ID.
+type Key struct {
+	ID         string
+	PrivateKey crypto.Signer
+}
+
+// SigningMethod returns the JWT signing method of the key.
+func (k Key) SigningMethod() (jwt.SigningMethod, error) {
+	switch key := k.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	}
+	return nil, fmt.Errorf("jwk: unsupported key type of key %s", k.ID)
+}
+
+// KeySet is a set of keys used to sign JSON Web Tokens.
+//
+// The last key in the set is used to sign new tokens, while all keys in
+// the set can be used to verify tokens. To rotate keys, add a new key to
+// the set, and remove the old key after all tokens signed by it expire.
+type KeySet struct {
+	path  string
+	mutex sync.RWMutex
+	keys  []Key
+}
+
+// NewKeySet creates a KeySet with the specified keys.
+func NewKeySet(keys ...Key) *KeySet {
+	return &KeySet{
+		keys: keys,
+	}
+}
+
+// LoadKeySet creates a KeySet with the PEM encoded private keys in the
+// directory. The file name of each key without extension is its key ID,
+// and the key whose file name sorts last is used for signing.
+func LoadKeySet(path string) (*KeySet, error) {
+	s := &KeySet{
+		path: path,
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GenerateKeySet creates a KeySet with a newly generated RSA key. The key
+// is not persisted, so tokens signed by it cannot be verified after
+// restart or by other instances.
+func GenerateKeySet() (*KeySet, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySet(Key{ID: "default", PrivateKey: privateKey}), nil
+}
+
+// Reload reads the keys in the directory again. It is a no-op if the
+// KeySet is not loaded from a directory.
+func (s *KeySet) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	filenames, err := filepath.Glob(filepath.Join(s.path, "*.pem"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(filenames)
+
+	keys := []Key{}
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		privateKey, err := parsePrivateKey(data)
+		if err != nil {
+			return fmt.Errorf("jwk: unable to parse key %s: %v", filename, err)
+		}
+
+		keys = append(keys, Key{
+			ID:         strings.TrimSuffix(filepath.Base(filename), ".pem"),
+			PrivateKey: privateKey,
+		})
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("jwk: no keys found in %s", s.path)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.keys = keys
+	return nil
+}
+
+// Watch reloads the keys in the directory periodically in background, so
+// that keys can be added or removed without restart. Failure to reload is
+// logged and the previously loaded keys are kept.
+func (s *KeySet) Watch(interval time.Duration) {
+	if s.path == "" {
+		return
+	}
+
+	logger := logging.LoggerEntry("jwk")
+	go func() {
+		for range time.Tick(interval) {
+			if err := s.Reload(); err != nil {
+				logger.WithError(err).Errorf("Failed to reload keys in %s", s.path)
+			}
+		}
+	}()
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+		return rsaKey, nil
+	}
+	ecKey, err := jwt.ParseECPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	return ecKey, nil
+}
+
+// SigningKey returns the key used to sign new tokens.
+func (s *KeySet) SigningKey() (Key, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if len(s.keys) == 0 {
+		return Key{}, ErrNoSigningKey
+	}
+	return s.keys[len(s.keys)-1], nil
+}
+
+// Key returns the key of the key ID.
+func (s *KeySet) Key(kid string) (Key, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, key := range s.keys {
+		if key.ID == kid {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Sign signs the claims with the signing key. The key ID is set in the
+// kid header of the token.
+func (s *KeySet) Sign(claims jwt.Claims, header map[string]interface{}) (string, error) {
+	key, err := s.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	method, err := key.SigningMethod()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	for k, v := range header {
+		token.Header[k] = v
+	}
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+// Keyfunc looks up the public key to verify the token by the kid header.
+// It can be used with jwt.Parse.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("jwk: unknown key id %s", kid)
+	}
+
+	method, err := key.SigningMethod()
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != method.Alg() {
+		return nil, fmt.Errorf("jwk: unexpected signing method %s", token.Method.Alg())
+	}
+	return key.PrivateKey.Public(), nil
+}
+
+// SigningMethods returns the algorithms of all keys in the set, to be
+// used as jwt.Parser.ValidMethods.
+func (s *KeySet) SigningMethods() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	methods := []string{}
+	seen := map[string]bool{}
+	for _, key := range s.keys {
+		method, err := key.SigningMethod()
+		if err != nil || seen[method.Alg()] {
+			continue
+		}
+		seen[method.Alg()] = true
+		methods = append(methods, method.Alg())
+	}
+	return methods
+}
+
+// JSONWebKey is the public part of a key in JSON Web Key format.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a set of JSONWebKey.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of the set in JSON Web Key Set format.
+func (s *KeySet) JWKS() JSONWebKeySet {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	jwks := JSONWebKeySet{
+		Keys: []JSONWebKey{},
+	}
+	for _, key := range s.keys {
+		method, err := key.SigningMethod()
+		if err != nil {
+			continue
+		}
+
+		jwk := JSONWebKey{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: method.Alg(),
+		}
+		switch publicKey := key.PrivateKey.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encodeBigInt(publicKey.N)
+			jwk.E = encodeBigInt(big.NewInt(int64(publicKey.E)))
+		case *ecdsa.PublicKey:
+			jwk.Kty = "EC"
+			jwk.Crv = publicKey.Curve.Params().Name
+			size := (publicKey.Curve.Params().BitSize + 7) / 8
+			jwk.X = encodeCoordinate(publicKey.X, size)
+			jwk.Y = encodeCoordinate(publicKey.Y, size)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+// encodeCoordinate encodes the coordinate of an EC point, padded to the
+// size of the curve as required by RFC 7518.
+func encodeCoordinate(i *big.Int, size int) string {
+	b := i.Bytes()
+	if len(b) < size {
+		padded := make([]byte, size)
+		copy(padded[size-len(b):], b)
+		b = padded
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,148 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKeySet(t *testing.T) {
+	Convey("KeySet", t, func() {
+		rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+		Convey("sign with the last key", func() {
+			keySet := NewKeySet(
+				Key{ID: "key1", PrivateKey: rsaKey},
+				Key{ID: "key2", PrivateKey: ecKey},
+			)
+
+			signed, err := keySet.Sign(jwt.StandardClaims{Subject: "faseng"}, nil)
+			So(err, ShouldBeNil)
+
+			token, err := jwt.Parse(signed, keySet.Keyfunc)
+			So(err, ShouldBeNil)
+			So(token.Header["kid"], ShouldEqual, "key2")
+			So(token.Header["alg"], ShouldEqual, "ES256")
+			So(token.Claims.(jwt.MapClaims)["sub"], ShouldEqual, "faseng")
+		})
+
+		Convey("verify token signed by old key", func() {
+			oldKeySet := NewKeySet(Key{ID: "key1", PrivateKey: rsaKey})
+			signed, err := oldKeySet.Sign(jwt.StandardClaims{Subject: "faseng"}, nil)
+			So(err, ShouldBeNil)
+
+			keySet := NewKeySet(
+				Key{ID: "key1", PrivateKey: rsaKey},
+				Key{ID: "key2", PrivateKey: ecKey},
+			)
+			_, err = jwt.Parse(signed, keySet.Keyfunc)
+			So(err, ShouldBeNil)
+		})
+
+		Convey("reject token signed by removed key", func() {
+			oldKeySet := NewKeySet(Key{ID: "key1", PrivateKey: rsaKey})
+			signed, err := oldKeySet.Sign(jwt.StandardClaims{Subject: "faseng"}, nil)
+			So(err, ShouldBeNil)
+
+			keySet := NewKeySet(Key{ID: "key2", PrivateKey: ecKey})
+			_, err = jwt.Parse(signed, keySet.Keyfunc)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("reject token with mismatched algorithm", func() {
+			keySet := NewKeySet(Key{ID: "key1", PrivateKey: rsaKey})
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{})
+			token.Header["kid"] = "key1"
+			signed, _ := token.SignedString([]byte("secret"))
+
+			_, err := jwt.Parse(signed, keySet.Keyfunc)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("publish public keys", func() {
+			keySet := NewKeySet(
+				Key{ID: "key1", PrivateKey: rsaKey},
+				Key{ID: "key2", PrivateKey: ecKey},
+			)
+
+			jwks := keySet.JWKS()
+			So(jwks.Keys, ShouldHaveLength, 2)
+			So(jwks.Keys[0].Kty, ShouldEqual, "RSA")
+			So(jwks.Keys[0].Kid, ShouldEqual, "key1")
+			So(jwks.Keys[0].Alg, ShouldEqual, "RS256")
+			So(jwks.Keys[0].E, ShouldEqual, "AQAB")
+			So(jwks.Keys[1].Kty, ShouldEqual, "EC")
+			So(jwks.Keys[1].Crv, ShouldEqual, "P-256")
+			So(jwks.Keys[1].X, ShouldHaveLength, 43)
+			So(keySet.SigningMethods(), ShouldResemble, []string{"RS256", "ES256"})
+		})
+
+		Convey("load keys from directory", func() {
+			dir, err := ioutil.TempDir("", "jwk")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			rsaPEM := pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+			})
+			ioutil.WriteFile(filepath.Join(dir, "2017-01.pem"), rsaPEM, 0600)
+
+			ecBytes, _ := x509.MarshalECPrivateKey(ecKey)
+			ecPEM := pem.EncodeToMemory(&pem.Block{
+				Type:  "EC PRIVATE KEY",
+				Bytes: ecBytes,
+			})
+			ioutil.WriteFile(filepath.Join(dir, "2017-02.pem"), ecPEM, 0600)
+
+			keySet, err := LoadKeySet(dir)
+			So(err, ShouldBeNil)
+
+			key, err := keySet.SigningKey()
+			So(err, ShouldBeNil)
+			So(key.ID, ShouldEqual, "2017-02")
+
+			_, ok := keySet.Key("2017-01")
+			So(ok, ShouldBeTrue)
+
+			os.Remove(filepath.Join(dir, "2017-01.pem"))
+			So(keySet.Reload(), ShouldBeNil)
+			_, ok = keySet.Key("2017-01")
+			So(ok, ShouldBeFalse)
+		})
+
+		Convey("fail to load empty directory", func() {
+			dir, err := ioutil.TempDir("", "jwk")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+
+			_, err = LoadKeySet(dir)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,272 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oauth implements the token issuing logic of Skygear acting as
+// an OAuth 2.0 authorization server and OpenID Connect provider.
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/uuid"
+)
+
+// Grant types supported by the authorization server.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// Scopes understood by the authorization server.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+	ScopeRoles   = "roles"
+)
+
+// DefaultScopes is the scopes allowed for a client if not specified.
+var DefaultScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail, ScopeRoles}
+
+// CodeChallengeMethodS256 is the only PKCE code challenge method supported.
+const CodeChallengeMethodS256 = "S256"
+
+// accessTokenType is the typ header of access tokens, which distinguishes
+// them from ID tokens signed by the same keys.
+const accessTokenType = "at+jwt"
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+// Error is an error response defined in RFC 6749.
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// NewError creates an Error.
+func NewError(code string, description string) *Error {
+	return &Error{
+		Code:        code,
+		Description: description,
+	}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("oauth: %s: %s", e.Code, e.Description)
+}
+
+// AccessTokenClaims is the claims of an access token issued by Server.
+//
+// Subject is the user ID for tokens issued with authorization code
+// grant, and the client ID for tokens issued with client credentials
+// grant.
+type AccessTokenClaims struct {
+	jwt.StandardClaims
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+}
+
+// Scopes returns the scopes granted to the access token.
+func (c AccessTokenClaims) Scopes() []string {
+	return ParseScope(c.Scope)
+}
+
+// IsClientToken returns true if the token is issued to the client
+// itself instead of on behalf of a user.
+func (c AccessTokenClaims) IsClientToken() bool {
+	return c.Subject == c.ClientID
+}
+
+// Server issues and verifies tokens of the authorization server.
+type Server struct {
+	KeySet            *jwk.KeySet
+	Issuer            string
+	AccessTokenExpiry time.Duration
+	IDTokenExpiry     time.Duration
+	CodeExpiry        time.Duration
+}
+
+// NewAuthorizationCode creates an authorization code issued to the
+// client on behalf of the user.
+func (s *Server) NewAuthorizationCode(clientID string, userID string, redirectURI string, scopes []string) skydb.OAuthAuthorizationCode {
+	now := timeNow()
+	return skydb.OAuthAuthorizationCode{
+		Code:        GenerateSecret(),
+		ClientID:    clientID,
+		UserID:      userID,
+		RedirectURI: redirectURI,
+		Scopes:      scopes,
+		ExpireAt:    now.Add(s.CodeExpiry),
+		CreatedAt:   now,
+	}
+}
+
+// IssueAccessToken issues an access token to the client for the subject.
+func (s *Server) IssueAccessToken(clientID string, subject string, scopes []string) (string, error) {
+	now := timeNow()
+	claims := AccessTokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New(),
+			Issuer:    s.Issuer,
+			Audience:  s.Issuer,
+			Subject:   subject,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(s.AccessTokenExpiry).Unix(),
+		},
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+	}
+	return s.KeySet.Sign(claims, map[string]interface{}{
+		"typ": accessTokenType,
+	})
+}
+
+// VerifyAccessToken verifies an access token issued by the server.
+func (s *Server) VerifyAccessToken(tokenString string) (AccessTokenClaims, error) {
+	claims := AccessTokenClaims{}
+	parser := jwt.Parser{ValidMethods: s.KeySet.SigningMethods()}
+	token, err := parser.ParseWithClaims(tokenString, &claims, s.KeySet.Keyfunc)
+	if err != nil {
+		return claims, err
+	}
+
+	if typ, _ := token.Header["typ"].(string); typ != accessTokenType {
+		return claims, errors.New("oauth: token is not an access token")
+	}
+	if !claims.VerifyIssuer(s.Issuer, true) || !claims.VerifyAudience(s.Issuer, true) {
+		return claims, errors.New("oauth: access token is not issued by this server")
+	}
+	return claims, nil
+}
+
+// IssueIDToken issues an ID token of the user to the client. The
+// specified claims, usually returned by UserInfo, are included in the
+// token.
+func (s *Server) IssueIDToken(clientID string, userID string, nonce string, userInfo map[string]interface{}) (string, error) {
+	now := timeNow()
+	claims := jwt.MapClaims{}
+	for k, v := range userInfo {
+		claims[k] = v
+	}
+	claims["iss"] = s.Issuer
+	claims["sub"] = userID
+	claims["aud"] = clientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(s.IDTokenExpiry).Unix()
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	return s.KeySet.Sign(claims, nil)
+}
+
+// UserInfo returns the claims about the user allowed by the scopes,
+// derived from the user record and the roles of the user.
+func (s *Server) UserInfo(authInfo *skydb.AuthInfo, user *skydb.Record, scopes []string) map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": authInfo.ID,
+	}
+
+	if HasScope(scopes, ScopeProfile) && user != nil {
+		if username, ok := user.Data["username"].(string); ok {
+			claims["preferred_username"] = username
+		}
+		if name, ok := user.Data["name"].(string); ok {
+			claims["name"] = name
+		}
+		if !user.UpdatedAt.IsZero() {
+			claims["updated_at"] = user.UpdatedAt.Unix()
+		}
+	}
+
+	if HasScope(scopes, ScopeEmail) && user != nil {
+		if email, ok := user.Data["email"].(string); ok {
+			claims["email"] = email
+		}
+	}
+
+	if HasScope(scopes, ScopeRoles) {
+		roles := authInfo.EffectiveRoles()
+		if roles == nil {
+			roles = []string{}
+		}
+		claims["roles"] = roles
+	}
+
+	return claims
+}
+
+// Discovery returns the OpenID Provider Metadata of the server.
+func (s *Server) Discovery() map[string]interface{} {
+	return map[string]interface{}{
+		"issuer":                                s.Issuer,
+		"authorization_endpoint":                s.Issuer + "/oauth/authorize",
+		"token_endpoint":                        s.Issuer + "/oauth/token",
+		"userinfo_endpoint":                     s.Issuer + "/oauth/userinfo",
+		"jwks_uri":                              s.Issuer + "/oauth/jwks",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{GrantTypeAuthorizationCode, GrantTypeClientCredentials},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": s.KeySet.SigningMethods(),
+		"scopes_supported":                      DefaultScopes,
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{CodeChallengeMethodS256},
+	}
+}
+
+// VerifyCodeChallenge verifies the PKCE code verifier against the code
+// challenge.
+func VerifyCodeChallenge(challenge string, method string, verifier string) bool {
+	if method != CodeChallengeMethodS256 || verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// ParseScope splits a space delimited scope string.
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// HasScope returns true if the scope is in the scopes.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateSecret returns a random string suitable for client secrets
+// and authorization codes.
+func GenerateSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
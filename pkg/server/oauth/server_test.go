@@ -0,0 +1,147 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/jwk"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+func newTestServer() *Server {
+	keySet, err := jwk.GenerateKeySet()
+	if err != nil {
+		panic(err)
+	}
+	return &Server{
+		KeySet:            keySet,
+		Issuer:            "https://skygear.example.com",
+		AccessTokenExpiry: time.Hour,
+		IDTokenExpiry:     time.Hour,
+		CodeExpiry:        10 * time.Minute,
+	}
+}
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	Convey("VerifyCodeChallenge", t, func() {
+		// Example in RFC 7636 Appendix B
+		verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+		Convey("accepts matching verifier", func() {
+			So(VerifyCodeChallenge(challenge, "S256", verifier), ShouldBeTrue)
+		})
+
+		Convey("rejects wrong verifier", func() {
+			So(VerifyCodeChallenge(challenge, "S256", "wrong"), ShouldBeFalse)
+			So(VerifyCodeChallenge(challenge, "S256", ""), ShouldBeFalse)
+		})
+
+		Convey("rejects plain method", func() {
+			So(VerifyCodeChallenge(verifier, "plain", verifier), ShouldBeFalse)
+		})
+	})
+}
+
+func TestServer(t *testing.T) {
+	realTimeNow := timeNow
+	now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	defer func() {
+		timeNow = realTimeNow
+	}()
+
+	Convey("Server", t, func() {
+		server := newTestServer()
+
+		Convey("issues and verifies access token", func() {
+			tokenString, err := server.IssueAccessToken("client-id", "user-id", []string{"openid", "email"})
+			So(err, ShouldBeNil)
+
+			claims, err := server.VerifyAccessToken(tokenString)
+			So(err, ShouldBeNil)
+			So(claims.Subject, ShouldEqual, "user-id")
+			So(claims.ClientID, ShouldEqual, "client-id")
+			So(claims.Scopes(), ShouldResemble, []string{"openid", "email"})
+			So(claims.IsClientToken(), ShouldBeFalse)
+		})
+
+		Convey("rejects expired access token", func() {
+			timeNow = func() time.Time { return now }
+			tokenString, err := server.IssueAccessToken("client-id", "user-id", nil)
+			So(err, ShouldBeNil)
+			timeNow = realTimeNow
+
+			_, err = server.VerifyAccessToken(tokenString)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("rejects id token as access token", func() {
+			tokenString, err := server.IssueIDToken("client-id", "user-id", "", nil)
+			So(err, ShouldBeNil)
+
+			_, err = server.VerifyAccessToken(tokenString)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("rejects access token of another issuer", func() {
+			other := newTestServer()
+			other.KeySet = server.KeySet
+			other.Issuer = "https://other.example.com"
+			tokenString, err := other.IssueAccessToken("client-id", "user-id", nil)
+			So(err, ShouldBeNil)
+
+			_, err = server.VerifyAccessToken(tokenString)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("rejects access token signed by unknown key", func() {
+			tokenString, err := newTestServer().IssueAccessToken("client-id", "user-id", nil)
+			So(err, ShouldBeNil)
+
+			_, err = server.VerifyAccessToken(tokenString)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("derives user info by scopes", func() {
+			authInfo := skydb.AuthInfo{
+				ID:    "user-id",
+				Roles: []string{"admin"},
+			}
+			user := skydb.Record{
+				ID:        skydb.NewRecordID("user", "user-id"),
+				UpdatedAt: now,
+				Data: map[string]interface{}{
+					"username": "john",
+					"email":    "john@example.com",
+				},
+			}
+
+			So(server.UserInfo(&authInfo, &user, []string{"openid"}), ShouldResemble, map[string]interface{}{
+				"sub": "user-id",
+			})
+			So(server.UserInfo(&authInfo, &user, []string{"openid", "profile", "email", "roles"}), ShouldResemble, map[string]interface{}{
+				"sub":                "user-id",
+				"preferred_username": "john",
+				"updated_at":         now.Unix(),
+				"email":              "john@example.com",
+				"roles":              []string{"admin"},
+			})
+		})
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package permission decides whether a user is permitted to call a router
+// action according to the actions permitted for each role.
+package permission
+
+import (
+	"sync"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+// Decision is the result of checking a user against the permissions of
+// an action.
+type Decision int
+
+const (
+	// Unrestricted means no role is given permission to the action, so
+	// the action is not restricted by permissions.
+	Unrestricted Decision = iota
+	// Permitted means the user has a role permitted to call the action,
+	// or an admin role.
+	Permitted
+	// Denied means the action is restricted to roles the user does not
+	// have.
+	Denied
+)
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+// Checker decides whether a user is permitted to call an action. Role
+// permissions and admin roles are loaded from the database and cached.
+type Checker struct {
+	ConnOpener func() (skydb.Conn, error)
+	CacheTTL   time.Duration
+
+	mutex       sync.Mutex
+	permissions skydb.RolePermissions
+	adminRoles  []string
+	loadedAt    time.Time
+}
+
+// NewChecker creates a Checker.
+func NewChecker(connOpener func() (skydb.Conn, error), cacheTTL time.Duration) *Checker {
+	return &Checker{
+		ConnOpener: connOpener,
+		CacheTTL:   cacheTTL,
+	}
+}
+
+// Decide returns the decision of the user calling the action. authInfo is
+// nil if the request is not made by a user.
+func (c *Checker) Decide(authInfo *skydb.AuthInfo, action string) (Decision, error) {
+	permissions, adminRoles, err := c.load()
+	if err != nil {
+		return Denied, err
+	}
+
+	if !permissions.Governs(action) {
+		return Unrestricted, nil
+	}
+	if authInfo == nil {
+		return Denied, nil
+	}
+
+	roles := authInfo.EffectiveRoles()
+	if permissions.Permits(roles, action) || authInfo.HasAnyRoles(adminRoles) {
+		return Permitted, nil
+	}
+	return Denied, nil
+}
+
+// Invalidate removes cached permissions, so that changes to permissions
+// take effect immediately on this server instance.
+func (c *Checker) Invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.permissions = nil
+	c.adminRoles = nil
+}
+
+func (c *Checker) load() (skydb.RolePermissions, []string, error) {
+	now := timeNow()
+
+	c.mutex.Lock()
+	if c.permissions != nil && now.Sub(c.loadedAt) < c.CacheTTL {
+		defer c.mutex.Unlock()
+		return c.permissions, c.adminRoles, nil
+	}
+	c.mutex.Unlock()
+
+	conn, err := c.ConnOpener()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	permissions, err := conn.GetRolePermissions()
+	if err != nil {
+		return nil, nil, err
+	}
+	adminRoles, err := conn.GetAdminRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.permissions = permissions
+	c.adminRoles = adminRoles
+	c.loadedAt = now
+	return permissions, adminRoles, nil
+}
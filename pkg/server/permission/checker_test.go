@@ -0,0 +1,104 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package permission
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func TestChecker(t *testing.T) {
+	Convey("Checker", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		conn.RolePermissions = skydb.RolePermissions{
+			"support": []string{"auth:disable:set"},
+		}
+		opened := 0
+		checker := NewChecker(func() (skydb.Conn, error) {
+			opened++
+			return conn, nil
+		}, time.Minute)
+
+		support := &skydb.AuthInfo{ID: "support", Roles: []string{"support"}}
+		lead := &skydb.AuthInfo{ID: "lead", Roles: []string{"lead"}, InheritedRoles: []string{"support"}}
+		admin := &skydb.AuthInfo{ID: "admin", Roles: []string{"admin"}}
+		user := &skydb.AuthInfo{ID: "user", Roles: []string{"user"}}
+
+		Convey("permits user with permitted role", func() {
+			decision, err := checker.Decide(support, "auth:disable:set")
+			So(err, ShouldBeNil)
+			So(decision, ShouldEqual, Permitted)
+
+			decision, err = checker.Decide(lead, "auth:disable:set")
+			So(err, ShouldBeNil)
+			So(decision, ShouldEqual, Permitted)
+		})
+
+		Convey("permits admin", func() {
+			decision, err := checker.Decide(admin, "auth:disable:set")
+			So(err, ShouldBeNil)
+			So(decision, ShouldEqual, Permitted)
+		})
+
+		Convey("denies user without permitted role", func() {
+			decision, err := checker.Decide(user, "auth:disable:set")
+			So(err, ShouldBeNil)
+			So(decision, ShouldEqual, Denied)
+
+			decision, err = checker.Decide(nil, "auth:disable:set")
+			So(err, ShouldBeNil)
+			So(decision, ShouldEqual, Denied)
+		})
+
+		Convey("does not restrict action without permissions", func() {
+			decision, err := checker.Decide(user, "record:fetch")
+			So(err, ShouldBeNil)
+			So(decision, ShouldEqual, Unrestricted)
+		})
+
+		Convey("caches permissions until invalidated", func() {
+			checker.Decide(user, "record:fetch")
+			conn.RolePermissions["user"] = []string{"record:*"}
+
+			decision, _ := checker.Decide(user, "record:fetch")
+			So(decision, ShouldEqual, Unrestricted)
+			So(opened, ShouldEqual, 1)
+
+			checker.Invalidate()
+			decision, _ = checker.Decide(user, "record:fetch")
+			So(decision, ShouldEqual, Permitted)
+			So(opened, ShouldEqual, 2)
+		})
+
+		Convey("reloads permissions after cache expired", func() {
+			checker.Decide(user, "record:fetch")
+			now = now.Add(time.Minute)
+			checker.Decide(user, "record:fetch")
+			So(opened, ShouldEqual, 2)
+		})
+	})
+}
@@ -28,7 +28,7 @@ type AuthProvider struct {
 func (p *AuthProvider) Login(ctx context.Context, authData map[string]interface{}) (principalID string, newAuthData map[string]interface{}, err error) {
 	request := AuthRequest{p.Name, "login", authData}
 
-	response, err := p.plugin.transport.RunProvider(ctx, &request)
+	response, err := p.run(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -42,7 +42,7 @@ func (p *AuthProvider) Login(ctx context.Context, authData map[string]interface{
 func (p *AuthProvider) Logout(ctx context.Context, authData map[string]interface{}) (newAuthData map[string]interface{}, err error) {
 	request := AuthRequest{p.Name, "logout", authData}
 
-	response, err := p.plugin.transport.RunProvider(ctx, &request)
+	response, err := p.run(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -55,7 +55,7 @@ func (p *AuthProvider) Logout(ctx context.Context, authData map[string]interface
 func (p *AuthProvider) Info(ctx context.Context, authData map[string]interface{}) (newAuthData map[string]interface{}, err error) {
 	request := AuthRequest{p.Name, "info", authData}
 
-	response, err := p.plugin.transport.RunProvider(ctx, &request)
+	response, err := p.run(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -64,6 +64,14 @@ func (p *AuthProvider) Info(ctx context.Context, authData map[string]interface{}
 	return
 }
 
+func (p *AuthProvider) run(ctx context.Context, request *AuthRequest) (response *AuthResponse, err error) {
+	out, err := p.plugin.call(ctx, callOptions{}, func(ctx context.Context) (interface{}, error) {
+		return p.plugin.transport.RunProvider(ctx, request)
+	})
+	response, _ = out.(*AuthResponse)
+	return
+}
+
 // NewAuthProvider creates a new AuthProvider.
 func NewAuthProvider(providerName string, plugin *Plugin) *AuthProvider {
 	return &AuthProvider{
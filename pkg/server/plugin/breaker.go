@@ -0,0 +1,135 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// CircuitBreakerThreshold defines the number of consecutive failed
+	// calls after which the circuit of a plugin is opened
+	CircuitBreakerThreshold = 5
+
+	// CircuitBreakerCooldown defines how long an opened circuit fails
+	// calls fast before a trial call is let through
+	CircuitBreakerCooldown = 30 * time.Second
+)
+
+// CircuitState is the state of the circuit breaker of a plugin.
+type CircuitState string
+
+const (
+	// CircuitClosed is the state in which calls are sent to the plugin.
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen is the state in which calls fail fast without being
+	// sent to the plugin.
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen is the state in which a trial call is sent to the
+	// plugin to decide whether the circuit is to be closed again.
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+var breakerTimeNow = func() time.Time { return time.Now().UTC() }
+
+// CircuitBreaker keeps a plugin from being called after consecutive
+// failures, so that callers fail fast instead of waiting for a plugin
+// that hangs.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		state:     CircuitClosed,
+	}
+}
+
+// State returns the current state of the circuit.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.state == CircuitOpen && !breakerTimeNow().Before(b.openedAt.Add(b.Cooldown)) {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Failures returns the number of consecutive failed calls.
+func (b *CircuitBreaker) Failures() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.failures
+}
+
+// Allow tells whether a call is to be sent to the plugin. When the
+// circuit is half open, only one trial call is allowed at a time. Each
+// allowed call must be followed by a call to Record.
+func (b *CircuitBreaker) Allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if breakerTimeNow().Before(b.openedAt.Add(b.Cooldown)) {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.trial = true
+		return true
+	case CircuitHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Record records the outcome of an allowed call. A failed trial call
+// opens the circuit again; a successful call closes it.
+func (b *CircuitBreaker) Record(failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.trial = false
+	if !failed {
+		b.state = CircuitClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.Threshold {
+		if b.state != CircuitOpen {
+			log.Warnf("Opening circuit after %d consecutive plugin failures", b.failures)
+		}
+		b.state = CircuitOpen
+		b.openedAt = breakerTimeNow()
+	}
+}
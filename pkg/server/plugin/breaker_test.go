@@ -0,0 +1,79 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	Convey("CircuitBreaker", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		originalTimeNow := breakerTimeNow
+		breakerTimeNow = func() time.Time { return now }
+		defer func() {
+			breakerTimeNow = originalTimeNow
+		}()
+
+		breaker := NewCircuitBreaker(3, 30*time.Second)
+
+		Convey("stays closed below the threshold", func() {
+			breaker.Record(true)
+			breaker.Record(true)
+			So(breaker.State(), ShouldEqual, CircuitClosed)
+			So(breaker.Allow(), ShouldBeTrue)
+		})
+
+		Convey("resets failures on success", func() {
+			breaker.Record(true)
+			breaker.Record(true)
+			breaker.Record(false)
+			breaker.Record(true)
+			So(breaker.State(), ShouldEqual, CircuitClosed)
+			So(breaker.Failures(), ShouldEqual, 1)
+		})
+
+		Convey("opens at the threshold", func() {
+			breaker.Record(true)
+			breaker.Record(true)
+			breaker.Record(true)
+			So(breaker.State(), ShouldEqual, CircuitOpen)
+			So(breaker.Allow(), ShouldBeFalse)
+
+			Convey("lets one trial call through after cooldown", func() {
+				now = now.Add(30 * time.Second)
+				So(breaker.State(), ShouldEqual, CircuitHalfOpen)
+				So(breaker.Allow(), ShouldBeTrue)
+				So(breaker.Allow(), ShouldBeFalse)
+
+				Convey("closes on successful trial", func() {
+					breaker.Record(false)
+					So(breaker.State(), ShouldEqual, CircuitClosed)
+					So(breaker.Failures(), ShouldEqual, 0)
+					So(breaker.Allow(), ShouldBeTrue)
+				})
+
+				Convey("opens again on failed trial", func() {
+					breaker.Record(true)
+					So(breaker.State(), ShouldEqual, CircuitOpen)
+					So(breaker.Allow(), ShouldBeFalse)
+				})
+			})
+		})
+	})
+}
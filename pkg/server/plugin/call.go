@@ -0,0 +1,165 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+// PluginCallMaxRetryCount defines the maximum retries of an idempotent call
+// to a plugin that failed because the plugin is unavailable
+const PluginCallMaxRetryCount = 2
+
+// callRetryInterval is the wait before the first retry of a call, which
+// doubles on each retry.
+var callRetryInterval = 100 * time.Millisecond
+
+// callOptions are the options of calling a handler, hook or lambda of a
+// plugin, as declared in the registration info.
+type callOptions struct {
+	// timeout is the time to wait for the plugin before failing the
+	// call with PluginTimeout. Zero means the call is bounded only by
+	// the context and the transport.
+	timeout time.Duration
+
+	// idempotent tells whether the call can be safely retried.
+	idempotent bool
+}
+
+func newCallOptions(timeoutSeconds float64, idempotent bool) callOptions {
+	return callOptions{
+		timeout:    time.Duration(timeoutSeconds * float64(time.Second)),
+		idempotent: idempotent,
+	}
+}
+
+// call runs fn, which calls the transport of the plugin, with the timeout
+// and retries in the options, and returns the output of fn. Calls fail
+// fast with PluginCircuitOpen when the circuit breaker of the plugin is
+// open.
+//
+// fn returns its output instead of setting variables of the caller, so
+// that a call abandoned after a timeout cannot change the output seen by
+// the caller.
+func (p *Plugin) call(ctx context.Context, options callOptions, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	attempts := 1
+	if options.idempotent {
+		attempts += PluginCallMaxRetryCount
+	}
+
+	var (
+		out interface{}
+		err error
+	)
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(callRetryInterval << uint(i-1)):
+			case <-ctx.Done():
+				return out, err
+			}
+		}
+
+		if p.breaker != nil && !p.breaker.Allow() {
+			return nil, skyerr.NewError(
+				skyerr.PluginCircuitOpen,
+				"plugin is unavailable after consecutive failures",
+			)
+		}
+
+		out, err = callWithTimeout(ctx, options.timeout, fn)
+		failed := isTransportFailure(err)
+		if p.breaker != nil {
+			p.breaker.Record(failed)
+		}
+
+		if !failed || !isRetryable(err) {
+			return out, err
+		}
+	}
+	return out, err
+}
+
+// callWithTimeout runs fn in the calling goroutine if there is no
+// timeout. Otherwise fn runs in its own goroutine, which is left behind
+// if it does not finish in time, and its output is dropped.
+func callWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		out interface{}
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := fn(ctx)
+		done <- result{out, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.out, r.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, skyerr.NewError(skyerr.PluginTimeout, "plugin did not respond in time")
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// isTransportFailure tells whether the error means the plugin failed to
+// handle the call, as opposed to an error returned by the plugin itself.
+func isTransportFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	skyErr, ok := err.(skyerr.Error)
+	if !ok {
+		return true
+	}
+
+	switch skyErr.Code() {
+	case skyerr.PluginUnavailable, skyerr.PluginTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// isRetryable tells whether a failed call is worth retrying. Timed out
+// calls are not retried, so that a hanging plugin does not hold up the
+// caller for several timeouts.
+func isRetryable(err error) bool {
+	if err == context.Canceled {
+		return false
+	}
+	if skyErr, ok := err.(skyerr.Error); ok {
+		return skyErr.Code() != skyerr.PluginTimeout
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+func TestPluginCall(t *testing.T) {
+	Convey("Plugin call", t, func() {
+		originalRetryInterval := callRetryInterval
+		callRetryInterval = time.Millisecond
+		defer func() {
+			callRetryInterval = originalRetryInterval
+		}()
+
+		plugin := &Plugin{
+			transport: &nullTransport{},
+			breaker:   NewCircuitBreaker(3, time.Minute),
+		}
+		calls := 0
+		failing := func(ctx context.Context) (interface{}, error) {
+			calls++
+			return nil, errors.New("connection refused")
+		}
+
+		Convey("returns the result of a successful call", func() {
+			out, err := plugin.call(context.Background(), callOptions{}, func(ctx context.Context) (interface{}, error) {
+				calls++
+				return "output", nil
+			})
+			So(err, ShouldBeNil)
+			So(out, ShouldEqual, "output")
+			So(calls, ShouldEqual, 1)
+		})
+
+		Convey("does not retry non-idempotent call", func() {
+			_, err := plugin.call(context.Background(), callOptions{}, failing)
+			So(err, ShouldResemble, errors.New("connection refused"))
+			So(calls, ShouldEqual, 1)
+		})
+
+		Convey("retries idempotent call", func() {
+			_, err := plugin.call(context.Background(), callOptions{idempotent: true}, failing)
+			So(err, ShouldResemble, errors.New("connection refused"))
+			So(calls, ShouldEqual, 1+PluginCallMaxRetryCount)
+		})
+
+		Convey("does not retry error returned by the plugin", func() {
+			pluginErr := skyerr.NewError(skyerr.InvalidArgument, "bad")
+			_, err := plugin.call(context.Background(), callOptions{idempotent: true}, func(ctx context.Context) (interface{}, error) {
+				calls++
+				return nil, pluginErr
+			})
+			So(err, ShouldEqual, pluginErr)
+			So(calls, ShouldEqual, 1)
+			So(plugin.breaker.Failures(), ShouldEqual, 0)
+		})
+
+		Convey("times out slow call", func() {
+			options := callOptions{timeout: 10 * time.Millisecond, idempotent: true}
+			release := make(chan struct{})
+			defer close(release)
+			out, err := plugin.call(context.Background(), options, func(ctx context.Context) (interface{}, error) {
+				<-release
+				return "late output", nil
+			})
+			So(out, ShouldBeNil)
+			So(err.(skyerr.Error).Code(), ShouldEqual, skyerr.PluginTimeout)
+			So(plugin.breaker.Failures(), ShouldEqual, 1)
+		})
+
+		Convey("runs call without timeout in the calling goroutine", func() {
+			var inline bool
+			_, err := plugin.call(context.Background(), callOptions{}, func(ctx context.Context) (interface{}, error) {
+				// Convey assertions panic outside of the goroutine of the test
+				inline = true
+				So(ctx, ShouldNotBeNil)
+				return nil, nil
+			})
+			So(err, ShouldBeNil)
+			So(inline, ShouldBeTrue)
+		})
+
+		Convey("fails fast when circuit is open", func() {
+			for i := 0; i < 3; i++ {
+				plugin.call(context.Background(), callOptions{}, failing)
+			}
+			So(plugin.Status().Circuit, ShouldEqual, CircuitOpen)
+
+			_, err := plugin.call(context.Background(), callOptions{}, failing)
+			So(err.(skyerr.Error).Code(), ShouldEqual, skyerr.PluginCircuitOpen)
+			So(calls, ShouldEqual, 3)
+		})
+	})
+}
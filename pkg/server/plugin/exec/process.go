@@ -25,6 +25,7 @@ import (
 
 	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
 	"github.com/skygeario/skygear-server/pkg/server/plugin/common"
+	pluginrequest "github.com/skygeario/skygear-server/pkg/server/plugin/request"
 	"github.com/skygeario/skygear-server/pkg/server/skyconfig"
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
 	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
@@ -235,6 +236,54 @@ func (p *execTransport) RunHook(ctx context.Context, hookName string, record *sk
 	return &recordout, nil
 }
 
+func (p *execTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	req, err := pluginrequest.NewQueryHookRequest(ctx, hookName, query)
+	if err != nil {
+		return nil, err
+	}
+	out, err := p.runHookProc(ctx, hookName, req.Param)
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseQueryHookResult(ctx, out, query)
+}
+
+func (p *execTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	req := pluginrequest.NewFetchHookRequest(ctx, hookName, records)
+	out, err := p.runHookProc(ctx, hookName, req.Param)
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseFetchHookResult(out, records)
+}
+
+func (p *execTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	req := pluginrequest.NewAuthHookRequest(ctx, hookName, authInfo, user)
+	out, err := p.runHookProc(ctx, hookName, req.Param)
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseAuthHookResult(out, user)
+}
+
+// runHookProc runs the hook with the param as input.
+func (p *execTransport) runHookProc(ctx context.Context, hookName string, param interface{}) ([]byte, error) {
+	in, err := json.Marshal(param)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal hook param: %v", err)
+	}
+
+	pluginCtx := skyplugin.ContextMap(ctx)
+	encodedCtx, err := common.EncodeBase64JSON(pluginCtx)
+	if err != nil {
+		return nil, err
+	}
+	env := []string{
+		fmt.Sprintf("SKYGEAR_CONTEXT=%s", encodedCtx),
+	}
+	return p.runProc([]string{"hook", hookName}, env, in)
+}
+
 func (p *execTransport) RunTimer(name string, in []byte) (out []byte, err error) {
 	out, err = p.runProc([]string{"timer", name}, []string{}, in)
 	return
@@ -0,0 +1,133 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/plugin/grpc/pluginpb"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+// callbackRetryInterval is the interval between attempts to open the
+// callback stream when the plugin is unavailable.
+var callbackRetryInterval = 2 * time.Second
+
+type callbackResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newCallbackResponseWriter() *callbackResponseWriter {
+	return &callbackResponseWriter{
+		header: http.Header{},
+		status: http.StatusOK,
+	}
+}
+
+func (w *callbackResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *callbackResponseWriter) Write(body []byte) (int, error) {
+	return w.body.Write(body)
+}
+
+func (w *callbackResponseWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+// listenCallbacks keeps the callback stream open, reopening it when the
+// plugin disconnects.
+func (p *grpcTransport) listenCallbacks() {
+	for {
+		err := p.serveCallbacks()
+		p.logger.WithError(err).Debug("Callback stream closed, reopening")
+		time.Sleep(callbackRetryInterval)
+	}
+}
+
+// serveCallbacks opens the callback stream and serves requests from the
+// plugin until the stream is closed.
+func (p *grpcTransport) serveCallbacks() error {
+	stream, err := p.client.Callback(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// Responses are sent concurrently, but a grpc stream does not
+	// support concurrent sends.
+	var sendMutex sync.Mutex
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		go func(req *pluginpb.RouterRequest) {
+			resp := p.handleCallback(req)
+			sendMutex.Lock()
+			defer sendMutex.Unlock()
+			if err := stream.Send(resp); err != nil {
+				p.logger.WithError(err).Warn("Failed to send callback response to plugin")
+			}
+		}(req)
+	}
+}
+
+// handleCallback handles a request from the plugin with the router. The
+// request is made with the master key, like requests from plugins through
+// the other bidirectional transports.
+func (p *grpcTransport) handleCallback(req *pluginpb.RouterRequest) *pluginpb.RouterResponse {
+	data := map[string]interface{}{}
+	if err := json.Unmarshal(req.Payload, &data); err != nil {
+		body, _ := json.Marshal(struct {
+			Error skyerr.Error `json:"error"`
+		}{skyerr.NewError(skyerr.BadRequest, "fails to decode the request payload")})
+		return &pluginpb.RouterResponse{
+			Id:     req.Id,
+			Status: http.StatusBadRequest,
+			Body:   body,
+		}
+	}
+
+	action, _ := data["action"].(string)
+	payload := &router.Payload{
+		Meta: map[string]interface{}{
+			"method": "POST",
+			"path":   strings.Replace(action, ":", "/", -1),
+		},
+		Data:      data,
+		AccessKey: router.MasterAccessKey,
+	}
+	payload.SetContext(context.Background())
+
+	writer := newCallbackResponseWriter()
+	p.router.HandlePayload(payload, router.NewResponse(writer))
+
+	return &pluginpb.RouterResponse{
+		Id:     req.Id,
+		Status: int32(writer.status),
+		Body:   writer.body.Bytes(),
+	}
+}
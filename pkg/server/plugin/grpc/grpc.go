@@ -0,0 +1,326 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpc implements the grpc plugin transport. The protocol is
+// published in pluginpb/plugin.proto.
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/common"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/grpc/pluginpb"
+	pluginrequest "github.com/skygeario/skygear-server/pkg/server/plugin/request"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyconfig"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+var log = logging.LoggerEntryWithTag("plugin", "plugin")
+
+type grpcTransport struct {
+	address string
+	conn    *grpc.ClientConn
+	client  pluginpb.PluginClient
+	state   skyplugin.TransportState
+	logger  *logrus.Entry
+	config  skyconfig.Configuration
+
+	router       *router.Router
+	callbackOnce sync.Once
+}
+
+func (p *grpcTransport) State() skyplugin.TransportState {
+	return p.state
+}
+
+func (p *grpcTransport) SetState(state skyplugin.TransportState) {
+	if state != p.state {
+		oldState := p.state
+		p.state = state
+		p.logger.Infof("Transport state changes from %v to %v.", oldState, p.state)
+	}
+}
+
+// SetRouter sets the router serving callbacks from the plugin, and opens
+// the callback stream.
+func (p *grpcTransport) SetRouter(r *router.Router) {
+	p.router = r
+	p.callbackOnce.Do(func() {
+		go p.listenCallbacks()
+	})
+}
+
+func (p *grpcTransport) SendEvent(name string, in []byte) ([]byte, error) {
+	ctx := context.Background()
+	if name == "init" {
+		registration, err := p.client.Init(ctx, &pluginpb.InitRequest{Config: in})
+		if err != nil {
+			return nil, err
+		}
+		return registrationJSON(registration)
+	}
+
+	resp, err := p.client.SendEvent(ctx, &pluginpb.EventRequest{Name: name, Data: in})
+	return result(resp, err)
+}
+
+func (p *grpcTransport) RunLambda(ctx context.Context, name string, in []byte) ([]byte, error) {
+	req, err := newRequest(pluginrequest.NewLambdaRequest(ctx, name, in))
+	if err != nil {
+		return nil, err
+	}
+	return result(p.client.RunLambda(ctx, req))
+}
+
+func (p *grpcTransport) RunHandler(ctx context.Context, name string, in []byte) ([]byte, error) {
+	req, err := newRequest(pluginrequest.NewHandlerRequest(ctx, name, in))
+	if err != nil {
+		return nil, err
+	}
+	return result(p.client.RunHandler(ctx, req))
+}
+
+func (p *grpcTransport) RunHook(ctx context.Context, hookName string, record *skydb.Record, originalRecord *skydb.Record, async bool) (*skydb.Record, error) {
+	req, err := newRequest(pluginrequest.NewHookRequest(ctx, hookName, record, originalRecord, async))
+	if err != nil {
+		return nil, err
+	}
+	out, err := result(p.client.RunHook(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+
+	var recordout skydb.Record
+	if err := json.Unmarshal(out, (*skyconv.JSONRecord)(&recordout)); err != nil {
+		p.logger.WithField("data", string(out)).Error("failed to unmarshal record")
+		return nil, fmt.Errorf("failed to unmarshal record: %v", err)
+	}
+	recordout.OwnerID = record.OwnerID
+	recordout.CreatedAt = record.CreatedAt
+	recordout.CreatorID = record.CreatorID
+	recordout.UpdatedAt = record.UpdatedAt
+	recordout.UpdaterID = record.UpdaterID
+
+	return &recordout, nil
+}
+
+func (p *grpcTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	pluginReq, err := pluginrequest.NewQueryHookRequest(ctx, hookName, query)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newRequest(pluginReq)
+	if err != nil {
+		return nil, err
+	}
+	out, err := result(p.client.RunHook(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseQueryHookResult(ctx, out, query)
+}
+
+func (p *grpcTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	req, err := newRequest(pluginrequest.NewFetchHookRequest(ctx, hookName, records))
+	if err != nil {
+		return nil, err
+	}
+	out, err := result(p.client.RunHook(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseFetchHookResult(out, records)
+}
+
+func (p *grpcTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	req, err := newRequest(pluginrequest.NewAuthHookRequest(ctx, hookName, authInfo, user))
+	if err != nil {
+		return nil, err
+	}
+	out, err := result(p.client.RunHook(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseAuthHookResult(out, user)
+}
+
+func (p *grpcTransport) RunTimer(name string, in []byte) ([]byte, error) {
+	pluginReq := pluginrequest.NewTimerRequest(name)
+	req, err := newRequest(pluginReq)
+	if err != nil {
+		return nil, err
+	}
+	return result(p.client.RunTimer(pluginReq.Context, req))
+}
+
+func (p *grpcTransport) RunProvider(ctx context.Context, request *skyplugin.AuthRequest) (*skyplugin.AuthResponse, error) {
+	req, err := newRequest(pluginrequest.NewAuthRequest(ctx, request))
+	if err != nil {
+		return nil, err
+	}
+	out, err := result(p.client.RunProvider(ctx, req))
+	if err != nil {
+		return nil, err
+	}
+
+	resp := skyplugin.AuthResponse{}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %v", err)
+	}
+	return &resp, nil
+}
+
+// newRequest converts a plugin request to the grpc message.
+func newRequest(req *pluginrequest.Request) (*pluginpb.Request, error) {
+	var param []byte
+	if rawParam, ok := req.Param.(json.RawMessage); ok {
+		param = rawParam
+	} else if req.Param != nil {
+		var err error
+		if param, err = json.Marshal(req.Param); err != nil {
+			return nil, err
+		}
+	}
+
+	pluginCtx := skyplugin.ContextMap(req.Context)
+	ctx := &pluginpb.Context{}
+	ctx.UserId, _ = pluginCtx["user_id"].(string)
+	ctx.AccessKeyType, _ = pluginCtx["access_key_type"].(string)
+	ctx.RequestId, _ = pluginCtx["request_id"].(string)
+	ctx.RequestTag, _ = pluginCtx["request_tag"].(string)
+
+	return &pluginpb.Request{
+		Name:    req.Name,
+		Param:   param,
+		Context: ctx,
+		Async:   req.Async,
+	}, nil
+}
+
+// result returns the result of the response, or the error returned by the
+// plugin as *common.ExecError.
+func result(resp *pluginpb.Response, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		execError := &common.ExecError{
+			ErrorCode:    skyerr.ErrorCode(resp.Error.Code),
+			ErrorMessage: resp.Error.Message,
+		}
+		if len(resp.Error.Info) > 0 {
+			if err := json.Unmarshal(resp.Error.Info, &execError.ErrorInfo); err != nil {
+				return nil, fmt.Errorf("failed to parse error info: %v", err)
+			}
+		}
+		return nil, execError
+	}
+	return resp.Result, nil
+}
+
+// registrationJSON converts the registration to the JSON returned by the
+// init event of other transports.
+func registrationJSON(registration *pluginpb.Registration) ([]byte, error) {
+	handlers := []map[string]interface{}{}
+	for _, info := range registration.Handlers {
+		handlers = append(handlers, map[string]interface{}{
+			"name":          info.Name,
+			"methods":       info.Methods,
+			"auth_required": info.AuthRequired,
+			"key_required":  info.KeyRequired,
+			"user_required": info.UserRequired,
+		})
+	}
+
+	hooks := []map[string]interface{}{}
+	for _, info := range registration.Hooks {
+		hooks = append(hooks, map[string]interface{}{
+			"name":    info.Name,
+			"trigger": info.Trigger,
+			"type":    info.Type,
+			"async":   info.Async,
+		})
+	}
+
+	lambdas := []map[string]interface{}{}
+	for _, info := range registration.Lambdas {
+		lambdas = append(lambdas, map[string]interface{}{
+			"name":          info.Name,
+			"key_required":  info.KeyRequired,
+			"user_required": info.UserRequired,
+		})
+	}
+
+	timers := []map[string]interface{}{}
+	for _, info := range registration.Timers {
+		timers = append(timers, map[string]interface{}{
+			"name": info.Name,
+			"spec": info.Spec,
+		})
+	}
+
+	providers := []map[string]interface{}{}
+	for _, info := range registration.Providers {
+		providers = append(providers, map[string]interface{}{
+			"id":   info.Name,
+			"type": info.Type,
+		})
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"handler":  handlers,
+		"hook":     hooks,
+		"op":       lambdas,
+		"timer":    timers,
+		"provider": providers,
+	})
+}
+
+type grpcTransportFactory struct {
+}
+
+func (f grpcTransportFactory) Open(path string, args []string, config skyconfig.Configuration) skyplugin.Transport {
+	logger := log.WithFields(logrus.Fields{"plugin": path})
+
+	// Dial does not block; the connection is established when the plugin
+	// is initialized and re-established if the plugin restarts.
+	conn, err := grpc.Dial(path, grpc.WithInsecure())
+	if err != nil {
+		logger.Panicf("Failed to dial plugin for grpc transport: %v", err)
+	}
+
+	return &grpcTransport{
+		address: path,
+		conn:    conn,
+		client:  pluginpb.NewPluginClient(conn),
+		state:   skyplugin.TransportStateUninitialized,
+		logger:  logger,
+		config:  config,
+	}
+}
+
+func init() {
+	skyplugin.RegisterTransport("grpc", grpcTransportFactory{})
+}
@@ -0,0 +1,219 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc"
+
+	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/common"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/grpc/pluginpb"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyconfig"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+)
+
+// testPluginServer records the last request and returns the configured
+// response.
+type testPluginServer struct {
+	request       *pluginpb.Request
+	response      *pluginpb.Response
+	callbackResps chan *pluginpb.RouterResponse
+}
+
+func (s *testPluginServer) Init(ctx context.Context, in *pluginpb.InitRequest) (*pluginpb.Registration, error) {
+	return &pluginpb.Registration{
+		Lambdas: []*pluginpb.LambdaInfo{
+			{Name: "hello", UserRequired: true},
+		},
+		Hooks: []*pluginpb.HookInfo{
+			{Name: "before_note", Trigger: "beforeSave", Type: "note"},
+		},
+		Providers: []*pluginpb.ProviderInfo{
+			{Name: "com.example", Type: "auth"},
+		},
+	}, nil
+}
+
+func (s *testPluginServer) SendEvent(ctx context.Context, in *pluginpb.EventRequest) (*pluginpb.Response, error) {
+	return s.response, nil
+}
+
+func (s *testPluginServer) run(in *pluginpb.Request) (*pluginpb.Response, error) {
+	s.request = in
+	return s.response, nil
+}
+
+func (s *testPluginServer) RunLambda(ctx context.Context, in *pluginpb.Request) (*pluginpb.Response, error) {
+	return s.run(in)
+}
+
+func (s *testPluginServer) RunHandler(ctx context.Context, in *pluginpb.Request) (*pluginpb.Response, error) {
+	return s.run(in)
+}
+
+func (s *testPluginServer) RunHook(ctx context.Context, in *pluginpb.Request) (*pluginpb.Response, error) {
+	return s.run(in)
+}
+
+func (s *testPluginServer) RunTimer(ctx context.Context, in *pluginpb.Request) (*pluginpb.Response, error) {
+	return s.run(in)
+}
+
+func (s *testPluginServer) RunProvider(ctx context.Context, in *pluginpb.Request) (*pluginpb.Response, error) {
+	return s.run(in)
+}
+
+func (s *testPluginServer) Callback(stream pluginpb.Plugin_CallbackServer) error {
+	err := stream.Send(&pluginpb.RouterRequest{
+		Id:      "req1",
+		Payload: []byte(`{"action": "hello:world"}`),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	s.callbackResps <- resp
+	<-stream.Context().Done()
+	return nil
+}
+
+func startTestPluginServer(s *testPluginServer) (*grpc.Server, string) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	So(err, ShouldBeNil)
+	server := grpc.NewServer()
+	pluginpb.RegisterPluginServer(server, s)
+	go server.Serve(lis)
+	return server, lis.Addr().String()
+}
+
+func TestGRPCTransport(t *testing.T) {
+	Convey("grpc transport", t, func() {
+		pluginServer := &testPluginServer{
+			response:      &pluginpb.Response{},
+			callbackResps: make(chan *pluginpb.RouterResponse, 1),
+		}
+		server, address := startTestPluginServer(pluginServer)
+		defer server.Stop()
+
+		transport := grpcTransportFactory{}.Open(address, []string{}, skyconfig.Configuration{}).(*grpcTransport)
+		defer transport.conn.Close()
+
+		Convey("returns registration info on init", func() {
+			out, err := transport.SendEvent("init", []byte(`{"config":{}}`))
+			So(err, ShouldBeNil)
+			So(out, ShouldEqualJSON, `{
+				"handler": [],
+				"hook": [{"name": "before_note", "trigger": "beforeSave", "type": "note", "async": false}],
+				"op": [{"name": "hello", "key_required": false, "user_required": true}],
+				"timer": [],
+				"provider": [{"id": "com.example", "type": "auth"}]
+			}`)
+		})
+
+		Convey("runs lambda with param and context", func() {
+			pluginServer.response.Result = []byte(`{"message": "hello"}`)
+			ctx := context.WithValue(context.Background(), router.UserIDContextKey, "user1")
+			out, err := transport.RunLambda(ctx, "hello", []byte(`["world"]`))
+			So(err, ShouldBeNil)
+			So(out, ShouldEqualJSON, `{"message": "hello"}`)
+
+			So(pluginServer.request.Name, ShouldEqual, "hello")
+			So(pluginServer.request.Param, ShouldEqualJSON, `["world"]`)
+			So(pluginServer.request.Context.UserId, ShouldEqual, "user1")
+		})
+
+		Convey("returns error of plugin", func() {
+			pluginServer.response.Error = &pluginpb.Error{
+				Code:    int32(skyerr.InvalidArgument),
+				Message: "invalid name",
+				Info:    []byte(`{"arguments": ["name"]}`),
+			}
+			_, err := transport.RunLambda(context.Background(), "hello", []byte(`[]`))
+			So(err, ShouldResemble, &common.ExecError{
+				ErrorCode:    skyerr.InvalidArgument,
+				ErrorMessage: "invalid name",
+				ErrorInfo: map[string]interface{}{
+					"arguments": []interface{}{"name"},
+				},
+			})
+		})
+
+		Convey("runs hook with record", func() {
+			pluginServer.response.Result = []byte(`{
+				"_id": "note/note1",
+				"_type": "record",
+				"content": "modified"
+			}`)
+			record := &skydb.Record{
+				ID:      skydb.NewRecordID("note", "note1"),
+				OwnerID: "user1",
+				Data:    skydb.Data{"content": "original"},
+			}
+			recordout, err := transport.RunHook(context.Background(), "before_note", record, nil, false)
+			So(err, ShouldBeNil)
+			So(recordout.OwnerID, ShouldEqual, "user1")
+			So(recordout.Data["content"], ShouldEqual, "modified")
+
+			param := map[string]interface{}{}
+			So(json.Unmarshal(pluginServer.request.Param, &param), ShouldBeNil)
+			So(param["record"], ShouldContainKey, "content")
+		})
+
+		Convey("runs provider", func() {
+			pluginServer.response.Result = []byte(`{"principal_id": "john", "auth_data": {"name": "John"}}`)
+			resp, err := transport.RunProvider(context.Background(), &skyplugin.AuthRequest{
+				ProviderName: "com.example",
+				Action:       "login",
+				AuthData:     map[string]interface{}{"token": "abc"},
+			})
+			So(err, ShouldBeNil)
+			So(resp.PrincipalID, ShouldEqual, "john")
+			So(pluginServer.request.Param, ShouldEqualJSON, `{"action": "login", "auth_data": {"token": "abc"}}`)
+		})
+
+		Convey("serves callback from plugin with router", func() {
+			r := router.NewRouter()
+			r.Map("hello:world", "", router.NewFuncHandler(func(payload *router.Payload, response *router.Response) {
+				response.Result = map[string]interface{}{
+					"master_key": payload.HasMasterKey(),
+				}
+			}))
+			transport.SetRouter(r)
+
+			select {
+			case resp := <-pluginServer.callbackResps:
+				So(resp.Id, ShouldEqual, "req1")
+				So(resp.Status, ShouldEqual, 200)
+				So(resp.Body, ShouldEqualJSON, `{"result": {"master_key": true}}`)
+			case <-time.After(5 * time.Second):
+				So("callback timed out", ShouldBeEmpty)
+			}
+		})
+	})
+}
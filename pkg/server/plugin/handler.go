@@ -1,8 +1,10 @@
 package plugin
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -38,6 +40,8 @@ type Handler struct {
 	Name              string
 	AccessKeyRequired bool
 	UserRequired      bool
+	Timeout           time.Duration
+	Idempotent        bool
 
 	Authenticator         router.Processor `preprocessor:"authenticator"`
 	InjectIDAuthenticator router.Processor `preprocessor:"inject_auth_id"`
@@ -54,6 +58,8 @@ func NewPluginHandler(info pluginHandlerInfo, p *Plugin) *Handler {
 		Name:              info.Name,
 		AccessKeyRequired: info.KeyRequired,
 		UserRequired:      info.UserRequired,
+		Timeout:           time.Duration(info.Timeout * float64(time.Second)),
+		Idempotent:        info.Idempotent,
 	}
 	return handler
 }
@@ -102,7 +108,11 @@ func (h *Handler) Handle(payload *router.Payload, response *router.Response) {
 		panic(err)
 	}
 
-	outbytes, err := h.Plugin.transport.RunHandler(payload.Context(), h.Name, inbytes)
+	options := callOptions{timeout: h.Timeout, idempotent: h.Idempotent}
+	out, err := h.Plugin.call(payload.Context(), options, func(ctx context.Context) (interface{}, error) {
+		return h.Plugin.transport.RunHandler(ctx, h.Name, inbytes)
+	})
+	outbytes, _ := out.([]byte)
 	log.WithFields(logrus.Fields{
 		"name": h.Name,
 		"err":  err,
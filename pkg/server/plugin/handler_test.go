@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	. "github.com/skygeario/skygear-server/pkg/server/skytest"
 	. "github.com/smartystreets/goconvey/convey"
@@ -40,6 +41,17 @@ func TestHandlerCreation(t *testing.T) {
 
 		So(handler.AccessKeyRequired, ShouldBeTrue)
 	})
+
+	Convey("create handler with timeout", t, func() {
+		handler := NewPluginHandler(pluginHandlerInfo{
+			Name:       "hello:world",
+			Timeout:    2,
+			Idempotent: true,
+		}, nil)
+
+		So(handler.Timeout, ShouldEqual, 2*time.Second)
+		So(handler.Idempotent, ShouldBeTrue)
+	})
 }
 
 func TestHandler(t *testing.T) {
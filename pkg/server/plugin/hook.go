@@ -27,10 +27,13 @@ import (
 // CreateHookFunc returns a hook.HookFunc that run the hook registered by a
 // plugin
 func CreateHookFunc(p *Plugin, hookInfo pluginHookInfo) hook.Func {
+	options := newCallOptions(hookInfo.Timeout, hookInfo.Idempotent)
 	hookFunc := func(ctx context.Context, record *skydb.Record, oldRecord *skydb.Record) skyerr.Error {
-		recordout, err := p.transport.RunHook(ctx, hookInfo.Name, record, oldRecord, hookInfo.Async)
+		out, err := p.call(ctx, options, func(ctx context.Context) (interface{}, error) {
+			return p.transport.RunHook(ctx, hookInfo.Name, record, oldRecord, hookInfo.Async)
+		})
 		if err == nil && hookInfo.Trigger == string(hook.BeforeSave) && !hookInfo.Async {
-			*record = *recordout
+			*record = *out.(*skydb.Record)
 		}
 
 		if err == nil {
@@ -67,3 +70,56 @@ func CreateHookFunc(p *Plugin, hookInfo pluginHookInfo) hook.Func {
 
 	return hookFunc
 }
+
+// CreateQueryHookFunc returns a hook.QueryFunc that run the beforeQuery
+// hook registered by a plugin
+func CreateQueryHookFunc(p *Plugin, hookInfo pluginHookInfo) hook.QueryFunc {
+	options := newCallOptions(hookInfo.Timeout, hookInfo.Idempotent)
+	return func(ctx context.Context, query *skydb.Query) skyerr.Error {
+		out, err := p.call(ctx, options, func(ctx context.Context) (interface{}, error) {
+			return p.transport.RunQueryHook(ctx, hookInfo.Name, query)
+		})
+		if err != nil {
+			return skyerr.MakeError(err)
+		}
+
+		query.Predicate = out.(*skydb.Query).Predicate
+		return nil
+	}
+}
+
+// CreateFetchHookFunc returns a hook.FetchFunc that run the afterFetch
+// hook registered by a plugin
+func CreateFetchHookFunc(p *Plugin, hookInfo pluginHookInfo) hook.FetchFunc {
+	options := newCallOptions(hookInfo.Timeout, hookInfo.Idempotent)
+	return func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+		out, err := p.call(ctx, options, func(ctx context.Context) (interface{}, error) {
+			return p.transport.RunFetchHook(ctx, hookInfo.Name, records)
+		})
+		if err != nil {
+			return nil, skyerr.MakeError(err)
+		}
+		recordsout, _ := out.([]*skydb.Record)
+		return recordsout, nil
+	}
+}
+
+// CreateAuthHookFunc returns a hook.AuthFunc that run the auth hook
+// registered by a plugin
+func CreateAuthHookFunc(p *Plugin, hookInfo pluginHookInfo) hook.AuthFunc {
+	options := newCallOptions(hookInfo.Timeout, hookInfo.Idempotent)
+	return func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+		out, err := p.call(ctx, options, func(ctx context.Context) (interface{}, error) {
+			return p.transport.RunAuthHook(ctx, hookInfo.Name, authInfo, user)
+		})
+		if err != nil {
+			return skyerr.MakeError(err)
+		}
+
+		userout, _ := out.(*skydb.Record)
+		if user != nil && userout != nil {
+			user.Data = userout.Data
+		}
+		return nil
+	}
+}
@@ -34,13 +34,81 @@ const (
 	AfterDelete  Kind = "afterDelete"
 )
 
+// The kinds of hooks executed on record query and fetch. They are
+// registered with RegisterQueryHook and RegisterFetchHook.
+const (
+	BeforeQuery Kind = "beforeQuery"
+	AfterFetch  Kind = "afterFetch"
+)
+
+// The kinds of hooks executed on auth lifecycle events. They are
+// registered with RegisterAuthHook.
+const (
+	BeforeSignup         Kind = "beforeSignup"
+	AfterSignup          Kind = "afterSignup"
+	BeforeLogin          Kind = "beforeLogin"
+	AfterLogin           Kind = "afterLogin"
+	BeforeLogout         Kind = "beforeLogout"
+	AfterLogout          Kind = "afterLogout"
+	BeforePasswordChange Kind = "beforePasswordChange"
+	AfterPasswordChange  Kind = "afterPasswordChange"
+	BeforeProviderLink   Kind = "beforeProviderLink"
+	AfterProviderLink    Kind = "afterProviderLink"
+	BeforeProviderUnlink Kind = "beforeProviderUnlink"
+	AfterProviderUnlink  Kind = "afterProviderUnlink"
+	BeforeUserDisable    Kind = "beforeUserDisable"
+	AfterUserDisable     Kind = "afterUserDisable"
+)
+
+// AnyRecordType is the record type of record hooks executed for records of
+// all types, after the hooks registered for the type of the record.
+const AnyRecordType = "*"
+
+// IsAuthKind returns whether kind is a kind of auth hook.
+func IsAuthKind(kind Kind) bool {
+	switch kind {
+	case BeforeSignup, AfterSignup,
+		BeforeLogin, AfterLogin,
+		BeforeLogout, AfterLogout,
+		BeforePasswordChange, AfterPasswordChange,
+		BeforeProviderLink, AfterProviderLink,
+		BeforeProviderUnlink, AfterProviderUnlink,
+		BeforeUserDisable, AfterUserDisable:
+		return true
+	}
+	return false
+}
+
 // Func defines the interface of a function that can be hooked.
 //
 // The supplied record is fully fetched for all four kind of hooks.
 type Func func(context.Context, *skydb.Record, *skydb.Record) skyerr.Error
 
+// QueryFunc defines the interface of a beforeQuery hook, which may rewrite
+// the predicate of the query before it is executed.
+type QueryFunc func(context.Context, *skydb.Query) skyerr.Error
+
+// FetchFunc defines the interface of an afterFetch hook. It receives the
+// fetched records of a record type and returns the records to be returned
+// to the client, which may be modified. Records not returned are dropped.
+type FetchFunc func(context.Context, []*skydb.Record) ([]*skydb.Record, skyerr.Error)
+
+// AuthFunc defines the interface of an auth hook. It receives the auth info
+// and the user record of the user involved. The user record may be nil if
+// it cannot be fetched.
+//
+// A before hook may reject the action by returning an error. Both before
+// and after hooks may modify the data of the user record, which is saved
+// by the caller.
+type AuthFunc func(context.Context, *skydb.AuthInfo, *skydb.Record) skyerr.Error
+
 type recordTypeHookMap map[string][]Func
 
+type namedSet struct {
+	name     string
+	registry *Registry
+}
+
 // Registry is a registry of hooks by record type.
 //
 // It provides method to execute hooks but is not responsible to execute
@@ -54,16 +122,22 @@ type Registry struct {
 	afterSaveHooks    recordTypeHookMap
 	beforeDeleteHooks recordTypeHookMap
 	afterDeleteHooks  recordTypeHookMap
+	beforeQueryHooks  map[string][]QueryFunc
+	afterFetchHooks   map[string][]FetchFunc
+	authHooks         map[Kind][]AuthFunc
+	sets              []namedSet
 }
 
 // NewRegistry returns a Registry ready for use.
 func NewRegistry() *Registry {
 	return &Registry{
-		sync.RWMutex{},
-		recordTypeHookMap{},
-		recordTypeHookMap{},
-		recordTypeHookMap{},
-		recordTypeHookMap{},
+		beforeSaveHooks:   recordTypeHookMap{},
+		afterSaveHooks:    recordTypeHookMap{},
+		beforeDeleteHooks: recordTypeHookMap{},
+		afterDeleteHooks:  recordTypeHookMap{},
+		beforeQueryHooks:  map[string][]QueryFunc{},
+		afterFetchHooks:   map[string][]FetchFunc{},
+		authHooks:         map[Kind][]AuthFunc{},
 	}
 }
 
@@ -81,6 +155,40 @@ func (r *Registry) Register(kind Kind, recordType string, hook Func) error {
 	return nil
 }
 
+// ReplaceSet replaces the hooks added by the set of the name with the
+// hooks registered in set, such as when a plugin is reloaded. A nil set
+// removes the hooks of the name. The hooks in sets are executed after the
+// hooks registered with the registry directly, in the order the sets are
+// first added. The set must not be modified after it is added.
+func (r *Registry) ReplaceSet(name string, set *Registry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, s := range r.sets {
+		if s.name != name {
+			continue
+		}
+		if set == nil {
+			r.sets = append(r.sets[:i], r.sets[i+1:]...)
+		} else {
+			r.sets[i].registry = set
+		}
+		return
+	}
+	if set != nil {
+		r.sets = append(r.sets, namedSet{name, set})
+	}
+}
+
+// registries returns the registry itself followed by the registries of
+// the sets. Acquire lock before calling this function.
+func (r *Registry) registries() []*Registry {
+	registries := []*Registry{r}
+	for _, s := range r.sets {
+		registries = append(registries, s.registry)
+	}
+	return registries
+}
+
 // ExecuteHooks executes registered hooks for the type of supplied record to
 // be executed at the specific kind of moment.
 //
@@ -101,17 +209,117 @@ func (r *Registry) ExecuteHooks(ctx context.Context, kind Kind, record *skydb.Re
 	return nil
 }
 
+// RegisterQueryHook adds a beforeQuery hook for queries on recordType.
+func (r *Registry) RegisterQueryHook(recordType string, hook QueryFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.beforeQueryHooks[recordType] = append(r.beforeQueryHooks[recordType], hook)
+}
+
+// RegisterFetchHook adds an afterFetch hook for records of recordType.
+func (r *Registry) RegisterFetchHook(recordType string, hook FetchFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.afterFetchHooks[recordType] = append(r.afterFetchHooks[recordType], hook)
+}
+
+// ExecuteQueryHooks executes the beforeQuery hooks registered for the type
+// of the query. The hooks may modify the query.
+//
+// If one of the hooks returns an error, it halts execution of other hooks and
+// returns that error untouched.
+func (r *Registry) ExecuteQueryHooks(ctx context.Context, query *skydb.Query) skyerr.Error {
+	r.mutex.RLock()
+	var hooks []QueryFunc
+	for _, registry := range r.registries() {
+		hooks = append(hooks, registry.beforeQueryHooks[query.Type]...)
+	}
+	r.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExecuteFetchHooks executes the afterFetch hooks registered for
+// recordType on the records, which must be of recordType. The records
+// returned by a hook are passed to the next one.
+//
+// If one of the hooks returns an error, it halts execution of other hooks and
+// returns that error untouched.
+func (r *Registry) ExecuteFetchHooks(ctx context.Context, recordType string, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+	r.mutex.RLock()
+	var hooks []FetchFunc
+	for _, registry := range r.registries() {
+		hooks = append(hooks, registry.afterFetchHooks[recordType]...)
+	}
+	r.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		if len(records) == 0 {
+			break
+		}
+
+		var err skyerr.Error
+		if records, err = hook(ctx, records); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// RegisterAuthHook adds an auth hook to be executed at the moment provided
+// by kind, which must be a kind of auth hook.
+func (r *Registry) RegisterAuthHook(kind Kind, hook AuthFunc) error {
+	if !IsAuthKind(kind) {
+		return fmt.Errorf("unrecognized kind of auth hook = %#v", string(kind))
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.authHooks[kind] = append(r.authHooks[kind], hook)
+	return nil
+}
+
+// ExecuteAuthHooks executes the auth hooks registered for kind.
+//
+// If one of the hooks returns an error, it halts execution of other hooks and
+// returns that error untouched.
+func (r *Registry) ExecuteAuthHooks(ctx context.Context, kind Kind, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+	r.mutex.RLock()
+	var hooks []AuthFunc
+	for _, registry := range r.registries() {
+		hooks = append(hooks, registry.authHooks[kind]...)
+	}
+	r.mutex.RUnlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, authInfo, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *Registry) hooks(kind Kind, recordType string) (m []Func, err error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	recordTypeHookMap, err := r.recordTypeHookMap(kind)
-	if err != nil {
-		return nil, err
-	}
+	var hooks []Func
+	for _, registry := range r.registries() {
+		recordTypeHookMap, err := registry.recordTypeHookMap(kind)
+		if err != nil {
+			return nil, err
+		}
 
-	hooks := make([]Func, len(recordTypeHookMap[recordType]))
-	copy(hooks, recordTypeHookMap[recordType])
+		hooks = append(hooks, recordTypeHookMap[recordType]...)
+		if recordType != AnyRecordType {
+			hooks = append(hooks, recordTypeHookMap[AnyRecordType]...)
+		}
+	}
 	return hooks, nil
 }
 
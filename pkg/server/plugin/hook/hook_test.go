@@ -20,6 +20,7 @@ import (
 
 	"github.com/skygeario/skygear-server/pkg/server/plugin/hook/hooktest"
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -125,6 +126,61 @@ func TestHookRegistry(t *testing.T) {
 			So(hook2.Context[0].Value(HelloContextKey), ShouldEqual, "world")
 		})
 
+		Convey("executes hooks of any record type after the record type", func() {
+			order := []string{}
+			registry.Register(AfterSave, AnyRecordType, func(ctx context.Context, record *skydb.Record, originalRecord *skydb.Record) skyerr.Error {
+				order = append(order, "any:"+record.ID.Type)
+				return nil
+			})
+			registry.Register(AfterSave, "note", func(ctx context.Context, record *skydb.Record, originalRecord *skydb.Record) skyerr.Error {
+				order = append(order, "note")
+				return nil
+			})
+
+			registry.ExecuteHooks(ctx, AfterSave, &skydb.Record{ID: skydb.NewRecordID("note", "id")}, nil)
+			registry.ExecuteHooks(ctx, AfterSave, &skydb.Record{ID: skydb.NewRecordID("comment", "id")}, nil)
+			So(order, ShouldResemble, []string{"note", "any:note", "any:comment"})
+		})
+
+		Convey("replaces hooks of a set", func() {
+			order := []string{}
+			hookFunc := func(name string) Func {
+				return func(ctx context.Context, record *skydb.Record, originalRecord *skydb.Record) skyerr.Error {
+					order = append(order, name)
+					return nil
+				}
+			}
+			registry.Register(AfterSave, "note", hookFunc("registry"))
+
+			set := NewRegistry()
+			set.Register(AfterSave, "note", hookFunc("old"))
+			registry.ReplaceSet("plugin", set)
+
+			record := &skydb.Record{ID: skydb.NewRecordID("note", "id")}
+			registry.ExecuteHooks(ctx, AfterSave, record, nil)
+			So(order, ShouldResemble, []string{"registry", "old"})
+
+			newSet := NewRegistry()
+			newSet.Register(AfterSave, "note", hookFunc("new"))
+			newSet.RegisterQueryHook("note", func(ctx context.Context, query *skydb.Query) skyerr.Error {
+				order = append(order, "query")
+				return nil
+			})
+			registry.ReplaceSet("plugin", newSet)
+
+			order = []string{}
+			registry.ExecuteHooks(ctx, AfterSave, record, nil)
+			registry.ExecuteQueryHooks(ctx, &skydb.Query{Type: "note"})
+			So(order, ShouldResemble, []string{"registry", "new", "query"})
+
+			registry.ReplaceSet("plugin", nil)
+
+			order = []string{}
+			registry.ExecuteHooks(ctx, AfterSave, record, nil)
+			registry.ExecuteQueryHooks(ctx, &skydb.Query{Type: "note"})
+			So(order, ShouldResemble, []string{"registry"})
+		})
+
 		Convey("executes no hooks", func() {
 			record := &skydb.Record{
 				ID: skydb.NewRecordID("record", "id"),
@@ -134,6 +190,93 @@ func TestHookRegistry(t *testing.T) {
 			}, ShouldNotPanic)
 		})
 
+		Convey("executes query hooks", func() {
+			registry.RegisterQueryHook("note", func(ctx context.Context, query *skydb.Query) skyerr.Error {
+				So(ctx.Value(HelloContextKey), ShouldEqual, "world")
+				query.Predicate = skydb.Predicate{
+					Operator: skydb.Equal,
+					Children: []interface{}{
+						skydb.Expression{Type: skydb.KeyPath, Value: "tenant"},
+						skydb.Expression{Type: skydb.Literal, Value: "skygear"},
+					},
+				}
+				return nil
+			})
+
+			query := skydb.Query{Type: "note"}
+			So(registry.ExecuteQueryHooks(ctx, &query), ShouldBeNil)
+			So(query.Predicate.Operator, ShouldEqual, skydb.Equal)
+
+			otherQuery := skydb.Query{Type: "comment"}
+			So(registry.ExecuteQueryHooks(ctx, &otherQuery), ShouldBeNil)
+			So(otherQuery.Predicate.IsEmpty(), ShouldBeTrue)
+		})
+
+		Convey("executes fetch hooks in order", func() {
+			record1 := &skydb.Record{ID: skydb.NewRecordID("note", "1"), Data: skydb.Data{}}
+			record2 := &skydb.Record{ID: skydb.NewRecordID("note", "2"), Data: skydb.Data{}}
+
+			registry.RegisterFetchHook("note", func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+				return records[:1], nil
+			})
+			registry.RegisterFetchHook("note", func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+				for _, record := range records {
+					record.Data["seen"] = true
+				}
+				return records, nil
+			})
+
+			records, err := registry.ExecuteFetchHooks(ctx, "note", []*skydb.Record{record1, record2})
+			So(err, ShouldBeNil)
+			So(records, ShouldResemble, []*skydb.Record{record1})
+			So(record1.Data["seen"], ShouldEqual, true)
+			So(record2.Data["seen"], ShouldBeNil)
+		})
+
+		Convey("returns error from fetch hooks", func() {
+			registry.RegisterFetchHook("note", func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, skyerr.Error) {
+				return nil, skyerr.NewError(skyerr.PermissionDenied, "denied")
+			})
+
+			_, err := registry.ExecuteFetchHooks(ctx, "note", []*skydb.Record{
+				&skydb.Record{ID: skydb.NewRecordID("note", "1")},
+			})
+			So(err, ShouldResemble, skyerr.NewError(skyerr.PermissionDenied, "denied"))
+		})
+
+		Convey("rejects query kinds in Register", func() {
+			So(registry.Register(BeforeQuery, "note", beforeSave.Func), ShouldNotBeNil)
+		})
+
+		Convey("executes auth hooks of the kind", func() {
+			authInfo := &skydb.AuthInfo{ID: "user0"}
+			user := &skydb.Record{ID: skydb.NewRecordID("user", "user0"), Data: skydb.Data{}}
+
+			So(registry.RegisterAuthHook(BeforeSignup, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				So(ctx.Value(HelloContextKey), ShouldEqual, "world")
+				user.Data["nickname"] = authInfo.ID
+				return nil
+			}), ShouldBeNil)
+			So(registry.RegisterAuthHook(BeforeLogin, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				return skyerr.NewError(skyerr.PermissionDenied, "login disallowed")
+			}), ShouldBeNil)
+
+			So(registry.ExecuteAuthHooks(ctx, BeforeSignup, authInfo, user), ShouldBeNil)
+			So(user.Data["nickname"], ShouldEqual, "user0")
+
+			err := registry.ExecuteAuthHooks(ctx, BeforeLogin, authInfo, user)
+			So(err, ShouldResemble, skyerr.NewError(skyerr.PermissionDenied, "login disallowed"))
+
+			So(registry.ExecuteAuthHooks(ctx, AfterLogin, authInfo, user), ShouldBeNil)
+		})
+
+		Convey("rejects non-auth kinds in RegisterAuthHook", func() {
+			So(registry.RegisterAuthHook(BeforeSave, func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+				return nil
+			}), ShouldNotBeNil)
+			So(registry.Register(BeforeSignup, "user", beforeSave.Func), ShouldNotBeNil)
+		})
+
 		Convey("panics executing nil record", func() {
 			So(func() {
 				registry.ExecuteHooks(ctx, AfterDelete, nil, nil)
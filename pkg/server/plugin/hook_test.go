@@ -21,14 +21,30 @@ import (
 
 	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
 type hookOnlyTransport struct {
-	RunHookFunc func(context.Context, string, *skydb.Record, *skydb.Record) (*skydb.Record, error)
+	RunHookFunc      func(context.Context, string, *skydb.Record, *skydb.Record) (*skydb.Record, error)
+	RunQueryHookFunc func(context.Context, string, *skydb.Query) (*skydb.Query, error)
+	RunFetchHookFunc func(context.Context, string, []*skydb.Record) ([]*skydb.Record, error)
+	RunAuthHookFunc  func(context.Context, string, *skydb.AuthInfo, *skydb.Record) (*skydb.Record, error)
 	Transport
 }
 
+func (t *hookOnlyTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	return t.RunAuthHookFunc(ctx, hookName, authInfo, user)
+}
+
+func (t *hookOnlyTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	return t.RunQueryHookFunc(ctx, hookName, query)
+}
+
+func (t *hookOnlyTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	return t.RunFetchHookFunc(ctx, hookName, records)
+}
+
 func (t *hookOnlyTransport) RunHook(ctx context.Context, hookName string, record *skydb.Record, originalRecord *skydb.Record, async bool) (*skydb.Record, error) {
 	return t.RunHookFunc(ctx, hookName, record, originalRecord)
 }
@@ -119,3 +135,133 @@ func TestCreateHookFunc(t *testing.T) {
 		})
 	})
 }
+
+func TestCreateQueryHookFunc(t *testing.T) {
+	Convey("CreateQueryHookFunc", t, func() {
+		transport := &hookOnlyTransport{}
+		plugin := Plugin{transport: transport}
+
+		predicate := skydb.Predicate{
+			Operator: skydb.Equal,
+			Children: []interface{}{
+				skydb.Expression{Type: skydb.KeyPath, Value: "tenant"},
+				skydb.Expression{Type: skydb.Literal, Value: "skygear"},
+			},
+		}
+
+		Convey("rewrites predicate", func() {
+			hookFunc := CreateQueryHookFunc(&plugin, pluginHookInfo{
+				Trigger: string(hook.BeforeQuery),
+				Type:    "note",
+				Name:    "note_beforeQuery",
+			})
+
+			transport.RunQueryHookFunc = func(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+				So(hookName, ShouldEqual, "note_beforeQuery")
+				queryout := *query
+				queryout.Predicate = predicate
+				return &queryout, nil
+			}
+
+			query := skydb.Query{Type: "note"}
+			So(hookFunc(nil, &query), ShouldBeNil)
+			So(query.Predicate, ShouldResemble, predicate)
+		})
+
+		Convey("returns error", func() {
+			hookFunc := CreateQueryHookFunc(&plugin, pluginHookInfo{
+				Trigger: string(hook.BeforeQuery),
+				Type:    "note",
+				Name:    "note_beforeQuery",
+			})
+
+			transport.RunQueryHookFunc = func(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+				return nil, errors.New("exit status 1")
+			}
+
+			query := skydb.Query{Type: "note"}
+			err := hookFunc(nil, &query)
+			So(err.Error(), ShouldEqual, "UnexpectedError: exit status 1")
+			So(query.Predicate.IsEmpty(), ShouldBeTrue)
+		})
+	})
+}
+
+func TestCreateFetchHookFunc(t *testing.T) {
+	Convey("CreateFetchHookFunc", t, func() {
+		transport := &hookOnlyTransport{}
+		plugin := Plugin{transport: transport}
+
+		hookFunc := CreateFetchHookFunc(&plugin, pluginHookInfo{
+			Trigger: string(hook.AfterFetch),
+			Type:    "note",
+			Name:    "note_afterFetch",
+		})
+
+		records := []*skydb.Record{
+			&skydb.Record{ID: skydb.NewRecordID("note", "1")},
+			&skydb.Record{ID: skydb.NewRecordID("note", "2")},
+		}
+
+		Convey("returns records from plugin", func() {
+			transport.RunFetchHookFunc = func(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+				So(hookName, ShouldEqual, "note_afterFetch")
+				return records[1:], nil
+			}
+
+			recordsout, err := hookFunc(nil, records)
+			So(err, ShouldBeNil)
+			So(recordsout, ShouldResemble, []*skydb.Record{records[1]})
+		})
+
+		Convey("returns error", func() {
+			transport.RunFetchHookFunc = func(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+				return nil, errors.New("exit status 1")
+			}
+
+			_, err := hookFunc(nil, records)
+			So(err.Error(), ShouldEqual, "UnexpectedError: exit status 1")
+		})
+	})
+}
+
+func TestCreateAuthHookFunc(t *testing.T) {
+	Convey("CreateAuthHookFunc", t, func() {
+		transport := &hookOnlyTransport{}
+		plugin := Plugin{transport: transport}
+
+		hookFunc := CreateAuthHookFunc(&plugin, pluginHookInfo{
+			Trigger: string(hook.BeforeSignup),
+			Name:    "default_nickname",
+		})
+
+		authInfo := &skydb.AuthInfo{ID: "user0"}
+		user := &skydb.Record{
+			ID:   skydb.NewRecordID("user", "user0"),
+			Data: skydb.Data{},
+		}
+
+		Convey("updates user data", func() {
+			transport.RunAuthHookFunc = func(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+				So(hookName, ShouldEqual, "default_nickname")
+				So(authInfo.ID, ShouldEqual, "user0")
+				userout := *user
+				userout.Data = skydb.Data{"nickname": "user0"}
+				return &userout, nil
+			}
+
+			So(hookFunc(nil, authInfo, user), ShouldBeNil)
+			So(user.Data, ShouldResemble, skydb.Data{"nickname": "user0"})
+		})
+
+		Convey("returns error", func() {
+			transport.RunAuthHookFunc = func(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+				return nil, skyerr.NewError(skyerr.PermissionDenied, "signup disallowed")
+			}
+
+			err := hookFunc(nil, authInfo, user)
+			So(err, ShouldResemble, skyerr.NewError(skyerr.PermissionDenied, "signup disallowed"))
+			So(user.Data, ShouldResemble, skydb.Data{})
+		})
+	})
+}
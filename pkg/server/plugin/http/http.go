@@ -151,6 +151,34 @@ func (p *httpTransport) RunHook(ctx context.Context, hookName string, record *sk
 	return &recordout, nil
 }
 
+func (p *httpTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	req, err := pluginrequest.NewQueryHookRequest(ctx, hookName, query)
+	if err != nil {
+		return nil, err
+	}
+	out, err := p.rpc(req)
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseQueryHookResult(ctx, out, query)
+}
+
+func (p *httpTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	out, err := p.rpc(pluginrequest.NewFetchHookRequest(ctx, hookName, records))
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseFetchHookResult(out, records)
+}
+
+func (p *httpTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	out, err := p.rpc(pluginrequest.NewAuthHookRequest(ctx, hookName, authInfo, user))
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseAuthHookResult(out, user)
+}
+
 func (p *httpTransport) RunTimer(name string, in []byte) (out []byte, err error) {
 	req := pluginrequest.NewTimerRequest(name)
 	out, err = p.rpc(req)
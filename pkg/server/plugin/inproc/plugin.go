@@ -0,0 +1,310 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inproc implements a plugin transport for plugins written in Go
+// and compiled into the server binary. Hooks, lambdas, handlers, timers and
+// auth providers are registered with a Plugin and called directly, without
+// an external process or serializing records over IPC.
+//
+// A plugin registers itself by name, typically from an init function:
+//
+//	func init() {
+//		p := inproc.NewPlugin()
+//		p.Hook(hook.BeforeSave, "note", "sanitize_note", sanitizeNote)
+//		p.Lambda("hello", hello, inproc.LambdaOptions{})
+//		inproc.Register("notes", p)
+//	}
+//
+// It is then enabled like any other plugin, with the transport set to
+// inproc and the path set to the registered name:
+//
+//	PLUGINS=NOTES
+//	NOTES_TRANSPORT=inproc
+//	NOTES_PATH=notes
+//
+// The server registers the functions into the router, hook registry and
+// provider registry with the same semantics as an external plugin.
+package inproc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/provider"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+// HookFunc is a record hook. The record may be modified by a beforeSave
+// hook and the modification is saved. originalRecord is nil if the record
+// is newly created and must not be modified.
+type HookFunc func(ctx context.Context, record *skydb.Record, originalRecord *skydb.Record) error
+
+// QueryHookFunc is a beforeQuery hook, which may rewrite the predicate of
+// the query.
+type QueryHookFunc func(ctx context.Context, query *skydb.Query) error
+
+// FetchHookFunc is an afterFetch hook. It returns the records to be
+// returned to the client, which may be modified. Records not returned are
+// dropped.
+type FetchHookFunc func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, error)
+
+// AuthHookFunc is an auth hook. A before hook may reject the action by
+// returning an error. The data of the user record may be modified and the
+// modification is saved. user is nil if the user record is not available.
+type AuthHookFunc func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) error
+
+// LambdaFunc is a lambda function. params is the decoded JSON of the lambda
+// arguments and the returned value is encoded as JSON in the response.
+type LambdaFunc func(ctx context.Context, params interface{}) (interface{}, error)
+
+// TimerFunc is a function run by the scheduler.
+type TimerFunc func(ctx context.Context) error
+
+// LambdaOptions specifies the access requirement of a lambda.
+type LambdaOptions struct {
+	KeyRequired  bool
+	UserRequired bool
+}
+
+// HandlerOptions specifies the HTTP methods and the access requirement of
+// a handler.
+type HandlerOptions struct {
+	Methods      []string
+	KeyRequired  bool
+	UserRequired bool
+}
+
+type hookEntry struct {
+	kind       hook.Kind
+	recordType string
+	async      bool
+	fn         HookFunc
+	queryFn    QueryHookFunc
+	fetchFn    FetchHookFunc
+	authFn     AuthHookFunc
+}
+
+type lambdaEntry struct {
+	options LambdaOptions
+	fn      LambdaFunc
+}
+
+type handlerEntry struct {
+	options HandlerOptions
+	handler http.Handler
+}
+
+type timerEntry struct {
+	spec string
+	fn   TimerFunc
+}
+
+// Plugin is a collection of functions implementing a plugin in process.
+//
+// Functions should be registered before the server starts; names of each
+// kind of function must be unique within a plugin.
+type Plugin struct {
+	hookNames    []string
+	hooks        map[string]hookEntry
+	lambdaNames  []string
+	lambdas      map[string]lambdaEntry
+	handlerNames []string
+	handlers     map[string]handlerEntry
+	timerNames   []string
+	timers       map[string]timerEntry
+	authNames    []string
+	auths        map[string]provider.AuthProvider
+}
+
+// NewPlugin returns an empty Plugin.
+func NewPlugin() *Plugin {
+	return &Plugin{
+		hooks:    map[string]hookEntry{},
+		lambdas:  map[string]lambdaEntry{},
+		handlers: map[string]handlerEntry{},
+		timers:   map[string]timerEntry{},
+		auths:    map[string]provider.AuthProvider{},
+	}
+}
+
+// Hook registers a hook of the specified kind on records of recordType.
+func (p *Plugin) Hook(kind hook.Kind, recordType string, name string, fn HookFunc) {
+	p.addHook(name, hookEntry{kind: kind, recordType: recordType, fn: fn})
+}
+
+// AsyncHook registers a hook that is run after the response is returned.
+// Errors and modifications to the record are ignored.
+func (p *Plugin) AsyncHook(kind hook.Kind, recordType string, name string, fn HookFunc) {
+	p.addHook(name, hookEntry{kind: kind, recordType: recordType, async: true, fn: fn})
+}
+
+// QueryHook registers a beforeQuery hook on queries of recordType.
+func (p *Plugin) QueryHook(recordType string, name string, fn QueryHookFunc) {
+	p.addHook(name, hookEntry{kind: hook.BeforeQuery, recordType: recordType, queryFn: fn})
+}
+
+// FetchHook registers an afterFetch hook on records of recordType.
+func (p *Plugin) FetchHook(recordType string, name string, fn FetchHookFunc) {
+	p.addHook(name, hookEntry{kind: hook.AfterFetch, recordType: recordType, fetchFn: fn})
+}
+
+// AuthHook registers an auth hook of the specified kind, such as
+// hook.BeforeSignup.
+func (p *Plugin) AuthHook(kind hook.Kind, name string, fn AuthHookFunc) {
+	if !hook.IsAuthKind(kind) {
+		panic(fmt.Errorf(`"%s" is not a kind of auth hook`, kind))
+	}
+	p.addHook(name, hookEntry{kind: kind, authFn: fn})
+}
+
+func (p *Plugin) addHook(name string, entry hookEntry) {
+	if _, ok := p.hooks[name]; ok {
+		panic(fmt.Errorf(`hook "%s" is already registered`, name))
+	}
+	p.hookNames = append(p.hookNames, name)
+	p.hooks[name] = entry
+}
+
+// Lambda registers a lambda callable by the action name.
+func (p *Plugin) Lambda(name string, fn LambdaFunc, options LambdaOptions) {
+	if _, ok := p.lambdas[name]; ok {
+		panic(fmt.Errorf(`lambda "%s" is already registered`, name))
+	}
+	p.lambdaNames = append(p.lambdaNames, name)
+	p.lambdas[name] = lambdaEntry{options, fn}
+}
+
+// Handler registers an HTTP handler served at the path name. The request
+// passed to the handler carries the context of the server request.
+func (p *Plugin) Handler(name string, handler http.Handler, options HandlerOptions) {
+	if _, ok := p.handlers[name]; ok {
+		panic(fmt.Errorf(`handler "%s" is already registered`, name))
+	}
+	p.handlerNames = append(p.handlerNames, name)
+	p.handlers[name] = handlerEntry{options, handler}
+}
+
+// Timer registers a function run on the cron spec.
+func (p *Plugin) Timer(name string, spec string, fn TimerFunc) {
+	if _, ok := p.timers[name]; ok {
+		panic(fmt.Errorf(`timer "%s" is already registered`, name))
+	}
+	p.timerNames = append(p.timerNames, name)
+	p.timers[name] = timerEntry{spec, fn}
+}
+
+// AuthProvider registers an auth provider. The principal ID returned by
+// Login is prefixed with the provider name by the server.
+func (p *Plugin) AuthProvider(name string, authProvider provider.AuthProvider) {
+	if _, ok := p.auths[name]; ok {
+		panic(fmt.Errorf(`auth provider "%s" is already registered`, name))
+	}
+	p.authNames = append(p.authNames, name)
+	p.auths[name] = authProvider
+}
+
+// registrationInfo returns the registration info of the plugin in the
+// format sent by an external plugin on init.
+func (p *Plugin) registrationInfo() map[string]interface{} {
+	handlers := []map[string]interface{}{}
+	for _, name := range p.handlerNames {
+		options := p.handlers[name].options
+		methods := options.Methods
+		if len(methods) == 0 {
+			methods = []string{"GET", "POST", "PUT"}
+		}
+		handlers = append(handlers, map[string]interface{}{
+			"name":          name,
+			"methods":       methods,
+			"key_required":  options.KeyRequired,
+			"user_required": options.UserRequired,
+		})
+	}
+
+	hooks := []map[string]interface{}{}
+	for _, name := range p.hookNames {
+		entry := p.hooks[name]
+		hooks = append(hooks, map[string]interface{}{
+			"name":    name,
+			"trigger": string(entry.kind),
+			"type":    entry.recordType,
+			"async":   entry.async,
+		})
+	}
+
+	lambdas := []map[string]interface{}{}
+	for _, name := range p.lambdaNames {
+		options := p.lambdas[name].options
+		lambdas = append(lambdas, map[string]interface{}{
+			"name":          name,
+			"key_required":  options.KeyRequired,
+			"user_required": options.UserRequired,
+		})
+	}
+
+	timers := []map[string]interface{}{}
+	for _, name := range p.timerNames {
+		timers = append(timers, map[string]interface{}{
+			"name": name,
+			"spec": p.timers[name].spec,
+		})
+	}
+
+	providers := []map[string]interface{}{}
+	for _, name := range p.authNames {
+		providers = append(providers, map[string]interface{}{
+			"type": "auth",
+			"id":   name,
+		})
+	}
+
+	return map[string]interface{}{
+		"handler":  handlers,
+		"hook":     hooks,
+		"op":       lambdas,
+		"timer":    timers,
+		"provider": providers,
+	}
+}
+
+var (
+	pluginsMutex sync.RWMutex
+	plugins      = map[string]*Plugin{}
+)
+
+// Register makes a plugin available to the inproc transport by name.
+func Register(name string, p *Plugin) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	if _, ok := plugins[name]; ok {
+		panic(fmt.Errorf(`in-process plugin "%s" is already registered`, name))
+	}
+	plugins[name] = p
+}
+
+func getPlugin(name string) (*Plugin, bool) {
+	pluginsMutex.RLock()
+	defer pluginsMutex.RUnlock()
+	p, ok := plugins[name]
+	return p, ok
+}
+
+func unregisterAllPlugins() {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+	plugins = map[string]*Plugin{}
+}
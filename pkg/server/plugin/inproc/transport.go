@@ -0,0 +1,335 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inproc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/skyconfig"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var log = logging.LoggerEntryWithTag("plugin", "plugin")
+
+// handlerPayload is the request and response of a handler, in the same
+// format exchanged with an external plugin.
+type handlerPayload struct {
+	Status      int                 `json:"status"`
+	Method      string              `json:"method,omitempty"`
+	Header      map[string][]string `json:"header"`
+	Body        []byte              `json:"body"`
+	Path        string              `json:"path,omitempty"`
+	QueryString string              `json:"query_string,omitempty"`
+}
+
+type inprocTransport struct {
+	plugin *Plugin
+	config skyconfig.Configuration
+	logger *logrus.Entry
+
+	stateMutex sync.RWMutex
+	state      skyplugin.TransportState
+}
+
+func (p *inprocTransport) State() skyplugin.TransportState {
+	p.stateMutex.RLock()
+	defer p.stateMutex.RUnlock()
+	return p.state
+}
+
+func (p *inprocTransport) SetState(state skyplugin.TransportState) {
+	p.stateMutex.Lock()
+	defer p.stateMutex.Unlock()
+	if state != p.state {
+		oldState := p.state
+		p.state = state
+		p.logger.Infof("Transport state changes from %v to %v.", oldState, p.state)
+	}
+}
+
+func (p *inprocTransport) SendEvent(name string, in []byte) ([]byte, error) {
+	if name == "init" {
+		return json.Marshal(p.plugin.registrationInfo())
+	}
+	return nil, nil
+}
+
+func (p *inprocTransport) RunLambda(ctx context.Context, name string, in []byte) ([]byte, error) {
+	entry, ok := p.plugin.lambdas[name]
+	if !ok {
+		return nil, fmt.Errorf(`lambda "%s" is not registered`, name)
+	}
+
+	var params interface{}
+	if len(in) > 0 {
+		if err := json.Unmarshal(in, &params); err != nil {
+			return nil, fmt.Errorf("failed to parse lambda params: %v", err)
+		}
+	}
+
+	result, err := entry.fn(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+func (p *inprocTransport) RunHandler(ctx context.Context, name string, in []byte) ([]byte, error) {
+	entry, ok := p.plugin.handlers[name]
+	if !ok {
+		return nil, fmt.Errorf(`handler "%s" is not registered`, name)
+	}
+
+	var reqPayload handlerPayload
+	if err := json.Unmarshal(in, &reqPayload); err != nil {
+		return nil, fmt.Errorf("failed to parse handler request: %v", err)
+	}
+
+	req, err := http.NewRequest(
+		reqPayload.Method,
+		(&url.URL{Path: reqPayload.Path, RawQuery: reqPayload.QueryString}).String(),
+		bytes.NewReader(reqPayload.Body),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = http.Header(reqPayload.Header)
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	w := newResponseRecorder()
+	entry.handler.ServeHTTP(w, req.WithContext(ctx))
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return json.Marshal(handlerPayload{
+		Status: w.status,
+		Header: w.header,
+		Body:   w.body.Bytes(),
+	})
+}
+
+func (p *inprocTransport) RunHook(ctx context.Context, hookName string, record *skydb.Record, originalRecord *skydb.Record, async bool) (*skydb.Record, error) {
+	entry, ok := p.plugin.hooks[hookName]
+	if !ok || entry.fn == nil {
+		return nil, fmt.Errorf(`hook "%s" is not registered`, hookName)
+	}
+
+	recordout := record.Copy()
+	if record.ACL != nil {
+		recordout.ACL = append(skydb.RecordACL{}, record.ACL...)
+	}
+
+	var originalCopy *skydb.Record
+	if originalRecord != nil {
+		copied := originalRecord.Copy()
+		originalCopy = &copied
+	}
+
+	if err := entry.fn(ctx, &recordout, originalCopy); err != nil {
+		return nil, err
+	}
+
+	// A hook may only modify the content of the record, as for an
+	// external plugin.
+	recordout.ID = record.ID
+	recordout.DatabaseID = record.DatabaseID
+	recordout.OwnerID = record.OwnerID
+	recordout.CreatedAt = record.CreatedAt
+	recordout.CreatorID = record.CreatorID
+	recordout.UpdatedAt = record.UpdatedAt
+	recordout.UpdaterID = record.UpdaterID
+
+	return &recordout, nil
+}
+
+func (p *inprocTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	entry, ok := p.plugin.hooks[hookName]
+	if !ok || entry.queryFn == nil {
+		return nil, fmt.Errorf(`query hook "%s" is not registered`, hookName)
+	}
+
+	queryout := *query
+	if err := entry.queryFn(ctx, &queryout); err != nil {
+		return nil, err
+	}
+	return &queryout, nil
+}
+
+func (p *inprocTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	entry, ok := p.plugin.hooks[hookName]
+	if !ok || entry.fetchFn == nil {
+		return nil, fmt.Errorf(`fetch hook "%s" is not registered`, hookName)
+	}
+
+	recordMap := map[skydb.RecordID]*skydb.Record{}
+	copies := make([]*skydb.Record, len(records))
+	for i, record := range records {
+		recordMap[record.ID] = record
+		copied := record.Copy()
+		copies[i] = &copied
+	}
+
+	recordsout, err := entry.fetchFn(ctx, copies)
+	if err != nil {
+		return nil, err
+	}
+
+	// As for an external plugin, a hook may only return the content of
+	// the records passed to it.
+	for _, recordout := range recordsout {
+		record, ok := recordMap[recordout.ID]
+		if !ok {
+			return nil, fmt.Errorf("hook returned record %s which is not fetched", recordout.ID)
+		}
+		recordout.OwnerID = record.OwnerID
+		recordout.CreatedAt = record.CreatedAt
+		recordout.CreatorID = record.CreatorID
+		recordout.UpdatedAt = record.UpdatedAt
+		recordout.UpdaterID = record.UpdaterID
+		recordout.DatabaseID = record.DatabaseID
+	}
+	return recordsout, nil
+}
+
+func (p *inprocTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	entry, ok := p.plugin.hooks[hookName]
+	if !ok || entry.authFn == nil {
+		return nil, fmt.Errorf(`auth hook "%s" is not registered`, hookName)
+	}
+
+	var authInfoCopy *skydb.AuthInfo
+	if authInfo != nil {
+		copied := *authInfo
+		authInfoCopy = &copied
+	}
+
+	var userCopy *skydb.Record
+	if user != nil {
+		copied := user.Copy()
+		userCopy = &copied
+	}
+
+	if err := entry.authFn(ctx, authInfoCopy, userCopy); err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	// Only the data of the user record may be modified by the hook.
+	userout := *user
+	userout.Data = userCopy.Data
+	return &userout, nil
+}
+
+func (p *inprocTransport) RunTimer(name string, in []byte) ([]byte, error) {
+	entry, ok := p.plugin.timers[name]
+	if !ok {
+		return nil, fmt.Errorf(`timer "%s" is not registered`, name)
+	}
+
+	if err := entry.fn(context.Background()); err != nil {
+		p.logger.WithError(err).WithField("timer", name).Error("Timer returned an error")
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (p *inprocTransport) RunProvider(ctx context.Context, request *skyplugin.AuthRequest) (*skyplugin.AuthResponse, error) {
+	authProvider, ok := p.plugin.auths[request.ProviderName]
+	if !ok {
+		return nil, fmt.Errorf(`auth provider "%s" is not registered`, request.ProviderName)
+	}
+
+	resp := skyplugin.AuthResponse{}
+	var err error
+	switch request.Action {
+	case "login":
+		resp.PrincipalID, resp.AuthData, err = authProvider.Login(ctx, request.AuthData)
+	case "logout":
+		resp.AuthData, err = authProvider.Logout(ctx, request.AuthData)
+	case "info":
+		resp.AuthData, err = authProvider.Info(ctx, request.AuthData)
+	default:
+		err = fmt.Errorf(`unknown auth provider action "%s"`, request.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// responseRecorder collects the response written by a handler.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{
+		header: http.Header{},
+	}
+}
+
+func (w *responseRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+type inprocTransportFactory struct {
+}
+
+func (f inprocTransportFactory) Open(path string, args []string, config skyconfig.Configuration) skyplugin.Transport {
+	p, ok := getPlugin(path)
+	if !ok {
+		panic(fmt.Errorf(`unable to find in-process plugin "%s"`, path))
+	}
+
+	return &inprocTransport{
+		plugin: p,
+		config: config,
+		logger: log.WithField("plugin", path),
+		state:  skyplugin.TransportStateUninitialized,
+	}
+}
+
+func init() {
+	skyplugin.RegisterTransport("inproc", inprocTransportFactory{})
+}
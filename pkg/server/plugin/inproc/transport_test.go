@@ -0,0 +1,396 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inproc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyconfig"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testAuthProvider struct{}
+
+func (p *testAuthProvider) Login(ctx context.Context, authData map[string]interface{}) (string, map[string]interface{}, error) {
+	token, _ := authData["token"].(string)
+	if token == "" {
+		return "", nil, errors.New("missing token")
+	}
+	return "user-" + token, map[string]interface{}{"token": token}, nil
+}
+
+func (p *testAuthProvider) Logout(ctx context.Context, authData map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (p *testAuthProvider) Info(ctx context.Context, authData map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"name": "John"}, nil
+}
+
+func TestInprocTransport(t *testing.T) {
+	Convey("inproc transport", t, func() {
+		timerRun := 0
+		p := NewPlugin()
+		p.Hook(hook.BeforeSave, "note", "add_title", func(ctx context.Context, record *skydb.Record, originalRecord *skydb.Record) error {
+			record.Data["title"] = "untitled"
+			record.OwnerID = "hacker"
+			return nil
+		})
+		p.AsyncHook(hook.AfterSave, "note", "notify", func(ctx context.Context, record *skydb.Record, originalRecord *skydb.Record) error {
+			return nil
+		})
+		p.Hook(hook.BeforeDelete, "note", "forbid_delete", func(ctx context.Context, record *skydb.Record, originalRecord *skydb.Record) error {
+			return skyerr.NewError(skyerr.PermissionDenied, "cannot delete note")
+		})
+		p.Lambda("hello", func(ctx context.Context, params interface{}) (interface{}, error) {
+			userID, _ := ctx.Value(router.UserIDContextKey).(string)
+			args := params.([]interface{})
+			return map[string]interface{}{
+				"message": fmt.Sprintf("hello %s", args[0]),
+				"user_id": userID,
+			}, nil
+		}, LambdaOptions{UserRequired: true})
+		p.Handler("pkg:status", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, "%s %s?%s %s", r.Method, r.URL.Path, r.URL.RawQuery, body)
+		}), HandlerOptions{Methods: []string{"POST"}, KeyRequired: true})
+		p.Timer("cleanup", "0 * * * * *", func(ctx context.Context) error {
+			timerRun++
+			return nil
+		})
+		p.AuthProvider("com.example", &testAuthProvider{})
+
+		Register("test", p)
+		defer unregisterAllPlugins()
+
+		transport := inprocTransportFactory{}.Open("test", []string{}, skyconfig.Configuration{})
+
+		Convey("returns registration info on init", func() {
+			out, err := transport.SendEvent("init", []byte(`{}`))
+			So(err, ShouldBeNil)
+			So(out, ShouldEqualJSON, `{
+				"handler": [{
+					"name": "pkg:status",
+					"methods": ["POST"],
+					"key_required": true,
+					"user_required": false
+				}],
+				"hook": [{
+					"name": "add_title",
+					"trigger": "beforeSave",
+					"type": "note",
+					"async": false
+				}, {
+					"name": "notify",
+					"trigger": "afterSave",
+					"type": "note",
+					"async": true
+				}, {
+					"name": "forbid_delete",
+					"trigger": "beforeDelete",
+					"type": "note",
+					"async": false
+				}],
+				"op": [{
+					"name": "hello",
+					"key_required": false,
+					"user_required": true
+				}],
+				"timer": [{
+					"name": "cleanup",
+					"spec": "0 * * * * *"
+				}],
+				"provider": [{
+					"type": "auth",
+					"id": "com.example"
+				}]
+			}`)
+		})
+
+		Convey("ignores other events", func() {
+			out, err := transport.SendEvent("after-config", []byte(`{}`))
+			So(err, ShouldBeNil)
+			So(out, ShouldBeNil)
+		})
+
+		Convey("sets state", func() {
+			So(transport.State(), ShouldEqual, skyplugin.TransportStateUninitialized)
+			transport.SetState(skyplugin.TransportStateReady)
+			So(transport.State(), ShouldEqual, skyplugin.TransportStateReady)
+		})
+
+		Convey("runs hook on a copy of the record", func() {
+			record := skydb.Record{
+				ID:      skydb.NewRecordID("note", "id"),
+				OwnerID: "john.doe@example.com",
+				Data:    skydb.Data{"content": "hello"},
+			}
+			recordout, err := transport.RunHook(context.Background(), "add_title", &record, nil, false)
+			So(err, ShouldBeNil)
+			So(recordout, ShouldNotPointTo, &record)
+			So(recordout.Data, ShouldResemble, skydb.Data{
+				"content": "hello",
+				"title":   "untitled",
+			})
+			So(recordout.OwnerID, ShouldEqual, "john.doe@example.com")
+			So(record.Data, ShouldResemble, skydb.Data{"content": "hello"})
+		})
+
+		Convey("returns error from hook", func() {
+			record := skydb.Record{
+				ID:   skydb.NewRecordID("note", "id"),
+				Data: skydb.Data{},
+			}
+			_, err := transport.RunHook(context.Background(), "forbid_delete", &record, nil, false)
+			So(err, ShouldResemble, skyerr.NewError(skyerr.PermissionDenied, "cannot delete note"))
+		})
+
+		Convey("runs lambda with context", func() {
+			ctx := context.WithValue(context.Background(), router.UserIDContextKey, "user-id")
+			out, err := transport.RunLambda(ctx, "hello", []byte(`["world"]`))
+			So(err, ShouldBeNil)
+			So(out, ShouldEqualJSON, `{"message": "hello world", "user_id": "user-id"}`)
+		})
+
+		Convey("returns error for unknown lambda", func() {
+			_, err := transport.RunLambda(context.Background(), "unknown", []byte(`[]`))
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("runs handler", func() {
+			in, _ := json.Marshal(handlerPayload{
+				Method:      "POST",
+				Path:        "/pkg/status",
+				QueryString: "verbose=1",
+				Header:      map[string][]string{"X-Test": {"1"}},
+				Body:        []byte("ping"),
+			})
+			out, err := transport.RunHandler(context.Background(), "pkg:status", in)
+			So(err, ShouldBeNil)
+
+			resp := handlerPayload{}
+			So(json.Unmarshal(out, &resp), ShouldBeNil)
+			So(resp.Status, ShouldEqual, http.StatusCreated)
+			So(resp.Header["Content-Type"], ShouldResemble, []string{"text/plain"})
+			So(string(resp.Body), ShouldEqual, "POST /pkg/status?verbose=1 ping")
+		})
+
+		Convey("runs timer", func() {
+			_, err := transport.RunTimer("cleanup", []byte{})
+			So(err, ShouldBeNil)
+			So(timerRun, ShouldEqual, 1)
+		})
+
+		Convey("runs auth provider", func() {
+			resp, err := transport.RunProvider(context.Background(), &skyplugin.AuthRequest{
+				ProviderName: "com.example",
+				Action:       "login",
+				AuthData:     map[string]interface{}{"token": "abc"},
+			})
+			So(err, ShouldBeNil)
+			So(resp, ShouldResemble, &skyplugin.AuthResponse{
+				PrincipalID: "user-abc",
+				AuthData:    map[string]interface{}{"token": "abc"},
+			})
+
+			_, err = transport.RunProvider(context.Background(), &skyplugin.AuthRequest{
+				ProviderName: "com.example",
+				Action:       "login",
+				AuthData:     map[string]interface{}{},
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("inproc plugin", t, func() {
+		Convey("panics on duplicated names", func() {
+			p := NewPlugin()
+			p.Lambda("hello", nil, LambdaOptions{})
+			So(func() {
+				p.Lambda("hello", nil, LambdaOptions{})
+			}, ShouldPanic)
+		})
+
+		Convey("panics when opening an unregistered plugin", func() {
+			So(func() {
+				inprocTransportFactory{}.Open("missing", []string{}, skyconfig.Configuration{})
+			}, ShouldPanic)
+		})
+	})
+}
+
+func TestInprocQueryHooks(t *testing.T) {
+	Convey("inproc query hooks", t, func() {
+		p := NewPlugin()
+		p.QueryHook("note", "tenant_filter", func(ctx context.Context, query *skydb.Query) error {
+			query.Predicate = skydb.Predicate{
+				Operator: skydb.Equal,
+				Children: []interface{}{
+					skydb.Expression{Type: skydb.KeyPath, Value: "tenant"},
+					skydb.Expression{Type: skydb.Literal, Value: "skygear"},
+				},
+			}
+			return nil
+		})
+		p.FetchHook("note", "redact", func(ctx context.Context, records []*skydb.Record) ([]*skydb.Record, error) {
+			recordsout := []*skydb.Record{}
+			for _, record := range records {
+				if record.Data["secret"] == true {
+					continue
+				}
+				delete(record.Data, "internal")
+				record.OwnerID = "hacker"
+				recordsout = append(recordsout, record)
+			}
+			return recordsout, nil
+		})
+
+		Register("query", p)
+		defer unregisterAllPlugins()
+
+		transport := inprocTransportFactory{}.Open("query", []string{}, skyconfig.Configuration{})
+
+		Convey("returns registration info on init", func() {
+			out, err := transport.SendEvent("init", []byte(`{}`))
+			So(err, ShouldBeNil)
+			So(out, ShouldEqualJSON, `{
+				"handler": [],
+				"hook": [{
+					"name": "tenant_filter",
+					"trigger": "beforeQuery",
+					"type": "note",
+					"async": false
+				}, {
+					"name": "redact",
+					"trigger": "afterFetch",
+					"type": "note",
+					"async": false
+				}],
+				"op": [],
+				"timer": [],
+				"provider": []
+			}`)
+		})
+
+		Convey("runs query hook on a copy of the query", func() {
+			query := skydb.Query{Type: "note"}
+			queryout, err := transport.RunQueryHook(context.Background(), "tenant_filter", &query)
+			So(err, ShouldBeNil)
+			So(queryout.Predicate.Operator, ShouldEqual, skydb.Equal)
+			So(query.Predicate.IsEmpty(), ShouldBeTrue)
+		})
+
+		Convey("runs fetch hook on copies of the records", func() {
+			record1 := &skydb.Record{
+				ID:      skydb.NewRecordID("note", "1"),
+				OwnerID: "john.doe@example.com",
+				Data:    skydb.Data{"internal": "x"},
+			}
+			record2 := &skydb.Record{
+				ID:   skydb.NewRecordID("note", "2"),
+				Data: skydb.Data{"secret": true},
+			}
+			recordsout, err := transport.RunFetchHook(context.Background(), "redact", []*skydb.Record{record1, record2})
+			So(err, ShouldBeNil)
+			So(len(recordsout), ShouldEqual, 1)
+			So(recordsout[0].ID, ShouldResemble, record1.ID)
+			So(recordsout[0].OwnerID, ShouldEqual, "john.doe@example.com")
+			So(recordsout[0].Data, ShouldResemble, skydb.Data{})
+			So(record1.Data, ShouldResemble, skydb.Data{"internal": "x"})
+		})
+	})
+}
+
+func TestInprocAuthHooks(t *testing.T) {
+	Convey("inproc auth hooks", t, func() {
+		p := NewPlugin()
+		p.AuthHook(hook.BeforeSignup, "default_nickname", func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) error {
+			user.Data["nickname"] = "user-" + authInfo.ID
+			user.OwnerID = "hacker"
+			authInfo.Roles = []string{"admin"}
+			return nil
+		})
+		p.AuthHook(hook.BeforeLogin, "deny_login", func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) error {
+			return skyerr.NewError(skyerr.PermissionDenied, "login disallowed")
+		})
+
+		Register("auth", p)
+		defer unregisterAllPlugins()
+
+		transport := inprocTransportFactory{}.Open("auth", []string{}, skyconfig.Configuration{})
+
+		Convey("returns registration info on init", func() {
+			out, err := transport.SendEvent("init", []byte(`{}`))
+			So(err, ShouldBeNil)
+			So(out, ShouldEqualJSON, `{
+				"handler": [],
+				"hook": [{
+					"name": "default_nickname",
+					"trigger": "beforeSignup",
+					"type": "",
+					"async": false
+				}, {
+					"name": "deny_login",
+					"trigger": "beforeLogin",
+					"type": "",
+					"async": false
+				}],
+				"op": [],
+				"timer": [],
+				"provider": []
+			}`)
+		})
+
+		Convey("runs auth hook on copies of auth info and user", func() {
+			authInfo := &skydb.AuthInfo{ID: "0"}
+			user := &skydb.Record{
+				ID:      skydb.NewRecordID("user", "0"),
+				OwnerID: "0",
+				Data:    skydb.Data{},
+			}
+			userout, err := transport.RunAuthHook(context.Background(), "default_nickname", authInfo, user)
+			So(err, ShouldBeNil)
+			So(userout.Data, ShouldResemble, skydb.Data{"nickname": "user-0"})
+			So(userout.OwnerID, ShouldEqual, "0")
+			So(user.Data, ShouldResemble, skydb.Data{})
+			So(authInfo.Roles, ShouldBeNil)
+		})
+
+		Convey("returns error from auth hook", func() {
+			_, err := transport.RunAuthHook(context.Background(), "deny_login", &skydb.AuthInfo{ID: "0"}, nil)
+			So(err, ShouldResemble, skyerr.NewError(skyerr.PermissionDenied, "login disallowed"))
+		})
+
+		Convey("panics registering non-auth kind", func() {
+			So(func() {
+				p.AuthHook(hook.BeforeSave, "invalid", nil)
+			}, ShouldPanic)
+		})
+	})
+}
@@ -0,0 +1,360 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/skygeario/skygear-server/pkg/server/recordutil"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+// dbSession is the database access of an invocation, made on behalf of
+// the user in the context of the invocation.
+type dbSession struct {
+	conn          skydb.Conn
+	authInfo      *skydb.AuthInfo
+	withMasterKey bool
+}
+
+func (p *jsTransport) openSession(ctx context.Context) (*dbSession, error) {
+	withMasterKey := ctx.Value(router.AccessKeyTypeContextKey) == router.MasterAccessKey
+	dbConfig := skydb.DBConfig{
+		CanMigrate: p.config.App.DevMode || withMasterKey,
+	}
+	conn, err := p.dbOpener(ctx, p.config.DB.ImplName, p.config.App.Name, p.config.App.AccessControl, p.config.DB.Option, dbConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &dbSession{
+		conn:          conn,
+		withMasterKey: withMasterKey,
+	}
+	if userID, ok := ctx.Value(router.UserIDContextKey).(string); ok && userID != "" {
+		authInfo := skydb.AuthInfo{}
+		if err := conn.GetAuth(userID, &authInfo); err == nil {
+			session.authInfo = &authInfo
+		} else if err != skydb.ErrUserNotFound {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// requireUser returns an error when the session is not made on behalf of
+// a user, which is required to modify records.
+func (s *dbSession) requireUser() skyerr.Error {
+	if s.authInfo == nil {
+		return skyerr.NewError(
+			skyerr.NotAuthenticated,
+			"User is required for this action, please login.",
+		)
+	}
+	return nil
+}
+
+// withSession runs fn with the database session, which is opened on first
+// use. An error returned by fn is thrown to the script.
+func (rt *scriptRuntime) withSession(fn func(*dbSession) skyerr.Error) {
+	rt.limiter.enterNative()
+	err := func() skyerr.Error {
+		if rt.session == nil {
+			session, err := rt.transport.openSession(rt.ctx)
+			if err != nil {
+				return skyerr.NewError(skyerr.UnexpectedUnableToOpenDatabase, err.Error())
+			}
+			rt.session = session
+		}
+		return fn(rt.session)
+	}()
+	rt.limiter.exitNative()
+
+	if err != nil {
+		rt.throw(err)
+	}
+}
+
+func (rt *scriptRuntime) jsonRecordValue(record *skyconv.JSONRecord) goja.Value {
+	data, err := json.Marshal(record)
+	if err != nil {
+		rt.throw(skyerr.MakeError(err))
+	}
+	value, err := rt.parseJSON(data)
+	if err != nil {
+		rt.throw(skyerr.MakeError(err))
+	}
+	return value
+}
+
+// dbGet returns the record of the type and ID, or null if it does not exist.
+func (rt *scriptRuntime) dbGet(call goja.FunctionCall) goja.Value {
+	recordID := skydb.NewRecordID(call.Argument(0).String(), call.Argument(1).String())
+
+	var result *skyconv.JSONRecord
+	rt.withSession(func(s *dbSession) skyerr.Error {
+		db := s.conn.PublicDB()
+		fetcher := recordutil.NewRecordFetcher(rt.ctx, db, s.conn, s.withMasterKey)
+		record, err := fetcher.FetchRecord(recordID, s.authInfo, skydb.ReadLevel)
+		if err != nil {
+			if err.Code() == skyerr.ResourceNotFound {
+				return nil
+			}
+			return err
+		}
+
+		filter, filterErr := recordutil.NewRecordResultFilter(s.conn, nil, s.authInfo, s.withMasterKey)
+		if filterErr != nil {
+			return skyerr.MakeError(filterErr)
+		}
+		result = filter.JSONResult(record)
+		return nil
+	})
+
+	if result == nil {
+		return goja.Null()
+	}
+	return rt.jsonRecordValue(result)
+}
+
+// dbSave saves the record and returns the saved record.
+func (rt *scriptRuntime) dbSave(call goja.FunctionCall) goja.Value {
+	data, err := rt.stringifyJSON(call.Argument(0))
+	if err != nil {
+		rt.throw(skyerr.MakeError(err))
+	}
+
+	var record skydb.Record
+	if err := json.Unmarshal(data, (*skyconv.JSONRecord)(&record)); err != nil {
+		rt.throw(skyerr.NewError(skyerr.InvalidArgument, err.Error()))
+	}
+	record.SanitizeForInput()
+
+	var result *skyconv.JSONRecord
+	rt.withSession(func(s *dbSession) skyerr.Error {
+		if err := s.requireUser(); err != nil {
+			return err
+		}
+
+		db := s.conn.PublicDB()
+		if _, err := recordutil.ExtendRecordSchema(rt.ctx, db, []*skydb.Record{&record}); err != nil {
+			return skyerr.MakeError(err)
+		}
+
+		req := recordutil.RecordModifyRequest{
+			Db:            db,
+			Conn:          s.conn,
+			AuthInfo:      s.authInfo,
+			RecordsToSave: []*skydb.Record{&record},
+			WithMasterKey: s.withMasterKey,
+			Context:       rt.ctx,
+			ModifyAt:      timeNow(),
+		}
+		resp := recordutil.RecordModifyResponse{
+			ErrMap: map[skydb.RecordID]skyerr.Error{},
+		}
+		if err := recordutil.RecordSaveHandler(&req, &resp); err != nil {
+			return err
+		}
+		if err, ok := resp.ErrMap[record.ID]; ok {
+			return err
+		}
+
+		filter, err := recordutil.NewRecordResultFilter(s.conn, nil, s.authInfo, s.withMasterKey)
+		if err != nil {
+			return skyerr.MakeError(err)
+		}
+		result = filter.JSONResult(resp.SavedRecords[0])
+		return nil
+	})
+
+	return rt.jsonRecordValue(result)
+}
+
+// dbDelete deletes the record of the type and ID.
+func (rt *scriptRuntime) dbDelete(call goja.FunctionCall) goja.Value {
+	recordID := skydb.NewRecordID(call.Argument(0).String(), call.Argument(1).String())
+
+	rt.withSession(func(s *dbSession) skyerr.Error {
+		if err := s.requireUser(); err != nil {
+			return err
+		}
+
+		req := recordutil.RecordModifyRequest{
+			Db:                s.conn.PublicDB(),
+			Conn:              s.conn,
+			AuthInfo:          s.authInfo,
+			RecordIDsToDelete: []skydb.RecordID{recordID},
+			WithMasterKey:     s.withMasterKey,
+			Context:           rt.ctx,
+			ModifyAt:          timeNow(),
+		}
+		resp := recordutil.RecordModifyResponse{
+			ErrMap: map[skydb.RecordID]skyerr.Error{},
+		}
+		if err := recordutil.RecordDeleteHandler(&req, &resp); err != nil {
+			return err
+		}
+		if err, ok := resp.ErrMap[recordID]; ok {
+			return err
+		}
+		return nil
+	})
+
+	return goja.Undefined()
+}
+
+// dbQuery returns the records of the type matching the options, which
+// may contain:
+//
+//	where: an object of keys and the values they must equal
+//	sort: an array of keys, prefixed with "-" for descending order
+//	limit, offset: numbers for pagination
+func (rt *scriptRuntime) dbQuery(call goja.FunctionCall) goja.Value {
+	recordType := call.Argument(0).String()
+	options := rt.options(call.Argument(1))
+
+	query := skydb.Query{
+		Type: recordType,
+	}
+	keys := map[string]skydb.FieldAccessMode{}
+
+	if where, ok := options["where"].(map[string]interface{}); ok {
+		predicates := []interface{}{}
+		for key, value := range where {
+			predicates = append(predicates, skydb.Predicate{
+				Operator: skydb.Equal,
+				Children: []interface{}{
+					skydb.Expression{Type: skydb.KeyPath, Value: key},
+					skydb.Expression{Type: skydb.Literal, Value: value},
+				},
+			})
+			keys[key] = skydb.DiscoverOrCompareFieldAccessMode
+		}
+		if len(predicates) == 1 {
+			query.Predicate = predicates[0].(skydb.Predicate)
+		} else if len(predicates) > 1 {
+			query.Predicate = skydb.Predicate{
+				Operator: skydb.And,
+				Children: predicates,
+			}
+		}
+	}
+
+	if sorts, ok := options["sort"].([]interface{}); ok {
+		for _, sort := range sorts {
+			key, _ := sort.(string)
+			order := skydb.Ascending
+			if strings.HasPrefix(key, "-") {
+				key = key[1:]
+				order = skydb.Descending
+			}
+			query.Sorts = append(query.Sorts, skydb.Sort{
+				Expression: skydb.Expression{Type: skydb.KeyPath, Value: key},
+				Order:      order,
+			})
+			if _, ok := keys[key]; !ok {
+				keys[key] = skydb.CompareFieldAccessMode
+			}
+		}
+	}
+
+	if limit, ok := toUint64(options["limit"]); ok {
+		query.Limit = &limit
+	}
+	if offset, ok := toUint64(options["offset"]); ok {
+		query.Offset = offset
+	}
+
+	results := []interface{}{}
+	rt.withSession(func(s *dbSession) skyerr.Error {
+		db := s.conn.PublicDB()
+		accessControlOptions := &skydb.AccessControlOptions{
+			ViewAsUser:          s.authInfo,
+			BypassAccessControl: s.withMasterKey,
+		}
+
+		if !s.withMasterKey {
+			fieldACL, err := s.conn.GetRecordFieldAccess()
+			if err != nil {
+				return skyerr.MakeError(err)
+			}
+			for key, mode := range keys {
+				if !fieldACL.Accessible(recordType, key, mode, s.authInfo, nil) {
+					return skyerr.NewErrorf(skyerr.RecordQueryDenied, `cannot query on key "%s"`, key)
+				}
+			}
+		}
+
+		rows, err := db.Query(&query, accessControlOptions)
+		if err != nil {
+			return skyerr.MakeError(err)
+		}
+		defer rows.Close()
+
+		records := []skydb.Record{}
+		for rows.Scan() {
+			records = append(records, rows.Record())
+		}
+		if err := rows.Err(); err != nil {
+			return skyerr.MakeError(err)
+		}
+		recordutil.MakeAssetsComplete(db, s.conn, records)
+
+		filter, err := recordutil.NewRecordResultFilter(s.conn, nil, s.authInfo, s.withMasterKey)
+		if err != nil {
+			return skyerr.MakeError(err)
+		}
+		for i := range records {
+			results = append(results, filter.JSONResult(&records[i]))
+		}
+		return nil
+	})
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		rt.throw(skyerr.MakeError(err))
+	}
+	value, err := rt.parseJSON(data)
+	if err != nil {
+		rt.throw(skyerr.MakeError(err))
+	}
+	return value
+}
+
+func toUint64(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case int64:
+		if v >= 0 {
+			return uint64(v), true
+		}
+	case float64:
+		if v >= 0 {
+			return uint64(v), true
+		}
+	}
+	return 0, false
+}
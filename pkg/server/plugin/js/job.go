@@ -0,0 +1,102 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/skygeario/skygear-server/pkg/server/job"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	"github.com/skygeario/skygear-server/pkg/server/uuid"
+)
+
+var uuidNew = uuid.New
+
+// enqueueJob enqueues a job to run the lambda in the background with the
+// args, on behalf of the user calling the function. It returns the ID of
+// the job. The options may contain:
+//
+//	delay: seconds to wait before the job is run
+//	runAt: a Date or an RFC3339 string of when the job is run
+//	maxAttempts: the number of attempts before the job is dead
+func (rt *scriptRuntime) enqueueJob(call goja.FunctionCall) goja.Value {
+	lambda := call.Argument(0).String()
+	if lambda == "" {
+		rt.throw(skyerr.NewInvalidArgument("empty lambda", []string{"lambda"}))
+	}
+	args, err := rt.stringifyJSON(call.Argument(1))
+	if err != nil {
+		rt.throw(skyerr.MakeError(err))
+	}
+	options := rt.options(call.Argument(2))
+
+	now := timeNow()
+	runAt := now
+	switch v := options["runAt"].(type) {
+	case time.Time:
+		runAt = v.UTC()
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			rt.throw(skyerr.NewInvalidArgument("runAt must be in RFC3339 format", []string{"runAt"}))
+		}
+		runAt = t.UTC()
+	}
+	if delay, ok := options["delay"]; ok {
+		seconds := toFloat64(delay)
+		if seconds < 0 {
+			rt.throw(skyerr.NewInvalidArgument("delay must not be negative", []string{"delay"}))
+		}
+		runAt = now.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	maxAttempts := job.DefaultMaxAttempts
+	if attempts, ok := toUint64(options["maxAttempts"]); ok && attempts > 0 {
+		maxAttempts = int(attempts)
+	}
+
+	j := skydb.Job{
+		ID:          uuidNew(),
+		Lambda:      lambda,
+		Args:        args,
+		Status:      skydb.JobPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   now,
+	}
+	rt.withSession(func(s *dbSession) skyerr.Error {
+		if s.authInfo != nil {
+			j.UserID = s.authInfo.ID
+		}
+		if err := s.conn.CreateJob(&j); err != nil {
+			return skyerr.MakeError(err)
+		}
+		return nil
+	})
+
+	return rt.vm.ToValue(j.ID)
+}
+
+func toFloat64(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
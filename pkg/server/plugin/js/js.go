@@ -0,0 +1,385 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package js implements a plugin transport running JavaScript cloud
+// functions in an embedded interpreter, without a separate plugin process.
+//
+// Scripts are loaded from the directory set as the plugin path, in the
+// order of their file names. They register functions with the skygear
+// object:
+//
+//	skygear.op('hello', function (params, context) {
+//		return {message: 'hello ' + params.name};
+//	}, {userRequired: true});
+//
+//	skygear.beforeSave('note', function (record, original, context) {
+//		record.title = record.title || 'untitled';
+//		return record;
+//	});
+//
+//	skygear.timer('cleanup', '0 0 * * * *', function (context) {
+//		var notes = skygear.db.query('note', {where: {archived: true}});
+//		notes.forEach(function (note) {
+//			skygear.db.delete('note', note._id.split('/')[1]);
+//		});
+//	});
+//
+// Hooks are registered with skygear.beforeSave, skygear.afterSave,
+// skygear.beforeDelete, skygear.afterDelete, skygear.beforeQuery and
+// skygear.afterFetch. Records and queries are passed in the same JSON
+// format as to an external plugin.
+//
+// Auth hooks, such as skygear.beforeSignup and skygear.afterLogin, are
+// called with the user record and the auth info. A before hook may reject
+// the action by throwing a SkygearError:
+//
+//	skygear.beforeLogin(function (user, authInfo, context) {
+//		if (user.banned) {
+//			throw new SkygearError('banned', 102);
+//		}
+//	});
+//
+// Scripts access records with skygear.db.get, skygear.db.save,
+// skygear.db.delete and skygear.db.query, which run with the access
+// control of the user calling the function. Timers run with the master
+// key, once per tick across all server instances. A timer registered with
+// {catchUp: true} runs once for the ticks missed while the server is down.
+// Records saved or deleted by a script do not trigger hooks.
+//
+// Scripts enqueue background jobs running a lambda with
+// skygear.enqueueJob, which are run on behalf of the calling user and
+// retried if they fail:
+//
+//	skygear.enqueueJob('send_email', {to: user.email}, {delay: 60});
+//
+// Every invocation runs in a fresh interpreter and is limited in CPU time
+// and memory. Memory is accounted as the size of the params, results and
+// records passed between the script and the server, and as the growth of
+// the live heap while the script runs. The limits are set with plugin
+// args:
+//
+//	JS_TRANSPORT=js
+//	JS_PATH=cloud
+//	JS_ARGS=-cpu-limit=500ms,-memory-limit=32
+package js
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/sirupsen/logrus"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	pluginrequest "github.com/skygeario/skygear-server/pkg/server/plugin/request"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyconfig"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+var log = logging.LoggerEntryWithTag("plugin", "plugin")
+
+const (
+	defaultCPULimit      = time.Second
+	defaultMemoryLimitMB = 64
+)
+
+type jsTransport struct {
+	path        string
+	config      skyconfig.Configuration
+	cpuLimit    time.Duration
+	memoryLimit uint64
+	dbOpener    skydb.DBOpener
+	logger      *logrus.Entry
+
+	mutex    sync.RWMutex
+	state    skyplugin.TransportState
+	programs []*goja.Program
+}
+
+func (p *jsTransport) State() skyplugin.TransportState {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.state
+}
+
+func (p *jsTransport) SetState(state skyplugin.TransportState) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if state != p.state {
+		oldState := p.state
+		p.state = state
+		p.logger.Infof("Transport state changes from %v to %v.", oldState, p.state)
+	}
+}
+
+// SendEvent loads the scripts on init and returns the functions they
+// register. Other events are ignored.
+func (p *jsTransport) SendEvent(name string, in []byte) ([]byte, error) {
+	if name != "init" {
+		return nil, nil
+	}
+
+	programs, err := loadScripts(p.path)
+	if err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	p.programs = programs
+	p.mutex.Unlock()
+
+	rt, err := p.newRuntime(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer rt.close()
+
+	return json.Marshal(rt.registry.registrationInfo())
+}
+
+func (p *jsTransport) RunLambda(ctx context.Context, name string, in []byte) ([]byte, error) {
+	rt, err := p.newRuntime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rt.close()
+
+	lambda := rt.registry.lambda(name)
+	if lambda == nil {
+		return nil, fmt.Errorf(`lambda "%s" is not registered`, name)
+	}
+
+	params, err := rt.parseJSON(in)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := lambda.fn(goja.Undefined(), params, rt.contextValue())
+	if err != nil {
+		return nil, rt.error(err)
+	}
+	return rt.stringifyJSON(out)
+}
+
+func (p *jsTransport) RunHandler(ctx context.Context, name string, in []byte) ([]byte, error) {
+	return nil, skyerr.NewError(skyerr.NotSupported, "handlers are not supported by the js transport")
+}
+
+func (p *jsTransport) RunHook(ctx context.Context, hookName string, record *skydb.Record, originalRecord *skydb.Record, async bool) (*skydb.Record, error) {
+	rt, err := p.newRuntime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rt.close()
+
+	hookInfo := rt.registry.hook(hookName)
+	if hookInfo == nil {
+		return nil, fmt.Errorf(`hook "%s" is not registered`, hookName)
+	}
+
+	recordValue, err := rt.recordValue(record)
+	if err != nil {
+		return nil, err
+	}
+	originalValue, err := rt.recordValue(originalRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := hookInfo.fn(goja.Undefined(), recordValue, originalValue, rt.contextValue())
+	if err != nil {
+		return nil, rt.error(err)
+	}
+
+	if hookInfo.kind != hook.BeforeSave {
+		recordout := record.Copy()
+		return &recordout, nil
+	}
+
+	// The record passed in may be modified in place instead of returned.
+	if goja.IsUndefined(out) || goja.IsNull(out) {
+		out = recordValue
+	}
+	data, err := rt.stringifyJSON(out)
+	if err != nil {
+		return nil, err
+	}
+
+	var recordout skydb.Record
+	if err := json.Unmarshal(data, (*skyconv.JSONRecord)(&recordout)); err != nil {
+		return nil, skyerr.NewErrorf(skyerr.UnexpectedError, "failed to unmarshal record returned by hook: %v", err)
+	}
+	recordout.OwnerID = record.OwnerID
+	recordout.CreatedAt = record.CreatedAt
+	recordout.CreatorID = record.CreatorID
+	recordout.UpdatedAt = record.UpdatedAt
+	recordout.UpdaterID = record.UpdaterID
+
+	return &recordout, nil
+}
+
+func (p *jsTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	req, err := pluginrequest.NewQueryHookRequest(ctx, hookName, query)
+	if err != nil {
+		return nil, err
+	}
+	out, err := p.runHook(ctx, hookName, req.Param.(pluginrequest.QueryHookRequest).Query)
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseQueryHookResult(ctx, out, query)
+}
+
+func (p *jsTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	req := pluginrequest.NewFetchHookRequest(ctx, hookName, records)
+	out, err := p.runHook(ctx, hookName, req.Param.(pluginrequest.FetchHookRequest).Records)
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseFetchHookResult(out, records)
+}
+
+func (p *jsTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	req := pluginrequest.NewAuthHookRequest(ctx, hookName, authInfo, user)
+	param := req.Param.(pluginrequest.AuthHookRequest)
+	out, err := p.runHook(ctx, hookName, param.User, param.AuthInfo)
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseAuthHookResult(out, user)
+}
+
+// runHook calls the hook with params, the first of which may be modified
+// in place instead of returned, and returns the result in JSON.
+func (p *jsTransport) runHook(ctx context.Context, hookName string, params ...interface{}) ([]byte, error) {
+	rt, err := p.newRuntime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rt.close()
+
+	hookInfo := rt.registry.hook(hookName)
+	if hookInfo == nil {
+		return nil, fmt.Errorf(`hook "%s" is not registered`, hookName)
+	}
+
+	args := make([]goja.Value, len(params), len(params)+1)
+	for i, param := range params {
+		data, err := json.Marshal(param)
+		if err != nil {
+			return nil, err
+		}
+		if args[i], err = rt.parseJSON(data); err != nil {
+			return nil, err
+		}
+	}
+	args = append(args, rt.contextValue())
+
+	out, err := hookInfo.fn(goja.Undefined(), args...)
+	if err != nil {
+		return nil, rt.error(err)
+	}
+	if goja.IsUndefined(out) || goja.IsNull(out) {
+		out = args[0]
+	}
+	return rt.stringifyJSON(out)
+}
+
+func (p *jsTransport) RunTimer(name string, in []byte) ([]byte, error) {
+	ctx := context.WithValue(context.Background(), router.AccessKeyTypeContextKey, router.MasterAccessKey)
+	rt, err := p.newRuntime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rt.close()
+
+	timer := rt.registry.timer(name)
+	if timer == nil {
+		return nil, fmt.Errorf(`timer "%s" is not registered`, name)
+	}
+
+	if _, err := timer.fn(goja.Undefined(), rt.contextValue()); err != nil {
+		err = rt.error(err)
+		p.logger.WithError(err).WithField("timer", name).Error("Timer returned an error")
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (p *jsTransport) RunProvider(ctx context.Context, request *skyplugin.AuthRequest) (*skyplugin.AuthResponse, error) {
+	return nil, skyerr.NewError(skyerr.NotSupported, "auth providers are not supported by the js transport")
+}
+
+// loadScripts compiles the scripts in the directory, ordered by file name.
+func loadScripts(dir string) ([]*goja.Program, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read script directory: %v", err)
+	}
+
+	programs := []*goja.Program{}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".js") {
+			continue
+		}
+
+		src, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		program, err := goja.Compile(file.Name(), string(src), false)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compile %s: %v", file.Name(), err)
+		}
+		programs = append(programs, program)
+	}
+	return programs, nil
+}
+
+type jsTransportFactory struct {
+}
+
+func (f jsTransportFactory) Open(path string, args []string, config skyconfig.Configuration) skyplugin.Transport {
+	flags := flag.NewFlagSet("js", flag.ContinueOnError)
+	cpuLimit := flags.Duration("cpu-limit", defaultCPULimit, "CPU time limit of an invocation")
+	memoryLimit := flags.Uint64("memory-limit", defaultMemoryLimitMB, "memory limit of an invocation in megabytes")
+	if err := flags.Parse(args); err != nil {
+		panic(fmt.Errorf("unable to parse js plugin args: %v", err))
+	}
+
+	return &jsTransport{
+		path:        path,
+		config:      config,
+		cpuLimit:    *cpuLimit,
+		memoryLimit: *memoryLimit * 1024 * 1024,
+		dbOpener:    skydb.Open,
+		logger:      log.WithField("plugin", path),
+		state:       skyplugin.TransportStateUninitialized,
+	}
+}
+
+func init() {
+	skyplugin.RegisterTransport("js", jsTransportFactory{})
+}
@@ -0,0 +1,389 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skyconfig"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testScript = `
+skygear.op('hello', function (params, context) {
+	return {message: 'hello ' + params.name, user_id: context.user_id};
+}, {userRequired: true});
+
+skygear.op('fail', function () {
+	throw new SkygearError('not allowed', 102, {reason: 'test'});
+});
+
+skygear.op('loop', function () {
+	while (true) {}
+});
+
+skygear.op('hog', function () {
+	var chunk = new Array(1025).join('x');
+	var items = [];
+	while (true) {
+		items.push(chunk + items.length);
+	}
+});
+
+skygear.op('notes', function (params) {
+	skygear.db.save({_id: 'note/1', content: params.content});
+	return skygear.db.get('note', '1');
+});
+
+skygear.beforeSave('note', function (record, original, context) {
+	record.title = record.title || 'untitled';
+});
+
+skygear.afterDelete('note', function () {}, {async: true, name: 'notify'});
+
+skygear.timer('cleanup', '0 * * * * *', function () {}, {catchUp: true});
+`
+
+func TestJSTransport(t *testing.T) {
+	Convey("js transport", t, func() {
+		dir, err := ioutil.TempDir("", "skygear.plugin.js.test")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		So(ioutil.WriteFile(filepath.Join(dir, "main.js"), []byte(testScript), 0644), ShouldBeNil)
+
+		conn := skydbtest.NewMapConn()
+		conn.InternalPublicDB = skydbtest.NewMapDB()
+		transport := jsTransportFactory{}.Open(
+			dir,
+			[]string{"-cpu-limit=100ms", "-memory-limit=1"},
+			skyconfig.Configuration{},
+		).(*jsTransport)
+		transport.dbOpener = func(context.Context, string, string, string, string, skydb.DBConfig) (skydb.Conn, error) {
+			return conn, nil
+		}
+
+		out, err := transport.SendEvent("init", []byte(`{}`))
+		So(err, ShouldBeNil)
+
+		Convey("returns registration info on init", func() {
+			So(out, ShouldEqualJSON, `{
+				"handler": [],
+				"hook": [{
+					"name": "beforeSave:note:0",
+					"trigger": "beforeSave",
+					"type": "note",
+					"async": false
+				}, {
+					"name": "notify",
+					"trigger": "afterDelete",
+					"type": "note",
+					"async": true
+				}],
+				"op": [{
+					"name": "hello",
+					"key_required": false,
+					"user_required": true
+				}, {
+					"name": "fail",
+					"key_required": false,
+					"user_required": false
+				}, {
+					"name": "loop",
+					"key_required": false,
+					"user_required": false
+				}, {
+					"name": "hog",
+					"key_required": false,
+					"user_required": false
+				}, {
+					"name": "notes",
+					"key_required": false,
+					"user_required": false
+				}],
+				"timer": [{
+					"name": "cleanup",
+					"spec": "0 * * * * *",
+					"catch_up": true
+				}],
+				"provider": []
+			}`)
+		})
+
+		Convey("sets state", func() {
+			So(transport.State(), ShouldEqual, skyplugin.TransportStateUninitialized)
+			transport.SetState(skyplugin.TransportStateReady)
+			So(transport.State(), ShouldEqual, skyplugin.TransportStateReady)
+		})
+
+		Convey("runs lambda with context", func() {
+			ctx := context.WithValue(context.Background(), router.UserIDContextKey, "user-id")
+			out, err := transport.RunLambda(ctx, "hello", []byte(`{"name": "world"}`))
+			So(err, ShouldBeNil)
+			So(out, ShouldEqualJSON, `{"message": "hello world", "user_id": "user-id"}`)
+		})
+
+		Convey("returns error thrown by lambda", func() {
+			_, err := transport.RunLambda(context.Background(), "fail", []byte(`{}`))
+			So(err, ShouldResemble, skyerr.NewErrorWithInfo(
+				skyerr.PermissionDenied,
+				"not allowed",
+				map[string]interface{}{"reason": "test"},
+			))
+		})
+
+		Convey("interrupts lambda exceeding CPU time limit", func() {
+			_, err := transport.RunLambda(context.Background(), "loop", []byte(`{}`))
+			So(err, ShouldNotBeNil)
+			So(err.(skyerr.Error).Code(), ShouldEqual, skyerr.PluginTimeout)
+		})
+
+		Convey("interrupts lambda exceeding memory limit", func() {
+			params := fmt.Sprintf(`{"name": "%s"}`, strings.Repeat("a", 1024*1024))
+			_, err := transport.RunLambda(context.Background(), "hello", []byte(params))
+			So(err, ShouldResemble, skyerr.NewError(skyerr.UnexpectedError, "script exceeded the memory limit"))
+		})
+
+		Convey("interrupts lambda allocating beyond memory limit", func() {
+			// collect the garbage of previous tests, which is otherwise
+			// counted in the live heap until the next collection
+			runtime.GC()
+			_, err := transport.RunLambda(context.Background(), "hog", []byte(`{}`))
+			So(err, ShouldResemble, skyerr.NewError(skyerr.UnexpectedError, "script exceeded the memory limit"))
+		})
+
+		Convey("saves and gets records", func() {
+			conn.UserMap["user-id"] = skydb.AuthInfo{ID: "user-id"}
+			ctx := context.WithValue(context.Background(), router.AccessKeyTypeContextKey, router.MasterAccessKey)
+			ctx = context.WithValue(ctx, router.UserIDContextKey, "user-id")
+			out, err := transport.RunLambda(ctx, "notes", []byte(`{"content": "hello"}`))
+			So(err, ShouldBeNil)
+
+			record := skydb.Record{}
+			So(conn.InternalPublicDB.Get(skydb.NewRecordID("note", "1"), &record), ShouldBeNil)
+			So(record.Data["content"], ShouldEqual, "hello")
+			So(string(out), ShouldContainSubstring, `"content":"hello"`)
+		})
+
+		Convey("requires user to save records", func() {
+			ctx := context.WithValue(context.Background(), router.AccessKeyTypeContextKey, router.MasterAccessKey)
+			_, err := transport.RunLambda(ctx, "notes", []byte(`{"content": "hello"}`))
+			So(err, ShouldNotBeNil)
+			So(err.(skyerr.Error).Code(), ShouldEqual, skyerr.NotAuthenticated)
+		})
+
+		Convey("runs beforeSave hook", func() {
+			now := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+			record := skydb.Record{
+				ID:        skydb.NewRecordID("note", "id"),
+				OwnerID:   "john.doe@example.com",
+				CreatedAt: now,
+				Data:      skydb.Data{"content": "hello"},
+			}
+			recordout, err := transport.RunHook(context.Background(), "beforeSave:note:0", &record, nil, false)
+			So(err, ShouldBeNil)
+			So(recordout.Data, ShouldResemble, skydb.Data{
+				"content": "hello",
+				"title":   "untitled",
+			})
+			So(recordout.OwnerID, ShouldEqual, "john.doe@example.com")
+			So(recordout.CreatedAt, ShouldResemble, now)
+			So(record.Data, ShouldResemble, skydb.Data{"content": "hello"})
+		})
+
+		Convey("runs timer", func() {
+			_, err := transport.RunTimer("cleanup", []byte{})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("does not support handlers", func() {
+			_, err := transport.RunHandler(context.Background(), "handler", []byte(`{}`))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+const testQueryHookScript = `
+skygear.beforeQuery('note', function (query, context) {
+	query.predicate = ['eq', {$type: 'keypath', $val: 'tenant'}, 'skygear'];
+	return query;
+}, {name: 'tenant_filter'});
+
+skygear.afterFetch('note', function (records, context) {
+	return records.filter(function (record) {
+		return !record.secret;
+	});
+}, {name: 'redact'});
+`
+
+func TestJSQueryHooks(t *testing.T) {
+	Convey("js query hooks", t, func() {
+		dir, err := ioutil.TempDir("", "skygear.plugin.js.test")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		So(ioutil.WriteFile(filepath.Join(dir, "query.js"), []byte(testQueryHookScript), 0644), ShouldBeNil)
+
+		transport := jsTransportFactory{}.Open(dir, []string{}, skyconfig.Configuration{})
+		_, err = transport.SendEvent("init", []byte(`{}`))
+		So(err, ShouldBeNil)
+
+		Convey("rewrites query predicate", func() {
+			query := skydb.Query{Type: "note"}
+			queryout, err := transport.RunQueryHook(context.Background(), "tenant_filter", &query)
+			So(err, ShouldBeNil)
+			So(queryout.Predicate, ShouldResemble, skydb.Predicate{
+				Operator: skydb.Equal,
+				Children: []interface{}{
+					skydb.Expression{Type: skydb.KeyPath, Value: "tenant"},
+					skydb.Expression{Type: skydb.Literal, Value: "skygear"},
+				},
+			})
+		})
+
+		Convey("drops fetched records", func() {
+			records := []*skydb.Record{
+				&skydb.Record{ID: skydb.NewRecordID("note", "1"), Data: skydb.Data{"secret": false}},
+				&skydb.Record{ID: skydb.NewRecordID("note", "2"), Data: skydb.Data{"secret": true}},
+			}
+			recordsout, err := transport.RunFetchHook(context.Background(), "redact", records)
+			So(err, ShouldBeNil)
+			So(len(recordsout), ShouldEqual, 1)
+			So(recordsout[0].ID, ShouldResemble, skydb.NewRecordID("note", "1"))
+		})
+	})
+}
+
+const testAuthHookScript = `
+skygear.beforeSignup(function (user, authInfo, context) {
+	user.nickname = 'user-' + authInfo._id;
+}, {name: 'default_nickname'});
+
+skygear.beforeLogin(function (user, authInfo, context) {
+	if (user.banned) {
+		throw new SkygearError('banned', 102);
+	}
+}, {name: 'deny_banned'});
+`
+
+func TestJSAuthHooks(t *testing.T) {
+	Convey("js auth hooks", t, func() {
+		dir, err := ioutil.TempDir("", "skygear.plugin.js.test")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		So(ioutil.WriteFile(filepath.Join(dir, "auth.js"), []byte(testAuthHookScript), 0644), ShouldBeNil)
+
+		transport := jsTransportFactory{}.Open(dir, []string{}, skyconfig.Configuration{})
+		_, err = transport.SendEvent("init", []byte(`{}`))
+		So(err, ShouldBeNil)
+
+		Convey("modifies user data in place", func() {
+			user := &skydb.Record{
+				ID:      skydb.NewRecordID("user", "0"),
+				OwnerID: "0",
+				Data:    skydb.Data{},
+			}
+			userout, err := transport.RunAuthHook(context.Background(), "default_nickname", &skydb.AuthInfo{ID: "0"}, user)
+			So(err, ShouldBeNil)
+			So(userout.ID, ShouldResemble, user.ID)
+			So(userout.OwnerID, ShouldEqual, "0")
+			So(userout.Data, ShouldResemble, skydb.Data{"nickname": "user-0"})
+		})
+
+		Convey("rejects with error thrown", func() {
+			user := &skydb.Record{
+				ID:   skydb.NewRecordID("user", "0"),
+				Data: skydb.Data{"banned": true},
+			}
+			_, err := transport.RunAuthHook(context.Background(), "deny_banned", &skydb.AuthInfo{ID: "0"}, user)
+			So(err.(skyerr.Error).Code(), ShouldEqual, skyerr.PermissionDenied)
+		})
+	})
+}
+
+const testJobScript = `
+skygear.op('signup_followup', function (params) {
+	return skygear.enqueueJob('send_email', {to: params.email}, {delay: 60, maxAttempts: 3});
+});
+
+skygear.op('scheduled', function () {
+	return skygear.enqueueJob('report', null, {runAt: new Date(Date.UTC(2017, 0, 2))});
+});
+`
+
+func TestJSJobs(t *testing.T) {
+	Convey("js jobs", t, func() {
+		dir, err := ioutil.TempDir("", "skygear.plugin.js.test")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		So(ioutil.WriteFile(filepath.Join(dir, "job.js"), []byte(testJobScript), 0644), ShouldBeNil)
+
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		realUUIDNew := uuidNew
+		uuidNew = func() string { return "job-id" }
+		defer func() {
+			timeNow = realTimeNow
+			uuidNew = realUUIDNew
+		}()
+
+		conn := skydbtest.NewMapConn()
+		conn.CreateAuth(&skydb.AuthInfo{ID: "user-id"})
+		transport := jsTransportFactory{}.Open(dir, []string{}, skyconfig.Configuration{}).(*jsTransport)
+		transport.dbOpener = func(context.Context, string, string, string, string, skydb.DBConfig) (skydb.Conn, error) {
+			return conn, nil
+		}
+		_, err = transport.SendEvent("init", []byte(`{}`))
+		So(err, ShouldBeNil)
+
+		Convey("enqueues delayed job on behalf of user", func() {
+			ctx := context.WithValue(context.Background(), router.UserIDContextKey, "user-id")
+			out, err := transport.RunLambda(ctx, "signup_followup", []byte(`{"email": "someone@example.com"}`))
+			So(err, ShouldBeNil)
+			So(out, ShouldEqualJSON, `"job-id"`)
+
+			j := conn.JobMap["job-id"]
+			So(j.Lambda, ShouldEqual, "send_email")
+			So([]byte(j.Args), ShouldEqualJSON, `{"to": "someone@example.com"}`)
+			So(j.UserID, ShouldEqual, "user-id")
+			So(j.Status, ShouldEqual, skydb.JobPending)
+			So(j.MaxAttempts, ShouldEqual, 3)
+			So(j.RunAt, ShouldResemble, now.Add(time.Minute))
+		})
+
+		Convey("enqueues job at time", func() {
+			_, err := transport.RunLambda(context.Background(), "scheduled", []byte(`{}`))
+			So(err, ShouldBeNil)
+
+			j := conn.JobMap["job-id"]
+			So(string(j.Args), ShouldEqual, "null")
+			So(j.UserID, ShouldBeEmpty)
+			So(j.RunAt, ShouldResemble, time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC))
+		})
+	})
+}
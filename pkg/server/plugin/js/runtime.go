@@ -0,0 +1,651 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package js
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+
+	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	"github.com/skygeario/skygear-server/pkg/server/uuid"
+)
+
+const prelude = `
+function SkygearError(message, code, info) {
+	this.name = 'SkygearError';
+	this.message = message;
+	this.code = code === undefined ? 10000 : code;
+	this.info = info;
+}
+`
+
+type lambdaInfo struct {
+	name         string
+	keyRequired  bool
+	userRequired bool
+	fn           goja.Callable
+}
+
+type hookInfo struct {
+	name       string
+	kind       hook.Kind
+	recordType string
+	async      bool
+	fn         goja.Callable
+}
+
+type timerInfo struct {
+	name    string
+	spec    string
+	catchUp bool
+	fn      goja.Callable
+}
+
+// registry contains the functions registered by the scripts in a runtime.
+type registry struct {
+	lambdas []lambdaInfo
+	hooks   []hookInfo
+	timers  []timerInfo
+}
+
+func (r *registry) lambda(name string) *lambdaInfo {
+	for i := range r.lambdas {
+		if r.lambdas[i].name == name {
+			return &r.lambdas[i]
+		}
+	}
+	return nil
+}
+
+func (r *registry) hook(name string) *hookInfo {
+	for i := range r.hooks {
+		if r.hooks[i].name == name {
+			return &r.hooks[i]
+		}
+	}
+	return nil
+}
+
+func (r *registry) timer(name string) *timerInfo {
+	for i := range r.timers {
+		if r.timers[i].name == name {
+			return &r.timers[i]
+		}
+	}
+	return nil
+}
+
+// registrationInfo returns the registration info in the format sent by an
+// external plugin on init.
+func (r *registry) registrationInfo() map[string]interface{} {
+	lambdas := []map[string]interface{}{}
+	for _, lambda := range r.lambdas {
+		lambdas = append(lambdas, map[string]interface{}{
+			"name":          lambda.name,
+			"key_required":  lambda.keyRequired,
+			"user_required": lambda.userRequired,
+		})
+	}
+
+	hooks := []map[string]interface{}{}
+	for _, hookInfo := range r.hooks {
+		hooks = append(hooks, map[string]interface{}{
+			"name":    hookInfo.name,
+			"trigger": string(hookInfo.kind),
+			"type":    hookInfo.recordType,
+			"async":   hookInfo.async,
+		})
+	}
+
+	timers := []map[string]interface{}{}
+	for _, timer := range r.timers {
+		timers = append(timers, map[string]interface{}{
+			"name":     timer.name,
+			"spec":     timer.spec,
+			"catch_up": timer.catchUp,
+		})
+	}
+
+	return map[string]interface{}{
+		"handler":  []interface{}{},
+		"hook":     hooks,
+		"op":       lambdas,
+		"timer":    timers,
+		"provider": []interface{}{},
+	}
+}
+
+// scriptRuntime is an interpreter with the scripts loaded, used for a
+// single invocation. It must be closed after use.
+type scriptRuntime struct {
+	vm        *goja.Runtime
+	ctx       context.Context
+	transport *jsTransport
+	registry  *registry
+	limiter   *limiter
+	session   *dbSession
+
+	jsonParse     goja.Callable
+	jsonStringify goja.Callable
+	newError      goja.Callable
+}
+
+func (p *jsTransport) newRuntime(ctx context.Context) (*scriptRuntime, error) {
+	p.mutex.RLock()
+	programs := p.programs
+	p.mutex.RUnlock()
+
+	vm := goja.New()
+	rt := &scriptRuntime{
+		vm:        vm,
+		ctx:       ctx,
+		transport: p,
+		registry:  &registry{},
+		limiter:   startLimiter(vm, p.cpuLimit, p.memoryLimit),
+	}
+
+	if err := rt.setup(); err != nil {
+		rt.close()
+		return nil, err
+	}
+
+	for _, program := range programs {
+		if _, err := vm.RunProgram(program); err != nil {
+			rt.close()
+			return nil, rt.error(err)
+		}
+	}
+	return rt, nil
+}
+
+func (rt *scriptRuntime) setup() error {
+	vm := rt.vm
+	if _, err := vm.RunString(prelude); err != nil {
+		return err
+	}
+
+	var err error
+	if rt.jsonParse, err = rt.function("JSON.parse"); err != nil {
+		return err
+	}
+	if rt.jsonStringify, err = rt.function("JSON.stringify"); err != nil {
+		return err
+	}
+	rt.newError, err = rt.function("(function (message, code, info) { return new SkygearError(message, code, info); })")
+	if err != nil {
+		return err
+	}
+
+	db := vm.NewObject()
+	db.Set("get", rt.dbGet)
+	db.Set("save", rt.dbSave)
+	db.Set("delete", rt.dbDelete)
+	db.Set("query", rt.dbQuery)
+
+	skygear := vm.NewObject()
+	skygear.Set("op", rt.registerLambda)
+	skygear.Set("beforeSave", rt.hookRegisterer(hook.BeforeSave))
+	skygear.Set("afterSave", rt.hookRegisterer(hook.AfterSave))
+	skygear.Set("beforeDelete", rt.hookRegisterer(hook.BeforeDelete))
+	skygear.Set("afterDelete", rt.hookRegisterer(hook.AfterDelete))
+	skygear.Set("beforeQuery", rt.hookRegisterer(hook.BeforeQuery))
+	skygear.Set("afterFetch", rt.hookRegisterer(hook.AfterFetch))
+	for _, kind := range authHookKinds {
+		skygear.Set(string(kind), rt.authHookRegisterer(kind))
+	}
+	skygear.Set("timer", rt.registerTimer)
+	skygear.Set("enqueueJob", rt.enqueueJob)
+	skygear.Set("uuid", func(call goja.FunctionCall) goja.Value {
+		return vm.ToValue(uuid.New())
+	})
+	skygear.Set("db", db)
+	vm.Set("skygear", skygear)
+
+	console := vm.NewObject()
+	console.Set("log", rt.consoleLog)
+	console.Set("error", rt.consoleLog)
+	vm.Set("console", console)
+
+	return nil
+}
+
+// function evaluates src to a function.
+func (rt *scriptRuntime) function(src string) (goja.Callable, error) {
+	value, err := rt.vm.RunString(src)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := goja.AssertFunction(value)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a function", src)
+	}
+	return fn, nil
+}
+
+func (rt *scriptRuntime) close() {
+	rt.limiter.stop()
+	if rt.session != nil {
+		rt.session.conn.Close()
+	}
+}
+
+func (rt *scriptRuntime) registerLambda(call goja.FunctionCall) goja.Value {
+	name := call.Argument(0).String()
+	fn := rt.assertFunction(call.Argument(1))
+	options := rt.options(call.Argument(2))
+	keyRequired, _ := options["keyRequired"].(bool)
+	userRequired, _ := options["userRequired"].(bool)
+
+	rt.registry.lambdas = append(rt.registry.lambdas, lambdaInfo{
+		name:         name,
+		keyRequired:  keyRequired,
+		userRequired: userRequired,
+		fn:           fn,
+	})
+	return goja.Undefined()
+}
+
+func (rt *scriptRuntime) hookRegisterer(kind hook.Kind) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		recordType := call.Argument(0).String()
+		fn := rt.assertFunction(call.Argument(1))
+		options := rt.options(call.Argument(2))
+		async, _ := options["async"].(bool)
+
+		// Scripts are run in the same order in every runtime, so the
+		// generated name refers to the same hook.
+		name, _ := options["name"].(string)
+		if name == "" {
+			name = fmt.Sprintf("%s:%s:%d", kind, recordType, len(rt.registry.hooks))
+		}
+
+		rt.registry.hooks = append(rt.registry.hooks, hookInfo{
+			name:       name,
+			kind:       kind,
+			recordType: recordType,
+			async:      async,
+			fn:         fn,
+		})
+		return goja.Undefined()
+	}
+}
+
+var authHookKinds = []hook.Kind{
+	hook.BeforeSignup, hook.AfterSignup,
+	hook.BeforeLogin, hook.AfterLogin,
+	hook.BeforeLogout, hook.AfterLogout,
+	hook.BeforePasswordChange, hook.AfterPasswordChange,
+	hook.BeforeProviderLink, hook.AfterProviderLink,
+	hook.BeforeProviderUnlink, hook.AfterProviderUnlink,
+	hook.BeforeUserDisable, hook.AfterUserDisable,
+}
+
+func (rt *scriptRuntime) authHookRegisterer(kind hook.Kind) func(goja.FunctionCall) goja.Value {
+	return func(call goja.FunctionCall) goja.Value {
+		fn := rt.assertFunction(call.Argument(0))
+		options := rt.options(call.Argument(1))
+
+		name, _ := options["name"].(string)
+		if name == "" {
+			name = fmt.Sprintf("%s:%d", kind, len(rt.registry.hooks))
+		}
+
+		rt.registry.hooks = append(rt.registry.hooks, hookInfo{
+			name: name,
+			kind: kind,
+			fn:   fn,
+		})
+		return goja.Undefined()
+	}
+}
+
+func (rt *scriptRuntime) registerTimer(call goja.FunctionCall) goja.Value {
+	options := rt.options(call.Argument(3))
+	catchUp, _ := options["catchUp"].(bool)
+
+	rt.registry.timers = append(rt.registry.timers, timerInfo{
+		name:    call.Argument(0).String(),
+		spec:    call.Argument(1).String(),
+		catchUp: catchUp,
+		fn:      rt.assertFunction(call.Argument(2)),
+	})
+	return goja.Undefined()
+}
+
+func (rt *scriptRuntime) consoleLog(call goja.FunctionCall) goja.Value {
+	args := make([]interface{}, len(call.Arguments))
+	for i, arg := range call.Arguments {
+		args[i] = arg.String()
+	}
+	rt.transport.logger.Info(args...)
+	return goja.Undefined()
+}
+
+func (rt *scriptRuntime) assertFunction(value goja.Value) goja.Callable {
+	fn, ok := goja.AssertFunction(value)
+	if !ok {
+		panic(rt.vm.NewTypeError("expected a function"))
+	}
+	return fn
+}
+
+func (rt *scriptRuntime) options(value goja.Value) map[string]interface{} {
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return map[string]interface{}{}
+	}
+	options, ok := value.Export().(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return options
+}
+
+func (rt *scriptRuntime) contextValue() goja.Value {
+	return rt.vm.ToValue(skyplugin.ContextMap(rt.ctx))
+}
+
+func (rt *scriptRuntime) parseJSON(data []byte) (goja.Value, error) {
+	if len(data) == 0 {
+		return goja.Undefined(), nil
+	}
+	if err := rt.limiter.allocate(len(data)); err != nil {
+		return nil, err
+	}
+	value, err := rt.jsonParse(goja.Undefined(), rt.vm.ToValue(string(data)))
+	if err != nil {
+		return nil, rt.error(err)
+	}
+	return value, nil
+}
+
+func (rt *scriptRuntime) stringifyJSON(value goja.Value) ([]byte, error) {
+	if value == nil || goja.IsUndefined(value) {
+		return []byte("null"), nil
+	}
+	out, err := rt.jsonStringify(goja.Undefined(), value)
+	if err != nil {
+		return nil, rt.error(err)
+	}
+	if goja.IsUndefined(out) {
+		return []byte("null"), nil
+	}
+	data := []byte(out.String())
+	if err := rt.limiter.allocate(len(data)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (rt *scriptRuntime) recordValue(record *skydb.Record) (goja.Value, error) {
+	if record == nil {
+		return goja.Null(), nil
+	}
+	data, err := json.Marshal((*skyconv.JSONRecord)(record))
+	if err != nil {
+		return nil, err
+	}
+	return rt.parseJSON(data)
+}
+
+// throw panics with a SkygearError of err, which is thrown as an exception
+// to the script.
+func (rt *scriptRuntime) throw(err skyerr.Error) {
+	value, callErr := rt.newError(
+		goja.Undefined(),
+		rt.vm.ToValue(err.Message()),
+		rt.vm.ToValue(int(err.Code())),
+		rt.vm.ToValue(err.Info()),
+	)
+	if callErr != nil {
+		panic(rt.vm.NewGoError(err))
+	}
+	panic(value)
+}
+
+// error converts an error returned by the interpreter to skyerr.Error.
+func (rt *scriptRuntime) error(err error) error {
+	switch e := err.(type) {
+	case *goja.InterruptedError:
+		if skyErr, ok := e.Value().(skyerr.Error); ok {
+			return skyErr
+		}
+		return skyerr.NewError(skyerr.PluginTimeout, e.Error())
+	case *goja.Exception:
+		return exceptionError(e)
+	}
+	return err
+}
+
+func exceptionError(e *goja.Exception) skyerr.Error {
+	thrown, ok := e.Value().Export().(map[string]interface{})
+	if !ok {
+		return skyerr.NewError(skyerr.UnexpectedError, e.Error())
+	}
+
+	name, _ := thrown["name"].(string)
+	message, _ := thrown["message"].(string)
+	if name != "SkygearError" {
+		return skyerr.NewError(skyerr.UnexpectedError, e.Error())
+	}
+
+	code := skyerr.UnexpectedError
+	switch c := thrown["code"].(type) {
+	case int64:
+		code = skyerr.ErrorCode(c)
+	case float64:
+		code = skyerr.ErrorCode(c)
+	}
+	if info, ok := thrown["info"].(map[string]interface{}); ok {
+		return skyerr.NewErrorWithInfo(code, message, info)
+	}
+	return skyerr.NewError(code, message)
+}
+
+// limiterInterval is how often the CPU time and the heap growth of an
+// invocation are checked.
+const limiterInterval = 10 * time.Millisecond
+
+// gcPercent is the garbage collection target percentage of the process,
+// read from GOGC as the runtime does. It is 0 if garbage collection is
+// off.
+var gcPercent = readGCPercent(os.Getenv("GOGC"))
+
+func readGCPercent(value string) uint64 {
+	if value == "" {
+		return 100
+	}
+	if value == "off" {
+		return 0
+	}
+	percent, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 100
+	}
+	return percent
+}
+
+// heapSampler reads the heap of the process for all limiters, so that
+// the heap is read at most once per interval however many invocations
+// are running.
+type heapSampler struct {
+	mutex     sync.Mutex
+	sampledAt time.Time
+	live      uint64
+}
+
+var heap = &heapSampler{}
+
+// liveHeap returns the live heap marked by the last garbage collection,
+// derived from the heap size at which the runtime starts the next one.
+// Garbage is not counted, and no collection is forced: a growing heap
+// is collected by the runtime as it reaches the target.
+func (h *heapSampler) liveHeap() uint64 {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if time.Since(h.sampledAt) >= limiterInterval {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		h.live = stats.NextGC * 100 / (100 + gcPercent)
+		h.sampledAt = time.Now()
+	}
+	return h.live
+}
+
+// limiter interrupts a script exceeding the CPU time or memory limit.
+//
+// The interpreter runs a script on a single goroutine, so CPU time is
+// measured as the time spent in the interpreter, excluding calls waiting
+// on the database.
+//
+// The interpreter does not account the memory of a script, so memory is
+// limited in two ways. The data passed between the script and the
+// server, such as params, results and records, is counted exactly when
+// it is passed. Memory allocated by the script itself is measured as the
+// growth of the live heap since the invocation started, which is only
+// known after the runtime collects garbage, and not at all if garbage
+// collection is off. The runtime collects garbage when the heap doubles
+// by default, so a script may allocate about as much as the live heap
+// of the process before it is interrupted.
+// The heap is shared by the invocations running at the same time, so an
+// invocation may be charged for the memory of another one; the limit is
+// an upper bound of the memory an invocation can hold, not an exact
+// measurement.
+type limiter struct {
+	vm          *goja.Runtime
+	cpuLimit    time.Duration
+	memoryLimit uint64
+
+	mutex       sync.Mutex
+	started     time.Time
+	native      time.Duration
+	nativeStart time.Time
+	memory      uint64
+	baseline    uint64
+	done        chan struct{}
+}
+
+func startLimiter(vm *goja.Runtime, cpuLimit time.Duration, memoryLimit uint64) *limiter {
+	l := &limiter{
+		vm:          vm,
+		cpuLimit:    cpuLimit,
+		memoryLimit: memoryLimit,
+		started:     time.Now(),
+		done:        make(chan struct{}),
+	}
+	if memoryLimit > 0 {
+		l.baseline = heap.liveHeap()
+	}
+	go l.watch()
+	return l
+}
+
+func (l *limiter) stop() {
+	close(l.done)
+}
+
+// enterNative pauses the CPU time accounting while the script waits on a
+// call outside the interpreter.
+func (l *limiter) enterNative() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.nativeStart = time.Now()
+}
+
+func (l *limiter) exitNative() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.native += time.Since(l.nativeStart)
+	l.nativeStart = time.Time{}
+}
+
+func (l *limiter) cpuTime() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	used := time.Since(l.started) - l.native
+	if !l.nativeStart.IsZero() {
+		used -= time.Since(l.nativeStart)
+	}
+	return used
+}
+
+// allocate accounts size bytes of data passed to or from the script. If
+// the memory limit is exceeded, the script is interrupted and an error
+// is returned.
+func (l *limiter) allocate(size int) skyerr.Error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.memory += uint64(size)
+	if l.memoryLimit == 0 || l.memory <= l.memoryLimit {
+		return nil
+	}
+
+	err := errMemoryLimit
+	l.vm.Interrupt(err)
+	return err
+}
+
+// heapExceeded reports whether the live heap has grown more than the
+// memory limit since the invocation started.
+func (l *limiter) heapExceeded() bool {
+	if l.memoryLimit == 0 || gcPercent == 0 {
+		return false
+	}
+
+	live := heap.liveHeap()
+	if live < l.baseline {
+		// the memory live when the invocation started has been freed
+		l.baseline = live
+	}
+	return live > l.baseline+l.memoryLimit
+}
+
+var errMemoryLimit = skyerr.NewError(skyerr.UnexpectedError, "script exceeded the memory limit")
+
+func (l *limiter) watch() {
+	if l.cpuLimit == 0 && l.memoryLimit == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(limiterInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+
+		if l.cpuLimit > 0 && l.cpuTime() > l.cpuLimit {
+			l.vm.Interrupt(skyerr.NewError(skyerr.PluginTimeout, "script exceeded the CPU time limit"))
+			return
+		}
+		if l.heapExceeded() {
+			l.vm.Interrupt(errMemoryLimit)
+			return
+		}
+	}
+}
@@ -15,7 +15,9 @@
 package plugin
 
 import (
+	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -31,6 +33,8 @@ type LambdaHandler struct {
 	Name              string
 	AccessKeyRequired bool
 	UserRequired      bool
+	Timeout           time.Duration
+	Idempotent        bool
 
 	AssetStore asset.Store `inject:"AssetStore"`
 
@@ -50,6 +54,9 @@ func NewLambdaHandler(info map[string]interface{}, p *Plugin) *LambdaHandler {
 	}
 	handler.AccessKeyRequired, _ = info["key_required"].(bool)
 	handler.UserRequired, _ = info["user_required"].(bool)
+	timeout, _ := info["timeout"].(float64)
+	handler.Timeout = time.Duration(timeout * float64(time.Second))
+	handler.Idempotent, _ = info["idempotent"].(bool)
 	return handler
 }
 
@@ -89,7 +96,11 @@ func (h *LambdaHandler) Handle(payload *router.Payload, response *router.Respons
 		return
 	}
 
-	outbytes, transportErr := h.Plugin.transport.RunLambda(payload.Context(), h.Name, inbytes)
+	options := callOptions{timeout: h.Timeout, idempotent: h.Idempotent}
+	transportOut, transportErr := h.Plugin.call(payload.Context(), options, func(ctx context.Context) (interface{}, error) {
+		return h.Plugin.transport.RunLambda(ctx, h.Name, inbytes)
+	})
+	outbytes, _ := transportOut.([]byte)
 	if transportErr != nil {
 		switch e := transportErr.(type) {
 		case skyerr.Error:
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/smartystreets/goconvey/convey"
@@ -58,6 +59,17 @@ func TestLambdaCreation(t *testing.T) {
 
 		So(handler.AccessKeyRequired, ShouldBeTrue)
 	})
+
+	Convey("create lambda with timeout", t, func() {
+		handler := NewLambdaHandler(map[string]interface{}{
+			"name":       "hello:world",
+			"timeout":    1.5,
+			"idempotent": true,
+		}, nil)
+
+		So(handler.Timeout, ShouldEqual, 1500*time.Millisecond)
+		So(handler.Idempotent, ShouldBeTrue)
+	})
 }
 
 func TestLambdaHandler(t *testing.T) {
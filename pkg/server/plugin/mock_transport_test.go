@@ -109,6 +109,45 @@ func (_mr *MockTransportMockRecorder) RunHook(arg0, arg1, arg2, arg3, arg4 inter
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RunHook", reflect.TypeOf((*MockTransport)(nil).RunHook), arg0, arg1, arg2, arg3, arg4)
 }
 
+// RunQueryHook mocks base method
+func (_m *MockTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	ret := _m.ctrl.Call(_m, "RunQueryHook", ctx, hookName, query)
+	ret0, _ := ret[0].(*skydb.Query)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunQueryHook indicates an expected call of RunQueryHook
+func (_mr *MockTransportMockRecorder) RunQueryHook(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RunQueryHook", reflect.TypeOf((*MockTransport)(nil).RunQueryHook), arg0, arg1, arg2)
+}
+
+// RunFetchHook mocks base method
+func (_m *MockTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	ret := _m.ctrl.Call(_m, "RunFetchHook", ctx, hookName, records)
+	ret0, _ := ret[0].([]*skydb.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunFetchHook indicates an expected call of RunFetchHook
+func (_mr *MockTransportMockRecorder) RunFetchHook(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RunFetchHook", reflect.TypeOf((*MockTransport)(nil).RunFetchHook), arg0, arg1, arg2)
+}
+
+// RunAuthHook mocks base method
+func (_m *MockTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	ret := _m.ctrl.Call(_m, "RunAuthHook", ctx, hookName, authInfo, user)
+	ret0, _ := ret[0].(*skydb.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunAuthHook indicates an expected call of RunAuthHook
+func (_mr *MockTransportMockRecorder) RunAuthHook(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RunAuthHook", reflect.TypeOf((*MockTransport)(nil).RunAuthHook), arg0, arg1, arg2, arg3)
+}
+
 // RunTimer mocks base method
 func (_m *MockTransport) RunTimer(name string, in []byte) ([]byte, error) {
 	ret := _m.ctrl.Call(_m, "RunTimer", name, in)
@@ -15,6 +15,7 @@
 package plugin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -24,7 +25,6 @@ import (
 
 	"github.com/sirupsen/logrus"
 
-	"github.com/robfig/cron"
 	"github.com/skygeario/skygear-server/pkg/server/logging"
 	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
 	"github.com/skygeario/skygear-server/pkg/server/plugin/provider"
@@ -42,9 +42,26 @@ const (
 // Plugin represents a collection of handlers, hooks and lambda functions
 // that extends or modifies functionality provided by skygear.
 type Plugin struct {
+	name           string
+	path           string
 	initRetryCount int
 	transport      Transport
+	breaker        *CircuitBreaker
 	gatewayMap     map[string]*router.Gateway
+	regInfo        registrationInfo
+
+	// WatchPath is the file or directory of the plugin code. If set, the
+	// plugin is reloaded when a file under the path is modified.
+	WatchPath string
+}
+
+// Status is the status of a plugin reported by the health check.
+type Status struct {
+	Transport string       `json:"transport"`
+	Path      string       `json:"path"`
+	State     string       `json:"state"`
+	Circuit   CircuitState `json:"circuit"`
+	Failures  int          `json:"failures"`
 }
 
 type pluginHandlerInfo struct {
@@ -53,6 +70,8 @@ type pluginHandlerInfo struct {
 	Methods      []string `json:"methods"`
 	KeyRequired  bool     `json:"key_required"`
 	UserRequired bool     `json:"user_required"`
+	Timeout      float64  `json:"timeout"`    // in seconds
+	Idempotent   bool     `json:"idempotent"` // safe to retry
 }
 
 type pluginHookInfo struct {
@@ -60,11 +79,15 @@ type pluginHookInfo struct {
 	Trigger string `json:"trigger"` // before_save etc.
 	Type    string `json:"type"`    // record type
 	Name    string `json:"name"`    // hook name
+
+	Timeout    float64 `json:"timeout"`    // in seconds
+	Idempotent bool    `json:"idempotent"` // safe to retry
 }
 
 type timerInfo struct {
-	Name string `json:"name"`
-	Spec string `json:"spec"`
+	Name    string `json:"name"`
+	Spec    string `json:"spec"`
+	CatchUp bool   `json:"catch_up"`
 }
 
 type providerInfo struct {
@@ -107,12 +130,21 @@ func NewPlugin(name string, path string, args []string, config skyconfig.Configu
 		panic(fmt.Errorf("unable to find plugin transport '%v'", name))
 	}
 	p := Plugin{
+		name:       name,
+		path:       path,
 		transport:  factory.Open(path, args, config),
+		breaker:    NewCircuitBreaker(CircuitBreakerThreshold, CircuitBreakerCooldown),
 		gatewayMap: map[string]*router.Gateway{},
 	}
 	return p
 }
 
+// Scheduler runs the timers registered by plugins at their cron specs.
+type Scheduler interface {
+	AddTimer(name string, spec string, catchUp bool, run func() error) error
+	RemoveTimer(name string)
+}
+
 // Context contains reference to structs that will be initialized by plugin.
 type Context struct {
 	plugins          []*Plugin
@@ -121,8 +153,10 @@ type Context struct {
 	HandlerInjector  router.HandlerInjector
 	HookRegistry     *hook.Registry
 	ProviderRegistry *provider.Registry
-	Scheduler        *cron.Cron
+	Scheduler        Scheduler
 	Config           skyconfig.Configuration
+	lambdas          map[string]*Plugin
+	reloadMutex      sync.Mutex
 	sync.Mutex
 }
 
@@ -195,6 +229,58 @@ func (c *Context) IsReady() bool {
 	return true
 }
 
+// Statuses returns the status of each plugin, including the state of its
+// circuit breaker.
+func (c *Context) Statuses() []Status {
+	statuses := make([]Status, 0, len(c.plugins))
+	for _, eachPlugin := range c.plugins {
+		statuses = append(statuses, eachPlugin.Status())
+	}
+	return statuses
+}
+
+// RunLambda runs the lambda registered by a plugin with the arguments in
+// JSON. Unlike calling the lambda through the router, the lambda is run
+// without preprocessors and the response timeout, so that it can be run
+// outside of a request, such as by the job queue.
+func (c *Context) RunLambda(ctx context.Context, name string, in []byte) ([]byte, error) {
+	c.Lock()
+	p, ok := c.lambdas[name]
+	c.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("lambda %s is not registered", name)
+	}
+
+	out, err := p.call(ctx, callOptions{}, func(ctx context.Context) (interface{}, error) {
+		return p.transport.RunLambda(ctx, name, in)
+	})
+	outbytes, _ := out.([]byte)
+	return outbytes, err
+}
+
+// registerLambdas records the plugin of the lambdas for RunLambda. The
+// context must be locked by the caller.
+func (c *Context) registerLambdas(p *Plugin, lambdas []map[string]interface{}) {
+	if c.lambdas == nil {
+		c.lambdas = map[string]*Plugin{}
+	}
+	for _, lambda := range lambdas {
+		if name, ok := lambda["name"].(string); ok {
+			c.lambdas[name] = p
+		}
+	}
+}
+
+// unregisterLambdas removes the lambdas of the plugin from RunLambda. The
+// context must be locked by the caller.
+func (c *Context) unregisterLambdas(p *Plugin, lambdas []map[string]interface{}) {
+	for _, lambda := range lambdas {
+		if name, ok := lambda["name"].(string); ok && c.lambdas[name] == p {
+			delete(c.lambdas, name)
+		}
+	}
+}
+
 // SendEvent sends event to all plugins
 //
 // SendEvent accepts `async` flag. Setting `async` to `false` means that
@@ -285,6 +371,24 @@ func (p *Plugin) IsReady() bool {
 	return p.transport.State() == TransportStateReady
 }
 
+// Status returns the status of the plugin.
+func (p *Plugin) Status() Status {
+	status := Status{
+		Transport: p.name,
+		Path:      p.path,
+		State:     p.transport.State().String(),
+		Circuit:   CircuitClosed,
+	}
+	if p.breaker != nil {
+		status.Circuit = p.breaker.State()
+		status.Failures = p.breaker.Failures()
+	}
+	return status
+}
+
+// processRegistrationInfo registers the handlers, lambdas, hooks, timers
+// and providers of the plugin, replacing the ones registered previously
+// by the plugin, if any.
 func (p *Plugin) processRegistrationInfo(context *Context, regInfo registrationInfo) {
 	context.Lock()
 	defer context.Unlock()
@@ -292,15 +396,32 @@ func (p *Plugin) processRegistrationInfo(context *Context, regInfo registrationI
 		"regInfo":   regInfo,
 		"transport": p.transport,
 	}).Debugln("Got configuration from plugin, registering")
+	oldInfo := p.regInfo
+
 	p.initHandler(context.Mux, context.HandlerInjector, regInfo.Handlers, context.Config)
+	p.removeHandler(oldInfo.Handlers, regInfo.Handlers)
+
 	p.initLambda(context.Router, context.HandlerInjector, regInfo.Lambdas)
-	p.initHook(context.HookRegistry, regInfo.Hooks)
+	p.removeLambda(context.Router, oldInfo.Lambdas, regInfo.Lambdas)
+	context.unregisterLambdas(p, oldInfo.Lambdas)
+	context.registerLambdas(p, regInfo.Lambdas)
+
+	hookSet := hook.NewRegistry()
+	p.initHook(hookSet, regInfo.Hooks)
+	context.HookRegistry.ReplaceSet(p.name+":"+p.path, hookSet)
+
 	if context.Scheduler != nil {
-		p.initTimer(context.Scheduler, regInfo.Timers)
+		removed, added := diffTimers(oldInfo.Timers, regInfo.Timers)
+		p.removeTimer(context.Scheduler, removed)
+		p.initTimer(context.Scheduler, added)
 	} else {
 		log.Info("Ignoring scheduled cron jobs because server is in slave mode.")
 	}
+
 	p.initProvider(context.ProviderRegistry, regInfo.Providers)
+	p.removeProvider(context.ProviderRegistry, oldInfo.Providers, regInfo.Providers)
+
+	p.regInfo = regInfo
 }
 
 func (p *Plugin) initHandler(mux *http.ServeMux, injector router.HandlerInjector, handlers []pluginHandlerInfo, config skyconfig.Configuration) {
@@ -308,11 +429,7 @@ func (p *Plugin) initHandler(mux *http.ServeMux, injector router.HandlerInjector
 		h := NewPluginHandler(handler, p)
 		injector.Inject(h)
 		h.Setup()
-		name := h.Name
-		name = strings.Replace(name, ":", "/", -1)
-		if !strings.HasPrefix(name, "/") {
-			name = "/" + name
-		}
+		name := handlerPath(h.Name)
 		var handlerGateway *router.Gateway
 		handlerGateway, ok := p.gatewayMap[name]
 		if !ok {
@@ -327,6 +444,39 @@ func (p *Plugin) initHandler(mux *http.ServeMux, injector router.HandlerInjector
 	}
 }
 
+// removeHandler removes the methods of the old handlers that are not
+// handled by the new handlers.
+func (p *Plugin) removeHandler(oldHandlers []pluginHandlerInfo, newHandlers []pluginHandlerInfo) {
+	handled := map[string]bool{}
+	for _, handler := range newHandlers {
+		for _, method := range handler.Methods {
+			handled[method+" "+handlerPath(handler.Name)] = true
+		}
+	}
+
+	for _, handler := range oldHandlers {
+		name := handlerPath(handler.Name)
+		handlerGateway, ok := p.gatewayMap[name]
+		if !ok {
+			continue
+		}
+		for _, method := range handler.Methods {
+			if !handled[method+" "+name] {
+				handlerGateway.Remove(method)
+				log.Debugf(`Removed handler "%s" of method %s`, handler.Name, method)
+			}
+		}
+	}
+}
+
+func handlerPath(name string) string {
+	name = strings.Replace(name, ":", "/", -1)
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return name
+}
+
 func (p *Plugin) initLambda(r *router.Router, injector router.HandlerInjector, lambdas []map[string]interface{}) {
 	for _, lambda := range lambdas {
 		handler := NewLambdaHandler(lambda, p)
@@ -337,21 +487,51 @@ func (p *Plugin) initLambda(r *router.Router, injector router.HandlerInjector, l
 	}
 }
 
+// removeLambda unmaps the old lambdas that are not in the new lambdas.
+func (p *Plugin) removeLambda(r *router.Router, oldLambdas []map[string]interface{}, newLambdas []map[string]interface{}) {
+	names := map[string]bool{}
+	for _, lambda := range newLambdas {
+		if name, ok := lambda["name"].(string); ok {
+			names[name] = true
+		}
+	}
+
+	for _, lambda := range oldLambdas {
+		if name, ok := lambda["name"].(string); ok && !names[name] {
+			r.Unmap(name)
+			log.Debugf(`Removed lambda "%s" from router.`, name)
+		}
+	}
+}
+
 func (p *Plugin) initHook(registry *hook.Registry, hookInfos []pluginHookInfo) {
 	for _, hookInfo := range hookInfos {
 		kind := hook.Kind(hookInfo.Trigger)
 		recordType := hookInfo.Type
 
-		registry.Register(kind, recordType, CreateHookFunc(p, hookInfo))
+		switch {
+		case kind == hook.BeforeQuery:
+			registry.RegisterQueryHook(recordType, CreateQueryHookFunc(p, hookInfo))
+		case kind == hook.AfterFetch:
+			registry.RegisterFetchHook(recordType, CreateFetchHookFunc(p, hookInfo))
+		case hook.IsAuthKind(kind):
+			registry.RegisterAuthHook(kind, CreateAuthHookFunc(p, hookInfo))
+		default:
+			registry.Register(kind, recordType, CreateHookFunc(p, hookInfo))
+		}
 	}
 }
 
-func (p *Plugin) initTimer(c *cron.Cron, timerInfos []timerInfo) {
+func (p *Plugin) initTimer(scheduler Scheduler, timerInfos []timerInfo) {
 	for _, timerInfo := range timerInfos {
 		timerName := timerInfo.Name
-		err := c.AddFunc(timerInfo.Spec, func() {
-			output, _ := p.transport.RunTimer(timerName, []byte{})
-			log.Debugf("Executed a timer{%v} with result: %s", timerName, output)
+		err := scheduler.AddTimer(timerName, timerInfo.Spec, timerInfo.CatchUp, func() error {
+			_, err := p.call(context.Background(), callOptions{}, func(context.Context) (interface{}, error) {
+				output, err := p.transport.RunTimer(timerName, []byte{})
+				log.Debugf("Executed a timer{%v} with result: %s", timerName, output)
+				return nil, err
+			})
+			return err
 		})
 
 		if err != nil {
@@ -360,9 +540,56 @@ func (p *Plugin) initTimer(c *cron.Cron, timerInfos []timerInfo) {
 	}
 }
 
+// removeTimer removes the timers from the scheduler.
+func (p *Plugin) removeTimer(scheduler Scheduler, timerInfos []timerInfo) {
+	for _, timerInfo := range timerInfos {
+		scheduler.RemoveTimer(timerInfo.Name)
+	}
+}
+
+// diffTimers returns the old timers to be removed and the new timers to be
+// added. A timer changed is both removed and added.
+func diffTimers(oldTimers []timerInfo, newTimers []timerInfo) (removed []timerInfo, added []timerInfo) {
+	oldTimerMap := map[string]timerInfo{}
+	for _, timerInfo := range oldTimers {
+		oldTimerMap[timerInfo.Name] = timerInfo
+	}
+	newTimerMap := map[string]timerInfo{}
+	for _, timerInfo := range newTimers {
+		newTimerMap[timerInfo.Name] = timerInfo
+	}
+
+	for _, timerInfo := range oldTimers {
+		if newTimerMap[timerInfo.Name] != timerInfo {
+			removed = append(removed, timerInfo)
+		}
+	}
+	for _, timerInfo := range newTimers {
+		if oldTimerMap[timerInfo.Name] != timerInfo {
+			added = append(added, timerInfo)
+		}
+	}
+	return
+}
+
 func (p *Plugin) initProvider(registry *provider.Registry, providerInfos []providerInfo) {
 	for _, providerInfo := range providerInfos {
 		provider := NewAuthProvider(providerInfo.Name, p)
 		registry.RegisterAuthProvider(providerInfo.Name, provider)
 	}
 }
+
+// removeProvider unregisters the old providers that are not in the new
+// providers.
+func (p *Plugin) removeProvider(registry *provider.Registry, oldInfos []providerInfo, newInfos []providerInfo) {
+	names := map[string]bool{}
+	for _, providerInfo := range newInfos {
+		names[providerInfo.Name] = true
+	}
+
+	for _, providerInfo := range oldInfos {
+		if !names[providerInfo.Name] {
+			registry.UnregisterAuthProvider(providerInfo.Name)
+		}
+	}
+}
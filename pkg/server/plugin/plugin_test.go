@@ -15,21 +15,40 @@
 package plugin
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
 	"github.com/facebookgo/inject"
-	"github.com/robfig/cron"
 	. "github.com/smartystreets/goconvey/convey"
 
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skyconfig"
+	"github.com/skygeario/skygear-server/pkg/server/timer"
 )
 
 type ContextKey string
 
 var HelloContextKey ContextKey = "hello"
 
+type fakeScheduler struct {
+	names    []string
+	catchUps []bool
+	runs     []func() error
+	removed  []string
+}
+
+func (s *fakeScheduler) AddTimer(name string, spec string, catchUp bool, run func() error) error {
+	s.names = append(s.names, name)
+	s.catchUps = append(s.catchUps, catchUp)
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+func (s *fakeScheduler) RemoveTimer(name string) {
+	s.removed = append(s.removed, name)
+}
+
 type MockPluginReadyPreprocessor struct{}
 
 func (p MockPluginReadyPreprocessor) Preprocess(payload *router.Payload, response *router.Response) int {
@@ -81,15 +100,62 @@ func TestPlugin(t *testing.T) {
 		RegisterTransport("null", nullFactory{})
 		plugin := NewPlugin("null", "/tmp/nonexistent", []string{}, config)
 
-		c := cron.New()
+		scheduler := timer.NewScheduler(nil)
 		panicFunc := func() {
-			plugin.initTimer(c, []timerInfo{
-				{"timerName", "incorrect-spec"},
+			plugin.initTimer(scheduler, []timerInfo{
+				{Name: "timerName", Spec: "incorrect-spec"},
 			})
 		}
 		So(panicFunc, ShouldPanic)
 	})
 
+	Convey("register timer to scheduler", t, func() {
+		RegisterTransport("null", nullFactory{})
+		plugin := NewPlugin("null", "/tmp/nonexistent", []string{}, config)
+
+		scheduler := &fakeScheduler{}
+		plugin.initTimer(scheduler, []timerInfo{
+			{Name: "cleanup", Spec: "0 0 * * * *", CatchUp: true},
+		})
+		So(scheduler.names, ShouldResemble, []string{"cleanup"})
+		So(scheduler.catchUps, ShouldResemble, []bool{true})
+		So(scheduler.runs[0](), ShouldBeNil)
+	})
+
+	Convey("run lambda registered by plugin", t, func() {
+		transport := &fakeTransport{outBytes: []byte(`{"ok":true}`)}
+		plugin := &Plugin{transport: transport}
+		pluginContext := &Context{}
+		pluginContext.registerLambdas(plugin, []map[string]interface{}{
+			{"name": "send_email"},
+		})
+
+		ctx := context.WithValue(context.Background(), HelloContextKey, "world")
+		out, err := pluginContext.RunLambda(ctx, "send_email", []byte(`{}`))
+		So(err, ShouldBeNil)
+		So(string(out), ShouldEqual, `{"ok":true}`)
+		So(transport.lastContext.Value(HelloContextKey), ShouldEqual, "world")
+
+		_, err = pluginContext.RunLambda(ctx, "unknown", []byte(`{}`))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("report statuses of plugins", t, func() {
+		RegisterTransport("null", nullFactory{})
+		pluginContext := &Context{}
+		plugin := pluginContext.AddPluginConfiguration("null", "/tmp/nonexistent", []string{})
+		plugin.transport.SetState(TransportStateReady)
+
+		So(pluginContext.Statuses(), ShouldResemble, []Status{
+			{
+				Transport: "null",
+				Path:      "/tmp/nonexistent",
+				State:     "TransportStateReady",
+				Circuit:   CircuitClosed,
+			},
+		})
+	})
+
 	Convey("init handler", t, func() {
 		RegisterTransport("null", nullFactory{})
 		plugin := NewPlugin("null", "/tmp/nonexistent", []string{}, config)
@@ -0,0 +1,293 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oidc implements an AuthProvider that authenticates users with
+// an OpenID Connect provider natively, without a plugin.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+const discoveryPath = "/.well-known/openid-configuration"
+
+var validSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// Config is the configuration of an OpenID Connect provider.
+//
+// Issuer and ClientID are required. Endpoints that are not specified
+// are discovered from the OpenID Provider Configuration document of
+// the issuer.
+type Config struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	TokenEndpoint string
+	JWKSURI       string
+}
+
+type discoveryDocument struct {
+	Issuer        string `json:"issuer"`
+	TokenEndpoint string `json:"token_endpoint"`
+	JWKSURI       string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Provider is an AuthProvider backed by an OpenID Connect provider.
+//
+// Login accepts either an authorization code obtained by the client with
+// PKCE (code, redirect_uri and code_verifier), which is exchanged for an
+// ID token at the token endpoint, or an ID token obtained by the client
+// directly (id_token).
+//
+// An optional nonce is checked against the nonce claim of the ID token.
+// The principal ID of the user is the provider name and the subject of
+// the ID token, separated by a colon.
+type Provider struct {
+	Name       string
+	config     Config
+	httpClient *http.Client
+
+	discoverMutex sync.Mutex
+	discovered    bool
+	keys          *keySet
+}
+
+// NewProvider creates a new Provider.
+func NewProvider(name string, config Config) *Provider {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	p := &Provider{
+		Name:   name,
+		config: config,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+	p.keys = newKeySet(p.httpClient)
+	return p
+}
+
+// Login verifies the ID token of the user and returns the claims of the
+// ID token as auth data.
+func (p *Provider) Login(ctx context.Context, authData map[string]interface{}) (principalID string, newAuthData map[string]interface{}, err error) {
+	if err = p.discover(ctx); err != nil {
+		return
+	}
+
+	idToken, _ := authData["id_token"].(string)
+	if code, _ := authData["code"].(string); code != "" {
+		redirectURI, _ := authData["redirect_uri"].(string)
+		codeVerifier, _ := authData["code_verifier"].(string)
+		idToken, err = p.exchangeCode(ctx, code, redirectURI, codeVerifier)
+		if err != nil {
+			return
+		}
+	}
+	if idToken == "" {
+		err = errors.New("oidc: either code or id_token is required")
+		return
+	}
+
+	nonce, _ := authData["nonce"].(string)
+	claims, err := p.verifyIDToken(ctx, idToken, nonce)
+	if err != nil {
+		return
+	}
+
+	principalID = p.Name + ":" + claims["sub"].(string)
+	newAuthData = map[string]interface{}(claims)
+	return
+}
+
+// Logout does nothing as the ID token is not kept by Skygear.
+func (p *Provider) Logout(ctx context.Context, authData map[string]interface{}) (newAuthData map[string]interface{}, err error) {
+	newAuthData = authData
+	return
+}
+
+// Info returns the auth data saved on login.
+func (p *Provider) Info(ctx context.Context, authData map[string]interface{}) (newAuthData map[string]interface{}, err error) {
+	newAuthData = authData
+	return
+}
+
+// discover fills in endpoints not specified in config from the OpenID
+// Provider Configuration document of the issuer. The document is fetched
+// again on next call if discovery fails.
+func (p *Provider) discover(ctx context.Context) error {
+	p.discoverMutex.Lock()
+	defer p.discoverMutex.Unlock()
+
+	if p.discovered || (p.config.TokenEndpoint != "" && p.config.JWKSURI != "") {
+		p.discovered = true
+		return nil
+	}
+
+	doc := discoveryDocument{}
+	if err := getJSON(ctx, p.httpClient, p.config.Issuer+discoveryPath, &doc); err != nil {
+		return err
+	}
+
+	if strings.TrimSuffix(doc.Issuer, "/") != p.config.Issuer {
+		return fmt.Errorf("oidc: issuer in discovery document %s does not match %s", doc.Issuer, p.config.Issuer)
+	}
+
+	if p.config.TokenEndpoint == "" {
+		p.config.TokenEndpoint = doc.TokenEndpoint
+	}
+	if p.config.JWKSURI == "" {
+		p.config.JWKSURI = doc.JWKSURI
+	}
+	p.discovered = true
+	return nil
+}
+
+func (p *Provider) exchangeCode(ctx context.Context, code string, redirectURI string, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", p.config.ClientID)
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+	if codeVerifier != "" {
+		form.Set("code_verifier", codeVerifier)
+	}
+
+	req, err := http.NewRequest("POST", p.config.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	token := tokenResponse{}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("oidc: unable to decode token response: %v", err)
+	}
+
+	if token.Error != "" {
+		return "", fmt.Errorf("oidc: token endpoint returned %s: %s", token.Error, token.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc: token endpoint returned status %d", resp.StatusCode)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("oidc: token response does not contain id_token")
+	}
+	return token.IDToken, nil
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, idToken string, nonce string) (jwt.MapClaims, error) {
+	parser := jwt.Parser{ValidMethods: validSigningMethods}
+	token, err := parser.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.Get(ctx, p.config.JWKSURI, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("oidc: invalid id token")
+	}
+
+	if !claims.VerifyIssuer(p.config.Issuer, true) {
+		return nil, errors.New("oidc: unexpected id token issuer")
+	}
+
+	if !verifyAudience(claims["aud"], p.config.ClientID) {
+		return nil, errors.New("oidc: unexpected id token audience")
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("oidc: id token does not expire")
+	}
+
+	if nonce != "" {
+		if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+			return nil, errors.New("oidc: id token nonce mismatch")
+		}
+	}
+
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("oidc: id token does not contain subject")
+	}
+
+	return claims, nil
+}
+
+func verifyAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("oidc: %s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,209 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProvider(t *testing.T) {
+	Convey("Provider", t, func() {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		So(err, ShouldBeNil)
+
+		var issuer string
+		var tokenRequest map[string]string
+		var idToken string
+		jwksRequested := 0
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]string{
+				"issuer":         issuer,
+				"token_endpoint": issuer + "/token",
+				"jwks_uri":       issuer + "/jwks",
+			})
+		})
+		mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+			jwksRequested++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"keys": []map[string]string{
+					{
+						"kty": "RSA",
+						"kid": "key1",
+						"use": "sig",
+						"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+						"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+					},
+				},
+			})
+		})
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			tokenRequest = map[string]string{}
+			for key := range r.PostForm {
+				tokenRequest[key] = r.PostForm.Get(key)
+			}
+			if r.PostForm.Get("code") != "valid-code" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "invalid_grant",
+				})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{
+				"access_token": "access-token",
+				"id_token":     idToken,
+			})
+		})
+		server := httptest.NewServer(mux)
+		defer server.Close()
+		issuer = server.URL
+
+		signToken := func(kid string, claims jwt.MapClaims) string {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			token.Header["kid"] = kid
+			signed, err := token.SignedString(privateKey)
+			So(err, ShouldBeNil)
+			return signed
+		}
+
+		validClaims := func() jwt.MapClaims {
+			return jwt.MapClaims{
+				"iss":   issuer,
+				"sub":   "user1",
+				"aud":   "client-id",
+				"exp":   time.Now().Add(time.Hour).Unix(),
+				"iat":   time.Now().Unix(),
+				"nonce": "nonce1",
+				"email": "user1@example.com",
+			}
+		}
+
+		provider := NewProvider("example", Config{
+			Issuer:       issuer,
+			ClientID:     "client-id",
+			ClientSecret: "client-secret",
+		})
+		ctx := context.Background()
+
+		Convey("login with authorization code", func() {
+			idToken = signToken("key1", validClaims())
+			principalID, authData, err := provider.Login(ctx, map[string]interface{}{
+				"code":          "valid-code",
+				"redirect_uri":  "https://example.com/callback",
+				"code_verifier": "verifier",
+				"nonce":         "nonce1",
+			})
+			So(err, ShouldBeNil)
+			So(principalID, ShouldEqual, "example:user1")
+			So(authData["email"], ShouldEqual, "user1@example.com")
+			So(tokenRequest, ShouldResemble, map[string]string{
+				"grant_type":    "authorization_code",
+				"code":          "valid-code",
+				"redirect_uri":  "https://example.com/callback",
+				"client_id":     "client-id",
+				"client_secret": "client-secret",
+				"code_verifier": "verifier",
+			})
+		})
+
+		Convey("login with id token", func() {
+			principalID, _, err := provider.Login(ctx, map[string]interface{}{
+				"id_token": signToken("key1", validClaims()),
+			})
+			So(err, ShouldBeNil)
+			So(principalID, ShouldEqual, "example:user1")
+		})
+
+		Convey("reject invalid authorization code", func() {
+			_, _, err := provider.Login(ctx, map[string]interface{}{
+				"code": "invalid-code",
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("reject id token with wrong audience", func() {
+			claims := validClaims()
+			claims["aud"] = []interface{}{"other-client"}
+			_, _, err := provider.Login(ctx, map[string]interface{}{
+				"id_token": signToken("key1", claims),
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("reject id token with wrong issuer", func() {
+			claims := validClaims()
+			claims["iss"] = "https://evil.example.com"
+			_, _, err := provider.Login(ctx, map[string]interface{}{
+				"id_token": signToken("key1", claims),
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("reject expired id token", func() {
+			claims := validClaims()
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			_, _, err := provider.Login(ctx, map[string]interface{}{
+				"id_token": signToken("key1", claims),
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("reject id token with mismatched nonce", func() {
+			_, _, err := provider.Login(ctx, map[string]interface{}{
+				"id_token": signToken("key1", validClaims()),
+				"nonce":    "nonce2",
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("reject id token signed by unknown key", func() {
+			_, _, err := provider.Login(ctx, map[string]interface{}{
+				"id_token": signToken("key1", validClaims()),
+			})
+			So(err, ShouldBeNil)
+			So(jwksRequested, ShouldEqual, 1)
+
+			_, _, err = provider.Login(ctx, map[string]interface{}{
+				"id_token": signToken("key2", validClaims()),
+			})
+			So(err, ShouldNotBeNil)
+			So(jwksRequested, ShouldEqual, 1)
+		})
+
+		Convey("reject id token signed with HMAC", func() {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+			token.Header["kid"] = "key1"
+			signed, _ := token.SignedString([]byte("secret"))
+			_, _, err := provider.Login(ctx, map[string]interface{}{
+				"id_token": signed,
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
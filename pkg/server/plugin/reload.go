@@ -0,0 +1,208 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReloadResult is the outcome of reloading a plugin, telling the
+// registrations added and removed by the reload.
+type ReloadResult struct {
+	Transport string   `json:"transport"`
+	Path      string   `json:"path"`
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+}
+
+// ReloadPlugins reloads all plugins one after another, stopping at the
+// first plugin failing to reload.
+func (c *Context) ReloadPlugins() ([]ReloadResult, error) {
+	results := make([]ReloadResult, 0, len(c.plugins))
+	for _, eachPlugin := range c.plugins {
+		result, err := eachPlugin.Reload(c)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// WatchPlugins reloads the plugins with WatchPath whenever their code is
+// modified, checking at the interval. For the exec transport, which
+// starts a process for each call, this picks up changes to the plugin
+// without restarting the server.
+func (c *Context) WatchPlugins(interval time.Duration) {
+	for _, eachPlugin := range c.plugins {
+		if eachPlugin.WatchPath != "" {
+			go eachPlugin.watch(c, interval)
+		}
+	}
+}
+
+// Reload runs the init handshake with the plugin again and replaces the
+// registrations of the plugin with the ones returned, so that the changes
+// to its handlers, lambdas, hooks, timers and providers take effect
+// without restarting the server. Requests in progress are completed by
+// the registrations they are matched to. The registrations are unchanged
+// if the handshake fails.
+func (p *Plugin) Reload(context *Context) (ReloadResult, error) {
+	context.reloadMutex.Lock()
+	defer context.reloadMutex.Unlock()
+
+	result := ReloadResult{
+		Transport: p.name,
+		Path:      p.path,
+	}
+	if !p.IsInitialized() {
+		return result, fmt.Errorf("plugin %s is not initialized", p.path)
+	}
+
+	data, err := context.getInitPayload()
+	if err != nil {
+		return result, err
+	}
+
+	p.transport.SendEvent("before-config", data)
+	regInfo, err := p.requestInit(data)
+	if err != nil {
+		return result, err
+	}
+
+	result.Added, result.Removed = diffRegistrationInfo(p.regInfo, regInfo)
+	p.processRegistrationInfo(context, regInfo)
+	p.transport.SendEvent("after-config", data)
+
+	log.WithFields(logrus.Fields{
+		"path":    p.path,
+		"added":   result.Added,
+		"removed": result.Removed,
+	}).Info("Reloaded plugin")
+	return result, nil
+}
+
+func (p *Plugin) watch(context *Context, interval time.Duration) {
+	logger := log.WithField("path", p.WatchPath)
+	modTime, err := latestModTime(p.WatchPath)
+	if err != nil {
+		logger.WithError(err).Warn("Unable to check plugin files")
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		latest, err := latestModTime(p.WatchPath)
+		if err != nil {
+			logger.WithError(err).Warn("Unable to check plugin files")
+			continue
+		}
+
+		// changes made during plugin initialization are picked up
+		// after the plugin is ready
+		if !latest.After(modTime) || !p.IsReady() {
+			continue
+		}
+		modTime = latest
+
+		logger.Info("Plugin files modified, reloading plugin")
+		if _, err := p.Reload(context); err != nil {
+			logger.WithError(err).Error("Fail to reload plugin")
+		}
+	}
+}
+
+// latestModTime returns the latest modification time of the files under
+// path, skipping hidden files and compiled python files, which are
+// written when the plugin is run.
+func latestModTime(path string) (time.Time, error) {
+	var latest time.Time
+	err := filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		base := info.Name()
+		if name != path && (strings.HasPrefix(base, ".") || base == "__pycache__") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(base, ".pyc") {
+			return nil
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest, err
+}
+
+// diffRegistrationInfo returns the registrations in newInfo but not in
+// oldInfo, and the ones in oldInfo but not in newInfo.
+func diffRegistrationInfo(oldInfo registrationInfo, newInfo registrationInfo) (added []string, removed []string) {
+	oldKeys := registrationKeys(oldInfo)
+	newKeys := registrationKeys(newInfo)
+
+	added = []string{}
+	for key := range newKeys {
+		if !oldKeys[key] {
+			added = append(added, key)
+		}
+	}
+	removed = []string{}
+	for key := range oldKeys {
+		if !newKeys[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return
+}
+
+func registrationKeys(regInfo registrationInfo) map[string]bool {
+	keys := map[string]bool{}
+	for _, handler := range regInfo.Handlers {
+		for _, method := range handler.Methods {
+			keys[fmt.Sprintf("handler:%s %s", method, handler.Name)] = true
+		}
+	}
+	for _, lambda := range regInfo.Lambdas {
+		if name, ok := lambda["name"].(string); ok {
+			keys["lambda:"+name] = true
+		}
+	}
+	for _, hook := range regInfo.Hooks {
+		keys[fmt.Sprintf("hook:%s:%s:%s", hook.Trigger, hook.Type, hook.Name)] = true
+	}
+	for _, timer := range regInfo.Timers {
+		keys[fmt.Sprintf("timer:%s %s", timer.Name, timer.Spec)] = true
+	}
+	for _, provider := range regInfo.Providers {
+		keys["provider:"+provider.Name] = true
+	}
+	return keys
+}
@@ -0,0 +1,195 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/facebookgo/inject"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/asset"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/provider"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+type reloadTransport struct {
+	nullTransport
+	regInfo string
+	hooks   []string
+}
+
+func (t *reloadTransport) SendEvent(name string, in []byte) (out []byte, err error) {
+	if name == "init" {
+		out = []byte(t.regInfo)
+	}
+	return
+}
+
+func (t *reloadTransport) RunHook(ctx context.Context, hookName string, record *skydb.Record, oldRecord *skydb.Record, async bool) (*skydb.Record, error) {
+	t.hooks = append(t.hooks, hookName)
+	return record, nil
+}
+
+func TestPluginReload(t *testing.T) {
+	Convey("reload plugin", t, func() {
+		transport := &reloadTransport{
+			regInfo: `{
+				"op": [{"name": "hello"}],
+				"hook": [{"trigger": "afterSave", "type": "note", "name": "notify"}],
+				"timer": [{"name": "cleanup", "spec": "0 0 * * * *"}],
+				"provider": [{"type": "auth", "id": "com.example"}]
+			}`,
+		}
+		plugin := &Plugin{
+			name:       "null",
+			path:       "/tmp/nonexistent",
+			transport:  transport,
+			gatewayMap: map[string]*router.Gateway{},
+		}
+		scheduler := &fakeScheduler{}
+		graph := &inject.Graph{}
+		graph.Provide(&inject.Object{
+			Value:    asset.NewFileStore("/tmp/nonexistent", "", "", false),
+			Complete: true,
+			Name:     "AssetStore",
+		})
+		pluginContext := &Context{
+			plugins:          []*Plugin{plugin},
+			Router:           router.NewRouter(),
+			Mux:              http.NewServeMux(),
+			HookRegistry:     hook.NewRegistry(),
+			ProviderRegistry: provider.NewRegistry(),
+			Scheduler:        scheduler,
+			HandlerInjector: router.HandlerInjector{
+				ServiceGraph: graph,
+				PreprocessorMap: &router.PreprocessorRegistry{
+					"inject_auth_id": MockInjectAuthIDPreprocessor{},
+					"plugin_ready":   MockPluginReadyPreprocessor{},
+					"authenticator":  MockNullPreprocessor{},
+					"dbconn":         MockNullPreprocessor{},
+					"require_auth":   MockNullPreprocessor{},
+					"check_user":     MockNullPreprocessor{},
+				},
+			},
+		}
+		plugin.Init(pluginContext)
+
+		record := &skydb.Record{ID: skydb.NewRecordID("note", "1")}
+		pluginContext.HookRegistry.ExecuteHooks(context.Background(), hook.AfterSave, record, nil)
+		So(transport.hooks, ShouldResemble, []string{"notify"})
+
+		Convey("replaces registrations", func() {
+			transport.regInfo = `{
+				"op": [{"name": "bye"}],
+				"hook": [{"trigger": "afterSave", "type": "note", "name": "audit"}],
+				"timer": [{"name": "cleanup", "spec": "0 30 * * * *"}]
+			}`
+
+			results, err := pluginContext.ReloadPlugins()
+			So(err, ShouldBeNil)
+			So(results, ShouldResemble, []ReloadResult{
+				{
+					Transport: "null",
+					Path:      "/tmp/nonexistent",
+					Added: []string{
+						"hook:afterSave:note:audit",
+						"lambda:bye",
+						"timer:cleanup 0 30 * * * *",
+					},
+					Removed: []string{
+						"hook:afterSave:note:notify",
+						"lambda:hello",
+						"provider:com.example",
+						"timer:cleanup 0 0 * * * *",
+					},
+				},
+			})
+
+			So(pluginContext.lambdas, ShouldContainKey, "bye")
+			So(pluginContext.lambdas, ShouldNotContainKey, "hello")
+			So(scheduler.removed, ShouldResemble, []string{"cleanup"})
+			So(scheduler.names, ShouldResemble, []string{"cleanup", "cleanup"})
+
+			_, err = pluginContext.ProviderRegistry.GetAuthProvider("com.example")
+			So(err, ShouldNotBeNil)
+
+			transport.hooks = nil
+			pluginContext.HookRegistry.ExecuteHooks(context.Background(), hook.AfterSave, record, nil)
+			So(transport.hooks, ShouldResemble, []string{"audit"})
+		})
+
+		Convey("keeps registrations if plugin fails to reload", func() {
+			transport.regInfo = `malformed`
+
+			_, err := pluginContext.ReloadPlugins()
+			So(err, ShouldNotBeNil)
+			So(pluginContext.lambdas, ShouldContainKey, "hello")
+
+			transport.hooks = nil
+			pluginContext.HookRegistry.ExecuteHooks(context.Background(), hook.AfterSave, record, nil)
+			So(transport.hooks, ShouldResemble, []string{"notify"})
+		})
+
+		Convey("does not reload uninitialized plugin", func() {
+			transport.SetState(TransportStateUninitialized)
+
+			_, err := plugin.Reload(pluginContext)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestLatestModTime(t *testing.T) {
+	Convey("latestModTime", t, func() {
+		dir, err := ioutil.TempDir("", "plugin")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		past := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		later := past.Add(time.Hour)
+		writeFile := func(name string, modTime time.Time) {
+			path := filepath.Join(dir, name)
+			So(os.MkdirAll(filepath.Dir(path), 0755), ShouldBeNil)
+			So(ioutil.WriteFile(path, []byte{}, 0644), ShouldBeNil)
+			So(os.Chtimes(path, modTime, modTime), ShouldBeNil)
+		}
+
+		writeFile("plugin/__init__.py", past)
+		writeFile("plugin/__pycache__/__init__.pyc", later)
+		writeFile(".git/index", later)
+		So(os.Chtimes(filepath.Join(dir, "plugin"), past, past), ShouldBeNil)
+		So(os.Chtimes(dir, past, past), ShouldBeNil)
+
+		latest, err := latestModTime(dir)
+		So(err, ShouldBeNil)
+		So(latest.Equal(past), ShouldBeTrue)
+
+		writeFile("plugin/note.py", later)
+		So(os.Chtimes(filepath.Join(dir, "plugin"), past, past), ShouldBeNil)
+
+		latest, err = latestModTime(dir)
+		So(err, ShouldBeNil)
+		So(latest.Equal(later), ShouldBeTrue)
+	})
+}
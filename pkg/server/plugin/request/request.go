@@ -17,8 +17,11 @@ package request
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	skyplugin "github.com/skygeario/skygear-server/pkg/server/plugin"
+	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
 	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
 )
@@ -62,6 +65,157 @@ func NewHookRequest(ctx context.Context, hookName string, record *skydb.Record,
 	return &Request{Kind: "hook", Name: hookName, Param: param, Context: ctx, Async: async}
 }
 
+// QueryHookRequest contains the query of a beforeQuery hook.
+type QueryHookRequest struct {
+	Query RawQuery `json:"query"`
+}
+
+// RawQuery is the query passed to and returned from a beforeQuery hook.
+// Only the predicate may be rewritten by the hook.
+type RawQuery struct {
+	Type      string        `json:"record_type"`
+	Predicate []interface{} `json:"predicate,omitempty"`
+}
+
+// FetchHookRequest contains the fetched records of an afterFetch hook.
+type FetchHookRequest struct {
+	Records []*skyconv.JSONRecord `json:"records"`
+}
+
+// NewQueryHookRequest creates a new request of a beforeQuery hook.
+func NewQueryHookRequest(ctx context.Context, hookName string, query *skydb.Query) (*Request, error) {
+	predicate, err := skyconv.ToRawPredicate(query.Predicate)
+	if err != nil {
+		return nil, err
+	}
+	param := QueryHookRequest{
+		Query: RawQuery{query.Type, predicate},
+	}
+	return &Request{Kind: "hook", Name: hookName, Param: param, Context: ctx}, nil
+}
+
+// NewFetchHookRequest creates a new request of an afterFetch hook.
+func NewFetchHookRequest(ctx context.Context, hookName string, records []*skydb.Record) *Request {
+	param := FetchHookRequest{
+		Records: make([]*skyconv.JSONRecord, len(records)),
+	}
+	for i, record := range records {
+		param.Records[i] = (*skyconv.JSONRecord)(record)
+	}
+	return &Request{Kind: "hook", Name: hookName, Param: param, Context: ctx}
+}
+
+// ParseQueryHookResult returns a copy of the query with the predicate
+// returned by a beforeQuery hook. {"$type": "user"} in the predicate
+// refers to the user in the context.
+func ParseQueryHookResult(ctx context.Context, out []byte, query *skydb.Query) (*skydb.Query, error) {
+	var rawQuery RawQuery
+	if err := json.Unmarshal(out, &rawQuery); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query: %v", err)
+	}
+
+	queryout := *query
+	queryout.Predicate = skydb.Predicate{}
+	if len(rawQuery.Predicate) > 0 {
+		userID, _ := ctx.Value(router.UserIDContextKey).(string)
+		parser := skyconv.PredicateParser{UserID: userID}
+		predicate, err := parser.ParsePredicate(rawQuery.Predicate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse predicate: %v", err)
+		}
+		queryout.Predicate = predicate.BindCurrentUser(userID)
+	}
+	return &queryout, nil
+}
+
+// ParseFetchHookResult returns the records returned by an afterFetch
+// hook. A hook may only return records passed to it; the metadata of the
+// records are copied from them.
+func ParseFetchHookResult(out []byte, records []*skydb.Record) ([]*skydb.Record, error) {
+	var jsonRecords []skyconv.JSONRecord
+	if err := json.Unmarshal(out, &jsonRecords); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal records: %v", err)
+	}
+
+	recordMap := map[skydb.RecordID]*skydb.Record{}
+	for _, record := range records {
+		recordMap[record.ID] = record
+	}
+
+	recordsout := make([]*skydb.Record, len(jsonRecords))
+	for i := range jsonRecords {
+		recordout := (*skydb.Record)(&jsonRecords[i])
+		record, ok := recordMap[recordout.ID]
+		if !ok {
+			return nil, fmt.Errorf("hook returned record %s which is not fetched", recordout.ID)
+		}
+		recordout.OwnerID = record.OwnerID
+		recordout.CreatedAt = record.CreatedAt
+		recordout.CreatorID = record.CreatorID
+		recordout.UpdatedAt = record.UpdatedAt
+		recordout.UpdaterID = record.UpdaterID
+		recordout.DatabaseID = record.DatabaseID
+		recordsout[i] = recordout
+	}
+	return recordsout, nil
+}
+
+// AuthHookRequest contains the user involved in an auth hook.
+type AuthHookRequest struct {
+	AuthInfo *AuthHookInfo       `json:"auth_info"`
+	User     *skyconv.JSONRecord `json:"user"`
+}
+
+// AuthHookInfo is the auth info passed to an auth hook. Password and
+// provider info are not passed to plugins.
+type AuthHookInfo struct {
+	ID              string     `json:"_id"`
+	Roles           []string   `json:"roles,omitempty"`
+	LastSeenAt      *time.Time `json:"last_seen_at,omitempty"`
+	Disabled        bool       `json:"disabled"`
+	DisabledMessage string     `json:"disabled_message,omitempty"`
+	DisabledExpiry  *time.Time `json:"disabled_expiry,omitempty"`
+}
+
+// NewAuthHookRequest creates a new request of an auth hook.
+func NewAuthHookRequest(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) *Request {
+	param := AuthHookRequest{
+		User: (*skyconv.JSONRecord)(user),
+	}
+	if authInfo != nil {
+		param.AuthInfo = &AuthHookInfo{
+			ID:              authInfo.ID,
+			Roles:           authInfo.Roles,
+			LastSeenAt:      authInfo.LastSeenAt,
+			Disabled:        authInfo.Disabled,
+			DisabledMessage: authInfo.DisabledMessage,
+			DisabledExpiry:  authInfo.DisabledExpiry,
+		}
+	}
+	return &Request{Kind: "hook", Name: hookName, Param: param, Context: ctx}
+}
+
+// ParseAuthHookResult returns the user record returned by an auth hook.
+// Only the data of the user record may be changed by the hook; a null
+// result leaves the user record unchanged.
+func ParseAuthHookResult(out []byte, user *skydb.Record) (*skydb.Record, error) {
+	if user == nil {
+		return nil, nil
+	}
+
+	var jsonRecord *skyconv.JSONRecord
+	if err := json.Unmarshal(out, &jsonRecord); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal user: %v", err)
+	}
+	if jsonRecord == nil {
+		return user, nil
+	}
+
+	userout := *user
+	userout.Data = (*skydb.Record)(jsonRecord).Data
+	return &userout, nil
+}
+
 // NewAuthRequest creates a new auth request.
 func NewAuthRequest(ctx context.Context, authReq *skyplugin.AuthRequest) *Request {
 	return &Request{
@@ -85,6 +85,21 @@ type Transport interface {
 	// in any of its memebers with the record being passed in.
 	RunHook(ctx context.Context, hookName string, record *skydb.Record, oldRecord *skydb.Record, async bool) (*skydb.Record, error)
 
+	// RunQueryHook runs the beforeQuery hook with a name recognized by
+	// plugin. A newly allocated query is returned with the predicate
+	// rewritten by the plugin.
+	RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error)
+
+	// RunFetchHook runs the afterFetch hook with a name recognized by
+	// plugin, passing in the fetched records. The records to be returned
+	// to the client are returned as newly allocated instances.
+	RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error)
+
+	// RunAuthHook runs the auth hook with a name recognized by plugin,
+	// passing in the auth info and the user record. The user record
+	// returned from the plugin is returned as a newly allocated instance.
+	RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error)
+
 	RunTimer(name string, in []byte) ([]byte, error)
 
 	// RunProvider runs the auth provider with the specified AuthRequest.
@@ -54,6 +54,18 @@ func (t *nullTransport) RunHook(ctx context.Context, hookName string, reocrd *sk
 	t.lastContext = ctx
 	return
 }
+func (t *nullTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	t.lastContext = ctx
+	return query, nil
+}
+func (t *nullTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	t.lastContext = ctx
+	return records, nil
+}
+func (t *nullTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	t.lastContext = ctx
+	return user, nil
+}
 func (t *nullTransport) RunTimer(name string, in []byte) (out []byte, err error) {
 	out = in
 	return
@@ -98,6 +98,34 @@ func (p *zmqTransport) RunHook(ctx context.Context, hookName string, record *sky
 	return &recordout, nil
 }
 
+func (p *zmqTransport) RunQueryHook(ctx context.Context, hookName string, query *skydb.Query) (*skydb.Query, error) {
+	req, err := pluginrequest.NewQueryHookRequest(ctx, hookName, query)
+	if err != nil {
+		return nil, err
+	}
+	out, err := p.rpc(req)
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseQueryHookResult(ctx, out, query)
+}
+
+func (p *zmqTransport) RunFetchHook(ctx context.Context, hookName string, records []*skydb.Record) ([]*skydb.Record, error) {
+	out, err := p.rpc(pluginrequest.NewFetchHookRequest(ctx, hookName, records))
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseFetchHookResult(out, records)
+}
+
+func (p *zmqTransport) RunAuthHook(ctx context.Context, hookName string, authInfo *skydb.AuthInfo, user *skydb.Record) (*skydb.Record, error) {
+	out, err := p.rpc(pluginrequest.NewAuthHookRequest(ctx, hookName, authInfo, user))
+	if err != nil {
+		return nil, err
+	}
+	return pluginrequest.ParseAuthHookResult(out, user)
+}
+
 func (p *zmqTransport) RunTimer(name string, in []byte) (out []byte, err error) {
 	return p.rpc(pluginrequest.NewTimerRequest(name))
 }
@@ -0,0 +1,109 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package poller runs the background loops that claim and process due
+// items, such as webhook deliveries, jobs and timers, which are shared by
+// all server instances.
+//
+// Each item is claimed with a lease, during which it is hidden from the
+// other instances. Items are claimed one at a time so that the lease
+// only has to cover the processing of a single item, and the result of
+// processing is saved only if the lease is still held.
+package poller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+)
+
+var log = logging.LoggerEntry("poller")
+
+// Poller calls a poll function at an interval in the background. The
+// zero value is a stopped Poller.
+type Poller struct {
+	mutex sync.Mutex
+	stop  chan struct{}
+}
+
+// Start calls poll at the interval until Stop is called. Errors returned
+// by poll are logged with the name of the poller. Start does nothing if
+// the Poller is already started.
+func (p *Poller) Start(name string, interval time.Duration, poll func() error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	p.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := poll(); err != nil {
+					log.WithField("poller", name).WithError(err).Errorln("Failed to poll")
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops calling the poll function.
+func (p *Poller) Stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// Drain calls claim until it reports that no item is due, at most limit
+// times, returning the number of items claimed. claim is expected to
+// claim a single item with a fresh lease and process it.
+func Drain(limit uint64, claim func() (bool, error)) (int, error) {
+	n := 0
+	for uint64(n) < limit {
+		claimed, err := claim()
+		if err != nil {
+			return n, err
+		}
+		if !claimed {
+			break
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Backoff returns the interval before the next attempt after the number
+// of attempts made, which starts at initial and is doubled on every
+// further attempt up to max.
+func Backoff(initial time.Duration, max time.Duration, attempts int) time.Duration {
+	interval := initial
+	for i := 1; i < attempts && interval < max; i++ {
+		interval *= 2
+	}
+	if interval > max {
+		interval = max
+	}
+	return interval
+}
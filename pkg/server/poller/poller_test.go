@@ -0,0 +1,100 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package poller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPoller(t *testing.T) {
+	Convey("Poller", t, func() {
+		polled := make(chan struct{}, 10)
+		p := Poller{}
+
+		Convey("polls until stopped", func() {
+			p.Start("test", time.Millisecond, func() error {
+				polled <- struct{}{}
+				return nil
+			})
+			<-polled
+			<-polled
+			p.Stop()
+
+			for len(polled) > 0 {
+				<-polled
+			}
+			time.Sleep(10 * time.Millisecond)
+			So(len(polled), ShouldBeLessThanOrEqualTo, 1)
+		})
+
+		Convey("is not started twice", func() {
+			p.Start("test", time.Hour, func() error { return nil })
+			stop := p.stop
+			p.Start("test", time.Hour, func() error { return nil })
+			So(p.stop, ShouldEqual, stop)
+			p.Stop()
+			So(p.stop, ShouldBeNil)
+		})
+	})
+}
+
+func TestDrain(t *testing.T) {
+	Convey("Drain", t, func() {
+		due := 3
+		claim := func() (bool, error) {
+			if due == 0 {
+				return false, nil
+			}
+			due--
+			return true, nil
+		}
+
+		Convey("claims until no item is due", func() {
+			n, err := Drain(10, claim)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 3)
+		})
+
+		Convey("claims at most limit items", func() {
+			n, err := Drain(2, claim)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(due, ShouldEqual, 1)
+		})
+
+		Convey("stops at error", func() {
+			n, err := Drain(10, func() (bool, error) {
+				if due == 1 {
+					return false, errors.New("claim error")
+				}
+				return claim()
+			})
+			So(err, ShouldNotBeNil)
+			So(n, ShouldEqual, 2)
+		})
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	Convey("Backoff", t, func() {
+		So(Backoff(30*time.Second, 6*time.Hour, 1), ShouldEqual, 30*time.Second)
+		So(Backoff(30*time.Second, 6*time.Hour, 4), ShouldEqual, 4*time.Minute)
+		So(Backoff(30*time.Second, 6*time.Hour, 20), ShouldEqual, 6*time.Hour)
+	})
+}
@@ -16,17 +16,20 @@ package preprocessor
 
 import (
 	"context"
+	"math"
 	"net/http"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/skygeario/skygear-server/pkg/server/apikey"
+	"github.com/skygeario/skygear-server/pkg/server/audit"
 	"github.com/skygeario/skygear-server/pkg/server/authtoken"
 	"github.com/skygeario/skygear-server/pkg/server/logging"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skyerr"
 )
 
-func checkRequestAccessKey(payload *router.Payload, clientKey string, masterKey string) skyerr.Error {
+func checkRequestAccessKey(payload *router.Payload, clientKey string, masterKey string, apiKeys *apikey.Checker) skyerr.Error {
 	if payload.AccessKey != router.NoAccessKey {
 		return nil
 	}
@@ -37,6 +40,11 @@ func checkRequestAccessKey(payload *router.Payload, clientKey string, masterKey
 		payload.AccessKey = router.ClientAccessKey
 	} else if apiKey == "" {
 		payload.AccessKey = router.NoAccessKey
+	} else if apiKeys != nil {
+		if err := checkManagedAPIKey(payload, apiKeys, apiKey); err != nil {
+			return err
+		}
+		payload.AccessKey = router.ClientAccessKey
 	} else {
 		return skyerr.NewErrorf(skyerr.AccessKeyNotAccepted, "Cannot verify api key: `%v`", apiKey)
 	}
@@ -44,18 +52,68 @@ func checkRequestAccessKey(payload *router.Payload, clientKey string, masterKey
 	return nil
 }
 
+// checkManagedAPIKey checks the api key against the API keys stored in
+// the database, which are limited to the scopes and origins of the key.
+func checkManagedAPIKey(payload *router.Payload, apiKeys *apikey.Checker, apiKey string) skyerr.Error {
+	req := apikey.Request{
+		Key:    apiKey,
+		Action: payload.RouteAction(),
+	}
+	req.Tag, _ = payload.Context().Value("RequestTag").(string)
+	if payload.Req != nil {
+		req.Origin = payload.Req.Header.Get("Origin")
+	}
+
+	_, err := apiKeys.Check(req)
+	if err == nil {
+		return nil
+	}
+
+	if rateLimitErr, ok := err.(*apikey.RateLimitError); ok {
+		retryAfter := int64(math.Ceil(rateLimitErr.RetryAfter.Seconds()))
+		return skyerr.NewErrorWithInfo(skyerr.RateLimitExceeded, "Rate limit of api key exceeded", map[string]interface{}{
+			"retry_after": retryAfter,
+		})
+	}
+
+	switch err {
+	case apikey.ErrInvalidKey:
+		return skyerr.NewErrorf(skyerr.AccessKeyNotAccepted, "Cannot verify api key: `%v`", apiKey)
+	case apikey.ErrOriginNotAllowed:
+		return skyerr.NewError(skyerr.AccessKeyNotAccepted, "Api key is not allowed from this origin")
+	case apikey.ErrScopeNotAllowed:
+		return skyerr.NewError(skyerr.PermissionDenied, "Api key is not allowed to access this action")
+	default:
+		return skyerr.MakeError(err)
+	}
+}
+
+// accessKeyErrorStatus returns the HTTP status of the error returned by
+// checkRequestAccessKey.
+func accessKeyErrorStatus(err skyerr.Error) int {
+	switch err.Code() {
+	case skyerr.PermissionDenied:
+		return http.StatusForbidden
+	case skyerr.RateLimitExceeded:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusUnauthorized
+	}
+}
+
 // AccessKeyValidationPreprocessor provides preprocess method to check the
 // API key of the request.
 type AccessKeyValidationPreprocessor struct {
-	ClientKey string
-	MasterKey string
-	AppName   string
+	ClientKey     string
+	MasterKey     string
+	AppName       string
+	APIKeyChecker *apikey.Checker
 }
 
 func (p AccessKeyValidationPreprocessor) Preprocess(payload *router.Payload, response *router.Response) int {
-	if err := checkRequestAccessKey(payload, p.ClientKey, p.MasterKey); err != nil {
+	if err := checkRequestAccessKey(payload, p.ClientKey, p.MasterKey, p.APIKeyChecker); err != nil {
 		response.Err = err
-		return http.StatusUnauthorized
+		return accessKeyErrorStatus(err)
 	}
 
 	if payload.AccessKey == router.NoAccessKey {
@@ -80,17 +138,18 @@ type UserAuthenticator struct {
 	MasterKey          string
 	AppName            string
 	TokenStore         authtoken.Store
+	APIKeyChecker      *apikey.Checker
 	BypassUnauthorized bool
 }
 
 func (p *UserAuthenticator) Preprocess(payload *router.Payload, response *router.Response) int {
 	logger := logging.CreateLogger(payload.Context(), "preprocessor")
-	if err := checkRequestAccessKey(payload, p.ClientKey, p.MasterKey); err != nil {
+	if err := checkRequestAccessKey(payload, p.ClientKey, p.MasterKey, p.APIKeyChecker); err != nil {
 		if p.BypassUnauthorized {
 			return http.StatusOK
 		}
 		response.Err = err
-		return http.StatusUnauthorized
+		return accessKeyErrorStatus(err)
 	}
 
 	// If payload contains an access token, check whether if the access
@@ -116,6 +175,19 @@ func (p *UserAuthenticator) Preprocess(payload *router.Payload, response *router
 			return http.StatusUnauthorized
 		}
 
+		if token.IsImpersonated() {
+			// Impersonation tokens are short-lived, check the expiry in case
+			// the store does not expire tokens by itself.
+			if token.IsExpired() {
+				if p.BypassUnauthorized {
+					return http.StatusOK
+				}
+				response.Err = skyerr.NewError(skyerr.AccessTokenNotAccepted, "token does not exist or it has expired")
+				return http.StatusUnauthorized
+			}
+			impersonate(payload, token)
+		}
+
 		payload.AppName = token.AppName
 		payload.AuthInfoID = token.AuthInfoID
 		payload.SetContext(context.WithValue(payload.Context(), router.UserIDContextKey, token.AuthInfoID))
@@ -143,3 +215,25 @@ func (p *UserAuthenticator) Preprocess(payload *router.Payload, response *router
 	payload.AppName = p.AppName
 	return http.StatusOK
 }
+
+// impersonate marks the payload as impersonated by the admin of the
+// token, and writes an audit trail entry of the request.
+func impersonate(payload *router.Payload, token authtoken.Token) {
+	// The request is made with the ACL of the impersonated user, even
+	// if the master key is also specified.
+	if payload.AccessKey == router.MasterAccessKey {
+		payload.AccessKey = router.ClientAccessKey
+		payload.SetContext(context.WithValue(payload.Context(), router.AccessKeyTypeContextKey, payload.AccessKey))
+	}
+	payload.ImpersonatorID = token.ImpersonatorID
+
+	audit.Trail(audit.Entry{
+		Event:  audit.EventImpersonatedRequest,
+		Admin:  true,
+		AuthID: token.AuthInfoID,
+		Data: map[string]interface{}{
+			"impersonator_id": token.ImpersonatorID,
+			"action":          payload.RouteAction(),
+		},
+	}.WithRouterPayload(payload))
+}
@@ -21,9 +21,12 @@ import (
 
 	. "github.com/smartystreets/goconvey/convey"
 
+	"github.com/skygeario/skygear-server/pkg/server/apikey"
 	"github.com/skygeario/skygear-server/pkg/server/authtoken"
 	"github.com/skygeario/skygear-server/pkg/server/authtoken/authtokentest"
 	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
 	"github.com/skygeario/skygear-server/pkg/server/skyerr"
 )
 
@@ -74,6 +77,77 @@ func TestAccessKeyValidationPreprocessor(t *testing.T) {
 	})
 }
 
+func TestAccessKeyValidationPreprocessorWithAPIKeys(t *testing.T) {
+	Convey("test access key validation preprocessor with api keys", t, func() {
+		conn := skydbtest.NewMapConn()
+		key := skydb.APIKey{
+			ID:             "key-id",
+			Scopes:         []string{"record:*"},
+			AllowedOrigins: []string{"https://partner.example.com"},
+			RateLimit:      1,
+		}
+		key.SetKey("partner-key")
+		conn.CreateAPIKey(&key)
+
+		pp := AccessKeyValidationPreprocessor{
+			ClientKey: "client-key",
+			MasterKey: "master-key",
+			AppName:   "app-name",
+			APIKeyChecker: apikey.NewChecker(func() (skydb.Conn, error) {
+				return conn, nil
+			}, time.Minute),
+		}
+
+		req, _ := http.NewRequest("POST", "/", nil)
+		payload := &router.Payload{
+			Req: req,
+			Data: map[string]interface{}{
+				"api_key": "partner-key",
+				"action":  "record:fetch",
+			},
+			Meta: map[string]interface{}{},
+		}
+		resp := &router.Response{}
+
+		Convey("test managed key", func() {
+			So(pp.Preprocess(payload, resp), ShouldEqual, http.StatusOK)
+			So(payload.AccessKey, ShouldEqual, router.ClientAccessKey)
+			So(resp.Err, ShouldBeNil)
+		})
+
+		Convey("test managed key out of scope", func() {
+			payload.Data["action"] = "auth:login"
+			So(pp.Preprocess(payload, resp), ShouldEqual, http.StatusForbidden)
+			So(payload.AccessKey, ShouldEqual, router.NoAccessKey)
+			So(resp.Err.Code(), ShouldEqual, skyerr.PermissionDenied)
+		})
+
+		Convey("test managed key from origin not allowed", func() {
+			req.Header.Set("Origin", "https://evil.example.com")
+			So(pp.Preprocess(payload, resp), ShouldEqual, http.StatusUnauthorized)
+			So(resp.Err.Code(), ShouldEqual, skyerr.AccessKeyNotAccepted)
+		})
+
+		Convey("test managed key exceeding rate limit", func() {
+			So(pp.Preprocess(payload, resp), ShouldEqual, http.StatusOK)
+
+			payload = &router.Payload{
+				Req:  req,
+				Data: payload.Data,
+				Meta: map[string]interface{}{},
+			}
+			So(pp.Preprocess(payload, resp), ShouldEqual, http.StatusTooManyRequests)
+			So(resp.Err.Code(), ShouldEqual, skyerr.RateLimitExceeded)
+		})
+
+		Convey("test wrong key", func() {
+			payload.Data["api_key"] = "wrong-key"
+			So(pp.Preprocess(payload, resp), ShouldEqual, http.StatusUnauthorized)
+			So(resp.Err.Code(), ShouldEqual, skyerr.AccessKeyNotAccepted)
+		})
+	})
+}
+
 func TestUserAuthenticator(t *testing.T) {
 	Convey("test access user authenticator for api key", t, func() {
 		pp := UserAuthenticator{
@@ -184,5 +258,28 @@ func TestUserAuthenticator(t *testing.T) {
 			So(resp.Err, ShouldNotBeNil)
 			So(resp.Err.Code(), ShouldEqual, skyerr.AccessTokenNotAccepted)
 		})
+
+		Convey("test impersonation token", func() {
+			token, err := authtoken.NewImpersonationToken(pp.TokenStore, "app-name", "user-id", "admin-id", time.Now().Add(time.Hour))
+			So(err, ShouldBeNil)
+			pp.TokenStore.Put(&token)
+			payload.Data["api_key"] = "master-key"
+			payload.Data["access_token"] = token.AccessToken
+			So(pp.Preprocess(payload, resp), ShouldEqual, http.StatusOK)
+			So(payload.AuthInfoID, ShouldEqual, "user-id")
+			So(payload.ImpersonatorID, ShouldEqual, "admin-id")
+			So(payload.AccessKey, ShouldEqual, router.ClientAccessKey)
+			So(resp.Err, ShouldBeNil)
+		})
+
+		Convey("test expired impersonation token", func() {
+			token, err := authtoken.NewImpersonationToken(pp.TokenStore, "app-name", "user-id", "admin-id", time.Now().Add(-time.Second))
+			So(err, ShouldBeNil)
+			pp.TokenStore.Put(&token)
+			payload.Data["access_token"] = token.AccessToken
+			So(pp.Preprocess(payload, resp), ShouldEqual, http.StatusUnauthorized)
+			So(resp.Err.Code(), ShouldEqual, skyerr.AccessTokenNotAccepted)
+			So(payload.ImpersonatorID, ShouldBeEmpty)
+		})
 	})
 }
@@ -15,6 +15,7 @@
 package preprocessor
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/skygeario/skygear-server/pkg/server/logging"
@@ -46,6 +47,7 @@ func (p ConnPreprocessor) Preprocess(payload *router.Payload, response *router.R
 		return http.StatusServiceUnavailable
 	}
 	payload.DBConn = conn
+	payload.SetContext(context.WithValue(payload.Context(), router.DBConnContextKey, conn))
 
 	logger.Debugf("Get DB OK")
 
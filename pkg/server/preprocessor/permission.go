@@ -0,0 +1,97 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocessor
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/skygeario/skygear-server/pkg/server/permission"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+// RequirePermission denies the request if the action is restricted by role
+// permissions to roles the user does not have. It is run for every action
+// mapped in the router. Requests with master key are always allowed, so
+// are internal actions such as "_status:healthz".
+type RequirePermission struct {
+	PermissionChecker *permission.Checker
+}
+
+func (p RequirePermission) Preprocess(payload *router.Payload, response *router.Response) int {
+	if payload.HasMasterKey() {
+		return http.StatusOK
+	}
+
+	action, _ := payload.Meta["action"].(string)
+	if action == "" || strings.HasPrefix(action, "_") {
+		return http.StatusOK
+	}
+
+	decision, err := p.PermissionChecker.Decide(nil, action)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return http.StatusInternalServerError
+	}
+	if decision == permission.Unrestricted {
+		return http.StatusOK
+	}
+
+	authInfo, err := permissionAuthInfo(payload)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return http.StatusInternalServerError
+	}
+	if authInfo == nil {
+		response.Err = skyerr.NewError(
+			skyerr.NotAuthenticated,
+			"User is required for this action, please login.",
+		)
+		return http.StatusUnauthorized
+	}
+
+	decision, err = p.PermissionChecker.Decide(authInfo, action)
+	if err != nil {
+		response.Err = skyerr.MakeError(err)
+		return http.StatusInternalServerError
+	}
+	if decision == permission.Denied {
+		response.Err = skyerr.NewError(
+			skyerr.PermissionDenied,
+			"no permission to perform this action",
+		)
+		return http.StatusForbidden
+	}
+	return http.StatusOK
+}
+
+// permissionAuthInfo returns the AuthInfo of the request, fetching it if
+// the action authenticates the user without injecting the AuthInfo.
+func permissionAuthInfo(payload *router.Payload) (*skydb.AuthInfo, error) {
+	if payload.AuthInfo != nil || payload.AuthInfoID == "" || payload.DBConn == nil {
+		return payload.AuthInfo, nil
+	}
+
+	authInfo := skydb.AuthInfo{}
+	if err := payload.DBConn.GetAuth(payload.AuthInfoID, &authInfo); err != nil {
+		if err == skydb.ErrUserNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &authInfo, nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preprocessor
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/permission"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+)
+
+func TestRequirePermission(t *testing.T) {
+	Convey("RequirePermission", t, func() {
+		conn := skydbtest.NewMapConn()
+		conn.RolePermissions = skydb.RolePermissions{
+			"support": []string{"auth:disable:set"},
+		}
+		conn.UserMap["support"] = skydb.AuthInfo{
+			ID:    "support",
+			Roles: []string{"support"},
+		}
+		pp := RequirePermission{
+			PermissionChecker: permission.NewChecker(func() (skydb.Conn, error) {
+				return conn, nil
+			}, time.Minute),
+		}
+
+		newPayload := func(action string) *router.Payload {
+			return &router.Payload{
+				DBConn: conn,
+				Meta:   map[string]interface{}{"action": action},
+			}
+		}
+
+		Convey("allows unrestricted action", func() {
+			payload := newPayload("record:fetch")
+			resp := router.Response{}
+			So(pp.Preprocess(payload, &resp), ShouldEqual, http.StatusOK)
+			So(resp.Err, ShouldBeNil)
+		})
+
+		Convey("allows internal action", func() {
+			conn.RolePermissions["support"] = []string{"*"}
+			payload := newPayload("_status:healthz")
+			resp := router.Response{}
+			So(pp.Preprocess(payload, &resp), ShouldEqual, http.StatusOK)
+			So(resp.Err, ShouldBeNil)
+		})
+
+		Convey("allows master key", func() {
+			payload := newPayload("auth:disable:set")
+			payload.AccessKey = router.MasterAccessKey
+			resp := router.Response{}
+			So(pp.Preprocess(payload, &resp), ShouldEqual, http.StatusOK)
+			So(resp.Err, ShouldBeNil)
+		})
+
+		Convey("allows user having permitted role", func() {
+			payload := newPayload("auth:disable:set")
+			payload.AuthInfo = &skydb.AuthInfo{ID: "support", Roles: []string{"support"}}
+			resp := router.Response{}
+			So(pp.Preprocess(payload, &resp), ShouldEqual, http.StatusOK)
+			So(resp.Err, ShouldBeNil)
+		})
+
+		Convey("fetches auth info when only user id is injected", func() {
+			payload := newPayload("auth:disable:set")
+			payload.AuthInfoID = "support"
+			resp := router.Response{}
+			So(pp.Preprocess(payload, &resp), ShouldEqual, http.StatusOK)
+			So(resp.Err, ShouldBeNil)
+		})
+
+		Convey("denies user without permitted role", func() {
+			payload := newPayload("auth:disable:set")
+			payload.AuthInfo = &skydb.AuthInfo{ID: "user", Roles: []string{"user"}}
+			resp := router.Response{}
+			So(pp.Preprocess(payload, &resp), ShouldEqual, http.StatusForbidden)
+			So(resp.Err.Code(), ShouldEqual, skyerr.PermissionDenied)
+		})
+
+		Convey("denies request without user", func() {
+			payload := newPayload("auth:disable:set")
+			resp := router.Response{}
+			So(pp.Preprocess(payload, &resp), ShouldEqual, http.StatusUnauthorized)
+			So(resp.Err.Code(), ShouldEqual, skyerr.NotAuthenticated)
+		})
+	})
+}
@@ -17,6 +17,7 @@ package preprocessor
 import (
 	"net/http"
 
+	"github.com/skygeario/skygear-server/pkg/server/apikey"
 	"github.com/skygeario/skygear-server/pkg/server/plugin"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skyerr"
@@ -26,6 +27,7 @@ type EnsurePluginReadyPreprocessor struct {
 	PluginContext *plugin.Context
 	ClientKey     string
 	MasterKey     string
+	APIKeyChecker *apikey.Checker
 }
 
 func (p *EnsurePluginReadyPreprocessor) Preprocess(
@@ -41,9 +43,9 @@ func (p *EnsurePluginReadyPreprocessor) Preprocess(
 	// only allow requests with master key and the "_from_plugin" is set to true
 	// when the some plugin are just initialized
 	if p.PluginContext.IsInitialized() {
-		if err := checkRequestAccessKey(payload, p.ClientKey, p.MasterKey); err != nil {
+		if err := checkRequestAccessKey(payload, p.ClientKey, p.MasterKey, p.APIKeyChecker); err != nil {
 			response.Err = err
-			return http.StatusUnauthorized
+			return accessKeyErrorStatus(err)
 		}
 
 		fromPlugin, _ := payload.Data["_from_plugin"].(bool)
@@ -23,6 +23,7 @@ import (
 	"github.com/skygeario/skygear-server/pkg/server/asset"
 	"github.com/skygeario/skygear-server/pkg/server/audit"
 	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/permission"
 	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
 	"github.com/skygeario/skygear-server/pkg/server/recordutil"
 	"github.com/skygeario/skygear-server/pkg/server/router"
@@ -326,7 +327,11 @@ func (p InjectPublicDatabase) Preprocess(payload *router.Payload, response *rout
 	return http.StatusOK
 }
 
+// RequireAdminOrMasterKey requires the request to have master key or to be
+// made by a user having an admin role. If PermissionChecker is set, users
+// having a role permitted to call the action are also allowed.
 type RequireAdminOrMasterKey struct {
+	PermissionChecker *permission.Checker
 }
 
 func (p RequireAdminOrMasterKey) Preprocess(payload *router.Payload, response *router.Response) int {
@@ -352,6 +357,18 @@ func (p RequireAdminOrMasterKey) Preprocess(payload *router.Payload, response *r
 		return http.StatusOK
 	}
 
+	if p.PermissionChecker != nil {
+		action, _ := payload.Meta["action"].(string)
+		decision, err := p.PermissionChecker.Decide(payload.AuthInfo, action)
+		if err != nil {
+			response.Err = skyerr.MakeError(err)
+			return http.StatusInternalServerError
+		}
+		if decision == permission.Permitted {
+			return http.StatusOK
+		}
+	}
+
 	response.Err = skyerr.NewError(
 		skyerr.PermissionDenied,
 		"no permission to perform this action",
@@ -24,6 +24,7 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	"github.com/skygeario/skygear-server/pkg/server/audit"
+	"github.com/skygeario/skygear-server/pkg/server/permission"
 	"github.com/skygeario/skygear-server/pkg/server/router"
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
 	"github.com/skygeario/skygear-server/pkg/server/skydb/mock_skydb"
@@ -500,6 +501,31 @@ func TestRequireAdminOrMasterKey(t *testing.T) {
 			So(pp.Preprocess(&payload, &resp), ShouldEqual, http.StatusUnauthorized)
 			So(resp.Err, ShouldNotBeNil)
 		})
+
+		Convey("should ok with role permitted to the action", func() {
+			permConn := skydbtest.NewMapConn()
+			permConn.RolePermissions = skydb.RolePermissions{
+				"support": []string{"auth:disable:set"},
+			}
+			pp.PermissionChecker = permission.NewChecker(func() (skydb.Conn, error) {
+				return permConn, nil
+			}, time.Minute)
+
+			payload := router.Payload{
+				DBConn: conn,
+				Meta:   map[string]interface{}{"action": "auth:disable:set"},
+				AuthInfo: &skydb.AuthInfo{
+					Roles: []string{"support"},
+				},
+			}
+			resp := router.Response{}
+			So(pp.Preprocess(&payload, &resp), ShouldEqual, http.StatusOK)
+			So(resp.Err, ShouldBeNil)
+
+			payload.Meta["action"] = "auth:unlock"
+			So(pp.Preprocess(&payload, &resp), ShouldEqual, http.StatusUnauthorized)
+			So(resp.Err, ShouldNotBeNil)
+		})
 	})
 }
 
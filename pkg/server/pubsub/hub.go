@@ -25,11 +25,21 @@ type Parcel struct {
 	Connection *connection
 }
 
+// BlockChecker checks whether a user is blocked by another user.
+type BlockChecker interface {
+	IsBlocked(blockerID string, blockedID string) (bool, error)
+}
+
 // Hub is the struct that hold the subscription and do the broadcast logic
 type Hub struct {
-	Subscribe    chan Parcel
-	Unsubscribe  chan Parcel
-	Broadcast    chan Parcel
+	Subscribe   chan Parcel
+	Unsubscribe chan Parcel
+	Broadcast   chan Parcel
+
+	// BlockChecker, if set, prevents a message published by a user from
+	// being delivered to connections of users who blocked the publisher.
+	BlockChecker BlockChecker
+
 	stop         chan int
 	subscription map[string][]*connection
 	channels     map[string]chan []byte
@@ -62,7 +72,7 @@ func (h *Hub) run() {
 			h.unsubscribe(p.Channel, p.Connection)
 		case p := <-h.Broadcast:
 			log.Warnf("Broadcast %v:%s", p.Channel, p.Data)
-			h.publish(p.Channel, p.Data)
+			h.publish(p.Channel, p.Data, p.Connection)
 		case <-h.stop:
 			return
 		}
@@ -94,7 +104,7 @@ func (h *Hub) unsubscribe(channel string, c *connection) {
 	h.subscription[channel] = newSubscription
 }
 
-func (h *Hub) publish(channel string, data []byte) {
+func (h *Hub) publish(channel string, data []byte, publisher *connection) {
 	log.Debugf("publish %v, %s", channel, data)
 	parcel := Parcel{
 		Channel: channel,
@@ -103,6 +113,10 @@ func (h *Hub) publish(channel string, data []byte) {
 	for _, c := range h.subscription[channel] {
 		c := c
 		go func() {
+			if h.blocked(c, publisher) {
+				log.Debugf("Not published to %p, publisher is blocked", c)
+				return
+			}
 			select {
 			case c.Send <- parcel:
 				log.Debugf("Published to %p", c)
@@ -112,3 +126,18 @@ func (h *Hub) publish(channel string, data []byte) {
 		}()
 	}
 }
+
+// blocked returns true if the user of the connection has blocked the user
+// of the publisher. Messages are delivered if the check fails.
+func (h *Hub) blocked(c *connection, publisher *connection) bool {
+	if h.BlockChecker == nil || publisher == nil || c.userID == "" || publisher.userID == "" {
+		return false
+	}
+
+	blocked, err := h.BlockChecker.IsBlocked(c.userID, publisher.userID)
+	if err != nil {
+		log.Warnf("Failed to check user block: %v", err)
+		return false
+	}
+	return blocked
+}
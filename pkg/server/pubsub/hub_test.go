@@ -176,3 +176,64 @@ func TestNormalSubscription(t *testing.T) {
 		})
 	})
 }
+
+type mapBlockChecker map[string]bool
+
+func (m mapBlockChecker) IsBlocked(blockerID string, blockedID string) (bool, error) {
+	return m[blockerID+"/"+blockedID], nil
+}
+
+func TestBlockedPublisher(t *testing.T) {
+	Convey("Hub with BlockChecker", t, func(c C) {
+		hub := NewHub()
+		hub.BlockChecker = mapBlockChecker{"alice/bob": true}
+		go hub.run()
+
+		alice := connection{
+			userID: "alice",
+			Send:   make(chan Parcel),
+		}
+		bob := connection{
+			userID: "bob",
+		}
+		carol := connection{
+			userID: "carol",
+		}
+		hub.Subscribe <- Parcel{
+			Channel:    "alice",
+			Connection: &alice,
+		}
+
+		Convey("does not deliver message of blocked publisher", func() {
+			hub.Broadcast <- Parcel{
+				Channel:    "alice",
+				Data:       []byte("Hello"),
+				Connection: &bob,
+			}
+
+			select {
+			case <-alice.Send:
+				t.Fatal("received message of blocked publisher")
+			case <-time.After(50 * time.Millisecond):
+				// do nothing
+			}
+			hub.stop <- 1
+		})
+
+		Convey("delivers message of other publisher", func() {
+			hub.Broadcast <- Parcel{
+				Channel:    "alice",
+				Data:       []byte("Hello"),
+				Connection: &carol,
+			}
+
+			select {
+			case recv := <-alice.Send:
+				c.So(recv.Data, ShouldResemble, []byte("Hello"))
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("did not receive message of publisher not blocked")
+			}
+			hub.stop <- 1
+		})
+	})
+}
@@ -23,6 +23,7 @@ import (
 
 type connection struct {
 	ws       *websocket.Conn
+	userID   string
 	channels []string
 	Send     chan Parcel
 	done     chan bool
@@ -65,15 +66,23 @@ func NewWsPubsub(hub *Hub) *WsPubSub {
 
 // Handle will hijack the http responseWriter and req.
 func (w *WsPubSub) Handle(writer http.ResponseWriter, req *http.Request) {
+	w.HandleUser(writer, req, "")
+}
+
+// HandleUser is like Handle, and associates the connection with the
+// user, so that messages published by users blocked by the user are not
+// delivered to the connection.
+func (w *WsPubSub) HandleUser(writer http.ResponseWriter, req *http.Request, userID string) {
 	conn, err := w.upgrader.Upgrade(writer, req, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 	c := &connection{
-		ws:   conn,
-		Send: make(chan Parcel),
-		done: make(chan bool),
+		ws:     conn,
+		userID: userID,
+		Send:   make(chan Parcel),
+		done:   make(chan bool),
 	}
 	go w.writer(c)
 	go w.reader(c)
@@ -161,8 +170,9 @@ func (w *WsPubSub) reader(c *connection) {
 				return
 			}
 			w.hub.Broadcast <- Parcel{
-				Channel: payload.Channel,
-				Data:    []byte(*payload.Data),
+				Channel:    payload.Channel,
+				Data:       []byte(*payload.Data),
+				Connection: c,
 			}
 		default:
 			c.ws.WriteMessage(
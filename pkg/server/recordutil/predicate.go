@@ -0,0 +1,472 @@
+package recordutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+// ValidateRecordWritePredicate checks that the write predicate of a
+// record type can be evaluated against a record in memory. Write
+// predicates are checked on records before they are saved, so they can
+// only refer to fields of the record itself and to user relations.
+//
+// Comparisons are limited to those evaluated the same in memory as in
+// the SQL of queries: literals must be null, booleans, numbers, strings,
+// dates or references, only numbers and dates can be ordered, patterns
+// must be strings, and in must check a key path against a list of
+// literals or a string against a key path.
+func ValidateRecordWritePredicate(predicate skydb.Predicate) error {
+	if predicate.IsEmpty() {
+		return nil
+	}
+
+	switch {
+	case predicate.Operator.IsCompound():
+		for _, child := range predicate.GetSubPredicates() {
+			if err := ValidateRecordWritePredicate(child); err != nil {
+				return err
+			}
+		}
+	case predicate.Operator == skydb.Functional:
+		expr := predicate.GetExpressions()[0]
+		fn, ok := expr.Value.(skydb.UserRelationFunc)
+		if !ok {
+			return fmt.Errorf("function %T is not supported", expr.Value)
+		}
+		return validateWritePredicateKeyPath(fn.KeyPath)
+	default:
+		exprs := predicate.GetExpressions()
+		for _, expr := range exprs {
+			switch expr.Type {
+			case skydb.KeyPath:
+				if err := validateWritePredicateKeyPath(expr.Value.(string)); err != nil {
+					return err
+				}
+			case skydb.Function:
+				return fmt.Errorf("function %T is not supported", expr.Value)
+			}
+		}
+		return validateWritePredicateOperands(predicate.Operator, exprs[0], exprs[1])
+	}
+	return nil
+}
+
+func validateWritePredicateOperands(operator skydb.Operator, lhs skydb.Expression, rhs skydb.Expression) error {
+	if operator == skydb.In {
+		switch {
+		case lhs.Type == skydb.KeyPath && rhs.Type == skydb.Literal:
+			values, ok := rhs.Value.([]interface{})
+			if !ok {
+				return fmt.Errorf("operator %v requires a list of literals", operator)
+			}
+			for _, value := range values {
+				if err := validateWritePredicateLiteral(operator, value); err != nil {
+					return err
+				}
+			}
+			return nil
+		case lhs.Type == skydb.Literal && rhs.Type == skydb.KeyPath:
+			if _, ok := lhs.Value.(string); !ok {
+				return fmt.Errorf("operator %v requires a string to look up in a key path", operator)
+			}
+			return nil
+		default:
+			return fmt.Errorf("operator %v requires a key path and a literal", operator)
+		}
+	}
+
+	for _, expr := range []skydb.Expression{lhs, rhs} {
+		if expr.Type != skydb.Literal {
+			continue
+		}
+		if err := validateWritePredicateLiteral(operator, expr.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateWritePredicateLiteral(operator skydb.Operator, value interface{}) error {
+	switch operator {
+	case skydb.GreaterThan, skydb.LessThan, skydb.GreaterThanOrEqual, skydb.LessThanOrEqual:
+		switch value.(type) {
+		case nil, float64, int, int64, time.Time:
+			return nil
+		}
+		return fmt.Errorf("operator %v does not support %T", operator, value)
+	case skydb.Like, skydb.ILike:
+		switch value.(type) {
+		case nil, string:
+			return nil
+		}
+		return fmt.Errorf("operator %v does not support %T", operator, value)
+	}
+
+	switch value.(type) {
+	case nil, bool, float64, int, int64, string, time.Time, skydb.Reference, *skydb.Reference, skydb.CurrentUser:
+		return nil
+	}
+	return fmt.Errorf("operator %v does not support %T", operator, value)
+}
+
+func validateWritePredicateKeyPath(keyPath string) error {
+	if strings.Contains(keyPath, ".") {
+		return fmt.Errorf("key path %s refers to another record", keyPath)
+	}
+	return nil
+}
+
+// truth is the result of a predicate in the three-valued logic of SQL,
+// in which comparing with null is unknown rather than false. A record
+// matches a predicate only if the result is true, so that a predicate
+// negating a comparison with null does not match either.
+type truth int
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+func (t truth) not() truth {
+	switch t {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	default:
+		return truthUnknown
+	}
+}
+
+// recordPredicateMatcher evaluates a record predicate against a record in
+// memory, following the semantics of the SQL generated for queries.
+// CurrentUser literals are expected to be bound already.
+type recordPredicateMatcher struct {
+	conn skydb.Conn
+}
+
+func (m recordPredicateMatcher) match(predicate skydb.Predicate, record *skydb.Record) (bool, error) {
+	result, err := m.eval(predicate, record)
+	return result == truthTrue, err
+}
+
+func (m recordPredicateMatcher) eval(predicate skydb.Predicate, record *skydb.Record) (truth, error) {
+	if predicate.IsEmpty() {
+		return truthTrue, nil
+	}
+
+	switch predicate.Operator {
+	case skydb.And:
+		result := truthTrue
+		for _, child := range predicate.GetSubPredicates() {
+			t, err := m.eval(child, record)
+			if err != nil || t == truthFalse {
+				return truthFalse, err
+			} else if t == truthUnknown {
+				result = truthUnknown
+			}
+		}
+		return result, nil
+	case skydb.Or:
+		result := truthFalse
+		for _, child := range predicate.GetSubPredicates() {
+			t, err := m.eval(child, record)
+			if err != nil {
+				return truthFalse, err
+			} else if t == truthTrue {
+				return truthTrue, nil
+			} else if t == truthUnknown {
+				result = truthUnknown
+			}
+		}
+		return result, nil
+	case skydb.Not:
+		t, err := m.eval(predicate.GetSubPredicates()[0], record)
+		if err != nil {
+			return truthFalse, err
+		}
+		return t.not(), nil
+	case skydb.Functional:
+		expr := predicate.GetExpressions()[0]
+		fn, ok := expr.Value.(skydb.UserRelationFunc)
+		if !ok {
+			return truthFalse, fmt.Errorf("function %T is not supported", expr.Value)
+		}
+		related, err := m.matchUserRelation(fn, record)
+		return truthOf(related), err
+	}
+
+	exprs := predicate.GetExpressions()
+	lhs, err := predicateValue(exprs[0], record)
+	if err != nil {
+		return truthFalse, err
+	}
+	rhs, err := predicateValue(exprs[1], record)
+	if err != nil {
+		return truthFalse, err
+	}
+
+	switch predicate.Operator {
+	case skydb.Equal, skydb.NotEqual:
+		equal := predicate.Operator == skydb.Equal
+		// comparing with a null literal is IS NULL or IS NOT NULL,
+		// otherwise comparing with null is unknown
+		if exprs[0].IsLiteralNull() || exprs[1].IsLiteralNull() {
+			return truthOf((lhs == nil && rhs == nil) == equal), nil
+		}
+		if lhs == nil || rhs == nil {
+			return truthUnknown, nil
+		}
+		return truthOf(reflect.DeepEqual(lhs, rhs) == equal), nil
+	case skydb.GreaterThan, skydb.LessThan, skydb.GreaterThanOrEqual, skydb.LessThanOrEqual:
+		if lhs == nil || rhs == nil {
+			return truthUnknown, nil
+		}
+		matched, err := compareValues(predicate.Operator, lhs, rhs)
+		return truthOf(matched), err
+	case skydb.Like, skydb.ILike:
+		if lhs == nil || rhs == nil {
+			return truthUnknown, nil
+		}
+		return truthOf(likeValues(predicate.Operator == skydb.ILike, lhs, rhs)), nil
+	case skydb.In:
+		if exprs[0].Type == skydb.KeyPath && exprs[1].Type == skydb.Literal {
+			return inValues(lhs, rhs), nil
+		} else if exprs[0].Type == skydb.Literal && exprs[1].Type == skydb.KeyPath {
+			return existsValue(rhs, lhs), nil
+		}
+		return truthFalse, fmt.Errorf("operator %v requires a key path and a literal", predicate.Operator)
+	default:
+		return truthFalse, fmt.Errorf("operator %v is not supported", predicate.Operator)
+	}
+}
+
+// matchUserRelation checks whether the user bound to the function is
+// related to the user at the key path of the record.
+func (m recordPredicateMatcher) matchUserRelation(fn skydb.UserRelationFunc, record *skydb.Record) (bool, error) {
+	keyPath := fn.KeyPath
+	if keyPath == "" {
+		keyPath = "_owner_id"
+	}
+	value, err := predicateValue(skydb.Expression{Type: skydb.KeyPath, Value: keyPath}, record)
+	if err != nil {
+		return false, err
+	}
+	targetUser, ok := value.(string)
+	if fn.User == "" || !ok || targetUser == "" {
+		return false, nil
+	}
+
+	direction := fn.RelationDirection
+	if direction == "" {
+		direction = "outward"
+	}
+	if direction == "outward" || direction == "mutual" {
+		related, err := hasRelation(m.conn, fn.User, fn.RelationName, targetUser)
+		if err != nil || !related {
+			return false, err
+		}
+	}
+	if direction == "inward" || direction == "mutual" {
+		return hasRelation(m.conn, targetUser, fn.RelationName, fn.User)
+	}
+	return true, nil
+}
+
+// predicateValue returns the value of the expression for the record.
+// References are compared by the ID of the referenced record, the same
+// as they are stored in the database.
+func predicateValue(expr skydb.Expression, record *skydb.Record) (interface{}, error) {
+	var value interface{}
+	switch expr.Type {
+	case skydb.Literal:
+		value = expr.Value
+	case skydb.KeyPath:
+		keyPath := expr.Value.(string)
+		if err := validateWritePredicateKeyPath(keyPath); err != nil {
+			return nil, err
+		}
+		if keyPath == "_owner" {
+			keyPath = "_owner_id"
+		}
+		value = record.Get(keyPath)
+	default:
+		return nil, errors.New("function is not supported in write predicate")
+	}
+	return normalizePredicateValue(value), nil
+}
+
+func normalizePredicateValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case skydb.Reference:
+		return v.ID.Key
+	case *skydb.Reference:
+		return v.ID.Key
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case time.Time:
+		return v.UTC()
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, item := range v {
+			values[i] = normalizePredicateValue(item)
+		}
+		return values
+	default:
+		return value
+	}
+}
+
+// compareValues orders numbers and dates. Other values are not ordered,
+// since strings are ordered by the collation of the database in SQL.
+func compareValues(operator skydb.Operator, lhs interface{}, rhs interface{}) (bool, error) {
+	var cmp int
+	switch l := lhs.(type) {
+	case float64:
+		r, ok := rhs.(float64)
+		if !ok {
+			return false, nil
+		}
+		cmp = compareFloat(l, r)
+	case time.Time:
+		r, ok := rhs.(time.Time)
+		if !ok {
+			return false, nil
+		}
+		cmp = compareFloat(float64(l.Sub(r)), 0)
+	default:
+		return false, fmt.Errorf("operator %v does not support %T", operator, lhs)
+	}
+
+	switch operator {
+	case skydb.GreaterThan:
+		return cmp > 0, nil
+	case skydb.LessThan:
+		return cmp < 0, nil
+	case skydb.GreaterThanOrEqual:
+		return cmp >= 0, nil
+	default:
+		return cmp <= 0, nil
+	}
+}
+
+func compareFloat(l float64, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// likeValues matches the string against the pattern of SQL LIKE, in
+// which % matches any sequence of characters and _ matches a character.
+func likeValues(caseInsensitive bool, lhs interface{}, rhs interface{}) bool {
+	s, ok := lhs.(string)
+	if !ok {
+		return false
+	}
+	pattern, ok := rhs.(string)
+	if !ok {
+		return false
+	}
+
+	var expr bytes.Buffer
+	if caseInsensitive {
+		expr.WriteString("(?is)")
+	} else {
+		expr.WriteString("(?s)")
+	}
+	expr.WriteString("^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			expr.WriteString(".*")
+		case c == '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	matched, err := regexp.MatchString(expr.String(), s)
+	return err == nil && matched
+}
+
+// inValues matches the value against a list of values as SQL IN, which
+// is unknown if the value is null, or if it is not found and the list
+// contains null.
+func inValues(needle interface{}, list interface{}) truth {
+	haystack, ok := list.([]interface{})
+	if !ok {
+		return truthFalse
+	}
+	if needle == nil {
+		return truthUnknown
+	}
+
+	result := truthFalse
+	for _, item := range haystack {
+		if item == nil {
+			result = truthUnknown
+		} else if reflect.DeepEqual(item, needle) {
+			return truthTrue
+		}
+	}
+	return result
+}
+
+// existsValue matches the string against the value of a key path as
+// jsonb_exists in SQL, which looks up the string in the elements of a
+// list or the keys of a dictionary, and is unknown if the value is null.
+func existsValue(value interface{}, key interface{}) truth {
+	k, ok := key.(string)
+	if !ok {
+		return truthFalse
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return truthUnknown
+	case string:
+		return truthOf(v == k)
+	case []interface{}:
+		for _, item := range v {
+			if item == k {
+				return truthTrue
+			}
+		}
+		return truthFalse
+	case map[string]interface{}:
+		_, ok := v[k]
+		return truthOf(ok)
+	default:
+		return truthFalse
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package recordutil
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func keyPath(path string) skydb.Expression {
+	return skydb.Expression{Type: skydb.KeyPath, Value: path}
+}
+
+func literal(value interface{}) skydb.Expression {
+	return skydb.Expression{Type: skydb.Literal, Value: value}
+}
+
+func binary(operator skydb.Operator, lhs skydb.Expression, rhs skydb.Expression) skydb.Predicate {
+	return skydb.Predicate{Operator: operator, Children: []interface{}{lhs, rhs}}
+}
+
+func not(predicate skydb.Predicate) skydb.Predicate {
+	return skydb.Predicate{Operator: skydb.Not, Children: []interface{}{predicate}}
+}
+
+func TestRecordPredicateMatcher(t *testing.T) {
+	Convey("recordPredicateMatcher", t, func() {
+		matcher := recordPredicateMatcher{conn: skydbtest.NewMapConn()}
+		date := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		record := &skydb.Record{
+			ID:      skydb.NewRecordID("note", "1"),
+			OwnerID: "user-1",
+			Data: map[string]interface{}{
+				"title":    "Hello World",
+				"count":    float64(3),
+				"done":     true,
+				"due":      date,
+				"tags":     []interface{}{"a", "b"},
+				"category": skydb.NewReference("category", "c1"),
+				"empty":    nil,
+			},
+		}
+
+		// Each predicate is expected to match the record the same as the
+		// SQL generated for a query, where a comparison with null is
+		// unknown and NOT of unknown is still unknown.
+		cases := []struct {
+			name      string
+			predicate skydb.Predicate
+			expected  bool
+		}{
+			{"equal", binary(skydb.Equal, keyPath("title"), literal("Hello World")), true},
+			{"equal different value", binary(skydb.Equal, keyPath("title"), literal("Hello")), false},
+			{"equal number", binary(skydb.Equal, keyPath("count"), literal(3)), true},
+			{"equal boolean", binary(skydb.Equal, keyPath("done"), literal(true)), true},
+			{"equal date", binary(skydb.Equal, keyPath("due"), literal(date)), true},
+			{"equal reference", binary(skydb.Equal, keyPath("category"), literal(skydb.NewReference("category", "c1"))), true},
+			{"equal owner", binary(skydb.Equal, keyPath("_owner"), literal("user-1")), true},
+			{"equal null literal is IS NULL", binary(skydb.Equal, keyPath("empty"), literal(nil)), true},
+			{"null literal on the left is IS NULL", binary(skydb.Equal, literal(nil), keyPath("title")), false},
+			{"equal null field is unknown", binary(skydb.Equal, keyPath("empty"), literal("x")), false},
+			{"not equal null field is unknown", not(binary(skydb.Equal, keyPath("empty"), literal("x"))), false},
+			{"not equal", binary(skydb.NotEqual, keyPath("title"), literal("Hello")), true},
+			{"not equal null literal is IS NOT NULL", binary(skydb.NotEqual, keyPath("title"), literal(nil)), true},
+			{"not equal with null field is unknown", binary(skydb.NotEqual, keyPath("empty"), literal("x")), false},
+			{"greater than", binary(skydb.GreaterThan, keyPath("count"), literal(2)), true},
+			{"less than", binary(skydb.LessThan, keyPath("count"), literal(3)), false},
+			{"greater than or equal", binary(skydb.GreaterThanOrEqual, keyPath("count"), literal(3)), true},
+			{"less than or equal date", binary(skydb.LessThanOrEqual, keyPath("due"), literal(date.Add(time.Hour))), true},
+			{"compare null field is unknown", not(binary(skydb.GreaterThan, keyPath("empty"), literal(2))), false},
+			{"like", binary(skydb.Like, keyPath("title"), literal("Hello%")), true},
+			{"like is case sensitive", binary(skydb.Like, keyPath("title"), literal("hello%")), false},
+			{"like single character", binary(skydb.Like, keyPath("title"), literal("Hello_World")), true},
+			{"like escaped", binary(skydb.Like, keyPath("title"), literal(`Hello\_World`)), false},
+			{"ilike", binary(skydb.ILike, keyPath("title"), literal("hello%")), true},
+			{"like null field is unknown", not(binary(skydb.Like, keyPath("empty"), literal("%"))), false},
+			{"in list", binary(skydb.In, keyPath("title"), literal([]interface{}{"Hello World", "Bye"})), true},
+			{"not in list", binary(skydb.In, keyPath("title"), literal([]interface{}{"Bye"})), false},
+			{"not in list with null is unknown", not(binary(skydb.In, keyPath("title"), literal([]interface{}{"Bye", nil}))), false},
+			{"null field in list is unknown", not(binary(skydb.In, keyPath("empty"), literal([]interface{}{"Bye"}))), false},
+			{"string in key path", binary(skydb.In, literal("a"), keyPath("tags")), true},
+			{"string not in key path", binary(skydb.In, literal("c"), keyPath("tags")), false},
+			{"string in null field is unknown", not(binary(skydb.In, literal("a"), keyPath("empty"))), false},
+			{"and", skydb.Predicate{Operator: skydb.And, Children: []interface{}{
+				binary(skydb.Equal, keyPath("done"), literal(true)),
+				binary(skydb.GreaterThan, keyPath("count"), literal(1)),
+			}}, true},
+			{"or with unknown", skydb.Predicate{Operator: skydb.Or, Children: []interface{}{
+				binary(skydb.Equal, keyPath("empty"), literal("x")),
+				binary(skydb.Equal, keyPath("done"), literal(true)),
+			}}, true},
+			{"not or with unknown", not(skydb.Predicate{Operator: skydb.Or, Children: []interface{}{
+				binary(skydb.Equal, keyPath("empty"), literal("x")),
+				binary(skydb.Equal, keyPath("done"), literal(false)),
+			}}), false},
+			{"not and with false", not(skydb.Predicate{Operator: skydb.And, Children: []interface{}{
+				binary(skydb.Equal, keyPath("empty"), literal("x")),
+				binary(skydb.Equal, keyPath("done"), literal(false)),
+			}}), true},
+		}
+
+		for _, c := range cases {
+			c := c
+			Convey(c.name, func() {
+				matched, err := matcher.match(c.predicate, record)
+				So(err, ShouldBeNil)
+				So(matched, ShouldEqual, c.expected)
+			})
+		}
+
+		Convey("rejects ordering strings", func() {
+			_, err := matcher.match(binary(skydb.GreaterThan, keyPath("title"), keyPath("title")), record)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestValidateRecordWritePredicate(t *testing.T) {
+	Convey("ValidateRecordWritePredicate", t, func() {
+		Convey("accepts predicates evaluated the same as SQL", func() {
+			So(ValidateRecordWritePredicate(skydb.Predicate{}), ShouldBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.Equal, keyPath("_owner"), literal(skydb.CurrentUser{}))), ShouldBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.NotEqual, keyPath("category"), literal(skydb.NewReference("category", "c1")))), ShouldBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.GreaterThan, keyPath("count"), literal(float64(1)))), ShouldBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.LessThan, keyPath("due"), literal(time.Now()))), ShouldBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.ILike, keyPath("title"), literal("hello%"))), ShouldBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.In, keyPath("title"), literal([]interface{}{"a", nil}))), ShouldBeNil)
+			So(ValidateRecordWritePredicate(not(binary(skydb.In, literal("a"), keyPath("tags")))), ShouldBeNil)
+		})
+
+		Convey("rejects key path of another record", func() {
+			So(ValidateRecordWritePredicate(binary(skydb.Equal, keyPath("category.name"), literal("a"))), ShouldNotBeNil)
+		})
+
+		Convey("rejects literal not comparable in memory", func() {
+			So(ValidateRecordWritePredicate(binary(skydb.Equal, keyPath("tags"), literal([]interface{}{"a"}))), ShouldNotBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.Equal, keyPath("location"), literal(skydb.NewLocation(1, 2)))), ShouldNotBeNil)
+		})
+
+		Convey("rejects ordering strings and booleans", func() {
+			So(ValidateRecordWritePredicate(binary(skydb.GreaterThan, keyPath("title"), literal("a"))), ShouldNotBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.LessThanOrEqual, keyPath("done"), literal(true))), ShouldNotBeNil)
+		})
+
+		Convey("rejects pattern other than string", func() {
+			So(ValidateRecordWritePredicate(binary(skydb.Like, keyPath("count"), literal(float64(1)))), ShouldNotBeNil)
+		})
+
+		Convey("rejects in without a key path and a literal", func() {
+			So(ValidateRecordWritePredicate(binary(skydb.In, keyPath("title"), keyPath("tags"))), ShouldNotBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.In, keyPath("title"), literal("a"))), ShouldNotBeNil)
+			So(ValidateRecordWritePredicate(binary(skydb.In, literal(float64(1)), keyPath("tags"))), ShouldNotBeNil)
+		})
+	})
+}
@@ -158,7 +158,44 @@ func (f RecordFetcher) FetchRecord(recordID skydb.RecordID, authInfo *skydb.Auth
 	}
 
 	record = &dbRecord
-	if !f.withMasterKey && !dbRecord.Accessible(authInfo, accessLevel) {
+	if f.withMasterKey {
+		return
+	}
+
+	if !dbRecord.Accessible(authInfo, accessLevel) && !f.relationAccessible(&dbRecord, authInfo, accessLevel) {
+		err = skyerr.NewError(
+			skyerr.PermissionDenied,
+			"no permission to perform operation",
+		)
+		return
+	}
+
+	blocked, dbErr := f.ownerBlocked(&dbRecord, authInfo)
+	if dbErr != nil {
+		logger := logging.CreateLogger(f.context, "handler")
+		logger.WithFields(logrus.Fields{
+			"recordID": recordID,
+			"err":      dbErr,
+		}).Errorln("Failed to check user block")
+		err = skyerr.NewResourceFetchFailureErr("record", recordID.String())
+		return
+	} else if blocked {
+		err = skyerr.NewError(
+			skyerr.PermissionDenied,
+			"no permission to perform operation",
+		)
+		return
+	}
+
+	accessible, dbErr := f.predicateAccessible(&dbRecord, authInfo, accessLevel)
+	if dbErr != nil {
+		logger := logging.CreateLogger(f.context, "handler")
+		logger.WithFields(logrus.Fields{
+			"recordID": recordID,
+			"err":      dbErr,
+		}).Errorln("Failed to check record predicate")
+		err = skyerr.NewResourceFetchFailureErr("record", recordID.String())
+	} else if !accessible {
 		err = skyerr.NewError(
 			skyerr.PermissionDenied,
 			"no permission to perform operation",
@@ -168,6 +205,152 @@ func (f RecordFetcher) FetchRecord(recordID skydb.RecordID, authInfo *skydb.Auth
 	return
 }
 
+// ownerBlocked returns true if the record owner has blocked the user.
+func (f RecordFetcher) ownerBlocked(record *skydb.Record, authInfo *skydb.AuthInfo) (bool, error) {
+	if authInfo == nil || record.OwnerID == "" || record.OwnerID == authInfo.ID {
+		return false, nil
+	}
+	return f.conn.IsUserBlocked(record.OwnerID, authInfo.ID)
+}
+
+// relationAccessible checks the relation entries in the record ACL, which
+// grant access to users the record owner is related to. Records in a
+// private database are never shared by relation.
+func (f RecordFetcher) relationAccessible(record *skydb.Record, authInfo *skydb.AuthInfo, accessLevel skydb.RecordACLLevel) bool {
+	if authInfo == nil || record.OwnerID == "" || record.DatabaseID != "" {
+		return false
+	}
+
+	for _, ace := range record.ACL {
+		if ace.Relation == "" || ace.Relation == "$direct" || !ace.AccessibleLevel(accessLevel) {
+			continue
+		}
+		related, err := hasRelation(f.conn, record.OwnerID, ace.Relation, authInfo.ID)
+		if related {
+			return true
+		}
+		if err != nil {
+			logger := logging.CreateLogger(f.context, "handler")
+			logger.WithFields(logrus.Fields{
+				"recordID": record.ID,
+				"relation": ace.Relation,
+				"err":      err,
+			}).Warnln("Failed to check record relation access")
+		}
+	}
+	return false
+}
+
+// hasRelation checks whether user has the relation to targetUser. The
+// relation type is checked before the relation, as looking up a relation
+// of an undefined type fails the transaction.
+func hasRelation(conn skydb.Conn, user string, name string, targetUser string) (bool, error) {
+	name = skydb.CanonicalRelationName(name)
+	if !skydb.IsBuiltinRelation(name) {
+		if _, err := conn.GetRelationType(name); err == skydb.ErrRelationTypeNotFound {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+	}
+
+	_, err := conn.GetRelation(user, name, targetUser)
+	switch err {
+	case nil:
+		return true, nil
+	case skydb.ErrRelationNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// predicateAccessible checks the record against the record predicate
+// declared for its type. The read predicate is checked by counting the
+// record with the predicate applied, while the write predicate is
+// evaluated against the record in memory, the same as it is checked on
+// the record to be saved.
+func (f RecordFetcher) predicateAccessible(record *skydb.Record, authInfo *skydb.AuthInfo, accessLevel skydb.RecordACLLevel) (bool, error) {
+	access, err := f.conn.GetRecordPredicateAccess(record.ID.Type)
+	if err != nil {
+		return false, err
+	}
+	if len(access.Predicate(accessLevel)) == 0 || f.db.DatabaseType() != skydb.PublicDatabase {
+		return true, nil
+	}
+
+	if accessLevel == skydb.WriteLevel {
+		return f.writePredicateMatch(access, record, authInfo)
+	}
+
+	query := skydb.Query{
+		Type: record.ID.Type,
+		Predicate: skydb.Predicate{
+			Operator: skydb.Equal,
+			Children: []interface{}{
+				skydb.Expression{Type: skydb.KeyPath, Value: "_id"},
+				skydb.Expression{Type: skydb.Literal, Value: record.ID.Key},
+			},
+		},
+	}
+	count, err := f.db.QueryCount(&query, &skydb.AccessControlOptions{
+		ViewAsUser:     authInfo,
+		PredicateLevel: accessLevel,
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// checkWritePredicate checks the record to be saved against the write
+// predicate declared for its type, so that a record cannot be changed
+// or created to a state the user is not allowed to write.
+func (f RecordFetcher) checkWritePredicate(record *skydb.Record, authInfo *skydb.AuthInfo) skyerr.Error {
+	if f.withMasterKey {
+		return nil
+	}
+
+	access, err := f.conn.GetRecordPredicateAccess(record.ID.Type)
+	if err != nil {
+		return skyerr.MakeError(err)
+	}
+	if len(access.Write) == 0 || f.db.DatabaseType() != skydb.PublicDatabase {
+		return nil
+	}
+
+	matched, err := f.writePredicateMatch(access, record, authInfo)
+	if err != nil {
+		logger := logging.CreateLogger(f.context, "handler")
+		logger.WithFields(logrus.Fields{
+			"recordID": record.ID,
+			"err":      err,
+		}).Errorln("Failed to check record predicate")
+		return skyerr.NewError(skyerr.UnexpectedError, "failed to check record predicate")
+	} else if !matched {
+		return skyerr.NewError(
+			skyerr.PermissionDenied,
+			"no permission to perform operation",
+		)
+	}
+	return nil
+}
+
+func (f RecordFetcher) writePredicateMatch(access skydb.RecordPredicateAccess, record *skydb.Record, authInfo *skydb.AuthInfo) (bool, error) {
+	parser := skyconv.PredicateParser{}
+	predicate, err := parser.ParsePredicate(access.Write)
+	if err != nil {
+		return false, err
+	}
+
+	var userID string
+	if authInfo != nil {
+		userID = authInfo.ID
+	}
+	matcher := recordPredicateMatcher{conn: f.conn}
+	return matcher.match(predicate.BindCurrentUser(userID), record)
+}
+
 func (f RecordFetcher) FetchOrCreateRecord(recordID skydb.RecordID, authInfo *skydb.AuthInfo) (record skydb.Record, created bool, err skyerr.Error) {
 	fetchedRecord, err := f.FetchRecord(recordID, authInfo, skydb.WriteLevel)
 	if err == nil {
@@ -295,6 +478,12 @@ func RecordSaveHandler(req *RecordModifyRequest, resp *RecordModifyResponse) sky
 		removeRecordFieldTypeHints(r)
 	}
 
+	// check the records as they are saved against the write predicates,
+	// which also applies to records being created
+	records = executeRecordFunc(records, resp.ErrMap, func(record *skydb.Record) skyerr.Error {
+		return fetcher.checkWritePredicate(record, req.AuthInfo)
+	})
+
 	// save records
 	records = executeRecordFunc(records, resp.ErrMap, func(record *skydb.Record) (err skyerr.Error) {
 		var deltaRecord skydb.Record
@@ -28,6 +28,10 @@ import (
 	"github.com/skygeario/skygear-server/pkg/server/skyversion"
 )
 
+// ImpersonatedByHeader is the response header carrying the ID of the admin
+// impersonating the user of the request.
+const ImpersonatedByHeader = "X-Skygear-Impersonated-By"
+
 // commonRouter implements the HandlerFunc interface that is common
 // to Router and Gateway.
 type commonRouter struct {
@@ -79,6 +83,9 @@ func (r *commonRouter) HandlePayload(payload *Payload, resp *Response) {
 		}
 
 		writer.Header().Set("Content-Type", "application/json")
+		if payload.ImpersonatorID != "" {
+			writer.Header().Set(ImpersonatedByHeader, payload.ImpersonatorID)
+		}
 
 		if timedOut {
 			resp.Err = skyerr.NewError(
@@ -33,6 +33,7 @@ func (cors *CORSMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Access-Control-Allow-Origin", cors.Origin)
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Set("Access-Control-Expose-Headers", ImpersonatedByHeader)
 
 	if corsMethod != "" {
 		logger.Debugf("CORS Method: %s", corsMethod)
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"net/http"
 	"regexp"
+	"sync"
 
 	"github.com/skygeario/skygear-server/pkg/server/logging"
 )
@@ -36,6 +37,7 @@ type Gateway struct {
 	ParamMatch  *regexp.Regexp
 	methodPaths map[string]pathRoute
 	Tag         string
+	mutex       sync.RWMutex
 }
 
 func NewGateway(pattern string, path string, tag string, mux *http.ServeMux) *Gateway {
@@ -74,18 +76,30 @@ func (g *Gateway) Handle(method string, handler Handler, preprocessors ...Proces
 	if len(preprocessors) == 0 {
 		preprocessors = handler.GetPreprocessors()
 	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
 	g.methodPaths[method] = pathRoute{
 		Preprocessors: preprocessors,
 		Handler:       handler,
 	}
 }
 
+// Remove removes the handler of method. Requests already matched to the
+// handler are not affected.
+func (g *Gateway) Remove(method string) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	delete(g.methodPaths, method)
+}
+
 func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	g.commonRouter.ServeHTTP(w, req)
 }
 
 func (g *Gateway) matchHandler(p *Payload) (routeConfig, error) {
 	method := p.Meta["method"].(string)
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
 	if pathRoute, ok := g.methodPaths[method]; ok {
 		return routeConfig{
 			Tag:           g.Tag,
@@ -54,6 +54,21 @@ func TestRouterMatchByURL(t *testing.T) {
 			So(w.Code, ShouldEqual, 404)
 		})
 
+		Convey("don't matches removed method", func() {
+			g := NewGateway("endpoint", "/endpoint", "tag", nil)
+			g.POST(NewFuncHandler(func(payload *Payload, resp *Response) {
+				writeEntity(resp.Writer(), struct {
+					Status string `json:"status"`
+				}{"ok"})
+			}))
+			g.Remove("POST")
+
+			req, _ := http.NewRequest("POST", "http://skygear.test/endpoint", nil)
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+			So(w.Code, ShouldEqual, 404)
+		})
+
 		Convey("matches simple url action with passin ServeMux", func() {
 			mux := http.NewServeMux()
 			g := NewGateway("endpoint", "/endpoint", "tag", mux)
@@ -36,6 +36,11 @@ type ContextKey string
 var UserIDContextKey ContextKey = "UserID"
 var AccessKeyTypeContextKey ContextKey = "AccessKeyType"
 
+// DBConnContextKey is the key of the database connection of a request,
+// which runs the transaction of the request if one has begun. It is not
+// passed to async hooks, which may run after the connection is closed.
+var DBConnContextKey ContextKey = "DBConn"
+
 // HandlerFunc specifies the function signature of a request handler function
 type HandlerFunc func(*Payload, *Response)
 
@@ -122,6 +127,13 @@ type Payload struct {
 	// is nil if the AccessToken does not exist or is not valid.
 	AccessToken AccessToken
 
+	// ImpersonatorID is the ID of the admin impersonating the user of
+	// AuthInfoID with an impersonation access token.
+	//
+	// The field is injected by preprocessor. The field is empty if the
+	// request is not impersonated.
+	ImpersonatorID string
+
 	DBConn   skydb.Conn
 	Database skydb.Database
 
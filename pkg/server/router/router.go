@@ -40,6 +40,7 @@ type Router struct {
 		sync.RWMutex
 		m map[string]pipeline
 	}
+	actionPreprocessors []Processor
 }
 
 // NewRouter is factory for Router
@@ -71,6 +72,23 @@ func (r *Router) Map(action, tag string, handler Handler, preprocessors ...Proce
 	}
 }
 
+// Unmap removes the mapping of action. Requests already matched to the
+// handler of the action are not affected.
+func (r *Router) Unmap(action string) {
+	r.actions.Lock()
+	defer r.actions.Unlock()
+	delete(r.actions.m, action)
+}
+
+// AppendPreprocessors appends preprocessors to the pipeline of every
+// mapped action, including actions mapped afterwards. They are run after
+// the preprocessors of the action.
+func (r *Router) AppendPreprocessors(preprocessors ...Processor) {
+	r.actions.Lock()
+	defer r.actions.Unlock()
+	r.actionPreprocessors = append(r.actionPreprocessors, preprocessors...)
+}
+
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.commonRouter.ServeHTTP(w, req)
 }
@@ -94,7 +112,8 @@ func (r *Router) matchHandler(p *Payload) (routeConfig, error) {
 	}
 
 	if matchedPipeline == nil {
-		if pipeline, ok := r.actions.m[p.RouteAction()]; ok {
+		action = p.RouteAction()
+		if pipeline, ok := r.actions.m[action]; ok {
 			matchedPipeline = &pipeline
 		}
 	}
@@ -103,9 +122,20 @@ func (r *Router) matchHandler(p *Payload) (routeConfig, error) {
 		return routeConfig{}, errors.New("route unmatched")
 	}
 
+	// the matched action is recorded for preprocessors that apply to
+	// every action
+	p.Meta["action"] = action
+
+	preprocessors := matchedPipeline.Preprocessors
+	if len(r.actionPreprocessors) > 0 {
+		preprocessors = make([]Processor, 0, len(matchedPipeline.Preprocessors)+len(r.actionPreprocessors))
+		preprocessors = append(preprocessors, matchedPipeline.Preprocessors...)
+		preprocessors = append(preprocessors, r.actionPreprocessors...)
+	}
+
 	return routeConfig{
 		Tag:           matchedPipeline.Tag,
-		Preprocessors: matchedPipeline.Preprocessors,
+		Preprocessors: preprocessors,
 		Handler:       matchedPipeline.Handler,
 	}, nil
 }
@@ -156,6 +156,34 @@ func TestRouterMapMissing(t *testing.T) {
 	}
 }
 
+func TestRouterUnmap(t *testing.T) {
+	mockHandler := MockHandler{
+		outputs: Response{},
+	}
+	r := NewRouter()
+	r.Map("mock:map", "tag", &mockHandler)
+	r.Unmap("mock:map")
+	var mockJSON = `{
+	"action": "mock:map"
+}`
+
+	req, _ := http.NewRequest(
+		"POST",
+		"http://skygear.dev/",
+		strings.NewReader(mockJSON),
+	)
+	req.Header.Set("Content-Type", "application/json")
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	expectedBody := `{"error":{"name":"UndefinedOperation","code":117,"message":"route unmatched"}}
+`
+	responseBody := resp.Body.String()
+	if responseBody != expectedBody {
+		t.Fatalf("want resp.Body.String() = %#v, got %#v", expectedBody, responseBody)
+	}
+}
+
 type getPreprocessor struct {
 	Status int
 	Err    skyerr.Error
@@ -293,6 +321,97 @@ func TestPreprocess(t *testing.T) {
 	})
 }
 
+func TestAppendPreprocessors(t *testing.T) {
+	Convey("Router with preprocessors for every action", t, func() {
+		r := NewRouter()
+		calls := []string{}
+		actionPreprocessor := &callbackPreprocessor{
+			callback: func(p *Payload, resp *Response) int {
+				calls = append(calls, "action:"+p.Meta["action"].(string))
+				return http.StatusOK
+			},
+		}
+		handlerPreprocessor := &callbackPreprocessor{
+			callback: func(p *Payload, resp *Response) int {
+				calls = append(calls, "handler")
+				return http.StatusOK
+			},
+		}
+		r.Map("mock:before", "tag", &MockHandler{}, handlerPreprocessor)
+		r.AppendPreprocessors(actionPreprocessor)
+		r.Map("mock:after", "tag", &MockHandler{}, handlerPreprocessor)
+
+		Convey("runs after the preprocessors of actions mapped before", func() {
+			req, _ := http.NewRequest(
+				"POST",
+				"http://skygear.dev/",
+				strings.NewReader(`{"action": "mock:before"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			So(calls, ShouldResemble, []string{"handler", "action:mock:before"})
+		})
+
+		Convey("runs for actions mapped afterwards and routed by URL", func() {
+			req, _ := http.NewRequest(
+				"POST",
+				"http://skygear.dev/mock/after",
+				strings.NewReader(""),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			So(calls, ShouldResemble, []string{"handler", "action:mock:after"})
+		})
+	})
+}
+
+func TestImpersonatedResponse(t *testing.T) {
+	Convey("Router", t, func() {
+		r := NewRouter()
+		impersonatorID := ""
+		preprocessor := &callbackPreprocessor{
+			callback: func(p *Payload, resp *Response) int {
+				p.ImpersonatorID = impersonatorID
+				return http.StatusOK
+			},
+		}
+		r.Map("mock:map", "tag", &MockHandler{}, preprocessor)
+
+		serve := func() *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(
+				"POST",
+				"http://skygear.dev/",
+				strings.NewReader(`{"action": "mock:map"}`),
+			)
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			return resp
+		}
+
+		Convey("marks impersonated response with the impersonator", func() {
+			impersonatorID = "admin"
+			resp := serve()
+			So(resp.Header().Get("X-Skygear-Impersonated-By"), ShouldEqual, "admin")
+		})
+
+		Convey("does not mark response not impersonated", func() {
+			resp := serve()
+			So(resp.Header().Get("X-Skygear-Impersonated-By"), ShouldBeEmpty)
+		})
+	})
+}
+
+type callbackPreprocessor struct {
+	callback func(*Payload, *Response) int
+}
+
+func (p *callbackPreprocessor) Preprocess(payload *Payload, response *Response) int {
+	return p.callback(payload, response)
+}
+
 func TestPreprocessorRegistry(t *testing.T) {
 	mockPreprocessor := &getPreprocessor{}
 
@@ -113,6 +113,17 @@ type PluginConfig struct {
 	Transport string
 	Path      string
 	Args      []string
+	Watch     string
+}
+
+// OIDCProviderConfig is the configuration of a built-in OpenID Connect
+// provider. Endpoints not specified are discovered from the issuer.
+type OIDCProviderConfig struct {
+	Issuer        string
+	ClientID      string
+	ClientSecret  string
+	TokenEndpoint string
+	JWKSURI       string
 }
 
 // Configuration is Skygear's configuration
@@ -140,11 +151,13 @@ type Configuration struct {
 		Option   string `json:"option"`
 	} `json:"database"`
 	TokenStore struct {
-		ImplName string `json:"implementation"`
-		Path     string `json:"path"`
-		Prefix   string `json:"prefix"`
-		Expiry   int64  `json:"expiry"`
-		Secret   string `json:"secret"`
+		ImplName     string `json:"implementation"`
+		Path         string `json:"path"`
+		Prefix       string `json:"prefix"`
+		Expiry       int64  `json:"expiry"`
+		Secret       string `json:"secret"`
+		KeysPath     string `json:"keys_path"`
+		VerifySecret bool   `json:"verify_secret"`
 	} `json:"-"`
 	Auth struct {
 		CustomTokenSecret string `json:"custom_token_secret"`
@@ -216,7 +229,8 @@ type Configuration struct {
 		Timeout   int `json:"timeout"`
 		MaxBounce int `json:"max_bounce"`
 	} `json:"zmq"`
-	Plugin    map[string]*PluginConfig `json:"-"`
+	Plugin    map[string]*PluginConfig       `json:"-"`
+	OIDC      map[string]*OIDCProviderConfig `json:"-"`
 	UserAudit struct {
 		Enabled             bool     `json:"enabled"`
 		TrailHandlerURL     string   `json:"trail_handler_url"`
@@ -235,6 +249,31 @@ type Configuration struct {
 	Verification struct {
 		Required bool `json:"required"`
 	} `json:"verification"`
+	LoginThrottle struct {
+		Enabled            bool     `json:"enabled"`
+		ImplName           string   `json:"implementation"`
+		Path               string   `json:"-"`
+		Prefix             string   `json:"prefix"`
+		AccountMaxAttempts int      `json:"account_max_attempts"`
+		IPMaxAttempts      int      `json:"ip_max_attempts"`
+		Window             int64    `json:"window"`
+		LockoutDuration    int64    `json:"lockout_duration"`
+		MaxLockoutDuration int64    `json:"max_lockout_duration"`
+		TrustedProxies     []string `json:"trusted_proxies"`
+	} `json:"login_throttle"`
+	OAuthServer struct {
+		Enabled           bool   `json:"enabled"`
+		Issuer            string `json:"issuer"`
+		KeysPath          string `json:"-"`
+		AccessTokenExpiry int64  `json:"access_token_expiry"`
+		IDTokenExpiry     int64  `json:"id_token_expiry"`
+		CodeExpiry        int64  `json:"code_expiry"`
+	} `json:"oauth_server"`
+	UserErasure struct {
+		DefaultAction   string              `json:"default_action"`
+		RecordActions   map[string]string   `json:"record_actions"`
+		AnonymizeFields map[string][]string `json:"anonymize_fields"`
+	} `json:"user_erasure"`
 }
 
 func NewConfiguration() Configuration {
@@ -270,6 +309,19 @@ func NewConfiguration() Configuration {
 	config.Zmq.Timeout = 30
 	config.Zmq.MaxBounce = 10
 	config.Plugin = map[string]*PluginConfig{}
+	config.OIDC = map[string]*OIDCProviderConfig{}
+	config.LoginThrottle.ImplName = "memory"
+	config.LoginThrottle.AccountMaxAttempts = 5
+	config.LoginThrottle.IPMaxAttempts = 20
+	config.LoginThrottle.Window = 900
+	config.LoginThrottle.LockoutDuration = 60
+	config.LoginThrottle.MaxLockoutDuration = 3600
+	config.OAuthServer.AccessTokenExpiry = 3600
+	config.OAuthServer.IDTokenExpiry = 3600
+	config.OAuthServer.CodeExpiry = 600
+	config.UserErasure.DefaultAction = "delete"
+	config.UserErasure.RecordActions = map[string]string{}
+	config.UserErasure.AnonymizeFields = map[string][]string{}
 	return config
 }
 
@@ -302,6 +354,29 @@ func (config *Configuration) Validate() error {
 	if config.APNS.Enable && !regexp.MustCompile("^(cert|token)$").MatchString(config.APNS.Type) {
 		return fmt.Errorf("APNS_TYPE must be cert or token")
 	}
+	if config.LoginThrottle.Enabled && !regexp.MustCompile("^(memory|redis)$").MatchString(config.LoginThrottle.ImplName) {
+		return fmt.Errorf("LOGIN_THROTTLE_STORE must be memory or redis")
+	}
+	for name, providerConfig := range config.OIDC {
+		if providerConfig.Issuer == "" {
+			return fmt.Errorf("issuer of OIDC provider '%s' is not set", name)
+		}
+		if providerConfig.ClientID == "" {
+			return fmt.Errorf("client ID of OIDC provider '%s' is not set", name)
+		}
+	}
+	if config.OAuthServer.Enabled && config.OAuthServer.Issuer == "" {
+		return errors.New("OAUTH_SERVER_ISSUER is not set")
+	}
+	erasureActionRegexp := regexp.MustCompile("^(delete|anonymize|keep)$")
+	if config.UserErasure.DefaultAction != "" && !erasureActionRegexp.MatchString(config.UserErasure.DefaultAction) {
+		return fmt.Errorf("USER_ERASURE_DEFAULT_ACTION must be delete, anonymize or keep")
+	}
+	for recordType, action := range config.UserErasure.RecordActions {
+		if !erasureActionRegexp.MatchString(action) {
+			return fmt.Errorf("erasure action of record type '%s' must be delete, anonymize or keep", recordType)
+		}
+	}
 	return config.checkAuthRecordKeysDuplication()
 }
 
@@ -393,8 +468,12 @@ func (config *Configuration) ReadFromEnv() {
 	config.readBaidu()
 	config.readLog()
 	config.readPlugins()
+	config.readOIDCProviders()
 	config.readUserAudit()
 	config.readUserVerification()
+	config.readLoginThrottle()
+	config.readOAuthServer()
+	config.readUserErasure()
 }
 
 func (config *Configuration) readHost() {
@@ -436,6 +515,14 @@ func (config *Configuration) readTokenStore() {
 	} else {
 		config.TokenStore.Secret = config.App.MasterKey
 	}
+
+	if keysPath := os.Getenv("TOKEN_STORE_KEYS_PATH"); keysPath != "" {
+		config.TokenStore.KeysPath = keysPath
+	}
+
+	if verifySecret, err := parseBool(os.Getenv("TOKEN_STORE_VERIFY_SECRET")); err == nil {
+		config.TokenStore.VerifySecret = verifySecret
+	}
 }
 
 func (config *Configuration) readAssetStore() {
@@ -654,10 +741,25 @@ func (config *Configuration) readPlugins() {
 		if args != "" {
 			pluginConfig.Args = strings.Split(args, ",")
 		}
+		pluginConfig.Watch = os.Getenv(p + "_WATCH")
 		config.Plugin[p] = pluginConfig
 	}
 }
 
+func (config *Configuration) readOIDCProviders() {
+	providers := parseCommaSeparatedString(os.Getenv("OIDC_PROVIDERS"))
+	for _, p := range providers {
+		prefix := "OIDC_" + strings.ToUpper(p) + "_"
+		providerConfig := &OIDCProviderConfig{}
+		providerConfig.Issuer = os.Getenv(prefix + "ISSUER")
+		providerConfig.ClientID = os.Getenv(prefix + "CLIENT_ID")
+		providerConfig.ClientSecret = os.Getenv(prefix + "CLIENT_SECRET")
+		providerConfig.TokenEndpoint = os.Getenv(prefix + "TOKEN_ENDPOINT")
+		providerConfig.JWKSURI = os.Getenv(prefix + "JWKS_URI")
+		config.OIDC[p] = providerConfig
+	}
+}
+
 // nolint: gocyclo
 func (config *Configuration) readUserAudit() {
 	if v, err := parseBool(os.Getenv("USER_AUDIT_ENABLED")); err == nil {
@@ -704,3 +806,85 @@ func (config *Configuration) readUserVerification() {
 		config.Verification.Required = v
 	}
 }
+
+func (config *Configuration) readLoginThrottle() {
+	if v, err := parseBool(os.Getenv("LOGIN_THROTTLE_ENABLED")); err == nil {
+		config.LoginThrottle.Enabled = v
+	}
+	if v := os.Getenv("LOGIN_THROTTLE_STORE"); v != "" {
+		config.LoginThrottle.ImplName = v
+	}
+	if v := os.Getenv("LOGIN_THROTTLE_STORE_PATH"); v != "" {
+		config.LoginThrottle.Path = v
+	}
+	if v := os.Getenv("LOGIN_THROTTLE_STORE_PREFIX"); v != "" {
+		config.LoginThrottle.Prefix = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("LOGIN_THROTTLE_ACCOUNT_MAX_ATTEMPTS"), 10, 0); err == nil && v >= 0 {
+		config.LoginThrottle.AccountMaxAttempts = int(v)
+	}
+	if v, err := strconv.ParseInt(os.Getenv("LOGIN_THROTTLE_IP_MAX_ATTEMPTS"), 10, 0); err == nil && v >= 0 {
+		config.LoginThrottle.IPMaxAttempts = int(v)
+	}
+	if v, err := strconv.ParseInt(os.Getenv("LOGIN_THROTTLE_WINDOW"), 10, 64); err == nil && v > 0 {
+		config.LoginThrottle.Window = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("LOGIN_THROTTLE_LOCKOUT_DURATION"), 10, 64); err == nil && v > 0 {
+		config.LoginThrottle.LockoutDuration = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("LOGIN_THROTTLE_MAX_LOCKOUT_DURATION"), 10, 64); err == nil && v > 0 {
+		config.LoginThrottle.MaxLockoutDuration = v
+	}
+	if v := os.Getenv("LOGIN_THROTTLE_TRUSTED_PROXIES"); v != "" {
+		config.LoginThrottle.TrustedProxies = parseCommaSeparatedString(v)
+	}
+}
+
+func (config *Configuration) readOAuthServer() {
+	if v, err := parseBool(os.Getenv("OAUTH_SERVER_ENABLED")); err == nil {
+		config.OAuthServer.Enabled = v
+	}
+	if v := os.Getenv("OAUTH_SERVER_ISSUER"); v != "" {
+		config.OAuthServer.Issuer = strings.TrimSuffix(v, "/")
+	}
+	if v := os.Getenv("OAUTH_SERVER_KEYS_PATH"); v != "" {
+		config.OAuthServer.KeysPath = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("OAUTH_SERVER_ACCESS_TOKEN_EXPIRY"), 10, 64); err == nil && v > 0 {
+		config.OAuthServer.AccessTokenExpiry = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("OAUTH_SERVER_ID_TOKEN_EXPIRY"), 10, 64); err == nil && v > 0 {
+		config.OAuthServer.IDTokenExpiry = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("OAUTH_SERVER_CODE_EXPIRY"), 10, 64); err == nil && v > 0 {
+		config.OAuthServer.CodeExpiry = v
+	}
+}
+
+func (config *Configuration) readUserErasure() {
+	if v := os.Getenv("USER_ERASURE_DEFAULT_ACTION"); v != "" {
+		config.UserErasure.DefaultAction = v
+	}
+	if v := os.Getenv("USER_ERASURE_RECORD_ACTIONS"); v != "" {
+		actions := map[string]string{}
+		for _, item := range parseCommaSeparatedString(v) {
+			parts := strings.SplitN(item, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			actions[parts[0]] = parts[1]
+		}
+		config.UserErasure.RecordActions = actions
+	}
+	if v := os.Getenv("USER_ERASURE_ANONYMIZE_FIELDS"); v != "" {
+		fields := map[string][]string{}
+		for _, item := range parseCommaSeparatedString(v) {
+			parts := strings.SplitN(item, ".", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			fields[parts[0]] = append(fields[parts[0]], parts[1])
+		}
+		config.UserErasure.AnonymizeFields = fields
+	}
+}
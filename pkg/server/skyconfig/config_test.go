@@ -128,6 +128,22 @@ func TestRequiredConfig(t *testing.T) {
 			os.Setenv("TOKEN_STORE_EXPIRY", "")
 		})
 
+		Convey("Read token store keys config correctly", func() {
+			config := NewConfigurationWithKeys()
+			os.Setenv("TOKEN_STORE", "jwt")
+			os.Setenv("TOKEN_STORE_KEYS_PATH", "/etc/skygear/keys")
+			os.Setenv("TOKEN_STORE_VERIFY_SECRET", "true")
+
+			config.readTokenStore()
+			So(config.TokenStore.ImplName, ShouldEqual, "jwt")
+			So(config.TokenStore.KeysPath, ShouldEqual, "/etc/skygear/keys")
+			So(config.TokenStore.VerifySecret, ShouldBeTrue)
+
+			os.Setenv("TOKEN_STORE", "")
+			os.Setenv("TOKEN_STORE_KEYS_PATH", "")
+			os.Setenv("TOKEN_STORE_VERIFY_SECRET", "")
+		})
+
 		Convey("Read plugin config correctly", func() {
 			config := NewConfigurationWithKeys()
 			os.Setenv("PLUGINS", "CAT")
@@ -140,6 +156,7 @@ func TestRequiredConfig(t *testing.T) {
 				"exec",
 				"py-skygear",
 				[]string{"chima", "faseng"},
+				"",
 			})
 
 			os.Setenv("PLUGINS", "")
@@ -154,6 +171,7 @@ func TestRequiredConfig(t *testing.T) {
 			os.Setenv("CAT_TRANSPORT", "exec")
 			os.Setenv("CAT_PATH", "py-skygear")
 			os.Setenv("CAT_ARGS", "chima,faseng")
+			os.Setenv("CAT_WATCH", "/usr/src/app")
 			os.Setenv("BUG_TRANSPORT", "zmq")
 			os.Setenv("BUG_PATH", "tcp://skygear:5555")
 
@@ -162,18 +180,21 @@ func TestRequiredConfig(t *testing.T) {
 				"exec",
 				"py-skygear",
 				[]string{"chima", "faseng"},
+				"/usr/src/app",
 			})
 
 			So(config.Plugin["BUG"], ShouldResemble, &PluginConfig{
 				"zmq",
 				"tcp://skygear:5555",
 				nil,
+				"",
 			})
 
 			os.Setenv("PLUGINS", "")
 			os.Setenv("CAT_TRANSPORT", "")
 			os.Setenv("CAT_PATH", "")
 			os.Setenv("CAT_ARGS", "")
+			os.Setenv("CAT_WATCH", "")
 			os.Setenv("BUG_TRANSPORT", "")
 			os.Setenv("BUG_PATH", "")
 		})
@@ -247,6 +268,131 @@ func TestRequiredConfig(t *testing.T) {
 			os.Setenv("USER_AUDIT_PW_HISTORY_DAYS", "")
 			os.Setenv("USER_AUDIT_PW_EXPIRY_DAYS", "")
 		})
+
+		Convey("Login throttle default values", func() {
+			config := NewConfigurationWithKeys()
+			config.readLoginThrottle()
+			So(config.LoginThrottle.Enabled, ShouldEqual, false)
+			So(config.LoginThrottle.ImplName, ShouldEqual, "memory")
+			So(config.LoginThrottle.AccountMaxAttempts, ShouldEqual, 5)
+			So(config.LoginThrottle.IPMaxAttempts, ShouldEqual, 20)
+			So(config.LoginThrottle.Window, ShouldEqual, 900)
+			So(config.LoginThrottle.LockoutDuration, ShouldEqual, 60)
+			So(config.LoginThrottle.MaxLockoutDuration, ShouldEqual, 3600)
+			So(config.LoginThrottle.TrustedProxies, ShouldBeEmpty)
+		})
+
+		Convey("Read login throttle config correctly", func() {
+			config := NewConfigurationWithKeys()
+			os.Setenv("LOGIN_THROTTLE_ENABLED", "true")
+			os.Setenv("LOGIN_THROTTLE_STORE", "redis")
+			os.Setenv("LOGIN_THROTTLE_STORE_PATH", "redis://redis:6379")
+			os.Setenv("LOGIN_THROTTLE_STORE_PREFIX", "PREFIX")
+			os.Setenv("LOGIN_THROTTLE_ACCOUNT_MAX_ATTEMPTS", "3")
+			os.Setenv("LOGIN_THROTTLE_IP_MAX_ATTEMPTS", "0")
+			os.Setenv("LOGIN_THROTTLE_WINDOW", "60")
+			os.Setenv("LOGIN_THROTTLE_LOCKOUT_DURATION", "30")
+			os.Setenv("LOGIN_THROTTLE_MAX_LOCKOUT_DURATION", "600")
+			os.Setenv("LOGIN_THROTTLE_TRUSTED_PROXIES", "10.0.0.0/8, 127.0.0.1")
+
+			config.readLoginThrottle()
+			So(config.LoginThrottle.Enabled, ShouldEqual, true)
+			So(config.LoginThrottle.ImplName, ShouldEqual, "redis")
+			So(config.LoginThrottle.Path, ShouldEqual, "redis://redis:6379")
+			So(config.LoginThrottle.Prefix, ShouldEqual, "PREFIX")
+			So(config.LoginThrottle.AccountMaxAttempts, ShouldEqual, 3)
+			So(config.LoginThrottle.IPMaxAttempts, ShouldEqual, 0)
+			So(config.LoginThrottle.Window, ShouldEqual, 60)
+			So(config.LoginThrottle.LockoutDuration, ShouldEqual, 30)
+			So(config.LoginThrottle.MaxLockoutDuration, ShouldEqual, 600)
+			So(config.LoginThrottle.TrustedProxies, ShouldResemble, []string{"10.0.0.0/8", "127.0.0.1"})
+
+			os.Setenv("LOGIN_THROTTLE_ENABLED", "")
+			os.Setenv("LOGIN_THROTTLE_STORE", "")
+			os.Setenv("LOGIN_THROTTLE_STORE_PATH", "")
+			os.Setenv("LOGIN_THROTTLE_STORE_PREFIX", "")
+			os.Setenv("LOGIN_THROTTLE_ACCOUNT_MAX_ATTEMPTS", "")
+			os.Setenv("LOGIN_THROTTLE_IP_MAX_ATTEMPTS", "")
+			os.Setenv("LOGIN_THROTTLE_WINDOW", "")
+			os.Setenv("LOGIN_THROTTLE_LOCKOUT_DURATION", "")
+			os.Setenv("LOGIN_THROTTLE_MAX_LOCKOUT_DURATION", "")
+			os.Setenv("LOGIN_THROTTLE_TRUSTED_PROXIES", "")
+		})
+
+		Convey("Read OIDC provider config correctly", func() {
+			config := NewConfigurationWithKeys()
+			os.Setenv("OIDC_PROVIDERS", "google")
+			os.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com")
+			os.Setenv("OIDC_GOOGLE_CLIENT_ID", "client-id")
+			os.Setenv("OIDC_GOOGLE_CLIENT_SECRET", "client-secret")
+			os.Setenv("OIDC_GOOGLE_JWKS_URI", "https://www.googleapis.com/oauth2/v3/certs")
+			config.readOIDCProviders()
+
+			So(config.OIDC, ShouldResemble, map[string]*OIDCProviderConfig{
+				"google": &OIDCProviderConfig{
+					Issuer:       "https://accounts.google.com",
+					ClientID:     "client-id",
+					ClientSecret: "client-secret",
+					JWKSURI:      "https://www.googleapis.com/oauth2/v3/certs",
+				},
+			})
+
+			os.Setenv("OIDC_PROVIDERS", "")
+			os.Setenv("OIDC_GOOGLE_ISSUER", "")
+			os.Setenv("OIDC_GOOGLE_CLIENT_ID", "")
+			os.Setenv("OIDC_GOOGLE_CLIENT_SECRET", "")
+			os.Setenv("OIDC_GOOGLE_JWKS_URI", "")
+		})
+
+		Convey("Read OAuth server config correctly", func() {
+			config := NewConfigurationWithKeys()
+			So(config.OAuthServer.Enabled, ShouldBeFalse)
+			So(config.OAuthServer.CodeExpiry, ShouldEqual, 600)
+
+			os.Setenv("OAUTH_SERVER_ENABLED", "true")
+			os.Setenv("OAUTH_SERVER_ISSUER", "https://myapp.example.com/")
+			os.Setenv("OAUTH_SERVER_KEYS_PATH", "data/keys")
+			os.Setenv("OAUTH_SERVER_ACCESS_TOKEN_EXPIRY", "60")
+			config.readOAuthServer()
+
+			So(config.OAuthServer.Enabled, ShouldBeTrue)
+			So(config.OAuthServer.Issuer, ShouldEqual, "https://myapp.example.com")
+			So(config.OAuthServer.KeysPath, ShouldEqual, "data/keys")
+			So(config.OAuthServer.AccessTokenExpiry, ShouldEqual, 60)
+			So(config.OAuthServer.IDTokenExpiry, ShouldEqual, 3600)
+
+			os.Setenv("OAUTH_SERVER_ENABLED", "")
+			os.Setenv("OAUTH_SERVER_ISSUER", "")
+			os.Setenv("OAUTH_SERVER_KEYS_PATH", "")
+			os.Setenv("OAUTH_SERVER_ACCESS_TOKEN_EXPIRY", "")
+		})
+
+		Convey("Read user erasure config correctly", func() {
+			config := NewConfigurationWithKeys()
+			So(config.UserErasure.DefaultAction, ShouldEqual, "delete")
+
+			os.Setenv("USER_ERASURE_DEFAULT_ACTION", "keep")
+			os.Setenv("USER_ERASURE_RECORD_ACTIONS", "note:delete, comment:anonymize")
+			os.Setenv("USER_ERASURE_ANONYMIZE_FIELDS", "comment.author_name,comment.author_email")
+			config.readUserErasure()
+
+			So(config.UserErasure.DefaultAction, ShouldEqual, "keep")
+			So(config.UserErasure.RecordActions, ShouldResemble, map[string]string{
+				"note":    "delete",
+				"comment": "anonymize",
+			})
+			So(config.UserErasure.AnonymizeFields, ShouldResemble, map[string][]string{
+				"comment": []string{"author_name", "author_email"},
+			})
+			So(config.Validate(), ShouldBeNil)
+
+			config.UserErasure.RecordActions["note"] = "destroy"
+			So(config.Validate(), ShouldNotBeNil)
+
+			os.Setenv("USER_ERASURE_DEFAULT_ACTION", "")
+			os.Setenv("USER_ERASURE_RECORD_ACTIONS", "")
+			os.Setenv("USER_ERASURE_ANONYMIZE_FIELDS", "")
+		})
 	})
 }
 
@@ -21,12 +21,14 @@ import (
 	"strings"
 )
 
-// RecordACLEntry grants access to a record by relation or by user_id
+// RecordACLEntry grants access to a record by relation, by user_id or by
+// group_id
 type RecordACLEntry struct {
 	Relation string         `json:"relation,omitempty"`
 	Role     string         `json:"role,omitempty"`
 	Level    RecordACLLevel `json:"level"`
 	UserID   string         `json:"user_id,omitempty"`
+	GroupID  string         `json:"group_id,omitempty"`
 	Public   bool           `json:"public,omitempty"`
 }
 
@@ -65,6 +67,14 @@ func NewRecordACLEntryRole(role string, level RecordACLLevel) RecordACLEntry {
 	}
 }
 
+// NewRecordACLEntryGroup return an ACE for members of a group
+func NewRecordACLEntryGroup(groupID string, level RecordACLLevel) RecordACLEntry {
+	return RecordACLEntry{
+		GroupID: groupID,
+		Level:   level,
+	}
+}
+
 // NewRecordACLEntryPublic return an ACE on public access
 func NewRecordACLEntryPublic(level RecordACLLevel) RecordACLEntry {
 	return RecordACLEntry{
@@ -85,13 +95,18 @@ func (ace *RecordACLEntry) Accessible(authinfo *AuthInfo, level RecordACLLevel)
 			return true
 		}
 	}
-	for _, role := range authinfo.Roles {
+	for _, role := range authinfo.EffectiveRoles() {
 		if role == ace.Role {
 			if ace.AccessibleLevel(level) {
 				return true
 			}
 		}
 	}
+	if ace.GroupID != "" && authinfo.IsGroupMember(ace.GroupID) {
+		if ace.AccessibleLevel(level) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -137,6 +152,86 @@ func (acl RecordACL) Accessible(authinfo *AuthInfo, level RecordACLLevel) bool {
 	return accessible
 }
 
+// RecordACLPatch is a change to the ACL of a record. Entries in Remove
+// are removed from the ACL first, then entries in Add that are not
+// already in the ACL are appended.
+type RecordACLPatch struct {
+	Add    []RecordACLEntry
+	Remove []RecordACLEntry
+}
+
+// IsEmpty returns true if the patch does not change any ACL.
+func (patch RecordACLPatch) IsEmpty() bool {
+	return len(patch.Add) == 0 && len(patch.Remove) == 0
+}
+
+// Apply returns the ACL with the patch applied, and whether the ACL
+// is changed. The supplied ACL is not modified.
+//
+// A nil ACL grants public read and write access, so it is patched as an
+// ACL with a public write entry. It stays nil if the patch does not
+// change it.
+func (patch RecordACLPatch) Apply(acl RecordACL) (RecordACL, bool) {
+	original := acl
+	if acl == nil {
+		acl = RecordACL{NewRecordACLEntryPublic(WriteLevel)}
+	}
+
+	patched := RecordACL{}
+	changed := false
+
+	for _, ace := range acl {
+		if containsRecordACLEntry(patch.Remove, ace) {
+			changed = true
+			continue
+		}
+		patched = append(patched, ace)
+	}
+
+	for _, ace := range patch.Add {
+		if containsRecordACLEntry(patched, ace) {
+			continue
+		}
+		patched = append(patched, ace)
+		changed = true
+	}
+
+	if !changed {
+		return original, false
+	}
+	return patched, true
+}
+
+func containsRecordACLEntry(entries []RecordACLEntry, ace RecordACLEntry) bool {
+	for _, entry := range entries {
+		if entry == ace {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordPredicateAccess is the row-level access of a record type.
+//
+// Read is ANDed into every query of the record type and checked on fetch.
+// Write is checked on save, against the existing record and the record as
+// it is saved, and on delete.
+// Both are in the format of record query predicate, in which
+// {"$type": "user"} stands for the current user. An empty predicate does
+// not restrict access.
+type RecordPredicateAccess struct {
+	Read  []interface{} `json:"read,omitempty"`
+	Write []interface{} `json:"write,omitempty"`
+}
+
+// Predicate returns the predicate of the access level.
+func (access RecordPredicateAccess) Predicate(level RecordACLLevel) []interface{} {
+	if level == WriteLevel {
+		return access.Write
+	}
+	return access.Read
+}
+
 // FieldAccessMode is the intended access operation to be granted access
 type FieldAccessMode int
 
@@ -481,7 +576,7 @@ func (r FieldUserRole) Match(authinfo *AuthInfo, record *Record) bool {
 	case DynamicUserFieldUserRoleType:
 		return r.matchDynamic(authinfo, record)
 	case DefinedRoleFieldUserRoleType:
-		for _, role := range authinfo.Roles {
+		for _, role := range authinfo.EffectiveRoles() {
 			if role == r.Data {
 				return true
 			}
@@ -482,6 +482,9 @@ func TestFieldUserRole(t *testing.T) {
 			So(NewFieldUserRole("_any_user").Match(nil, nil), ShouldBeFalse)
 			So(NewFieldUserRole("_role:admin").Match(johndoe, nil), ShouldBeFalse)
 			So(NewFieldUserRole("_role:admin").Match(janedoe, nil), ShouldBeTrue)
+			So(NewFieldUserRole("_role:editor").Match(janedoe, nil), ShouldBeFalse)
+			janedoe.InheritedRoles = []string{"editor"}
+			So(NewFieldUserRole("_role:editor").Match(janedoe, nil), ShouldBeTrue)
 			So(NewFieldUserRole("_field:uid").Match(johndoe, record), ShouldBeTrue)
 			So(NewFieldUserRole("_field:uid").Match(johndoe, nil), ShouldBeFalse)
 			So(NewFieldUserRole("_field:uid").Match(janedoe, record), ShouldBeFalse)
@@ -495,3 +498,78 @@ func TestFieldUserRole(t *testing.T) {
 		})
 	})
 }
+
+func TestRecordACLPatch(t *testing.T) {
+	Convey("RecordACLPatch", t, func() {
+		publicRead := NewRecordACLEntryPublic(ReadLevel)
+		adminWrite := NewRecordACLEntryRole("admin", WriteLevel)
+		johnWrite := NewRecordACLEntryDirect("johndoe", WriteLevel)
+
+		Convey("should add entries", func() {
+			patch := RecordACLPatch{Add: []RecordACLEntry{adminWrite}}
+			acl, changed := patch.Apply(RecordACL{publicRead})
+			So(changed, ShouldBeTrue)
+			So(acl, ShouldResemble, RecordACL{publicRead, adminWrite})
+		})
+
+		Convey("should not add existing entries", func() {
+			patch := RecordACLPatch{Add: []RecordACLEntry{publicRead}}
+			acl, changed := patch.Apply(RecordACL{publicRead})
+			So(changed, ShouldBeFalse)
+			So(acl, ShouldResemble, RecordACL{publicRead})
+		})
+
+		Convey("should remove entries before adding", func() {
+			patch := RecordACLPatch{
+				Add:    []RecordACLEntry{johnWrite},
+				Remove: []RecordACLEntry{publicRead, johnWrite},
+			}
+			acl, changed := patch.Apply(RecordACL{publicRead, adminWrite})
+			So(changed, ShouldBeTrue)
+			So(acl, ShouldResemble, RecordACL{adminWrite, johnWrite})
+		})
+
+		Convey("should remove all entries to empty ACL", func() {
+			patch := RecordACLPatch{Remove: []RecordACLEntry{publicRead}}
+			acl, changed := patch.Apply(RecordACL{publicRead})
+			So(changed, ShouldBeTrue)
+			So(acl, ShouldNotBeNil)
+			So(acl, ShouldBeEmpty)
+		})
+
+		Convey("should keep nil ACL without entries to add", func() {
+			patch := RecordACLPatch{Remove: []RecordACLEntry{publicRead}}
+			acl, changed := patch.Apply(nil)
+			So(changed, ShouldBeFalse)
+			So(acl, ShouldBeNil)
+		})
+
+		Convey("should keep public access of nil ACL when adding entries", func() {
+			patch := RecordACLPatch{Add: []RecordACLEntry{adminWrite}}
+			acl, changed := patch.Apply(nil)
+			So(changed, ShouldBeTrue)
+			So(acl, ShouldResemble, RecordACL{
+				NewRecordACLEntryPublic(WriteLevel),
+				adminWrite,
+			})
+			So(acl.Accessible(nil, WriteLevel), ShouldBeTrue)
+		})
+
+		Convey("should remove public write entry of nil ACL", func() {
+			patch := RecordACLPatch{
+				Add:    []RecordACLEntry{publicRead},
+				Remove: []RecordACLEntry{NewRecordACLEntryPublic(WriteLevel)},
+			}
+			acl, changed := patch.Apply(nil)
+			So(changed, ShouldBeTrue)
+			So(acl, ShouldResemble, RecordACL{publicRead})
+		})
+
+		Convey("should not modify the supplied ACL", func() {
+			original := RecordACL{publicRead, adminWrite}
+			patch := RecordACLPatch{Remove: []RecordACLEntry{publicRead}}
+			patch.Apply(original)
+			So(original, ShouldResemble, RecordACL{publicRead, adminWrite})
+		})
+	})
+}
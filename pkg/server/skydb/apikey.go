@@ -0,0 +1,140 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrAPIKeyNotFound is returned by Conn.GetAPIKey, Conn.GetAPIKeyByHash
+// and Conn.UpdateAPIKey when the APIKey is not found.
+var ErrAPIKeyNotFound = errors.New("skydb: API key not found")
+
+// APIKey is an API key issued to a client application, managed in the
+// database in addition to the API key and master key in configuration.
+//
+// The key itself is not stored; HashedKey is the SHA-256 of the key so
+// that it can be looked up on each request. When the key is rotated with
+// a grace period, the previous key remains usable until
+// PreviousKeyExpireAt.
+type APIKey struct {
+	ID                  string     `json:"id"`
+	Name                string     `json:"name"`
+	HashedKey           string     `json:"-"`
+	PreviousHashedKey   string     `json:"-"`
+	PreviousKeyExpireAt *time.Time `json:"previous_key_expire_at,omitempty"`
+	Scopes              []string   `json:"scopes"`
+	AllowedOrigins      []string   `json:"allowed_origins"`
+	RateLimit           int        `json:"rate_limit"`
+	ExpireAt            *time.Time `json:"expire_at,omitempty"`
+	RevokedAt           *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// HashAPIKey returns the hash of the key stored as APIKey.HashedKey.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetKey sets the HashedKey with the key specified.
+func (k *APIKey) SetKey(key string) {
+	k.HashedKey = HashAPIKey(key)
+}
+
+// Rotate replaces the key with a new key. If gracePeriod is positive,
+// the current key remains usable until the grace period elapses.
+func (k *APIKey) Rotate(key string, now time.Time, gracePeriod time.Duration) {
+	if gracePeriod > 0 {
+		expireAt := now.Add(gracePeriod)
+		k.PreviousHashedKey = k.HashedKey
+		k.PreviousKeyExpireAt = &expireAt
+	} else {
+		k.PreviousHashedKey = ""
+		k.PreviousKeyExpireAt = nil
+	}
+	k.SetKey(key)
+}
+
+// IsValidAt returns true if the key with the hash can be used at the time
+// specified, i.e. it is not revoked or expired.
+func (k *APIKey) IsValidAt(hashedKey string, now time.Time) bool {
+	if k.RevokedAt != nil && !now.Before(*k.RevokedAt) {
+		return false
+	}
+	if k.ExpireAt != nil && !now.Before(*k.ExpireAt) {
+		return false
+	}
+
+	if hashedKey == k.HashedKey {
+		return true
+	}
+	return k.PreviousHashedKey != "" && hashedKey == k.PreviousHashedKey &&
+		k.PreviousKeyExpireAt != nil && now.Before(*k.PreviousKeyExpireAt)
+}
+
+// IsOriginAllowed returns true if requests from the origin are allowed.
+// Requests without an origin, i.e. not from a browser, are always allowed.
+func (k *APIKey) IsOriginAllowed(origin string) bool {
+	if origin == "" || len(k.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range k.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope returns true if the key is allowed to call the action, or the
+// route with the tag if the request is not an action.
+//
+// A scope is one of the following:
+//
+// "*" allows everything.
+//
+// An action name such as "record:fetch" allows that action.
+//
+// An action prefix such as "record:*" allows actions starting with
+// "record:".
+//
+// A tag prefixed with "@" such as "@asset" allows actions and routes with
+// that tag.
+func (k *APIKey) HasScope(action string, tag string) bool {
+	for _, scope := range k.Scopes {
+		switch {
+		case scope == "*":
+			return true
+		case strings.HasPrefix(scope, "@"):
+			if tag != "" && scope[1:] == tag {
+				return true
+			}
+		case strings.HasSuffix(scope, ":*"):
+			if action != "" && strings.HasPrefix(action, strings.TrimSuffix(scope, "*")) {
+				return true
+			}
+		default:
+			if action != "" && scope == action {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAPIKey(t *testing.T) {
+	Convey("APIKey", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		key := APIKey{}
+		key.SetKey("secret-key")
+		hashedKey := HashAPIKey("secret-key")
+
+		Convey("is valid unless revoked or expired", func() {
+			So(key.IsValidAt(hashedKey, now), ShouldBeTrue)
+			So(key.IsValidAt(HashAPIKey("other-key"), now), ShouldBeFalse)
+
+			expireAt := now.Add(time.Hour)
+			key.ExpireAt = &expireAt
+			So(key.IsValidAt(hashedKey, now), ShouldBeTrue)
+			So(key.IsValidAt(hashedKey, expireAt), ShouldBeFalse)
+
+			key.RevokedAt = &now
+			So(key.IsValidAt(hashedKey, now), ShouldBeFalse)
+		})
+
+		Convey("keeps previous key valid in grace period", func() {
+			key.Rotate("new-key", now, time.Hour)
+			So(key.IsValidAt(HashAPIKey("new-key"), now), ShouldBeTrue)
+			So(key.IsValidAt(hashedKey, now.Add(time.Minute)), ShouldBeTrue)
+			So(key.IsValidAt(hashedKey, now.Add(time.Hour)), ShouldBeFalse)
+
+			key.Rotate("newer-key", now, 0)
+			So(key.IsValidAt(HashAPIKey("new-key"), now), ShouldBeFalse)
+			So(key.PreviousKeyExpireAt, ShouldBeNil)
+		})
+
+		Convey("matches scopes", func() {
+			key.Scopes = []string{"record:*", "me", "@asset"}
+			So(key.HasScope("record:fetch", "record"), ShouldBeTrue)
+			So(key.HasScope("me", ""), ShouldBeTrue)
+			So(key.HasScope("asset:put", "asset"), ShouldBeTrue)
+			So(key.HasScope("", "asset"), ShouldBeTrue)
+			So(key.HasScope("recordx", "record"), ShouldBeFalse)
+			So(key.HasScope("auth:login", "auth"), ShouldBeFalse)
+			So(key.HasScope("", "pubsub"), ShouldBeFalse)
+
+			key.Scopes = []string{"*"}
+			So(key.HasScope("auth:login", "auth"), ShouldBeTrue)
+		})
+
+		Convey("matches allowed origins", func() {
+			So(key.IsOriginAllowed("https://any.example.com"), ShouldBeTrue)
+
+			key.AllowedOrigins = []string{"https://app.example.com"}
+			So(key.IsOriginAllowed(""), ShouldBeTrue)
+			So(key.IsOriginAllowed("https://app.example.com"), ShouldBeTrue)
+			So(key.IsOriginAllowed("https://any.example.com"), ShouldBeFalse)
+		})
+	})
+}
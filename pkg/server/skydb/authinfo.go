@@ -45,6 +45,8 @@ type AuthInfo struct {
 	ID              string       `json:"_id"`
 	HashedPassword  []byte       `json:"password,omitempty"`
 	Roles           []string     `json:"roles,omitempty"`
+	InheritedRoles  []string     `json:"-"`                       // roles inherited from Roles, populated on load
+	GroupIDs        []string     `json:"-"`                       // groups the user is a member of, populated on load
 	ProviderInfo    ProviderInfo `json:"provider_info,omitempty"` // auth data for alternative methods
 	TokenValidSince *time.Time   `json:"token_valid_since,omitempty"`
 	LastSeenAt      *time.Time   `json:"last_seen_at,omitempty"`
@@ -267,14 +269,30 @@ func (info *AuthInfo) SetProviderInfoData(principalID string, authData map[strin
 	info.ProviderInfo[principalID] = authData
 }
 
+// EffectiveRoles returns the roles assigned to the user together with the
+// roles inherited from them.
+func (info *AuthInfo) EffectiveRoles() []string {
+	if len(info.InheritedRoles) == 0 {
+		return info.Roles
+	}
+	roles := make([]string, 0, len(info.Roles)+len(info.InheritedRoles))
+	roles = append(roles, info.Roles...)
+	return append(roles, info.InheritedRoles...)
+}
+
 // HasAnyRoles return true if authinfo belongs to one of the supplied roles
 func (info *AuthInfo) HasAnyRoles(roles []string) bool {
-	return utils.StringSliceContainAny(info.Roles, roles)
+	return utils.StringSliceContainAny(info.EffectiveRoles(), roles)
 }
 
 // HasAllRoles return true if authinfo has all roles supplied
 func (info *AuthInfo) HasAllRoles(roles []string) bool {
-	return utils.StringSliceContainAll(info.Roles, roles)
+	return utils.StringSliceContainAll(info.EffectiveRoles(), roles)
+}
+
+// IsGroupMember return true if authinfo is a member of the group
+func (info *AuthInfo) IsGroupMember(groupID string) bool {
+	return utils.StringSliceContainAny(info.GroupIDs, []string{groupID})
 }
 
 // GetProviderInfoData gets the auth data for the specified principal.
@@ -0,0 +1,33 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUserBlockNotFound is returned by Conn.UnblockUser when the user is
+// not blocked.
+var ErrUserBlockNotFound = errors.New("skydb: User block not found")
+
+// UserBlock records that the blocker has blocked the blocked user. A
+// blocked user cannot read records owned by the blocker, request
+// relations with the blocker, or publish to the blocker via pubsub.
+type UserBlock struct {
+	BlockerID string    `json:"blocker_id"`
+	BlockedID string    `json:"blocked_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -41,6 +41,12 @@ var ErrDeviceNotFound = errors.New("skydb: Specific device not found")
 // operation modifies the database and the database is readonly.
 var ErrDatabaseIsReadOnly = errors.New("skydb: database is read only")
 
+// ErrLeaseLost is returned by the updates of claimed items, such as
+// Conn.UpdateClaimedWebhookDelivery, Conn.UpdateClaimedJob and
+// Conn.UpdateClaimedTimer, when the lease of the claim has
+// expired and the item is changed or claimed again since.
+var ErrLeaseLost = errors.New("skydb: lease of the claimed item is lost")
+
 // ZeroTime represent a zero time.Time. It is used in DeleteDevicesByToken and
 // DeleteEmptyDevicesByTime to signify a Delete without time constraint.
 var ZeroTime = time.Time{}
@@ -94,6 +100,17 @@ type Conn interface {
 	// exist in the container.
 	DeleteAuth(id string) error
 
+	// PurgeAuth removes AuthInfo with the supplied ID together with the
+	// data referencing it, which are the roles, devices, subscriptions,
+	// relations, relation requests, blocks, group memberships, password
+	// history, verification codes and SSO principals of the user.
+	//
+	// Records are not removed, which are erased by the caller.
+	//
+	// PurgeAuth returns ErrUserNotFound if such AuthInfo does not
+	// exist in the container.
+	PurgeAuth(id string) error
+
 	// GetPasswordHistory returns a slice of PasswordHistory of the given user
 	//
 	// If historySize is greater than 0, the returned slice contains history
@@ -133,6 +150,21 @@ type Conn interface {
 	// GetRoles returns roles of users specified by user IDs
 	GetRoles(userIDs []string) (map[string][]string, error)
 
+	// GetRoleHierarchy returns the roles inherited by each role
+	GetRoleHierarchy() (RoleHierarchy, error)
+
+	// SetRoleHierarchy replaces the inherited roles of each role in the
+	// supplied hierarchy; roles not in the hierarchy are left untouched.
+	// Roles not already existed in DB will be created.
+	SetRoleHierarchy(hierarchy RoleHierarchy) error
+
+	// GetRolePermissions returns the actions permitted for each role
+	GetRolePermissions() (RolePermissions, error)
+
+	// SetRolePermissions replaces the actions permitted for the role. The
+	// role will be created if not already existed in DB.
+	SetRolePermissions(role string, actions []string) error
+
 	// SetRecordAccess sets default record access of a specific type
 	SetRecordAccess(recordType string, acl RecordACL) error
 
@@ -151,6 +183,13 @@ type Conn interface {
 	// GetRecordFieldAccess retrieve field ACL setting
 	GetRecordFieldAccess() (FieldACL, error)
 
+	// SetRecordPredicateAccess sets the row-level access of a specific type
+	SetRecordPredicateAccess(recordType string, access RecordPredicateAccess) error
+
+	// GetRecordPredicateAccess returns the row-level access of a specific
+	// type
+	GetRecordPredicateAccess(recordType string) (RecordPredicateAccess, error)
+
 	// GetAsset retrieves Asset information by its name
 	GetAsset(name string, asset *Asset) error
 
@@ -165,6 +204,64 @@ type Conn interface {
 	AddRelation(user string, name string, targetUser string) error
 	RemoveRelation(user string, name string, targetUser string) error
 
+	// CreateRelationType defines a relation type. It returns
+	// ErrRelationTypeDuplicated if the type is already defined.
+	CreateRelationType(relationType *RelationType) error
+
+	// GetRelationType returns the relation type of the name. It returns
+	// ErrRelationTypeNotFound if the type is not defined.
+	GetRelationType(name string) (RelationType, error)
+
+	// GetRelationTypes returns all relation types defined, ordered by name.
+	// The builtin _friend and _follow relations are not included.
+	GetRelationTypes() ([]RelationType, error)
+
+	// DeleteRelationType removes the relation type and all relations of
+	// the type.
+	DeleteRelationType(name string) error
+
+	// SaveRelation adds a relation, or updates the metadata of an existing
+	// relation. The reverse relation is also saved if the relation type is
+	// mutual.
+	SaveRelation(relation *Relation) error
+
+	// GetRelation returns the relation from user to targetUser. It returns
+	// ErrRelationNotFound if the users are not related.
+	GetRelation(user string, name string, targetUser string) (Relation, error)
+
+	// CreateRelationRequest creates a pending request from the requester
+	// to the recipient. A request no longer pending is replaced. It
+	// returns ErrRelationRequestDuplicated if a pending request exists.
+	CreateRelationRequest(request *RelationRequest) error
+
+	// GetRelationRequest returns the request of the relation from the
+	// requester to the recipient. It returns ErrRelationRequestNotFound if
+	// there is no such request.
+	GetRelationRequest(name string, requesterID string, recipientID string) (RelationRequest, error)
+
+	// UpdateRelationRequest updates the state of the request.
+	UpdateRelationRequest(request *RelationRequest) error
+
+	// QueryRelationRequests returns the pending requests of the relation
+	// received by the user (direction "incoming") or sent by the user
+	// (direction "outgoing"), latest first.
+	QueryRelationRequests(user string, name string, direction string, config QueryConfig) ([]RelationRequest, error)
+
+	// BlockUser adds the blocked user to the block list of the blocker.
+	// Blocking a user already blocked has no effect.
+	BlockUser(block *UserBlock) error
+
+	// UnblockUser removes the blocked user from the block list of the
+	// blocker. It returns ErrUserBlockNotFound if the user is not blocked.
+	UnblockUser(blockerID string, blockedID string) error
+
+	// QueryBlockedUsers returns the block list of the blocker, ordered by
+	// the time of blocking.
+	QueryBlockedUsers(blockerID string) ([]UserBlock, error)
+
+	// IsUserBlocked returns true if the blocker has blocked the user.
+	IsUserBlocked(blockerID string, blockedID string) (bool, error)
+
 	GetDevice(id string, device *Device) error
 
 	// QueryDevicesByUser queries the Device database which are registered
@@ -243,6 +340,13 @@ type Conn interface {
 	Close() error
 
 	CustomTokenConn
+	OAuthServerConn
+	APIKeyConn
+	GroupConn
+	WebhookConn
+	JobConn
+	TimerConn
+	UserDataExportConn
 }
 
 type CustomTokenConn interface {
@@ -251,6 +355,264 @@ type CustomTokenConn interface {
 	DeleteCustomTokenInfo(principalID string) error
 }
 
+// OAuthServerConn persists the clients and authorization codes of the
+// OAuth 2.0 authorization server.
+type OAuthServerConn interface {
+	// CreateOAuthClient creates a new OAuthClient.
+	CreateOAuthClient(client *OAuthClient) error
+
+	// GetOAuthClient fetches the OAuthClient with the specified client ID.
+	//
+	// GetOAuthClient returns ErrOAuthClientNotFound if the client does
+	// not exist.
+	GetOAuthClient(clientID string, client *OAuthClient) error
+
+	// DeleteOAuthClient removes the OAuthClient with the specified client
+	// ID, together with its authorization codes.
+	//
+	// DeleteOAuthClient returns ErrOAuthClientNotFound if the client does
+	// not exist.
+	DeleteOAuthClient(clientID string) error
+
+	// CreateOAuthAuthorizationCode creates a new OAuthAuthorizationCode.
+	CreateOAuthAuthorizationCode(code *OAuthAuthorizationCode) error
+
+	// ConsumeOAuthAuthorizationCode fetches and removes the
+	// OAuthAuthorizationCode with the specified code, so that a code
+	// can be consumed once only.
+	//
+	// ConsumeOAuthAuthorizationCode returns ErrOAuthCodeNotFound if the
+	// code does not exist, is consumed or is expired.
+	ConsumeOAuthAuthorizationCode(code string, authCode *OAuthAuthorizationCode) error
+}
+
+// APIKeyConn persists the API keys issued to client applications.
+type APIKeyConn interface {
+	// CreateAPIKey creates a new APIKey.
+	CreateAPIKey(key *APIKey) error
+
+	// GetAPIKey fetches the APIKey with the specified ID.
+	//
+	// GetAPIKey returns ErrAPIKeyNotFound if the key does not exist.
+	GetAPIKey(id string, key *APIKey) error
+
+	// GetAPIKeyByHash fetches the APIKey whose current or previous key
+	// has the specified hash. The caller is responsible for checking
+	// whether the key is still valid.
+	//
+	// GetAPIKeyByHash returns ErrAPIKeyNotFound if the key does not exist.
+	GetAPIKeyByHash(hashedKey string, key *APIKey) error
+
+	// UpdateAPIKey updates an existing APIKey.
+	//
+	// UpdateAPIKey returns ErrAPIKeyNotFound if the key does not exist.
+	UpdateAPIKey(key *APIKey) error
+
+	// QueryAPIKeys returns all APIKeys ordered by creation time.
+	QueryAPIKeys() ([]APIKey, error)
+}
+
+// GroupConn persists groups of users and their memberships.
+type GroupConn interface {
+	// CreateGroup creates a new group.
+	CreateGroup(group *Group) error
+
+	// GetGroup fetches the group by its ID.
+	//
+	// GetGroup returns ErrGroupNotFound if the group does not exist.
+	GetGroup(id string, group *Group) error
+
+	// DeleteGroup deletes the group and all its memberships.
+	//
+	// DeleteGroup returns ErrGroupNotFound if the group does not exist.
+	DeleteGroup(id string) error
+
+	// QueryGroupsByMember returns the groups the user is a member of
+	// ordered by name.
+	QueryGroupsByMember(userID string) ([]Group, error)
+
+	// SetGroupMember adds the user to the group, or updates the role of
+	// the user if the user is already a member.
+	SetGroupMember(membership *GroupMembership) error
+
+	// GetGroupMember fetches the membership of the user in the group.
+	//
+	// GetGroupMember returns ErrGroupMemberNotFound if the user is not
+	// a member of the group.
+	GetGroupMember(groupID string, userID string, membership *GroupMembership) error
+
+	// RemoveGroupMember removes the user from the group.
+	//
+	// RemoveGroupMember returns ErrGroupMemberNotFound if the user is not
+	// a member of the group.
+	RemoveGroupMember(groupID string, userID string) error
+
+	// QueryGroupMembers returns all memberships of the group ordered by
+	// the time the members joined.
+	QueryGroupMembers(groupID string) ([]GroupMembership, error)
+}
+
+// WebhookConn persists webhooks and the outbox of their deliveries.
+type WebhookConn interface {
+	// CreateWebhook creates a new Webhook.
+	CreateWebhook(webhook *Webhook) error
+
+	// GetWebhook fetches the Webhook with the specified ID.
+	//
+	// GetWebhook returns ErrWebhookNotFound if the webhook does not exist.
+	GetWebhook(id string, webhook *Webhook) error
+
+	// UpdateWebhook updates an existing Webhook.
+	//
+	// UpdateWebhook returns ErrWebhookNotFound if the webhook does not
+	// exist.
+	UpdateWebhook(webhook *Webhook) error
+
+	// DeleteWebhook removes the Webhook with the specified ID, together
+	// with its deliveries.
+	//
+	// DeleteWebhook returns ErrWebhookNotFound if the webhook does not
+	// exist.
+	DeleteWebhook(id string) error
+
+	// QueryWebhooks returns all Webhooks ordered by creation time.
+	QueryWebhooks() ([]Webhook, error)
+
+	// CreateWebhookDelivery creates a new WebhookDelivery.
+	CreateWebhookDelivery(delivery *WebhookDelivery) error
+
+	// GetWebhookDelivery fetches the WebhookDelivery with the specified ID.
+	//
+	// GetWebhookDelivery returns ErrWebhookDeliveryNotFound if the
+	// delivery does not exist.
+	GetWebhookDelivery(id string, delivery *WebhookDelivery) error
+
+	// UpdateWebhookDelivery updates an existing WebhookDelivery.
+	//
+	// UpdateWebhookDelivery returns ErrWebhookDeliveryNotFound if the
+	// delivery does not exist.
+	UpdateWebhookDelivery(delivery *WebhookDelivery) error
+
+	// QueryWebhookDeliveries returns the latest deliveries of the webhook
+	// first, at most limit of them. If status is not empty, only
+	// deliveries of that status are returned.
+	QueryWebhookDeliveries(webhookID string, status WebhookDeliveryStatus, limit uint64) ([]WebhookDelivery, error)
+
+	// ClaimWebhookDelivery fetches a pending delivery due at now, and
+	// postpones its next attempt to leaseUntil so that it is not claimed
+	// by another server instance while being attempted.
+	//
+	// ClaimWebhookDelivery returns ErrWebhookDeliveryNotFound if no
+	// delivery is due.
+	ClaimWebhookDelivery(now time.Time, leaseUntil time.Time, delivery *WebhookDelivery) error
+
+	// UpdateClaimedWebhookDelivery updates a delivery claimed until
+	// leaseUntil, only if it is not changed since it is claimed.
+	//
+	// UpdateClaimedWebhookDelivery returns ErrWebhookDeliveryNotFound if
+	// the delivery does not exist, and ErrLeaseLost if the delivery is
+	// changed or claimed again after the lease has expired.
+	UpdateClaimedWebhookDelivery(delivery *WebhookDelivery, leaseUntil time.Time) error
+}
+
+// JobConn persists the background jobs of plugin lambdas.
+type JobConn interface {
+	// CreateJob creates a new Job.
+	CreateJob(job *Job) error
+
+	// GetJob fetches the Job with the specified ID.
+	//
+	// GetJob returns ErrJobNotFound if the job does not exist.
+	GetJob(id string, job *Job) error
+
+	// UpdateJob updates an existing Job.
+	//
+	// UpdateJob returns ErrJobNotFound if the job does not exist.
+	UpdateJob(job *Job) error
+
+	// QueryJobs returns the latest jobs of the status first, at most
+	// limit of them.
+	QueryJobs(status JobStatus, limit uint64) ([]Job, error)
+
+	// ClaimJob fetches a job that is pending and due at now, or running
+	// but locked until before now. The claimed job is marked as running
+	// and locked until lockedUntil, and its attempts are incremented, so
+	// that it is not claimed by another server instance while being run.
+	//
+	// ClaimJob returns ErrJobNotFound if no job is due.
+	ClaimJob(now time.Time, lockedUntil time.Time, job *Job) error
+
+	// UpdateClaimedJob updates a job claimed until lockedUntil, only if
+	// it is not changed since it is claimed.
+	//
+	// UpdateClaimedJob returns ErrJobNotFound if the job does not exist,
+	// and ErrLeaseLost if the job is changed or claimed again after the
+	// lock has expired.
+	UpdateClaimedJob(job *Job, lockedUntil time.Time) error
+}
+
+// TimerConn persists the schedule and the last run of plugin timers.
+type TimerConn interface {
+	// RegisterTimer creates the Timer if it does not exist, otherwise it
+	// updates the spec and catch-up of the timer. The next run of an
+	// existing timer is updated only if its spec is changed.
+	RegisterTimer(timer *Timer) error
+
+	// GetTimer fetches the Timer with the specified name.
+	//
+	// GetTimer returns ErrTimerNotFound if the timer does not exist.
+	GetTimer(name string, timer *Timer) error
+
+	// UpdateTimer updates the next run, the lock and the last run of an
+	// existing Timer.
+	//
+	// UpdateTimer returns ErrTimerNotFound if the timer does not exist.
+	UpdateTimer(timer *Timer) error
+
+	// QueryTimers returns all timers ordered by name.
+	QueryTimers() ([]Timer, error)
+
+	// ClaimTimer fetches a timer of the names that is due at now and not
+	// locked, and locks it until lockedUntil, so that it is not run by
+	// another server instance.
+	//
+	// ClaimTimer returns ErrTimerNotFound if no timer is due.
+	ClaimTimer(names []string, now time.Time, lockedUntil time.Time, timer *Timer) error
+
+	// UpdateClaimedTimer updates a timer claimed or locked until
+	// lockedUntil, only if it is not claimed again since.
+	//
+	// UpdateClaimedTimer returns ErrTimerNotFound if the timer does not
+	// exist, and ErrLeaseLost if the timer is claimed again after the
+	// lock has expired.
+	UpdateClaimedTimer(timer *Timer, lockedUntil time.Time) error
+
+	// LockTimer locks the Timer until lockedUntil regardless of whether
+	// it is due.
+	//
+	// LockTimer returns ErrTimerNotFound if the timer does not exist, and
+	// ErrTimerLocked if the timer is locked at now.
+	LockTimer(name string, now time.Time, lockedUntil time.Time, timer *Timer) error
+}
+
+// UserDataExportConn keeps track of the archives of user data, so that
+// they are deleted when they expire.
+type UserDataExportConn interface {
+	// CreateUserDataExport creates a new UserDataExport.
+	CreateUserDataExport(export *UserDataExport) error
+
+	// QueryUserDataExports returns the exports of the user.
+	QueryUserDataExports(userID string) ([]UserDataExport, error)
+
+	// QueryExpiredUserDataExports returns at most limit exports expired
+	// at now, the earliest expired first.
+	QueryExpiredUserDataExports(now time.Time, limit uint64) ([]UserDataExport, error)
+
+	// DeleteUserDataExport removes the export of the asset name. It is
+	// not an error if the export does not exist.
+	DeleteUserDataExport(assetName string) error
+}
+
 // AccessModel indicates the type of access control model while db query.
 //go:generate stringer -type=AccessModel
 type AccessModel int
@@ -133,6 +133,16 @@ type Database interface {
 	// the number of records matching the query's predicate.
 	QueryCount(query *Query, accessControlOptions *AccessControlOptions) (uint64, error)
 
+	// UpdateRecordACL applies the ACL patch to all records matching the
+	// query, in batches of batchSize records ordered by record ID.
+	// Access control is not applied to the query. Records with the ACL
+	// unchanged by the patch are not written.
+	//
+	// fn, if not nil, is called after each batch is written; the update
+	// stops if fn returns an error. UpdateRecordACL returns the progress
+	// of the update up to the last batch written.
+	UpdateRecordACL(query *Query, patch RecordACLPatch, batchSize uint64, fn RecordACLUpdateFunc) (RecordACLUpdateProgress, error)
+
 	// Extend extends the Database record schema such that a record
 	// arrived subsequently with that schema can be saved
 	//
@@ -164,6 +174,21 @@ type Database interface {
 	DeleteIndex(recordType string, indexName string) error
 }
 
+// RecordACLUpdateProgress is the progress of Database.UpdateRecordACL.
+type RecordACLUpdateProgress struct {
+	// Batches is the number of batches written.
+	Batches uint64
+	// Matched is the number of records matching the query.
+	Matched uint64
+	// Updated is the number of records with the ACL changed.
+	Updated uint64
+}
+
+// RecordACLUpdateFunc is called by Database.UpdateRecordACL after a batch
+// of records is written. records are the records with ACL changed in the
+// batch, and originalRecords are the same records before the change.
+type RecordACLUpdateFunc func(records []Record, originalRecords []Record, progress RecordACLUpdateProgress) error
+
 // Transactional defines the methods for a persistence storage that supports
 // transaction.
 //
@@ -0,0 +1,68 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrGroupNotFound is returned by Conn.GetGroup and Conn.DeleteGroup when
+// the Group is not found.
+var ErrGroupNotFound = errors.New("skydb: Group not found")
+
+// ErrGroupMemberNotFound is returned by Conn.GetGroupMember and
+// Conn.RemoveGroupMember when the user is not a member of the group.
+var ErrGroupMemberNotFound = errors.New("skydb: Group member not found")
+
+// Group is a team of users managed by its members. A record can be shared
+// with all members of a group by a RecordACLEntry with GroupID.
+type Group struct {
+	ID        string    `json:"_id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GroupMemberRole is the role of a member in a group.
+type GroupMemberRole string
+
+const (
+	// GroupOwner can manage the group, including its admins, and delete
+	// the group.
+	GroupOwner GroupMemberRole = "owner"
+	// GroupAdmin can manage members of the group.
+	GroupAdmin GroupMemberRole = "admin"
+	// GroupMember is an ordinary member of the group.
+	GroupMember GroupMemberRole = "member"
+)
+
+// IsValid returns true if the role is one of the defined roles.
+func (r GroupMemberRole) IsValid() bool {
+	return r == GroupOwner || r == GroupAdmin || r == GroupMember
+}
+
+// CanManageMembers returns true if the member can add and remove members.
+func (r GroupMemberRole) CanManageMembers() bool {
+	return r == GroupOwner || r == GroupAdmin
+}
+
+// GroupMembership is the membership of a user in a group.
+type GroupMembership struct {
+	GroupID   string          `json:"group_id"`
+	UserID    string          `json:"user_id"`
+	Role      GroupMemberRole `json:"role"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,46 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGroupMemberRole(t *testing.T) {
+	Convey("GroupMemberRole", t, func() {
+		Convey("validates role", func() {
+			So(GroupOwner.IsValid(), ShouldBeTrue)
+			So(GroupAdmin.IsValid(), ShouldBeTrue)
+			So(GroupMember.IsValid(), ShouldBeTrue)
+			So(GroupMemberRole("guest").IsValid(), ShouldBeFalse)
+		})
+
+		Convey("allows owner and admin to manage members", func() {
+			So(GroupOwner.CanManageMembers(), ShouldBeTrue)
+			So(GroupAdmin.CanManageMembers(), ShouldBeTrue)
+			So(GroupMember.CanManageMembers(), ShouldBeFalse)
+		})
+	})
+
+	Convey("AuthInfo", t, func() {
+		Convey("checks group membership", func() {
+			authinfo := AuthInfo{GroupIDs: []string{"editors"}}
+			So(authinfo.IsGroupMember("editors"), ShouldBeTrue)
+			So(authinfo.IsGroupMember("authors"), ShouldBeFalse)
+		})
+	})
+}
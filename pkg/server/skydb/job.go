@@ -0,0 +1,68 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrJobNotFound is returned by Conn.GetJob and Conn.UpdateJob when the
+// Job is not found.
+var ErrJobNotFound = errors.New("skydb: job not found")
+
+// JobStatus is the status of a Job.
+type JobStatus string
+
+// The statuses of a Job. A pending job is run when RunAt is due. A failed
+// run is retried until the job has been attempted MaxAttempts times, after
+// which the job is dead and kept for inspection.
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobDead      JobStatus = "dead"
+)
+
+// IsValid returns true if the status is one of the statuses of a Job.
+func (s JobStatus) IsValid() bool {
+	switch s {
+	case JobPending, JobRunning, JobSucceeded, JobDead:
+		return true
+	}
+	return false
+}
+
+// Job is a run of a plugin lambda scheduled in the background.
+//
+// Args is passed to the lambda as its parameters. If UserID is not empty,
+// the lambda is run on behalf of the user. A running job is locked until
+// LockedUntil, after which it is considered abandoned and run again.
+type Job struct {
+	ID          string          `json:"id"`
+	Lambda      string          `json:"lambda"`
+	Args        json.RawMessage `json:"args"`
+	UserID      string          `json:"user_id,omitempty"`
+	Status      JobStatus       `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedUntil *time.Time      `json:"-"`
+	LastError   string          `json:"last_error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
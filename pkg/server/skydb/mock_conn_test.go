@@ -631,61 +631,1606 @@ func (_mr *MockConnMockRecorder) DeleteCustomTokenInfo(arg0 interface{}) *gomock
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteCustomTokenInfo", reflect.TypeOf((*MockConn)(nil).DeleteCustomTokenInfo), arg0)
 }
 
+// CreateOAuthClient mocks base method
+func (_m *MockConn) CreateOAuthClient(client *OAuthClient) error {
+	ret := _m.ctrl.Call(_m, "CreateOAuthClient", client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOAuthClient indicates an expected call of CreateOAuthClient
+func (_mr *MockConnMockRecorder) CreateOAuthClient(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateOAuthClient", reflect.TypeOf((*MockConn)(nil).CreateOAuthClient), arg0)
+}
+
+// GetOAuthClient mocks base method
+func (_m *MockConn) GetOAuthClient(clientID string, client *OAuthClient) error {
+	ret := _m.ctrl.Call(_m, "GetOAuthClient", clientID, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetOAuthClient indicates an expected call of GetOAuthClient
+func (_mr *MockConnMockRecorder) GetOAuthClient(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetOAuthClient", reflect.TypeOf((*MockConn)(nil).GetOAuthClient), arg0, arg1)
+}
+
+// DeleteOAuthClient mocks base method
+func (_m *MockConn) DeleteOAuthClient(clientID string) error {
+	ret := _m.ctrl.Call(_m, "DeleteOAuthClient", clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOAuthClient indicates an expected call of DeleteOAuthClient
+func (_mr *MockConnMockRecorder) DeleteOAuthClient(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteOAuthClient", reflect.TypeOf((*MockConn)(nil).DeleteOAuthClient), arg0)
+}
+
+// CreateOAuthAuthorizationCode mocks base method
+func (_m *MockConn) CreateOAuthAuthorizationCode(code *OAuthAuthorizationCode) error {
+	ret := _m.ctrl.Call(_m, "CreateOAuthAuthorizationCode", code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOAuthAuthorizationCode indicates an expected call of CreateOAuthAuthorizationCode
+func (_mr *MockConnMockRecorder) CreateOAuthAuthorizationCode(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateOAuthAuthorizationCode", reflect.TypeOf((*MockConn)(nil).CreateOAuthAuthorizationCode), arg0)
+}
+
+// ConsumeOAuthAuthorizationCode mocks base method
+func (_m *MockConn) ConsumeOAuthAuthorizationCode(code string, authCode *OAuthAuthorizationCode) error {
+	ret := _m.ctrl.Call(_m, "ConsumeOAuthAuthorizationCode", code, authCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeOAuthAuthorizationCode indicates an expected call of ConsumeOAuthAuthorizationCode
+func (_mr *MockConnMockRecorder) ConsumeOAuthAuthorizationCode(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ConsumeOAuthAuthorizationCode", reflect.TypeOf((*MockConn)(nil).ConsumeOAuthAuthorizationCode), arg0, arg1)
+}
+
+// CreateAPIKey mocks base method
+func (_m *MockConn) CreateAPIKey(key *APIKey) error {
+	ret := _m.ctrl.Call(_m, "CreateAPIKey", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey
+func (_mr *MockConnMockRecorder) CreateAPIKey(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateAPIKey", reflect.TypeOf((*MockConn)(nil).CreateAPIKey), arg0)
+}
+
+// GetAPIKey mocks base method
+func (_m *MockConn) GetAPIKey(id string, key *APIKey) error {
+	ret := _m.ctrl.Call(_m, "GetAPIKey", id, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey
+func (_mr *MockConnMockRecorder) GetAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetAPIKey", reflect.TypeOf((*MockConn)(nil).GetAPIKey), arg0, arg1)
+}
+
+// GetAPIKeyByHash mocks base method
+func (_m *MockConn) GetAPIKeyByHash(hashedKey string, key *APIKey) error {
+	ret := _m.ctrl.Call(_m, "GetAPIKeyByHash", hashedKey, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash
+func (_mr *MockConnMockRecorder) GetAPIKeyByHash(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockConn)(nil).GetAPIKeyByHash), arg0, arg1)
+}
+
+// UpdateAPIKey mocks base method
+func (_m *MockConn) UpdateAPIKey(key *APIKey) error {
+	ret := _m.ctrl.Call(_m, "UpdateAPIKey", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAPIKey indicates an expected call of UpdateAPIKey
+func (_mr *MockConnMockRecorder) UpdateAPIKey(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateAPIKey", reflect.TypeOf((*MockConn)(nil).UpdateAPIKey), arg0)
+}
+
+// QueryAPIKeys mocks base method
+func (_m *MockConn) QueryAPIKeys() ([]APIKey, error) {
+	ret := _m.ctrl.Call(_m, "QueryAPIKeys")
+	ret0, _ := ret[0].([]APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryAPIKeys indicates an expected call of QueryAPIKeys
+func (_mr *MockConnMockRecorder) QueryAPIKeys() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryAPIKeys", reflect.TypeOf((*MockConn)(nil).QueryAPIKeys))
+}
+
+// GetRoleHierarchy mocks base method
+func (_m *MockConn) GetRoleHierarchy() (RoleHierarchy, error) {
+	ret := _m.ctrl.Call(_m, "GetRoleHierarchy")
+	ret0, _ := ret[0].(RoleHierarchy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleHierarchy indicates an expected call of GetRoleHierarchy
+func (_mr *MockConnMockRecorder) GetRoleHierarchy() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRoleHierarchy", reflect.TypeOf((*MockConn)(nil).GetRoleHierarchy))
+}
+
+// SetRoleHierarchy mocks base method
+func (_m *MockConn) SetRoleHierarchy(hierarchy RoleHierarchy) error {
+	ret := _m.ctrl.Call(_m, "SetRoleHierarchy", hierarchy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRoleHierarchy indicates an expected call of SetRoleHierarchy
+func (_mr *MockConnMockRecorder) SetRoleHierarchy(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetRoleHierarchy", reflect.TypeOf((*MockConn)(nil).SetRoleHierarchy), arg0)
+}
+
+// GetRolePermissions mocks base method
+func (_m *MockConn) GetRolePermissions() (RolePermissions, error) {
+	ret := _m.ctrl.Call(_m, "GetRolePermissions")
+	ret0, _ := ret[0].(RolePermissions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolePermissions indicates an expected call of GetRolePermissions
+func (_mr *MockConnMockRecorder) GetRolePermissions() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRolePermissions", reflect.TypeOf((*MockConn)(nil).GetRolePermissions))
+}
+
+// SetRolePermissions mocks base method
+func (_m *MockConn) SetRolePermissions(role string, actions []string) error {
+	ret := _m.ctrl.Call(_m, "SetRolePermissions", role, actions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRolePermissions indicates an expected call of SetRolePermissions
+func (_mr *MockConnMockRecorder) SetRolePermissions(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetRolePermissions", reflect.TypeOf((*MockConn)(nil).SetRolePermissions), arg0, arg1)
+}
+
+// CreateGroup mocks base method
+func (_m *MockConn) CreateGroup(group *Group) error {
+	ret := _m.ctrl.Call(_m, "CreateGroup", group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateGroup indicates an expected call of CreateGroup
+func (_mr *MockConnMockRecorder) CreateGroup(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateGroup", reflect.TypeOf((*MockConn)(nil).CreateGroup), arg0)
+}
+
+// GetGroup mocks base method
+func (_m *MockConn) GetGroup(id string, group *Group) error {
+	ret := _m.ctrl.Call(_m, "GetGroup", id, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetGroup indicates an expected call of GetGroup
+func (_mr *MockConnMockRecorder) GetGroup(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetGroup", reflect.TypeOf((*MockConn)(nil).GetGroup), arg0, arg1)
+}
+
+// DeleteGroup mocks base method
+func (_m *MockConn) DeleteGroup(id string) error {
+	ret := _m.ctrl.Call(_m, "DeleteGroup", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup
+func (_mr *MockConnMockRecorder) DeleteGroup(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteGroup", reflect.TypeOf((*MockConn)(nil).DeleteGroup), arg0)
+}
+
+// QueryGroupsByMember mocks base method
+func (_m *MockConn) QueryGroupsByMember(userID string) ([]Group, error) {
+	ret := _m.ctrl.Call(_m, "QueryGroupsByMember", userID)
+	ret0, _ := ret[0].([]Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryGroupsByMember indicates an expected call of QueryGroupsByMember
+func (_mr *MockConnMockRecorder) QueryGroupsByMember(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryGroupsByMember", reflect.TypeOf((*MockConn)(nil).QueryGroupsByMember), arg0)
+}
+
+// SetGroupMember mocks base method
+func (_m *MockConn) SetGroupMember(membership *GroupMembership) error {
+	ret := _m.ctrl.Call(_m, "SetGroupMember", membership)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGroupMember indicates an expected call of SetGroupMember
+func (_mr *MockConnMockRecorder) SetGroupMember(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetGroupMember", reflect.TypeOf((*MockConn)(nil).SetGroupMember), arg0)
+}
+
+// GetGroupMember mocks base method
+func (_m *MockConn) GetGroupMember(groupID string, userID string, membership *GroupMembership) error {
+	ret := _m.ctrl.Call(_m, "GetGroupMember", groupID, userID, membership)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetGroupMember indicates an expected call of GetGroupMember
+func (_mr *MockConnMockRecorder) GetGroupMember(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetGroupMember", reflect.TypeOf((*MockConn)(nil).GetGroupMember), arg0, arg1, arg2)
+}
+
+// RemoveGroupMember mocks base method
+func (_m *MockConn) RemoveGroupMember(groupID string, userID string) error {
+	ret := _m.ctrl.Call(_m, "RemoveGroupMember", groupID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGroupMember indicates an expected call of RemoveGroupMember
+func (_mr *MockConnMockRecorder) RemoveGroupMember(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RemoveGroupMember", reflect.TypeOf((*MockConn)(nil).RemoveGroupMember), arg0, arg1)
+}
+
+// QueryGroupMembers mocks base method
+func (_m *MockConn) QueryGroupMembers(groupID string) ([]GroupMembership, error) {
+	ret := _m.ctrl.Call(_m, "QueryGroupMembers", groupID)
+	ret0, _ := ret[0].([]GroupMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryGroupMembers indicates an expected call of QueryGroupMembers
+func (_mr *MockConnMockRecorder) QueryGroupMembers(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryGroupMembers", reflect.TypeOf((*MockConn)(nil).QueryGroupMembers), arg0)
+}
+
+// CreateWebhook mocks base method
+func (_m *MockConn) CreateWebhook(webhook *Webhook) error {
+	ret := _m.ctrl.Call(_m, "CreateWebhook", webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook
+func (_mr *MockConnMockRecorder) CreateWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateWebhook", reflect.TypeOf((*MockConn)(nil).CreateWebhook), arg0)
+}
+
+// GetWebhook mocks base method
+func (_m *MockConn) GetWebhook(id string, webhook *Webhook) error {
+	ret := _m.ctrl.Call(_m, "GetWebhook", id, webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWebhook indicates an expected call of GetWebhook
+func (_mr *MockConnMockRecorder) GetWebhook(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetWebhook", reflect.TypeOf((*MockConn)(nil).GetWebhook), arg0, arg1)
+}
+
+// UpdateWebhook mocks base method
+func (_m *MockConn) UpdateWebhook(webhook *Webhook) error {
+	ret := _m.ctrl.Call(_m, "UpdateWebhook", webhook)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook
+func (_mr *MockConnMockRecorder) UpdateWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateWebhook", reflect.TypeOf((*MockConn)(nil).UpdateWebhook), arg0)
+}
+
+// DeleteWebhook mocks base method
+func (_m *MockConn) DeleteWebhook(id string) error {
+	ret := _m.ctrl.Call(_m, "DeleteWebhook", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook
+func (_mr *MockConnMockRecorder) DeleteWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteWebhook", reflect.TypeOf((*MockConn)(nil).DeleteWebhook), arg0)
+}
+
+// QueryWebhooks mocks base method
+func (_m *MockConn) QueryWebhooks() ([]Webhook, error) {
+	ret := _m.ctrl.Call(_m, "QueryWebhooks")
+	ret0, _ := ret[0].([]Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWebhooks indicates an expected call of QueryWebhooks
+func (_mr *MockConnMockRecorder) QueryWebhooks() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryWebhooks", reflect.TypeOf((*MockConn)(nil).QueryWebhooks))
+}
+
+// CreateWebhookDelivery mocks base method
+func (_m *MockConn) CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "CreateWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery
+func (_mr *MockConnMockRecorder) CreateWebhookDelivery(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockConn)(nil).CreateWebhookDelivery), arg0)
+}
+
+// GetWebhookDelivery mocks base method
+func (_m *MockConn) GetWebhookDelivery(id string, delivery *WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "GetWebhookDelivery", id, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery
+func (_mr *MockConnMockRecorder) GetWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockConn)(nil).GetWebhookDelivery), arg0, arg1)
+}
+
+// UpdateWebhookDelivery mocks base method
+func (_m *MockConn) UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "UpdateWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery
+func (_mr *MockConnMockRecorder) UpdateWebhookDelivery(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockConn)(nil).UpdateWebhookDelivery), arg0)
+}
+
+// QueryWebhookDeliveries mocks base method
+func (_m *MockConn) QueryWebhookDeliveries(webhookID string, status WebhookDeliveryStatus, limit uint64) ([]WebhookDelivery, error) {
+	ret := _m.ctrl.Call(_m, "QueryWebhookDeliveries", webhookID, status, limit)
+	ret0, _ := ret[0].([]WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWebhookDeliveries indicates an expected call of QueryWebhookDeliveries
+func (_mr *MockConnMockRecorder) QueryWebhookDeliveries(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryWebhookDeliveries", reflect.TypeOf((*MockConn)(nil).QueryWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// ClaimWebhookDelivery mocks base method
+func (_m *MockConn) ClaimWebhookDelivery(now time.Time, leaseUntil time.Time, delivery *WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "ClaimWebhookDelivery", now, leaseUntil, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimWebhookDelivery indicates an expected call of ClaimWebhookDelivery
+func (_mr *MockConnMockRecorder) ClaimWebhookDelivery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimWebhookDelivery", reflect.TypeOf((*MockConn)(nil).ClaimWebhookDelivery), arg0, arg1, arg2)
+}
+
+// UpdateClaimedWebhookDelivery mocks base method
+func (_m *MockConn) UpdateClaimedWebhookDelivery(delivery *WebhookDelivery, leaseUntil time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedWebhookDelivery", delivery, leaseUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedWebhookDelivery indicates an expected call of UpdateClaimedWebhookDelivery
+func (_mr *MockConnMockRecorder) UpdateClaimedWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedWebhookDelivery", reflect.TypeOf((*MockConn)(nil).UpdateClaimedWebhookDelivery), arg0, arg1)
+}
+
+// CreateJob mocks base method
+func (_m *MockConn) CreateJob(job *Job) error {
+	ret := _m.ctrl.Call(_m, "CreateJob", job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJob indicates an expected call of CreateJob
+func (_mr *MockConnMockRecorder) CreateJob(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateJob", reflect.TypeOf((*MockConn)(nil).CreateJob), arg0)
+}
+
+// GetJob mocks base method
+func (_m *MockConn) GetJob(id string, job *Job) error {
+	ret := _m.ctrl.Call(_m, "GetJob", id, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetJob indicates an expected call of GetJob
+func (_mr *MockConnMockRecorder) GetJob(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetJob", reflect.TypeOf((*MockConn)(nil).GetJob), arg0, arg1)
+}
+
+// UpdateJob mocks base method
+func (_m *MockConn) UpdateJob(job *Job) error {
+	ret := _m.ctrl.Call(_m, "UpdateJob", job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateJob indicates an expected call of UpdateJob
+func (_mr *MockConnMockRecorder) UpdateJob(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateJob", reflect.TypeOf((*MockConn)(nil).UpdateJob), arg0)
+}
+
+// QueryJobs mocks base method
+func (_m *MockConn) QueryJobs(status JobStatus, limit uint64) ([]Job, error) {
+	ret := _m.ctrl.Call(_m, "QueryJobs", status, limit)
+	ret0, _ := ret[0].([]Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryJobs indicates an expected call of QueryJobs
+func (_mr *MockConnMockRecorder) QueryJobs(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryJobs", reflect.TypeOf((*MockConn)(nil).QueryJobs), arg0, arg1)
+}
+
+// ClaimJob mocks base method
+func (_m *MockConn) ClaimJob(now time.Time, lockedUntil time.Time, job *Job) error {
+	ret := _m.ctrl.Call(_m, "ClaimJob", now, lockedUntil, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimJob indicates an expected call of ClaimJob
+func (_mr *MockConnMockRecorder) ClaimJob(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimJob", reflect.TypeOf((*MockConn)(nil).ClaimJob), arg0, arg1, arg2)
+}
+
+// UpdateClaimedJob mocks base method
+func (_m *MockConn) UpdateClaimedJob(job *Job, lockedUntil time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedJob", job, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedJob indicates an expected call of UpdateClaimedJob
+func (_mr *MockConnMockRecorder) UpdateClaimedJob(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedJob", reflect.TypeOf((*MockConn)(nil).UpdateClaimedJob), arg0, arg1)
+}
+
+// RegisterTimer mocks base method
+func (_m *MockConn) RegisterTimer(timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "RegisterTimer", timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterTimer indicates an expected call of RegisterTimer
+func (_mr *MockConnMockRecorder) RegisterTimer(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RegisterTimer", reflect.TypeOf((*MockConn)(nil).RegisterTimer), arg0)
+}
+
+// GetTimer mocks base method
+func (_m *MockConn) GetTimer(name string, timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "GetTimer", name, timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetTimer indicates an expected call of GetTimer
+func (_mr *MockConnMockRecorder) GetTimer(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetTimer", reflect.TypeOf((*MockConn)(nil).GetTimer), arg0, arg1)
+}
+
+// UpdateTimer mocks base method
+func (_m *MockConn) UpdateTimer(timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "UpdateTimer", timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTimer indicates an expected call of UpdateTimer
+func (_mr *MockConnMockRecorder) UpdateTimer(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateTimer", reflect.TypeOf((*MockConn)(nil).UpdateTimer), arg0)
+}
+
+// QueryTimers mocks base method
+func (_m *MockConn) QueryTimers() ([]Timer, error) {
+	ret := _m.ctrl.Call(_m, "QueryTimers")
+	ret0, _ := ret[0].([]Timer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryTimers indicates an expected call of QueryTimers
+func (_mr *MockConnMockRecorder) QueryTimers() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryTimers", reflect.TypeOf((*MockConn)(nil).QueryTimers))
+}
+
+// ClaimTimer mocks base method
+func (_m *MockConn) ClaimTimer(names []string, now time.Time, lockedUntil time.Time, timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "ClaimTimer", names, now, lockedUntil, timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimTimer indicates an expected call of ClaimTimer
+func (_mr *MockConnMockRecorder) ClaimTimer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimTimer", reflect.TypeOf((*MockConn)(nil).ClaimTimer), arg0, arg1, arg2, arg3)
+}
+
+// UpdateClaimedTimer mocks base method
+func (_m *MockConn) UpdateClaimedTimer(timer *Timer, lockedUntil time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedTimer", timer, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedTimer indicates an expected call of UpdateClaimedTimer
+func (_mr *MockConnMockRecorder) UpdateClaimedTimer(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedTimer", reflect.TypeOf((*MockConn)(nil).UpdateClaimedTimer), arg0, arg1)
+}
+
+// LockTimer mocks base method
+func (_m *MockConn) LockTimer(name string, now time.Time, lockedUntil time.Time, timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "LockTimer", name, now, lockedUntil, timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockTimer indicates an expected call of LockTimer
+func (_mr *MockConnMockRecorder) LockTimer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "LockTimer", reflect.TypeOf((*MockConn)(nil).LockTimer), arg0, arg1, arg2, arg3)
+}
+
+// CreateUserDataExport mocks base method
+func (_m *MockConn) CreateUserDataExport(export *UserDataExport) error {
+	ret := _m.ctrl.Call(_m, "CreateUserDataExport", export)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUserDataExport indicates an expected call of CreateUserDataExport
+func (_mr *MockConnMockRecorder) CreateUserDataExport(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateUserDataExport", reflect.TypeOf((*MockConn)(nil).CreateUserDataExport), arg0)
+}
+
+// QueryUserDataExports mocks base method
+func (_m *MockConn) QueryUserDataExports(userID string) ([]UserDataExport, error) {
+	ret := _m.ctrl.Call(_m, "QueryUserDataExports", userID)
+	ret0, _ := ret[0].([]UserDataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryUserDataExports indicates an expected call of QueryUserDataExports
+func (_mr *MockConnMockRecorder) QueryUserDataExports(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryUserDataExports", reflect.TypeOf((*MockConn)(nil).QueryUserDataExports), arg0)
+}
+
+// QueryExpiredUserDataExports mocks base method
+func (_m *MockConn) QueryExpiredUserDataExports(now time.Time, limit uint64) ([]UserDataExport, error) {
+	ret := _m.ctrl.Call(_m, "QueryExpiredUserDataExports", now, limit)
+	ret0, _ := ret[0].([]UserDataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryExpiredUserDataExports indicates an expected call of QueryExpiredUserDataExports
+func (_mr *MockConnMockRecorder) QueryExpiredUserDataExports(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryExpiredUserDataExports", reflect.TypeOf((*MockConn)(nil).QueryExpiredUserDataExports), arg0, arg1)
+}
+
+// DeleteUserDataExport mocks base method
+func (_m *MockConn) DeleteUserDataExport(assetName string) error {
+	ret := _m.ctrl.Call(_m, "DeleteUserDataExport", assetName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserDataExport indicates an expected call of DeleteUserDataExport
+func (_mr *MockConnMockRecorder) DeleteUserDataExport(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteUserDataExport", reflect.TypeOf((*MockConn)(nil).DeleteUserDataExport), arg0)
+}
+
+// SetRecordPredicateAccess mocks base method
+func (_m *MockConn) SetRecordPredicateAccess(recordType string, access RecordPredicateAccess) error {
+	ret := _m.ctrl.Call(_m, "SetRecordPredicateAccess", recordType, access)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRecordPredicateAccess indicates an expected call of SetRecordPredicateAccess
+func (_mr *MockConnMockRecorder) SetRecordPredicateAccess(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetRecordPredicateAccess", reflect.TypeOf((*MockConn)(nil).SetRecordPredicateAccess), arg0, arg1)
+}
+
+// GetRecordPredicateAccess mocks base method
+func (_m *MockConn) GetRecordPredicateAccess(recordType string) (RecordPredicateAccess, error) {
+	ret := _m.ctrl.Call(_m, "GetRecordPredicateAccess", recordType)
+	ret0, _ := ret[0].(RecordPredicateAccess)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecordPredicateAccess indicates an expected call of GetRecordPredicateAccess
+func (_mr *MockConnMockRecorder) GetRecordPredicateAccess(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRecordPredicateAccess", reflect.TypeOf((*MockConn)(nil).GetRecordPredicateAccess), arg0)
+}
+
+// CreateRelationType mocks base method
+func (_m *MockConn) CreateRelationType(relationType *RelationType) error {
+	ret := _m.ctrl.Call(_m, "CreateRelationType", relationType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRelationType indicates an expected call of CreateRelationType
+func (_mr *MockConnMockRecorder) CreateRelationType(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateRelationType", reflect.TypeOf((*MockConn)(nil).CreateRelationType), arg0)
+}
+
+// GetRelationType mocks base method
+func (_m *MockConn) GetRelationType(name string) (RelationType, error) {
+	ret := _m.ctrl.Call(_m, "GetRelationType", name)
+	ret0, _ := ret[0].(RelationType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelationType indicates an expected call of GetRelationType
+func (_mr *MockConnMockRecorder) GetRelationType(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRelationType", reflect.TypeOf((*MockConn)(nil).GetRelationType), arg0)
+}
+
+// GetRelationTypes mocks base method
+func (_m *MockConn) GetRelationTypes() ([]RelationType, error) {
+	ret := _m.ctrl.Call(_m, "GetRelationTypes")
+	ret0, _ := ret[0].([]RelationType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelationTypes indicates an expected call of GetRelationTypes
+func (_mr *MockConnMockRecorder) GetRelationTypes() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRelationTypes", reflect.TypeOf((*MockConn)(nil).GetRelationTypes))
+}
+
+// DeleteRelationType mocks base method
+func (_m *MockConn) DeleteRelationType(name string) error {
+	ret := _m.ctrl.Call(_m, "DeleteRelationType", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRelationType indicates an expected call of DeleteRelationType
+func (_mr *MockConnMockRecorder) DeleteRelationType(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteRelationType", reflect.TypeOf((*MockConn)(nil).DeleteRelationType), arg0)
+}
+
+// SaveRelation mocks base method
+func (_m *MockConn) SaveRelation(relation *Relation) error {
+	ret := _m.ctrl.Call(_m, "SaveRelation", relation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRelation indicates an expected call of SaveRelation
+func (_mr *MockConnMockRecorder) SaveRelation(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SaveRelation", reflect.TypeOf((*MockConn)(nil).SaveRelation), arg0)
+}
+
+// GetRelation mocks base method
+func (_m *MockConn) GetRelation(user string, name string, targetUser string) (Relation, error) {
+	ret := _m.ctrl.Call(_m, "GetRelation", user, name, targetUser)
+	ret0, _ := ret[0].(Relation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelation indicates an expected call of GetRelation
+func (_mr *MockConnMockRecorder) GetRelation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRelation", reflect.TypeOf((*MockConn)(nil).GetRelation), arg0, arg1, arg2)
+}
+
+// CreateRelationRequest mocks base method
+func (_m *MockConn) CreateRelationRequest(request *RelationRequest) error {
+	ret := _m.ctrl.Call(_m, "CreateRelationRequest", request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRelationRequest indicates an expected call of CreateRelationRequest
+func (_mr *MockConnMockRecorder) CreateRelationRequest(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateRelationRequest", reflect.TypeOf((*MockConn)(nil).CreateRelationRequest), arg0)
+}
+
+// GetRelationRequest mocks base method
+func (_m *MockConn) GetRelationRequest(name string, requesterID string, recipientID string) (RelationRequest, error) {
+	ret := _m.ctrl.Call(_m, "GetRelationRequest", name, requesterID, recipientID)
+	ret0, _ := ret[0].(RelationRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelationRequest indicates an expected call of GetRelationRequest
+func (_mr *MockConnMockRecorder) GetRelationRequest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRelationRequest", reflect.TypeOf((*MockConn)(nil).GetRelationRequest), arg0, arg1, arg2)
+}
+
+// UpdateRelationRequest mocks base method
+func (_m *MockConn) UpdateRelationRequest(request *RelationRequest) error {
+	ret := _m.ctrl.Call(_m, "UpdateRelationRequest", request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRelationRequest indicates an expected call of UpdateRelationRequest
+func (_mr *MockConnMockRecorder) UpdateRelationRequest(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateRelationRequest", reflect.TypeOf((*MockConn)(nil).UpdateRelationRequest), arg0)
+}
+
+// QueryRelationRequests mocks base method
+func (_m *MockConn) QueryRelationRequests(user string, name string, direction string, config QueryConfig) ([]RelationRequest, error) {
+	ret := _m.ctrl.Call(_m, "QueryRelationRequests", user, name, direction, config)
+	ret0, _ := ret[0].([]RelationRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryRelationRequests indicates an expected call of QueryRelationRequests
+func (_mr *MockConnMockRecorder) QueryRelationRequests(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryRelationRequests", reflect.TypeOf((*MockConn)(nil).QueryRelationRequests), arg0, arg1, arg2, arg3)
+}
+
+// BlockUser mocks base method
+func (_m *MockConn) BlockUser(block *UserBlock) error {
+	ret := _m.ctrl.Call(_m, "BlockUser", block)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUser indicates an expected call of BlockUser
+func (_mr *MockConnMockRecorder) BlockUser(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "BlockUser", reflect.TypeOf((*MockConn)(nil).BlockUser), arg0)
+}
+
+// UnblockUser mocks base method
+func (_m *MockConn) UnblockUser(blockerID string, blockedID string) error {
+	ret := _m.ctrl.Call(_m, "UnblockUser", blockerID, blockedID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnblockUser indicates an expected call of UnblockUser
+func (_mr *MockConnMockRecorder) UnblockUser(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UnblockUser", reflect.TypeOf((*MockConn)(nil).UnblockUser), arg0, arg1)
+}
+
+// QueryBlockedUsers mocks base method
+func (_m *MockConn) QueryBlockedUsers(blockerID string) ([]UserBlock, error) {
+	ret := _m.ctrl.Call(_m, "QueryBlockedUsers", blockerID)
+	ret0, _ := ret[0].([]UserBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryBlockedUsers indicates an expected call of QueryBlockedUsers
+func (_mr *MockConnMockRecorder) QueryBlockedUsers(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryBlockedUsers", reflect.TypeOf((*MockConn)(nil).QueryBlockedUsers), arg0)
+}
+
+// IsUserBlocked mocks base method
+func (_m *MockConn) IsUserBlocked(blockerID string, blockedID string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "IsUserBlocked", blockerID, blockedID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserBlocked indicates an expected call of IsUserBlocked
+func (_mr *MockConnMockRecorder) IsUserBlocked(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "IsUserBlocked", reflect.TypeOf((*MockConn)(nil).IsUserBlocked), arg0, arg1)
+}
+
+// PurgeAuth mocks base method
+func (_m *MockConn) PurgeAuth(id string) error {
+	ret := _m.ctrl.Call(_m, "PurgeAuth", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeAuth indicates an expected call of PurgeAuth
+func (_mr *MockConnMockRecorder) PurgeAuth(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "PurgeAuth", reflect.TypeOf((*MockConn)(nil).PurgeAuth), arg0)
+}
+
 // MockCustomTokenConn is a mock of CustomTokenConn interface
 type MockCustomTokenConn struct {
 	ctrl     *gomock.Controller
-	recorder *MockCustomTokenConnMockRecorder
+	recorder *MockCustomTokenConnMockRecorder
+}
+
+// MockCustomTokenConnMockRecorder is the mock recorder for MockCustomTokenConn
+type MockCustomTokenConnMockRecorder struct {
+	mock *MockCustomTokenConn
+}
+
+// NewMockCustomTokenConn creates a new mock instance
+func NewMockCustomTokenConn(ctrl *gomock.Controller) *MockCustomTokenConn {
+	mock := &MockCustomTokenConn{ctrl: ctrl}
+	mock.recorder = &MockCustomTokenConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockCustomTokenConn) EXPECT() *MockCustomTokenConnMockRecorder {
+	return _m.recorder
+}
+
+// GetCustomTokenInfo mocks base method
+func (_m *MockCustomTokenConn) GetCustomTokenInfo(principalID string, tokenInfo *CustomTokenInfo) error {
+	ret := _m.ctrl.Call(_m, "GetCustomTokenInfo", principalID, tokenInfo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetCustomTokenInfo indicates an expected call of GetCustomTokenInfo
+func (_mr *MockCustomTokenConnMockRecorder) GetCustomTokenInfo(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetCustomTokenInfo", reflect.TypeOf((*MockCustomTokenConn)(nil).GetCustomTokenInfo), arg0, arg1)
+}
+
+// CreateCustomTokenInfo mocks base method
+func (_m *MockCustomTokenConn) CreateCustomTokenInfo(tokenInfo *CustomTokenInfo) error {
+	ret := _m.ctrl.Call(_m, "CreateCustomTokenInfo", tokenInfo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCustomTokenInfo indicates an expected call of CreateCustomTokenInfo
+func (_mr *MockCustomTokenConnMockRecorder) CreateCustomTokenInfo(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateCustomTokenInfo", reflect.TypeOf((*MockCustomTokenConn)(nil).CreateCustomTokenInfo), arg0)
+}
+
+// DeleteCustomTokenInfo mocks base method
+func (_m *MockCustomTokenConn) DeleteCustomTokenInfo(principalID string) error {
+	ret := _m.ctrl.Call(_m, "DeleteCustomTokenInfo", principalID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCustomTokenInfo indicates an expected call of DeleteCustomTokenInfo
+func (_mr *MockCustomTokenConnMockRecorder) DeleteCustomTokenInfo(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteCustomTokenInfo", reflect.TypeOf((*MockCustomTokenConn)(nil).DeleteCustomTokenInfo), arg0)
+}
+
+// MockOAuthServerConn is a mock of OAuthServerConn interface
+type MockOAuthServerConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockOAuthServerConnMockRecorder
+}
+
+// MockOAuthServerConnMockRecorder is the mock recorder for MockOAuthServerConn
+type MockOAuthServerConnMockRecorder struct {
+	mock *MockOAuthServerConn
+}
+
+// NewMockOAuthServerConn creates a new mock instance
+func NewMockOAuthServerConn(ctrl *gomock.Controller) *MockOAuthServerConn {
+	mock := &MockOAuthServerConn{ctrl: ctrl}
+	mock.recorder = &MockOAuthServerConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockOAuthServerConn) EXPECT() *MockOAuthServerConnMockRecorder {
+	return _m.recorder
+}
+
+// CreateOAuthClient mocks base method
+func (_m *MockOAuthServerConn) CreateOAuthClient(client *OAuthClient) error {
+	ret := _m.ctrl.Call(_m, "CreateOAuthClient", client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOAuthClient indicates an expected call of CreateOAuthClient
+func (_mr *MockOAuthServerConnMockRecorder) CreateOAuthClient(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateOAuthClient", reflect.TypeOf((*MockOAuthServerConn)(nil).CreateOAuthClient), arg0)
+}
+
+// GetOAuthClient mocks base method
+func (_m *MockOAuthServerConn) GetOAuthClient(clientID string, client *OAuthClient) error {
+	ret := _m.ctrl.Call(_m, "GetOAuthClient", clientID, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetOAuthClient indicates an expected call of GetOAuthClient
+func (_mr *MockOAuthServerConnMockRecorder) GetOAuthClient(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetOAuthClient", reflect.TypeOf((*MockOAuthServerConn)(nil).GetOAuthClient), arg0, arg1)
+}
+
+// DeleteOAuthClient mocks base method
+func (_m *MockOAuthServerConn) DeleteOAuthClient(clientID string) error {
+	ret := _m.ctrl.Call(_m, "DeleteOAuthClient", clientID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOAuthClient indicates an expected call of DeleteOAuthClient
+func (_mr *MockOAuthServerConnMockRecorder) DeleteOAuthClient(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteOAuthClient", reflect.TypeOf((*MockOAuthServerConn)(nil).DeleteOAuthClient), arg0)
+}
+
+// CreateOAuthAuthorizationCode mocks base method
+func (_m *MockOAuthServerConn) CreateOAuthAuthorizationCode(code *OAuthAuthorizationCode) error {
+	ret := _m.ctrl.Call(_m, "CreateOAuthAuthorizationCode", code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOAuthAuthorizationCode indicates an expected call of CreateOAuthAuthorizationCode
+func (_mr *MockOAuthServerConnMockRecorder) CreateOAuthAuthorizationCode(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateOAuthAuthorizationCode", reflect.TypeOf((*MockOAuthServerConn)(nil).CreateOAuthAuthorizationCode), arg0)
+}
+
+// ConsumeOAuthAuthorizationCode mocks base method
+func (_m *MockOAuthServerConn) ConsumeOAuthAuthorizationCode(code string, authCode *OAuthAuthorizationCode) error {
+	ret := _m.ctrl.Call(_m, "ConsumeOAuthAuthorizationCode", code, authCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeOAuthAuthorizationCode indicates an expected call of ConsumeOAuthAuthorizationCode
+func (_mr *MockOAuthServerConnMockRecorder) ConsumeOAuthAuthorizationCode(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ConsumeOAuthAuthorizationCode", reflect.TypeOf((*MockOAuthServerConn)(nil).ConsumeOAuthAuthorizationCode), arg0, arg1)
+}
+
+// MockAPIKeyConn is a mock of APIKeyConn interface
+type MockAPIKeyConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyConnMockRecorder
+}
+
+// MockAPIKeyConnMockRecorder is the mock recorder for MockAPIKeyConn
+type MockAPIKeyConnMockRecorder struct {
+	mock *MockAPIKeyConn
+}
+
+// NewMockAPIKeyConn creates a new mock instance
+func NewMockAPIKeyConn(ctrl *gomock.Controller) *MockAPIKeyConn {
+	mock := &MockAPIKeyConn{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockAPIKeyConn) EXPECT() *MockAPIKeyConnMockRecorder {
+	return _m.recorder
+}
+
+// CreateAPIKey mocks base method
+func (_m *MockAPIKeyConn) CreateAPIKey(key *APIKey) error {
+	ret := _m.ctrl.Call(_m, "CreateAPIKey", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey
+func (_mr *MockAPIKeyConnMockRecorder) CreateAPIKey(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateAPIKey", reflect.TypeOf((*MockAPIKeyConn)(nil).CreateAPIKey), arg0)
+}
+
+// GetAPIKey mocks base method
+func (_m *MockAPIKeyConn) GetAPIKey(id string, key *APIKey) error {
+	ret := _m.ctrl.Call(_m, "GetAPIKey", id, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey
+func (_mr *MockAPIKeyConnMockRecorder) GetAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetAPIKey", reflect.TypeOf((*MockAPIKeyConn)(nil).GetAPIKey), arg0, arg1)
+}
+
+// GetAPIKeyByHash mocks base method
+func (_m *MockAPIKeyConn) GetAPIKeyByHash(hashedKey string, key *APIKey) error {
+	ret := _m.ctrl.Call(_m, "GetAPIKeyByHash", hashedKey, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash
+func (_mr *MockAPIKeyConnMockRecorder) GetAPIKeyByHash(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockAPIKeyConn)(nil).GetAPIKeyByHash), arg0, arg1)
+}
+
+// UpdateAPIKey mocks base method
+func (_m *MockAPIKeyConn) UpdateAPIKey(key *APIKey) error {
+	ret := _m.ctrl.Call(_m, "UpdateAPIKey", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAPIKey indicates an expected call of UpdateAPIKey
+func (_mr *MockAPIKeyConnMockRecorder) UpdateAPIKey(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateAPIKey", reflect.TypeOf((*MockAPIKeyConn)(nil).UpdateAPIKey), arg0)
+}
+
+// QueryAPIKeys mocks base method
+func (_m *MockAPIKeyConn) QueryAPIKeys() ([]APIKey, error) {
+	ret := _m.ctrl.Call(_m, "QueryAPIKeys")
+	ret0, _ := ret[0].([]APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryAPIKeys indicates an expected call of QueryAPIKeys
+func (_mr *MockAPIKeyConnMockRecorder) QueryAPIKeys() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryAPIKeys", reflect.TypeOf((*MockAPIKeyConn)(nil).QueryAPIKeys))
+}
+
+// MockGroupConn is a mock of GroupConn interface
+type MockGroupConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockGroupConnMockRecorder
+}
+
+// MockGroupConnMockRecorder is the mock recorder for MockGroupConn
+type MockGroupConnMockRecorder struct {
+	mock *MockGroupConn
+}
+
+// NewMockGroupConn creates a new mock instance
+func NewMockGroupConn(ctrl *gomock.Controller) *MockGroupConn {
+	mock := &MockGroupConn{ctrl: ctrl}
+	mock.recorder = &MockGroupConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockGroupConn) EXPECT() *MockGroupConnMockRecorder {
+	return _m.recorder
+}
+
+// CreateGroup mocks base method
+func (_m *MockGroupConn) CreateGroup(group *Group) error {
+	ret := _m.ctrl.Call(_m, "CreateGroup", group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateGroup indicates an expected call of CreateGroup
+func (_mr *MockGroupConnMockRecorder) CreateGroup(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateGroup", reflect.TypeOf((*MockGroupConn)(nil).CreateGroup), arg0)
+}
+
+// GetGroup mocks base method
+func (_m *MockGroupConn) GetGroup(id string, group *Group) error {
+	ret := _m.ctrl.Call(_m, "GetGroup", id, group)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetGroup indicates an expected call of GetGroup
+func (_mr *MockGroupConnMockRecorder) GetGroup(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetGroup", reflect.TypeOf((*MockGroupConn)(nil).GetGroup), arg0, arg1)
+}
+
+// DeleteGroup mocks base method
+func (_m *MockGroupConn) DeleteGroup(id string) error {
+	ret := _m.ctrl.Call(_m, "DeleteGroup", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup
+func (_mr *MockGroupConnMockRecorder) DeleteGroup(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteGroup", reflect.TypeOf((*MockGroupConn)(nil).DeleteGroup), arg0)
+}
+
+// QueryGroupsByMember mocks base method
+func (_m *MockGroupConn) QueryGroupsByMember(userID string) ([]Group, error) {
+	ret := _m.ctrl.Call(_m, "QueryGroupsByMember", userID)
+	ret0, _ := ret[0].([]Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryGroupsByMember indicates an expected call of QueryGroupsByMember
+func (_mr *MockGroupConnMockRecorder) QueryGroupsByMember(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryGroupsByMember", reflect.TypeOf((*MockGroupConn)(nil).QueryGroupsByMember), arg0)
+}
+
+// SetGroupMember mocks base method
+func (_m *MockGroupConn) SetGroupMember(membership *GroupMembership) error {
+	ret := _m.ctrl.Call(_m, "SetGroupMember", membership)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGroupMember indicates an expected call of SetGroupMember
+func (_mr *MockGroupConnMockRecorder) SetGroupMember(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetGroupMember", reflect.TypeOf((*MockGroupConn)(nil).SetGroupMember), arg0)
+}
+
+// GetGroupMember mocks base method
+func (_m *MockGroupConn) GetGroupMember(groupID string, userID string, membership *GroupMembership) error {
+	ret := _m.ctrl.Call(_m, "GetGroupMember", groupID, userID, membership)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetGroupMember indicates an expected call of GetGroupMember
+func (_mr *MockGroupConnMockRecorder) GetGroupMember(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetGroupMember", reflect.TypeOf((*MockGroupConn)(nil).GetGroupMember), arg0, arg1, arg2)
+}
+
+// RemoveGroupMember mocks base method
+func (_m *MockGroupConn) RemoveGroupMember(groupID string, userID string) error {
+	ret := _m.ctrl.Call(_m, "RemoveGroupMember", groupID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGroupMember indicates an expected call of RemoveGroupMember
+func (_mr *MockGroupConnMockRecorder) RemoveGroupMember(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RemoveGroupMember", reflect.TypeOf((*MockGroupConn)(nil).RemoveGroupMember), arg0, arg1)
+}
+
+// QueryGroupMembers mocks base method
+func (_m *MockGroupConn) QueryGroupMembers(groupID string) ([]GroupMembership, error) {
+	ret := _m.ctrl.Call(_m, "QueryGroupMembers", groupID)
+	ret0, _ := ret[0].([]GroupMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryGroupMembers indicates an expected call of QueryGroupMembers
+func (_mr *MockGroupConnMockRecorder) QueryGroupMembers(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryGroupMembers", reflect.TypeOf((*MockGroupConn)(nil).QueryGroupMembers), arg0)
+}
+
+// MockWebhookConn is a mock of WebhookConn interface
+type MockWebhookConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookConnMockRecorder
 }
 
-// MockCustomTokenConnMockRecorder is the mock recorder for MockCustomTokenConn
-type MockCustomTokenConnMockRecorder struct {
-	mock *MockCustomTokenConn
+// MockWebhookConnMockRecorder is the mock recorder for MockWebhookConn
+type MockWebhookConnMockRecorder struct {
+	mock *MockWebhookConn
 }
 
-// NewMockCustomTokenConn creates a new mock instance
-func NewMockCustomTokenConn(ctrl *gomock.Controller) *MockCustomTokenConn {
-	mock := &MockCustomTokenConn{ctrl: ctrl}
-	mock.recorder = &MockCustomTokenConnMockRecorder{mock}
+// NewMockWebhookConn creates a new mock instance
+func NewMockWebhookConn(ctrl *gomock.Controller) *MockWebhookConn {
+	mock := &MockWebhookConn{ctrl: ctrl}
+	mock.recorder = &MockWebhookConnMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use
-func (_m *MockCustomTokenConn) EXPECT() *MockCustomTokenConnMockRecorder {
+func (_m *MockWebhookConn) EXPECT() *MockWebhookConnMockRecorder {
 	return _m.recorder
 }
 
-// GetCustomTokenInfo mocks base method
-func (_m *MockCustomTokenConn) GetCustomTokenInfo(principalID string, tokenInfo *CustomTokenInfo) error {
-	ret := _m.ctrl.Call(_m, "GetCustomTokenInfo", principalID, tokenInfo)
+// CreateWebhook mocks base method
+func (_m *MockWebhookConn) CreateWebhook(webhook *Webhook) error {
+	ret := _m.ctrl.Call(_m, "CreateWebhook", webhook)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// GetCustomTokenInfo indicates an expected call of GetCustomTokenInfo
-func (_mr *MockCustomTokenConnMockRecorder) GetCustomTokenInfo(arg0, arg1 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetCustomTokenInfo", reflect.TypeOf((*MockCustomTokenConn)(nil).GetCustomTokenInfo), arg0, arg1)
+// CreateWebhook indicates an expected call of CreateWebhook
+func (_mr *MockWebhookConnMockRecorder) CreateWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateWebhook", reflect.TypeOf((*MockWebhookConn)(nil).CreateWebhook), arg0)
 }
 
-// CreateCustomTokenInfo mocks base method
-func (_m *MockCustomTokenConn) CreateCustomTokenInfo(tokenInfo *CustomTokenInfo) error {
-	ret := _m.ctrl.Call(_m, "CreateCustomTokenInfo", tokenInfo)
+// GetWebhook mocks base method
+func (_m *MockWebhookConn) GetWebhook(id string, webhook *Webhook) error {
+	ret := _m.ctrl.Call(_m, "GetWebhook", id, webhook)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// CreateCustomTokenInfo indicates an expected call of CreateCustomTokenInfo
-func (_mr *MockCustomTokenConnMockRecorder) CreateCustomTokenInfo(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateCustomTokenInfo", reflect.TypeOf((*MockCustomTokenConn)(nil).CreateCustomTokenInfo), arg0)
+// GetWebhook indicates an expected call of GetWebhook
+func (_mr *MockWebhookConnMockRecorder) GetWebhook(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetWebhook", reflect.TypeOf((*MockWebhookConn)(nil).GetWebhook), arg0, arg1)
 }
 
-// DeleteCustomTokenInfo mocks base method
-func (_m *MockCustomTokenConn) DeleteCustomTokenInfo(principalID string) error {
-	ret := _m.ctrl.Call(_m, "DeleteCustomTokenInfo", principalID)
+// UpdateWebhook mocks base method
+func (_m *MockWebhookConn) UpdateWebhook(webhook *Webhook) error {
+	ret := _m.ctrl.Call(_m, "UpdateWebhook", webhook)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteCustomTokenInfo indicates an expected call of DeleteCustomTokenInfo
-func (_mr *MockCustomTokenConnMockRecorder) DeleteCustomTokenInfo(arg0 interface{}) *gomock.Call {
-	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteCustomTokenInfo", reflect.TypeOf((*MockCustomTokenConn)(nil).DeleteCustomTokenInfo), arg0)
+// UpdateWebhook indicates an expected call of UpdateWebhook
+func (_mr *MockWebhookConnMockRecorder) UpdateWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateWebhook", reflect.TypeOf((*MockWebhookConn)(nil).UpdateWebhook), arg0)
+}
+
+// DeleteWebhook mocks base method
+func (_m *MockWebhookConn) DeleteWebhook(id string) error {
+	ret := _m.ctrl.Call(_m, "DeleteWebhook", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook
+func (_mr *MockWebhookConnMockRecorder) DeleteWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteWebhook", reflect.TypeOf((*MockWebhookConn)(nil).DeleteWebhook), arg0)
+}
+
+// QueryWebhooks mocks base method
+func (_m *MockWebhookConn) QueryWebhooks() ([]Webhook, error) {
+	ret := _m.ctrl.Call(_m, "QueryWebhooks")
+	ret0, _ := ret[0].([]Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWebhooks indicates an expected call of QueryWebhooks
+func (_mr *MockWebhookConnMockRecorder) QueryWebhooks() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryWebhooks", reflect.TypeOf((*MockWebhookConn)(nil).QueryWebhooks))
+}
+
+// CreateWebhookDelivery mocks base method
+func (_m *MockWebhookConn) CreateWebhookDelivery(delivery *WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "CreateWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery
+func (_mr *MockWebhookConnMockRecorder) CreateWebhookDelivery(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockWebhookConn)(nil).CreateWebhookDelivery), arg0)
+}
+
+// GetWebhookDelivery mocks base method
+func (_m *MockWebhookConn) GetWebhookDelivery(id string, delivery *WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "GetWebhookDelivery", id, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery
+func (_mr *MockWebhookConnMockRecorder) GetWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockWebhookConn)(nil).GetWebhookDelivery), arg0, arg1)
+}
+
+// UpdateWebhookDelivery mocks base method
+func (_m *MockWebhookConn) UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "UpdateWebhookDelivery", delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery
+func (_mr *MockWebhookConnMockRecorder) UpdateWebhookDelivery(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockWebhookConn)(nil).UpdateWebhookDelivery), arg0)
+}
+
+// QueryWebhookDeliveries mocks base method
+func (_m *MockWebhookConn) QueryWebhookDeliveries(webhookID string, status WebhookDeliveryStatus, limit uint64) ([]WebhookDelivery, error) {
+	ret := _m.ctrl.Call(_m, "QueryWebhookDeliveries", webhookID, status, limit)
+	ret0, _ := ret[0].([]WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWebhookDeliveries indicates an expected call of QueryWebhookDeliveries
+func (_mr *MockWebhookConnMockRecorder) QueryWebhookDeliveries(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryWebhookDeliveries", reflect.TypeOf((*MockWebhookConn)(nil).QueryWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// ClaimWebhookDelivery mocks base method
+func (_m *MockWebhookConn) ClaimWebhookDelivery(now time.Time, leaseUntil time.Time, delivery *WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "ClaimWebhookDelivery", now, leaseUntil, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimWebhookDelivery indicates an expected call of ClaimWebhookDelivery
+func (_mr *MockWebhookConnMockRecorder) ClaimWebhookDelivery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimWebhookDelivery", reflect.TypeOf((*MockWebhookConn)(nil).ClaimWebhookDelivery), arg0, arg1, arg2)
+}
+
+// UpdateClaimedWebhookDelivery mocks base method
+func (_m *MockWebhookConn) UpdateClaimedWebhookDelivery(delivery *WebhookDelivery, leaseUntil time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedWebhookDelivery", delivery, leaseUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedWebhookDelivery indicates an expected call of UpdateClaimedWebhookDelivery
+func (_mr *MockWebhookConnMockRecorder) UpdateClaimedWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedWebhookDelivery", reflect.TypeOf((*MockWebhookConn)(nil).UpdateClaimedWebhookDelivery), arg0, arg1)
+}
+
+// MockJobConn is a mock of JobConn interface
+type MockJobConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobConnMockRecorder
+}
+
+// MockJobConnMockRecorder is the mock recorder for MockJobConn
+type MockJobConnMockRecorder struct {
+	mock *MockJobConn
+}
+
+// NewMockJobConn creates a new mock instance
+func NewMockJobConn(ctrl *gomock.Controller) *MockJobConn {
+	mock := &MockJobConn{ctrl: ctrl}
+	mock.recorder = &MockJobConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockJobConn) EXPECT() *MockJobConnMockRecorder {
+	return _m.recorder
+}
+
+// CreateJob mocks base method
+func (_m *MockJobConn) CreateJob(job *Job) error {
+	ret := _m.ctrl.Call(_m, "CreateJob", job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJob indicates an expected call of CreateJob
+func (_mr *MockJobConnMockRecorder) CreateJob(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateJob", reflect.TypeOf((*MockJobConn)(nil).CreateJob), arg0)
+}
+
+// GetJob mocks base method
+func (_m *MockJobConn) GetJob(id string, job *Job) error {
+	ret := _m.ctrl.Call(_m, "GetJob", id, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetJob indicates an expected call of GetJob
+func (_mr *MockJobConnMockRecorder) GetJob(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetJob", reflect.TypeOf((*MockJobConn)(nil).GetJob), arg0, arg1)
+}
+
+// UpdateJob mocks base method
+func (_m *MockJobConn) UpdateJob(job *Job) error {
+	ret := _m.ctrl.Call(_m, "UpdateJob", job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateJob indicates an expected call of UpdateJob
+func (_mr *MockJobConnMockRecorder) UpdateJob(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateJob", reflect.TypeOf((*MockJobConn)(nil).UpdateJob), arg0)
+}
+
+// QueryJobs mocks base method
+func (_m *MockJobConn) QueryJobs(status JobStatus, limit uint64) ([]Job, error) {
+	ret := _m.ctrl.Call(_m, "QueryJobs", status, limit)
+	ret0, _ := ret[0].([]Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryJobs indicates an expected call of QueryJobs
+func (_mr *MockJobConnMockRecorder) QueryJobs(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryJobs", reflect.TypeOf((*MockJobConn)(nil).QueryJobs), arg0, arg1)
+}
+
+// ClaimJob mocks base method
+func (_m *MockJobConn) ClaimJob(now time.Time, lockedUntil time.Time, job *Job) error {
+	ret := _m.ctrl.Call(_m, "ClaimJob", now, lockedUntil, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimJob indicates an expected call of ClaimJob
+func (_mr *MockJobConnMockRecorder) ClaimJob(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimJob", reflect.TypeOf((*MockJobConn)(nil).ClaimJob), arg0, arg1, arg2)
+}
+
+// UpdateClaimedJob mocks base method
+func (_m *MockJobConn) UpdateClaimedJob(job *Job, lockedUntil time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedJob", job, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedJob indicates an expected call of UpdateClaimedJob
+func (_mr *MockJobConnMockRecorder) UpdateClaimedJob(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedJob", reflect.TypeOf((*MockJobConn)(nil).UpdateClaimedJob), arg0, arg1)
+}
+
+// MockTimerConn is a mock of TimerConn interface
+type MockTimerConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockTimerConnMockRecorder
+}
+
+// MockTimerConnMockRecorder is the mock recorder for MockTimerConn
+type MockTimerConnMockRecorder struct {
+	mock *MockTimerConn
+}
+
+// NewMockTimerConn creates a new mock instance
+func NewMockTimerConn(ctrl *gomock.Controller) *MockTimerConn {
+	mock := &MockTimerConn{ctrl: ctrl}
+	mock.recorder = &MockTimerConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockTimerConn) EXPECT() *MockTimerConnMockRecorder {
+	return _m.recorder
+}
+
+// RegisterTimer mocks base method
+func (_m *MockTimerConn) RegisterTimer(timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "RegisterTimer", timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterTimer indicates an expected call of RegisterTimer
+func (_mr *MockTimerConnMockRecorder) RegisterTimer(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RegisterTimer", reflect.TypeOf((*MockTimerConn)(nil).RegisterTimer), arg0)
+}
+
+// GetTimer mocks base method
+func (_m *MockTimerConn) GetTimer(name string, timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "GetTimer", name, timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetTimer indicates an expected call of GetTimer
+func (_mr *MockTimerConnMockRecorder) GetTimer(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetTimer", reflect.TypeOf((*MockTimerConn)(nil).GetTimer), arg0, arg1)
+}
+
+// UpdateTimer mocks base method
+func (_m *MockTimerConn) UpdateTimer(timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "UpdateTimer", timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTimer indicates an expected call of UpdateTimer
+func (_mr *MockTimerConnMockRecorder) UpdateTimer(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateTimer", reflect.TypeOf((*MockTimerConn)(nil).UpdateTimer), arg0)
+}
+
+// QueryTimers mocks base method
+func (_m *MockTimerConn) QueryTimers() ([]Timer, error) {
+	ret := _m.ctrl.Call(_m, "QueryTimers")
+	ret0, _ := ret[0].([]Timer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryTimers indicates an expected call of QueryTimers
+func (_mr *MockTimerConnMockRecorder) QueryTimers() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryTimers", reflect.TypeOf((*MockTimerConn)(nil).QueryTimers))
+}
+
+// ClaimTimer mocks base method
+func (_m *MockTimerConn) ClaimTimer(names []string, now time.Time, lockedUntil time.Time, timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "ClaimTimer", names, now, lockedUntil, timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimTimer indicates an expected call of ClaimTimer
+func (_mr *MockTimerConnMockRecorder) ClaimTimer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimTimer", reflect.TypeOf((*MockTimerConn)(nil).ClaimTimer), arg0, arg1, arg2, arg3)
+}
+
+// UpdateClaimedTimer mocks base method
+func (_m *MockTimerConn) UpdateClaimedTimer(timer *Timer, lockedUntil time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedTimer", timer, lockedUntil)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedTimer indicates an expected call of UpdateClaimedTimer
+func (_mr *MockTimerConnMockRecorder) UpdateClaimedTimer(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedTimer", reflect.TypeOf((*MockTimerConn)(nil).UpdateClaimedTimer), arg0, arg1)
+}
+
+// LockTimer mocks base method
+func (_m *MockTimerConn) LockTimer(name string, now time.Time, lockedUntil time.Time, timer *Timer) error {
+	ret := _m.ctrl.Call(_m, "LockTimer", name, now, lockedUntil, timer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockTimer indicates an expected call of LockTimer
+func (_mr *MockTimerConnMockRecorder) LockTimer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "LockTimer", reflect.TypeOf((*MockTimerConn)(nil).LockTimer), arg0, arg1, arg2, arg3)
+}
+
+// MockUserDataExportConn is a mock of UserDataExportConn interface
+type MockUserDataExportConn struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserDataExportConnMockRecorder
+}
+
+// MockUserDataExportConnMockRecorder is the mock recorder for MockUserDataExportConn
+type MockUserDataExportConnMockRecorder struct {
+	mock *MockUserDataExportConn
+}
+
+// NewMockUserDataExportConn creates a new mock instance
+func NewMockUserDataExportConn(ctrl *gomock.Controller) *MockUserDataExportConn {
+	mock := &MockUserDataExportConn{ctrl: ctrl}
+	mock.recorder = &MockUserDataExportConnMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (_m *MockUserDataExportConn) EXPECT() *MockUserDataExportConnMockRecorder {
+	return _m.recorder
+}
+
+// CreateUserDataExport mocks base method
+func (_m *MockUserDataExportConn) CreateUserDataExport(export *UserDataExport) error {
+	ret := _m.ctrl.Call(_m, "CreateUserDataExport", export)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUserDataExport indicates an expected call of CreateUserDataExport
+func (_mr *MockUserDataExportConnMockRecorder) CreateUserDataExport(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateUserDataExport", reflect.TypeOf((*MockUserDataExportConn)(nil).CreateUserDataExport), arg0)
+}
+
+// QueryUserDataExports mocks base method
+func (_m *MockUserDataExportConn) QueryUserDataExports(userID string) ([]UserDataExport, error) {
+	ret := _m.ctrl.Call(_m, "QueryUserDataExports", userID)
+	ret0, _ := ret[0].([]UserDataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryUserDataExports indicates an expected call of QueryUserDataExports
+func (_mr *MockUserDataExportConnMockRecorder) QueryUserDataExports(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryUserDataExports", reflect.TypeOf((*MockUserDataExportConn)(nil).QueryUserDataExports), arg0)
+}
+
+// QueryExpiredUserDataExports mocks base method
+func (_m *MockUserDataExportConn) QueryExpiredUserDataExports(now time.Time, limit uint64) ([]UserDataExport, error) {
+	ret := _m.ctrl.Call(_m, "QueryExpiredUserDataExports", now, limit)
+	ret0, _ := ret[0].([]UserDataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryExpiredUserDataExports indicates an expected call of QueryExpiredUserDataExports
+func (_mr *MockUserDataExportConnMockRecorder) QueryExpiredUserDataExports(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryExpiredUserDataExports", reflect.TypeOf((*MockUserDataExportConn)(nil).QueryExpiredUserDataExports), arg0, arg1)
+}
+
+// DeleteUserDataExport mocks base method
+func (_m *MockUserDataExportConn) DeleteUserDataExport(assetName string) error {
+	ret := _m.ctrl.Call(_m, "DeleteUserDataExport", assetName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserDataExport indicates an expected call of DeleteUserDataExport
+func (_mr *MockUserDataExportConnMockRecorder) DeleteUserDataExport(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteUserDataExport", reflect.TypeOf((*MockUserDataExportConn)(nil).DeleteUserDataExport), arg0)
 }
@@ -191,6 +191,19 @@ func (_mr *MockDatabaseMockRecorder) QueryCount(arg0, arg1 interface{}) *gomock.
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryCount", reflect.TypeOf((*MockDatabase)(nil).QueryCount), arg0, arg1)
 }
 
+// UpdateRecordACL mocks base method
+func (_m *MockDatabase) UpdateRecordACL(query *Query, patch RecordACLPatch, batchSize uint64, fn RecordACLUpdateFunc) (RecordACLUpdateProgress, error) {
+	ret := _m.ctrl.Call(_m, "UpdateRecordACL", query, patch, batchSize, fn)
+	ret0, _ := ret[0].(RecordACLUpdateProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRecordACL indicates an expected call of UpdateRecordACL
+func (_mr *MockDatabaseMockRecorder) UpdateRecordACL(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateRecordACL", reflect.TypeOf((*MockDatabase)(nil).UpdateRecordACL), arg0, arg1, arg2, arg3)
+}
+
 // Extend mocks base method
 func (_m *MockDatabase) Extend(recordType string, schema RecordSchema) (bool, error) {
 	ret := _m.ctrl.Call(_m, "Extend", recordType, schema)
@@ -629,6 +642,19 @@ func (_mr *MockTxDatabaseMockRecorder) QueryCount(arg0, arg1 interface{}) *gomoc
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryCount", reflect.TypeOf((*MockTxDatabase)(nil).QueryCount), arg0, arg1)
 }
 
+// UpdateRecordACL mocks base method
+func (_m *MockTxDatabase) UpdateRecordACL(query *Query, patch RecordACLPatch, batchSize uint64, fn RecordACLUpdateFunc) (RecordACLUpdateProgress, error) {
+	ret := _m.ctrl.Call(_m, "UpdateRecordACL", query, patch, batchSize, fn)
+	ret0, _ := ret[0].(RecordACLUpdateProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRecordACL indicates an expected call of UpdateRecordACL
+func (_mr *MockTxDatabaseMockRecorder) UpdateRecordACL(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateRecordACL", reflect.TypeOf((*MockTxDatabase)(nil).UpdateRecordACL), arg0, arg1, arg2, arg3)
+}
+
 // Extend mocks base method
 func (_m *MockTxDatabase) Extend(recordType string, schema RecordSchema) (bool, error) {
 	ret := _m.ctrl.Call(_m, "Extend", recordType, schema)
@@ -57,6 +57,54 @@ func (_mr *MockConnMockRecorder) AssignRoles(arg0, arg1 interface{}) *gomock.Cal
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "AssignRoles", reflect.TypeOf((*MockConn)(nil).AssignRoles), arg0, arg1)
 }
 
+// BlockUser mocks base method
+func (_m *MockConn) BlockUser(_param0 *skydb.UserBlock) error {
+	ret := _m.ctrl.Call(_m, "BlockUser", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUser indicates an expected call of BlockUser
+func (_mr *MockConnMockRecorder) BlockUser(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "BlockUser", reflect.TypeOf((*MockConn)(nil).BlockUser), arg0)
+}
+
+// ClaimJob mocks base method
+func (_m *MockConn) ClaimJob(_param0 time.Time, _param1 time.Time, _param2 *skydb.Job) error {
+	ret := _m.ctrl.Call(_m, "ClaimJob", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimJob indicates an expected call of ClaimJob
+func (_mr *MockConnMockRecorder) ClaimJob(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimJob", reflect.TypeOf((*MockConn)(nil).ClaimJob), arg0, arg1, arg2)
+}
+
+// ClaimTimer mocks base method
+func (_m *MockConn) ClaimTimer(_param0 []string, _param1 time.Time, _param2 time.Time, _param3 *skydb.Timer) error {
+	ret := _m.ctrl.Call(_m, "ClaimTimer", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimTimer indicates an expected call of ClaimTimer
+func (_mr *MockConnMockRecorder) ClaimTimer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimTimer", reflect.TypeOf((*MockConn)(nil).ClaimTimer), arg0, arg1, arg2, arg3)
+}
+
+// ClaimWebhookDelivery mocks base method
+func (_m *MockConn) ClaimWebhookDelivery(_param0 time.Time, _param1 time.Time, _param2 *skydb.WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "ClaimWebhookDelivery", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClaimWebhookDelivery indicates an expected call of ClaimWebhookDelivery
+func (_mr *MockConnMockRecorder) ClaimWebhookDelivery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ClaimWebhookDelivery", reflect.TypeOf((*MockConn)(nil).ClaimWebhookDelivery), arg0, arg1, arg2)
+}
+
 // Close mocks base method
 func (_m *MockConn) Close() error {
 	ret := _m.ctrl.Call(_m, "Close")
@@ -69,6 +117,30 @@ func (_mr *MockConnMockRecorder) Close() *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Close", reflect.TypeOf((*MockConn)(nil).Close))
 }
 
+// ConsumeOAuthAuthorizationCode mocks base method
+func (_m *MockConn) ConsumeOAuthAuthorizationCode(_param0 string, _param1 *skydb.OAuthAuthorizationCode) error {
+	ret := _m.ctrl.Call(_m, "ConsumeOAuthAuthorizationCode", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConsumeOAuthAuthorizationCode indicates an expected call of ConsumeOAuthAuthorizationCode
+func (_mr *MockConnMockRecorder) ConsumeOAuthAuthorizationCode(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "ConsumeOAuthAuthorizationCode", reflect.TypeOf((*MockConn)(nil).ConsumeOAuthAuthorizationCode), arg0, arg1)
+}
+
+// CreateAPIKey mocks base method
+func (_m *MockConn) CreateAPIKey(_param0 *skydb.APIKey) error {
+	ret := _m.ctrl.Call(_m, "CreateAPIKey", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey
+func (_mr *MockConnMockRecorder) CreateAPIKey(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateAPIKey", reflect.TypeOf((*MockConn)(nil).CreateAPIKey), arg0)
+}
+
 // CreateAuth mocks base method
 func (_m *MockConn) CreateAuth(_param0 *skydb.AuthInfo) error {
 	ret := _m.ctrl.Call(_m, "CreateAuth", _param0)
@@ -93,6 +165,54 @@ func (_mr *MockConnMockRecorder) CreateCustomTokenInfo(arg0 interface{}) *gomock
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateCustomTokenInfo", reflect.TypeOf((*MockConn)(nil).CreateCustomTokenInfo), arg0)
 }
 
+// CreateGroup mocks base method
+func (_m *MockConn) CreateGroup(_param0 *skydb.Group) error {
+	ret := _m.ctrl.Call(_m, "CreateGroup", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateGroup indicates an expected call of CreateGroup
+func (_mr *MockConnMockRecorder) CreateGroup(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateGroup", reflect.TypeOf((*MockConn)(nil).CreateGroup), arg0)
+}
+
+// CreateJob mocks base method
+func (_m *MockConn) CreateJob(_param0 *skydb.Job) error {
+	ret := _m.ctrl.Call(_m, "CreateJob", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJob indicates an expected call of CreateJob
+func (_mr *MockConnMockRecorder) CreateJob(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateJob", reflect.TypeOf((*MockConn)(nil).CreateJob), arg0)
+}
+
+// CreateOAuthAuthorizationCode mocks base method
+func (_m *MockConn) CreateOAuthAuthorizationCode(_param0 *skydb.OAuthAuthorizationCode) error {
+	ret := _m.ctrl.Call(_m, "CreateOAuthAuthorizationCode", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOAuthAuthorizationCode indicates an expected call of CreateOAuthAuthorizationCode
+func (_mr *MockConnMockRecorder) CreateOAuthAuthorizationCode(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateOAuthAuthorizationCode", reflect.TypeOf((*MockConn)(nil).CreateOAuthAuthorizationCode), arg0)
+}
+
+// CreateOAuthClient mocks base method
+func (_m *MockConn) CreateOAuthClient(_param0 *skydb.OAuthClient) error {
+	ret := _m.ctrl.Call(_m, "CreateOAuthClient", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOAuthClient indicates an expected call of CreateOAuthClient
+func (_mr *MockConnMockRecorder) CreateOAuthClient(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateOAuthClient", reflect.TypeOf((*MockConn)(nil).CreateOAuthClient), arg0)
+}
+
 // CreateOAuthInfo mocks base method
 func (_m *MockConn) CreateOAuthInfo(_param0 *skydb.OAuthInfo) error {
 	ret := _m.ctrl.Call(_m, "CreateOAuthInfo", _param0)
@@ -105,6 +225,66 @@ func (_mr *MockConnMockRecorder) CreateOAuthInfo(arg0 interface{}) *gomock.Call
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateOAuthInfo", reflect.TypeOf((*MockConn)(nil).CreateOAuthInfo), arg0)
 }
 
+// CreateRelationRequest mocks base method
+func (_m *MockConn) CreateRelationRequest(_param0 *skydb.RelationRequest) error {
+	ret := _m.ctrl.Call(_m, "CreateRelationRequest", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRelationRequest indicates an expected call of CreateRelationRequest
+func (_mr *MockConnMockRecorder) CreateRelationRequest(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateRelationRequest", reflect.TypeOf((*MockConn)(nil).CreateRelationRequest), arg0)
+}
+
+// CreateRelationType mocks base method
+func (_m *MockConn) CreateRelationType(_param0 *skydb.RelationType) error {
+	ret := _m.ctrl.Call(_m, "CreateRelationType", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateRelationType indicates an expected call of CreateRelationType
+func (_mr *MockConnMockRecorder) CreateRelationType(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateRelationType", reflect.TypeOf((*MockConn)(nil).CreateRelationType), arg0)
+}
+
+// CreateUserDataExport mocks base method
+func (_m *MockConn) CreateUserDataExport(_param0 *skydb.UserDataExport) error {
+	ret := _m.ctrl.Call(_m, "CreateUserDataExport", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUserDataExport indicates an expected call of CreateUserDataExport
+func (_mr *MockConnMockRecorder) CreateUserDataExport(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateUserDataExport", reflect.TypeOf((*MockConn)(nil).CreateUserDataExport), arg0)
+}
+
+// CreateWebhook mocks base method
+func (_m *MockConn) CreateWebhook(_param0 *skydb.Webhook) error {
+	ret := _m.ctrl.Call(_m, "CreateWebhook", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook
+func (_mr *MockConnMockRecorder) CreateWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateWebhook", reflect.TypeOf((*MockConn)(nil).CreateWebhook), arg0)
+}
+
+// CreateWebhookDelivery mocks base method
+func (_m *MockConn) CreateWebhookDelivery(_param0 *skydb.WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "CreateWebhookDelivery", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery
+func (_mr *MockConnMockRecorder) CreateWebhookDelivery(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockConn)(nil).CreateWebhookDelivery), arg0)
+}
+
 // DeleteAuth mocks base method
 func (_m *MockConn) DeleteAuth(_param0 string) error {
 	ret := _m.ctrl.Call(_m, "DeleteAuth", _param0)
@@ -165,6 +345,18 @@ func (_mr *MockConnMockRecorder) DeleteEmptyDevicesByTime(arg0 interface{}) *gom
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteEmptyDevicesByTime", reflect.TypeOf((*MockConn)(nil).DeleteEmptyDevicesByTime), arg0)
 }
 
+// DeleteGroup mocks base method
+func (_m *MockConn) DeleteGroup(_param0 string) error {
+	ret := _m.ctrl.Call(_m, "DeleteGroup", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroup indicates an expected call of DeleteGroup
+func (_mr *MockConnMockRecorder) DeleteGroup(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteGroup", reflect.TypeOf((*MockConn)(nil).DeleteGroup), arg0)
+}
+
 // DeleteOAuth mocks base method
 func (_m *MockConn) DeleteOAuth(_param0 string, _param1 string) error {
 	ret := _m.ctrl.Call(_m, "DeleteOAuth", _param0, _param1)
@@ -177,6 +369,54 @@ func (_mr *MockConnMockRecorder) DeleteOAuth(arg0, arg1 interface{}) *gomock.Cal
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteOAuth", reflect.TypeOf((*MockConn)(nil).DeleteOAuth), arg0, arg1)
 }
 
+// DeleteOAuthClient mocks base method
+func (_m *MockConn) DeleteOAuthClient(_param0 string) error {
+	ret := _m.ctrl.Call(_m, "DeleteOAuthClient", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOAuthClient indicates an expected call of DeleteOAuthClient
+func (_mr *MockConnMockRecorder) DeleteOAuthClient(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteOAuthClient", reflect.TypeOf((*MockConn)(nil).DeleteOAuthClient), arg0)
+}
+
+// DeleteRelationType mocks base method
+func (_m *MockConn) DeleteRelationType(_param0 string) error {
+	ret := _m.ctrl.Call(_m, "DeleteRelationType", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRelationType indicates an expected call of DeleteRelationType
+func (_mr *MockConnMockRecorder) DeleteRelationType(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteRelationType", reflect.TypeOf((*MockConn)(nil).DeleteRelationType), arg0)
+}
+
+// DeleteUserDataExport mocks base method
+func (_m *MockConn) DeleteUserDataExport(_param0 string) error {
+	ret := _m.ctrl.Call(_m, "DeleteUserDataExport", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserDataExport indicates an expected call of DeleteUserDataExport
+func (_mr *MockConnMockRecorder) DeleteUserDataExport(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteUserDataExport", reflect.TypeOf((*MockConn)(nil).DeleteUserDataExport), arg0)
+}
+
+// DeleteWebhook mocks base method
+func (_m *MockConn) DeleteWebhook(_param0 string) error {
+	ret := _m.ctrl.Call(_m, "DeleteWebhook", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook
+func (_mr *MockConnMockRecorder) DeleteWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "DeleteWebhook", reflect.TypeOf((*MockConn)(nil).DeleteWebhook), arg0)
+}
+
 // EnsureAuthRecordKeysExist mocks base method
 func (_m *MockConn) EnsureAuthRecordKeysExist(_param0 [][]string) error {
 	ret := _m.ctrl.Call(_m, "EnsureAuthRecordKeysExist", _param0)
@@ -201,6 +441,30 @@ func (_mr *MockConnMockRecorder) EnsureAuthRecordKeysIndexesMatch(arg0 interface
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "EnsureAuthRecordKeysIndexesMatch", reflect.TypeOf((*MockConn)(nil).EnsureAuthRecordKeysIndexesMatch), arg0)
 }
 
+// GetAPIKey mocks base method
+func (_m *MockConn) GetAPIKey(_param0 string, _param1 *skydb.APIKey) error {
+	ret := _m.ctrl.Call(_m, "GetAPIKey", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey
+func (_mr *MockConnMockRecorder) GetAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetAPIKey", reflect.TypeOf((*MockConn)(nil).GetAPIKey), arg0, arg1)
+}
+
+// GetAPIKeyByHash mocks base method
+func (_m *MockConn) GetAPIKeyByHash(_param0 string, _param1 *skydb.APIKey) error {
+	ret := _m.ctrl.Call(_m, "GetAPIKeyByHash", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash
+func (_mr *MockConnMockRecorder) GetAPIKeyByHash(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockConn)(nil).GetAPIKeyByHash), arg0, arg1)
+}
+
 // GetAdminRoles mocks base method
 func (_m *MockConn) GetAdminRoles() ([]string, error) {
 	ret := _m.ctrl.Call(_m, "GetAdminRoles")
@@ -300,6 +564,54 @@ func (_mr *MockConnMockRecorder) GetDevice(arg0, arg1 interface{}) *gomock.Call
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetDevice", reflect.TypeOf((*MockConn)(nil).GetDevice), arg0, arg1)
 }
 
+// GetGroup mocks base method
+func (_m *MockConn) GetGroup(_param0 string, _param1 *skydb.Group) error {
+	ret := _m.ctrl.Call(_m, "GetGroup", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetGroup indicates an expected call of GetGroup
+func (_mr *MockConnMockRecorder) GetGroup(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetGroup", reflect.TypeOf((*MockConn)(nil).GetGroup), arg0, arg1)
+}
+
+// GetGroupMember mocks base method
+func (_m *MockConn) GetGroupMember(_param0 string, _param1 string, _param2 *skydb.GroupMembership) error {
+	ret := _m.ctrl.Call(_m, "GetGroupMember", _param0, _param1, _param2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetGroupMember indicates an expected call of GetGroupMember
+func (_mr *MockConnMockRecorder) GetGroupMember(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetGroupMember", reflect.TypeOf((*MockConn)(nil).GetGroupMember), arg0, arg1, arg2)
+}
+
+// GetJob mocks base method
+func (_m *MockConn) GetJob(_param0 string, _param1 *skydb.Job) error {
+	ret := _m.ctrl.Call(_m, "GetJob", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetJob indicates an expected call of GetJob
+func (_mr *MockConnMockRecorder) GetJob(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetJob", reflect.TypeOf((*MockConn)(nil).GetJob), arg0, arg1)
+}
+
+// GetOAuthClient mocks base method
+func (_m *MockConn) GetOAuthClient(_param0 string, _param1 *skydb.OAuthClient) error {
+	ret := _m.ctrl.Call(_m, "GetOAuthClient", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetOAuthClient indicates an expected call of GetOAuthClient
+func (_mr *MockConnMockRecorder) GetOAuthClient(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetOAuthClient", reflect.TypeOf((*MockConn)(nil).GetOAuthClient), arg0, arg1)
+}
+
 // GetOAuthInfo mocks base method
 func (_m *MockConn) GetOAuthInfo(_param0 string, _param1 string, _param2 *skydb.OAuthInfo) error {
 	ret := _m.ctrl.Call(_m, "GetOAuthInfo", _param0, _param1, _param2)
@@ -376,6 +688,97 @@ func (_mr *MockConnMockRecorder) GetRecordFieldAccess() *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRecordFieldAccess", reflect.TypeOf((*MockConn)(nil).GetRecordFieldAccess))
 }
 
+// GetRecordPredicateAccess mocks base method
+func (_m *MockConn) GetRecordPredicateAccess(_param0 string) (skydb.RecordPredicateAccess, error) {
+	ret := _m.ctrl.Call(_m, "GetRecordPredicateAccess", _param0)
+	ret0, _ := ret[0].(skydb.RecordPredicateAccess)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecordPredicateAccess indicates an expected call of GetRecordPredicateAccess
+func (_mr *MockConnMockRecorder) GetRecordPredicateAccess(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRecordPredicateAccess", reflect.TypeOf((*MockConn)(nil).GetRecordPredicateAccess), arg0)
+}
+
+// GetRelation mocks base method
+func (_m *MockConn) GetRelation(_param0 string, _param1 string, _param2 string) (skydb.Relation, error) {
+	ret := _m.ctrl.Call(_m, "GetRelation", _param0, _param1, _param2)
+	ret0, _ := ret[0].(skydb.Relation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelation indicates an expected call of GetRelation
+func (_mr *MockConnMockRecorder) GetRelation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRelation", reflect.TypeOf((*MockConn)(nil).GetRelation), arg0, arg1, arg2)
+}
+
+// GetRelationRequest mocks base method
+func (_m *MockConn) GetRelationRequest(_param0 string, _param1 string, _param2 string) (skydb.RelationRequest, error) {
+	ret := _m.ctrl.Call(_m, "GetRelationRequest", _param0, _param1, _param2)
+	ret0, _ := ret[0].(skydb.RelationRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelationRequest indicates an expected call of GetRelationRequest
+func (_mr *MockConnMockRecorder) GetRelationRequest(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRelationRequest", reflect.TypeOf((*MockConn)(nil).GetRelationRequest), arg0, arg1, arg2)
+}
+
+// GetRelationType mocks base method
+func (_m *MockConn) GetRelationType(_param0 string) (skydb.RelationType, error) {
+	ret := _m.ctrl.Call(_m, "GetRelationType", _param0)
+	ret0, _ := ret[0].(skydb.RelationType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelationType indicates an expected call of GetRelationType
+func (_mr *MockConnMockRecorder) GetRelationType(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRelationType", reflect.TypeOf((*MockConn)(nil).GetRelationType), arg0)
+}
+
+// GetRelationTypes mocks base method
+func (_m *MockConn) GetRelationTypes() ([]skydb.RelationType, error) {
+	ret := _m.ctrl.Call(_m, "GetRelationTypes")
+	ret0, _ := ret[0].([]skydb.RelationType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRelationTypes indicates an expected call of GetRelationTypes
+func (_mr *MockConnMockRecorder) GetRelationTypes() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRelationTypes", reflect.TypeOf((*MockConn)(nil).GetRelationTypes))
+}
+
+// GetRoleHierarchy mocks base method
+func (_m *MockConn) GetRoleHierarchy() (skydb.RoleHierarchy, error) {
+	ret := _m.ctrl.Call(_m, "GetRoleHierarchy")
+	ret0, _ := ret[0].(skydb.RoleHierarchy)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleHierarchy indicates an expected call of GetRoleHierarchy
+func (_mr *MockConnMockRecorder) GetRoleHierarchy() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRoleHierarchy", reflect.TypeOf((*MockConn)(nil).GetRoleHierarchy))
+}
+
+// GetRolePermissions mocks base method
+func (_m *MockConn) GetRolePermissions() (skydb.RolePermissions, error) {
+	ret := _m.ctrl.Call(_m, "GetRolePermissions")
+	ret0, _ := ret[0].(skydb.RolePermissions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolePermissions indicates an expected call of GetRolePermissions
+func (_mr *MockConnMockRecorder) GetRolePermissions() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRolePermissions", reflect.TypeOf((*MockConn)(nil).GetRolePermissions))
+}
+
 // GetRoles mocks base method
 func (_m *MockConn) GetRoles(_param0 []string) (map[string][]string, error) {
 	ret := _m.ctrl.Call(_m, "GetRoles", _param0)
@@ -389,6 +792,67 @@ func (_mr *MockConnMockRecorder) GetRoles(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetRoles", reflect.TypeOf((*MockConn)(nil).GetRoles), arg0)
 }
 
+// GetTimer mocks base method
+func (_m *MockConn) GetTimer(_param0 string, _param1 *skydb.Timer) error {
+	ret := _m.ctrl.Call(_m, "GetTimer", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetTimer indicates an expected call of GetTimer
+func (_mr *MockConnMockRecorder) GetTimer(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetTimer", reflect.TypeOf((*MockConn)(nil).GetTimer), arg0, arg1)
+}
+
+// GetWebhook mocks base method
+func (_m *MockConn) GetWebhook(_param0 string, _param1 *skydb.Webhook) error {
+	ret := _m.ctrl.Call(_m, "GetWebhook", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWebhook indicates an expected call of GetWebhook
+func (_mr *MockConnMockRecorder) GetWebhook(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetWebhook", reflect.TypeOf((*MockConn)(nil).GetWebhook), arg0, arg1)
+}
+
+// GetWebhookDelivery mocks base method
+func (_m *MockConn) GetWebhookDelivery(_param0 string, _param1 *skydb.WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "GetWebhookDelivery", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery
+func (_mr *MockConnMockRecorder) GetWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockConn)(nil).GetWebhookDelivery), arg0, arg1)
+}
+
+// IsUserBlocked mocks base method
+func (_m *MockConn) IsUserBlocked(_param0 string, _param1 string) (bool, error) {
+	ret := _m.ctrl.Call(_m, "IsUserBlocked", _param0, _param1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserBlocked indicates an expected call of IsUserBlocked
+func (_mr *MockConnMockRecorder) IsUserBlocked(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "IsUserBlocked", reflect.TypeOf((*MockConn)(nil).IsUserBlocked), arg0, arg1)
+}
+
+// LockTimer mocks base method
+func (_m *MockConn) LockTimer(_param0 string, _param1 time.Time, _param2 time.Time, _param3 *skydb.Timer) error {
+	ret := _m.ctrl.Call(_m, "LockTimer", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockTimer indicates an expected call of LockTimer
+func (_mr *MockConnMockRecorder) LockTimer(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "LockTimer", reflect.TypeOf((*MockConn)(nil).LockTimer), arg0, arg1, arg2, arg3)
+}
+
 // PrivateDB mocks base method
 func (_m *MockConn) PrivateDB(_param0 string) skydb.Database {
 	ret := _m.ctrl.Call(_m, "PrivateDB", _param0)
@@ -413,6 +877,44 @@ func (_mr *MockConnMockRecorder) PublicDB() *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "PublicDB", reflect.TypeOf((*MockConn)(nil).PublicDB))
 }
 
+// PurgeAuth mocks base method
+func (_m *MockConn) PurgeAuth(_param0 string) error {
+	ret := _m.ctrl.Call(_m, "PurgeAuth", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeAuth indicates an expected call of PurgeAuth
+func (_mr *MockConnMockRecorder) PurgeAuth(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "PurgeAuth", reflect.TypeOf((*MockConn)(nil).PurgeAuth), arg0)
+}
+
+// QueryAPIKeys mocks base method
+func (_m *MockConn) QueryAPIKeys() ([]skydb.APIKey, error) {
+	ret := _m.ctrl.Call(_m, "QueryAPIKeys")
+	ret0, _ := ret[0].([]skydb.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryAPIKeys indicates an expected call of QueryAPIKeys
+func (_mr *MockConnMockRecorder) QueryAPIKeys() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryAPIKeys", reflect.TypeOf((*MockConn)(nil).QueryAPIKeys))
+}
+
+// QueryBlockedUsers mocks base method
+func (_m *MockConn) QueryBlockedUsers(_param0 string) ([]skydb.UserBlock, error) {
+	ret := _m.ctrl.Call(_m, "QueryBlockedUsers", _param0)
+	ret0, _ := ret[0].([]skydb.UserBlock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryBlockedUsers indicates an expected call of QueryBlockedUsers
+func (_mr *MockConnMockRecorder) QueryBlockedUsers(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryBlockedUsers", reflect.TypeOf((*MockConn)(nil).QueryBlockedUsers), arg0)
+}
+
 // QueryDevicesByUser mocks base method
 func (_m *MockConn) QueryDevicesByUser(_param0 string) ([]skydb.Device, error) {
 	ret := _m.ctrl.Call(_m, "QueryDevicesByUser", _param0)
@@ -439,6 +941,58 @@ func (_mr *MockConnMockRecorder) QueryDevicesByUserAndTopic(arg0, arg1 interface
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryDevicesByUserAndTopic", reflect.TypeOf((*MockConn)(nil).QueryDevicesByUserAndTopic), arg0, arg1)
 }
 
+// QueryExpiredUserDataExports mocks base method
+func (_m *MockConn) QueryExpiredUserDataExports(_param0 time.Time, _param1 uint64) ([]skydb.UserDataExport, error) {
+	ret := _m.ctrl.Call(_m, "QueryExpiredUserDataExports", _param0, _param1)
+	ret0, _ := ret[0].([]skydb.UserDataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryExpiredUserDataExports indicates an expected call of QueryExpiredUserDataExports
+func (_mr *MockConnMockRecorder) QueryExpiredUserDataExports(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryExpiredUserDataExports", reflect.TypeOf((*MockConn)(nil).QueryExpiredUserDataExports), arg0, arg1)
+}
+
+// QueryGroupMembers mocks base method
+func (_m *MockConn) QueryGroupMembers(_param0 string) ([]skydb.GroupMembership, error) {
+	ret := _m.ctrl.Call(_m, "QueryGroupMembers", _param0)
+	ret0, _ := ret[0].([]skydb.GroupMembership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryGroupMembers indicates an expected call of QueryGroupMembers
+func (_mr *MockConnMockRecorder) QueryGroupMembers(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryGroupMembers", reflect.TypeOf((*MockConn)(nil).QueryGroupMembers), arg0)
+}
+
+// QueryGroupsByMember mocks base method
+func (_m *MockConn) QueryGroupsByMember(_param0 string) ([]skydb.Group, error) {
+	ret := _m.ctrl.Call(_m, "QueryGroupsByMember", _param0)
+	ret0, _ := ret[0].([]skydb.Group)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryGroupsByMember indicates an expected call of QueryGroupsByMember
+func (_mr *MockConnMockRecorder) QueryGroupsByMember(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryGroupsByMember", reflect.TypeOf((*MockConn)(nil).QueryGroupsByMember), arg0)
+}
+
+// QueryJobs mocks base method
+func (_m *MockConn) QueryJobs(_param0 skydb.JobStatus, _param1 uint64) ([]skydb.Job, error) {
+	ret := _m.ctrl.Call(_m, "QueryJobs", _param0, _param1)
+	ret0, _ := ret[0].([]skydb.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryJobs indicates an expected call of QueryJobs
+func (_mr *MockConnMockRecorder) QueryJobs(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryJobs", reflect.TypeOf((*MockConn)(nil).QueryJobs), arg0, arg1)
+}
+
 // QueryRelation mocks base method
 func (_m *MockConn) QueryRelation(_param0 string, _param1 string, _param2 string, _param3 skydb.QueryConfig) []skydb.AuthInfo {
 	ret := _m.ctrl.Call(_m, "QueryRelation", _param0, _param1, _param2, _param3)
@@ -464,6 +1018,95 @@ func (_mr *MockConnMockRecorder) QueryRelationCount(arg0, arg1, arg2 interface{}
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryRelationCount", reflect.TypeOf((*MockConn)(nil).QueryRelationCount), arg0, arg1, arg2)
 }
 
+// QueryRelationRequests mocks base method
+func (_m *MockConn) QueryRelationRequests(_param0 string, _param1 string, _param2 string, _param3 skydb.QueryConfig) ([]skydb.RelationRequest, error) {
+	ret := _m.ctrl.Call(_m, "QueryRelationRequests", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].([]skydb.RelationRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryRelationRequests indicates an expected call of QueryRelationRequests
+func (_mr *MockConnMockRecorder) QueryRelationRequests(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryRelationRequests", reflect.TypeOf((*MockConn)(nil).QueryRelationRequests), arg0, arg1, arg2, arg3)
+}
+
+// QueryTimers mocks base method
+func (_m *MockConn) QueryTimers() ([]skydb.Timer, error) {
+	ret := _m.ctrl.Call(_m, "QueryTimers")
+	ret0, _ := ret[0].([]skydb.Timer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryTimers indicates an expected call of QueryTimers
+func (_mr *MockConnMockRecorder) QueryTimers() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryTimers", reflect.TypeOf((*MockConn)(nil).QueryTimers))
+}
+
+// QueryUserDataExports mocks base method
+func (_m *MockConn) QueryUserDataExports(_param0 string) ([]skydb.UserDataExport, error) {
+	ret := _m.ctrl.Call(_m, "QueryUserDataExports", _param0)
+	ret0, _ := ret[0].([]skydb.UserDataExport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryUserDataExports indicates an expected call of QueryUserDataExports
+func (_mr *MockConnMockRecorder) QueryUserDataExports(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryUserDataExports", reflect.TypeOf((*MockConn)(nil).QueryUserDataExports), arg0)
+}
+
+// QueryWebhookDeliveries mocks base method
+func (_m *MockConn) QueryWebhookDeliveries(_param0 string, _param1 skydb.WebhookDeliveryStatus, _param2 uint64) ([]skydb.WebhookDelivery, error) {
+	ret := _m.ctrl.Call(_m, "QueryWebhookDeliveries", _param0, _param1, _param2)
+	ret0, _ := ret[0].([]skydb.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWebhookDeliveries indicates an expected call of QueryWebhookDeliveries
+func (_mr *MockConnMockRecorder) QueryWebhookDeliveries(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryWebhookDeliveries", reflect.TypeOf((*MockConn)(nil).QueryWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// QueryWebhooks mocks base method
+func (_m *MockConn) QueryWebhooks() ([]skydb.Webhook, error) {
+	ret := _m.ctrl.Call(_m, "QueryWebhooks")
+	ret0, _ := ret[0].([]skydb.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryWebhooks indicates an expected call of QueryWebhooks
+func (_mr *MockConnMockRecorder) QueryWebhooks() *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "QueryWebhooks", reflect.TypeOf((*MockConn)(nil).QueryWebhooks))
+}
+
+// RegisterTimer mocks base method
+func (_m *MockConn) RegisterTimer(_param0 *skydb.Timer) error {
+	ret := _m.ctrl.Call(_m, "RegisterTimer", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterTimer indicates an expected call of RegisterTimer
+func (_mr *MockConnMockRecorder) RegisterTimer(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RegisterTimer", reflect.TypeOf((*MockConn)(nil).RegisterTimer), arg0)
+}
+
+// RemoveGroupMember mocks base method
+func (_m *MockConn) RemoveGroupMember(_param0 string, _param1 string) error {
+	ret := _m.ctrl.Call(_m, "RemoveGroupMember", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveGroupMember indicates an expected call of RemoveGroupMember
+func (_mr *MockConnMockRecorder) RemoveGroupMember(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "RemoveGroupMember", reflect.TypeOf((*MockConn)(nil).RemoveGroupMember), arg0, arg1)
+}
+
 // RemovePasswordHistory mocks base method
 func (_m *MockConn) RemovePasswordHistory(_param0 string, _param1 int, _param2 int) error {
 	ret := _m.ctrl.Call(_m, "RemovePasswordHistory", _param0, _param1, _param2)
@@ -524,6 +1167,18 @@ func (_mr *MockConnMockRecorder) SaveDevice(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SaveDevice", reflect.TypeOf((*MockConn)(nil).SaveDevice), arg0)
 }
 
+// SaveRelation mocks base method
+func (_m *MockConn) SaveRelation(_param0 *skydb.Relation) error {
+	ret := _m.ctrl.Call(_m, "SaveRelation", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRelation indicates an expected call of SaveRelation
+func (_mr *MockConnMockRecorder) SaveRelation(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SaveRelation", reflect.TypeOf((*MockConn)(nil).SaveRelation), arg0)
+}
+
 // SetAdminRoles mocks base method
 func (_m *MockConn) SetAdminRoles(_param0 []string) error {
 	ret := _m.ctrl.Call(_m, "SetAdminRoles", _param0)
@@ -548,6 +1203,18 @@ func (_mr *MockConnMockRecorder) SetDefaultRoles(arg0 interface{}) *gomock.Call
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetDefaultRoles", reflect.TypeOf((*MockConn)(nil).SetDefaultRoles), arg0)
 }
 
+// SetGroupMember mocks base method
+func (_m *MockConn) SetGroupMember(_param0 *skydb.GroupMembership) error {
+	ret := _m.ctrl.Call(_m, "SetGroupMember", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetGroupMember indicates an expected call of SetGroupMember
+func (_mr *MockConnMockRecorder) SetGroupMember(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetGroupMember", reflect.TypeOf((*MockConn)(nil).SetGroupMember), arg0)
+}
+
 // SetRecordAccess mocks base method
 func (_m *MockConn) SetRecordAccess(_param0 string, _param1 skydb.RecordACL) error {
 	ret := _m.ctrl.Call(_m, "SetRecordAccess", _param0, _param1)
@@ -584,6 +1251,42 @@ func (_mr *MockConnMockRecorder) SetRecordFieldAccess(arg0 interface{}) *gomock.
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetRecordFieldAccess", reflect.TypeOf((*MockConn)(nil).SetRecordFieldAccess), arg0)
 }
 
+// SetRecordPredicateAccess mocks base method
+func (_m *MockConn) SetRecordPredicateAccess(_param0 string, _param1 skydb.RecordPredicateAccess) error {
+	ret := _m.ctrl.Call(_m, "SetRecordPredicateAccess", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRecordPredicateAccess indicates an expected call of SetRecordPredicateAccess
+func (_mr *MockConnMockRecorder) SetRecordPredicateAccess(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetRecordPredicateAccess", reflect.TypeOf((*MockConn)(nil).SetRecordPredicateAccess), arg0, arg1)
+}
+
+// SetRoleHierarchy mocks base method
+func (_m *MockConn) SetRoleHierarchy(_param0 skydb.RoleHierarchy) error {
+	ret := _m.ctrl.Call(_m, "SetRoleHierarchy", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRoleHierarchy indicates an expected call of SetRoleHierarchy
+func (_mr *MockConnMockRecorder) SetRoleHierarchy(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetRoleHierarchy", reflect.TypeOf((*MockConn)(nil).SetRoleHierarchy), arg0)
+}
+
+// SetRolePermissions mocks base method
+func (_m *MockConn) SetRolePermissions(_param0 string, _param1 []string) error {
+	ret := _m.ctrl.Call(_m, "SetRolePermissions", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRolePermissions indicates an expected call of SetRolePermissions
+func (_mr *MockConnMockRecorder) SetRolePermissions(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "SetRolePermissions", reflect.TypeOf((*MockConn)(nil).SetRolePermissions), arg0, arg1)
+}
+
 // Subscribe mocks base method
 func (_m *MockConn) Subscribe(_param0 chan skydb.RecordEvent) error {
 	ret := _m.ctrl.Call(_m, "Subscribe", _param0)
@@ -596,6 +1299,18 @@ func (_mr *MockConnMockRecorder) Subscribe(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "Subscribe", reflect.TypeOf((*MockConn)(nil).Subscribe), arg0)
 }
 
+// UnblockUser mocks base method
+func (_m *MockConn) UnblockUser(_param0 string, _param1 string) error {
+	ret := _m.ctrl.Call(_m, "UnblockUser", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnblockUser indicates an expected call of UnblockUser
+func (_mr *MockConnMockRecorder) UnblockUser(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UnblockUser", reflect.TypeOf((*MockConn)(nil).UnblockUser), arg0, arg1)
+}
+
 // UnionDB mocks base method
 func (_m *MockConn) UnionDB() skydb.Database {
 	ret := _m.ctrl.Call(_m, "UnionDB")
@@ -608,6 +1323,18 @@ func (_mr *MockConnMockRecorder) UnionDB() *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UnionDB", reflect.TypeOf((*MockConn)(nil).UnionDB))
 }
 
+// UpdateAPIKey mocks base method
+func (_m *MockConn) UpdateAPIKey(_param0 *skydb.APIKey) error {
+	ret := _m.ctrl.Call(_m, "UpdateAPIKey", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAPIKey indicates an expected call of UpdateAPIKey
+func (_mr *MockConnMockRecorder) UpdateAPIKey(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateAPIKey", reflect.TypeOf((*MockConn)(nil).UpdateAPIKey), arg0)
+}
+
 // UpdateAuth mocks base method
 func (_m *MockConn) UpdateAuth(_param0 *skydb.AuthInfo) error {
 	ret := _m.ctrl.Call(_m, "UpdateAuth", _param0)
@@ -620,6 +1347,54 @@ func (_mr *MockConnMockRecorder) UpdateAuth(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateAuth", reflect.TypeOf((*MockConn)(nil).UpdateAuth), arg0)
 }
 
+// UpdateClaimedJob mocks base method
+func (_m *MockConn) UpdateClaimedJob(_param0 *skydb.Job, _param1 time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedJob", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedJob indicates an expected call of UpdateClaimedJob
+func (_mr *MockConnMockRecorder) UpdateClaimedJob(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedJob", reflect.TypeOf((*MockConn)(nil).UpdateClaimedJob), arg0, arg1)
+}
+
+// UpdateClaimedTimer mocks base method
+func (_m *MockConn) UpdateClaimedTimer(_param0 *skydb.Timer, _param1 time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedTimer", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedTimer indicates an expected call of UpdateClaimedTimer
+func (_mr *MockConnMockRecorder) UpdateClaimedTimer(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedTimer", reflect.TypeOf((*MockConn)(nil).UpdateClaimedTimer), arg0, arg1)
+}
+
+// UpdateClaimedWebhookDelivery mocks base method
+func (_m *MockConn) UpdateClaimedWebhookDelivery(_param0 *skydb.WebhookDelivery, _param1 time.Time) error {
+	ret := _m.ctrl.Call(_m, "UpdateClaimedWebhookDelivery", _param0, _param1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateClaimedWebhookDelivery indicates an expected call of UpdateClaimedWebhookDelivery
+func (_mr *MockConnMockRecorder) UpdateClaimedWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateClaimedWebhookDelivery", reflect.TypeOf((*MockConn)(nil).UpdateClaimedWebhookDelivery), arg0, arg1)
+}
+
+// UpdateJob mocks base method
+func (_m *MockConn) UpdateJob(_param0 *skydb.Job) error {
+	ret := _m.ctrl.Call(_m, "UpdateJob", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateJob indicates an expected call of UpdateJob
+func (_mr *MockConnMockRecorder) UpdateJob(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateJob", reflect.TypeOf((*MockConn)(nil).UpdateJob), arg0)
+}
+
 // UpdateOAuthInfo mocks base method
 func (_m *MockConn) UpdateOAuthInfo(_param0 *skydb.OAuthInfo) error {
 	ret := _m.ctrl.Call(_m, "UpdateOAuthInfo", _param0)
@@ -631,3 +1406,51 @@ func (_m *MockConn) UpdateOAuthInfo(_param0 *skydb.OAuthInfo) error {
 func (_mr *MockConnMockRecorder) UpdateOAuthInfo(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateOAuthInfo", reflect.TypeOf((*MockConn)(nil).UpdateOAuthInfo), arg0)
 }
+
+// UpdateRelationRequest mocks base method
+func (_m *MockConn) UpdateRelationRequest(_param0 *skydb.RelationRequest) error {
+	ret := _m.ctrl.Call(_m, "UpdateRelationRequest", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRelationRequest indicates an expected call of UpdateRelationRequest
+func (_mr *MockConnMockRecorder) UpdateRelationRequest(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateRelationRequest", reflect.TypeOf((*MockConn)(nil).UpdateRelationRequest), arg0)
+}
+
+// UpdateTimer mocks base method
+func (_m *MockConn) UpdateTimer(_param0 *skydb.Timer) error {
+	ret := _m.ctrl.Call(_m, "UpdateTimer", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTimer indicates an expected call of UpdateTimer
+func (_mr *MockConnMockRecorder) UpdateTimer(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateTimer", reflect.TypeOf((*MockConn)(nil).UpdateTimer), arg0)
+}
+
+// UpdateWebhook mocks base method
+func (_m *MockConn) UpdateWebhook(_param0 *skydb.Webhook) error {
+	ret := _m.ctrl.Call(_m, "UpdateWebhook", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook
+func (_mr *MockConnMockRecorder) UpdateWebhook(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateWebhook", reflect.TypeOf((*MockConn)(nil).UpdateWebhook), arg0)
+}
+
+// UpdateWebhookDelivery mocks base method
+func (_m *MockConn) UpdateWebhookDelivery(_param0 *skydb.WebhookDelivery) error {
+	ret := _m.ctrl.Call(_m, "UpdateWebhookDelivery", _param0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery
+func (_mr *MockConnMockRecorder) UpdateWebhookDelivery(arg0 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockConn)(nil).UpdateWebhookDelivery), arg0)
+}
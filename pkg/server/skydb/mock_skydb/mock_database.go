@@ -340,6 +340,19 @@ func (_mr *MockDatabaseMockRecorder) TableName(arg0 interface{}) *gomock.Call {
 	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "TableName", reflect.TypeOf((*MockDatabase)(nil).TableName), arg0)
 }
 
+// UpdateRecordACL mocks base method
+func (_m *MockDatabase) UpdateRecordACL(_param0 *skydb.Query, _param1 skydb.RecordACLPatch, _param2 uint64, _param3 skydb.RecordACLUpdateFunc) (skydb.RecordACLUpdateProgress, error) {
+	ret := _m.ctrl.Call(_m, "UpdateRecordACL", _param0, _param1, _param2, _param3)
+	ret0, _ := ret[0].(skydb.RecordACLUpdateProgress)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRecordACL indicates an expected call of UpdateRecordACL
+func (_mr *MockDatabaseMockRecorder) UpdateRecordACL(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return _mr.mock.ctrl.RecordCallWithMethodType(_mr.mock, "UpdateRecordACL", reflect.TypeOf((*MockDatabase)(nil).UpdateRecordACL), arg0, arg1, arg2, arg3)
+}
+
 // UserRecordType mocks base method
 func (_m *MockDatabase) UserRecordType() string {
 	ret := _m.ctrl.Call(_m, "UserRecordType")
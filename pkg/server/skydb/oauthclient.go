@@ -0,0 +1,107 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrOAuthClientNotFound is returned by Conn.GetOAuthClient and
+// Conn.DeleteOAuthClient when the OAuthClient is not found.
+var ErrOAuthClientNotFound = errors.New("skydb: OAuth client not found")
+
+// ErrOAuthCodeNotFound is returned by Conn.ConsumeOAuthAuthorizationCode
+// when the code does not exist or is already consumed.
+var ErrOAuthCodeNotFound = errors.New("skydb: OAuth authorization code not found")
+
+// OAuthClient is an application registered to authenticate users with
+// Skygear acting as an OAuth 2.0 authorization server.
+//
+// A client without HashedSecret is a public client, which is required to
+// use PKCE with authorization code grant.
+type OAuthClient struct {
+	ID           string     `json:"client_id"`
+	Name         string     `json:"name"`
+	HashedSecret []byte     `json:"-"`
+	RedirectURIs []string   `json:"redirect_uris"`
+	GrantTypes   []string   `json:"grant_types"`
+	Scopes       []string   `json:"scopes"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+}
+
+// SetSecret sets the HashedSecret with the secret specified.
+func (c *OAuthClient) SetSecret(secret string) {
+	hashedSecret, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		panic("oauthclient: Failed to hash secret")
+	}
+	c.HashedSecret = hashedSecret
+}
+
+// IsSameSecret determines whether the specified secret is the secret
+// of the client.
+func (c *OAuthClient) IsSameSecret(secret string) bool {
+	if len(c.HashedSecret) == 0 {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword(c.HashedSecret, []byte(secret)) == nil
+}
+
+// IsPublic returns true if the client does not have a secret.
+func (c *OAuthClient) IsPublic() bool {
+	return len(c.HashedSecret) == 0
+}
+
+// HasRedirectURI returns true if the redirect URI is registered.
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	return containsString(c.RedirectURIs, uri)
+}
+
+// HasGrantType returns true if the client is allowed to use the grant type.
+func (c *OAuthClient) HasGrantType(grantType string) bool {
+	return containsString(c.GrantTypes, grantType)
+}
+
+// HasScope returns true if the client is allowed to request the scope.
+func (c *OAuthClient) HasScope(scope string) bool {
+	return containsString(c.Scopes, scope)
+}
+
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// OAuthAuthorizationCode is an authorization code issued to a client
+// on behalf of a user, to be exchanged for tokens once.
+type OAuthAuthorizationCode struct {
+	Code                string
+	ClientID            string
+	UserID              string
+	RedirectURI         string
+	Scopes              []string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpireAt            time.Time
+	CreatedAt           time.Time
+}
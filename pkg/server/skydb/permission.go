@@ -0,0 +1,64 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"strings"
+)
+
+// RolePermissions maps a role to the router actions users having the role
+// are permitted to call. Plugin lambdas are router actions named after the
+// lambda.
+//
+// An action is either an action name such as "auth:disable:set", an action
+// prefix such as "record:*", or "*" for all actions.
+type RolePermissions map[string][]string
+
+// matchAction returns true if the action pattern matches the action.
+func matchAction(pattern string, action string) bool {
+	if pattern == "*" || pattern == action {
+		return true
+	}
+	if strings.HasSuffix(pattern, ":*") {
+		return strings.HasPrefix(action, strings.TrimSuffix(pattern, "*"))
+	}
+	return false
+}
+
+// Governs returns true if any role is given permission to the action. Only
+// users having one of these roles are permitted to call a governed action.
+func (p RolePermissions) Governs(action string) bool {
+	for _, patterns := range p {
+		for _, pattern := range patterns {
+			if matchAction(pattern, action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Permits returns true if any of the roles is given permission to the
+// action.
+func (p RolePermissions) Permits(roles []string, action string) bool {
+	for _, role := range roles {
+		for _, pattern := range p[role] {
+			if matchAction(pattern, action) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRolePermissions(t *testing.T) {
+	Convey("RolePermissions", t, func() {
+		permissions := RolePermissions{
+			"support": []string{"auth:disable:set", "auth:unlock"},
+			"editor":  []string{"record:*"},
+		}
+
+		Convey("governs actions permitted to any role", func() {
+			So(permissions.Governs("auth:disable:set"), ShouldBeTrue)
+			So(permissions.Governs("record:save"), ShouldBeTrue)
+			So(permissions.Governs("auth:login"), ShouldBeFalse)
+			So(permissions.Governs("recordings"), ShouldBeFalse)
+		})
+
+		Convey("permits roles", func() {
+			So(permissions.Permits([]string{"support"}, "auth:unlock"), ShouldBeTrue)
+			So(permissions.Permits([]string{"user", "editor"}, "record:delete"), ShouldBeTrue)
+			So(permissions.Permits([]string{"editor"}, "auth:unlock"), ShouldBeFalse)
+			So(permissions.Permits(nil, "auth:unlock"), ShouldBeFalse)
+		})
+
+		Convey("permits all actions with wildcard", func() {
+			permissions["superuser"] = []string{"*"}
+			So(permissions.Permits([]string{"superuser"}, "schema:create"), ShouldBeTrue)
+			So(permissions.Governs("schema:create"), ShouldBeTrue)
+		})
+	})
+}
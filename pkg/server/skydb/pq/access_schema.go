@@ -282,3 +282,88 @@ func (c *conn) GetRecordFieldAccess() (skydb.FieldACL, error) {
 	c.FieldACL = &acl
 	return acl, nil
 }
+
+func (c *conn) SetRecordPredicateAccess(recordType string, access skydb.RecordPredicateAccess) error {
+	pkData := map[string]interface{}{
+		"record_type": recordType,
+	}
+	values := map[string]interface{}{
+		"read_predicate":  predicateValue(access.Read),
+		"write_predicate": predicateValue(access.Write),
+	}
+
+	upsert := builder.UpsertQuery(c.tableName("_record_predicate_access"), pkData, values)
+	if _, err := c.ExecWith(upsert); err != nil {
+		return err
+	}
+
+	delete(c.recordPredicateAccess, recordType) // invalidate cached predicates
+	return nil
+}
+
+func (c *conn) GetRecordPredicateAccess(recordType string) (skydb.RecordPredicateAccess, error) {
+	builder := psql.
+		Select("read_predicate", "write_predicate").
+		From(c.tableName("_record_predicate_access")).
+		Where(sq.Eq{"record_type": recordType})
+
+	var read, write sql.NullString
+	err := c.QueryRowWith(builder).Scan(&read, &write)
+	if err == sql.ErrNoRows {
+		return skydb.RecordPredicateAccess{}, nil
+	} else if err != nil {
+		return skydb.RecordPredicateAccess{}, err
+	}
+
+	access := skydb.RecordPredicateAccess{}
+	if read.Valid {
+		if err := json.Unmarshal([]byte(read.String), &access.Read); err != nil {
+			return skydb.RecordPredicateAccess{}, err
+		}
+	}
+	if write.Valid {
+		if err := json.Unmarshal([]byte(write.String), &access.Write); err != nil {
+			return skydb.RecordPredicateAccess{}, err
+		}
+	}
+	return access, nil
+}
+
+// compiledRecordPredicateAccess is a skydb.RecordPredicateAccess with
+// predicates compiled, cached in conn for each record type.
+type compiledRecordPredicateAccess struct {
+	Read  skydb.Predicate
+	Write skydb.Predicate
+}
+
+func (access compiledRecordPredicateAccess) Predicate(level skydb.RecordACLLevel) skydb.Predicate {
+	if level == skydb.WriteLevel {
+		return access.Write
+	}
+	return access.Read
+}
+
+func (c *conn) getCompiledRecordPredicateAccess(recordType string) (compiledRecordPredicateAccess, error) {
+	if compiled, ok := c.recordPredicateAccess[recordType]; ok {
+		return compiled, nil
+	}
+
+	access, err := c.GetRecordPredicateAccess(recordType)
+	if err != nil {
+		return compiledRecordPredicateAccess{}, err
+	}
+
+	compiled := compiledRecordPredicateAccess{}
+	if compiled.Read, err = builder.CompileRecordPredicate(access.Read); err != nil {
+		return compiledRecordPredicateAccess{}, err
+	}
+	if compiled.Write, err = builder.CompileRecordPredicate(access.Write); err != nil {
+		return compiledRecordPredicateAccess{}, err
+	}
+
+	if c.recordPredicateAccess == nil {
+		c.recordPredicateAccess = map[string]compiledRecordPredicateAccess{}
+	}
+	c.recordPredicateAccess[recordType] = compiled
+	return compiled, nil
+}
@@ -199,3 +199,81 @@ func TestRecordFieldAccess(t *testing.T) {
 		})
 	})
 }
+
+func TestRecordPredicateAccess(t *testing.T) {
+	var c *conn
+
+	Convey("RecordPredicateAccess", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		readPredicate := []interface{}{
+			"eq",
+			map[string]interface{}{"$type": "keypath", "$val": "status"},
+			"published",
+		}
+		writePredicate := []interface{}{
+			"eq",
+			map[string]interface{}{"$type": "keypath", "$val": "_owner"},
+			map[string]interface{}{"$type": "user"},
+		}
+
+		Convey("get empty access of unknown record type", func() {
+			access, err := c.GetRecordPredicateAccess("note")
+			So(err, ShouldBeNil)
+			So(access, ShouldResemble, skydb.RecordPredicateAccess{})
+		})
+
+		Convey("set and get access", func() {
+			err := c.SetRecordPredicateAccess("note", skydb.RecordPredicateAccess{
+				Read:  readPredicate,
+				Write: writePredicate,
+			})
+			So(err, ShouldBeNil)
+
+			access, err := c.GetRecordPredicateAccess("note")
+			So(err, ShouldBeNil)
+			So(access, ShouldResemble, skydb.RecordPredicateAccess{
+				Read:  readPredicate,
+				Write: writePredicate,
+			})
+		})
+
+		Convey("remove access", func() {
+			So(c.SetRecordPredicateAccess("note", skydb.RecordPredicateAccess{
+				Read: readPredicate,
+			}), ShouldBeNil)
+			So(c.SetRecordPredicateAccess("note", skydb.RecordPredicateAccess{}), ShouldBeNil)
+
+			access, err := c.GetRecordPredicateAccess("note")
+			So(err, ShouldBeNil)
+			So(access, ShouldResemble, skydb.RecordPredicateAccess{})
+		})
+
+		Convey("compile access and invalidate on set", func() {
+			So(c.SetRecordPredicateAccess("note", skydb.RecordPredicateAccess{
+				Write: writePredicate,
+			}), ShouldBeNil)
+
+			compiled, err := c.getCompiledRecordPredicateAccess("note")
+			So(err, ShouldBeNil)
+			So(compiled.Predicate(skydb.ReadLevel).IsEmpty(), ShouldBeTrue)
+			So(compiled.Predicate(skydb.WriteLevel), ShouldResemble, skydb.Predicate{
+				Operator: skydb.Equal,
+				Children: []interface{}{
+					skydb.Expression{Type: skydb.KeyPath, Value: "_owner"},
+					skydb.Expression{Type: skydb.Literal, Value: skydb.CurrentUser{}},
+				},
+			})
+
+			So(c.SetRecordPredicateAccess("note", skydb.RecordPredicateAccess{
+				Read: readPredicate,
+			}), ShouldBeNil)
+
+			compiled, err = c.getCompiledRecordPredicateAccess("note")
+			So(err, ShouldBeNil)
+			So(compiled.Predicate(skydb.ReadLevel).IsEmpty(), ShouldBeFalse)
+			So(compiled.Predicate(skydb.WriteLevel).IsEmpty(), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,194 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/lann/squirrel"
+	"github.com/lib/pq"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var apiKeyColumns = []string{
+	"id",
+	"name",
+	"hashed_key",
+	"previous_hashed_key",
+	"previous_key_expire_at",
+	"scopes",
+	"allowed_origins",
+	"rate_limit",
+	"expire_at",
+	"revoked_at",
+	"created_at",
+}
+
+func (c *conn) CreateAPIKey(key *skydb.APIKey) error {
+	createdAt := key.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	builder := psql.Insert(c.tableName("_api_key")).Columns(apiKeyColumns...).Values(
+		key.ID,
+		key.Name,
+		key.HashedKey,
+		nullString(key.PreviousHashedKey),
+		key.PreviousKeyExpireAt,
+		stringArray(key.Scopes),
+		stringArray(key.AllowedOrigins),
+		key.RateLimit,
+		key.ExpireAt,
+		key.RevokedAt,
+		createdAt,
+	)
+
+	_, err := c.ExecWith(builder)
+	if isUniqueViolated(err) {
+		return fmt.Errorf("skydb: duplicated API key %s", key.ID)
+	}
+	return err
+}
+
+func (c *conn) GetAPIKey(id string, key *skydb.APIKey) error {
+	builder := psql.Select(apiKeyColumns...).
+		From(c.tableName("_api_key")).
+		Where("id = ?", id)
+
+	return c.doScanAPIKey(key, c.QueryRowWith(builder))
+}
+
+func (c *conn) GetAPIKeyByHash(hashedKey string, key *skydb.APIKey) error {
+	builder := psql.Select(apiKeyColumns...).
+		From(c.tableName("_api_key")).
+		Where("hashed_key = ? OR previous_hashed_key = ?", hashedKey, hashedKey).
+		Limit(1)
+
+	return c.doScanAPIKey(key, c.QueryRowWith(builder))
+}
+
+func (c *conn) UpdateAPIKey(key *skydb.APIKey) error {
+	builder := psql.Update(c.tableName("_api_key")).
+		Set("name", key.Name).
+		Set("hashed_key", key.HashedKey).
+		Set("previous_hashed_key", nullString(key.PreviousHashedKey)).
+		Set("previous_key_expire_at", key.PreviousKeyExpireAt).
+		Set("scopes", stringArray(key.Scopes)).
+		Set("allowed_origins", stringArray(key.AllowedOrigins)).
+		Set("rate_limit", key.RateLimit).
+		Set("expire_at", key.ExpireAt).
+		Set("revoked_at", key.RevokedAt).
+		Where("id = ?", key.ID)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrAPIKeyNotFound
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) QueryAPIKeys() ([]skydb.APIKey, error) {
+	builder := psql.Select(apiKeyColumns...).
+		From(c.tableName("_api_key")).
+		OrderBy("created_at")
+
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []skydb.APIKey{}
+	for rows.Next() {
+		key := skydb.APIKey{}
+		if err := c.doScanAPIKey(&key, rows); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (c *conn) doScanAPIKey(key *skydb.APIKey, scanner sq.RowScanner) error {
+	var (
+		previousHashedKey   sql.NullString
+		previousKeyExpireAt pq.NullTime
+		scopes              pq.StringArray
+		allowedOrigins      pq.StringArray
+		expireAt            pq.NullTime
+		revokedAt           pq.NullTime
+	)
+	err := scanner.Scan(
+		&key.ID,
+		&key.Name,
+		&key.HashedKey,
+		&previousHashedKey,
+		&previousKeyExpireAt,
+		&scopes,
+		&allowedOrigins,
+		&key.RateLimit,
+		&expireAt,
+		&revokedAt,
+		&key.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrAPIKeyNotFound
+	} else if err != nil {
+		return err
+	}
+
+	key.PreviousHashedKey = previousHashedKey.String
+	key.PreviousKeyExpireAt = nullTimePtr(previousKeyExpireAt)
+	key.Scopes = []string(scopes)
+	key.AllowedOrigins = []string(allowedOrigins)
+	key.ExpireAt = nullTimePtr(expireAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+	return nil
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// stringArray converts a nil slice to an empty array, which is stored
+// as NULL otherwise.
+func stringArray(s []string) pq.StringArray {
+	if s == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(s)
+}
+
+func nullTimePtr(t pq.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,103 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAPIKeyConn(t *testing.T) {
+	var c *conn
+
+	Convey("Conn", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		createdAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		expireAt := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+		key := skydb.APIKey{
+			ID:             "key-id",
+			Name:           "Partner",
+			Scopes:         []string{"record:*", "@asset"},
+			AllowedOrigins: []string{"https://partner.example.com"},
+			RateLimit:      60,
+			ExpireAt:       &expireAt,
+			CreatedAt:      createdAt,
+		}
+		key.SetKey("secret-key")
+
+		Convey("create and get api key", func() {
+			So(c.CreateAPIKey(&key), ShouldBeNil)
+
+			fetched := skydb.APIKey{}
+			So(c.GetAPIKey("key-id", &fetched), ShouldBeNil)
+			So(fetched.Name, ShouldEqual, "Partner")
+			So(fetched.HashedKey, ShouldEqual, key.HashedKey)
+			So(fetched.Scopes, ShouldResemble, []string{"record:*", "@asset"})
+			So(fetched.AllowedOrigins, ShouldResemble, []string{"https://partner.example.com"})
+			So(fetched.RateLimit, ShouldEqual, 60)
+			So(fetched.ExpireAt.Unix(), ShouldEqual, expireAt.Unix())
+			So(fetched.RevokedAt, ShouldBeNil)
+			So(fetched.CreatedAt.Unix(), ShouldEqual, createdAt.Unix())
+
+			fetched = skydb.APIKey{}
+			So(c.GetAPIKeyByHash(skydb.HashAPIKey("secret-key"), &fetched), ShouldBeNil)
+			So(fetched.ID, ShouldEqual, "key-id")
+		})
+
+		Convey("get api key by previous key after rotation", func() {
+			So(c.CreateAPIKey(&key), ShouldBeNil)
+
+			key.Rotate("new-key", createdAt, time.Hour)
+			So(c.UpdateAPIKey(&key), ShouldBeNil)
+
+			fetched := skydb.APIKey{}
+			So(c.GetAPIKeyByHash(skydb.HashAPIKey("secret-key"), &fetched), ShouldBeNil)
+			So(fetched.ID, ShouldEqual, "key-id")
+			So(c.GetAPIKeyByHash(skydb.HashAPIKey("new-key"), &fetched), ShouldBeNil)
+			So(fetched.ID, ShouldEqual, "key-id")
+			So(fetched.PreviousKeyExpireAt.Unix(), ShouldEqual, createdAt.Add(time.Hour).Unix())
+		})
+
+		Convey("query api keys", func() {
+			So(c.CreateAPIKey(&key), ShouldBeNil)
+			other := skydb.APIKey{
+				ID:        "other-key-id",
+				Name:      "Internal",
+				Scopes:    []string{"*"},
+				CreatedAt: createdAt.Add(time.Hour),
+			}
+			other.SetKey("other-key")
+			So(c.CreateAPIKey(&other), ShouldBeNil)
+
+			keys, err := c.QueryAPIKeys()
+			So(err, ShouldBeNil)
+			So(keys, ShouldHaveLength, 2)
+			So(keys[0].ID, ShouldEqual, "key-id")
+			So(keys[1].ID, ShouldEqual, "other-key-id")
+		})
+
+		Convey("return ErrAPIKeyNotFound when key does not exist", func() {
+			fetched := skydb.APIKey{}
+			So(c.GetAPIKey("not-exist", &fetched), ShouldEqual, skydb.ErrAPIKeyNotFound)
+			So(c.GetAPIKeyByHash("not-exist", &fetched), ShouldEqual, skydb.ErrAPIKeyNotFound)
+			So(c.UpdateAPIKey(&key), ShouldEqual, skydb.ErrAPIKeyNotFound)
+		})
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+func (c *conn) BlockUser(block *skydb.UserBlock) error {
+	if block.CreatedAt.IsZero() {
+		block.CreatedAt = timeNow()
+	}
+
+	builder := psql.Insert(c.tableName("_user_block")).Columns(
+		"blocker_id",
+		"blocked_id",
+		"created_at",
+	).Values(
+		block.BlockerID,
+		block.BlockedID,
+		block.CreatedAt,
+	).Suffix("ON CONFLICT (blocker_id, blocked_id) DO NOTHING")
+
+	if _, err := c.ExecWith(builder); err != nil {
+		if isForeignKeyViolated(err) {
+			return skydb.ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *conn) UnblockUser(blockerID string, blockedID string) error {
+	builder := psql.Delete(c.tableName("_user_block")).
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrUserBlockNotFound
+	}
+	return nil
+}
+
+func (c *conn) QueryBlockedUsers(blockerID string) ([]skydb.UserBlock, error) {
+	builder := psql.Select("blocker_id", "blocked_id", "created_at").
+		From(c.tableName("_user_block")).
+		Where("blocker_id = ?", blockerID).
+		OrderBy("created_at", "blocked_id")
+
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := []skydb.UserBlock{}
+	for rows.Next() {
+		block := skydb.UserBlock{}
+		if err := rows.Scan(&block.BlockerID, &block.BlockedID, &block.CreatedAt); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, rows.Err()
+}
+
+func (c *conn) IsUserBlocked(blockerID string, blockedID string) (bool, error) {
+	var blocked bool
+	err := c.QueryRowx(
+		"SELECT EXISTS (SELECT 1 FROM "+c.tableName("_user_block")+" WHERE blocker_id = $1 AND blocked_id = $2)",
+		blockerID, blockedID,
+	).Scan(&blocked)
+	return blocked, err
+}
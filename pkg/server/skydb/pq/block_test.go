@@ -0,0 +1,127 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUserBlock(t *testing.T) {
+	Convey("Conn user block", t, func() {
+		c := getTestConn(t)
+		defer cleanupConn(t, c)
+
+		addUser(t, c, "alice")
+		addUser(t, c, "bob")
+		addUser(t, c, "carol")
+
+		createdAt := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+		So(c.BlockUser(&skydb.UserBlock{
+			BlockerID: "alice",
+			BlockedID: "bob",
+			CreatedAt: createdAt,
+		}), ShouldBeNil)
+
+		Convey("check blocked user", func() {
+			blocked, err := c.IsUserBlocked("alice", "bob")
+			So(err, ShouldBeNil)
+			So(blocked, ShouldBeTrue)
+
+			blocked, err = c.IsUserBlocked("bob", "alice")
+			So(err, ShouldBeNil)
+			So(blocked, ShouldBeFalse)
+		})
+
+		Convey("block the same user twice", func() {
+			So(c.BlockUser(&skydb.UserBlock{
+				BlockerID: "alice",
+				BlockedID: "bob",
+				CreatedAt: createdAt.Add(time.Hour),
+			}), ShouldBeNil)
+
+			blocks, err := c.QueryBlockedUsers("alice")
+			So(err, ShouldBeNil)
+			So(blocks, ShouldResemble, []skydb.UserBlock{
+				{BlockerID: "alice", BlockedID: "bob", CreatedAt: createdAt},
+			})
+		})
+
+		Convey("block non-existent user", func() {
+			err := c.BlockUser(&skydb.UserBlock{
+				BlockerID: "alice",
+				BlockedID: "nobody",
+				CreatedAt: createdAt,
+			})
+			So(err, ShouldEqual, skydb.ErrUserNotFound)
+		})
+
+		Convey("query blocked users", func() {
+			So(c.BlockUser(&skydb.UserBlock{
+				BlockerID: "alice",
+				BlockedID: "carol",
+				CreatedAt: createdAt.Add(time.Hour),
+			}), ShouldBeNil)
+
+			blocks, err := c.QueryBlockedUsers("alice")
+			So(err, ShouldBeNil)
+			So(blocks, ShouldResemble, []skydb.UserBlock{
+				{BlockerID: "alice", BlockedID: "bob", CreatedAt: createdAt},
+				{BlockerID: "alice", BlockedID: "carol", CreatedAt: createdAt.Add(time.Hour)},
+			})
+		})
+
+		Convey("unblock user", func() {
+			So(c.UnblockUser("alice", "bob"), ShouldBeNil)
+
+			blocked, err := c.IsUserBlocked("alice", "bob")
+			So(err, ShouldBeNil)
+			So(blocked, ShouldBeFalse)
+
+			So(c.UnblockUser("alice", "bob"), ShouldEqual, skydb.ErrUserBlockNotFound)
+		})
+
+		Convey("hide records of the blocker from query", func() {
+			record := skydb.Record{
+				ID:      skydb.NewRecordID("note", "id1"),
+				OwnerID: "alice",
+				ACL: skydb.RecordACL{
+					skydb.NewRecordACLEntryPublic(skydb.ReadLevel),
+				},
+			}
+
+			db := c.PublicDB()
+			_, err := db.Extend("note", skydb.RecordSchema{})
+			So(err, ShouldBeNil)
+			So(db.Save(&record), ShouldBeNil)
+
+			query := skydb.Query{Type: "note"}
+			records, err := exhaustRows(db.Query(&query, &skydb.AccessControlOptions{
+				ViewAsUser: &skydb.AuthInfo{ID: "bob"},
+			}))
+			So(err, ShouldBeNil)
+			So(records, ShouldBeEmpty)
+
+			records, err = exhaustRows(db.Query(&query, &skydb.AccessControlOptions{
+				ViewAsUser: &skydb.AuthInfo{ID: "carol"},
+			}))
+			So(err, ShouldBeNil)
+			So(records, ShouldResemble, []skydb.Record{record})
+		})
+	})
+}
@@ -109,7 +109,7 @@ func (f *predicateSqlizerFactory) newFunctionalPredicateSqlizer(predicate skydb.
 }
 
 func (f *predicateSqlizerFactory) newUserRelationFunctionalPredicateSqlizer(fn skydb.UserRelationFunc) (sq.Sqlizer, error) {
-	table := fn.RelationName
+	table := RelationTableName(fn.RelationName)
 	direction := fn.RelationDirection
 	if direction == "" {
 		direction = "outward"
@@ -135,11 +135,81 @@ func (f *predicateSqlizerFactory) newUserRelationFunctionalPredicateSqlizer(fn s
 }
 
 func (f *predicateSqlizerFactory) NewAccessControlSqlizer(user *skydb.AuthInfo, aclLevel skydb.RecordACLLevel) (sq.Sqlizer, error) {
-	return &accessPredicateSqlizer{
+	sqlizer := &accessPredicateSqlizer{
 		f.primaryTable,
 		user,
 		aclLevel,
-	}, nil
+	}
+	if user == nil {
+		return sqlizer, nil
+	}
+
+	or := sq.Or{sqlizer}
+
+	// group entries are matched only for users in any group, so that
+	// queries by other users are not slowed down by the join
+	if len(user.GroupIDs) > 0 {
+		or = append(or, &groupAccessPredicateSqlizer{
+			alias:            f.primaryTable,
+			groupMemberTable: f.db.TableName("_group_member"),
+			user:             user,
+			level:            aclLevel,
+		})
+	}
+
+	relations, err := f.relationAccesses()
+	if err != nil {
+		return nil, err
+	}
+	or = append(or, &relationAccessPredicateSqlizer{
+		alias:     f.primaryTable,
+		relations: relations,
+		user:      user,
+		level:     aclLevel,
+	})
+
+	// records of users who blocked the user are hidden regardless of ACL
+	return sq.And{or, &blockedOwnerPredicateSqlizer{
+		alias:      f.primaryTable,
+		blockTable: f.db.TableName("_user_block"),
+		user:       user,
+	}}, nil
+}
+
+// relationAccesses returns the builtin relations and the relation types
+// defined by the app, which are cached by the conn, with the names they
+// can be referred to in ACL entries.
+func (f *predicateSqlizerFactory) relationAccesses() ([]relationAccess, error) {
+	relationTypes, err := f.db.Conn().GetRelationTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	relations := []relationAccess{}
+	for _, names := range [][]string{{"friend", "_friend"}, {"follow", "_follow"}} {
+		relations = append(relations, relationAccess{
+			names: names,
+			table: f.db.TableName(names[1]),
+		})
+	}
+	for _, relationType := range relationTypes {
+		relations = append(relations, relationAccess{
+			names: []string{relationType.Name},
+			table: f.db.TableName(RelationTableName(relationType.Name)),
+		})
+	}
+	return relations, nil
+}
+
+// RelationTableName returns the name of the table storing relations of a
+// relation type. Relations of the builtin _friend and _follow relations
+// are stored in tables of the same name, while relations of app defined
+// types are stored in tables prefixed by _relation_.
+func RelationTableName(name string) string {
+	if skydb.IsBuiltinRelation(name) {
+		return name
+	}
+	return "_relation_" + name
 }
 
 func (f *predicateSqlizerFactory) newComparisonPredicateSqlizer(p skydb.Predicate) (sq.Sqlizer, error) {
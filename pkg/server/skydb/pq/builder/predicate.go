@@ -55,7 +55,7 @@ func (p accessPredicateSqlizer) ToSql() (string, []interface{}, error) {
 			panic("unexpected serialize error on user_id")
 		}
 
-		for _, role := range p.user.Roles {
+		for _, role := range p.user.EffectiveRoles() {
 			escapedRole, err := json.Marshal(role)
 			if err != nil {
 				panic("unexpected serialize error on role")
@@ -79,6 +79,116 @@ func (p accessPredicateSqlizer) ToSql() (string, []interface{}, error) {
 	return b.String(), args, nil
 }
 
+// groupAccessPredicateSqlizer matches records shared with any group the
+// user is a member of, by joining the group memberships of the user with
+// the group entries of the record ACL.
+//
+// The sql for record accessible by members of groups which user rickmak
+// is a member of
+// `EXISTS (SELECT 1 FROM "_group_member" AS "_gm" WHERE "_gm"."auth_id" = 'rickmak'
+// AND "_access" @> jsonb_build_array(jsonb_build_object('group_id', "_gm"."group_id")))`
+type groupAccessPredicateSqlizer struct {
+	alias            string
+	groupMemberTable string
+	user             *skydb.AuthInfo
+	level            skydb.RecordACLLevel
+}
+
+func (p groupAccessPredicateSqlizer) ToSql() (string, []interface{}, error) {
+	entry := `jsonb_build_object('group_id', "_gm"."group_id")`
+	if p.level == skydb.WriteLevel {
+		entry = `jsonb_build_object('group_id', "_gm"."group_id", 'level', 'write')`
+	}
+	sql := fmt.Sprintf(
+		`EXISTS (SELECT 1 FROM %s AS "_gm" WHERE "_gm"."auth_id" = ? AND %s @> jsonb_build_array(%s))`,
+		p.groupMemberTable,
+		fullQuoteIdentifier(p.alias, "_access"),
+		entry,
+	)
+	return sql, []interface{}{p.user.ID}, nil
+}
+
+// relationAccessPredicateSqlizer matches records shared with users
+// related to the record owner, i.e. the owner has the relation to the user.
+// Only the relations named in the relation entries of the record ACL are
+// looked up. The builtin relations are matched by their names with or
+// without the leading underscore.
+//
+// The sql for read by user rickmak, with the _friend and colleague relations
+// `EXISTS (SELECT 1 FROM jsonb_array_elements("note"."_access") AS "_ace" WHERE
+// CASE WHEN "_ace"->>'relation' IN ('friend','_friend') THEN EXISTS (SELECT 1 FROM "_friend" AS "_rel"
+// WHERE "_rel"."left_id" = "note"."_owner_id" AND "_rel"."right_id" = 'rickmak')
+// WHEN "_ace"->>'relation' IN ('colleague') THEN EXISTS (SELECT 1 FROM "_relation_colleague" AS "_rel"
+// WHERE "_rel"."left_id" = "note"."_owner_id" AND "_rel"."right_id" = 'rickmak') ELSE FALSE END)`
+type relationAccessPredicateSqlizer struct {
+	alias     string
+	relations []relationAccess
+	user      *skydb.AuthInfo
+	level     skydb.RecordACLLevel
+}
+
+// relationAccess is a relation matched by relationAccessPredicateSqlizer,
+// with the names it is referred to in ACL entries and its table.
+type relationAccess struct {
+	names []string
+	table string
+}
+
+func (p relationAccessPredicateSqlizer) ToSql() (string, []interface{}, error) {
+	if len(p.relations) == 0 {
+		return "FALSE", []interface{}{}, nil
+	}
+
+	b := bytes.Buffer{}
+	args := []interface{}{}
+
+	b.WriteString(fmt.Sprintf(
+		`EXISTS (SELECT 1 FROM jsonb_array_elements(%s) AS "_ace" WHERE `,
+		fullQuoteIdentifier(p.alias, "_access"),
+	))
+	if p.level == skydb.WriteLevel {
+		b.WriteString(`"_ace"->>'level' = ? AND `)
+		args = append(args, string(skydb.WriteLevel))
+	}
+	b.WriteString("CASE")
+	for _, relation := range p.relations {
+		b.WriteString(fmt.Sprintf(
+			` WHEN "_ace"->>'relation' IN (%s) THEN EXISTS (SELECT 1 FROM %s AS "_rel" WHERE "_rel"."left_id" = %s AND "_rel"."right_id" = ?)`,
+			sq.Placeholders(len(relation.names)),
+			relation.table,
+			fullQuoteIdentifier(p.alias, "_owner_id"),
+		))
+		for _, name := range relation.names {
+			args = append(args, name)
+		}
+		args = append(args, p.user.ID)
+	}
+	b.WriteString(" ELSE FALSE END)")
+
+	return b.String(), args, nil
+}
+
+// blockedOwnerPredicateSqlizer excludes records owned by users who have
+// blocked the user.
+//
+// The sql for user rickmak
+// `NOT EXISTS (SELECT 1 FROM "_user_block" AS "_block"
+// WHERE "_block"."blocker_id" = "note"."_owner_id" AND "_block"."blocked_id" = 'rickmak')`
+type blockedOwnerPredicateSqlizer struct {
+	alias      string
+	blockTable string
+	user       *skydb.AuthInfo
+}
+
+func (p blockedOwnerPredicateSqlizer) ToSql() (string, []interface{}, error) {
+	sql := fmt.Sprintf(
+		`NOT EXISTS (SELECT 1 FROM %s AS "_block" WHERE "_block"."blocker_id" = %s AND "_block"."blocked_id" = ?)`,
+		p.blockTable,
+		fullQuoteIdentifier(p.alias, "_owner_id"),
+	)
+	return sql, []interface{}{p.user.ID}, nil
+}
+
 type userRelationPredicateSqlizer struct {
 	outwardAlias string
 	inwardAlias  string
@@ -310,6 +310,111 @@ func TestAccessPredicateSqlizer(t *testing.T) {
 	})
 }
 
+func TestGroupAccessPredicateSqlizer(t *testing.T) {
+	Convey("group access Predicate", t, func() {
+		authinfo := skydb.AuthInfo{
+			ID:       "userid",
+			GroupIDs: []string{"group1"},
+		}
+
+		Convey("serialized for read", func() {
+			sqlizer := &groupAccessPredicateSqlizer{
+				alias:            "note",
+				groupMemberTable: `"app_test"."_group_member"`,
+				user:             &authinfo,
+				level:            skydb.ReadLevel,
+			}
+			sql, args, err := sqlizer.ToSql()
+			So(err, ShouldBeNil)
+			So(sql, ShouldEqual,
+				`EXISTS (SELECT 1 FROM "app_test"."_group_member" AS "_gm" `+
+					`WHERE "_gm"."auth_id" = ? AND `+
+					`"note"."_access" @> jsonb_build_array(jsonb_build_object('group_id', "_gm"."group_id")))`)
+			So(args, ShouldResemble, []interface{}{"userid"})
+		})
+
+		Convey("serialized for write", func() {
+			sqlizer := &groupAccessPredicateSqlizer{
+				alias:            "note",
+				groupMemberTable: `"app_test"."_group_member"`,
+				user:             &authinfo,
+				level:            skydb.WriteLevel,
+			}
+			sql, _, err := sqlizer.ToSql()
+			So(err, ShouldBeNil)
+			So(sql, ShouldEqual,
+				`EXISTS (SELECT 1 FROM "app_test"."_group_member" AS "_gm" `+
+					`WHERE "_gm"."auth_id" = ? AND `+
+					`"note"."_access" @> jsonb_build_array(jsonb_build_object('group_id', "_gm"."group_id", 'level', 'write')))`)
+		})
+	})
+}
+
+func TestRelationAccessPredicateSqlizer(t *testing.T) {
+	Convey("relation access Predicate", t, func() {
+		authinfo := skydb.AuthInfo{
+			ID: "userid",
+		}
+
+		relations := []relationAccess{
+			{names: []string{"friend", "_friend"}, table: `"app_test"."_friend"`},
+			{names: []string{"colleague"}, table: `"app_test"."_relation_colleague"`},
+		}
+
+		Convey("serialized for read", func() {
+			sqlizer := &relationAccessPredicateSqlizer{
+				alias:     "note",
+				relations: relations,
+				user:      &authinfo,
+				level:     skydb.ReadLevel,
+			}
+			sql, args, err := sqlizer.ToSql()
+			So(err, ShouldBeNil)
+			So(sql, ShouldEqual,
+				`EXISTS (SELECT 1 FROM jsonb_array_elements("note"."_access") AS "_ace" WHERE CASE`+
+					` WHEN "_ace"->>'relation' IN (?,?) THEN EXISTS (SELECT 1 FROM "app_test"."_friend" AS "_rel" `+
+					`WHERE "_rel"."left_id" = "note"."_owner_id" AND "_rel"."right_id" = ?)`+
+					` WHEN "_ace"->>'relation' IN (?) THEN EXISTS (SELECT 1 FROM "app_test"."_relation_colleague" AS "_rel" `+
+					`WHERE "_rel"."left_id" = "note"."_owner_id" AND "_rel"."right_id" = ?)`+
+					` ELSE FALSE END)`)
+			So(args, ShouldResemble, []interface{}{"friend", "_friend", "userid", "colleague", "userid"})
+		})
+
+		Convey("serialized for write", func() {
+			sqlizer := &relationAccessPredicateSqlizer{
+				alias:     "note",
+				relations: relations[:1],
+				user:      &authinfo,
+				level:     skydb.WriteLevel,
+			}
+			sql, args, err := sqlizer.ToSql()
+			So(err, ShouldBeNil)
+			So(sql, ShouldEqual,
+				`EXISTS (SELECT 1 FROM jsonb_array_elements("note"."_access") AS "_ace" WHERE "_ace"->>'level' = ? AND CASE`+
+					` WHEN "_ace"->>'relation' IN (?,?) THEN EXISTS (SELECT 1 FROM "app_test"."_friend" AS "_rel" `+
+					`WHERE "_rel"."left_id" = "note"."_owner_id" AND "_rel"."right_id" = ?)`+
+					` ELSE FALSE END)`)
+			So(args, ShouldResemble, []interface{}{"write", "friend", "_friend", "userid"})
+		})
+	})
+}
+
+func TestBlockedOwnerPredicateSqlizer(t *testing.T) {
+	Convey("blocked owner Predicate", t, func() {
+		sqlizer := &blockedOwnerPredicateSqlizer{
+			alias:      "note",
+			blockTable: `"app_test"."_user_block"`,
+			user:       &skydb.AuthInfo{ID: "userid"},
+		}
+		sql, args, err := sqlizer.ToSql()
+		So(err, ShouldBeNil)
+		So(sql, ShouldEqual,
+			`NOT EXISTS (SELECT 1 FROM "app_test"."_user_block" AS "_block" `+
+				`WHERE "_block"."blocker_id" = "note"."_owner_id" AND "_block"."blocked_id" = ?)`)
+		So(args, ShouldResemble, []interface{}{"userid"})
+	})
+}
+
 func TestDistancePredicateSqlizer(t *testing.T) {
 	Convey("distance predicate", t, func() {
 		Convey("serialized", func() {
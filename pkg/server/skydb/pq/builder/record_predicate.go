@@ -0,0 +1,42 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package builder
+
+import (
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
+)
+
+// CompileRecordPredicate parses and validates a record predicate declared
+// for a record type, in the format of record query predicate. The result
+// is bound to the current user by skydb.Predicate.BindCurrentUser before
+// creating its sqlizer. An empty predicate is compiled to an empty
+// skydb.Predicate.
+func CompileRecordPredicate(rawPredicate []interface{}) (skydb.Predicate, error) {
+	if len(rawPredicate) == 0 {
+		return skydb.Predicate{}, nil
+	}
+
+	parser := skyconv.PredicateParser{}
+	predicate, err := parser.ParsePredicate(rawPredicate)
+	if err != nil {
+		return skydb.Predicate{}, err
+	}
+
+	if err := predicate.Validate(); err != nil {
+		return skydb.Predicate{}, err
+	}
+	return predicate, nil
+}
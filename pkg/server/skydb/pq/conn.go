@@ -81,6 +81,8 @@ type conn struct {
 	tx                     *sqlx.Tx // transaction wrapper, nil when no transaction
 	RecordSchema           map[string]skydb.RecordSchema
 	FieldACL               *skydb.FieldACL
+	recordPredicateAccess  map[string]compiledRecordPredicateAccess
+	relationTypes          []skydb.RelationType
 	appName                string
 	option                 string
 	statementCount         uint64
@@ -0,0 +1,235 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/lann/squirrel"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+func (c *conn) CreateGroup(group *skydb.Group) error {
+	if group.CreatedAt.IsZero() {
+		group.CreatedAt = time.Now().UTC()
+	}
+
+	builder := psql.Insert(c.tableName("_group")).Columns(
+		"id",
+		"name",
+		"created_by",
+		"created_at",
+	).Values(
+		group.ID,
+		group.Name,
+		nullString(group.CreatedBy),
+		group.CreatedAt,
+	)
+
+	_, err := c.ExecWith(builder)
+	if isUniqueViolated(err) {
+		return fmt.Errorf("skydb: duplicated group %s", group.ID)
+	}
+	return err
+}
+
+func (c *conn) GetGroup(id string, group *skydb.Group) error {
+	builder := psql.Select("id", "name", "created_by", "created_at").
+		From(c.tableName("_group")).
+		Where("id = ?", id)
+
+	return c.doScanGroup(group, c.QueryRowWith(builder))
+}
+
+func (c *conn) DeleteGroup(id string) error {
+	builder := psql.Delete(c.tableName("_group")).
+		Where("id = ?", id)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrGroupNotFound
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows deleted, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) QueryGroupsByMember(userID string) ([]skydb.Group, error) {
+	builder := psql.Select("g.id", "g.name", "g.created_by", "g.created_at").
+		From(c.tableName("_group")+" AS g").
+		Join(c.tableName("_group_member")+" AS m ON m.group_id = g.id").
+		Where("m.auth_id = ?", userID).
+		OrderBy("g.name", "g.id")
+
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []skydb.Group{}
+	for rows.Next() {
+		group := skydb.Group{}
+		if err := c.doScanGroup(&group, rows); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+func (c *conn) doScanGroup(group *skydb.Group, scanner sq.RowScanner) error {
+	var createdBy sql.NullString
+	err := scanner.Scan(
+		&group.ID,
+		&group.Name,
+		&createdBy,
+		&group.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrGroupNotFound
+	} else if err != nil {
+		return err
+	}
+
+	group.CreatedBy = createdBy.String
+	return nil
+}
+
+func (c *conn) SetGroupMember(membership *skydb.GroupMembership) error {
+	if membership.CreatedAt.IsZero() {
+		membership.CreatedAt = time.Now().UTC()
+	}
+
+	builder := psql.Insert(c.tableName("_group_member")).Columns(
+		"group_id",
+		"auth_id",
+		"role",
+		"created_at",
+	).Values(
+		membership.GroupID,
+		membership.UserID,
+		string(membership.Role),
+		membership.CreatedAt,
+	).Suffix("ON CONFLICT (group_id, auth_id) DO UPDATE SET role = EXCLUDED.role")
+
+	_, err := c.ExecWith(builder)
+	if isForeignKeyViolated(err) {
+		return skydb.ErrGroupNotFound
+	}
+	return err
+}
+
+func (c *conn) GetGroupMember(groupID string, userID string, membership *skydb.GroupMembership) error {
+	builder := psql.Select("group_id", "auth_id", "role", "created_at").
+		From(c.tableName("_group_member")).
+		Where("group_id = ? AND auth_id = ?", groupID, userID)
+
+	return c.doScanGroupMember(membership, c.QueryRowWith(builder))
+}
+
+func (c *conn) RemoveGroupMember(groupID string, userID string) error {
+	builder := psql.Delete(c.tableName("_group_member")).
+		Where("group_id = ? AND auth_id = ?", groupID, userID)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrGroupMemberNotFound
+	}
+	return nil
+}
+
+func (c *conn) QueryGroupMembers(groupID string) ([]skydb.GroupMembership, error) {
+	builder := psql.Select("group_id", "auth_id", "role", "created_at").
+		From(c.tableName("_group_member")).
+		Where("group_id = ?", groupID).
+		OrderBy("created_at", "auth_id")
+
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := []skydb.GroupMembership{}
+	for rows.Next() {
+		membership := skydb.GroupMembership{}
+		if err := c.doScanGroupMember(&membership, rows); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, membership)
+	}
+	return memberships, rows.Err()
+}
+
+func (c *conn) doScanGroupMember(membership *skydb.GroupMembership, scanner sq.RowScanner) error {
+	var role string
+	err := scanner.Scan(
+		&membership.GroupID,
+		&membership.UserID,
+		&role,
+		&membership.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrGroupMemberNotFound
+	} else if err != nil {
+		return err
+	}
+
+	membership.Role = skydb.GroupMemberRole(role)
+	return nil
+}
+
+// populateGroupIDs sets the groups the user is a member of, so that
+// record ACL entries of groups can be checked.
+func (c *conn) populateGroupIDs(authinfo *skydb.AuthInfo) error {
+	builder := psql.Select("group_id").
+		From(c.tableName("_group_member")).
+		Where("auth_id = ?", authinfo.ID).
+		OrderBy("group_id")
+
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var groupIDs []string
+	for rows.Next() {
+		var groupID string
+		if err := rows.Scan(&groupID); err != nil {
+			return err
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+	authinfo.GroupIDs = groupIDs
+	return rows.Err()
+}
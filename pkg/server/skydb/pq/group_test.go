@@ -0,0 +1,133 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGroupConn(t *testing.T) {
+	var c *conn
+
+	Convey("Conn", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		createdAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		So(c.CreateAuth(&skydb.AuthInfo{ID: "owner"}), ShouldBeNil)
+		So(c.CreateAuth(&skydb.AuthInfo{ID: "member"}), ShouldBeNil)
+
+		group := skydb.Group{
+			ID:        "group-id",
+			Name:      "Editors",
+			CreatedBy: "owner",
+			CreatedAt: createdAt,
+		}
+		So(c.CreateGroup(&group), ShouldBeNil)
+
+		Convey("get group", func() {
+			fetched := skydb.Group{}
+			So(c.GetGroup("group-id", &fetched), ShouldBeNil)
+			So(fetched, ShouldResemble, group)
+		})
+
+		Convey("get non-existent group", func() {
+			fetched := skydb.Group{}
+			So(c.GetGroup("not-exist", &fetched), ShouldEqual, skydb.ErrGroupNotFound)
+		})
+
+		Convey("delete group", func() {
+			So(c.SetGroupMember(&skydb.GroupMembership{
+				GroupID: "group-id",
+				UserID:  "member",
+				Role:    skydb.GroupMember,
+			}), ShouldBeNil)
+
+			So(c.DeleteGroup("group-id"), ShouldBeNil)
+			So(c.GetGroup("group-id", &skydb.Group{}), ShouldEqual, skydb.ErrGroupNotFound)
+
+			memberships, err := c.QueryGroupMembers("group-id")
+			So(err, ShouldBeNil)
+			So(memberships, ShouldBeEmpty)
+
+			So(c.DeleteGroup("group-id"), ShouldEqual, skydb.ErrGroupNotFound)
+		})
+
+		Convey("set, update and remove group member", func() {
+			membership := skydb.GroupMembership{
+				GroupID:   "group-id",
+				UserID:    "member",
+				Role:      skydb.GroupMember,
+				CreatedAt: createdAt,
+			}
+			So(c.SetGroupMember(&membership), ShouldBeNil)
+
+			membership.Role = skydb.GroupAdmin
+			So(c.SetGroupMember(&membership), ShouldBeNil)
+
+			fetched := skydb.GroupMembership{}
+			So(c.GetGroupMember("group-id", "member", &fetched), ShouldBeNil)
+			So(fetched, ShouldResemble, membership)
+
+			memberships, err := c.QueryGroupMembers("group-id")
+			So(err, ShouldBeNil)
+			So(memberships, ShouldResemble, []skydb.GroupMembership{membership})
+
+			So(c.RemoveGroupMember("group-id", "member"), ShouldBeNil)
+			So(c.GetGroupMember("group-id", "member", &fetched), ShouldEqual, skydb.ErrGroupMemberNotFound)
+			So(c.RemoveGroupMember("group-id", "member"), ShouldEqual, skydb.ErrGroupMemberNotFound)
+		})
+
+		Convey("set member of non-existent group", func() {
+			err := c.SetGroupMember(&skydb.GroupMembership{
+				GroupID: "not-exist",
+				UserID:  "member",
+				Role:    skydb.GroupMember,
+			})
+			So(err, ShouldEqual, skydb.ErrGroupNotFound)
+		})
+
+		Convey("query groups by member and populate group ids on get auth", func() {
+			other := skydb.Group{
+				ID:        "other-group-id",
+				Name:      "Authors",
+				CreatedAt: createdAt,
+			}
+			So(c.CreateGroup(&other), ShouldBeNil)
+			for _, groupID := range []string{"group-id", "other-group-id"} {
+				So(c.SetGroupMember(&skydb.GroupMembership{
+					GroupID: groupID,
+					UserID:  "member",
+					Role:    skydb.GroupMember,
+				}), ShouldBeNil)
+			}
+
+			groups, err := c.QueryGroupsByMember("member")
+			So(err, ShouldBeNil)
+			So(groups, ShouldResemble, []skydb.Group{other, group})
+
+			authinfo := skydb.AuthInfo{}
+			So(c.GetAuth("member", &authinfo), ShouldBeNil)
+			So(authinfo.GroupIDs, ShouldResemble, []string{"group-id", "other-group-id"})
+
+			So(c.GetAuth("owner", &authinfo), ShouldBeNil)
+			So(authinfo.GroupIDs, ShouldBeNil)
+		})
+	})
+}
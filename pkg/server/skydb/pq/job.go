@@ -0,0 +1,245 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/lann/squirrel"
+	"github.com/lib/pq"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var jobColumns = []string{
+	"id",
+	"lambda",
+	"args",
+	"user_id",
+	"status",
+	"attempts",
+	"max_attempts",
+	"run_at",
+	"locked_until",
+	"last_error",
+	"result",
+	"created_at",
+	"completed_at",
+}
+
+func (c *conn) CreateJob(job *skydb.Job) error {
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now().UTC()
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = job.CreatedAt
+	}
+
+	builder := psql.Insert(c.tableName("_job")).Columns(jobColumns...).Values(
+		job.ID,
+		job.Lambda,
+		jsonArgs(job.Args),
+		nullString(job.UserID),
+		string(job.Status),
+		job.Attempts,
+		job.MaxAttempts,
+		job.RunAt,
+		job.LockedUntil,
+		nullString(job.LastError),
+		nullString(string(job.Result)),
+		job.CreatedAt,
+		job.CompletedAt,
+	)
+
+	_, err := c.ExecWith(builder)
+	if isUniqueViolated(err) {
+		return fmt.Errorf("skydb: duplicated job %s", job.ID)
+	}
+	return err
+}
+
+func (c *conn) GetJob(id string, job *skydb.Job) error {
+	builder := psql.Select(jobColumns...).
+		From(c.tableName("_job")).
+		Where("id = ?", id)
+
+	return c.doScanJob(job, c.QueryRowWith(builder))
+}
+
+func (c *conn) UpdateJob(job *skydb.Job) error {
+	builder := psql.Update(c.tableName("_job")).
+		Set("status", string(job.Status)).
+		Set("attempts", job.Attempts).
+		Set("max_attempts", job.MaxAttempts).
+		Set("run_at", job.RunAt).
+		Set("locked_until", job.LockedUntil).
+		Set("last_error", nullString(job.LastError)).
+		Set("result", nullString(string(job.Result))).
+		Set("completed_at", job.CompletedAt).
+		Where("id = ?", job.ID)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrJobNotFound
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) QueryJobs(status skydb.JobStatus, limit uint64) ([]skydb.Job, error) {
+	builder := psql.Select(jobColumns...).
+		From(c.tableName("_job")).
+		OrderBy("created_at DESC", "id").
+		Limit(limit)
+	if status != "" {
+		builder = builder.Where("status = ?", string(status))
+	}
+
+	return c.queryJobs(builder)
+}
+
+// ClaimJob locks the due job with SKIP LOCKED, so that concurrent server
+// instances claim different jobs.
+func (c *conn) ClaimJob(now time.Time, lockedUntil time.Time, job *skydb.Job) error {
+	table := c.tableName("_job")
+	builder := psql.Update(table).
+		Set("status", string(skydb.JobRunning)).
+		Set("locked_until", lockedUntil).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Where(fmt.Sprintf(`id = (
+			SELECT id FROM %s
+			WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)`, table), string(skydb.JobPending), now, string(skydb.JobRunning), now).
+		Suffix("RETURNING " + strings.Join(jobColumns, ", "))
+
+	return c.doScanJob(job, c.QueryRowWith(builder))
+}
+
+// UpdateClaimedJob fences the update with the lock and the attempts of
+// the claim, which are changed whenever the job is claimed again.
+func (c *conn) UpdateClaimedJob(job *skydb.Job, lockedUntil time.Time) error {
+	builder := psql.Update(c.tableName("_job")).
+		Set("status", string(job.Status)).
+		Set("attempts", job.Attempts).
+		Set("max_attempts", job.MaxAttempts).
+		Set("run_at", job.RunAt).
+		Set("locked_until", job.LockedUntil).
+		Set("last_error", nullString(job.LastError)).
+		Set("result", nullString(string(job.Result))).
+		Set("completed_at", job.CompletedAt).
+		Where("id = ? AND status = ? AND attempts = ? AND locked_until = ?",
+			job.ID, string(skydb.JobRunning), job.Attempts, lockedUntil)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		if err := c.GetJob(job.ID, &skydb.Job{}); err != nil {
+			return err
+		}
+		return skydb.ErrLeaseLost
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) queryJobs(builder sq.Sqlizer) ([]skydb.Job, error) {
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []skydb.Job{}
+	for rows.Next() {
+		job := skydb.Job{}
+		if err := c.doScanJob(&job, rows); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+func (c *conn) doScanJob(job *skydb.Job, scanner sq.RowScanner) error {
+	var (
+		args        []byte
+		userID      sql.NullString
+		status      string
+		lockedUntil pq.NullTime
+		lastError   sql.NullString
+		result      []byte
+		completedAt pq.NullTime
+	)
+	err := scanner.Scan(
+		&job.ID,
+		&job.Lambda,
+		&args,
+		&userID,
+		&status,
+		&job.Attempts,
+		&job.MaxAttempts,
+		&job.RunAt,
+		&lockedUntil,
+		&lastError,
+		&result,
+		&job.CreatedAt,
+		&completedAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrJobNotFound
+	} else if err != nil {
+		return err
+	}
+
+	job.Args = args
+	job.UserID = userID.String
+	job.Status = skydb.JobStatus(status)
+	job.LockedUntil = nullTimePtr(lockedUntil)
+	job.LastError = lastError.String
+	if len(result) > 0 {
+		job.Result = result
+	}
+	job.CompletedAt = nullTimePtr(completedAt)
+	return nil
+}
+
+// jsonArgs converts empty args to a JSON null, as args is not nullable.
+func jsonArgs(args json.RawMessage) string {
+	if len(args) == 0 {
+		return "null"
+	}
+	return string(args)
+}
@@ -0,0 +1,142 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/skygeario/skygear-server/pkg/server/skytest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJobConn(t *testing.T) {
+	var c *conn
+
+	Convey("Conn", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		createdAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		job := skydb.Job{
+			ID:          "job-id",
+			Lambda:      "send_email",
+			Args:        json.RawMessage(`{"to":"someone@example.com"}`),
+			UserID:      "user-id",
+			Status:      skydb.JobPending,
+			MaxAttempts: 3,
+			CreatedAt:   createdAt,
+		}
+
+		Convey("create and update job", func() {
+			So(c.CreateJob(&job), ShouldBeNil)
+
+			fetched := skydb.Job{}
+			So(c.GetJob("job-id", &fetched), ShouldBeNil)
+			So(fetched.Lambda, ShouldEqual, "send_email")
+			So([]byte(fetched.Args), ShouldEqualJSON, `{"to":"someone@example.com"}`)
+			So(fetched.UserID, ShouldEqual, "user-id")
+			So(fetched.Status, ShouldEqual, skydb.JobPending)
+			So(fetched.MaxAttempts, ShouldEqual, 3)
+			So(fetched.RunAt.Unix(), ShouldEqual, createdAt.Unix())
+			So(fetched.LockedUntil, ShouldBeNil)
+			So(fetched.Result, ShouldBeNil)
+			So(fetched.CompletedAt, ShouldBeNil)
+
+			completedAt := createdAt.Add(time.Minute)
+			job.Status = skydb.JobSucceeded
+			job.Attempts = 1
+			job.Result = json.RawMessage(`{"sent":true}`)
+			job.CompletedAt = &completedAt
+			So(c.UpdateJob(&job), ShouldBeNil)
+			So(c.GetJob("job-id", &fetched), ShouldBeNil)
+			So(fetched.Status, ShouldEqual, skydb.JobSucceeded)
+			So(fetched.Attempts, ShouldEqual, 1)
+			So([]byte(fetched.Result), ShouldEqualJSON, `{"sent":true}`)
+			So(fetched.CompletedAt.Unix(), ShouldEqual, completedAt.Unix())
+
+			jobs, err := c.QueryJobs(skydb.JobSucceeded, 10)
+			So(err, ShouldBeNil)
+			So(jobs, ShouldHaveLength, 1)
+			jobs, err = c.QueryJobs(skydb.JobDead, 10)
+			So(err, ShouldBeNil)
+			So(jobs, ShouldBeEmpty)
+		})
+
+		Convey("claim due and abandoned jobs", func() {
+			now := createdAt.Add(time.Hour)
+			lockedUntil := now.Add(time.Minute)
+			abandoned := createdAt.Add(time.Minute)
+
+			So(c.CreateJob(&job), ShouldBeNil)
+			So(c.CreateJob(&skydb.Job{
+				ID:          "later",
+				Lambda:      "send_email",
+				Status:      skydb.JobPending,
+				MaxAttempts: 3,
+				RunAt:       now.Add(time.Hour),
+				CreatedAt:   createdAt,
+			}), ShouldBeNil)
+			So(c.CreateJob(&skydb.Job{
+				ID:          "abandoned",
+				Lambda:      "send_email",
+				Status:      skydb.JobRunning,
+				Attempts:    1,
+				MaxAttempts: 3,
+				LockedUntil: &abandoned,
+				CreatedAt:   createdAt,
+			}), ShouldBeNil)
+			So(c.CreateJob(&skydb.Job{
+				ID:          "dead",
+				Lambda:      "send_email",
+				Status:      skydb.JobDead,
+				Attempts:    3,
+				MaxAttempts: 3,
+				CreatedAt:   createdAt,
+			}), ShouldBeNil)
+
+			attempts := map[string]int{}
+			claimed := skydb.Job{}
+			for i := 0; i < 2; i++ {
+				So(c.ClaimJob(now, lockedUntil, &claimed), ShouldBeNil)
+				So(claimed.Status, ShouldEqual, skydb.JobRunning)
+				So(claimed.LockedUntil.Unix(), ShouldEqual, lockedUntil.Unix())
+				attempts[claimed.ID] = claimed.Attempts
+			}
+			So(attempts, ShouldResemble, map[string]int{
+				"job-id":    1,
+				"abandoned": 2,
+			})
+
+			So(c.ClaimJob(now, lockedUntil, &skydb.Job{}), ShouldEqual, skydb.ErrJobNotFound)
+
+			claimedUntil := *claimed.LockedUntil
+			claimed.Status = skydb.JobSucceeded
+			claimed.LockedUntil = nil
+			So(c.UpdateClaimedJob(&claimed, claimedUntil.Add(time.Second)), ShouldEqual, skydb.ErrLeaseLost)
+			So(c.UpdateClaimedJob(&claimed, claimedUntil), ShouldBeNil)
+			So(c.UpdateClaimedJob(&claimed, claimedUntil), ShouldEqual, skydb.ErrLeaseLost)
+			So(c.UpdateClaimedJob(&skydb.Job{ID: "unknown"}, claimedUntil), ShouldEqual, skydb.ErrJobNotFound)
+		})
+
+		Convey("return not found for unknown job", func() {
+			fetched := skydb.Job{}
+			So(c.GetJob("unknown", &fetched), ShouldEqual, skydb.ErrJobNotFound)
+			So(c.UpdateJob(&skydb.Job{ID: "unknown"}), ShouldEqual, skydb.ErrJobNotFound)
+		})
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_03779a1ff7b0 struct {
+}
+
+func (r *revision_03779a1ff7b0) Version() string {
+	return "03779a1ff7b0"
+}
+
+func (r *revision_03779a1ff7b0) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _oauth_client (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		hashed_secret TEXT,
+		redirect_uris TEXT[] NOT NULL,
+		grant_types TEXT[] NOT NULL,
+		scopes TEXT[] NOT NULL,
+		created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+	);
+
+	CREATE TABLE _oauth_authorization_code (
+		code TEXT PRIMARY KEY,
+		client_id TEXT REFERENCES _oauth_client (id) ON DELETE CASCADE NOT NULL,
+		user_id TEXT REFERENCES _auth (id) ON DELETE CASCADE NOT NULL,
+		redirect_uri TEXT NOT NULL,
+		scopes TEXT[] NOT NULL,
+		nonce TEXT,
+		code_challenge TEXT,
+		code_challenge_method TEXT,
+		expire_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+		created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+	);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_03779a1ff7b0) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _oauth_authorization_code;
+	DROP TABLE _oauth_client;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -0,0 +1,64 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_1a5b72ce1437 struct {
+}
+
+func (r *revision_1a5b72ce1437) Version() string {
+	return "1a5b72ce1437"
+}
+
+func (r *revision_1a5b72ce1437) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _webhook (
+		id text PRIMARY KEY,
+		url text NOT NULL,
+		secret text NOT NULL,
+		record_type text,
+		events text[] NOT NULL,
+		disabled boolean NOT NULL DEFAULT FALSE,
+		created_at timestamp without time zone NOT NULL
+	);
+	CREATE TABLE _webhook_delivery (
+		id text PRIMARY KEY,
+		webhook_id text NOT NULL REFERENCES _webhook (id) ON DELETE CASCADE,
+		event text NOT NULL,
+		payload jsonb NOT NULL,
+		status text NOT NULL,
+		attempts integer NOT NULL DEFAULT 0,
+		next_attempt_at timestamp without time zone NOT NULL,
+		last_attempt_at timestamp without time zone,
+		last_error text,
+		response_status integer NOT NULL DEFAULT 0,
+		created_at timestamp without time zone NOT NULL
+	);
+	CREATE INDEX _webhook_delivery_pending_idx ON _webhook_delivery (next_attempt_at) WHERE status = 'pending';
+	CREATE INDEX _webhook_delivery_webhook_id_idx ON _webhook_delivery (webhook_id, created_at);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_1a5b72ce1437) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _webhook_delivery;
+	DROP TABLE _webhook;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -0,0 +1,44 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_2060fa3347c6 struct {
+}
+
+func (r *revision_2060fa3347c6) Version() string {
+	return "2060fa3347c6"
+}
+
+func (r *revision_2060fa3347c6) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _record_predicate_access (
+		record_type text PRIMARY KEY,
+		read_predicate jsonb,
+		write_predicate jsonb
+	);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_2060fa3347c6) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _record_predicate_access;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -0,0 +1,44 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_342591964ab2 struct {
+}
+
+func (r *revision_342591964ab2) Version() string {
+	return "342591964ab2"
+}
+
+func (r *revision_342591964ab2) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _role_permission (
+		role_id text REFERENCES _role (id) NOT NULL,
+		action text NOT NULL,
+		PRIMARY KEY (role_id, action)
+	);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_342591964ab2) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _role_permission;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -0,0 +1,45 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_3b9d51a7e4c0 struct {
+}
+
+func (r *revision_3b9d51a7e4c0) Version() string {
+	return "3b9d51a7e4c0"
+}
+
+func (r *revision_3b9d51a7e4c0) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _user_block (
+		blocker_id text NOT NULL REFERENCES _auth (id) ON DELETE CASCADE,
+		blocked_id text NOT NULL REFERENCES _auth (id) ON DELETE CASCADE,
+		created_at timestamp without time zone NOT NULL,
+		PRIMARY KEY (blocker_id, blocked_id)
+	);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_3b9d51a7e4c0) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _user_block;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -0,0 +1,44 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_50575890ea1e struct {
+}
+
+func (r *revision_50575890ea1e) Version() string {
+	return "50575890ea1e"
+}
+
+func (r *revision_50575890ea1e) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _role_inheritance (
+		role_id text REFERENCES _role (id) NOT NULL,
+		inherited_role_id text REFERENCES _role (id) NOT NULL,
+		PRIMARY KEY (role_id, inherited_role_id)
+	);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_50575890ea1e) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _role_inheritance;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -0,0 +1,47 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_5c2f1e8b7a94 struct {
+}
+
+func (r *revision_5c2f1e8b7a94) Version() string {
+	return "5c2f1e8b7a94"
+}
+
+func (r *revision_5c2f1e8b7a94) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _user_data_export (
+		asset_name text PRIMARY KEY,
+		user_id text NOT NULL,
+		created_at timestamp without time zone NOT NULL,
+		expired_at timestamp without time zone NOT NULL
+	);
+	CREATE INDEX _user_data_export_user_id_idx ON _user_data_export (user_id);
+	CREATE INDEX _user_data_export_expired_at_idx ON _user_data_export (expired_at);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_5c2f1e8b7a94) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _user_data_export;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
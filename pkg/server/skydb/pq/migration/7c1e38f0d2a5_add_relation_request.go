@@ -0,0 +1,49 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_7c1e38f0d2a5 struct {
+}
+
+func (r *revision_7c1e38f0d2a5) Version() string {
+	return "7c1e38f0d2a5"
+}
+
+func (r *revision_7c1e38f0d2a5) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _relation_request (
+		name text NOT NULL,
+		requester_id text NOT NULL REFERENCES _auth (id) ON DELETE CASCADE,
+		recipient_id text NOT NULL REFERENCES _auth (id) ON DELETE CASCADE,
+		state text NOT NULL,
+		created_at timestamp without time zone NOT NULL,
+		updated_at timestamp without time zone NOT NULL,
+		PRIMARY KEY (name, requester_id, recipient_id)
+	);
+	CREATE INDEX _relation_request_recipient_idx ON _relation_request (recipient_id, name, state);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_7c1e38f0d2a5) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _relation_request;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -0,0 +1,50 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_86f5945dcd86 struct {
+}
+
+func (r *revision_86f5945dcd86) Version() string {
+	return "86f5945dcd86"
+}
+
+func (r *revision_86f5945dcd86) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _timer (
+		name text PRIMARY KEY,
+		spec text NOT NULL,
+		catch_up boolean NOT NULL DEFAULT FALSE,
+		next_run_at timestamp without time zone NOT NULL,
+		locked_until timestamp without time zone,
+		last_run_at timestamp without time zone,
+		last_finished_at timestamp without time zone,
+		last_status text,
+		last_error text
+	);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_86f5945dcd86) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _timer;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
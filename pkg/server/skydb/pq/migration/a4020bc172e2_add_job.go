@@ -0,0 +1,57 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_a4020bc172e2 struct {
+}
+
+func (r *revision_a4020bc172e2) Version() string {
+	return "a4020bc172e2"
+}
+
+func (r *revision_a4020bc172e2) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _job (
+		id text PRIMARY KEY,
+		lambda text NOT NULL,
+		args jsonb NOT NULL,
+		user_id text,
+		status text NOT NULL,
+		attempts integer NOT NULL DEFAULT 0,
+		max_attempts integer NOT NULL,
+		run_at timestamp without time zone NOT NULL,
+		locked_until timestamp without time zone,
+		last_error text,
+		result jsonb,
+		created_at timestamp without time zone NOT NULL,
+		completed_at timestamp without time zone
+	);
+	CREATE INDEX _job_run_at_idx ON _job (run_at) WHERE status = 'pending';
+	CREATE INDEX _job_locked_until_idx ON _job (locked_until) WHERE status = 'running';
+	CREATE INDEX _job_status_idx ON _job (status, created_at);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_a4020bc172e2) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _job;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
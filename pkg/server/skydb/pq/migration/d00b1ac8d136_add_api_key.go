@@ -0,0 +1,53 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_d00b1ac8d136 struct {
+}
+
+func (r *revision_d00b1ac8d136) Version() string {
+	return "d00b1ac8d136"
+}
+
+func (r *revision_d00b1ac8d136) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _api_key (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		hashed_key TEXT NOT NULL UNIQUE,
+		previous_hashed_key TEXT,
+		previous_key_expire_at TIMESTAMP WITHOUT TIME ZONE,
+		scopes TEXT[] NOT NULL,
+		allowed_origins TEXT[] NOT NULL,
+		rate_limit INTEGER NOT NULL DEFAULT 0,
+		expire_at TIMESTAMP WITHOUT TIME ZONE,
+		revoked_at TIMESTAMP WITHOUT TIME ZONE,
+		created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+	);
+	CREATE INDEX _api_key_previous_hashed_key_idx ON _api_key (previous_hashed_key);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_d00b1ac8d136) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _api_key;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -0,0 +1,53 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_eda5e9f67983 struct {
+}
+
+func (r *revision_eda5e9f67983) Version() string {
+	return "eda5e9f67983"
+}
+
+func (r *revision_eda5e9f67983) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _relation_type (
+		name text PRIMARY KEY,
+		direction text NOT NULL,
+		fields jsonb,
+		created_at timestamp without time zone NOT NULL
+	);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_eda5e9f67983) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DO $$
+	DECLARE
+		r record;
+	BEGIN
+		FOR r IN SELECT name FROM _relation_type LOOP
+			EXECUTE format('DROP TABLE IF EXISTS %I', '_relation_' || r.name);
+		END LOOP;
+	END $$;
+	DROP TABLE _relation_type;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
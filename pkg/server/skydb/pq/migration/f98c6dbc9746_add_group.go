@@ -0,0 +1,54 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migration
+
+import "github.com/jmoiron/sqlx"
+
+type revision_f98c6dbc9746 struct {
+}
+
+func (r *revision_f98c6dbc9746) Version() string {
+	return "f98c6dbc9746"
+}
+
+func (r *revision_f98c6dbc9746) Up(tx *sqlx.Tx) error {
+	stmt := `
+	CREATE TABLE _group (
+		id text PRIMARY KEY,
+		name text NOT NULL,
+		created_by text REFERENCES _auth (id) ON DELETE SET NULL,
+		created_at timestamp without time zone NOT NULL
+	);
+	CREATE TABLE _group_member (
+		group_id text REFERENCES _group (id) ON DELETE CASCADE NOT NULL,
+		auth_id text REFERENCES _auth (id) ON DELETE CASCADE NOT NULL,
+		role text NOT NULL,
+		created_at timestamp without time zone NOT NULL,
+		PRIMARY KEY (group_id, auth_id)
+	);
+	CREATE INDEX _group_member_auth_id_idx ON _group_member (auth_id);
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
+
+func (r *revision_f98c6dbc9746) Down(tx *sqlx.Tx) error {
+	stmt := `
+	DROP TABLE _group_member;
+	DROP TABLE _group;
+	`
+	_, err := tx.Exec(stmt)
+	return err
+}
@@ -23,7 +23,7 @@ import (
 type fullMigration struct {
 }
 
-func (r *fullMigration) Version() string { return "7469be11899e" }
+func (r *fullMigration) Version() string { return "5c2f1e8b7a94" }
 
 func (r *fullMigration) createTable(tx *sqlx.Tx) error {
 	const stmt = `
@@ -220,6 +220,164 @@ CREATE TABLE _verify_code (
 	created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
 );
 CREATE INDEX ON _verify_code (auth_id, code, consumed);
+
+CREATE TABLE _oauth_client (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	hashed_secret TEXT,
+	redirect_uris TEXT[] NOT NULL,
+	grant_types TEXT[] NOT NULL,
+	scopes TEXT[] NOT NULL,
+	created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE TABLE _oauth_authorization_code (
+	code TEXT PRIMARY KEY,
+	client_id TEXT REFERENCES _oauth_client (id) ON DELETE CASCADE NOT NULL,
+	user_id TEXT REFERENCES _auth (id) ON DELETE CASCADE NOT NULL,
+	redirect_uri TEXT NOT NULL,
+	scopes TEXT[] NOT NULL,
+	nonce TEXT,
+	code_challenge TEXT,
+	code_challenge_method TEXT,
+	expire_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+
+CREATE TABLE _api_key (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	hashed_key TEXT NOT NULL UNIQUE,
+	previous_hashed_key TEXT,
+	previous_key_expire_at TIMESTAMP WITHOUT TIME ZONE,
+	scopes TEXT[] NOT NULL,
+	allowed_origins TEXT[] NOT NULL,
+	rate_limit INTEGER NOT NULL DEFAULT 0,
+	expire_at TIMESTAMP WITHOUT TIME ZONE,
+	revoked_at TIMESTAMP WITHOUT TIME ZONE,
+	created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL
+);
+CREATE INDEX _api_key_previous_hashed_key_idx ON _api_key (previous_hashed_key);
+
+CREATE TABLE _role_inheritance (
+	role_id text REFERENCES _role (id) NOT NULL,
+	inherited_role_id text REFERENCES _role (id) NOT NULL,
+	PRIMARY KEY (role_id, inherited_role_id)
+);
+
+CREATE TABLE _role_permission (
+	role_id text REFERENCES _role (id) NOT NULL,
+	action text NOT NULL,
+	PRIMARY KEY (role_id, action)
+);
+
+CREATE TABLE _group (
+	id text PRIMARY KEY,
+	name text NOT NULL,
+	created_by text REFERENCES _auth (id) ON DELETE SET NULL,
+	created_at timestamp without time zone NOT NULL
+);
+CREATE TABLE _group_member (
+	group_id text REFERENCES _group (id) ON DELETE CASCADE NOT NULL,
+	auth_id text REFERENCES _auth (id) ON DELETE CASCADE NOT NULL,
+	role text NOT NULL,
+	created_at timestamp without time zone NOT NULL,
+	PRIMARY KEY (group_id, auth_id)
+);
+CREATE INDEX _group_member_auth_id_idx ON _group_member (auth_id);
+
+CREATE TABLE _record_predicate_access (
+	record_type text PRIMARY KEY,
+	read_predicate jsonb,
+	write_predicate jsonb
+);
+
+CREATE TABLE _relation_type (
+	name text PRIMARY KEY,
+	direction text NOT NULL,
+	fields jsonb,
+	created_at timestamp without time zone NOT NULL
+);
+
+CREATE TABLE _relation_request (
+	name text NOT NULL,
+	requester_id text NOT NULL REFERENCES _auth (id) ON DELETE CASCADE,
+	recipient_id text NOT NULL REFERENCES _auth (id) ON DELETE CASCADE,
+	state text NOT NULL,
+	created_at timestamp without time zone NOT NULL,
+	updated_at timestamp without time zone NOT NULL,
+	PRIMARY KEY (name, requester_id, recipient_id)
+);
+CREATE INDEX _relation_request_recipient_idx ON _relation_request (recipient_id, name, state);
+
+CREATE TABLE _user_block (
+	blocker_id text NOT NULL REFERENCES _auth (id) ON DELETE CASCADE,
+	blocked_id text NOT NULL REFERENCES _auth (id) ON DELETE CASCADE,
+	created_at timestamp without time zone NOT NULL,
+	PRIMARY KEY (blocker_id, blocked_id)
+);
+
+CREATE TABLE _webhook (
+	id text PRIMARY KEY,
+	url text NOT NULL,
+	secret text NOT NULL,
+	record_type text,
+	events text[] NOT NULL,
+	disabled boolean NOT NULL DEFAULT FALSE,
+	created_at timestamp without time zone NOT NULL
+);
+CREATE TABLE _webhook_delivery (
+	id text PRIMARY KEY,
+	webhook_id text NOT NULL REFERENCES _webhook (id) ON DELETE CASCADE,
+	event text NOT NULL,
+	payload jsonb NOT NULL,
+	status text NOT NULL,
+	attempts integer NOT NULL DEFAULT 0,
+	next_attempt_at timestamp without time zone NOT NULL,
+	last_attempt_at timestamp without time zone,
+	last_error text,
+	response_status integer NOT NULL DEFAULT 0,
+	created_at timestamp without time zone NOT NULL
+);
+CREATE INDEX _webhook_delivery_pending_idx ON _webhook_delivery (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX _webhook_delivery_webhook_id_idx ON _webhook_delivery (webhook_id, created_at);
+CREATE TABLE _job (
+	id text PRIMARY KEY,
+	lambda text NOT NULL,
+	args jsonb NOT NULL,
+	user_id text,
+	status text NOT NULL,
+	attempts integer NOT NULL DEFAULT 0,
+	max_attempts integer NOT NULL,
+	run_at timestamp without time zone NOT NULL,
+	locked_until timestamp without time zone,
+	last_error text,
+	result jsonb,
+	created_at timestamp without time zone NOT NULL,
+	completed_at timestamp without time zone
+);
+CREATE INDEX _job_run_at_idx ON _job (run_at) WHERE status = 'pending';
+CREATE INDEX _job_locked_until_idx ON _job (locked_until) WHERE status = 'running';
+CREATE INDEX _job_status_idx ON _job (status, created_at);
+CREATE TABLE _timer (
+	name text PRIMARY KEY,
+	spec text NOT NULL,
+	catch_up boolean NOT NULL DEFAULT FALSE,
+	next_run_at timestamp without time zone NOT NULL,
+	locked_until timestamp without time zone,
+	last_run_at timestamp without time zone,
+	last_finished_at timestamp without time zone,
+	last_status text,
+	last_error text
+);
+CREATE TABLE _user_data_export (
+	asset_name text PRIMARY KEY,
+	user_id text NOT NULL,
+	created_at timestamp without time zone NOT NULL,
+	expired_at timestamp without time zone NOT NULL
+);
+CREATE INDEX _user_data_export_user_id_idx ON _user_data_export (user_id);
+CREATE INDEX _user_data_export_expired_at_idx ON _user_data_export (expired_at);
 `
 	_, err := tx.Exec(stmt)
 	return err
@@ -45,4 +45,17 @@ var revisions = []Revision{
 	&revision_94ffce762644{},
 	&revision_b3163d49bd6d{},
 	&revision_7469be11899e{},
+	&revision_03779a1ff7b0{},
+	&revision_d00b1ac8d136{},
+	&revision_50575890ea1e{},
+	&revision_342591964ab2{},
+	&revision_f98c6dbc9746{},
+	&revision_2060fa3347c6{},
+	&revision_eda5e9f67983{},
+	&revision_7c1e38f0d2a5{},
+	&revision_3b9d51a7e4c0{},
+	&revision_1a5b72ce1437{},
+	&revision_a4020bc172e2{},
+	&revision_86f5945dcd86{},
+	&revision_5c2f1e8b7a94{},
 }
@@ -0,0 +1,215 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	sq "github.com/lann/squirrel"
+	"github.com/lib/pq"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+func (c *conn) CreateOAuthClient(client *skydb.OAuthClient) error {
+	createdAt := time.Now().UTC()
+	if client.CreatedAt != nil && !client.CreatedAt.IsZero() {
+		createdAt = *client.CreatedAt
+	}
+
+	var hashedSecret *string
+	if len(client.HashedSecret) > 0 {
+		s := string(client.HashedSecret)
+		hashedSecret = &s
+	}
+
+	builder := psql.Insert(c.tableName("_oauth_client")).Columns(
+		"id",
+		"name",
+		"hashed_secret",
+		"redirect_uris",
+		"grant_types",
+		"scopes",
+		"created_at",
+	).Values(
+		client.ID,
+		client.Name,
+		hashedSecret,
+		pq.StringArray(client.RedirectURIs),
+		pq.StringArray(client.GrantTypes),
+		pq.StringArray(client.Scopes),
+		createdAt,
+	)
+
+	_, err := c.ExecWith(builder)
+	if isUniqueViolated(err) {
+		return fmt.Errorf("skydb: duplicated OAuth client %s", client.ID)
+	}
+	return err
+}
+
+func (c *conn) GetOAuthClient(clientID string, client *skydb.OAuthClient) error {
+	builder := psql.Select(
+		"id",
+		"name",
+		"hashed_secret",
+		"redirect_uris",
+		"grant_types",
+		"scopes",
+		"created_at",
+	).From(c.tableName("_oauth_client")).
+		Where("id = ?", clientID)
+
+	var (
+		hashedSecret sql.NullString
+		redirectURIs pq.StringArray
+		grantTypes   pq.StringArray
+		scopes       pq.StringArray
+		createdAt    pq.NullTime
+	)
+	err := c.QueryRowWith(builder).Scan(
+		&client.ID,
+		&client.Name,
+		&hashedSecret,
+		&redirectURIs,
+		&grantTypes,
+		&scopes,
+		&createdAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrOAuthClientNotFound
+	} else if err != nil {
+		return err
+	}
+
+	client.HashedSecret = nil
+	if hashedSecret.Valid {
+		client.HashedSecret = []byte(hashedSecret.String)
+	}
+	client.RedirectURIs = []string(redirectURIs)
+	client.GrantTypes = []string(grantTypes)
+	client.Scopes = []string(scopes)
+	client.CreatedAt = nil
+	if createdAt.Valid {
+		client.CreatedAt = &createdAt.Time
+	}
+	return nil
+}
+
+func (c *conn) DeleteOAuthClient(clientID string) error {
+	builder := psql.Delete(c.tableName("_oauth_client")).
+		Where("id = ?", clientID)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrOAuthClientNotFound
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows deleted, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) CreateOAuthAuthorizationCode(code *skydb.OAuthAuthorizationCode) error {
+	createdAt := code.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	builder := psql.Insert(c.tableName("_oauth_authorization_code")).Columns(
+		"code",
+		"client_id",
+		"user_id",
+		"redirect_uri",
+		"scopes",
+		"nonce",
+		"code_challenge",
+		"code_challenge_method",
+		"expire_at",
+		"created_at",
+	).Values(
+		code.Code,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		pq.StringArray(code.Scopes),
+		code.Nonce,
+		code.CodeChallenge,
+		code.CodeChallengeMethod,
+		code.ExpireAt,
+		createdAt,
+	)
+
+	_, err := c.ExecWith(builder)
+	if isForeignKeyViolated(err) {
+		return skydb.ErrOAuthClientNotFound
+	}
+	return err
+}
+
+func (c *conn) ConsumeOAuthAuthorizationCode(code string, authCode *skydb.OAuthAuthorizationCode) error {
+	builder := psql.Delete(c.tableName("_oauth_authorization_code")).
+		Where("code = ?", code).
+		Suffix("RETURNING code, client_id, user_id, redirect_uri, scopes, " +
+			"nonce, code_challenge, code_challenge_method, expire_at, created_at")
+
+	if err := c.doScanOAuthAuthorizationCode(authCode, c.QueryRowWith(builder)); err != nil {
+		return err
+	}
+
+	if authCode.ExpireAt.Before(time.Now().UTC()) {
+		return skydb.ErrOAuthCodeNotFound
+	}
+	return nil
+}
+
+func (c *conn) doScanOAuthAuthorizationCode(authCode *skydb.OAuthAuthorizationCode, scanner sq.RowScanner) error {
+	var (
+		scopes              pq.StringArray
+		nonce               sql.NullString
+		codeChallenge       sql.NullString
+		codeChallengeMethod sql.NullString
+	)
+	err := scanner.Scan(
+		&authCode.Code,
+		&authCode.ClientID,
+		&authCode.UserID,
+		&authCode.RedirectURI,
+		&scopes,
+		&nonce,
+		&codeChallenge,
+		&codeChallengeMethod,
+		&authCode.ExpireAt,
+		&authCode.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrOAuthCodeNotFound
+	} else if err != nil {
+		return err
+	}
+
+	authCode.Scopes = []string(scopes)
+	authCode.Nonce = nonce.String
+	authCode.CodeChallenge = codeChallenge.String
+	authCode.CodeChallengeMethod = codeChallengeMethod.String
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOAuthServerConn(t *testing.T) {
+	var c *conn
+
+	Convey("Conn", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		createdAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		client := skydb.OAuthClient{
+			ID:           "client-id",
+			Name:         "Faseng App",
+			RedirectURIs: []string{"https://example.com/callback"},
+			GrantTypes:   []string{"authorization_code"},
+			Scopes:       []string{"openid", "email"},
+			CreatedAt:    &createdAt,
+		}
+		client.SetSecret("secret")
+
+		Convey("create and get oauth client", func() {
+			So(c.CreateOAuthClient(&client), ShouldBeNil)
+
+			fetched := skydb.OAuthClient{}
+			So(c.GetOAuthClient("client-id", &fetched), ShouldBeNil)
+			So(fetched.Name, ShouldEqual, "Faseng App")
+			So(fetched.RedirectURIs, ShouldResemble, []string{"https://example.com/callback"})
+			So(fetched.GrantTypes, ShouldResemble, []string{"authorization_code"})
+			So(fetched.Scopes, ShouldResemble, []string{"openid", "email"})
+			So(fetched.IsSameSecret("secret"), ShouldBeTrue)
+		})
+
+		Convey("return ErrOAuthClientNotFound when client does not exist", func() {
+			fetched := skydb.OAuthClient{}
+			So(c.GetOAuthClient("not-exist", &fetched), ShouldEqual, skydb.ErrOAuthClientNotFound)
+			So(c.DeleteOAuthClient("not-exist"), ShouldEqual, skydb.ErrOAuthClientNotFound)
+		})
+
+		Convey("delete oauth client", func() {
+			So(c.CreateOAuthClient(&client), ShouldBeNil)
+			So(c.DeleteOAuthClient("client-id"), ShouldBeNil)
+
+			fetched := skydb.OAuthClient{}
+			So(c.GetOAuthClient("client-id", &fetched), ShouldEqual, skydb.ErrOAuthClientNotFound)
+		})
+
+		Convey("consume authorization code once", func() {
+			So(c.CreateOAuthClient(&client), ShouldBeNil)
+			addAuth(t, c, "userid")
+
+			code := skydb.OAuthAuthorizationCode{
+				Code:                "code",
+				ClientID:            "client-id",
+				UserID:              "userid",
+				RedirectURI:         "https://example.com/callback",
+				Scopes:              []string{"openid"},
+				CodeChallenge:       "challenge",
+				CodeChallengeMethod: "S256",
+				ExpireAt:            time.Now().UTC().Add(time.Minute),
+			}
+			So(c.CreateOAuthAuthorizationCode(&code), ShouldBeNil)
+
+			consumed := skydb.OAuthAuthorizationCode{}
+			So(c.ConsumeOAuthAuthorizationCode("code", &consumed), ShouldBeNil)
+			So(consumed.UserID, ShouldEqual, "userid")
+			So(consumed.Scopes, ShouldResemble, []string{"openid"})
+			So(consumed.CodeChallenge, ShouldEqual, "challenge")
+
+			So(c.ConsumeOAuthAuthorizationCode("code", &consumed), ShouldEqual, skydb.ErrOAuthCodeNotFound)
+		})
+
+		Convey("reject expired authorization code", func() {
+			So(c.CreateOAuthClient(&client), ShouldBeNil)
+			addAuth(t, c, "userid")
+
+			code := skydb.OAuthAuthorizationCode{
+				Code:        "code",
+				ClientID:    "client-id",
+				UserID:      "userid",
+				RedirectURI: "https://example.com/callback",
+				Scopes:      []string{"openid"},
+				ExpireAt:    time.Now().UTC().Add(-time.Minute),
+			}
+			So(c.CreateOAuthAuthorizationCode(&code), ShouldBeNil)
+
+			consumed := skydb.OAuthAuthorizationCode{}
+			So(c.ConsumeOAuthAuthorizationCode("code", &consumed), ShouldEqual, skydb.ErrOAuthCodeNotFound)
+		})
+	})
+}
@@ -94,6 +94,15 @@ func (db *database) GetByIDs(ids []skydb.RecordID, accessControlOptions *skydb.A
 			return nil, err
 		}
 		query = query.Where(aclSqlizer)
+
+		predicateSqlizer, err := db.newRecordPredicateSqlizer(factory, recordType, accessControlOptions)
+		if err != nil {
+			return nil, err
+		}
+		if predicateSqlizer != nil {
+			query = query.Where(predicateSqlizer)
+			query = factory.AddJoinsToSelectBuilder(query)
+		}
 	}
 
 	rows, err := db.c.QueryWith(query)
@@ -283,7 +292,6 @@ func (db *database) applyQueryPredicate(q sq.SelectBuilder, factory builder.Pred
 			return q, err
 		}
 		q = q.Where(sqlizer)
-		q = factory.AddJoinsToSelectBuilder(q)
 	}
 
 	if db.DatabaseType() == skydb.PublicDatabase && !accessControlOptions.BypassAccessControl {
@@ -292,11 +300,40 @@ func (db *database) applyQueryPredicate(q sq.SelectBuilder, factory builder.Pred
 			return q, err
 		}
 		q = q.Where(aclSqlizer)
+
+		predicateSqlizer, err := db.newRecordPredicateSqlizer(factory, query.Type, accessControlOptions)
+		if err != nil {
+			return q, err
+		}
+		if predicateSqlizer != nil {
+			q = q.Where(predicateSqlizer)
+		}
 	}
 
+	q = factory.AddJoinsToSelectBuilder(q)
 	return q, nil
 }
 
+// newRecordPredicateSqlizer returns the sqlizer of the record predicate
+// declared for the record type, or nil if there is none.
+func (db *database) newRecordPredicateSqlizer(factory builder.PredicateSqlizerFactory, recordType string, accessControlOptions *skydb.AccessControlOptions) (sq.Sqlizer, error) {
+	access, err := db.c.getCompiledRecordPredicateAccess(recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	predicate := access.Predicate(accessControlOptions.PredicateLevel)
+	if predicate.IsEmpty() {
+		return nil, nil
+	}
+
+	userID := ""
+	if accessControlOptions.ViewAsUser != nil {
+		userID = accessControlOptions.ViewAsUser.ID
+	}
+	return factory.NewPredicateSqlizer(predicate.BindCurrentUser(userID))
+}
+
 func (db *database) Query(query *skydb.Query, accessControlOptions *skydb.AccessControlOptions) (*skydb.Rows, error) {
 	if query.Type == "" {
 		return nil, errors.New("got empty query type")
@@ -396,6 +433,145 @@ func (db *database) QueryCount(query *skydb.Query, accessControlOptions *skydb.A
 	return recordCount, nil
 }
 
+func (db *database) UpdateRecordACL(query *skydb.Query, patch skydb.RecordACLPatch, batchSize uint64, fn skydb.RecordACLUpdateFunc) (skydb.RecordACLUpdateProgress, error) {
+	progress := skydb.RecordACLUpdateProgress{}
+	if db.IsReadOnly() {
+		return progress, skydb.ErrDatabaseIsReadOnly
+	}
+	if query.Type == "" {
+		return progress, errors.New("got empty query type")
+	}
+	if batchSize == 0 {
+		return progress, errors.New("got zero batch size")
+	}
+
+	logger := logging.CreateLogger(db.c.context, "skydb")
+	lastKey := ""
+	for {
+		records, err := db.queryRecordACLBatch(query, lastKey, batchSize)
+		if err != nil {
+			return progress, err
+		}
+		if len(records) == 0 {
+			break
+		}
+		lastKey = records[len(records)-1].ID.Key
+
+		updatedRecords := []skydb.Record{}
+		originalRecords := []skydb.Record{}
+		for _, record := range records {
+			acl, changed := patch.Apply(record.ACL)
+			if !changed {
+				continue
+			}
+			originalRecords = append(originalRecords, record)
+			record.ACL = acl
+			updatedRecords = append(updatedRecords, record)
+		}
+
+		if err := db.updateRecordACLs(query.Type, updatedRecords); err != nil {
+			return progress, err
+		}
+
+		progress.Batches++
+		progress.Matched += uint64(len(records))
+		progress.Updated += uint64(len(updatedRecords))
+		logger.WithFields(logrus.Fields{
+			"recordType": query.Type,
+			"batches":    progress.Batches,
+			"matched":    progress.Matched,
+			"updated":    progress.Updated,
+		}).Debugln("Updated a batch of record ACL")
+
+		if fn != nil {
+			if err := fn(updatedRecords, originalRecords, progress); err != nil {
+				return progress, err
+			}
+		}
+
+		if uint64(len(records)) < batchSize {
+			break
+		}
+	}
+
+	return progress, nil
+}
+
+// queryRecordACLBatch returns the next batch of records matching the query
+// with key greater than lastKey, ordered by key.
+func (db *database) queryRecordACLBatch(query *skydb.Query, lastKey string, batchSize uint64) ([]skydb.Record, error) {
+	predicate := query.Predicate
+	if lastKey != "" {
+		keyPredicate := skydb.Predicate{
+			Operator: skydb.GreaterThan,
+			Children: []interface{}{
+				skydb.Expression{Type: skydb.KeyPath, Value: "_id"},
+				skydb.Expression{Type: skydb.Literal, Value: lastKey},
+			},
+		}
+		if predicate.IsEmpty() {
+			predicate = keyPredicate
+		} else {
+			predicate = skydb.Predicate{
+				Operator: skydb.And,
+				Children: []interface{}{predicate, keyPredicate},
+			}
+		}
+	}
+
+	batchQuery := skydb.Query{
+		Type:      query.Type,
+		Predicate: predicate,
+		Sorts: []skydb.Sort{
+			{
+				Expression: skydb.Expression{Type: skydb.KeyPath, Value: "_id"},
+				Order:      skydb.Ascending,
+			},
+		},
+		Limit: &batchSize,
+	}
+
+	rows, err := db.Query(&batchQuery, &skydb.AccessControlOptions{
+		BypassAccessControl: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []skydb.Record{}
+	for rows.Scan() {
+		records = append(records, rows.Record())
+	}
+	return records, rows.Err()
+}
+
+// updateRecordACLs writes the ACL of the records in a single statement.
+func (db *database) updateRecordACLs(recordType string, records []skydb.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	values := make([]string, len(records))
+	args := make([]interface{}, 0, len(records)*2+1)
+	for i, record := range records {
+		values[i] = fmt.Sprintf("($%d, $%d::jsonb)", len(args)+1, len(args)+2)
+		args = append(args, record.ID.Key, aclValue(record.ACL))
+	}
+	args = append(args, db.userID)
+
+	stmt := fmt.Sprintf(
+		`UPDATE %s AS t SET "_access" = v.access FROM (VALUES %s) AS v(id, access) WHERE t."_id" = v.id AND t."_database_id" = $%d`,
+		db.TableName(recordType),
+		strings.Join(values, ", "),
+		len(args),
+	)
+	if _, err := db.c.Exec(stmt, args...); err != nil {
+		return fmt.Errorf("update record ACL of %s: %v", recordType, err)
+	}
+	return nil
+}
+
 // columnsScanner wraps over sqlx.Rows and sqlx.Row to provide
 // a consistent interface for column scanning.
 type columnsScanner interface {
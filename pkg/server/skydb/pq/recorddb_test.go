@@ -17,6 +17,7 @@ package pq
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -2305,6 +2306,107 @@ func TestRecordACL(t *testing.T) {
 	})
 }
 
+func TestUpdateRecordACL(t *testing.T) {
+	Convey("Database", t, func() {
+		c := getTestConn(t)
+		defer cleanupConn(t, c)
+
+		db := c.PublicDB()
+		_, err := db.Extend("note", skydb.RecordSchema{
+			"category": skydb.FieldType{Type: skydb.TypeString},
+		})
+		So(err, ShouldBeNil)
+
+		publicRead := skydb.NewRecordACLEntryPublic(skydb.ReadLevel)
+		adminWrite := skydb.NewRecordACLEntryRole("admin", skydb.WriteLevel)
+		for i, category := range []string{"work", "work", "home", "work", "work"} {
+			record := skydb.Record{
+				ID:      skydb.NewRecordID("note", fmt.Sprintf("id%d", i)),
+				OwnerID: "someuserid",
+				ACL:     skydb.RecordACL{publicRead},
+				Data: map[string]interface{}{
+					"category": category,
+				},
+			}
+			if i == 3 {
+				record.ACL = skydb.RecordACL{publicRead, adminWrite}
+			}
+			So(db.Save(&record), ShouldBeNil)
+		}
+
+		query := skydb.Query{
+			Type: "note",
+			Predicate: skydb.Predicate{
+				Operator: skydb.Equal,
+				Children: []interface{}{
+					skydb.Expression{Type: skydb.KeyPath, Value: "category"},
+					skydb.Expression{Type: skydb.Literal, Value: "work"},
+				},
+			},
+		}
+		patch := skydb.RecordACLPatch{
+			Add: []skydb.RecordACLEntry{adminWrite},
+		}
+
+		Convey("updates ACL of matching records in batches", func() {
+			batches := [][]string{}
+			progress, err := db.UpdateRecordACL(&query, patch, 2, func(records []skydb.Record, originalRecords []skydb.Record, progress skydb.RecordACLUpdateProgress) error {
+				keys := []string{}
+				for i, record := range records {
+					So(record.ACL, ShouldResemble, skydb.RecordACL{publicRead, adminWrite})
+					So(originalRecords[i].ACL, ShouldResemble, skydb.RecordACL{publicRead})
+					keys = append(keys, record.ID.Key)
+				}
+				batches = append(batches, keys)
+				return nil
+			})
+
+			So(err, ShouldBeNil)
+			So(progress, ShouldResemble, skydb.RecordACLUpdateProgress{
+				Batches: 2,
+				Matched: 4,
+				Updated: 3,
+			})
+			So(batches, ShouldResemble, [][]string{
+				{"id0", "id1"},
+				{"id4"},
+			})
+
+			record := skydb.Record{}
+			So(db.Get(skydb.NewRecordID("note", "id4"), &record), ShouldBeNil)
+			So(record.ACL, ShouldResemble, skydb.RecordACL{publicRead, adminWrite})
+			So(db.Get(skydb.NewRecordID("note", "id2"), &record), ShouldBeNil)
+			So(record.ACL, ShouldResemble, skydb.RecordACL{publicRead})
+		})
+
+		Convey("stops when the batch function returns error", func() {
+			progress, err := db.UpdateRecordACL(&query, patch, 2, func(records []skydb.Record, originalRecords []skydb.Record, progress skydb.RecordACLUpdateProgress) error {
+				return errors.New("stop")
+			})
+
+			So(err, ShouldNotBeNil)
+			So(progress.Batches, ShouldEqual, 1)
+
+			record := skydb.Record{}
+			So(db.Get(skydb.NewRecordID("note", "id4"), &record), ShouldBeNil)
+			So(record.ACL, ShouldResemble, skydb.RecordACL{publicRead})
+		})
+
+		Convey("removes entries", func() {
+			progress, err := db.UpdateRecordACL(&skydb.Query{Type: "note"}, skydb.RecordACLPatch{
+				Remove: []skydb.RecordACLEntry{publicRead},
+			}, 10, nil)
+
+			So(err, ShouldBeNil)
+			So(progress.Updated, ShouldEqual, 5)
+
+			record := skydb.Record{}
+			So(db.Get(skydb.NewRecordID("note", "id3"), &record), ShouldBeNil)
+			So(record.ACL, ShouldResemble, skydb.RecordACL{adminWrite})
+		})
+	})
+}
+
 func TestRecordJSON(t *testing.T) {
 	Convey("Database", t, func() {
 		c := getTestConn(t)
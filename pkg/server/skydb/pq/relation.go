@@ -15,6 +15,8 @@
 package pq
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	sq "github.com/lann/squirrel"
@@ -31,18 +33,18 @@ func (c *conn) QueryRelation(user string, name string, direction string, config
 	if direction == "outward" {
 		selectBuilder = psql.Select("u.id").
 			From(c.tableName("_auth")+" AS u").
-			Join(c.tableName(name)+" AS relation ON relation.right_id = u.id").
+			Join(c.relationTableName(name)+" AS relation ON relation.right_id = u.id").
 			Where("relation.left_id = ?", user)
 	} else if direction == "inward" {
 		selectBuilder = psql.Select("u.id").
 			From(c.tableName("_auth")+" AS u").
-			Join(c.tableName(name)+" AS relation ON relation.left_id = u.id").
+			Join(c.relationTableName(name)+" AS relation ON relation.left_id = u.id").
 			Where("relation.right_id = ?", user)
 	} else {
 		selectBuilder = psql.Select("u.id").
 			From(c.tableName("_auth")+" AS u").
-			Join(c.tableName(name)+" AS inward_relation ON inward_relation.left_id = u.id").
-			Join(c.tableName(name)+" AS outward_relation ON outward_relation.right_id = u.id").
+			Join(c.relationTableName(name)+" AS inward_relation ON inward_relation.left_id = u.id").
+			Join(c.relationTableName(name)+" AS outward_relation ON outward_relation.right_id = u.id").
 			Where("inward_relation.right_id = ?", user).
 			Where("outward_relation.left_id = ?", user)
 	}
@@ -77,14 +79,14 @@ func (c *conn) QueryRelation(user string, name string, direction string, config
 func (c *conn) QueryRelationCount(user string, name string, direction string) (uint64, error) {
 	logger := logging.CreateLogger(c.context, "skydb")
 	logger.Debugf("Query Relation Count: %v, %v, %v", user, name, direction)
-	query := psql.Select("COUNT(*)").From(c.tableName(name) + "AS _primary")
+	query := psql.Select("COUNT(*)").From(c.relationTableName(name) + "AS _primary")
 	if direction == "outward" {
 		query = query.Where("_primary.left_id = ?", user)
 	} else if direction == "inward" {
 		query = query.Where("_primary.right_id = ?", user)
 	} else {
 		query = query.
-			Join(c.tableName(name)+" AS _secondary ON _secondary.left_id = _primary.right_id").
+			Join(c.relationTableName(name)+" AS _secondary ON _secondary.left_id = _primary.right_id").
 			Where("_primary.left_id = ?", user).
 			Where("_secondary.right_id = ?", user)
 	}
@@ -97,12 +99,20 @@ func (c *conn) QueryRelationCount(user string, name string, direction string) (u
 }
 
 func (c *conn) AddRelation(user string, name string, targetUser string) error {
+	if !skydb.IsBuiltinRelation(name) {
+		return c.SaveRelation(&skydb.Relation{
+			Name:    name,
+			LeftID:  user,
+			RightID: targetUser,
+		})
+	}
+
 	ralationPair := map[string]interface{}{
 		"left_id":  user,
 		"right_id": targetUser,
 	}
 
-	upsert := builder.UpsertQuery(c.tableName(name), ralationPair, nil)
+	upsert := builder.UpsertQuery(c.relationTableName(name), ralationPair, nil)
 	_, err := c.ExecWith(upsert)
 	if err != nil {
 		if isForeignKeyViolated(err) {
@@ -114,8 +124,22 @@ func (c *conn) AddRelation(user string, name string, targetUser string) error {
 }
 
 func (c *conn) RemoveRelation(user string, name string, targetUser string) error {
-	builder := psql.Delete(c.tableName(name)).
+	mutual := false
+	if !skydb.IsBuiltinRelation(name) {
+		relationType, err := c.GetRelationType(name)
+		if err != nil {
+			return err
+		}
+		mutual = relationType.Direction == skydb.MutualRelation
+	}
+
+	builder := psql.Delete(c.relationTableName(name)).
 		Where("left_id = ? AND right_id = ?", user, targetUser)
+	if mutual {
+		builder = psql.Delete(c.relationTableName(name)).
+			Where("(left_id = ? AND right_id = ?) OR (left_id = ? AND right_id = ?)",
+				user, targetUser, targetUser, user)
+	}
 	result, err := c.ExecWith(builder)
 
 	if err != nil {
@@ -128,8 +152,230 @@ func (c *conn) RemoveRelation(user string, name string, targetUser string) error
 	if rowsAffected == 0 {
 		return fmt.Errorf("%v relation not exist {%v} => {%v}",
 			name, user, targetUser)
-	} else if rowsAffected > 1 {
+	} else if rowsAffected > 1 && !mutual {
 		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
 	}
 	return nil
 }
+
+func (c *conn) relationTableName(name string) string {
+	return c.tableName(builder.RelationTableName(name))
+}
+
+func (c *conn) CreateRelationType(relationType *skydb.RelationType) error {
+	if relationType.CreatedAt.IsZero() {
+		relationType.CreatedAt = timeNow()
+	}
+
+	fields, err := json.Marshal(relationType.Fields)
+	if err != nil {
+		return err
+	}
+
+	insert := psql.Insert(c.tableName("_relation_type")).Columns(
+		"name",
+		"direction",
+		"fields",
+		"created_at",
+	).Values(
+		relationType.Name,
+		string(relationType.Direction),
+		fields,
+		relationType.CreatedAt,
+	)
+	if _, err := c.ExecWith(insert); err != nil {
+		if isUniqueViolated(err) {
+			return skydb.ErrRelationTypeDuplicated
+		}
+		return err
+	}
+
+	table := c.relationTableName(relationType.Name)
+	stmt := fmt.Sprintf(`
+CREATE TABLE %[1]s (
+	left_id text NOT NULL REFERENCES %[2]s (id) ON DELETE CASCADE,
+	right_id text NOT NULL REFERENCES %[2]s (id) ON DELETE CASCADE,
+	created_at timestamp without time zone NOT NULL,
+	metadata jsonb,
+	PRIMARY KEY(left_id, right_id)
+);
+CREATE INDEX ON %[1]s (right_id);
+`, table, c.tableName("_auth"))
+	if _, err := c.Exec(stmt); err != nil {
+		return err
+	}
+
+	c.relationTypes = nil // invalidate cached relation types
+	return nil
+}
+
+func (c *conn) GetRelationType(name string) (skydb.RelationType, error) {
+	relationTypes, err := c.GetRelationTypes()
+	if err != nil {
+		return skydb.RelationType{}, err
+	}
+	for _, relationType := range relationTypes {
+		if relationType.Name == name {
+			return relationType, nil
+		}
+	}
+	return skydb.RelationType{}, skydb.ErrRelationTypeNotFound
+}
+
+// GetRelationTypes returns the relation types, which are cached in conn
+// as they are needed by every query for relation ACL entries.
+func (c *conn) GetRelationTypes() ([]skydb.RelationType, error) {
+	if c.relationTypes != nil {
+		return c.relationTypes, nil
+	}
+
+	builder := psql.Select("name", "direction", "fields", "created_at").
+		From(c.tableName("_relation_type")).
+		OrderBy("name")
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relationTypes := []skydb.RelationType{}
+	for rows.Next() {
+		var (
+			relationType skydb.RelationType
+			direction    string
+			fields       []byte
+		)
+		if err := rows.Scan(
+			&relationType.Name,
+			&direction,
+			&fields,
+			&relationType.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		relationType.Direction = skydb.RelationDirection(direction)
+		if len(fields) > 0 {
+			if err := json.Unmarshal(fields, &relationType.Fields); err != nil {
+				return nil, err
+			}
+		}
+		relationTypes = append(relationTypes, relationType)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.relationTypes = relationTypes
+	return relationTypes, nil
+}
+
+func (c *conn) DeleteRelationType(name string) error {
+	if _, err := c.GetRelationType(name); err != nil {
+		return err
+	}
+
+	if _, err := c.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", c.relationTableName(name))); err != nil {
+		return err
+	}
+
+	deleteBuilder := psql.Delete(c.tableName("_relation_type")).Where("name = ?", name)
+	if _, err := c.ExecWith(deleteBuilder); err != nil {
+		return err
+	}
+
+	c.relationTypes = nil // invalidate cached relation types
+	return nil
+}
+
+func (c *conn) SaveRelation(relation *skydb.Relation) error {
+	if skydb.IsBuiltinRelation(relation.Name) {
+		return c.AddRelation(relation.LeftID, relation.Name, relation.RightID)
+	}
+
+	relationType, err := c.GetRelationType(relation.Name)
+	if err != nil {
+		return err
+	}
+	if relation.CreatedAt.IsZero() {
+		relation.CreatedAt = timeNow()
+	}
+
+	var metadata interface{}
+	if len(relation.Metadata) > 0 {
+		metadata = jsonMapValue(relation.Metadata)
+	}
+
+	insert := psql.Insert(c.relationTableName(relation.Name)).Columns(
+		"left_id",
+		"right_id",
+		"created_at",
+		"metadata",
+	).Values(
+		relation.LeftID,
+		relation.RightID,
+		relation.CreatedAt,
+		metadata,
+	)
+	if relationType.Direction == skydb.MutualRelation && relation.LeftID != relation.RightID {
+		insert = insert.Values(
+			relation.RightID,
+			relation.LeftID,
+			relation.CreatedAt,
+			metadata,
+		)
+	}
+	insert = insert.Suffix("ON CONFLICT (left_id, right_id) DO UPDATE SET metadata = EXCLUDED.metadata")
+
+	if _, err := c.ExecWith(insert); err != nil {
+		if isForeignKeyViolated(err) {
+			return fmt.Errorf("userID not exist")
+		}
+		return err
+	}
+	return nil
+}
+
+func (c *conn) GetRelation(user string, name string, targetUser string) (skydb.Relation, error) {
+	relation := skydb.Relation{
+		Name:    name,
+		LeftID:  user,
+		RightID: targetUser,
+	}
+
+	if skydb.IsBuiltinRelation(name) {
+		// builtin relations have no created_at and metadata
+		var exists bool
+		err := c.QueryRowx(
+			fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE left_id = $1 AND right_id = $2)", c.relationTableName(name)),
+			user, targetUser,
+		).Scan(&exists)
+		if err != nil {
+			return skydb.Relation{}, err
+		}
+		if !exists {
+			return skydb.Relation{}, skydb.ErrRelationNotFound
+		}
+		return relation, nil
+	}
+
+	builder := psql.Select("created_at", "metadata").
+		From(c.relationTableName(name)).
+		Where("left_id = ? AND right_id = ?", user, targetUser)
+
+	var metadata []byte
+	err := c.QueryRowWith(builder).Scan(&relation.CreatedAt, &metadata)
+	if err == sql.ErrNoRows {
+		return skydb.Relation{}, skydb.ErrRelationNotFound
+	} else if isUndefinedTable(err) {
+		return skydb.Relation{}, skydb.ErrRelationTypeNotFound
+	} else if err != nil {
+		return skydb.Relation{}, err
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &relation.Metadata); err != nil {
+			return skydb.Relation{}, err
+		}
+	}
+	return relation, nil
+}
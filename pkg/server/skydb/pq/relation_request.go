@@ -0,0 +1,167 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"database/sql"
+	"fmt"
+
+	sq "github.com/lann/squirrel"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+func (c *conn) CreateRelationRequest(request *skydb.RelationRequest) error {
+	if request.CreatedAt.IsZero() {
+		request.CreatedAt = timeNow()
+	}
+	request.UpdatedAt = request.CreatedAt
+	request.State = skydb.RelationRequestPending
+
+	// A request no longer pending is replaced by the new request, while a
+	// pending request is left untouched so that no row is affected.
+	builder := psql.Insert(c.tableName("_relation_request")).Columns(
+		"name",
+		"requester_id",
+		"recipient_id",
+		"state",
+		"created_at",
+		"updated_at",
+	).Values(
+		request.Name,
+		request.RequesterID,
+		request.RecipientID,
+		string(request.State),
+		request.CreatedAt,
+		request.UpdatedAt,
+	).Suffix(fmt.Sprintf(
+		"ON CONFLICT (name, requester_id, recipient_id) DO UPDATE SET "+
+			"state = EXCLUDED.state, created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at "+
+			"WHERE _relation_request.state <> '%s'",
+		skydb.RelationRequestPending,
+	))
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		if isForeignKeyViolated(err) {
+			return skydb.ErrUserNotFound
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrRelationRequestDuplicated
+	}
+	return nil
+}
+
+func (c *conn) GetRelationRequest(name string, requesterID string, recipientID string) (skydb.RelationRequest, error) {
+	builder := c.selectRelationRequestBuilder().
+		Where("name = ? AND requester_id = ? AND recipient_id = ?", name, requesterID, recipientID)
+
+	request := skydb.RelationRequest{}
+	err := c.doScanRelationRequest(&request, c.QueryRowWith(builder))
+	return request, err
+}
+
+func (c *conn) UpdateRelationRequest(request *skydb.RelationRequest) error {
+	request.UpdatedAt = timeNow()
+
+	builder := psql.Update(c.tableName("_relation_request")).
+		Set("state", string(request.State)).
+		Set("updated_at", request.UpdatedAt).
+		Where("name = ? AND requester_id = ? AND recipient_id = ?",
+			request.Name, request.RequesterID, request.RecipientID)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrRelationRequestNotFound
+	}
+	return nil
+}
+
+func (c *conn) QueryRelationRequests(user string, name string, direction string, config skydb.QueryConfig) ([]skydb.RelationRequest, error) {
+	builder := c.selectRelationRequestBuilder().
+		Where("name = ? AND state = ?", name, string(skydb.RelationRequestPending)).
+		OrderBy("created_at DESC")
+
+	switch direction {
+	case "incoming":
+		builder = builder.Where("recipient_id = ?", user)
+	case "outgoing":
+		builder = builder.Where("requester_id = ?", user)
+	default:
+		return nil, fmt.Errorf("unknown relation request direction %q", direction)
+	}
+
+	if config.Limit != 0 {
+		builder = builder.Limit(config.Limit)
+	}
+	if config.Offset != 0 {
+		builder = builder.Offset(config.Offset)
+	}
+
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []skydb.RelationRequest{}
+	for rows.Next() {
+		request := skydb.RelationRequest{}
+		if err := c.doScanRelationRequest(&request, rows); err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	return requests, rows.Err()
+}
+
+func (c *conn) selectRelationRequestBuilder() sq.SelectBuilder {
+	return psql.Select("name", "requester_id", "recipient_id", "state", "created_at", "updated_at").
+		From(c.tableName("_relation_request"))
+}
+
+func (c *conn) doScanRelationRequest(request *skydb.RelationRequest, scanner sq.RowScanner) error {
+	var state string
+	err := scanner.Scan(
+		&request.Name,
+		&request.RequesterID,
+		&request.RecipientID,
+		&state,
+		&request.CreatedAt,
+		&request.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrRelationRequestNotFound
+	} else if err != nil {
+		return err
+	}
+
+	request.State = skydb.RelationRequestState(state)
+	return nil
+}
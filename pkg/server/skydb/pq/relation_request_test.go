@@ -0,0 +1,135 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRelationRequest(t *testing.T) {
+	Convey("Conn relation request", t, func() {
+		c := getTestConn(t)
+		defer cleanupConn(t, c)
+
+		addUser(t, c, "alice")
+		addUser(t, c, "bob")
+		addUser(t, c, "carol")
+
+		createdAt := time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)
+		request := skydb.RelationRequest{
+			Name:        "_friend",
+			RequesterID: "alice",
+			RecipientID: "bob",
+			CreatedAt:   createdAt,
+		}
+		So(c.CreateRelationRequest(&request), ShouldBeNil)
+		So(request.State, ShouldEqual, skydb.RelationRequestPending)
+
+		Convey("get relation request", func() {
+			fetched, err := c.GetRelationRequest("_friend", "alice", "bob")
+			So(err, ShouldBeNil)
+			So(fetched, ShouldResemble, request)
+		})
+
+		Convey("get non-existent relation request", func() {
+			_, err := c.GetRelationRequest("_friend", "bob", "alice")
+			So(err, ShouldEqual, skydb.ErrRelationRequestNotFound)
+		})
+
+		Convey("create duplicated pending request", func() {
+			err := c.CreateRelationRequest(&skydb.RelationRequest{
+				Name:        "_friend",
+				RequesterID: "alice",
+				RecipientID: "bob",
+			})
+			So(err, ShouldEqual, skydb.ErrRelationRequestDuplicated)
+		})
+
+		Convey("create request to non-existent user", func() {
+			err := c.CreateRelationRequest(&skydb.RelationRequest{
+				Name:        "_friend",
+				RequesterID: "alice",
+				RecipientID: "nobody",
+			})
+			So(err, ShouldEqual, skydb.ErrUserNotFound)
+		})
+
+		Convey("update and request again", func() {
+			request.State = skydb.RelationRequestDeclined
+			So(c.UpdateRelationRequest(&request), ShouldBeNil)
+
+			fetched, err := c.GetRelationRequest("_friend", "alice", "bob")
+			So(err, ShouldBeNil)
+			So(fetched.State, ShouldEqual, skydb.RelationRequestDeclined)
+
+			again := skydb.RelationRequest{
+				Name:        "_friend",
+				RequesterID: "alice",
+				RecipientID: "bob",
+			}
+			So(c.CreateRelationRequest(&again), ShouldBeNil)
+
+			fetched, err = c.GetRelationRequest("_friend", "alice", "bob")
+			So(err, ShouldBeNil)
+			So(fetched.State, ShouldEqual, skydb.RelationRequestPending)
+		})
+
+		Convey("query pending requests", func() {
+			So(c.CreateRelationRequest(&skydb.RelationRequest{
+				Name:        "_friend",
+				RequesterID: "carol",
+				RecipientID: "bob",
+				CreatedAt:   createdAt.Add(time.Hour),
+			}), ShouldBeNil)
+			So(c.CreateRelationRequest(&skydb.RelationRequest{
+				Name:        "_follow",
+				RequesterID: "carol",
+				RecipientID: "bob",
+			}), ShouldBeNil)
+
+			requests, err := c.QueryRelationRequests("bob", "_friend", "incoming", skydb.QueryConfig{})
+			So(err, ShouldBeNil)
+			So(len(requests), ShouldEqual, 2)
+			So(requests[0].RequesterID, ShouldEqual, "carol")
+			So(requests[1].RequesterID, ShouldEqual, "alice")
+
+			requests, err = c.QueryRelationRequests("bob", "_friend", "incoming", skydb.QueryConfig{
+				Limit:  1,
+				Offset: 1,
+			})
+			So(err, ShouldBeNil)
+			So(len(requests), ShouldEqual, 1)
+			So(requests[0].RequesterID, ShouldEqual, "alice")
+
+			requests, err = c.QueryRelationRequests("alice", "_friend", "outgoing", skydb.QueryConfig{})
+			So(err, ShouldBeNil)
+			So(len(requests), ShouldEqual, 1)
+			So(requests[0].RecipientID, ShouldEqual, "bob")
+		})
+
+		Convey("query excludes requests no longer pending", func() {
+			request.State = skydb.RelationRequestCancelled
+			So(c.UpdateRelationRequest(&request), ShouldBeNil)
+
+			requests, err := c.QueryRelationRequests("bob", "_friend", "incoming", skydb.QueryConfig{})
+			So(err, ShouldBeNil)
+			So(requests, ShouldBeEmpty)
+		})
+	})
+}
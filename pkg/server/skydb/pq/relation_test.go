@@ -102,3 +102,149 @@ func TestRelation(t *testing.T) {
 		})
 	})
 }
+
+func TestRelationType(t *testing.T) {
+	Convey("Conn relation type", t, func() {
+		c := getTestConn(t)
+		defer cleanupConn(t, c)
+
+		addUser(t, c, "alice")
+		addUser(t, c, "bob")
+		addUser(t, c, "carol")
+
+		colleague := skydb.RelationType{
+			Name:      "colleague",
+			Direction: skydb.MutualRelation,
+			Fields: map[string]string{
+				"department": "string",
+			},
+		}
+		So(c.CreateRelationType(&colleague), ShouldBeNil)
+
+		mentor := skydb.RelationType{
+			Name:      "mentor",
+			Direction: skydb.DirectedRelation,
+		}
+		So(c.CreateRelationType(&mentor), ShouldBeNil)
+
+		Convey("get relation types", func() {
+			relationType, err := c.GetRelationType("colleague")
+			So(err, ShouldBeNil)
+			So(relationType.Direction, ShouldEqual, skydb.MutualRelation)
+			So(relationType.Fields, ShouldResemble, map[string]string{
+				"department": "string",
+			})
+
+			relationTypes, err := c.GetRelationTypes()
+			So(err, ShouldBeNil)
+			So(len(relationTypes), ShouldEqual, 2)
+			So(relationTypes[0].Name, ShouldEqual, "colleague")
+			So(relationTypes[1].Name, ShouldEqual, "mentor")
+		})
+
+		Convey("get non-existent relation type", func() {
+			_, err := c.GetRelationType("neighbour")
+			So(err, ShouldEqual, skydb.ErrRelationTypeNotFound)
+		})
+
+		Convey("create duplicated relation type", func() {
+			err := c.CreateRelationType(&skydb.RelationType{
+				Name:      "colleague",
+				Direction: skydb.DirectedRelation,
+			})
+			So(err, ShouldEqual, skydb.ErrRelationTypeDuplicated)
+		})
+
+		Convey("save mutual relation with metadata", func() {
+			err := c.SaveRelation(&skydb.Relation{
+				Name:    "colleague",
+				LeftID:  "alice",
+				RightID: "bob",
+				Metadata: map[string]interface{}{
+					"department": "engineering",
+				},
+			})
+			So(err, ShouldBeNil)
+
+			relation, err := c.GetRelation("alice", "colleague", "bob")
+			So(err, ShouldBeNil)
+			So(relation.Metadata, ShouldResemble, map[string]interface{}{
+				"department": "engineering",
+			})
+
+			relation, err = c.GetRelation("bob", "colleague", "alice")
+			So(err, ShouldBeNil)
+			So(relation.Metadata["department"], ShouldEqual, "engineering")
+
+			users := c.QueryRelation("alice", "colleague", "outward", skydb.QueryConfig{})
+			So(len(users), ShouldEqual, 1)
+			So(users[0].ID, ShouldEqual, "bob")
+		})
+
+		Convey("remove mutual relation in both directions", func() {
+			So(c.AddRelation("alice", "colleague", "bob"), ShouldBeNil)
+			So(c.RemoveRelation("bob", "colleague", "alice"), ShouldBeNil)
+
+			_, err := c.GetRelation("alice", "colleague", "bob")
+			So(err, ShouldEqual, skydb.ErrRelationNotFound)
+			_, err = c.GetRelation("bob", "colleague", "alice")
+			So(err, ShouldEqual, skydb.ErrRelationNotFound)
+		})
+
+		Convey("save directed relation", func() {
+			So(c.AddRelation("alice", "mentor", "bob"), ShouldBeNil)
+
+			_, err := c.GetRelation("alice", "mentor", "bob")
+			So(err, ShouldBeNil)
+			_, err = c.GetRelation("bob", "mentor", "alice")
+			So(err, ShouldEqual, skydb.ErrRelationNotFound)
+		})
+
+		Convey("get builtin relation", func() {
+			So(c.AddRelation("alice", "_follow", "bob"), ShouldBeNil)
+
+			relation, err := c.GetRelation("alice", "_follow", "bob")
+			So(err, ShouldBeNil)
+			So(relation.Name, ShouldEqual, "_follow")
+		})
+
+		Convey("delete relation type", func() {
+			So(c.AddRelation("alice", "mentor", "bob"), ShouldBeNil)
+			So(c.DeleteRelationType("mentor"), ShouldBeNil)
+
+			_, err := c.GetRelationType("mentor")
+			So(err, ShouldEqual, skydb.ErrRelationTypeNotFound)
+			_, err = c.GetRelation("alice", "mentor", "bob")
+			So(err, ShouldEqual, skydb.ErrRelationTypeNotFound)
+		})
+
+		Convey("query records shared by relation", func() {
+			So(c.AddRelation("alice", "colleague", "bob"), ShouldBeNil)
+
+			record := skydb.Record{
+				ID:      skydb.NewRecordID("note", "id1"),
+				OwnerID: "alice",
+				ACL: skydb.RecordACL{
+					skydb.NewRecordACLEntryRelation("colleague", skydb.ReadLevel),
+				},
+			}
+			db := c.PublicDB()
+			_, err := db.Extend("note", skydb.RecordSchema{})
+			So(err, ShouldBeNil)
+			So(db.Save(&record), ShouldBeNil)
+
+			query := skydb.Query{Type: "note"}
+			records, err := exhaustRows(db.Query(&query, &skydb.AccessControlOptions{
+				ViewAsUser: &skydb.AuthInfo{ID: "bob"},
+			}))
+			So(err, ShouldBeNil)
+			So(len(records), ShouldEqual, 1)
+
+			records, err = exhaustRows(db.Query(&query, &skydb.AccessControlOptions{
+				ViewAsUser: &skydb.AuthInfo{ID: "carol"},
+			}))
+			So(err, ShouldBeNil)
+			So(len(records), ShouldEqual, 0)
+		})
+	})
+}
@@ -405,3 +405,117 @@ func (c *conn) ensureRole(roles []string) ([]string, error) {
 	absenceRoles := utils.StringSliceExcept(roles, existedRole)
 	return absenceRoles, c.createRoles(absenceRoles)
 }
+
+func (c *conn) GetRoleHierarchy() (skydb.RoleHierarchy, error) {
+	builder := psql.Select("role_id", "inherited_role_id").
+		From(c.tableName("_role_inheritance")).
+		OrderBy("role_id", "inherited_role_id")
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hierarchy := skydb.RoleHierarchy{}
+	for rows.Next() {
+		var role, inheritedRole string
+		if err := rows.Scan(&role, &inheritedRole); err != nil {
+			return nil, err
+		}
+		hierarchy[role] = append(hierarchy[role], inheritedRole)
+	}
+	return hierarchy, rows.Err()
+}
+
+func (c *conn) SetRoleHierarchy(hierarchy skydb.RoleHierarchy) error {
+	logger := logging.CreateLogger(c.context, "skydb")
+	logger.Debugf("SetRoleHierarchy %v", hierarchy)
+	if _, err := c.ensureRole(hierarchy.Roles()); err != nil {
+		return err
+	}
+
+	for role, inheritedRoles := range hierarchy {
+		deleteBuilder := psql.Delete(c.tableName("_role_inheritance")).
+			Where("role_id = ?", role)
+		if _, err := c.ExecWith(deleteBuilder); err != nil {
+			return err
+		}
+		if len(inheritedRoles) == 0 {
+			continue
+		}
+
+		insertBuilder := psql.Insert(c.tableName("_role_inheritance")).
+			Columns("role_id", "inherited_role_id")
+		for _, inheritedRole := range inheritedRoles {
+			insertBuilder = insertBuilder.Values(role, inheritedRole)
+		}
+		if _, err := c.ExecWith(insertBuilder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// populateInheritedRoles sets the roles inherited from the roles assigned
+// to the user, so that access control honors the role hierarchy.
+func (c *conn) populateInheritedRoles(authinfo *skydb.AuthInfo) error {
+	authinfo.InheritedRoles = nil
+	if len(authinfo.Roles) == 0 {
+		return nil
+	}
+
+	hierarchy, err := c.GetRoleHierarchy()
+	if err != nil {
+		return err
+	}
+	if inheritedRoles := hierarchy.Expand(authinfo.Roles); len(inheritedRoles) > 0 {
+		authinfo.InheritedRoles = inheritedRoles
+	}
+	return nil
+}
+
+func (c *conn) GetRolePermissions() (skydb.RolePermissions, error) {
+	builder := psql.Select("role_id", "action").
+		From(c.tableName("_role_permission")).
+		OrderBy("role_id", "action")
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := skydb.RolePermissions{}
+	for rows.Next() {
+		var role, action string
+		if err := rows.Scan(&role, &action); err != nil {
+			return nil, err
+		}
+		permissions[role] = append(permissions[role], action)
+	}
+	return permissions, rows.Err()
+}
+
+func (c *conn) SetRolePermissions(role string, actions []string) error {
+	logger := logging.CreateLogger(c.context, "skydb")
+	logger.Debugf("SetRolePermissions %v %v", role, actions)
+	if _, err := c.ensureRole([]string{role}); err != nil {
+		return err
+	}
+
+	deleteBuilder := psql.Delete(c.tableName("_role_permission")).
+		Where("role_id = ?", role)
+	if _, err := c.ExecWith(deleteBuilder); err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+
+	insertBuilder := psql.Insert(c.tableName("_role_permission")).
+		Columns("role_id", "action")
+	for _, action := range actions {
+		insertBuilder = insertBuilder.Values(role, action)
+	}
+	_, err := c.ExecWith(insertBuilder)
+	return err
+}
@@ -357,3 +357,108 @@ func TestRoleType(t *testing.T) {
 		})
 	})
 }
+
+func TestRoleHierarchy(t *testing.T) {
+	var c *conn
+
+	Convey("RoleHierarchy", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		Convey("set and get role hierarchy", func() {
+			err := c.SetRoleHierarchy(skydb.RoleHierarchy{
+				"admin":  []string{"editor"},
+				"editor": []string{"viewer"},
+			})
+			So(err, ShouldBeNil)
+
+			var role string
+			err = c.QueryRowx("SELECT id FROM _role WHERE id = 'viewer'").
+				Scan(&role)
+			So(err, ShouldBeNil)
+			So(role, ShouldEqual, "viewer")
+
+			hierarchy, err := c.GetRoleHierarchy()
+			So(err, ShouldBeNil)
+			So(hierarchy, ShouldResemble, skydb.RoleHierarchy{
+				"admin":  []string{"editor"},
+				"editor": []string{"viewer"},
+			})
+		})
+
+		Convey("replace inherited roles of specified roles only", func() {
+			err := c.SetRoleHierarchy(skydb.RoleHierarchy{
+				"admin":  []string{"editor"},
+				"editor": []string{"viewer"},
+			})
+			So(err, ShouldBeNil)
+
+			err = c.SetRoleHierarchy(skydb.RoleHierarchy{
+				"admin":  []string{"billing", "viewer"},
+				"editor": []string{},
+			})
+			So(err, ShouldBeNil)
+
+			hierarchy, err := c.GetRoleHierarchy()
+			So(err, ShouldBeNil)
+			So(hierarchy, ShouldResemble, skydb.RoleHierarchy{
+				"admin": []string{"billing", "viewer"},
+			})
+		})
+
+		Convey("populate inherited roles on get auth", func() {
+			authinfo := skydb.AuthInfo{
+				ID:    "userid",
+				Roles: []string{"admin"},
+			}
+			So(c.CreateAuth(&authinfo), ShouldBeNil)
+			So(c.SetRoleHierarchy(skydb.RoleHierarchy{
+				"admin":  []string{"editor"},
+				"editor": []string{"viewer"},
+			}), ShouldBeNil)
+
+			fetched := skydb.AuthInfo{}
+			So(c.GetAuth("userid", &fetched), ShouldBeNil)
+			So(fetched.Roles, ShouldResemble, []string{"admin"})
+			So(fetched.InheritedRoles, ShouldResemble, []string{"editor", "viewer"})
+			So(fetched.HasAnyRoles([]string{"viewer"}), ShouldBeTrue)
+		})
+	})
+}
+
+func TestRolePermissions(t *testing.T) {
+	var c *conn
+
+	Convey("RolePermissions", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		Convey("set and get role permissions", func() {
+			So(c.SetRolePermissions("support", []string{"auth:disable:set", "auth:unlock"}), ShouldBeNil)
+			So(c.SetRolePermissions("editor", []string{"record:*"}), ShouldBeNil)
+
+			permissions, err := c.GetRolePermissions()
+			So(err, ShouldBeNil)
+			So(permissions, ShouldResemble, skydb.RolePermissions{
+				"editor":  []string{"record:*"},
+				"support": []string{"auth:disable:set", "auth:unlock"},
+			})
+		})
+
+		Convey("replace and remove role permissions", func() {
+			So(c.SetRolePermissions("support", []string{"auth:disable:set"}), ShouldBeNil)
+			So(c.SetRolePermissions("support", []string{"auth:unlock"}), ShouldBeNil)
+
+			permissions, err := c.GetRolePermissions()
+			So(err, ShouldBeNil)
+			So(permissions, ShouldResemble, skydb.RolePermissions{
+				"support": []string{"auth:unlock"},
+			})
+
+			So(c.SetRolePermissions("support", nil), ShouldBeNil)
+			permissions, err = c.GetRolePermissions()
+			So(err, ShouldBeNil)
+			So(permissions, ShouldResemble, skydb.RolePermissions{})
+		})
+	})
+}
@@ -0,0 +1,232 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/lann/squirrel"
+	"github.com/lib/pq"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var timerColumns = []string{
+	"name",
+	"spec",
+	"catch_up",
+	"next_run_at",
+	"locked_until",
+	"last_run_at",
+	"last_finished_at",
+	"last_status",
+	"last_error",
+}
+
+// RegisterTimer keeps the next run of an existing timer unless its spec
+// is changed, so that registering the timers on every server instance
+// start does not postpone their runs.
+func (c *conn) RegisterTimer(timer *skydb.Timer) error {
+	builder := psql.Insert(c.tableName("_timer")).Columns(
+		"name",
+		"spec",
+		"catch_up",
+		"next_run_at",
+	).Values(
+		timer.Name,
+		timer.Spec,
+		timer.CatchUp,
+		timer.NextRunAt,
+	).Suffix(`ON CONFLICT (name) DO UPDATE SET
+		spec = EXCLUDED.spec,
+		catch_up = EXCLUDED.catch_up,
+		next_run_at = CASE WHEN _timer.spec = EXCLUDED.spec THEN _timer.next_run_at ELSE EXCLUDED.next_run_at END
+		WHERE _timer.spec <> EXCLUDED.spec OR _timer.catch_up <> EXCLUDED.catch_up`)
+
+	_, err := c.ExecWith(builder)
+	return err
+}
+
+func (c *conn) GetTimer(name string, timer *skydb.Timer) error {
+	builder := psql.Select(timerColumns...).
+		From(c.tableName("_timer")).
+		Where("name = ?", name)
+
+	return c.doScanTimer(timer, c.QueryRowWith(builder))
+}
+
+func (c *conn) UpdateTimer(timer *skydb.Timer) error {
+	builder := psql.Update(c.tableName("_timer")).
+		Set("next_run_at", timer.NextRunAt).
+		Set("locked_until", timer.LockedUntil).
+		Set("last_run_at", timer.LastRunAt).
+		Set("last_finished_at", timer.LastFinishedAt).
+		Set("last_status", nullString(string(timer.LastStatus))).
+		Set("last_error", nullString(timer.LastError)).
+		Where("name = ?", timer.Name)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrTimerNotFound
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) QueryTimers() ([]skydb.Timer, error) {
+	builder := psql.Select(timerColumns...).
+		From(c.tableName("_timer")).
+		OrderBy("name")
+
+	return c.queryTimers(builder)
+}
+
+// ClaimTimer locks the due timer with SKIP LOCKED, so that concurrent
+// server instances do not claim the same tick of a timer.
+func (c *conn) ClaimTimer(names []string, now time.Time, lockedUntil time.Time, timer *skydb.Timer) error {
+	if len(names) == 0 {
+		return skydb.ErrTimerNotFound
+	}
+
+	table := c.tableName("_timer")
+	placeholders := strings.Repeat("?, ", len(names)-1) + "?"
+	args := []interface{}{}
+	for _, name := range names {
+		args = append(args, name)
+	}
+	args = append(args, now, now)
+
+	builder := psql.Update(table).
+		Set("locked_until", lockedUntil).
+		Where(fmt.Sprintf(`name = (
+			SELECT name FROM %s
+			WHERE name IN (%s) AND next_run_at <= ?
+				AND (locked_until IS NULL OR locked_until <= ?)
+			ORDER BY next_run_at, name
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)`, table, placeholders), args...).
+		Suffix("RETURNING " + strings.Join(timerColumns, ", "))
+
+	return c.doScanTimer(timer, c.QueryRowWith(builder))
+}
+
+// UpdateClaimedTimer fences the update with the lock of the claim.
+func (c *conn) UpdateClaimedTimer(timer *skydb.Timer, lockedUntil time.Time) error {
+	builder := psql.Update(c.tableName("_timer")).
+		Set("next_run_at", timer.NextRunAt).
+		Set("locked_until", timer.LockedUntil).
+		Set("last_run_at", timer.LastRunAt).
+		Set("last_finished_at", timer.LastFinishedAt).
+		Set("last_status", nullString(string(timer.LastStatus))).
+		Set("last_error", nullString(timer.LastError)).
+		Where("name = ? AND locked_until = ?", timer.Name, lockedUntil)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		if err := c.GetTimer(timer.Name, &skydb.Timer{}); err != nil {
+			return err
+		}
+		return skydb.ErrLeaseLost
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) LockTimer(name string, now time.Time, lockedUntil time.Time, timer *skydb.Timer) error {
+	builder := psql.Update(c.tableName("_timer")).
+		Set("locked_until", lockedUntil).
+		Where("name = ? AND (locked_until IS NULL OR locked_until <= ?)", name, now).
+		Suffix("RETURNING " + strings.Join(timerColumns, ", "))
+
+	err := c.doScanTimer(timer, c.QueryRowWith(builder))
+	if err == skydb.ErrTimerNotFound {
+		// distinguish a locked timer from a missing one
+		if getErr := c.GetTimer(name, &skydb.Timer{}); getErr != nil {
+			return getErr
+		}
+		return skydb.ErrTimerLocked
+	}
+	return err
+}
+
+func (c *conn) queryTimers(builder sq.Sqlizer) ([]skydb.Timer, error) {
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	timers := []skydb.Timer{}
+	for rows.Next() {
+		timer := skydb.Timer{}
+		if err := c.doScanTimer(&timer, rows); err != nil {
+			return nil, err
+		}
+		timers = append(timers, timer)
+	}
+	return timers, rows.Err()
+}
+
+func (c *conn) doScanTimer(timer *skydb.Timer, scanner sq.RowScanner) error {
+	var (
+		lockedUntil    pq.NullTime
+		lastRunAt      pq.NullTime
+		lastFinishedAt pq.NullTime
+		lastStatus     sql.NullString
+		lastError      sql.NullString
+	)
+	err := scanner.Scan(
+		&timer.Name,
+		&timer.Spec,
+		&timer.CatchUp,
+		&timer.NextRunAt,
+		&lockedUntil,
+		&lastRunAt,
+		&lastFinishedAt,
+		&lastStatus,
+		&lastError,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrTimerNotFound
+	} else if err != nil {
+		return err
+	}
+
+	timer.LockedUntil = nullTimePtr(lockedUntil)
+	timer.LastRunAt = nullTimePtr(lastRunAt)
+	timer.LastFinishedAt = nullTimePtr(lastFinishedAt)
+	timer.LastStatus = skydb.TimerStatus(lastStatus.String)
+	timer.LastError = lastError.String
+	return nil
+}
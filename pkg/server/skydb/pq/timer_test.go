@@ -0,0 +1,113 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTimerConn(t *testing.T) {
+	var c *conn
+
+	Convey("Conn", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		timer := skydb.Timer{
+			Name:      "cleanup",
+			Spec:      "0 0 * * * *",
+			NextRunAt: now,
+		}
+
+		Convey("register timer keeping next run unless spec changed", func() {
+			So(c.RegisterTimer(&timer), ShouldBeNil)
+
+			rescheduled := timer
+			rescheduled.NextRunAt = now.Add(time.Hour)
+			rescheduled.CatchUp = true
+			So(c.RegisterTimer(&rescheduled), ShouldBeNil)
+
+			fetched := skydb.Timer{}
+			So(c.GetTimer("cleanup", &fetched), ShouldBeNil)
+			So(fetched.CatchUp, ShouldBeTrue)
+			So(fetched.NextRunAt.Unix(), ShouldEqual, now.Unix())
+
+			rescheduled.Spec = "0 */5 * * * *"
+			So(c.RegisterTimer(&rescheduled), ShouldBeNil)
+			So(c.GetTimer("cleanup", &fetched), ShouldBeNil)
+			So(fetched.Spec, ShouldEqual, "0 */5 * * * *")
+			So(fetched.NextRunAt.Unix(), ShouldEqual, now.Add(time.Hour).Unix())
+		})
+
+		Convey("claim due timer once", func() {
+			So(c.RegisterTimer(&timer), ShouldBeNil)
+			So(c.RegisterTimer(&skydb.Timer{
+				Name:      "later",
+				Spec:      "0 0 * * * *",
+				NextRunAt: now.Add(time.Hour),
+			}), ShouldBeNil)
+
+			lockedUntil := now.Add(time.Minute)
+			claimed := skydb.Timer{}
+			So(c.ClaimTimer([]string{"cleanup", "later"}, now, lockedUntil, &claimed), ShouldBeNil)
+			So(claimed.Name, ShouldEqual, "cleanup")
+			So(claimed.LockedUntil.Unix(), ShouldEqual, lockedUntil.Unix())
+
+			So(c.ClaimTimer([]string{"cleanup", "later"}, now, lockedUntil, &skydb.Timer{}), ShouldEqual, skydb.ErrTimerNotFound)
+			So(c.ClaimTimer([]string{}, now, lockedUntil, &skydb.Timer{}), ShouldEqual, skydb.ErrTimerNotFound)
+
+			So(c.LockTimer("cleanup", now, lockedUntil, &skydb.Timer{}), ShouldEqual, skydb.ErrTimerLocked)
+			So(c.LockTimer("unknown", now, lockedUntil, &skydb.Timer{}), ShouldEqual, skydb.ErrTimerNotFound)
+
+			finishedAt := now.Add(time.Second)
+			ran := skydb.Timer{
+				Name:           "cleanup",
+				NextRunAt:      now.Add(time.Hour),
+				LastRunAt:      &now,
+				LastFinishedAt: &finishedAt,
+				LastStatus:     skydb.TimerFailed,
+				LastError:      "timed out",
+			}
+			claimedUntil := *claimed.LockedUntil
+			So(c.UpdateClaimedTimer(&ran, claimedUntil.Add(time.Second)), ShouldEqual, skydb.ErrLeaseLost)
+			So(c.UpdateClaimedTimer(&ran, claimedUntil), ShouldBeNil)
+			So(c.UpdateClaimedTimer(&ran, claimedUntil), ShouldEqual, skydb.ErrLeaseLost)
+			So(c.UpdateClaimedTimer(&skydb.Timer{Name: "unknown"}, claimedUntil), ShouldEqual, skydb.ErrTimerNotFound)
+			So(c.UpdateTimer(&ran), ShouldBeNil)
+			So(c.UpdateTimer(&skydb.Timer{Name: "unknown"}), ShouldEqual, skydb.ErrTimerNotFound)
+
+			fetched := skydb.Timer{}
+			So(c.GetTimer("cleanup", &fetched), ShouldBeNil)
+			So(fetched.LockedUntil, ShouldBeNil)
+			So(fetched.LastStatus, ShouldEqual, skydb.TimerFailed)
+			So(fetched.LastError, ShouldEqual, "timed out")
+			So(fetched.LastFinishedAt.Unix(), ShouldEqual, finishedAt.Unix())
+
+			locked := skydb.Timer{}
+			So(c.LockTimer("cleanup", now, lockedUntil, &locked), ShouldBeNil)
+			So(locked.LockedUntil.Unix(), ShouldEqual, lockedUntil.Unix())
+
+			timers, err := c.QueryTimers()
+			So(err, ShouldBeNil)
+			So(timers, ShouldHaveLength, 2)
+			So(timers[0].Name, ShouldEqual, "cleanup")
+		})
+	})
+}
@@ -227,6 +227,15 @@ func (acl aclValue) Value() (driver.Value, error) {
 	return json.Marshal(acl)
 }
 
+type predicateValue []interface{}
+
+func (p predicateValue) Value() (driver.Value, error) {
+	if len(p) == 0 {
+		return nil, nil
+	}
+	return json.Marshal([]interface{}(p))
+}
+
 type locationValue skydb.Location
 
 func (loc locationValue) Value() (driver.Value, error) {
@@ -261,13 +261,25 @@ func (c *conn) doScanAuth(authinfo *skydb.AuthInfo, scanner sq.RowScanner) error
 func (c *conn) GetAuth(id string, authinfo *skydb.AuthInfo) error {
 	builder := c.baseUserBuilder().Where("id = ?", id)
 	scanner := c.QueryRowWith(builder)
-	return c.doScanAuth(authinfo, scanner)
+	if err := c.doScanAuth(authinfo, scanner); err != nil {
+		return err
+	}
+	if err := c.populateInheritedRoles(authinfo); err != nil {
+		return err
+	}
+	return c.populateGroupIDs(authinfo)
 }
 
 func (c *conn) GetAuthByPrincipalID(principalID string, authinfo *skydb.AuthInfo) error {
 	builder := c.baseUserBuilder().Where("jsonb_exists(provider_info, ?)", principalID)
 	scanner := c.QueryRowWith(builder)
-	return c.doScanAuth(authinfo, scanner)
+	if err := c.doScanAuth(authinfo, scanner); err != nil {
+		return err
+	}
+	if err := c.populateInheritedRoles(authinfo); err != nil {
+		return err
+	}
+	return c.populateGroupIDs(authinfo)
 }
 
 func (c *conn) DeleteAuth(id string) error {
@@ -291,6 +303,34 @@ func (c *conn) DeleteAuth(id string) error {
 	return nil
 }
 
+func (c *conn) PurgeAuth(id string) error {
+	var authinfo skydb.AuthInfo
+	if err := c.GetAuth(id, &authinfo); err != nil {
+		return err
+	}
+
+	// Tables referencing _auth without ON DELETE CASCADE, or not
+	// referencing _auth at all. Subscriptions are deleted before devices.
+	deletes := []sq.DeleteBuilder{
+		psql.Delete(c.tableName("_subscription")).Where("auth_id = ?", id),
+		psql.Delete(c.tableName("_device")).Where("auth_id = ?", id),
+		psql.Delete(c.tableName("_auth_role")).Where("auth_id = ?", id),
+		psql.Delete(c.relationTableName("_friend")).Where("left_id = ? OR right_id = ?", id, id),
+		psql.Delete(c.relationTableName("_follow")).Where("left_id = ? OR right_id = ?", id, id),
+		psql.Delete(c.tableName("_password_history")).Where("auth_id = ?", id),
+		psql.Delete(c.tableName("_verify_code")).Where("auth_id = ?", id),
+		psql.Delete(c.tableName("_sso_oauth")).Where("user_id = ?", id),
+		psql.Delete(c.tableName("_sso_custom_token")).Where("user_id = ?", id),
+	}
+	for _, builder := range deletes {
+		if _, err := c.ExecWith(builder); err != nil {
+			return err
+		}
+	}
+
+	return c.DeleteAuth(id)
+}
+
 func (c *conn) basePasswordHistoryBuilder(authID string) sq.SelectBuilder {
 	return psql.Select("id", "auth_id", "password", "logged_at").
 		From(c.tableName("_password_history")).
@@ -0,0 +1,103 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"fmt"
+	"time"
+
+	sq "github.com/lann/squirrel"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var userDataExportColumns = []string{
+	"asset_name",
+	"user_id",
+	"created_at",
+	"expired_at",
+}
+
+func (c *conn) CreateUserDataExport(export *skydb.UserDataExport) error {
+	if export.CreatedAt.IsZero() {
+		export.CreatedAt = time.Now().UTC()
+	}
+
+	builder := psql.Insert(c.tableName("_user_data_export")).
+		Columns(userDataExportColumns...).
+		Values(
+			export.AssetName,
+			export.UserID,
+			export.CreatedAt,
+			export.ExpiredAt,
+		)
+
+	_, err := c.ExecWith(builder)
+	if isUniqueViolated(err) {
+		return fmt.Errorf("skydb: duplicated user data export %s", export.AssetName)
+	}
+	return err
+}
+
+func (c *conn) QueryUserDataExports(userID string) ([]skydb.UserDataExport, error) {
+	builder := psql.Select(userDataExportColumns...).
+		From(c.tableName("_user_data_export")).
+		Where("user_id = ?", userID).
+		OrderBy("asset_name")
+
+	return c.queryUserDataExports(builder)
+}
+
+func (c *conn) QueryExpiredUserDataExports(now time.Time, limit uint64) ([]skydb.UserDataExport, error) {
+	builder := psql.Select(userDataExportColumns...).
+		From(c.tableName("_user_data_export")).
+		Where("expired_at <= ?", now).
+		OrderBy("expired_at", "asset_name").
+		Limit(limit)
+
+	return c.queryUserDataExports(builder)
+}
+
+func (c *conn) DeleteUserDataExport(assetName string) error {
+	builder := psql.Delete(c.tableName("_user_data_export")).
+		Where("asset_name = ?", assetName)
+
+	_, err := c.ExecWith(builder)
+	return err
+}
+
+func (c *conn) queryUserDataExports(builder sq.Sqlizer) ([]skydb.UserDataExport, error) {
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exports := []skydb.UserDataExport{}
+	for rows.Next() {
+		export := skydb.UserDataExport{}
+		if err := rows.Scan(
+			&export.AssetName,
+			&export.UserID,
+			&export.CreatedAt,
+			&export.ExpiredAt,
+		); err != nil {
+			return nil, err
+		}
+		export.CreatedAt = export.CreatedAt.UTC()
+		export.ExpiredAt = export.ExpiredAt.UTC()
+		exports = append(exports, export)
+	}
+	return exports, rows.Err()
+}
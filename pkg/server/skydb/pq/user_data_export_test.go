@@ -0,0 +1,82 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUserDataExportConn(t *testing.T) {
+	var c *conn
+
+	Convey("Conn", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		exports := []skydb.UserDataExport{
+			{
+				AssetName: "alice-1-export.zip",
+				UserID:    "alice",
+				CreatedAt: now.Add(-2 * time.Hour),
+				ExpiredAt: now.Add(-time.Hour),
+			},
+			{
+				AssetName: "alice-2-export.zip",
+				UserID:    "alice",
+				CreatedAt: now,
+				ExpiredAt: now.Add(time.Hour),
+			},
+			{
+				AssetName: "bob-1-export.zip",
+				UserID:    "bob",
+				CreatedAt: now.Add(-3 * time.Hour),
+				ExpiredAt: now.Add(-2 * time.Hour),
+			},
+		}
+		for i := range exports {
+			So(c.CreateUserDataExport(&exports[i]), ShouldBeNil)
+		}
+
+		Convey("query exports of user", func() {
+			fetched, err := c.QueryUserDataExports("alice")
+			So(err, ShouldBeNil)
+			So(fetched, ShouldResemble, exports[:2])
+		})
+
+		Convey("query expired exports", func() {
+			fetched, err := c.QueryExpiredUserDataExports(now, 10)
+			So(err, ShouldBeNil)
+			So(fetched, ShouldResemble, []skydb.UserDataExport{exports[2], exports[0]})
+
+			fetched, err = c.QueryExpiredUserDataExports(now, 1)
+			So(err, ShouldBeNil)
+			So(fetched, ShouldResemble, []skydb.UserDataExport{exports[2]})
+		})
+
+		Convey("delete export", func() {
+			So(c.DeleteUserDataExport("alice-1-export.zip"), ShouldBeNil)
+			So(c.DeleteUserDataExport("alice-1-export.zip"), ShouldBeNil)
+
+			fetched, err := c.QueryUserDataExports("alice")
+			So(err, ShouldBeNil)
+			So(fetched, ShouldResemble, exports[1:2])
+		})
+	})
+}
@@ -216,6 +216,27 @@ func TestAuthCRUD(t *testing.T) {
 			So(c.DeleteAuth("notexistid"), ShouldEqual, skydb.ErrUserNotFound)
 		})
 
+		Convey("purges an existing user with associated data", func() {
+			So(c.CreateAuth(&authinfo), ShouldBeNil)
+			addDevice(t, c, "userid", "deviceid")
+			authinfo.Roles = []string{"writer"}
+			So(c.UpdateUserRoles(&authinfo), ShouldBeNil)
+
+			So(c.PurgeAuth("userid"), ShouldBeNil)
+
+			count := 0
+			c.QueryRowx("SELECT COUNT(*) FROM _auth WHERE id = $1", "userid").Scan(&count)
+			So(count, ShouldEqual, 0)
+			c.QueryRowx("SELECT COUNT(*) FROM _device WHERE auth_id = $1", "userid").Scan(&count)
+			So(count, ShouldEqual, 0)
+			c.QueryRowx("SELECT COUNT(*) FROM _auth_role WHERE auth_id = $1", "userid").Scan(&count)
+			So(count, ShouldEqual, 0)
+		})
+
+		Convey("returns ErrUserNotFound when the user to purge does not exist", func() {
+			So(c.PurgeAuth("notexistid"), ShouldEqual, skydb.ErrUserNotFound)
+		})
+
 		Convey("deletes only the desired user", func() {
 			authinfo.ID = "1"
 			So(c.CreateAuth(&authinfo), ShouldBeNil)
@@ -0,0 +1,351 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/lann/squirrel"
+	"github.com/lib/pq"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var webhookColumns = []string{
+	"id",
+	"url",
+	"secret",
+	"record_type",
+	"events",
+	"disabled",
+	"created_at",
+}
+
+var webhookDeliveryColumns = []string{
+	"id",
+	"webhook_id",
+	"event",
+	"payload",
+	"status",
+	"attempts",
+	"next_attempt_at",
+	"last_attempt_at",
+	"last_error",
+	"response_status",
+	"created_at",
+}
+
+func (c *conn) CreateWebhook(webhook *skydb.Webhook) error {
+	if webhook.CreatedAt.IsZero() {
+		webhook.CreatedAt = time.Now().UTC()
+	}
+
+	builder := psql.Insert(c.tableName("_webhook")).Columns(webhookColumns...).Values(
+		webhook.ID,
+		webhook.URL,
+		webhook.Secret,
+		nullString(webhook.RecordType),
+		stringArray(webhook.Events),
+		webhook.Disabled,
+		webhook.CreatedAt,
+	)
+
+	_, err := c.ExecWith(builder)
+	if isUniqueViolated(err) {
+		return fmt.Errorf("skydb: duplicated webhook %s", webhook.ID)
+	}
+	return err
+}
+
+func (c *conn) GetWebhook(id string, webhook *skydb.Webhook) error {
+	builder := psql.Select(webhookColumns...).
+		From(c.tableName("_webhook")).
+		Where("id = ?", id)
+
+	return c.doScanWebhook(webhook, c.QueryRowWith(builder))
+}
+
+func (c *conn) UpdateWebhook(webhook *skydb.Webhook) error {
+	builder := psql.Update(c.tableName("_webhook")).
+		Set("url", webhook.URL).
+		Set("secret", webhook.Secret).
+		Set("record_type", nullString(webhook.RecordType)).
+		Set("events", stringArray(webhook.Events)).
+		Set("disabled", webhook.Disabled).
+		Where("id = ?", webhook.ID)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrWebhookNotFound
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) DeleteWebhook(id string) error {
+	builder := psql.Delete(c.tableName("_webhook")).
+		Where("id = ?", id)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrWebhookNotFound
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows deleted, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) QueryWebhooks() ([]skydb.Webhook, error) {
+	builder := psql.Select(webhookColumns...).
+		From(c.tableName("_webhook")).
+		OrderBy("created_at", "id")
+
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []skydb.Webhook{}
+	for rows.Next() {
+		webhook := skydb.Webhook{}
+		if err := c.doScanWebhook(&webhook, rows); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (c *conn) doScanWebhook(webhook *skydb.Webhook, scanner sq.RowScanner) error {
+	var (
+		recordType sql.NullString
+		events     pq.StringArray
+	)
+	err := scanner.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Secret,
+		&recordType,
+		&events,
+		&webhook.Disabled,
+		&webhook.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrWebhookNotFound
+	} else if err != nil {
+		return err
+	}
+
+	webhook.RecordType = recordType.String
+	webhook.Events = []string(events)
+	return nil
+}
+
+func (c *conn) CreateWebhookDelivery(delivery *skydb.WebhookDelivery) error {
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now().UTC()
+	}
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = delivery.CreatedAt
+	}
+
+	builder := psql.Insert(c.tableName("_webhook_delivery")).Columns(webhookDeliveryColumns...).Values(
+		delivery.ID,
+		delivery.WebhookID,
+		delivery.Event,
+		string(delivery.Payload),
+		string(delivery.Status),
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastAttemptAt,
+		nullString(delivery.LastError),
+		delivery.ResponseStatus,
+		delivery.CreatedAt,
+	)
+
+	_, err := c.ExecWith(builder)
+	if isUniqueViolated(err) {
+		return fmt.Errorf("skydb: duplicated webhook delivery %s", delivery.ID)
+	} else if isForeignKeyViolated(err) {
+		return skydb.ErrWebhookNotFound
+	}
+	return err
+}
+
+func (c *conn) GetWebhookDelivery(id string, delivery *skydb.WebhookDelivery) error {
+	builder := psql.Select(webhookDeliveryColumns...).
+		From(c.tableName("_webhook_delivery")).
+		Where("id = ?", id)
+
+	return c.doScanWebhookDelivery(delivery, c.QueryRowWith(builder))
+}
+
+func (c *conn) UpdateWebhookDelivery(delivery *skydb.WebhookDelivery) error {
+	builder := psql.Update(c.tableName("_webhook_delivery")).
+		Set("status", string(delivery.Status)).
+		Set("attempts", delivery.Attempts).
+		Set("next_attempt_at", delivery.NextAttemptAt).
+		Set("last_attempt_at", delivery.LastAttemptAt).
+		Set("last_error", nullString(delivery.LastError)).
+		Set("response_status", delivery.ResponseStatus).
+		Where("id = ?", delivery.ID)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return skydb.ErrWebhookDeliveryNotFound
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) QueryWebhookDeliveries(webhookID string, status skydb.WebhookDeliveryStatus, limit uint64) ([]skydb.WebhookDelivery, error) {
+	builder := psql.Select(webhookDeliveryColumns...).
+		From(c.tableName("_webhook_delivery")).
+		Where("webhook_id = ?", webhookID).
+		OrderBy("created_at DESC", "id").
+		Limit(limit)
+	if status != "" {
+		builder = builder.Where("status = ?", string(status))
+	}
+
+	return c.queryWebhookDeliveries(builder)
+}
+
+// ClaimWebhookDelivery locks the due delivery with SKIP LOCKED, so that
+// concurrent server instances claim different deliveries.
+func (c *conn) ClaimWebhookDelivery(now time.Time, leaseUntil time.Time, delivery *skydb.WebhookDelivery) error {
+	table := c.tableName("_webhook_delivery")
+	builder := psql.Update(table).
+		Set("next_attempt_at", leaseUntil).
+		Where(fmt.Sprintf(`id = (
+			SELECT id FROM %s
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)`, table), string(skydb.WebhookDeliveryPending), now).
+		Suffix("RETURNING " + strings.Join(webhookDeliveryColumns, ", "))
+
+	return c.doScanWebhookDelivery(delivery, c.QueryRowWith(builder))
+}
+
+// UpdateClaimedWebhookDelivery fences the update with the lease, which is
+// the next attempt of a claimed delivery.
+func (c *conn) UpdateClaimedWebhookDelivery(delivery *skydb.WebhookDelivery, leaseUntil time.Time) error {
+	builder := psql.Update(c.tableName("_webhook_delivery")).
+		Set("status", string(delivery.Status)).
+		Set("attempts", delivery.Attempts).
+		Set("next_attempt_at", delivery.NextAttemptAt).
+		Set("last_attempt_at", delivery.LastAttemptAt).
+		Set("last_error", nullString(delivery.LastError)).
+		Set("response_status", delivery.ResponseStatus).
+		Where("id = ? AND status = ? AND next_attempt_at = ?",
+			delivery.ID, string(skydb.WebhookDeliveryPending), leaseUntil)
+
+	result, err := c.ExecWith(builder)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		if err := c.GetWebhookDelivery(delivery.ID, &skydb.WebhookDelivery{}); err != nil {
+			return err
+		}
+		return skydb.ErrLeaseLost
+	} else if rowsAffected > 1 {
+		panic(fmt.Errorf("want 1 rows updated, got %v", rowsAffected))
+	}
+	return nil
+}
+
+func (c *conn) queryWebhookDeliveries(builder sq.Sqlizer) ([]skydb.WebhookDelivery, error) {
+	rows, err := c.QueryWith(builder)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []skydb.WebhookDelivery{}
+	for rows.Next() {
+		delivery := skydb.WebhookDelivery{}
+		if err := c.doScanWebhookDelivery(&delivery, rows); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (c *conn) doScanWebhookDelivery(delivery *skydb.WebhookDelivery, scanner sq.RowScanner) error {
+	var (
+		payload       []byte
+		status        string
+		lastAttemptAt pq.NullTime
+		lastError     sql.NullString
+	)
+	err := scanner.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.Event,
+		&payload,
+		&status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&lastAttemptAt,
+		&lastError,
+		&delivery.ResponseStatus,
+		&delivery.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return skydb.ErrWebhookDeliveryNotFound
+	} else if err != nil {
+		return err
+	}
+
+	delivery.Payload = payload
+	delivery.Status = skydb.WebhookDeliveryStatus(status)
+	delivery.LastAttemptAt = nullTimePtr(lastAttemptAt)
+	delivery.LastError = lastError.String
+	return nil
+}
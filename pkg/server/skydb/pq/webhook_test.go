@@ -0,0 +1,137 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pq
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebhookConn(t *testing.T) {
+	var c *conn
+
+	Convey("Conn", t, func() {
+		c = getTestConn(t)
+		defer cleanupConn(t, c)
+
+		createdAt := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		webhook := skydb.Webhook{
+			ID:         "webhook-id",
+			URL:        "https://example.com/hook",
+			Secret:     "secret",
+			RecordType: "note",
+			Events:     []string{"afterSave", "afterDelete"},
+			CreatedAt:  createdAt,
+		}
+
+		Convey("create, update and delete webhook", func() {
+			So(c.CreateWebhook(&webhook), ShouldBeNil)
+
+			fetched := skydb.Webhook{}
+			So(c.GetWebhook("webhook-id", &fetched), ShouldBeNil)
+			So(fetched.URL, ShouldEqual, "https://example.com/hook")
+			So(fetched.Secret, ShouldEqual, "secret")
+			So(fetched.RecordType, ShouldEqual, "note")
+			So(fetched.Events, ShouldResemble, []string{"afterSave", "afterDelete"})
+			So(fetched.Disabled, ShouldBeFalse)
+			So(fetched.CreatedAt.Unix(), ShouldEqual, createdAt.Unix())
+
+			webhook.Disabled = true
+			webhook.Events = []string{"*"}
+			So(c.UpdateWebhook(&webhook), ShouldBeNil)
+			So(c.GetWebhook("webhook-id", &fetched), ShouldBeNil)
+			So(fetched.Disabled, ShouldBeTrue)
+			So(fetched.Events, ShouldResemble, []string{"*"})
+
+			webhooks, err := c.QueryWebhooks()
+			So(err, ShouldBeNil)
+			So(webhooks, ShouldHaveLength, 1)
+
+			So(c.DeleteWebhook("webhook-id"), ShouldBeNil)
+			So(c.GetWebhook("webhook-id", &fetched), ShouldEqual, skydb.ErrWebhookNotFound)
+			So(c.DeleteWebhook("webhook-id"), ShouldEqual, skydb.ErrWebhookNotFound)
+		})
+
+		Convey("create and claim deliveries", func() {
+			So(c.CreateWebhook(&webhook), ShouldBeNil)
+
+			due := skydb.WebhookDelivery{
+				ID:            "due",
+				WebhookID:     "webhook-id",
+				Event:         "afterSave",
+				Payload:       json.RawMessage(`{"record":{"_id":"note/1"}}`),
+				Status:        skydb.WebhookDeliveryPending,
+				NextAttemptAt: createdAt,
+				CreatedAt:     createdAt,
+			}
+			later := due
+			later.ID = "later"
+			later.NextAttemptAt = createdAt.Add(time.Hour)
+			So(c.CreateWebhookDelivery(&due), ShouldBeNil)
+			So(c.CreateWebhookDelivery(&later), ShouldBeNil)
+
+			leaseUntil := createdAt.Add(time.Minute)
+			claimed := skydb.WebhookDelivery{}
+			So(c.ClaimWebhookDelivery(createdAt, leaseUntil, &claimed), ShouldBeNil)
+			So(claimed.ID, ShouldEqual, "due")
+			So(string(claimed.Payload), ShouldEqual, `{"record": {"_id": "note/1"}}`)
+			So(claimed.NextAttemptAt.Unix(), ShouldEqual, leaseUntil.Unix())
+
+			So(c.ClaimWebhookDelivery(createdAt, leaseUntil, &skydb.WebhookDelivery{}), ShouldEqual, skydb.ErrWebhookDeliveryNotFound)
+
+			claimedUntil := claimed.NextAttemptAt
+			claimed.Status = skydb.WebhookDeliveryDelivered
+			claimed.Attempts = 1
+			claimed.ResponseStatus = 200
+			claimed.LastAttemptAt = &createdAt
+			So(c.UpdateClaimedWebhookDelivery(&claimed, claimedUntil.Add(time.Second)), ShouldEqual, skydb.ErrLeaseLost)
+			So(c.UpdateClaimedWebhookDelivery(&claimed, claimedUntil), ShouldBeNil)
+			So(c.UpdateClaimedWebhookDelivery(&claimed, claimedUntil), ShouldEqual, skydb.ErrLeaseLost)
+
+			missing := claimed
+			missing.ID = "missing"
+			So(c.UpdateClaimedWebhookDelivery(&missing, claimedUntil), ShouldEqual, skydb.ErrWebhookDeliveryNotFound)
+
+			later.Status = skydb.WebhookDeliveryFailed
+			So(c.UpdateWebhookDelivery(&later), ShouldBeNil)
+			So(c.UpdateWebhookDelivery(&missing), ShouldEqual, skydb.ErrWebhookDeliveryNotFound)
+
+			deliveries, err := c.QueryWebhookDeliveries("webhook-id", skydb.WebhookDeliveryDelivered, 10)
+			So(err, ShouldBeNil)
+			So(deliveries, ShouldHaveLength, 1)
+			So(deliveries[0].ID, ShouldEqual, "due")
+			So(deliveries[0].ResponseStatus, ShouldEqual, 200)
+
+			deliveries, err = c.QueryWebhookDeliveries("webhook-id", "", 10)
+			So(err, ShouldBeNil)
+			So(deliveries, ShouldHaveLength, 2)
+		})
+
+		Convey("return not found for delivery of unknown webhook", func() {
+			delivery := skydb.WebhookDelivery{
+				ID:        "delivery",
+				WebhookID: "unknown",
+				Event:     "afterSave",
+				Payload:   json.RawMessage(`{}`),
+				Status:    skydb.WebhookDeliveryPending,
+			}
+			So(c.CreateWebhookDelivery(&delivery), ShouldEqual, skydb.ErrWebhookNotFound)
+		})
+	})
+}
@@ -290,6 +290,45 @@ func (p Predicate) validateEqualPredicate(parentPredicate *Predicate) skyerr.Err
 	return nil
 }
 
+// BindCurrentUser returns a copy of the predicate with CurrentUser
+// literals replaced by the user ID, or null if userID is empty. User
+// relation functions without a user are bound to the user as well.
+func (p Predicate) BindCurrentUser(userID string) Predicate {
+	if p.IsEmpty() {
+		return p
+	}
+
+	var value interface{}
+	if userID != "" {
+		value = userID
+	}
+
+	bound := Predicate{
+		Operator: p.Operator,
+		Children: make([]interface{}, len(p.Children)),
+	}
+	for i, child := range p.Children {
+		switch c := child.(type) {
+		case Predicate:
+			bound.Children[i] = c.BindCurrentUser(userID)
+		case Expression:
+			switch v := c.Value.(type) {
+			case CurrentUser:
+				c.Value = value
+			case UserRelationFunc:
+				if v.User == "" {
+					v.User = userID
+					c.Value = v
+				}
+			}
+			bound.Children[i] = c
+		default:
+			bound.Children[i] = child
+		}
+	}
+	return bound
+}
+
 // GetSubPredicates returns Predicate.Children as []Predicate.
 //
 // This method is only valid when Operator is either And, Or and Not. Caller
@@ -380,6 +419,11 @@ func (q Query) Accept(visitor Visitor) {
 	}
 }
 
+// CurrentUser is a literal in a Predicate that stands for the ID of the
+// user performing the query. It is replaced by Predicate.BindCurrentUser
+// before the predicate is executed.
+type CurrentUser struct{}
+
 // AccessControlOptions provide access control options to query.
 //
 // The following fields are generated from the server side, rather
@@ -387,6 +431,10 @@ func (q Query) Accept(visitor Visitor) {
 type AccessControlOptions struct {
 	ViewAsUser          *AuthInfo
 	BypassAccessControl bool
+
+	// PredicateLevel selects the record predicate of the record type
+	// applied to the query. ReadLevel is used if it is empty.
+	PredicateLevel RecordACLLevel
 }
 
 // Func is a marker interface to denote a type being a function in skydb.
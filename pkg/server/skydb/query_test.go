@@ -113,6 +113,65 @@ func TestPredicate(t *testing.T) {
 				p.Accept(v)
 			})
 		})
+
+		Convey("BindCurrentUser", func() {
+			p := Predicate{
+				Operator: And,
+				Children: []interface{}{
+					Predicate{
+						Operator: Equal,
+						Children: []interface{}{
+							Expression{KeyPath, "owner_id"},
+							Expression{Literal, CurrentUser{}},
+						},
+					},
+					Predicate{
+						Operator: Functional,
+						Children: []interface{}{
+							Expression{Function, UserRelationFunc{"_owner", "_friend", "outward", ""}},
+						},
+					},
+				},
+			}
+
+			Convey("should replace current user with user ID", func() {
+				bound := p.BindCurrentUser("johndoe")
+				So(bound, ShouldResemble, Predicate{
+					Operator: And,
+					Children: []interface{}{
+						Predicate{
+							Operator: Equal,
+							Children: []interface{}{
+								Expression{KeyPath, "owner_id"},
+								Expression{Literal, "johndoe"},
+							},
+						},
+						Predicate{
+							Operator: Functional,
+							Children: []interface{}{
+								Expression{Function, UserRelationFunc{"_owner", "_friend", "outward", "johndoe"}},
+							},
+						},
+					},
+				})
+			})
+
+			Convey("should replace current user with null without user", func() {
+				bound := p.BindCurrentUser("")
+				equal := bound.Children[0].(Predicate)
+				So(equal.Children[1], ShouldResemble, Expression{Literal, nil})
+			})
+
+			Convey("should not modify the original predicate", func() {
+				p.BindCurrentUser("johndoe")
+				equal := p.Children[0].(Predicate)
+				So(equal.Children[1], ShouldResemble, Expression{Literal, CurrentUser{}})
+			})
+
+			Convey("should return empty predicate as is", func() {
+				So(Predicate{}.BindCurrentUser("johndoe").IsEmpty(), ShouldBeTrue)
+			})
+		})
 	})
 }
 
@@ -114,6 +114,23 @@ func TestRecordACL(t *testing.T) {
 			So(note.Accessible(stranger, ReadLevel), ShouldBeFalse)
 		})
 
+		Convey("Check access right base on inherited role", func() {
+			note := Record{
+				ID:         NewRecordID("note", "0"),
+				DatabaseID: "",
+				ACL: RecordACL{
+					NewRecordACLEntryRole("editor", WriteLevel),
+				},
+			}
+
+			So(note.Accessible(authinfo, WriteLevel), ShouldBeFalse)
+
+			authinfo.InheritedRoles = []string{"editor"}
+			So(note.Accessible(authinfo, WriteLevel), ShouldBeTrue)
+			So(authinfo.HasAnyRoles([]string{"editor"}), ShouldBeTrue)
+			So(authinfo.HasAllRoles([]string{"admin", "editor"}), ShouldBeTrue)
+		})
+
 		Convey("Check access right base on direct ace", func() {
 			note := Record{
 				ID:         NewRecordID("note", "0"),
@@ -127,6 +144,23 @@ func TestRecordACL(t *testing.T) {
 			So(note.Accessible(stranger, ReadLevel), ShouldBeFalse)
 		})
 
+		Convey("Check access right base on group ace", func() {
+			note := Record{
+				ID:         NewRecordID("note", "0"),
+				DatabaseID: "",
+				ACL: RecordACL{
+					NewRecordACLEntryGroup("editors", ReadLevel),
+				},
+			}
+
+			So(note.Accessible(authinfo, ReadLevel), ShouldBeFalse)
+
+			authinfo.GroupIDs = []string{"editors"}
+			So(note.Accessible(authinfo, ReadLevel), ShouldBeTrue)
+			So(note.Accessible(authinfo, WriteLevel), ShouldBeFalse)
+			So(note.Accessible(stranger, ReadLevel), ShouldBeFalse)
+		})
+
 		Convey("Grant permission on any ACE matched", func() {
 			note := Record{
 				ID:         NewRecordID("note", "0"),
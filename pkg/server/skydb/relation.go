@@ -0,0 +1,173 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ErrRelationTypeNotFound is returned by Conn.GetRelationType and
+// Conn.DeleteRelationType when the relation type is not defined.
+var ErrRelationTypeNotFound = errors.New("skydb: Relation type not found")
+
+// ErrRelationTypeDuplicated is returned by Conn.CreateRelationType when
+// the relation type is already defined.
+var ErrRelationTypeDuplicated = errors.New("skydb: Relation type already exists")
+
+// ErrRelationNotFound is returned by Conn.GetRelation when the users are
+// not related.
+var ErrRelationNotFound = errors.New("skydb: Relation not found")
+
+// RelationDirection is the direction of a relation type.
+type RelationDirection string
+
+const (
+	// DirectedRelation relates the left user to the right user only,
+	// like follow.
+	DirectedRelation RelationDirection = "directed"
+	// MutualRelation relates both users to each other. Adding or removing
+	// a relation also adds or removes the reverse relation.
+	MutualRelation RelationDirection = "mutual"
+)
+
+// IsValid returns true if the direction is one of the defined directions.
+func (d RelationDirection) IsValid() bool {
+	return d == DirectedRelation || d == MutualRelation
+}
+
+var relationTypeNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// reservedRelationTypeNames cannot be used as relation type names because
+// relations of a type are stored in a table named after the type, which
+// would clash with the tables of the relation types and requests, or
+// because they refer to the builtin relations.
+var reservedRelationTypeNames = map[string]bool{
+	"type":    true,
+	"request": true,
+	"friend":  true,
+	"follow":  true,
+}
+
+// relationFieldTypes are the types allowed for metadata fields.
+var relationFieldTypes = map[string]bool{
+	"string":   true,
+	"number":   true,
+	"boolean":  true,
+	"datetime": true,
+	"json":     true,
+}
+
+// builtinRelationAliases maps the names of the builtin relations without
+// the leading underscore, as they are referred to in relation ACL entries
+// and queries, to the names of the relations.
+var builtinRelationAliases = map[string]string{
+	"friend": "_friend",
+	"follow": "_follow",
+}
+
+// CanonicalRelationName returns the name of the relation, i.e. _friend
+// and _follow for friend and follow.
+func CanonicalRelationName(name string) string {
+	if relationName, ok := builtinRelationAliases[name]; ok {
+		return relationName
+	}
+	return name
+}
+
+// IsBuiltinRelation returns true if the relation is one of the relations
+// provided by skygear, i.e. _friend and _follow.
+func IsBuiltinRelation(name string) bool {
+	return name == "_friend" || name == "_follow"
+}
+
+// RelationType is a relation between users defined by the app, in
+// addition to the builtin _friend and _follow relations. Relations of the
+// type can carry metadata with the fields declared in Fields, which maps
+// field names to one of string, number, boolean, datetime and json.
+type RelationType struct {
+	Name      string            `json:"name"`
+	Direction RelationDirection `json:"direction"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Validate checks the name, direction and fields of the relation type.
+func (t RelationType) Validate() error {
+	if !relationTypeNameRegexp.MatchString(t.Name) {
+		return fmt.Errorf("invalid relation name %q, want lower case letters, digits and underscores", t.Name)
+	}
+	if reservedRelationTypeNames[t.Name] {
+		return fmt.Errorf("relation name %q is reserved", t.Name)
+	}
+	if !t.Direction.IsValid() {
+		return fmt.Errorf("invalid relation direction %q", t.Direction)
+	}
+	for field, fieldType := range t.Fields {
+		if field == "" {
+			return errors.New("empty metadata field name")
+		}
+		if !relationFieldTypes[fieldType] {
+			return fmt.Errorf("invalid type %q of metadata field %q", fieldType, field)
+		}
+	}
+	return nil
+}
+
+// ValidateMetadata checks that every metadata field is declared and of
+// the declared type. Datetime fields are RFC 3339 strings.
+func (t RelationType) ValidateMetadata(metadata map[string]interface{}) error {
+	for field, value := range metadata {
+		fieldType, ok := t.Fields[field]
+		if !ok {
+			return fmt.Errorf("metadata field %q is not declared", field)
+		}
+		if value == nil {
+			continue
+		}
+
+		valid := true
+		switch fieldType {
+		case "string":
+			_, valid = value.(string)
+		case "number":
+			_, valid = value.(float64)
+		case "boolean":
+			_, valid = value.(bool)
+		case "datetime":
+			s, ok := value.(string)
+			if ok {
+				_, err := time.Parse(time.RFC3339Nano, s)
+				ok = err == nil
+			}
+			valid = ok
+		}
+		if !valid {
+			return fmt.Errorf("metadata field %q is not a %s", field, fieldType)
+		}
+	}
+	return nil
+}
+
+// Relation relates the left user to the right user by a relation type.
+type Relation struct {
+	Name      string                 `json:"name"`
+	LeftID    string                 `json:"left_id"`
+	RightID   string                 `json:"right_id"`
+	CreatedAt time.Time              `json:"created_at"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
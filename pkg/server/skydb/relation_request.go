@@ -0,0 +1,64 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRelationRequestNotFound is returned by Conn.GetRelationRequest when
+// there is no request between the users.
+var ErrRelationRequestNotFound = errors.New("skydb: Relation request not found")
+
+// ErrRelationRequestDuplicated is returned by Conn.CreateRelationRequest
+// when a pending request between the users already exists.
+var ErrRelationRequestDuplicated = errors.New("skydb: Relation request already pending")
+
+// RelationRequestState is the state of a relation request.
+type RelationRequestState string
+
+const (
+	// RelationRequestPending is the state of a request waiting for the
+	// recipient to accept or decline.
+	RelationRequestPending RelationRequestState = "pending"
+	// RelationRequestAccepted is the state of a request accepted by the
+	// recipient. The relation is added when the request is accepted.
+	RelationRequestAccepted RelationRequestState = "accepted"
+	// RelationRequestDeclined is the state of a request declined by the
+	// recipient.
+	RelationRequestDeclined RelationRequestState = "declined"
+	// RelationRequestCancelled is the state of a request cancelled by the
+	// requester.
+	RelationRequestCancelled RelationRequestState = "cancelled"
+)
+
+// RelationRequest is a request from the requester to be related to the
+// recipient, such as a friend request. There is at most one request of
+// a relation between two users; a new request replaces a request that is
+// no longer pending.
+type RelationRequest struct {
+	Name        string               `json:"name"`
+	RequesterID string               `json:"requester_id"`
+	RecipientID string               `json:"recipient_id"`
+	State       RelationRequestState `json:"state"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+}
+
+// IsPending returns true if the request is waiting for the recipient.
+func (r RelationRequest) IsPending() bool {
+	return r.State == RelationRequestPending
+}
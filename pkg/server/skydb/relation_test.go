@@ -0,0 +1,92 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRelationType(t *testing.T) {
+	Convey("RelationType", t, func() {
+		relationType := RelationType{
+			Name:      "colleague",
+			Direction: MutualRelation,
+			Fields: map[string]string{
+				"department": "string",
+				"level":      "number",
+				"active":     "boolean",
+				"since":      "datetime",
+				"extra":      "json",
+			},
+		}
+
+		Convey("validates a relation type", func() {
+			So(relationType.Validate(), ShouldBeNil)
+		})
+
+		Convey("rejects invalid name", func() {
+			relationType.Name = "Colleague"
+			So(relationType.Validate(), ShouldNotBeNil)
+			relationType.Name = "_friend"
+			So(relationType.Validate(), ShouldNotBeNil)
+			relationType.Name = "request"
+			So(relationType.Validate(), ShouldNotBeNil)
+			relationType.Name = "friend"
+			So(relationType.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("rejects invalid direction", func() {
+			relationType.Direction = "inward"
+			So(relationType.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("rejects invalid field type", func() {
+			relationType.Fields["location"] = "geometry"
+			So(relationType.Validate(), ShouldNotBeNil)
+		})
+
+		Convey("validates metadata", func() {
+			err := relationType.ValidateMetadata(map[string]interface{}{
+				"department": "engineering",
+				"level":      float64(3),
+				"active":     true,
+				"since":      "2017-07-23T19:30:24Z",
+				"extra":      map[string]interface{}{"desk": "A1"},
+			})
+			So(err, ShouldBeNil)
+		})
+
+		Convey("rejects undeclared metadata field", func() {
+			err := relationType.ValidateMetadata(map[string]interface{}{
+				"team": "server",
+			})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("rejects metadata of wrong type", func() {
+			err := relationType.ValidateMetadata(map[string]interface{}{
+				"level": "3",
+			})
+			So(err, ShouldNotBeNil)
+
+			err = relationType.ValidateMetadata(map[string]interface{}{
+				"since": "yesterday",
+			})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,88 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"sort"
+)
+
+// RoleHierarchy maps a role to the roles it inherits. A user having a role
+// also has all roles inherited by that role, directly or indirectly.
+//
+// For example, with {"admin": ["editor"], "editor": ["viewer"]}, a user
+// having the "admin" role also has the "editor" and "viewer" roles.
+type RoleHierarchy map[string][]string
+
+// Expand returns the roles inherited by the specified roles, excluding the
+// specified roles themselves, or nil if no roles are inherited. Cyclic
+// inheritance is tolerated.
+func (h RoleHierarchy) Expand(roles []string) []string {
+	visited := map[string]bool{}
+	for _, role := range roles {
+		visited[role] = true
+	}
+
+	var inherited []string
+	queue := append([]string{}, roles...)
+	for len(queue) > 0 {
+		role := queue[0]
+		queue = queue[1:]
+		for _, parent := range h[role] {
+			if visited[parent] {
+				continue
+			}
+			visited[parent] = true
+			inherited = append(inherited, parent)
+			queue = append(queue, parent)
+		}
+	}
+
+	return inherited
+}
+
+// HasCycle returns true if a role inherits itself, directly or indirectly.
+func (h RoleHierarchy) HasCycle() bool {
+	for role := range h {
+		for _, inherited := range h.Expand(h[role]) {
+			if inherited == role {
+				return true
+			}
+		}
+		for _, parent := range h[role] {
+			if parent == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Roles returns all roles in the hierarchy in sorted order.
+func (h RoleHierarchy) Roles() []string {
+	roleMap := map[string]bool{}
+	for role, inherited := range h {
+		roleMap[role] = true
+		for _, r := range inherited {
+			roleMap[r] = true
+		}
+	}
+
+	roles := []string{}
+	for role := range roleMap {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
@@ -0,0 +1,65 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRoleHierarchy(t *testing.T) {
+	Convey("RoleHierarchy", t, func() {
+		hierarchy := RoleHierarchy{
+			"admin":  []string{"editor", "billing"},
+			"editor": []string{"viewer"},
+		}
+
+		Convey("expands inherited roles", func() {
+			So(hierarchy.Expand([]string{"admin"}), ShouldResemble, []string{"editor", "billing", "viewer"})
+			So(hierarchy.Expand([]string{"editor"}), ShouldResemble, []string{"viewer"})
+		})
+
+		Convey("excludes roles already specified", func() {
+			So(hierarchy.Expand([]string{"admin", "viewer"}), ShouldResemble, []string{"editor", "billing"})
+		})
+
+		Convey("returns nil without inherited roles", func() {
+			So(hierarchy.Expand([]string{"viewer"}), ShouldBeNil)
+			So(hierarchy.Expand(nil), ShouldBeNil)
+		})
+
+		Convey("tolerates cyclic inheritance", func() {
+			hierarchy["viewer"] = []string{"admin"}
+			So(hierarchy.Expand([]string{"viewer"}), ShouldResemble, []string{"admin", "editor", "billing"})
+		})
+
+		Convey("detects cycle", func() {
+			So(hierarchy.HasCycle(), ShouldBeFalse)
+
+			hierarchy["viewer"] = []string{"admin"}
+			So(hierarchy.HasCycle(), ShouldBeTrue)
+		})
+
+		Convey("detects self inheritance", func() {
+			hierarchy["viewer"] = []string{"viewer"}
+			So(hierarchy.HasCycle(), ShouldBeTrue)
+		})
+
+		Convey("returns all roles", func() {
+			So(hierarchy.Roles(), ShouldResemble, []string{"admin", "billing", "editor", "viewer"})
+		})
+	})
+}
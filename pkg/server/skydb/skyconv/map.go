@@ -360,9 +360,10 @@ func (ace *MapACLEntry) FromMap(m map[string]interface{}) error {
 	relation, hasRelation := m["relation"].(string)
 	userID, hasUserID := m["user_id"].(string)
 	role, hasRole := m["role"].(string)
+	groupID, hasGroupID := m["group_id"].(string)
 	public, hasPublic := m["public"].(bool)
-	if !hasRelation && !hasUserID && !hasRole && !hasPublic {
-		return errors.New("ACLEntry must have relation, user_id, role or public")
+	if !hasRelation && !hasUserID && !hasRole && !hasGroupID && !hasPublic {
+		return errors.New("ACLEntry must have relation, user_id, role, group_id or public")
 	}
 
 	ace.Level = entryLevel
@@ -375,6 +376,9 @@ func (ace *MapACLEntry) FromMap(m map[string]interface{}) error {
 	if hasUserID {
 		ace.UserID = userID
 	}
+	if hasGroupID {
+		ace.GroupID = groupID
+	}
 	if hasPublic {
 		ace.Public = public
 	}
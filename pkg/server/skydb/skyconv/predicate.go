@@ -0,0 +1,347 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skyconv
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+// PredicateParser parses predicates and expressions in the format of
+// record query, for example:
+//
+//	["eq", {"$type": "keypath", "$val": "status"}, "published"]
+//
+// {"$type": "user"} is parsed to a skydb.CurrentUser literal, which
+// is substituted by skydb.Predicate.BindCurrentUser.
+type PredicateParser struct {
+	// UserID is the user of user relation functions.
+	UserID string
+}
+
+// ParsePredicate parses a predicate.
+func (parser *PredicateParser) ParsePredicate(rawPredicate []interface{}) (predicate skydb.Predicate, err error) {
+	defer recoverParseError(&err)
+
+	predicate = parser.predicateFromRaw(rawPredicate)
+	return
+}
+
+// ParseExpression parses an expression. A value that is neither a key
+// path nor a function is parsed as a literal.
+func (parser *PredicateParser) ParseExpression(i interface{}) (expr skydb.Expression, err error) {
+	defer recoverParseError(&err)
+
+	expr = parser.parseExpression(i)
+	return
+}
+
+func recoverParseError(err *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(runtime.Error); ok {
+			panic(r)
+		}
+		*err = r.(error)
+	}
+}
+
+func (parser *PredicateParser) predicateOperatorFromString(operatorString string) skydb.Operator {
+	switch operatorString {
+	case "and":
+		return skydb.And
+	case "or":
+		return skydb.Or
+	case "not":
+		return skydb.Not
+	case "eq":
+		return skydb.Equal
+	case "gt":
+		return skydb.GreaterThan
+	case "lt":
+		return skydb.LessThan
+	case "gte":
+		return skydb.GreaterThanOrEqual
+	case "lte":
+		return skydb.LessThanOrEqual
+	case "neq":
+		return skydb.NotEqual
+	case "like":
+		return skydb.Like
+	case "ilike":
+		return skydb.ILike
+	case "in":
+		return skydb.In
+	case "func":
+		return skydb.Functional
+	default:
+		panic(fmt.Errorf("unrecognized operator = %s", operatorString))
+	}
+}
+
+func (parser *PredicateParser) predicateFromRaw(rawPredicate []interface{}) skydb.Predicate {
+	if len(rawPredicate) < 2 {
+		panic(fmt.Errorf("got len(predicate) = %v, want at least 2", len(rawPredicate)))
+	}
+
+	rawOperator, ok := rawPredicate[0].(string)
+	if !ok {
+		panic(fmt.Errorf("got predicate[0]'s type = %T, want string", rawPredicate[0]))
+	}
+
+	predicate := skydb.Predicate{
+		Operator: parser.predicateOperatorFromString(rawOperator),
+		Children: make([]interface{}, 0),
+	}
+	if predicate.Operator == skydb.Functional {
+		predicate.Children = append(predicate.Children, parser.parseExpression(rawPredicate))
+	} else if predicate.Operator.IsCompound() {
+		for i := 1; i < len(rawPredicate); i++ {
+			subRawPredicate, ok := rawPredicate[i].([]interface{})
+			if !ok {
+				panic(fmt.Errorf("got non-dict in subpredicate at %v", i-1))
+			}
+			predicate.Children = append(predicate.Children, parser.predicateFromRaw(subRawPredicate))
+		}
+	} else {
+		for i := 1; i < len(rawPredicate); i++ {
+			expr := parser.parseExpression(rawPredicate[i])
+			predicate.Children = append(predicate.Children, expr)
+		}
+	}
+
+	if predicate.Operator.IsBinary() && len(predicate.Children) != 2 {
+		panic(fmt.Errorf("Expected number of expressions be 2, got %v", len(predicate.Children)))
+	}
+
+	return predicate
+}
+
+// parseExpression parses the specific structure into an Expression struct.
+//
+// Accepts one of the following types:
+//
+// * { "$type": "keypath", "$val": "_key_path_name_" }    // key path
+// * { "$type": "user" }                                  // current user
+// * [ "_func_name_" , _expression_1_ , _expression_2_ ]  // function
+// * 42                                                   // literal
+func (parser *PredicateParser) parseExpression(i interface{}) skydb.Expression {
+	switch v := i.(type) {
+	case map[string]interface{}:
+		var keyPath string
+		if err := MapFrom(i, (*MapKeyPath)(&keyPath)); err == nil {
+			if keyPath == "_owner" {
+				keyPath = "_owner_id"
+			}
+			return skydb.Expression{
+				Type:  skydb.KeyPath,
+				Value: keyPath,
+			}
+		}
+		if kind, _ := v["$type"].(string); kind == "user" {
+			return skydb.Expression{
+				Type:  skydb.Literal,
+				Value: skydb.CurrentUser{},
+			}
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			if f, err := parser.parseFunc(v); err == nil {
+				return skydb.Expression{
+					Type:  skydb.Function,
+					Value: f,
+				}
+			}
+		}
+	}
+
+	return skydb.Expression{
+		Type:  skydb.Literal,
+		Value: ParseLiteral(i),
+	}
+}
+
+func (parser *PredicateParser) parseFunc(s []interface{}) (f skydb.Func, err error) {
+	keyword, _ := s[0].(string)
+	if keyword != "func" {
+		return nil, errors.New("not a function")
+	}
+
+	funcName, _ := s[1].(string)
+	switch funcName {
+	case "distance":
+		f, err = parser.parseDistanceFunc(s[2:])
+	case "userRelation":
+		f, err = parser.parseUserRelationFunc(s[2:])
+	case "":
+		return nil, errors.New("empty function name")
+	default:
+		return nil, fmt.Errorf("got unrecgonized function name = %s", funcName)
+	}
+
+	return
+}
+
+func (parser *PredicateParser) parseDistanceFunc(s []interface{}) (skydb.DistanceFunc, error) {
+	emptyDistanceFunc := skydb.DistanceFunc{}
+	if len(s) != 2 {
+		return emptyDistanceFunc, fmt.Errorf("want 2 arguments for distance func, got %d", len(s))
+	}
+
+	var field string
+	if err := MapFrom(s[0], (*MapKeyPath)(&field)); err != nil {
+		return emptyDistanceFunc, fmt.Errorf("invalid key path: %v", err)
+	}
+
+	var location skydb.Location
+	if err := MapFrom(s[1], (*MapLocation)(&location)); err != nil {
+		return emptyDistanceFunc, fmt.Errorf("invalid location: %v", err)
+	}
+
+	return skydb.DistanceFunc{
+		Field:    field,
+		Location: location,
+	}, nil
+}
+
+func (parser *PredicateParser) parseUserRelationFunc(s []interface{}) (skydb.UserRelationFunc, error) {
+	emptyUserRelationFunc := skydb.UserRelationFunc{}
+	if len(s) != 2 {
+		return emptyUserRelationFunc, fmt.Errorf("want 2 arguments for user relation func, got %d", len(s))
+	}
+
+	var field string
+	if err := MapFrom(s[0], (*MapKeyPath)(&field)); err != nil {
+		return emptyUserRelationFunc, fmt.Errorf("invalid key path: %v", err)
+	}
+
+	var relation MapRelation
+	if err := MapFrom(s[1], (*MapRelation)(&relation)); err != nil {
+		return emptyUserRelationFunc, fmt.Errorf("invalid relation: %v", err)
+	}
+
+	return skydb.UserRelationFunc{
+		KeyPath:           field,
+		RelationName:      relation.Name,
+		RelationDirection: relation.Direction,
+		User:              parser.UserID,
+	}, nil
+}
+
+// ToRawPredicate converts a predicate to the format parsed by
+// PredicateParser. It returns nil for an empty predicate.
+func ToRawPredicate(predicate skydb.Predicate) (raw []interface{}, err error) {
+	if predicate.IsEmpty() {
+		return nil, nil
+	}
+
+	defer recoverParseError(&err)
+
+	raw = rawFromPredicate(predicate)
+	return
+}
+
+func operatorString(operator skydb.Operator) string {
+	switch operator {
+	case skydb.And:
+		return "and"
+	case skydb.Or:
+		return "or"
+	case skydb.Not:
+		return "not"
+	case skydb.Equal:
+		return "eq"
+	case skydb.GreaterThan:
+		return "gt"
+	case skydb.LessThan:
+		return "lt"
+	case skydb.GreaterThanOrEqual:
+		return "gte"
+	case skydb.LessThanOrEqual:
+		return "lte"
+	case skydb.NotEqual:
+		return "neq"
+	case skydb.Like:
+		return "like"
+	case skydb.ILike:
+		return "ilike"
+	case skydb.In:
+		return "in"
+	default:
+		panic(fmt.Errorf("unrecognized operator = %v", operator))
+	}
+}
+
+func rawFromPredicate(predicate skydb.Predicate) []interface{} {
+	if predicate.Operator == skydb.Functional {
+		expr, ok := predicate.Children[0].(skydb.Expression)
+		if !ok || expr.Type != skydb.Function {
+			panic(errors.New("functional predicate without function"))
+		}
+		return rawFromFunc(expr.Value)
+	}
+
+	raw := []interface{}{operatorString(predicate.Operator)}
+	for _, child := range predicate.Children {
+		switch c := child.(type) {
+		case skydb.Predicate:
+			raw = append(raw, rawFromPredicate(c))
+		case skydb.Expression:
+			raw = append(raw, rawFromExpression(c))
+		default:
+			panic(fmt.Errorf("got predicate child of type %T", child))
+		}
+	}
+	return raw
+}
+
+func rawFromExpression(expr skydb.Expression) interface{} {
+	switch expr.Type {
+	case skydb.KeyPath:
+		return ToMap(MapKeyPath(expr.Value.(string)))
+	case skydb.Function:
+		return rawFromFunc(expr.Value)
+	case skydb.Literal:
+		if _, ok := expr.Value.(skydb.CurrentUser); ok {
+			return map[string]interface{}{"$type": "user"}
+		}
+		return ToLiteral(expr.Value)
+	default:
+		panic(fmt.Errorf("unrecognized expression type = %v", expr.Type))
+	}
+}
+
+func rawFromFunc(i interface{}) []interface{} {
+	switch f := i.(type) {
+	case skydb.DistanceFunc:
+		return []interface{}{
+			"func",
+			"distance",
+			ToMap(MapKeyPath(f.Field)),
+			ToMap(MapLocation(f.Location)),
+		}
+	case skydb.UserRelationFunc:
+		return []interface{}{
+			"func",
+			"userRelation",
+			ToMap(MapKeyPath(f.KeyPath)),
+			ToMap(&MapRelation{f.RelationName, f.RelationDirection}),
+		}
+	default:
+		panic(fmt.Errorf("unsupported function = %T", i))
+	}
+}
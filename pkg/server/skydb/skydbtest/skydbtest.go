@@ -17,6 +17,7 @@ package skydbtest
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"time"
 
 	"github.com/skygeario/skygear-server/pkg/server/skydb"
@@ -32,6 +33,23 @@ type MapConn struct {
 	fieldAccess            skydb.FieldACL
 	OAuthMap               map[string]skydb.OAuthInfo
 	CustomTokenInfoMap     map[string]skydb.CustomTokenInfo
+	OAuthClientMap         map[string]skydb.OAuthClient
+	OAuthCodeMap           map[string]skydb.OAuthAuthorizationCode
+	APIKeyMap              map[string]skydb.APIKey
+	RoleHierarchy          skydb.RoleHierarchy
+	RolePermissions        skydb.RolePermissions
+	GroupMap               map[string]skydb.Group
+	GroupMemberMap         map[string]map[string]skydb.GroupMembership
+	PredicateAccessMap     map[string]skydb.RecordPredicateAccess
+	RelationTypeMap        map[string]skydb.RelationType
+	RelationMap            map[string]skydb.Relation
+	RelationRequestMap     map[string]skydb.RelationRequest
+	UserBlockMap           map[string]skydb.UserBlock
+	WebhookMap             map[string]skydb.Webhook
+	WebhookDeliveryMap     map[string]skydb.WebhookDelivery
+	JobMap                 map[string]skydb.Job
+	TimerMap               map[string]skydb.Timer
+	UserDataExportMap      map[string]skydb.UserDataExport
 	skydb.Conn
 }
 
@@ -45,6 +63,23 @@ func NewMapConn() *MapConn {
 		AssetMap:               map[string]skydb.Asset{},
 		OAuthMap:               map[string]skydb.OAuthInfo{},
 		CustomTokenInfoMap:     map[string]skydb.CustomTokenInfo{},
+		OAuthClientMap:         map[string]skydb.OAuthClient{},
+		OAuthCodeMap:           map[string]skydb.OAuthAuthorizationCode{},
+		APIKeyMap:              map[string]skydb.APIKey{},
+		RoleHierarchy:          skydb.RoleHierarchy{},
+		RolePermissions:        skydb.RolePermissions{},
+		GroupMap:               map[string]skydb.Group{},
+		GroupMemberMap:         map[string]map[string]skydb.GroupMembership{},
+		PredicateAccessMap:     map[string]skydb.RecordPredicateAccess{},
+		RelationTypeMap:        map[string]skydb.RelationType{},
+		RelationMap:            map[string]skydb.Relation{},
+		RelationRequestMap:     map[string]skydb.RelationRequest{},
+		UserBlockMap:           map[string]skydb.UserBlock{},
+		WebhookMap:             map[string]skydb.Webhook{},
+		WebhookDeliveryMap:     map[string]skydb.WebhookDelivery{},
+		JobMap:                 map[string]skydb.Job{},
+		TimerMap:               map[string]skydb.Timer{},
+		UserDataExportMap:      map[string]skydb.UserDataExport{},
 	}
 }
 
@@ -66,6 +101,8 @@ func (conn *MapConn) GetAuth(id string, authinfo *skydb.AuthInfo) error {
 	}
 
 	*authinfo = u
+	authinfo.InheritedRoles = conn.RoleHierarchy.Expand(u.Roles)
+	authinfo.GroupIDs = conn.groupIDs(u.ID)
 	return nil
 }
 
@@ -74,6 +111,8 @@ func (conn *MapConn) GetAuthByPrincipalID(principalID string, authinfo *skydb.Au
 	for _, u := range conn.UserMap {
 		if _, ok := u.ProviderInfo[principalID]; ok {
 			*authinfo = u
+			authinfo.InheritedRoles = conn.RoleHierarchy.Expand(u.Roles)
+			authinfo.GroupIDs = conn.groupIDs(u.ID)
 			return nil
 		}
 	}
@@ -101,6 +140,45 @@ func (conn *MapConn) DeleteAuth(id string) error {
 	return nil
 }
 
+// PurgeAuth removes an existing AuthInfo in UserMap together with the
+// relations, relation requests, blocks, group memberships and SSO
+// principals of the user.
+func (conn *MapConn) PurgeAuth(id string) error {
+	if err := conn.DeleteAuth(id); err != nil {
+		return err
+	}
+
+	for key, relation := range conn.RelationMap {
+		if relation.LeftID == id || relation.RightID == id {
+			delete(conn.RelationMap, key)
+		}
+	}
+	for key, request := range conn.RelationRequestMap {
+		if request.RequesterID == id || request.RecipientID == id {
+			delete(conn.RelationRequestMap, key)
+		}
+	}
+	for key, block := range conn.UserBlockMap {
+		if block.BlockerID == id || block.BlockedID == id {
+			delete(conn.UserBlockMap, key)
+		}
+	}
+	for _, members := range conn.GroupMemberMap {
+		delete(members, id)
+	}
+	for key, oauthinfo := range conn.OAuthMap {
+		if oauthinfo.UserID == id {
+			delete(conn.OAuthMap, key)
+		}
+	}
+	for key, tokenInfo := range conn.CustomTokenInfoMap {
+		if tokenInfo.UserID == id {
+			delete(conn.CustomTokenInfoMap, key)
+		}
+	}
+	return nil
+}
+
 // GetAdminRoles is not implemented.
 func (conn *MapConn) GetAdminRoles() ([]string, error) {
 	return []string{
@@ -125,6 +203,46 @@ func (conn *MapConn) SetDefaultRoles(roles []string) error {
 	panic("not implemented")
 }
 
+// GetRoleHierarchy returns RoleHierarchy.
+func (conn *MapConn) GetRoleHierarchy() (skydb.RoleHierarchy, error) {
+	hierarchy := skydb.RoleHierarchy{}
+	for role, inheritedRoles := range conn.RoleHierarchy {
+		hierarchy[role] = inheritedRoles
+	}
+	return hierarchy, nil
+}
+
+// SetRoleHierarchy replaces inherited roles in RoleHierarchy.
+func (conn *MapConn) SetRoleHierarchy(hierarchy skydb.RoleHierarchy) error {
+	for role, inheritedRoles := range hierarchy {
+		if len(inheritedRoles) == 0 {
+			delete(conn.RoleHierarchy, role)
+			continue
+		}
+		conn.RoleHierarchy[role] = inheritedRoles
+	}
+	return nil
+}
+
+// GetRolePermissions returns RolePermissions.
+func (conn *MapConn) GetRolePermissions() (skydb.RolePermissions, error) {
+	permissions := skydb.RolePermissions{}
+	for role, actions := range conn.RolePermissions {
+		permissions[role] = actions
+	}
+	return permissions, nil
+}
+
+// SetRolePermissions replaces the actions of the role in RolePermissions.
+func (conn *MapConn) SetRolePermissions(role string, actions []string) error {
+	if len(actions) == 0 {
+		delete(conn.RolePermissions, role)
+		return nil
+	}
+	conn.RolePermissions[role] = actions
+	return nil
+}
+
 // SetRecordAccess sets record creation access
 func (conn *MapConn) SetRecordAccess(recordType string, acl skydb.RecordACL) error {
 	conn.recordAccessMap[recordType] = acl
@@ -167,6 +285,18 @@ func (conn *MapConn) GetRecordFieldAccess() (skydb.FieldACL, error) {
 	return conn.fieldAccess, nil
 }
 
+// SetRecordPredicateAccess sets record predicate access of a specific type
+func (conn *MapConn) SetRecordPredicateAccess(recordType string, access skydb.RecordPredicateAccess) error {
+	conn.PredicateAccessMap[recordType] = access
+	return nil
+}
+
+// GetRecordPredicateAccess returns record predicate access of a specific
+// type
+func (conn *MapConn) GetRecordPredicateAccess(recordType string) (skydb.RecordPredicateAccess, error) {
+	return conn.PredicateAccessMap[recordType], nil
+}
+
 // GetAsset is not implemented.
 func (conn *MapConn) GetAsset(name string, asset *skydb.Asset) error {
 	panic("not implemented")
@@ -199,14 +329,195 @@ func (conn *MapConn) QueryRelationCount(user string, name string, direction stri
 	panic("not implemented")
 }
 
-// AddRelation is not implemented.
+// AddRelation adds a relation to RelationMap.
 func (conn *MapConn) AddRelation(user string, name string, targetUser string) error {
-	panic("not implemented")
+	return conn.SaveRelation(&skydb.Relation{
+		Name:    name,
+		LeftID:  user,
+		RightID: targetUser,
+	})
 }
 
-// RemoveRelation is not implemented.
+// RemoveRelation removes a relation from RelationMap.
 func (conn *MapConn) RemoveRelation(user string, name string, targetUser string) error {
-	panic("not implemented")
+	key := relationKey(name, user, targetUser)
+	if _, ok := conn.RelationMap[key]; !ok {
+		return fmt.Errorf("%v relation not exist {%v} => {%v}", name, user, targetUser)
+	}
+	delete(conn.RelationMap, key)
+	if relationType, ok := conn.RelationTypeMap[name]; ok && relationType.Direction == skydb.MutualRelation {
+		delete(conn.RelationMap, relationKey(name, targetUser, user))
+	}
+	return nil
+}
+
+// CreateRelationType creates a RelationType in RelationTypeMap.
+func (conn *MapConn) CreateRelationType(relationType *skydb.RelationType) error {
+	if _, ok := conn.RelationTypeMap[relationType.Name]; ok {
+		return skydb.ErrRelationTypeDuplicated
+	}
+	conn.RelationTypeMap[relationType.Name] = *relationType
+	return nil
+}
+
+// GetRelationType returns a RelationType from RelationTypeMap.
+func (conn *MapConn) GetRelationType(name string) (skydb.RelationType, error) {
+	relationType, ok := conn.RelationTypeMap[name]
+	if !ok {
+		return skydb.RelationType{}, skydb.ErrRelationTypeNotFound
+	}
+	return relationType, nil
+}
+
+// GetRelationTypes returns all RelationTypes in RelationTypeMap ordered
+// by name.
+func (conn *MapConn) GetRelationTypes() ([]skydb.RelationType, error) {
+	relationTypes := []skydb.RelationType{}
+	for _, relationType := range conn.RelationTypeMap {
+		relationTypes = append(relationTypes, relationType)
+	}
+	sort.Slice(relationTypes, func(i, j int) bool {
+		return relationTypes[i].Name < relationTypes[j].Name
+	})
+	return relationTypes, nil
+}
+
+// DeleteRelationType removes a RelationType and its relations.
+func (conn *MapConn) DeleteRelationType(name string) error {
+	if _, ok := conn.RelationTypeMap[name]; !ok {
+		return skydb.ErrRelationTypeNotFound
+	}
+	delete(conn.RelationTypeMap, name)
+	for key, relation := range conn.RelationMap {
+		if relation.Name == name {
+			delete(conn.RelationMap, key)
+		}
+	}
+	return nil
+}
+
+// SaveRelation saves a Relation in RelationMap.
+func (conn *MapConn) SaveRelation(relation *skydb.Relation) error {
+	relationType, ok := conn.RelationTypeMap[relation.Name]
+	if !ok && !skydb.IsBuiltinRelation(relation.Name) {
+		return skydb.ErrRelationTypeNotFound
+	}
+
+	conn.RelationMap[relationKey(relation.Name, relation.LeftID, relation.RightID)] = *relation
+	if relationType.Direction == skydb.MutualRelation {
+		reverse := *relation
+		reverse.LeftID, reverse.RightID = relation.RightID, relation.LeftID
+		conn.RelationMap[relationKey(reverse.Name, reverse.LeftID, reverse.RightID)] = reverse
+	}
+	return nil
+}
+
+// GetRelation returns a Relation from RelationMap.
+func (conn *MapConn) GetRelation(user string, name string, targetUser string) (skydb.Relation, error) {
+	relation, ok := conn.RelationMap[relationKey(name, user, targetUser)]
+	if !ok {
+		return skydb.Relation{}, skydb.ErrRelationNotFound
+	}
+	return relation, nil
+}
+
+func relationKey(name string, user string, targetUser string) string {
+	return name + "/" + user + "/" + targetUser
+}
+
+// CreateRelationRequest creates a pending RelationRequest in
+// RelationRequestMap.
+func (conn *MapConn) CreateRelationRequest(request *skydb.RelationRequest) error {
+	key := relationKey(request.Name, request.RequesterID, request.RecipientID)
+	if existing, ok := conn.RelationRequestMap[key]; ok && existing.IsPending() {
+		return skydb.ErrRelationRequestDuplicated
+	}
+	request.State = skydb.RelationRequestPending
+	request.UpdatedAt = request.CreatedAt
+	conn.RelationRequestMap[key] = *request
+	return nil
+}
+
+// GetRelationRequest returns a RelationRequest from RelationRequestMap.
+func (conn *MapConn) GetRelationRequest(name string, requesterID string, recipientID string) (skydb.RelationRequest, error) {
+	request, ok := conn.RelationRequestMap[relationKey(name, requesterID, recipientID)]
+	if !ok {
+		return skydb.RelationRequest{}, skydb.ErrRelationRequestNotFound
+	}
+	return request, nil
+}
+
+// UpdateRelationRequest updates a RelationRequest in RelationRequestMap.
+func (conn *MapConn) UpdateRelationRequest(request *skydb.RelationRequest) error {
+	key := relationKey(request.Name, request.RequesterID, request.RecipientID)
+	if _, ok := conn.RelationRequestMap[key]; !ok {
+		return skydb.ErrRelationRequestNotFound
+	}
+	conn.RelationRequestMap[key] = *request
+	return nil
+}
+
+// QueryRelationRequests returns pending RelationRequests in
+// RelationRequestMap ordered by requester and recipient. The config is
+// ignored.
+func (conn *MapConn) QueryRelationRequests(user string, name string, direction string, config skydb.QueryConfig) ([]skydb.RelationRequest, error) {
+	requests := []skydb.RelationRequest{}
+	for _, request := range conn.RelationRequestMap {
+		if request.Name != name || !request.IsPending() {
+			continue
+		}
+		if (direction == "incoming" && request.RecipientID == user) ||
+			(direction == "outgoing" && request.RequesterID == user) {
+			requests = append(requests, request)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].RequesterID != requests[j].RequesterID {
+			return requests[i].RequesterID < requests[j].RequesterID
+		}
+		return requests[i].RecipientID < requests[j].RecipientID
+	})
+	return requests, nil
+}
+
+// BlockUser adds a UserBlock to UserBlockMap.
+func (conn *MapConn) BlockUser(block *skydb.UserBlock) error {
+	key := block.BlockerID + "/" + block.BlockedID
+	if _, ok := conn.UserBlockMap[key]; !ok {
+		conn.UserBlockMap[key] = *block
+	}
+	return nil
+}
+
+// UnblockUser removes a UserBlock from UserBlockMap.
+func (conn *MapConn) UnblockUser(blockerID string, blockedID string) error {
+	key := blockerID + "/" + blockedID
+	if _, ok := conn.UserBlockMap[key]; !ok {
+		return skydb.ErrUserBlockNotFound
+	}
+	delete(conn.UserBlockMap, key)
+	return nil
+}
+
+// QueryBlockedUsers returns UserBlocks of the blocker in UserBlockMap
+// ordered by the blocked user.
+func (conn *MapConn) QueryBlockedUsers(blockerID string) ([]skydb.UserBlock, error) {
+	blocks := []skydb.UserBlock{}
+	for _, block := range conn.UserBlockMap {
+		if block.BlockerID == blockerID {
+			blocks = append(blocks, block)
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].BlockedID < blocks[j].BlockedID
+	})
+	return blocks, nil
+}
+
+// IsUserBlocked returns true if a UserBlock is in UserBlockMap.
+func (conn *MapConn) IsUserBlocked(blockerID string, blockedID string) (bool, error) {
+	_, ok := conn.UserBlockMap[blockerID+"/"+blockedID]
+	return ok, nil
 }
 
 // GetDevice is not implemented.
@@ -344,6 +655,225 @@ func (conn *MapConn) DeleteCustomTokenInfo(principalID string) error {
 	return nil
 }
 
+// CreateOAuthClient creates an OAuthClient in OAuthClientMap.
+func (conn *MapConn) CreateOAuthClient(client *skydb.OAuthClient) error {
+	if _, ok := conn.OAuthClientMap[client.ID]; ok {
+		return fmt.Errorf("duplicated OAuth client %s", client.ID)
+	}
+	conn.OAuthClientMap[client.ID] = *client
+	return nil
+}
+
+// GetOAuthClient returns an OAuthClient in OAuthClientMap.
+func (conn *MapConn) GetOAuthClient(clientID string, client *skydb.OAuthClient) error {
+	c, ok := conn.OAuthClientMap[clientID]
+	if !ok {
+		return skydb.ErrOAuthClientNotFound
+	}
+	*client = c
+	return nil
+}
+
+// DeleteOAuthClient removes an OAuthClient in OAuthClientMap.
+func (conn *MapConn) DeleteOAuthClient(clientID string) error {
+	if _, ok := conn.OAuthClientMap[clientID]; !ok {
+		return skydb.ErrOAuthClientNotFound
+	}
+	delete(conn.OAuthClientMap, clientID)
+	for code, authCode := range conn.OAuthCodeMap {
+		if authCode.ClientID == clientID {
+			delete(conn.OAuthCodeMap, code)
+		}
+	}
+	return nil
+}
+
+// CreateOAuthAuthorizationCode creates an OAuthAuthorizationCode in
+// OAuthCodeMap.
+func (conn *MapConn) CreateOAuthAuthorizationCode(code *skydb.OAuthAuthorizationCode) error {
+	conn.OAuthCodeMap[code.Code] = *code
+	return nil
+}
+
+// ConsumeOAuthAuthorizationCode returns and removes an
+// OAuthAuthorizationCode in OAuthCodeMap.
+func (conn *MapConn) ConsumeOAuthAuthorizationCode(code string, authCode *skydb.OAuthAuthorizationCode) error {
+	c, ok := conn.OAuthCodeMap[code]
+	if !ok {
+		return skydb.ErrOAuthCodeNotFound
+	}
+	delete(conn.OAuthCodeMap, code)
+	if c.ExpireAt.Before(time.Now()) {
+		return skydb.ErrOAuthCodeNotFound
+	}
+	*authCode = c
+	return nil
+}
+
+// CreateAPIKey creates an APIKey in APIKeyMap.
+func (conn *MapConn) CreateAPIKey(key *skydb.APIKey) error {
+	if _, ok := conn.APIKeyMap[key.ID]; ok {
+		return fmt.Errorf("duplicated API key %s", key.ID)
+	}
+	conn.APIKeyMap[key.ID] = *key
+	return nil
+}
+
+// GetAPIKey returns an APIKey in APIKeyMap.
+func (conn *MapConn) GetAPIKey(id string, key *skydb.APIKey) error {
+	k, ok := conn.APIKeyMap[id]
+	if !ok {
+		return skydb.ErrAPIKeyNotFound
+	}
+	*key = k
+	return nil
+}
+
+// GetAPIKeyByHash returns an APIKey in APIKeyMap by the hash of its
+// current or previous key.
+func (conn *MapConn) GetAPIKeyByHash(hashedKey string, key *skydb.APIKey) error {
+	for _, k := range conn.APIKeyMap {
+		if k.HashedKey == hashedKey || (k.PreviousHashedKey != "" && k.PreviousHashedKey == hashedKey) {
+			*key = k
+			return nil
+		}
+	}
+	return skydb.ErrAPIKeyNotFound
+}
+
+// UpdateAPIKey updates an APIKey in APIKeyMap.
+func (conn *MapConn) UpdateAPIKey(key *skydb.APIKey) error {
+	if _, ok := conn.APIKeyMap[key.ID]; !ok {
+		return skydb.ErrAPIKeyNotFound
+	}
+	conn.APIKeyMap[key.ID] = *key
+	return nil
+}
+
+// QueryAPIKeys returns all APIKeys in APIKeyMap ordered by creation time.
+func (conn *MapConn) QueryAPIKeys() ([]skydb.APIKey, error) {
+	keys := []skydb.APIKey{}
+	for _, k := range conn.APIKeyMap {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// CreateGroup creates a Group in GroupMap.
+func (conn *MapConn) CreateGroup(group *skydb.Group) error {
+	if _, ok := conn.GroupMap[group.ID]; ok {
+		return fmt.Errorf("group %s already exists", group.ID)
+	}
+	conn.GroupMap[group.ID] = *group
+	return nil
+}
+
+// GetGroup returns a Group in GroupMap.
+func (conn *MapConn) GetGroup(id string, group *skydb.Group) error {
+	g, ok := conn.GroupMap[id]
+	if !ok {
+		return skydb.ErrGroupNotFound
+	}
+	*group = g
+	return nil
+}
+
+// DeleteGroup removes a Group and its members.
+func (conn *MapConn) DeleteGroup(id string) error {
+	if _, ok := conn.GroupMap[id]; !ok {
+		return skydb.ErrGroupNotFound
+	}
+	delete(conn.GroupMap, id)
+	delete(conn.GroupMemberMap, id)
+	return nil
+}
+
+// QueryGroupsByMember returns the Groups the user is a member of,
+// ordered by name.
+func (conn *MapConn) QueryGroupsByMember(userID string) ([]skydb.Group, error) {
+	groups := []skydb.Group{}
+	for groupID, members := range conn.GroupMemberMap {
+		if _, ok := members[userID]; ok {
+			groups = append(groups, conn.GroupMap[groupID])
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name == groups[j].Name {
+			return groups[i].ID < groups[j].ID
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups, nil
+}
+
+// SetGroupMember adds or updates a GroupMembership in GroupMemberMap.
+func (conn *MapConn) SetGroupMember(membership *skydb.GroupMembership) error {
+	if _, ok := conn.GroupMap[membership.GroupID]; !ok {
+		return skydb.ErrGroupNotFound
+	}
+	members, ok := conn.GroupMemberMap[membership.GroupID]
+	if !ok {
+		members = map[string]skydb.GroupMembership{}
+		conn.GroupMemberMap[membership.GroupID] = members
+	}
+	if existing, ok := members[membership.UserID]; ok {
+		existing.Role = membership.Role
+		members[membership.UserID] = existing
+		return nil
+	}
+	members[membership.UserID] = *membership
+	return nil
+}
+
+// GetGroupMember returns a GroupMembership in GroupMemberMap.
+func (conn *MapConn) GetGroupMember(groupID string, userID string, membership *skydb.GroupMembership) error {
+	m, ok := conn.GroupMemberMap[groupID][userID]
+	if !ok {
+		return skydb.ErrGroupMemberNotFound
+	}
+	*membership = m
+	return nil
+}
+
+// RemoveGroupMember removes a GroupMembership from GroupMemberMap.
+func (conn *MapConn) RemoveGroupMember(groupID string, userID string) error {
+	if _, ok := conn.GroupMemberMap[groupID][userID]; !ok {
+		return skydb.ErrGroupMemberNotFound
+	}
+	delete(conn.GroupMemberMap[groupID], userID)
+	return nil
+}
+
+// QueryGroupMembers returns the members of a group ordered by
+// creation time.
+func (conn *MapConn) QueryGroupMembers(groupID string) ([]skydb.GroupMembership, error) {
+	memberships := []skydb.GroupMembership{}
+	for _, m := range conn.GroupMemberMap[groupID] {
+		memberships = append(memberships, m)
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		if memberships[i].CreatedAt.Equal(memberships[j].CreatedAt) {
+			return memberships[i].UserID < memberships[j].UserID
+		}
+		return memberships[i].CreatedAt.Before(memberships[j].CreatedAt)
+	})
+	return memberships, nil
+}
+
+func (conn *MapConn) groupIDs(userID string) []string {
+	var groupIDs []string
+	for groupID, members := range conn.GroupMemberMap {
+		if _, ok := members[userID]; ok {
+			groupIDs = append(groupIDs, groupID)
+		}
+	}
+	sort.Strings(groupIDs)
+	return groupIDs
+}
+
 // Close does nothing.
 func (conn *MapConn) Close() error {
 	// do nothing
@@ -399,7 +929,7 @@ func (db *MapDB) Get(id skydb.RecordID, record *skydb.Record) error {
 	if !ok {
 		return skydb.ErrRecordNotFound
 	}
-	*record = r
+	*record = r.Copy()
 	return nil
 
 }
@@ -556,3 +1086,388 @@ var (
 	_ skydb.Database   = NewMapDB()
 	_ skydb.TxDatabase = &MockTxDatabase{}
 )
+
+// CreateWebhook creates a Webhook in WebhookMap.
+func (conn *MapConn) CreateWebhook(webhook *skydb.Webhook) error {
+	if _, ok := conn.WebhookMap[webhook.ID]; ok {
+		return fmt.Errorf("duplicated webhook %s", webhook.ID)
+	}
+	conn.WebhookMap[webhook.ID] = *webhook
+	return nil
+}
+
+// GetWebhook returns a Webhook in WebhookMap.
+func (conn *MapConn) GetWebhook(id string, webhook *skydb.Webhook) error {
+	w, ok := conn.WebhookMap[id]
+	if !ok {
+		return skydb.ErrWebhookNotFound
+	}
+	*webhook = w
+	return nil
+}
+
+// UpdateWebhook updates a Webhook in WebhookMap.
+func (conn *MapConn) UpdateWebhook(webhook *skydb.Webhook) error {
+	if _, ok := conn.WebhookMap[webhook.ID]; !ok {
+		return skydb.ErrWebhookNotFound
+	}
+	conn.WebhookMap[webhook.ID] = *webhook
+	return nil
+}
+
+// DeleteWebhook removes a Webhook and its deliveries.
+func (conn *MapConn) DeleteWebhook(id string) error {
+	if _, ok := conn.WebhookMap[id]; !ok {
+		return skydb.ErrWebhookNotFound
+	}
+	delete(conn.WebhookMap, id)
+	for deliveryID, d := range conn.WebhookDeliveryMap {
+		if d.WebhookID == id {
+			delete(conn.WebhookDeliveryMap, deliveryID)
+		}
+	}
+	return nil
+}
+
+// QueryWebhooks returns all Webhooks in WebhookMap ordered by creation
+// time.
+func (conn *MapConn) QueryWebhooks() ([]skydb.Webhook, error) {
+	webhooks := []skydb.Webhook{}
+	for _, w := range conn.WebhookMap {
+		webhooks = append(webhooks, w)
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+// CreateWebhookDelivery creates a WebhookDelivery in WebhookDeliveryMap.
+func (conn *MapConn) CreateWebhookDelivery(delivery *skydb.WebhookDelivery) error {
+	if _, ok := conn.WebhookMap[delivery.WebhookID]; !ok {
+		return skydb.ErrWebhookNotFound
+	}
+	if _, ok := conn.WebhookDeliveryMap[delivery.ID]; ok {
+		return fmt.Errorf("duplicated webhook delivery %s", delivery.ID)
+	}
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = delivery.CreatedAt
+	}
+	conn.WebhookDeliveryMap[delivery.ID] = *delivery
+	return nil
+}
+
+// GetWebhookDelivery returns a WebhookDelivery in WebhookDeliveryMap.
+func (conn *MapConn) GetWebhookDelivery(id string, delivery *skydb.WebhookDelivery) error {
+	d, ok := conn.WebhookDeliveryMap[id]
+	if !ok {
+		return skydb.ErrWebhookDeliveryNotFound
+	}
+	*delivery = d
+	return nil
+}
+
+// UpdateWebhookDelivery updates a WebhookDelivery in WebhookDeliveryMap.
+func (conn *MapConn) UpdateWebhookDelivery(delivery *skydb.WebhookDelivery) error {
+	if _, ok := conn.WebhookDeliveryMap[delivery.ID]; !ok {
+		return skydb.ErrWebhookDeliveryNotFound
+	}
+	conn.WebhookDeliveryMap[delivery.ID] = *delivery
+	return nil
+}
+
+// QueryWebhookDeliveries returns the deliveries of a webhook in
+// WebhookDeliveryMap, latest first.
+func (conn *MapConn) QueryWebhookDeliveries(webhookID string, status skydb.WebhookDeliveryStatus, limit uint64) ([]skydb.WebhookDelivery, error) {
+	deliveries := []skydb.WebhookDelivery{}
+	for _, d := range conn.WebhookDeliveryMap {
+		if d.WebhookID == webhookID && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if uint64(len(deliveries)) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// ClaimWebhookDelivery returns the pending delivery that is due first at
+// now in WebhookDeliveryMap and postpones its next attempt to leaseUntil.
+func (conn *MapConn) ClaimWebhookDelivery(now time.Time, leaseUntil time.Time, delivery *skydb.WebhookDelivery) error {
+	var claimed *skydb.WebhookDelivery
+	for _, d := range conn.WebhookDeliveryMap {
+		if d.Status != skydb.WebhookDeliveryPending || d.NextAttemptAt.After(now) {
+			continue
+		}
+		if claimed == nil || d.NextAttemptAt.Before(claimed.NextAttemptAt) {
+			d := d
+			claimed = &d
+		}
+	}
+	if claimed == nil {
+		return skydb.ErrWebhookDeliveryNotFound
+	}
+
+	claimed.NextAttemptAt = leaseUntil
+	conn.WebhookDeliveryMap[claimed.ID] = *claimed
+	*delivery = *claimed
+	return nil
+}
+
+// UpdateClaimedWebhookDelivery updates a WebhookDelivery in
+// WebhookDeliveryMap if it is still pending and claimed until leaseUntil.
+func (conn *MapConn) UpdateClaimedWebhookDelivery(delivery *skydb.WebhookDelivery, leaseUntil time.Time) error {
+	d, ok := conn.WebhookDeliveryMap[delivery.ID]
+	if !ok {
+		return skydb.ErrWebhookDeliveryNotFound
+	}
+	if d.Status != skydb.WebhookDeliveryPending || !d.NextAttemptAt.Equal(leaseUntil) {
+		return skydb.ErrLeaseLost
+	}
+	conn.WebhookDeliveryMap[delivery.ID] = *delivery
+	return nil
+}
+
+// CreateJob creates a Job in JobMap.
+func (conn *MapConn) CreateJob(job *skydb.Job) error {
+	if _, ok := conn.JobMap[job.ID]; ok {
+		return fmt.Errorf("duplicated job %s", job.ID)
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = job.CreatedAt
+	}
+	conn.JobMap[job.ID] = *job
+	return nil
+}
+
+// GetJob returns a Job in JobMap.
+func (conn *MapConn) GetJob(id string, job *skydb.Job) error {
+	j, ok := conn.JobMap[id]
+	if !ok {
+		return skydb.ErrJobNotFound
+	}
+	*job = j
+	return nil
+}
+
+// UpdateJob updates a Job in JobMap.
+func (conn *MapConn) UpdateJob(job *skydb.Job) error {
+	if _, ok := conn.JobMap[job.ID]; !ok {
+		return skydb.ErrJobNotFound
+	}
+	conn.JobMap[job.ID] = *job
+	return nil
+}
+
+// QueryJobs returns the jobs of the status in JobMap, latest first.
+func (conn *MapConn) QueryJobs(status skydb.JobStatus, limit uint64) ([]skydb.Job, error) {
+	jobs := []skydb.Job{}
+	for _, j := range conn.JobMap {
+		if status == "" || j.Status == status {
+			jobs = append(jobs, j)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	if uint64(len(jobs)) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// ClaimJob returns the pending job due at now or the abandoned running
+// job in JobMap that is due first, and marks it as running until
+// lockedUntil.
+func (conn *MapConn) ClaimJob(now time.Time, lockedUntil time.Time, job *skydb.Job) error {
+	var claimed *skydb.Job
+	for _, j := range conn.JobMap {
+		due := j.Status == skydb.JobPending && !j.RunAt.After(now)
+		abandoned := j.Status == skydb.JobRunning && j.LockedUntil != nil && !j.LockedUntil.After(now)
+		if !due && !abandoned {
+			continue
+		}
+		if claimed == nil || j.RunAt.Before(claimed.RunAt) {
+			j := j
+			claimed = &j
+		}
+	}
+	if claimed == nil {
+		return skydb.ErrJobNotFound
+	}
+
+	until := lockedUntil
+	claimed.Status = skydb.JobRunning
+	claimed.LockedUntil = &until
+	claimed.Attempts++
+	conn.JobMap[claimed.ID] = *claimed
+	*job = *claimed
+	return nil
+}
+
+// UpdateClaimedJob updates a Job in JobMap if it is still running and
+// locked until lockedUntil by the same claim.
+func (conn *MapConn) UpdateClaimedJob(job *skydb.Job, lockedUntil time.Time) error {
+	j, ok := conn.JobMap[job.ID]
+	if !ok {
+		return skydb.ErrJobNotFound
+	}
+	if j.Status != skydb.JobRunning || j.Attempts != job.Attempts || j.LockedUntil == nil || !j.LockedUntil.Equal(lockedUntil) {
+		return skydb.ErrLeaseLost
+	}
+	conn.JobMap[job.ID] = *job
+	return nil
+}
+
+// RegisterTimer creates or updates a Timer in TimerMap, keeping the next
+// run unless the spec is changed.
+func (conn *MapConn) RegisterTimer(timer *skydb.Timer) error {
+	t, ok := conn.TimerMap[timer.Name]
+	if !ok {
+		conn.TimerMap[timer.Name] = *timer
+		return nil
+	}
+	if t.Spec != timer.Spec {
+		t.NextRunAt = timer.NextRunAt
+	}
+	t.Spec = timer.Spec
+	t.CatchUp = timer.CatchUp
+	conn.TimerMap[timer.Name] = t
+	return nil
+}
+
+// GetTimer returns a Timer in TimerMap.
+func (conn *MapConn) GetTimer(name string, timer *skydb.Timer) error {
+	t, ok := conn.TimerMap[name]
+	if !ok {
+		return skydb.ErrTimerNotFound
+	}
+	*timer = t
+	return nil
+}
+
+// UpdateTimer updates a Timer in TimerMap.
+func (conn *MapConn) UpdateTimer(timer *skydb.Timer) error {
+	t, ok := conn.TimerMap[timer.Name]
+	if !ok {
+		return skydb.ErrTimerNotFound
+	}
+	t.NextRunAt = timer.NextRunAt
+	t.LockedUntil = timer.LockedUntil
+	t.LastRunAt = timer.LastRunAt
+	t.LastFinishedAt = timer.LastFinishedAt
+	t.LastStatus = timer.LastStatus
+	t.LastError = timer.LastError
+	conn.TimerMap[timer.Name] = t
+	return nil
+}
+
+// QueryTimers returns all Timers in TimerMap ordered by name.
+func (conn *MapConn) QueryTimers() ([]skydb.Timer, error) {
+	timers := []skydb.Timer{}
+	for _, t := range conn.TimerMap {
+		timers = append(timers, t)
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		return timers[i].Name < timers[j].Name
+	})
+	return timers, nil
+}
+
+// ClaimTimer returns the first Timer of the names in TimerMap that is
+// due and not locked at now, and locks it until lockedUntil.
+func (conn *MapConn) ClaimTimer(names []string, now time.Time, lockedUntil time.Time, timer *skydb.Timer) error {
+	for _, name := range names {
+		t, ok := conn.TimerMap[name]
+		if !ok || t.NextRunAt.After(now) || (t.LockedUntil != nil && t.LockedUntil.After(now)) {
+			continue
+		}
+		until := lockedUntil
+		t.LockedUntil = &until
+		conn.TimerMap[name] = t
+		*timer = t
+		return nil
+	}
+	return skydb.ErrTimerNotFound
+}
+
+// UpdateClaimedTimer updates a Timer in TimerMap if it is still locked
+// until lockedUntil.
+func (conn *MapConn) UpdateClaimedTimer(timer *skydb.Timer, lockedUntil time.Time) error {
+	t, ok := conn.TimerMap[timer.Name]
+	if !ok {
+		return skydb.ErrTimerNotFound
+	}
+	if t.LockedUntil == nil || !t.LockedUntil.Equal(lockedUntil) {
+		return skydb.ErrLeaseLost
+	}
+	return conn.UpdateTimer(timer)
+}
+
+// LockTimer locks a Timer in TimerMap until lockedUntil if it is not
+// locked at now.
+func (conn *MapConn) LockTimer(name string, now time.Time, lockedUntil time.Time, timer *skydb.Timer) error {
+	t, ok := conn.TimerMap[name]
+	if !ok {
+		return skydb.ErrTimerNotFound
+	}
+	if t.LockedUntil != nil && t.LockedUntil.After(now) {
+		return skydb.ErrTimerLocked
+	}
+	until := lockedUntil
+	t.LockedUntil = &until
+	conn.TimerMap[name] = t
+	*timer = t
+	return nil
+}
+
+// CreateUserDataExport creates a UserDataExport in UserDataExportMap.
+func (conn *MapConn) CreateUserDataExport(export *skydb.UserDataExport) error {
+	if _, ok := conn.UserDataExportMap[export.AssetName]; ok {
+		return fmt.Errorf("duplicated user data export %s", export.AssetName)
+	}
+	conn.UserDataExportMap[export.AssetName] = *export
+	return nil
+}
+
+// QueryUserDataExports returns the exports of the user in
+// UserDataExportMap.
+func (conn *MapConn) QueryUserDataExports(userID string) ([]skydb.UserDataExport, error) {
+	exports := []skydb.UserDataExport{}
+	for _, export := range conn.UserDataExportMap {
+		if export.UserID == userID {
+			exports = append(exports, export)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].AssetName < exports[j].AssetName
+	})
+	return exports, nil
+}
+
+// QueryExpiredUserDataExports returns the expired exports in
+// UserDataExportMap.
+func (conn *MapConn) QueryExpiredUserDataExports(now time.Time, limit uint64) ([]skydb.UserDataExport, error) {
+	exports := []skydb.UserDataExport{}
+	for _, export := range conn.UserDataExportMap {
+		if !export.ExpiredAt.After(now) {
+			exports = append(exports, export)
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		return exports[i].ExpiredAt.Before(exports[j].ExpiredAt)
+	})
+	if uint64(len(exports)) > limit {
+		exports = exports[:limit]
+	}
+	return exports, nil
+}
+
+// DeleteUserDataExport removes a UserDataExport in UserDataExportMap.
+func (conn *MapConn) DeleteUserDataExport(assetName string) error {
+	delete(conn.UserDataExportMap, assetName)
+	return nil
+}
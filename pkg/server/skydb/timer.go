@@ -0,0 +1,56 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimerNotFound is returned by Conn.GetTimer, Conn.UpdateTimer and
+// Conn.LockTimer when the Timer is not found.
+var ErrTimerNotFound = errors.New("skydb: timer not found")
+
+// ErrTimerLocked is returned by Conn.LockTimer when the Timer is being
+// run by another server instance.
+var ErrTimerLocked = errors.New("skydb: timer is locked")
+
+// TimerStatus is the outcome of the last run of a Timer.
+type TimerStatus string
+
+// The outcomes of a run of a Timer.
+const (
+	TimerSucceeded TimerStatus = "succeeded"
+	TimerFailed    TimerStatus = "failed"
+)
+
+// Timer is the schedule and the last run of a plugin timer, shared by all
+// server instances so that a timer is run once per tick.
+//
+// A timer being run is locked until LockedUntil, after which it is
+// considered abandoned and may be run again. If CatchUp is true, a run
+// missed while no server instance was running is run once when a server
+// instance is started again, otherwise the missed runs are skipped.
+type Timer struct {
+	Name           string      `json:"name"`
+	Spec           string      `json:"spec"`
+	CatchUp        bool        `json:"catch_up"`
+	NextRunAt      time.Time   `json:"next_run_at"`
+	LockedUntil    *time.Time  `json:"locked_until,omitempty"`
+	LastRunAt      *time.Time  `json:"last_run_at,omitempty"`
+	LastFinishedAt *time.Time  `json:"last_finished_at,omitempty"`
+	LastStatus     TimerStatus `json:"last_status,omitempty"`
+	LastError      string      `json:"last_error,omitempty"`
+}
@@ -0,0 +1,29 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"time"
+)
+
+// UserDataExport is an archive of the data of a user saved as an asset.
+// The asset is deleted at ExpiredAt, or when the data of the user is
+// erased.
+type UserDataExport struct {
+	AssetName string
+	UserID    string
+	CreatedAt time.Time
+	ExpiredAt time.Time
+}
@@ -0,0 +1,90 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrWebhookNotFound is returned by Conn.GetWebhook, Conn.UpdateWebhook
+// and Conn.DeleteWebhook when the Webhook is not found.
+var ErrWebhookNotFound = errors.New("skydb: webhook not found")
+
+// ErrWebhookDeliveryNotFound is returned by Conn.GetWebhookDelivery and
+// Conn.UpdateWebhookDelivery when the WebhookDelivery is not found.
+var ErrWebhookDeliveryNotFound = errors.New("skydb: webhook delivery not found")
+
+// Webhook is a URL registered to receive events of records and users.
+//
+// Events are names of after hooks, such as "afterSave" and "afterLogin",
+// or "*" for all events. RecordType limits record events to records of
+// that type; an empty RecordType matches records of all types. Secret is
+// used to sign the deliveries.
+type Webhook struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	RecordType string    `json:"record_type,omitempty"`
+	Events     []string  `json:"events"`
+	Disabled   bool      `json:"disabled"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Matches returns true if the webhook should receive the event. recordType
+// is empty if the event is not a record event.
+func (w *Webhook) Matches(event string, recordType string) bool {
+	if w.Disabled {
+		return false
+	}
+	if recordType != "" && w.RecordType != "" && recordType != w.RecordType {
+		return false
+	}
+	for _, e := range w.Events {
+		if e == "*" || e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the status of a WebhookDelivery.
+type WebhookDeliveryStatus string
+
+// The statuses of a WebhookDelivery. A pending delivery is attempted until
+// it is delivered, or failed after the maximum number of attempts.
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is an event to be delivered to a Webhook. Deliveries are
+// persisted before they are attempted so that events are not lost when
+// the receiver is unavailable.
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	WebhookID      string                `json:"webhook_id"`
+	Event          string                `json:"event"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
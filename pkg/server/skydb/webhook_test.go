@@ -0,0 +1,50 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package skydb
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWebhookMatches(t *testing.T) {
+	Convey("Webhook", t, func() {
+		Convey("matches listed events", func() {
+			w := Webhook{Events: []string{"afterSave", "afterSignup"}}
+			So(w.Matches("afterSave", "note"), ShouldBeTrue)
+			So(w.Matches("afterSignup", ""), ShouldBeTrue)
+			So(w.Matches("afterDelete", "note"), ShouldBeFalse)
+		})
+
+		Convey("matches all events with wildcard", func() {
+			w := Webhook{Events: []string{"*"}}
+			So(w.Matches("afterDelete", "note"), ShouldBeTrue)
+			So(w.Matches("afterLogin", ""), ShouldBeTrue)
+		})
+
+		Convey("limits record events to record type", func() {
+			w := Webhook{RecordType: "note", Events: []string{"*"}}
+			So(w.Matches("afterSave", "note"), ShouldBeTrue)
+			So(w.Matches("afterSave", "comment"), ShouldBeFalse)
+			So(w.Matches("afterLogin", ""), ShouldBeTrue)
+		})
+
+		Convey("does not match when disabled", func() {
+			w := Webhook{Events: []string{"*"}, Disabled: true}
+			So(w.Matches("afterSave", "note"), ShouldBeFalse)
+		})
+	})
+}
@@ -161,6 +161,21 @@ const (
 	// than limit
 	AssetSizeTooLarge
 
+	// LoginThrottled is returned when too many failed login attempts have
+	// been made for an account or from a client address. The error info
+	// contains `retry_after` (in seconds) and `locked_until` telling when
+	// the client may retry.
+	LoginThrottled
+
+	// RateLimitExceeded is returned when the request exceeds the rate limit
+	// of the API key. The error info contains `retry_after` (in seconds).
+	RateLimitExceeded
+
+	// PluginCircuitOpen is returned when a plugin is not called because
+	// its circuit breaker is open after consecutive failures. The client
+	// may retry after a while.
+	PluginCircuitOpen
+
 	// Error codes for expected error condition should be placed
 	// above this line.
 )
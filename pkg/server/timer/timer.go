@@ -0,0 +1,304 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timer runs the timers of plugins once per tick across all
+// server instances.
+//
+// The schedule and the last run of every timer are persisted in the
+// database. A server instance runs a tick of a timer only if it claims
+// the timer in the database, so that the tick is not run by another
+// instance.
+package timer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/poller"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var log = logging.LoggerEntry("timer")
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+// ErrTimerNotRegistered is returned by Scheduler.Run when no timer of
+// the name is registered.
+var ErrTimerNotRegistered = errors.New("timer: timer is not registered")
+
+type entry struct {
+	spec       string
+	schedule   cron.Schedule
+	catchUp    bool
+	run        func() error
+	registered bool
+}
+
+// Scheduler runs the timers registered by plugins at their cron specs.
+type Scheduler struct {
+	ConnOpener func() (skydb.Conn, error)
+
+	// Ready reports whether the timers can be run. Timers are not run
+	// until it returns true. Timers are run anytime if it is nil.
+	Ready func() bool
+
+	// Lease is how long a running timer is locked from other server
+	// instances. A timer still running after Lease, such as one whose
+	// server instance crashed, may be run again.
+	Lease time.Duration
+
+	// MissedAfter is how late a tick is run before it is considered
+	// missed, such as when no server instance was running at the tick.
+	MissedAfter time.Duration
+
+	mutex  sync.Mutex
+	timers map[string]*entry
+	poller poller.Poller
+}
+
+// NewScheduler creates a Scheduler with default settings.
+func NewScheduler(connOpener func() (skydb.Conn, error)) *Scheduler {
+	return &Scheduler{
+		ConnOpener:  connOpener,
+		Lease:       10 * time.Minute,
+		MissedAfter: time.Minute,
+		timers:      map[string]*entry{},
+	}
+}
+
+// AddTimer registers a timer run at the cron spec. If catchUp is true,
+// the missed runs of the timer are run once when the timer is polled
+// again, otherwise they are skipped.
+func (s *Scheduler) AddTimer(name string, spec string, catchUp bool, run func() error) error {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.timers[name]; ok {
+		return fmt.Errorf(`timer "%s" is already registered`, name)
+	}
+	s.timers[name] = &entry{
+		spec:     spec,
+		schedule: schedule,
+		catchUp:  catchUp,
+		run:      run,
+	}
+	return nil
+}
+
+// RemoveTimer removes the timer of the name, so that it is no longer run.
+// A run of the timer in progress is not affected.
+func (s *Scheduler) RemoveTimer(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.timers, name)
+}
+
+// Start polls for due timers at the interval until Stop is called.
+func (s *Scheduler) Start(interval time.Duration) {
+	s.poller.Start("timer", interval, func() error {
+		_, err := s.RunDue()
+		return err
+	})
+}
+
+// Stop stops polling for due timers. Timers being run are not interrupted.
+func (s *Scheduler) Stop() {
+	s.poller.Stop()
+}
+
+// RunDue claims and runs the timers that are due, returning the number
+// of timers run.
+//
+// Each timer is claimed for Lease right before it is run, and at most
+// once per poll. The outcome of a run that outlives the lease is
+// dropped, since the tick may have been claimed again by another server
+// instance.
+func (s *Scheduler) RunDue() (int, error) {
+	if s.Ready != nil && !s.Ready() {
+		return 0, nil
+	}
+
+	conn, err := s.ConnOpener()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err := s.register(conn, timeNow()); err != nil {
+		return 0, err
+	}
+
+	names := s.names()
+	count := 0
+	_, err = poller.Drain(uint64(len(names)), func() (bool, error) {
+		now := timeNow()
+		timer := skydb.Timer{}
+		if err := conn.ClaimTimer(names, now, now.Add(s.Lease), &timer); err == skydb.ErrTimerNotFound {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		lockedUntil := *timer.LockedUntil
+		names = withoutName(names, timer.Name)
+
+		e := s.entry(timer.Name)
+		missed := now.Sub(timer.NextRunAt) > s.MissedAfter
+		switch {
+		case e == nil:
+			// the timer is removed after it is claimed
+			timer.LockedUntil = nil
+		case missed && !e.catchUp:
+			log.WithField("timer", timer.Name).Infoln("Skipped missed runs of timer")
+			timer.NextRunAt = e.schedule.Next(now)
+			timer.LockedUntil = nil
+		default:
+			s.run(&timer, e)
+			count++
+		}
+
+		err := conn.UpdateClaimedTimer(&timer, lockedUntil)
+		switch err {
+		case nil, skydb.ErrTimerNotFound:
+		case skydb.ErrLeaseLost:
+			log.WithField("timer", timer.Name).Warnln("Dropped the run of timer after its lease expired")
+		default:
+			return false, err
+		}
+		return true, nil
+	})
+	return count, err
+}
+
+// Run runs the timer of the name immediately, regardless of its schedule,
+// and returns the timer with the outcome of the run. The next scheduled
+// run of the timer is unchanged.
+//
+// Run returns skydb.ErrTimerLocked if the timer is being run, and
+// skydb.ErrLeaseLost if the run outlives the lease.
+func (s *Scheduler) Run(name string) (skydb.Timer, error) {
+	timer := skydb.Timer{}
+	e := s.entry(name)
+	if e == nil {
+		return timer, ErrTimerNotRegistered
+	}
+
+	conn, err := s.ConnOpener()
+	if err != nil {
+		return timer, err
+	}
+	defer conn.Close()
+
+	now := timeNow()
+	if err := s.register(conn, now); err != nil {
+		return timer, err
+	}
+	if err := conn.LockTimer(name, now, now.Add(s.Lease), &timer); err != nil {
+		return timer, err
+	}
+	lockedUntil := *timer.LockedUntil
+
+	s.run(&timer, e)
+	return timer, conn.UpdateClaimedTimer(&timer, lockedUntil)
+}
+
+// run runs a locked timer and records the outcome. The timer is unlocked
+// and rescheduled if it is due.
+func (s *Scheduler) run(timer *skydb.Timer, e *entry) {
+	startedAt := timeNow()
+	err := runSafely(e.run)
+	finishedAt := timeNow()
+
+	timer.LastRunAt = &startedAt
+	timer.LastFinishedAt = &finishedAt
+	timer.LockedUntil = nil
+	if err == nil {
+		timer.LastStatus = skydb.TimerSucceeded
+		timer.LastError = ""
+	} else {
+		log.WithField("timer", timer.Name).WithError(err).Warnln("Failed to run timer")
+		timer.LastStatus = skydb.TimerFailed
+		timer.LastError = err.Error()
+	}
+	if !timer.NextRunAt.After(finishedAt) {
+		timer.NextRunAt = e.schedule.Next(finishedAt)
+	}
+}
+
+func runSafely(run func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("timer: panicked: %v", r)
+		}
+	}()
+	return run()
+}
+
+// register persists the timers not yet registered to the database. The
+// next run of a new timer is the next tick after now.
+func (s *Scheduler) register(conn skydb.Conn, now time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for name, e := range s.timers {
+		if e.registered {
+			continue
+		}
+		if err := conn.RegisterTimer(&skydb.Timer{
+			Name:      name,
+			Spec:      e.spec,
+			CatchUp:   e.catchUp,
+			NextRunAt: e.schedule.Next(now),
+		}); err != nil {
+			return err
+		}
+		e.registered = true
+	}
+	return nil
+}
+
+func (s *Scheduler) entry(name string) *entry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.timers[name]
+}
+
+func (s *Scheduler) names() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	names := []string{}
+	for name := range s.timers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func withoutName(names []string, name string) []string {
+	rest := []string{}
+	for _, n := range names {
+		if n != name {
+			rest = append(rest, n)
+		}
+	}
+	return rest
+}
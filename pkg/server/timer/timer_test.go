@@ -0,0 +1,235 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func TestScheduler(t *testing.T) {
+	Convey("Scheduler", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		scheduler := NewScheduler(func() (skydb.Conn, error) {
+			return conn, nil
+		})
+
+		runs := 0
+		var runErr error
+		run := func() error {
+			runs++
+			return runErr
+		}
+
+		Convey("rejects invalid spec", func() {
+			So(scheduler.AddTimer("cleanup", "invalid", false, run), ShouldNotBeNil)
+		})
+
+		Convey("rejects duplicated timer", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldNotBeNil)
+		})
+
+		Convey("registers timer with next tick", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(conn.TimerMap["cleanup"], ShouldResemble, skydb.Timer{
+				Name:      "cleanup",
+				Spec:      "0 0 * * * *",
+				NextRunAt: now.Add(time.Hour),
+			})
+		})
+
+		Convey("runs timer once per tick and records outcome", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+			scheduler.RunDue()
+
+			now = now.Add(time.Hour + time.Second)
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(runs, ShouldEqual, 1)
+
+			timer := conn.TimerMap["cleanup"]
+			So(timer.LastStatus, ShouldEqual, skydb.TimerSucceeded)
+			So(*timer.LastRunAt, ShouldResemble, now)
+			So(timer.LockedUntil, ShouldBeNil)
+			So(timer.NextRunAt, ShouldResemble, time.Date(2017, 1, 1, 2, 0, 0, 0, time.UTC))
+
+			n, err = scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(runs, ShouldEqual, 1)
+		})
+
+		Convey("does not run timer locked by another instance", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+			scheduler.RunDue()
+
+			now = now.Add(time.Hour)
+			lockedUntil := now.Add(time.Minute)
+			timer := conn.TimerMap["cleanup"]
+			timer.LockedUntil = &lockedUntil
+			conn.TimerMap["cleanup"] = timer
+
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+
+			_, err = scheduler.Run("cleanup")
+			So(err, ShouldEqual, skydb.ErrTimerLocked)
+		})
+
+		Convey("drops the run after the lease is lost", func() {
+			otherLockedUntil := now.Add(2 * time.Hour)
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, func() error {
+				// another instance claims the tick after the lease expired
+				timer := conn.TimerMap["cleanup"]
+				timer.LockedUntil = &otherLockedUntil
+				conn.TimerMap["cleanup"] = timer
+				return run()
+			}), ShouldBeNil)
+			scheduler.RunDue()
+
+			now = now.Add(time.Hour)
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(runs, ShouldEqual, 1)
+
+			timer := conn.TimerMap["cleanup"]
+			So(timer.LastRunAt, ShouldBeNil)
+			So(*timer.LockedUntil, ShouldResemble, otherLockedUntil)
+		})
+
+		Convey("runs each due timer once per poll", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+			So(scheduler.AddTimer("report", "0 0 * * * *", false, run), ShouldBeNil)
+			scheduler.RunDue()
+
+			now = now.Add(time.Hour)
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(runs, ShouldEqual, 2)
+		})
+
+		Convey("records failed run", func() {
+			runErr = errors.New("database unavailable")
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+			scheduler.RunDue()
+
+			now = now.Add(time.Hour)
+			_, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			timer := conn.TimerMap["cleanup"]
+			So(timer.LastStatus, ShouldEqual, skydb.TimerFailed)
+			So(timer.LastError, ShouldEqual, "database unavailable")
+		})
+
+		Convey("skips missed runs without catch-up", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+			scheduler.RunDue()
+
+			now = now.Add(5*time.Hour + 30*time.Minute)
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(runs, ShouldEqual, 0)
+			So(conn.TimerMap["cleanup"].NextRunAt, ShouldResemble, time.Date(2017, 1, 1, 6, 0, 0, 0, time.UTC))
+			So(conn.TimerMap["cleanup"].LockedUntil, ShouldBeNil)
+		})
+
+		Convey("runs missed runs once with catch-up", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", true, run), ShouldBeNil)
+			scheduler.RunDue()
+
+			now = now.Add(5*time.Hour + 30*time.Minute)
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(runs, ShouldEqual, 1)
+			So(conn.TimerMap["cleanup"].NextRunAt, ShouldResemble, time.Date(2017, 1, 1, 6, 0, 0, 0, time.UTC))
+		})
+
+		Convey("does not run removed timer", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+			scheduler.RunDue()
+			scheduler.RemoveTimer("cleanup")
+
+			now = now.Add(time.Hour + time.Second)
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(runs, ShouldEqual, 0)
+
+			_, err = scheduler.Run("cleanup")
+			So(err, ShouldEqual, ErrTimerNotRegistered)
+
+			So(scheduler.AddTimer("cleanup", "0 30 * * * *", false, run), ShouldBeNil)
+		})
+
+		Convey("runs timer manually without changing schedule", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+
+			timer, err := scheduler.Run("cleanup")
+			So(err, ShouldBeNil)
+			So(runs, ShouldEqual, 1)
+			So(timer.LastStatus, ShouldEqual, skydb.TimerSucceeded)
+			So(timer.NextRunAt, ShouldResemble, now.Add(time.Hour))
+			So(conn.TimerMap["cleanup"].LastStatus, ShouldEqual, skydb.TimerSucceeded)
+
+			_, err = scheduler.Run("unknown")
+			So(err, ShouldEqual, ErrTimerNotRegistered)
+		})
+
+		Convey("recovers panicking timer", func() {
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, func() error {
+				panic("boom")
+			}), ShouldBeNil)
+
+			timer, err := scheduler.Run("cleanup")
+			So(err, ShouldBeNil)
+			So(timer.LastStatus, ShouldEqual, skydb.TimerFailed)
+			So(timer.LastError, ShouldEqual, "timer: panicked: boom")
+		})
+
+		Convey("does not run timer until ready", func() {
+			scheduler.Ready = func() bool { return false }
+			So(scheduler.AddTimer("cleanup", "0 0 * * * *", false, run), ShouldBeNil)
+
+			n, err := scheduler.RunDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(conn.TimerMap, ShouldBeEmpty)
+		})
+	})
+}
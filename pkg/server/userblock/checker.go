@@ -0,0 +1,106 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package userblock checks whether a user is blocked by another user
+// outside of a request, such as when delivering pubsub messages.
+package userblock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+type blockList struct {
+	blocked  map[string]bool
+	loadedAt time.Time
+}
+
+// Checker checks whether a user is blocked by another user. The block
+// list of each blocker is loaded from the database and cached.
+type Checker struct {
+	ConnOpener func() (skydb.Conn, error)
+	CacheTTL   time.Duration
+
+	mutex sync.Mutex
+	lists map[string]blockList
+}
+
+// NewChecker creates a Checker.
+func NewChecker(connOpener func() (skydb.Conn, error), cacheTTL time.Duration) *Checker {
+	return &Checker{
+		ConnOpener: connOpener,
+		CacheTTL:   cacheTTL,
+		lists:      map[string]blockList{},
+	}
+}
+
+// IsBlocked returns true if the blocker has blocked the user.
+func (c *Checker) IsBlocked(blockerID string, blockedID string) (bool, error) {
+	if blockerID == "" || blockedID == "" || blockerID == blockedID {
+		return false, nil
+	}
+
+	blocked, err := c.load(blockerID)
+	if err != nil {
+		return false, err
+	}
+	return blocked[blockedID], nil
+}
+
+// Invalidate removes the cached block list of the blocker, so that
+// changes to the block list take effect immediately on this server
+// instance.
+func (c *Checker) Invalidate(blockerID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.lists, blockerID)
+}
+
+func (c *Checker) load(blockerID string) (map[string]bool, error) {
+	now := timeNow()
+	c.mutex.Lock()
+	if list, ok := c.lists[blockerID]; ok && now.Sub(list.loadedAt) < c.CacheTTL {
+		defer c.mutex.Unlock()
+		return list.blocked, nil
+	}
+	c.mutex.Unlock()
+
+	conn, err := c.ConnOpener()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	blocks, err := conn.QueryBlockedUsers(blockerID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := map[string]bool{}
+	for _, block := range blocks {
+		blocked[block.BlockedID] = true
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lists[blockerID] = blockList{
+		blocked:  blocked,
+		loadedAt: now,
+	}
+	return blocked, nil
+}
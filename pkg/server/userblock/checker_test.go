@@ -0,0 +1,82 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userblock
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func TestChecker(t *testing.T) {
+	Convey("Checker", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		defer func() {
+			timeNow = realTimeNow
+		}()
+
+		conn := skydbtest.NewMapConn()
+		So(conn.BlockUser(&skydb.UserBlock{BlockerID: "alice", BlockedID: "bob"}), ShouldBeNil)
+		opened := 0
+		checker := NewChecker(func() (skydb.Conn, error) {
+			opened++
+			return conn, nil
+		}, time.Minute)
+
+		Convey("checks blocked user", func() {
+			blocked, err := checker.IsBlocked("alice", "bob")
+			So(err, ShouldBeNil)
+			So(blocked, ShouldBeTrue)
+
+			blocked, err = checker.IsBlocked("bob", "alice")
+			So(err, ShouldBeNil)
+			So(blocked, ShouldBeFalse)
+		})
+
+		Convey("does not load block list for anonymous user", func() {
+			blocked, err := checker.IsBlocked("alice", "")
+			So(err, ShouldBeNil)
+			So(blocked, ShouldBeFalse)
+			So(opened, ShouldEqual, 0)
+		})
+
+		Convey("caches block list until invalidated", func() {
+			checker.IsBlocked("alice", "bob")
+			So(conn.UnblockUser("alice", "bob"), ShouldBeNil)
+
+			blocked, _ := checker.IsBlocked("alice", "bob")
+			So(blocked, ShouldBeTrue)
+			So(opened, ShouldEqual, 1)
+
+			checker.Invalidate("alice")
+			blocked, _ = checker.IsBlocked("alice", "bob")
+			So(blocked, ShouldBeFalse)
+			So(opened, ShouldEqual, 2)
+		})
+
+		Convey("reloads block list after cache expired", func() {
+			checker.IsBlocked("alice", "bob")
+			now = now.Add(time.Minute)
+			checker.IsBlocked("alice", "bob")
+			So(opened, ShouldEqual, 2)
+		})
+	})
+}
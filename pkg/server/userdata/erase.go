@@ -0,0 +1,254 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userdata
+
+import (
+	"sort"
+
+	"github.com/skygeario/skygear-server/pkg/server/asset"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+// erasePageSize is the number of records fetched at a time when erasing
+// records.
+const erasePageSize = 100
+
+// Erase erases the data of a user according to the plan.
+//
+// Public records owned by the user are deleted, anonymized or kept
+// according to the plan, and all records in the private database of the
+// user are deleted. Afterwards the user record and the auth info of the
+// user are removed, together with devices, subscriptions, roles,
+// relations and other data associated with the auth info.
+//
+// Files of the assets in deleted records and anonymized fields, and the
+// archives exported by the user, are deleted if the asset store supports
+// deleting files.
+//
+// Records are not changed in a transaction; if Erase returns an error,
+// calling it again resumes erasing the remaining data.
+func Erase(conn skydb.Conn, assetStore asset.Store, plan *Plan, userID string) error {
+	e := eraser{assetStore}
+
+	db := conn.PublicDB()
+	recordTypes, err := listRecordTypes(db)
+	if err != nil {
+		return err
+	}
+
+	for _, recordType := range recordTypes {
+		action := plan.RecordAction(recordType)
+		fields := plan.AnonymizeFields[recordType]
+		if err := e.eraseRecords(db, recordType, userID, action, fields); err != nil {
+			return err
+		}
+	}
+
+	privateDB := conn.PrivateDB(userID)
+	for _, recordType := range recordTypes {
+		if err := e.eraseRecords(privateDB, recordType, userID, ActionDelete, nil); err != nil {
+			return err
+		}
+	}
+
+	userRecord := skydb.Record{}
+	userRecordID := skydb.NewRecordID(db.UserRecordType(), userID)
+	if err := db.Get(userRecordID, &userRecord); err == nil {
+		if err := e.deleteRecord(db, &userRecord); err != nil {
+			return err
+		}
+	} else if err != skydb.ErrRecordNotFound {
+		return err
+	}
+
+	deleter, _ := assetStore.(asset.FileDeleter)
+	exports, err := conn.QueryUserDataExports(userID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if err := deleteExport(conn, deleter, export); err != nil {
+			return err
+		}
+	}
+
+	return conn.PurgeAuth(userID)
+}
+
+// listRecordTypes returns the sorted record types of the database, excluding
+// the user record type.
+func listRecordTypes(db skydb.Database) ([]string, error) {
+	schemas, err := db.GetRecordSchemas()
+	if err != nil {
+		return nil, err
+	}
+
+	recordTypes := []string{}
+	for recordType := range schemas {
+		if recordType == db.UserRecordType() {
+			continue
+		}
+		recordTypes = append(recordTypes, recordType)
+	}
+	sort.Strings(recordTypes)
+	return recordTypes, nil
+}
+
+// eraser erases records and the files of their assets.
+type eraser struct {
+	assetStore asset.Store
+}
+
+func (e eraser) eraseRecords(db skydb.Database, recordType string, userID string, action Action, fields []string) error {
+	if action == ActionKeep {
+		return nil
+	}
+
+	// Deleted records no longer match the query, so deleting always
+	// fetches the first page. Anonymized records are still owned by
+	// the user and are skipped by offset.
+	var offset uint64
+	for {
+		records, err := queryOwnedRecords(db, recordType, userID, offset)
+		if err != nil {
+			return err
+		}
+
+		for i := range records {
+			record := &records[i]
+			if action == ActionAnonymize {
+				err = e.anonymizeRecord(db, record, userID, fields)
+			} else {
+				err = e.deleteRecord(db, record)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(records) < erasePageSize {
+			return nil
+		}
+		if action == ActionAnonymize {
+			offset += uint64(len(records))
+		}
+	}
+}
+
+// deleteRecord deletes the record after the files of its assets, so
+// that the files are found again if deleting is resumed.
+func (e eraser) deleteRecord(db skydb.Database, record *skydb.Record) error {
+	if err := e.deleteAssetFiles(record, nil); err != nil {
+		return err
+	}
+	return db.Delete(record.ID)
+}
+
+func (e eraser) anonymizeRecord(db skydb.Database, record *skydb.Record, userID string, fields []string) error {
+	if err := e.deleteAssetFiles(record, fields); err != nil {
+		return err
+	}
+	anonymize(record, userID, fields)
+	return db.Save(record)
+}
+
+// deleteAssetFiles deletes the files of the assets in the fields of the
+// record, or in all fields if fields is nil.
+func (e eraser) deleteAssetFiles(record *skydb.Record, fields []string) error {
+	deleter, ok := e.assetStore.(asset.FileDeleter)
+	if !ok {
+		return nil
+	}
+
+	for field, value := range record.Data {
+		if fields != nil && !containsField(fields, field) {
+			continue
+		}
+		if a, ok := value.(*skydb.Asset); ok && a != nil {
+			if err := deleter.DeleteFile(a.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func anonymize(record *skydb.Record, userID string, fields []string) {
+	for _, field := range fields {
+		if _, ok := record.Data[field]; ok {
+			record.Data[field] = nil
+		}
+	}
+
+	if record.ACL == nil {
+		return
+	}
+	acl := skydb.RecordACL{}
+	for _, entry := range record.ACL {
+		if entry.UserID == userID {
+			continue
+		}
+		acl = append(acl, entry)
+	}
+	record.ACL = acl
+}
+
+func queryOwnedRecords(db skydb.Database, recordType string, userID string, offset uint64) ([]skydb.Record, error) {
+	limit := uint64(erasePageSize)
+	query := skydb.Query{
+		Type: recordType,
+		Predicate: skydb.Predicate{
+			Operator: skydb.Equal,
+			Children: []interface{}{
+				skydb.Expression{Type: skydb.KeyPath, Value: "_owner_id"},
+				skydb.Expression{Type: skydb.Literal, Value: userID},
+			},
+		},
+		Sorts: []skydb.Sort{
+			{
+				Expression: skydb.Expression{Type: skydb.KeyPath, Value: "_id"},
+				Order:      skydb.Ascending,
+			},
+		},
+		Limit:  &limit,
+		Offset: offset,
+	}
+
+	results, err := db.Query(&query, &skydb.AccessControlOptions{
+		BypassAccessControl: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	records := []skydb.Record{}
+	for results.Scan() {
+		records = append(records, results.Record())
+	}
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userdata
+
+import (
+	"sort"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/asset"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+// ownerQueryDB is a MapDB supporting the owner queries issued by Erase
+// and Export.
+type ownerQueryDB struct {
+	*skydbtest.MapDB
+}
+
+func newOwnerQueryDB() *ownerQueryDB {
+	db := &ownerQueryDB{skydbtest.NewMapDB()}
+	db.RecordSchemaMap["note"] = skydb.RecordSchema{}
+	db.RecordSchemaMap["comment"] = skydb.RecordSchema{}
+	db.RecordSchemaMap["user"] = skydb.RecordSchema{}
+	return db
+}
+
+func (db *ownerQueryDB) Query(query *skydb.Query, accessControlOptions *skydb.AccessControlOptions) (*skydb.Rows, error) {
+	ownerID := query.Predicate.Children[1].(skydb.Expression).Value.(string)
+	records := []skydb.Record{}
+	for _, record := range db.RecordMap {
+		if record.ID.Type == query.Type && record.OwnerID == ownerID {
+			records = append(records, record)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID.Key < records[j].ID.Key
+	})
+
+	if query.Offset >= uint64(len(records)) {
+		records = []skydb.Record{}
+	} else {
+		records = records[query.Offset:]
+	}
+	if query.Limit != nil && *query.Limit < uint64(len(records)) {
+		records = records[:*query.Limit]
+	}
+	return skydb.NewRows(skydb.NewMemoryRows(records)), nil
+}
+
+func (db *ownerQueryDB) add(record skydb.Record) {
+	db.RecordMap[record.ID.String()] = record
+}
+
+type userdataConn struct {
+	*skydbtest.MapConn
+	privateDB *ownerQueryDB
+	devices   []skydb.Device
+	relations map[string][]skydb.AuthInfo
+}
+
+func newUserdataConn() *userdataConn {
+	conn := &userdataConn{
+		MapConn:   skydbtest.NewMapConn(),
+		privateDB: newOwnerQueryDB(),
+		relations: map[string][]skydb.AuthInfo{},
+	}
+	conn.InternalPublicDB = newOwnerQueryDB()
+	return conn
+}
+
+func (conn *userdataConn) publicDB() *ownerQueryDB {
+	return conn.InternalPublicDB.(*ownerQueryDB)
+}
+
+func (conn *userdataConn) PrivateDB(userKey string) skydb.Database {
+	return conn.privateDB
+}
+
+func (conn *userdataConn) QueryDevicesByUser(user string) ([]skydb.Device, error) {
+	return conn.devices, nil
+}
+
+func (conn *userdataConn) QueryRelation(user string, name string, direction string, config skydb.QueryConfig) []skydb.AuthInfo {
+	return conn.relations[name+":"+direction]
+}
+
+// deleteFileStore is an asset store recording the deleted files.
+type deleteFileStore struct {
+	asset.Store
+	deletedFiles []string
+}
+
+func (store *deleteFileStore) DeleteFile(name string) error {
+	store.deletedFiles = append(store.deletedFiles, name)
+	return nil
+}
+
+func TestErase(t *testing.T) {
+	Convey("Erase", t, func() {
+		assetStore := &deleteFileStore{}
+		conn := newUserdataConn()
+		conn.UserMap["alice"] = skydb.AuthInfo{ID: "alice"}
+		conn.UserMap["bob"] = skydb.AuthInfo{ID: "bob"}
+		db := conn.publicDB()
+		db.add(skydb.Record{
+			ID:      skydb.NewRecordID("user", "alice"),
+			OwnerID: "alice",
+			Data: skydb.Data{
+				"avatar": &skydb.Asset{Name: "avatar.png"},
+			},
+		})
+		db.add(skydb.Record{
+			ID:      skydb.NewRecordID("note", "note1"),
+			OwnerID: "alice",
+			Data: skydb.Data{
+				"attachment": &skydb.Asset{Name: "note1.pdf"},
+			},
+		})
+		db.add(skydb.Record{
+			ID:      skydb.NewRecordID("note", "note2"),
+			OwnerID: "bob",
+		})
+		db.add(skydb.Record{
+			ID:      skydb.NewRecordID("comment", "comment1"),
+			OwnerID: "alice",
+			ACL: skydb.RecordACL{
+				skydb.NewRecordACLEntryDirect("alice", skydb.WriteLevel),
+				skydb.NewRecordACLEntryPublic(skydb.ReadLevel),
+			},
+			Data: skydb.Data{
+				"content":      "hello",
+				"author_name":  "Alice",
+				"author_photo": &skydb.Asset{Name: "photo.png"},
+				"image":        &skydb.Asset{Name: "comment1.png"},
+			},
+		})
+		conn.privateDB.add(skydb.Record{
+			ID:      skydb.NewRecordID("note", "secret"),
+			OwnerID: "alice",
+		})
+		conn.UserDataExportMap["alice-export.zip"] = skydb.UserDataExport{
+			AssetName: "alice-export.zip",
+			UserID:    "alice",
+		}
+		conn.UserDataExportMap["bob-export.zip"] = skydb.UserDataExport{
+			AssetName: "bob-export.zip",
+			UserID:    "bob",
+		}
+
+		Convey("deletes records, user record and auth info by default", func() {
+			plan, err := NewPlan("", nil, nil)
+			So(err, ShouldBeNil)
+			So(Erase(conn, assetStore, plan, "alice"), ShouldBeNil)
+
+			So(db.RecordMap, ShouldContainKey, "note/note2")
+			So(db.RecordMap, ShouldNotContainKey, "note/note1")
+			So(db.RecordMap, ShouldNotContainKey, "comment/comment1")
+			So(db.RecordMap, ShouldNotContainKey, "user/alice")
+			So(conn.privateDB.RecordMap, ShouldBeEmpty)
+			So(conn.UserMap, ShouldNotContainKey, "alice")
+			So(conn.UserMap, ShouldContainKey, "bob")
+			So(assetStore.deletedFiles, ShouldHaveLength, 5)
+			So(assetStore.deletedFiles, ShouldContain, "avatar.png")
+			So(assetStore.deletedFiles, ShouldContain, "note1.pdf")
+			So(assetStore.deletedFiles, ShouldContain, "photo.png")
+			So(assetStore.deletedFiles, ShouldContain, "comment1.png")
+			So(assetStore.deletedFiles, ShouldContain, "alice-export.zip")
+			So(conn.UserDataExportMap, ShouldNotContainKey, "alice-export.zip")
+			So(conn.UserDataExportMap, ShouldContainKey, "bob-export.zip")
+		})
+
+		Convey("anonymizes and keeps records according to the plan", func() {
+			plan, err := NewPlan("keep", map[string]string{
+				"comment": "anonymize",
+			}, map[string][]string{
+				"comment": []string{"author_name", "author_photo"},
+			})
+			So(err, ShouldBeNil)
+			So(Erase(conn, assetStore, plan, "alice"), ShouldBeNil)
+
+			So(db.RecordMap, ShouldContainKey, "note/note1")
+			comment := db.RecordMap["comment/comment1"]
+			So(comment.Data, ShouldResemble, skydb.Data{
+				"content":      "hello",
+				"author_name":  nil,
+				"author_photo": nil,
+				"image":        &skydb.Asset{Name: "comment1.png"},
+			})
+			So(comment.ACL, ShouldResemble, skydb.RecordACL{
+				skydb.NewRecordACLEntryPublic(skydb.ReadLevel),
+			})
+			So(conn.privateDB.RecordMap, ShouldBeEmpty)
+			So(conn.UserMap, ShouldNotContainKey, "alice")
+			So(assetStore.deletedFiles, ShouldResemble, []string{"photo.png", "avatar.png", "alice-export.zip"})
+		})
+
+		Convey("returns error if the user does not exist", func() {
+			plan, _ := NewPlan("", nil, nil)
+			So(Erase(conn, assetStore, plan, "carol"), ShouldEqual, skydb.ErrUserNotFound)
+		})
+	})
+}
@@ -0,0 +1,231 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userdata
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/asset"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skyconv"
+)
+
+// ExportExpiry is the duration an exported archive is kept in the asset
+// store before it is deleted by ExpireExports.
+const ExportExpiry = 24 * time.Hour
+
+type exportedDevice struct {
+	ID               string    `json:"id"`
+	Type             string    `json:"type"`
+	Topic            string    `json:"topic,omitempty"`
+	LastRegisteredAt time.Time `json:"last_registered_at"`
+}
+
+type exportedRelation struct {
+	Outward []string `json:"outward"`
+	Inward  []string `json:"inward"`
+}
+
+// Export writes a zip archive of the data of a user to w. The archive
+// contains the following JSON files:
+//
+//	auth.json            auth info of the user, without the password
+//	user.json            user record
+//	records/<type>.json  public records owned by the user
+//	private/<type>.json  records in the private database of the user
+//	devices.json         devices registered by the user
+//	groups.json          groups the user is a member of
+//	relations.json       IDs of users related to the user, by relation
+//	blocks.json          users blocked by the user
+func Export(conn skydb.Conn, userID string, w io.Writer) error {
+	authinfo := skydb.AuthInfo{}
+	if err := conn.GetAuth(userID, &authinfo); err != nil {
+		return err
+	}
+	authinfo.HashedPassword = nil
+
+	zw := zip.NewWriter(w)
+	if err := writeJSON(zw, "auth.json", authinfo); err != nil {
+		return err
+	}
+
+	db := conn.PublicDB()
+	user := skydb.Record{}
+	err := db.Get(skydb.NewRecordID(db.UserRecordType(), userID), &user)
+	if err == nil {
+		if err := writeJSON(zw, "user.json", (*skyconv.JSONRecord)(&user)); err != nil {
+			return err
+		}
+	} else if err != skydb.ErrRecordNotFound {
+		return err
+	}
+
+	recordTypes, err := listRecordTypes(db)
+	if err != nil {
+		return err
+	}
+	if err := exportRecords(zw, "records/", db, recordTypes, userID); err != nil {
+		return err
+	}
+	if err := exportRecords(zw, "private/", conn.PrivateDB(userID), recordTypes, userID); err != nil {
+		return err
+	}
+
+	devices, err := conn.QueryDevicesByUser(userID)
+	if err != nil {
+		return err
+	}
+	exportedDevices := make([]exportedDevice, len(devices))
+	for i, device := range devices {
+		exportedDevices[i] = exportedDevice{
+			ID:               device.ID,
+			Type:             device.Type,
+			Topic:            device.Topic,
+			LastRegisteredAt: device.LastRegisteredAt,
+		}
+	}
+	if err := writeJSON(zw, "devices.json", exportedDevices); err != nil {
+		return err
+	}
+
+	groups, err := conn.QueryGroupsByMember(userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "groups.json", groups); err != nil {
+		return err
+	}
+
+	relations, err := exportRelations(conn, userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "relations.json", relations); err != nil {
+		return err
+	}
+
+	blocks, err := conn.QueryBlockedUsers(userID)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(zw, "blocks.json", blocks); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// ExpireExports deletes the files and the records of the exported
+// archives expired at now, and returns the number of archives deleted.
+func ExpireExports(conn skydb.Conn, deleter asset.FileDeleter, now time.Time) (int, error) {
+	count := 0
+	for {
+		exports, err := conn.QueryExpiredUserDataExports(now, erasePageSize)
+		if err != nil {
+			return count, err
+		}
+		for _, export := range exports {
+			if err := deleteExport(conn, deleter, export); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(exports) < erasePageSize {
+			return count, nil
+		}
+	}
+}
+
+// deleteExport deletes the file of the exported archive, if deleter is
+// not nil, before deleting its record, so that a failed deletion is
+// retried.
+func deleteExport(conn skydb.Conn, deleter asset.FileDeleter, export skydb.UserDataExport) error {
+	if deleter != nil {
+		if err := deleter.DeleteFile(export.AssetName); err != nil {
+			return err
+		}
+	}
+	return conn.DeleteUserDataExport(export.AssetName)
+}
+
+func exportRecords(zw *zip.Writer, prefix string, db skydb.Database, recordTypes []string, userID string) error {
+	for _, recordType := range recordTypes {
+		exported := []*skyconv.JSONRecord{}
+		var offset uint64
+		for {
+			records, err := queryOwnedRecords(db, recordType, userID, offset)
+			if err != nil {
+				return err
+			}
+			for i := range records {
+				exported = append(exported, (*skyconv.JSONRecord)(&records[i]))
+			}
+			if len(records) < erasePageSize {
+				break
+			}
+			offset += uint64(len(records))
+		}
+
+		if len(exported) == 0 {
+			continue
+		}
+		if err := writeJSON(zw, prefix+recordType+".json", exported); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportRelations(conn skydb.Conn, userID string) (map[string]exportedRelation, error) {
+	names := []string{"_friend", "_follow"}
+	relationTypes, err := conn.GetRelationTypes()
+	if err != nil {
+		return nil, err
+	}
+	for _, relationType := range relationTypes {
+		names = append(names, relationType.Name)
+	}
+
+	relations := map[string]exportedRelation{}
+	for _, name := range names {
+		relations[name] = exportedRelation{
+			Outward: relatedUserIDs(conn, userID, name, "outward"),
+			Inward:  relatedUserIDs(conn, userID, name, "inward"),
+		}
+	}
+	return relations, nil
+}
+
+func relatedUserIDs(conn skydb.Conn, userID string, name string, direction string) []string {
+	authinfos := conn.QueryRelation(userID, name, direction, skydb.QueryConfig{})
+	ids := make([]string, len(authinfos))
+	for i, authinfo := range authinfos {
+		ids[i] = authinfo.ID
+	}
+	return ids
+}
+
+func writeJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
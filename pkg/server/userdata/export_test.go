@@ -0,0 +1,140 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userdata
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+func readZip(data []byte) map[string]interface{} {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	So(err, ShouldBeNil)
+
+	files := map[string]interface{}{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		So(err, ShouldBeNil)
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		So(err, ShouldBeNil)
+
+		var v interface{}
+		So(json.Unmarshal(content, &v), ShouldBeNil)
+		files[f.Name] = v
+	}
+	return files
+}
+
+func TestExport(t *testing.T) {
+	Convey("Export", t, func() {
+		conn := newUserdataConn()
+		conn.UserMap["alice"] = skydb.AuthInfo{
+			ID:             "alice",
+			HashedPassword: []byte("secret"),
+		}
+		db := conn.publicDB()
+		db.add(skydb.Record{
+			ID:      skydb.NewRecordID("user", "alice"),
+			OwnerID: "alice",
+			Data:    skydb.Data{"username": "alice"},
+		})
+		db.add(skydb.Record{
+			ID:      skydb.NewRecordID("note", "note1"),
+			OwnerID: "alice",
+			Data:    skydb.Data{"content": "hello"},
+		})
+		db.add(skydb.Record{
+			ID:      skydb.NewRecordID("note", "note2"),
+			OwnerID: "bob",
+		})
+		conn.privateDB.add(skydb.Record{
+			ID:      skydb.NewRecordID("note", "secret"),
+			OwnerID: "alice",
+		})
+		conn.devices = []skydb.Device{
+			{ID: "device1", Type: "ios", Token: "token"},
+		}
+		conn.relations["_follow:outward"] = []skydb.AuthInfo{{ID: "bob"}}
+
+		Convey("writes an archive of user data", func() {
+			buf := bytes.Buffer{}
+			So(Export(conn, "alice", &buf), ShouldBeNil)
+
+			files := readZip(buf.Bytes())
+			So(files, ShouldContainKey, "auth.json")
+			So(files["auth.json"], ShouldNotContainKey, "password")
+			So(files["user.json"], ShouldContainKey, "username")
+
+			notes := files["records/note.json"].([]interface{})
+			So(notes, ShouldHaveLength, 1)
+			So(notes[0].(map[string]interface{})["_id"], ShouldEqual, "note/note1")
+			So(files["private/note.json"], ShouldHaveLength, 1)
+			So(files, ShouldNotContainKey, "records/comment.json")
+
+			devices := files["devices.json"].([]interface{})
+			So(devices, ShouldHaveLength, 1)
+			So(devices[0], ShouldNotContainKey, "token")
+
+			relations := files["relations.json"].(map[string]interface{})
+			So(relations["_follow"], ShouldResemble, map[string]interface{}{
+				"outward": []interface{}{"bob"},
+				"inward":  []interface{}{},
+			})
+			So(files, ShouldContainKey, "groups.json")
+			So(files, ShouldContainKey, "blocks.json")
+		})
+
+		Convey("returns error if the user does not exist", func() {
+			buf := bytes.Buffer{}
+			So(Export(conn, "carol", &buf), ShouldEqual, skydb.ErrUserNotFound)
+		})
+	})
+}
+
+func TestExpireExports(t *testing.T) {
+	Convey("ExpireExports", t, func() {
+		now := time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC)
+		assetStore := &deleteFileStore{}
+		conn := newUserdataConn()
+		conn.UserDataExportMap["expired-export.zip"] = skydb.UserDataExport{
+			AssetName: "expired-export.zip",
+			UserID:    "alice",
+			ExpiredAt: now.Add(-time.Hour),
+		}
+		conn.UserDataExportMap["fresh-export.zip"] = skydb.UserDataExport{
+			AssetName: "fresh-export.zip",
+			UserID:    "alice",
+			ExpiredAt: now.Add(time.Hour),
+		}
+
+		Convey("deletes files and records of expired exports", func() {
+			count, err := ExpireExports(conn, assetStore, now)
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+			So(assetStore.deletedFiles, ShouldResemble, []string{"expired-export.zip"})
+			So(conn.UserDataExportMap, ShouldNotContainKey, "expired-export.zip")
+			So(conn.UserDataExportMap, ShouldContainKey, "fresh-export.zip")
+		})
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package userdata erases and exports the data of a user, such as when
+// a user requests deletion of their account.
+package userdata
+
+import (
+	"fmt"
+)
+
+// Action is what to do with the records owned by a user when the user
+// is erased.
+type Action string
+
+const (
+	// ActionDelete deletes the records.
+	ActionDelete Action = "delete"
+	// ActionAnonymize keeps the records, but clears the configured fields
+	// and removes ACL entries naming the user.
+	ActionAnonymize Action = "anonymize"
+	// ActionKeep keeps the records untouched.
+	ActionKeep Action = "keep"
+)
+
+// ParseAction parses an erasure action.
+func ParseAction(s string) (Action, error) {
+	switch action := Action(s); action {
+	case ActionDelete, ActionAnonymize, ActionKeep:
+		return action, nil
+	}
+	return "", fmt.Errorf("userdata: unknown erasure action %q", s)
+}
+
+// Plan describes how the data of a user is erased.
+type Plan struct {
+	// DefaultAction applies to record types not in RecordActions.
+	DefaultAction Action
+	// RecordActions maps a record type to the action applied to the
+	// records of that type.
+	RecordActions map[string]Action
+	// AnonymizeFields maps a record type to the fields cleared when the
+	// records of that type are anonymized.
+	AnonymizeFields map[string][]string
+}
+
+// NewPlan creates a Plan from its string representation in the
+// configuration.
+func NewPlan(defaultAction string, recordActions map[string]string, anonymizeFields map[string][]string) (*Plan, error) {
+	plan := &Plan{
+		DefaultAction:   ActionDelete,
+		RecordActions:   map[string]Action{},
+		AnonymizeFields: map[string][]string{},
+	}
+
+	if defaultAction != "" {
+		action, err := ParseAction(defaultAction)
+		if err != nil {
+			return nil, err
+		}
+		plan.DefaultAction = action
+	}
+
+	for recordType, s := range recordActions {
+		action, err := ParseAction(s)
+		if err != nil {
+			return nil, err
+		}
+		plan.RecordActions[recordType] = action
+	}
+
+	for recordType, fields := range anonymizeFields {
+		plan.AnonymizeFields[recordType] = fields
+	}
+
+	return plan, nil
+}
+
+// RecordAction returns the action applied to records of the record type.
+func (p *Plan) RecordAction(recordType string) Action {
+	if action, ok := p.RecordActions[recordType]; ok {
+		return action
+	}
+	if p.DefaultAction == "" {
+		return ActionDelete
+	}
+	return p.DefaultAction
+}
@@ -0,0 +1,50 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package userdata
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPlan(t *testing.T) {
+	Convey("Plan", t, func() {
+		Convey("defaults to delete", func() {
+			plan, err := NewPlan("", nil, nil)
+			So(err, ShouldBeNil)
+			So(plan.RecordAction("note"), ShouldEqual, ActionDelete)
+		})
+
+		Convey("returns action of record type", func() {
+			plan, err := NewPlan("keep", map[string]string{
+				"comment": "anonymize",
+			}, nil)
+			So(err, ShouldBeNil)
+			So(plan.RecordAction("note"), ShouldEqual, ActionKeep)
+			So(plan.RecordAction("comment"), ShouldEqual, ActionAnonymize)
+		})
+
+		Convey("rejects unknown action", func() {
+			_, err := NewPlan("destroy", nil, nil)
+			So(err, ShouldNotBeNil)
+
+			_, err = NewPlan("delete", map[string]string{
+				"comment": "destroy",
+			}, nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
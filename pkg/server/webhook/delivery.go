@@ -0,0 +1,210 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/poller"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+)
+
+// The headers of a delivery request.
+const (
+	EventHeader     = "X-Skygear-Webhook-Event"
+	DeliveryHeader  = "X-Skygear-Webhook-Delivery"
+	SignatureHeader = "X-Skygear-Webhook-Signature"
+)
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and the body,
+// joined by a dot, with the secret of the webhook.
+//
+// The signature header of a delivery is "t=<timestamp>,v1=<signature>".
+// Receivers should compute the signature with the timestamp in the header
+// and reject requests with a stale timestamp to prevent replay attacks.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret returns a new random secret for signing deliveries.
+func GenerateSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Sender sends signed deliveries to webhooks.
+type Sender struct {
+	Client *http.Client
+}
+
+// NewSender creates a Sender with the timeout of each request.
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Send posts the payload of the delivery to the webhook, returning the
+// response status code. An error is returned if the request fails or the
+// status code is not 2xx.
+func (s *Sender) Send(webhook *skydb.Webhook, delivery *skydb.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequest("POST", webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(webhook.Secret, timestamp, delivery.Payload)))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook: unexpected status code %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Start polls for due deliveries at the interval until Stop is called.
+func (d *Dispatcher) Start(interval time.Duration) {
+	d.poller.Start("webhook", interval, func() error {
+		_, err := d.DeliverDue()
+		return err
+	})
+}
+
+// Stop stops polling for due deliveries.
+func (d *Dispatcher) Stop() {
+	d.poller.Stop()
+}
+
+// DeliverDue claims and attempts the pending deliveries that are due, at
+// most BatchSize of them, returning the number of deliveries attempted.
+//
+// Each delivery is claimed for Lease right before it is attempted, so
+// that server instances polling concurrently do not attempt the same
+// delivery. The result of an attempt that outlives the lease is dropped,
+// since the delivery may have been claimed again by another instance.
+func (d *Dispatcher) DeliverDue() (int, error) {
+	conn, err := d.ConnOpener()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	webhooks := map[string]*skydb.Webhook{}
+	return poller.Drain(d.BatchSize, func() (bool, error) {
+		now := timeNow()
+		delivery := skydb.WebhookDelivery{}
+		if err := conn.ClaimWebhookDelivery(now, now.Add(d.Lease), &delivery); err == skydb.ErrWebhookDeliveryNotFound {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		leaseUntil := delivery.NextAttemptAt
+
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook = &skydb.Webhook{}
+			if err := conn.GetWebhook(delivery.WebhookID, webhook); err != nil {
+				// the webhook is deleted together with its deliveries
+				if err == skydb.ErrWebhookNotFound {
+					return true, nil
+				}
+				return false, err
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		d.attempt(webhook, &delivery)
+		err := conn.UpdateClaimedWebhookDelivery(&delivery, leaseUntil)
+		switch err {
+		case nil, skydb.ErrWebhookDeliveryNotFound:
+		case skydb.ErrLeaseLost:
+			log.WithField("delivery", delivery.ID).Warnln("Dropped the attempt of webhook delivery after its lease expired")
+		default:
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+func (d *Dispatcher) attempt(webhook *skydb.Webhook, delivery *skydb.WebhookDelivery) {
+	now := timeNow()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	var err error
+	if webhook.Disabled {
+		delivery.ResponseStatus = 0
+		err = fmt.Errorf("webhook: webhook %s is disabled", webhook.ID)
+	} else {
+		delivery.ResponseStatus, err = d.Sender.Send(webhook, delivery, now)
+	}
+
+	if err == nil {
+		delivery.Status = skydb.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		return
+	}
+
+	log.WithField("delivery", delivery.ID).WithError(err).Warnln("Failed to deliver webhook")
+	delivery.LastError = err.Error()
+	if webhook.Disabled || delivery.Attempts >= d.MaxAttempts {
+		delivery.Status = skydb.WebhookDeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = now.Add(d.retryInterval(delivery.Attempts))
+}
+
+// retryInterval returns the interval before the next attempt after the
+// number of attempts made.
+func (d *Dispatcher) retryInterval(attempts int) time.Duration {
+	return poller.Backoff(d.RetryInterval, d.MaxRetryInterval, attempts)
+}
+
+// Replay resets the delivery to be attempted again at now, regardless of
+// its status. The payload, including its ID, is unchanged.
+func Replay(delivery *skydb.WebhookDelivery, now time.Time) {
+	delivery.Status = skydb.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.LastError = ""
+}
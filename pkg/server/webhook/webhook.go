@@ -0,0 +1,275 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook delivers events of records and users to the webhooks
+// registered in the database.
+//
+// Events are persisted as deliveries in the database before they are
+// attempted, so that they are not lost when the receiver is unavailable.
+// Deliveries are created in the transaction of the request, so that no
+// event is delivered for a change that is rolled back. Failed deliveries
+// are retried with exponential backoff.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/skygeario/skygear-server/pkg/server/logging"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	"github.com/skygeario/skygear-server/pkg/server/plugin/request"
+	"github.com/skygeario/skygear-server/pkg/server/poller"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skyerr"
+	"github.com/skygeario/skygear-server/pkg/server/uuid"
+)
+
+var log = logging.LoggerEntry("webhook")
+
+var timeNow = func() time.Time { return time.Now().UTC() }
+
+var uuidNew = uuid.New
+
+// RecordEvents are the events of records that webhooks can receive.
+var RecordEvents = []hook.Kind{
+	hook.AfterSave,
+	hook.AfterDelete,
+}
+
+// AuthEvents are the events of users that webhooks can receive.
+var AuthEvents = []hook.Kind{
+	hook.AfterSignup,
+	hook.AfterLogin,
+	hook.AfterLogout,
+	hook.AfterPasswordChange,
+	hook.AfterProviderLink,
+	hook.AfterProviderUnlink,
+	hook.AfterUserDisable,
+}
+
+// IsEvent returns true if webhooks can receive the event. The event "*"
+// stands for all events.
+func IsEvent(event string) bool {
+	if event == "*" {
+		return true
+	}
+	for _, kind := range RecordEvents {
+		if event == string(kind) {
+			return true
+		}
+	}
+	for _, kind := range AuthEvents {
+		if event == string(kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the body of a delivery. Data is the same as the parameters
+// of the hook of the event passed to plugins.
+type Payload struct {
+	ID         string      `json:"id"`
+	Event      string      `json:"event"`
+	RecordType string      `json:"record_type,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Dispatcher enqueues deliveries of events for the matching webhooks and
+// attempts the deliveries in the background.
+//
+// Webhooks are cached for CacheTTL to avoid a database query for every
+// event, so changes to webhooks made by another server instance take up
+// to CacheTTL to take effect.
+type Dispatcher struct {
+	ConnOpener func() (skydb.Conn, error)
+	CacheTTL   time.Duration
+	Sender     *Sender
+
+	// MaxAttempts is the number of attempts of a delivery before it is
+	// marked as failed.
+	MaxAttempts int
+
+	// RetryInterval is the interval before the first retry, which is
+	// doubled for every further retry up to MaxRetryInterval.
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+
+	// Lease is how long a claimed delivery is hidden from other server
+	// instances while it is being attempted. It must be longer than
+	// the timeout of the Sender.
+	Lease time.Duration
+
+	// BatchSize is the maximum number of deliveries attempted in a poll.
+	BatchSize uint64
+
+	mutex    sync.Mutex
+	webhooks []skydb.Webhook
+	cachedAt time.Time
+	poller   poller.Poller
+}
+
+// NewDispatcher creates a Dispatcher with default retry settings.
+func NewDispatcher(connOpener func() (skydb.Conn, error), cacheTTL time.Duration) *Dispatcher {
+	return &Dispatcher{
+		ConnOpener:       connOpener,
+		CacheTTL:         cacheTTL,
+		Sender:           NewSender(10 * time.Second),
+		MaxAttempts:      10,
+		RetryInterval:    30 * time.Second,
+		MaxRetryInterval: 6 * time.Hour,
+		Lease:            time.Minute,
+		BatchSize:        20,
+	}
+}
+
+// RegisterHooks registers hooks of the record and auth events to the
+// registry, which enqueue deliveries of the events.
+func (d *Dispatcher) RegisterHooks(registry *hook.Registry) {
+	for _, kind := range RecordEvents {
+		registry.Register(kind, hook.AnyRecordType, d.recordHook(kind))
+	}
+	for _, kind := range AuthEvents {
+		if err := registry.RegisterAuthHook(kind, d.authHook(kind)); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (d *Dispatcher) recordHook(kind hook.Kind) hook.Func {
+	return func(ctx context.Context, record *skydb.Record, originalRecord *skydb.Record) skyerr.Error {
+		data := request.NewHookRequest(ctx, string(kind), record, originalRecord, true).Param
+		if err := d.Enqueue(ctx, string(kind), record.ID.Type, data); err != nil {
+			log.WithError(err).Errorf("Failed to enqueue webhook deliveries of %s", kind)
+			return skyerr.MakeError(err)
+		}
+		return nil
+	}
+}
+
+func (d *Dispatcher) authHook(kind hook.Kind) hook.AuthFunc {
+	return func(ctx context.Context, authInfo *skydb.AuthInfo, user *skydb.Record) skyerr.Error {
+		data := request.NewAuthHookRequest(ctx, string(kind), authInfo, user).Param
+		if err := d.Enqueue(ctx, string(kind), "", data); err != nil {
+			log.WithError(err).Errorf("Failed to enqueue webhook deliveries of %s", kind)
+			return skyerr.MakeError(err)
+		}
+		return nil
+	}
+}
+
+// Enqueue creates a delivery of the event for each webhook matching the
+// event. recordType is empty if the event is not a record event.
+//
+// The deliveries are created with the database connection of the request
+// in ctx if there is one, so that they are created in the transaction of
+// the request and discarded if it is rolled back.
+func (d *Dispatcher) Enqueue(ctx context.Context, event string, recordType string, data interface{}) error {
+	webhooks, err := d.matchingWebhooks(event, recordType)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	conn, ok := ctx.Value(router.DBConnContextKey).(skydb.Conn)
+	if !ok {
+		conn, err = d.ConnOpener()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+	}
+
+	now := timeNow()
+	for _, webhook := range webhooks {
+		id := uuidNew()
+		payload, err := json.Marshal(Payload{
+			ID:         id,
+			Event:      event,
+			RecordType: recordType,
+			CreatedAt:  now,
+			Data:       data,
+		})
+		if err != nil {
+			return err
+		}
+
+		delivery := skydb.WebhookDelivery{
+			ID:            id,
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        skydb.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		if err := conn.CreateWebhookDelivery(&delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Invalidate removes the cached webhooks, so that changes to webhooks take
+// effect immediately on this server instance.
+func (d *Dispatcher) Invalidate() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.webhooks = nil
+}
+
+func (d *Dispatcher) matchingWebhooks(event string, recordType string) ([]skydb.Webhook, error) {
+	webhooks, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []skydb.Webhook{}
+	for _, webhook := range webhooks {
+		if webhook.Matches(event, recordType) {
+			matched = append(matched, webhook)
+		}
+	}
+	return matched, nil
+}
+
+func (d *Dispatcher) load() ([]skydb.Webhook, error) {
+	now := timeNow()
+	d.mutex.Lock()
+	if d.webhooks != nil && now.Sub(d.cachedAt) < d.CacheTTL {
+		defer d.mutex.Unlock()
+		return d.webhooks, nil
+	}
+	d.mutex.Unlock()
+
+	conn, err := d.ConnOpener()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	webhooks, err := conn.QueryWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	d.mutex.Lock()
+	d.webhooks = webhooks
+	d.cachedAt = now
+	d.mutex.Unlock()
+	return webhooks, nil
+}
@@ -0,0 +1,280 @@
+// Copyright 2015-present Oursky Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/skygeario/skygear-server/pkg/server/plugin/hook"
+	"github.com/skygeario/skygear-server/pkg/server/router"
+	"github.com/skygeario/skygear-server/pkg/server/skydb"
+	"github.com/skygeario/skygear-server/pkg/server/skydb/skydbtest"
+)
+
+func TestDispatcher(t *testing.T) {
+	Convey("Dispatcher", t, func() {
+		now := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+		realTimeNow := timeNow
+		timeNow = func() time.Time { return now }
+		realUUIDNew := uuidNew
+		count := 0
+		uuidNew = func() string {
+			count++
+			return fmt.Sprintf("delivery-%d", count)
+		}
+		defer func() {
+			timeNow = realTimeNow
+			uuidNew = realUUIDNew
+		}()
+
+		received := []*http.Request{}
+		bodies := [][]byte{}
+		statusCode := http.StatusOK
+		onReceive := func() {}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			onReceive()
+			body, _ := ioutil.ReadAll(r.Body)
+			received = append(received, r)
+			bodies = append(bodies, body)
+			w.WriteHeader(statusCode)
+		}))
+		defer server.Close()
+
+		conn := skydbtest.NewMapConn()
+		conn.CreateWebhook(&skydb.Webhook{
+			ID:         "note-hook",
+			URL:        server.URL,
+			Secret:     "secret",
+			RecordType: "note",
+			Events:     []string{"afterSave"},
+			CreatedAt:  now,
+		})
+		conn.CreateWebhook(&skydb.Webhook{
+			ID:        "auth-hook",
+			URL:       server.URL,
+			Secret:    "secret",
+			Events:    []string{"afterSignup"},
+			CreatedAt: now.Add(time.Second),
+		})
+
+		dispatcher := NewDispatcher(func() (skydb.Conn, error) {
+			return conn, nil
+		}, time.Minute)
+		dispatcher.MaxAttempts = 3
+
+		registry := hook.NewRegistry()
+		dispatcher.RegisterHooks(registry)
+		ctx := context.Background()
+
+		Convey("enqueues deliveries of matching webhooks", func() {
+			note := skydb.Record{ID: skydb.NewRecordID("note", "1")}
+			comment := skydb.Record{ID: skydb.NewRecordID("comment", "1")}
+			So(registry.ExecuteHooks(ctx, hook.AfterSave, &note, nil), ShouldBeNil)
+			So(registry.ExecuteHooks(ctx, hook.AfterSave, &comment, nil), ShouldBeNil)
+			So(registry.ExecuteHooks(ctx, hook.AfterDelete, &note, nil), ShouldBeNil)
+			So(registry.ExecuteAuthHooks(ctx, hook.AfterSignup, &skydb.AuthInfo{ID: "user-1"}, nil), ShouldBeNil)
+
+			So(conn.WebhookDeliveryMap, ShouldHaveLength, 2)
+			delivery := conn.WebhookDeliveryMap["delivery-1"]
+			So(delivery.WebhookID, ShouldEqual, "note-hook")
+			So(delivery.Event, ShouldEqual, "afterSave")
+			So(delivery.Status, ShouldEqual, skydb.WebhookDeliveryPending)
+			So(delivery.NextAttemptAt, ShouldResemble, now)
+
+			payload := map[string]interface{}{}
+			So(json.Unmarshal(delivery.Payload, &payload), ShouldBeNil)
+			So(payload["id"], ShouldEqual, "delivery-1")
+			So(payload["record_type"], ShouldEqual, "note")
+			So(payload["data"].(map[string]interface{})["record"].(map[string]interface{})["_id"], ShouldEqual, "note/1")
+
+			delivery = conn.WebhookDeliveryMap["delivery-2"]
+			So(delivery.WebhookID, ShouldEqual, "auth-hook")
+			So(delivery.Event, ShouldEqual, "afterSignup")
+		})
+
+		Convey("enqueues deliveries with the connection of the request", func() {
+			requestConn := skydbtest.NewMapConn()
+			requestConn.WebhookMap = conn.WebhookMap
+			ctx := context.WithValue(ctx, router.DBConnContextKey, skydb.Conn(requestConn))
+			note := skydb.Record{ID: skydb.NewRecordID("note", "1")}
+			So(registry.ExecuteHooks(ctx, hook.AfterSave, &note, nil), ShouldBeNil)
+
+			So(requestConn.WebhookDeliveryMap, ShouldHaveLength, 1)
+			So(conn.WebhookDeliveryMap, ShouldBeEmpty)
+		})
+
+		Convey("fails the hook if deliveries cannot be enqueued", func() {
+			dispatcher.ConnOpener = func() (skydb.Conn, error) {
+				return nil, errors.New("database is unavailable")
+			}
+			note := skydb.Record{ID: skydb.NewRecordID("note", "1")}
+			So(registry.ExecuteHooks(ctx, hook.AfterSave, &note, nil), ShouldNotBeNil)
+			So(registry.ExecuteAuthHooks(ctx, hook.AfterSignup, &skydb.AuthInfo{ID: "user-1"}, nil), ShouldNotBeNil)
+		})
+
+		Convey("delivers with signature", func() {
+			So(dispatcher.Enqueue(ctx, "afterSignup", "", map[string]interface{}{"hello": "world"}), ShouldBeNil)
+
+			n, err := dispatcher.DeliverDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(received, ShouldHaveLength, 1)
+
+			req := received[0]
+			So(req.Header.Get(EventHeader), ShouldEqual, "afterSignup")
+			So(req.Header.Get(DeliveryHeader), ShouldEqual, "delivery-1")
+			So(req.Header.Get(SignatureHeader), ShouldEqual,
+				fmt.Sprintf("t=%d,v1=%s", now.Unix(), Sign("secret", now.Unix(), bodies[0])))
+
+			delivery := conn.WebhookDeliveryMap["delivery-1"]
+			So(delivery.Status, ShouldEqual, skydb.WebhookDeliveryDelivered)
+			So(delivery.Attempts, ShouldEqual, 1)
+			So(delivery.ResponseStatus, ShouldEqual, http.StatusOK)
+
+			n, err = dispatcher.DeliverDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+		})
+
+		Convey("attempts at most BatchSize deliveries", func() {
+			dispatcher.BatchSize = 2
+			for i := 0; i < 3; i++ {
+				So(dispatcher.Enqueue(ctx, "afterSignup", "", nil), ShouldBeNil)
+			}
+
+			n, err := dispatcher.DeliverDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(received, ShouldHaveLength, 2)
+
+			n, err = dispatcher.DeliverDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(received, ShouldHaveLength, 3)
+		})
+
+		Convey("drops the attempt after the lease is lost", func() {
+			So(dispatcher.Enqueue(ctx, "afterSignup", "", nil), ShouldBeNil)
+			onReceive = func() {
+				// another instance claims the delivery after the lease expired
+				delivery := conn.WebhookDeliveryMap["delivery-1"]
+				delivery.NextAttemptAt = now.Add(2 * time.Minute)
+				conn.WebhookDeliveryMap["delivery-1"] = delivery
+			}
+
+			n, err := dispatcher.DeliverDue()
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(received, ShouldHaveLength, 1)
+
+			delivery := conn.WebhookDeliveryMap["delivery-1"]
+			So(delivery.Status, ShouldEqual, skydb.WebhookDeliveryPending)
+			So(delivery.Attempts, ShouldEqual, 0)
+			So(delivery.NextAttemptAt, ShouldResemble, now.Add(2*time.Minute))
+		})
+
+		Convey("retries with exponential backoff until failed", func() {
+			statusCode = http.StatusServiceUnavailable
+			So(dispatcher.Enqueue(ctx, "afterSignup", "", nil), ShouldBeNil)
+
+			dispatcher.DeliverDue()
+			delivery := conn.WebhookDeliveryMap["delivery-1"]
+			So(delivery.Status, ShouldEqual, skydb.WebhookDeliveryPending)
+			So(delivery.Attempts, ShouldEqual, 1)
+			So(delivery.ResponseStatus, ShouldEqual, http.StatusServiceUnavailable)
+			So(delivery.LastError, ShouldEqual, "webhook: unexpected status code 503")
+			So(delivery.NextAttemptAt, ShouldResemble, now.Add(30*time.Second))
+
+			now = now.Add(30 * time.Second)
+			dispatcher.DeliverDue()
+			delivery = conn.WebhookDeliveryMap["delivery-1"]
+			So(delivery.Attempts, ShouldEqual, 2)
+			So(delivery.NextAttemptAt, ShouldResemble, now.Add(time.Minute))
+
+			now = now.Add(time.Minute)
+			dispatcher.DeliverDue()
+			delivery = conn.WebhookDeliveryMap["delivery-1"]
+			So(delivery.Attempts, ShouldEqual, 3)
+			So(delivery.Status, ShouldEqual, skydb.WebhookDeliveryFailed)
+			So(received, ShouldHaveLength, 3)
+
+			Convey("and replays failed delivery", func() {
+				statusCode = http.StatusOK
+				Replay(&delivery, now)
+				conn.UpdateWebhookDelivery(&delivery)
+
+				n, err := dispatcher.DeliverDue()
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 1)
+				delivery = conn.WebhookDeliveryMap["delivery-1"]
+				So(delivery.Status, ShouldEqual, skydb.WebhookDeliveryDelivered)
+				So(delivery.Attempts, ShouldEqual, 1)
+				So(delivery.LastError, ShouldEqual, "")
+			})
+		})
+
+		Convey("caps retry interval", func() {
+			So(dispatcher.retryInterval(1), ShouldEqual, 30*time.Second)
+			So(dispatcher.retryInterval(4), ShouldEqual, 4*time.Minute)
+			So(dispatcher.retryInterval(20), ShouldEqual, 6*time.Hour)
+		})
+
+		Convey("fails delivery of disabled webhook", func() {
+			So(dispatcher.Enqueue(ctx, "afterSignup", "", nil), ShouldBeNil)
+			webhook := conn.WebhookMap["auth-hook"]
+			webhook.Disabled = true
+			conn.UpdateWebhook(&webhook)
+
+			dispatcher.DeliverDue()
+			delivery := conn.WebhookDeliveryMap["delivery-1"]
+			So(delivery.Status, ShouldEqual, skydb.WebhookDeliveryFailed)
+			So(received, ShouldBeEmpty)
+		})
+
+		Convey("caches webhooks until invalidated", func() {
+			So(dispatcher.Enqueue(ctx, "afterLogin", "", nil), ShouldBeNil)
+			webhook := conn.WebhookMap["auth-hook"]
+			webhook.Events = []string{"*"}
+			conn.UpdateWebhook(&webhook)
+
+			So(dispatcher.Enqueue(ctx, "afterLogin", "", nil), ShouldBeNil)
+			So(conn.WebhookDeliveryMap, ShouldBeEmpty)
+
+			dispatcher.Invalidate()
+			So(dispatcher.Enqueue(ctx, "afterLogin", "", nil), ShouldBeNil)
+			So(conn.WebhookDeliveryMap, ShouldHaveLength, 1)
+		})
+	})
+}
+
+func TestIsEvent(t *testing.T) {
+	Convey("IsEvent", t, func() {
+		So(IsEvent("*"), ShouldBeTrue)
+		So(IsEvent("afterSave"), ShouldBeTrue)
+		So(IsEvent("afterLogin"), ShouldBeTrue)
+		So(IsEvent("beforeSave"), ShouldBeFalse)
+		So(IsEvent("afterFetch"), ShouldBeFalse)
+	})
+}